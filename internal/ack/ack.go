@@ -0,0 +1,152 @@
+package ack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// findings are acknowledged with 'powerpipe finding ack <fingerprint> --until <date> --reason <reason>'
+// while an acknowledgement is active, runs report the alarm or error result of the finding as info, so that a known
+// issue is silenced (e.g. for on-call) - unlike a permanent change to the control, an acknowledgement always expires
+//
+// the acknowledgements are stored in a state file in the root of the workspace, so they are shared by every run of
+// the workspace and may be committed with it
+
+// FileName is the name of the acknowledgements file in the root of the workspace
+const FileName = ".finding_acks.json"
+
+// fingerprints are the first 32 hex characters of a sha256 hash (see controlexecute.FindingFingerprint)
+var fingerprintPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Acknowledgement is a temporary acknowledgement of a finding
+type Acknowledgement struct {
+	Fingerprint    string    `json:"fingerprint"`
+	Until          time.Time `json:"until"`
+	Reason         string    `json:"reason,omitempty"`
+	AcknowledgedBy string    `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt time.Time `json:"acknowledged_at"`
+}
+
+// Active returns whether the acknowledgement has not expired at the given time
+func (a *Acknowledgement) Active(at time.Time) bool {
+	return at.Before(a.Until)
+}
+
+// Acknowledgements are the acknowledgements of a workspace, keyed by fingerprint
+type Acknowledgements struct {
+	Findings map[string]*Acknowledgement `json:"findings"`
+	path     string
+}
+
+// Path returns the path of the acknowledgements file of the workspace
+func Path(workspacePath string) string {
+	return filepath.Join(workspacePath, FileName)
+}
+
+// Load loads the acknowledgements from the given path - if the file does not exist, there are no acknowledgements
+func Load(path string) (*Acknowledgements, error) {
+	res := &Acknowledgements{Findings: make(map[string]*Acknowledgement), path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return res, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, fmt.Errorf("failed to parse acknowledgements file '%s': %w", path, err)
+	}
+	if res.Findings == nil {
+		res.Findings = make(map[string]*Acknowledgement)
+	}
+	return res, nil
+}
+
+// Get returns the acknowledgement of the finding, if it is active at the given time
+func (a *Acknowledgements) Get(fingerprint string, at time.Time) (*Acknowledgement, bool) {
+	res, ok := a.Findings[fingerprint]
+	if !ok || !res.Active(at) {
+		return nil, false
+	}
+	return res, true
+}
+
+// Add acknowledges a finding, replacing any existing acknowledgement of it
+func (a *Acknowledgements) Add(ack *Acknowledgement) error {
+	if !fingerprintPattern.MatchString(ack.Fingerprint) {
+		return fmt.Errorf("invalid fingerprint '%s' - it must be the 32 character fingerprint of a control result", ack.Fingerprint)
+	}
+	if !ack.Active(ack.AcknowledgedAt) {
+		return fmt.Errorf("the acknowledgement of '%s' must expire in the future", ack.Fingerprint)
+	}
+	a.Findings[ack.Fingerprint] = ack
+	return nil
+}
+
+// Remove removes the acknowledgement of a finding, returning whether it was acknowledged
+func (a *Acknowledgements) Remove(fingerprint string) bool {
+	_, ok := a.Findings[fingerprint]
+	delete(a.Findings, fingerprint)
+	return ok
+}
+
+// List returns the acknowledgements which are active at the given time, in order of expiry
+func (a *Acknowledgements) List(at time.Time) []*Acknowledgement {
+	var res []*Acknowledgement
+	for _, ack := range a.Findings {
+		if ack.Active(at) {
+			res = append(res, ack)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].Until.Equal(res[j].Until) {
+			return res[i].Until.Before(res[j].Until)
+		}
+		return res[i].Fingerprint < res[j].Fingerprint
+	})
+	return res
+}
+
+// Save writes the acknowledgements which have not expired to the file they were loaded from
+func (a *Acknowledgements) Save() error {
+	now := time.Now()
+	for fingerprint, ack := range a.Findings {
+		if !ack.Active(now) {
+			delete(a.Findings, fingerprint)
+		}
+	}
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(a.path, data, 0644) //nolint:gosec // the file is part of the workspace
+}
+
+// ParseUntil parses the expiry of an acknowledgement, which is either:
+//   - a date (the acknowledgement expires at the start of the date, in the local timezone), e.g. 2025-01-01
+//   - a timestamp, e.g. 2025-01-01T09:00:00Z
+//   - a duration from now, in days or as a go duration, e.g. 7d or 12h
+func ParseUntil(until string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, until, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, until); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(until, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return now.AddDate(0, 0, n), nil
+		}
+	}
+	if d, err := time.ParseDuration(until); err == nil && d > 0 {
+		return now.Add(d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid expiry '%s' - it must be a date (2025-01-01), a timestamp (2025-01-01T09:00:00Z) or a duration (7d, 12h)", until)
+}
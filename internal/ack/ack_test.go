@@ -0,0 +1,91 @@
+package ack
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type parseUntilTest struct {
+	until    string
+	expected time.Time
+	err      bool
+}
+
+var testNow = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+var testCasesParseUntil = map[string]parseUntilTest{
+	"date": {
+		until:    "2025-01-01",
+		expected: time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local),
+	},
+	"timestamp": {
+		until:    "2025-01-01T09:00:00Z",
+		expected: time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC),
+	},
+	"days": {
+		until:    "7d",
+		expected: time.Date(2024, 6, 8, 12, 0, 0, 0, time.UTC),
+	},
+	"duration": {
+		until:    "12h",
+		expected: time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC),
+	},
+	"negative duration": {
+		until: "-12h",
+		err:   true,
+	},
+	"invalid": {
+		until: "next week",
+		err:   true,
+	},
+}
+
+func TestParseUntil(t *testing.T) {
+	for name, test := range testCasesParseUntil {
+		res, err := ParseUntil(test.until, testNow)
+		if test.err {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected an error, got %v", name, res)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		if !res.Equal(test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, res)
+		}
+	}
+}
+
+func TestAcknowledgements(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	acknowledgements, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	fingerprint := "63ac7e199dc796a67ba227e9cfa75ae7"
+	if err := acknowledgements.Add(&Acknowledgement{Fingerprint: fingerprint, Until: now.Add(time.Hour), AcknowledgedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+	if err := acknowledgements.Add(&Acknowledgement{Fingerprint: "a347e9341180f4f75fa0cde9697bc2d6", Until: now.Add(-time.Hour), AcknowledgedAt: now}); err == nil {
+		t.Errorf("Test: 'expired' FAILED : expected an error, got nil")
+	}
+	if err := acknowledgements.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.Get(fingerprint, now); !ok {
+		t.Errorf("Test: 'active' FAILED : expected %s to be acknowledged", fingerprint)
+	}
+	if _, ok := loaded.Get(fingerprint, now.Add(2*time.Hour)); ok {
+		t.Errorf("Test: 'after expiry' FAILED : expected %s not to be acknowledged", fingerprint)
+	}
+}
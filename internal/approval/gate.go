@@ -0,0 +1,258 @@
+package approval
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// in server mode, the results of scheduled runs may be held for approval before they are pushed to external systems
+// (e.g. Security Hub, Jira) - the run requests approval from the gate, which notifies the approval webhook (if any)
+// and connected dashboard clients, then waits until the request is approved or rejected through the API,
+// or expires
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusApproved Status = "approved"
+	StatusRejected Status = "rejected"
+	StatusExpired  Status = "expired"
+
+	notifyTimeout = 30 * time.Second
+	// the number of decided requests retained, for the API
+	maxRetainedRequests = 100
+)
+
+var (
+	ErrNotFound       = errors.New("approval request not found")
+	ErrAlreadyDecided = errors.New("approval request has already been decided")
+)
+
+// Request is a request for approval to push the results of a run to external systems
+type Request struct {
+	Id string `json:"id"`
+	// the name of the run awaiting approval
+	Run string `json:"run"`
+	// a summary of the results, for the approver
+	Summary string `json:"summary,omitempty"`
+	// the external systems the results will be pushed to
+	Targets   []string   `json:"targets,omitempty"`
+	Status    Status     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+	DecidedBy string     `json:"decided_by,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+
+	decided chan struct{}
+}
+
+// ChangeHandler is called when a request is created and when it is decided
+type ChangeHandler func(ctx context.Context, request *Request)
+
+// Gate holds approval requests until they are decided
+type Gate struct {
+	// the url approval requests are posted to (optional)
+	WebhookUrl string
+	// the duration after which a pending request expires
+	Timeout  time.Duration
+	onChange ChangeHandler
+	// the client of webhook requests
+	client *http.Client
+
+	lock     sync.Mutex
+	requests map[string]*Request
+}
+
+func NewGate(webhookUrl string, timeout time.Duration, client *http.Client, onChange ChangeHandler) *Gate {
+	return &Gate{
+		WebhookUrl: webhookUrl,
+		Timeout:    timeout,
+		onChange:   onChange,
+		client:     client,
+		requests:   make(map[string]*Request),
+	}
+}
+
+// Await requests approval to push the results of the run to the targets, and waits until the request is decided
+// it returns the decided request - the results may only be pushed if its status is StatusApproved
+func (g *Gate) Await(ctx context.Context, run, summary string, targets []string) (*Request, error) {
+	request, decided, err := g.create(run, summary, targets)
+	if err != nil {
+		return nil, err
+	}
+	g.changed(ctx, request)
+	if err := g.notifyWebhook(ctx, request); err != nil {
+		// approval is still possible through the API
+		slog.Warn("failed to send approval request to webhook", "run", run, "error", err)
+	}
+
+	timer := time.NewTimer(time.Until(request.ExpiresAt))
+	defer timer.Stop()
+	select {
+	case <-decided:
+	case <-timer.C:
+		// the request may have been decided concurrently - so ignore the error
+		_, _ = g.decide(ctx, request.Id, StatusExpired, "", "not decided before the approval timeout")
+	case <-ctx.Done():
+		_, _ = g.decide(ctx, request.Id, StatusExpired, "", "the run was cancelled")
+		return nil, ctx.Err()
+	}
+	return g.Get(request.Id)
+}
+
+// Approve approves the pending request with the given id
+func (g *Gate) Approve(ctx context.Context, id, by, reason string) (*Request, error) {
+	return g.decide(ctx, id, StatusApproved, by, reason)
+}
+
+// Reject rejects the pending request with the given id
+func (g *Gate) Reject(ctx context.Context, id, by, reason string) (*Request, error) {
+	return g.decide(ctx, id, StatusRejected, by, reason)
+}
+
+// Get returns a copy of the request with the given id
+func (g *Gate) Get(id string) (*Request, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	request, ok := g.requests[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return request.copy(), nil
+}
+
+// List returns copies of the retained requests, newest first - if status is set, only requests with that status are returned
+func (g *Gate) List(status Status) []*Request {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	res := make([]*Request, 0, len(g.requests))
+	for _, r := range g.requests {
+		if status == "" || r.Status == status {
+			res = append(res, r.copy())
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].CreatedAt.After(res[j].CreatedAt) })
+	return res
+}
+
+// create adds a pending request, returning a copy of it and the channel which is closed when it is decided
+func (g *Gate) create(run, summary string, targets []string) (*Request, chan struct{}, error) {
+	id, err := newRequestId()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	request := &Request{
+		Id:        id,
+		Run:       run,
+		Summary:   summary,
+		Targets:   targets,
+		Status:    StatusPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(g.Timeout),
+		decided:   make(chan struct{}),
+	}
+	g.lock.Lock()
+	g.requests[id] = request
+	res := request.copy()
+	g.lock.Unlock()
+	return res, request.decided, nil
+}
+
+func (g *Gate) decide(ctx context.Context, id string, status Status, by, reason string) (*Request, error) {
+	g.lock.Lock()
+	request, ok := g.requests[id]
+	if !ok {
+		g.lock.Unlock()
+		return nil, ErrNotFound
+	}
+	if request.Status != StatusPending {
+		g.lock.Unlock()
+		return nil, ErrAlreadyDecided
+	}
+	now := time.Now()
+	request.Status = status
+	request.DecidedAt = &now
+	request.DecidedBy = by
+	request.Reason = reason
+	close(request.decided)
+	res := request.copy()
+	g.pruneDecided()
+	g.lock.Unlock()
+
+	g.changed(ctx, res)
+	return res, nil
+}
+
+// pruneDecided removes the oldest decided requests in excess of maxRetainedRequests - the lock must be held
+func (g *Gate) pruneDecided() {
+	var decided []*Request
+	for _, r := range g.requests {
+		if r.Status != StatusPending {
+			decided = append(decided, r)
+		}
+	}
+	if excess := len(decided) - maxRetainedRequests; excess > 0 {
+		sort.Slice(decided, func(i, j int) bool { return decided[i].DecidedAt.Before(*decided[j].DecidedAt) })
+		for _, r := range decided[:excess] {
+			delete(g.requests, r.Id)
+		}
+	}
+}
+
+func (g *Gate) changed(ctx context.Context, request *Request) {
+	if g.onChange != nil {
+		g.onChange(ctx, request)
+	}
+}
+
+// notifyWebhook posts the request to the approval webhook - the receiver approves or rejects it through the API
+func (g *Gate) notifyWebhook(ctx context.Context, request *Request) error {
+	if g.WebhookUrl == "" {
+		return nil
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.WebhookUrl, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned status %s", g.WebhookUrl, resp.Status)
+	}
+	return nil
+}
+
+func (r *Request) copy() *Request {
+	res := *r
+	res.decided = nil
+	return &res
+}
+
+func newRequestId() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
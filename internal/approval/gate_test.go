@@ -0,0 +1,72 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type gateTest struct {
+	timeout time.Duration
+	// the decision made once the request is pending (nil for no decision)
+	decide       func(g *Gate, id string) (*Request, error)
+	expectStatus Status
+}
+
+var testCasesGate = map[string]gateTest{
+	"approved": {
+		timeout: time.Minute,
+		decide: func(g *Gate, id string) (*Request, error) {
+			return g.Approve(context.Background(), id, "alice", "triaged")
+		},
+		expectStatus: StatusApproved,
+	},
+	"rejected": {
+		timeout: time.Minute,
+		decide: func(g *Gate, id string) (*Request, error) {
+			return g.Reject(context.Background(), id, "alice", "false positives")
+		},
+		expectStatus: StatusRejected,
+	},
+	"expired": {
+		timeout:      10 * time.Millisecond,
+		expectStatus: StatusExpired,
+	},
+}
+
+func TestGate(t *testing.T) {
+	for name, test := range testCasesGate {
+		pending := make(chan *Request, 1)
+		g := NewGate("", test.timeout, http.DefaultClient, func(_ context.Context, r *Request) {
+			if r.Status == StatusPending {
+				pending <- r
+			}
+		})
+		if test.decide != nil {
+			go func() {
+				r := <-pending
+				if _, err := test.decide(g, r.Id); err != nil {
+					t.Errorf("Test: '%s' FAILED : decide returned %s", name, err.Error())
+				}
+			}()
+		}
+
+		request, err := g.Await(context.Background(), "benchmark.cis", "3 alarms", []string{"jira"})
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %s", name, err.Error())
+			continue
+		}
+		if request.Status != test.expectStatus {
+			t.Errorf("Test: '%s' FAILED : expected status %s, got %s", name, test.expectStatus, request.Status)
+		}
+		// a decided request cannot be decided again
+		if _, err := g.Approve(context.Background(), request.Id, "bob", ""); !errors.Is(err, ErrAlreadyDecided) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, ErrAlreadyDecided, err)
+		}
+		if len(g.List(StatusPending)) != 0 || len(g.List("")) != 1 {
+			t.Errorf("Test: '%s' FAILED : expected 1 decided request, got %d pending, %d total", name, len(g.List(StatusPending)), len(g.List("")))
+		}
+	}
+}
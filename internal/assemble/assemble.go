@@ -0,0 +1,159 @@
+package assemble
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+// assembled benchmarks are benchmarks which select their child controls from the workspace and its
+// dependency mods at load time, e.g.
+//
+//	benchmark "critical" {
+//	  title = "Critical controls"
+//	  tags = {
+//	    assemble_tags     = "severity=critical"
+//	    assemble_controls = "aws_compliance.control.cis_v300_*,control.my_local_check"
+//	  }
+//	}
+//
+// assemble_controls is a comma-separated list of control names, which may contain glob wildcards
+// assemble_tags is a comma-separated list of tag conditions ('key=value' or 'key'), ALL of which must match
+// controls are included if they match ANY control name, or ALL tag conditions
+const (
+	TagControls = "assemble_controls"
+	TagTags     = "assemble_tags"
+)
+
+// Benchmarks adds the selected controls as children of every assembled benchmark in the resource maps
+func Benchmarks(resourceMaps *modconfig.ResourceMaps) error {
+	for _, benchmark := range resourceMaps.Benchmarks {
+		selector, err := newSelector(benchmark)
+		if err != nil {
+			return err
+		}
+		if selector == nil {
+			continue
+		}
+		assembleBenchmark(benchmark, selector, resourceMaps.Controls)
+	}
+	return nil
+}
+
+func assembleBenchmark(benchmark *modconfig.Benchmark, selector *selector, controls map[string]*modconfig.Control) {
+	children := benchmark.GetChildren()
+	// build a lookup of existing children - these are not added again
+	existing := make(map[string]struct{}, len(children))
+	for _, child := range children {
+		existing[child.Name()] = struct{}{}
+	}
+
+	var selected []*modconfig.Control
+	for name, control := range controls {
+		if _, ok := existing[name]; ok {
+			continue
+		}
+		if selector.matches(control) {
+			selected = append(selected, control)
+		}
+	}
+	if len(selected) == 0 {
+		return
+	}
+	// sort for consistent ordering
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Name() < selected[j].Name() })
+
+	for _, control := range selected {
+		children = append(children, control)
+		benchmark.ChildNameStrings = append(benchmark.ChildNameStrings, control.Name())
+		// errors from AddParent are not possible
+		_ = control.AddParent(benchmark)
+		// paths are lazily populated from the parents - clear so they are rebuilt including the new parent
+		control.Paths = nil
+	}
+	benchmark.SetChildren(children)
+}
+
+type selector struct {
+	controlPatterns []string
+	tagConditions   map[string]*string
+}
+
+// newSelector builds the selector for an assembled benchmark - returns nil if the benchmark is not assembled
+func newSelector(benchmark *modconfig.Benchmark) (*selector, error) {
+	tags := benchmark.GetTags()
+	controlPatterns := splitTagValue(tags[TagControls])
+	tagConditions := splitTagValue(tags[TagTags])
+	if len(controlPatterns) == 0 && len(tagConditions) == 0 {
+		return nil, nil
+	}
+
+	s := &selector{
+		controlPatterns: controlPatterns,
+		tagConditions:   make(map[string]*string, len(tagConditions)),
+	}
+	for _, pattern := range controlPatterns {
+		// validate the pattern
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid %s tag '%s' for %s: %s", TagControls, pattern, benchmark.Name(), err.Error())
+		}
+	}
+	for _, condition := range tagConditions {
+		key, value, hasValue := strings.Cut(condition, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("invalid %s tag '%s' for %s: expected 'key=value' or 'key'", TagTags, condition, benchmark.Name())
+		}
+		if hasValue {
+			value = strings.TrimSpace(value)
+			s.tagConditions[key] = &value
+		} else {
+			s.tagConditions[key] = nil
+		}
+	}
+	return s, nil
+}
+
+func (s *selector) matches(control *modconfig.Control) bool {
+	for _, pattern := range s.controlPatterns {
+		if matchesName(pattern, control.Name()) || matchesName(pattern, control.GetUnqualifiedName()) {
+			return true
+		}
+	}
+	return s.matchesTags(control.GetTags())
+}
+
+func (s *selector) matchesTags(tags map[string]string) bool {
+	if len(s.tagConditions) == 0 {
+		return false
+	}
+	for key, value := range s.tagConditions {
+		tagValue, ok := tags[key]
+		if !ok {
+			return false
+		}
+		if value != nil && tagValue != *value {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesName(pattern, name string) bool {
+	match, _ := path.Match(pattern, name)
+	return match
+}
+
+func splitTagValue(value string) []string {
+	var res []string
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s != "" && !helpers.StringSliceContains(res, s) {
+			res = append(res, s)
+		}
+	}
+	return res
+}
@@ -0,0 +1,55 @@
+package assemble
+
+import (
+	"testing"
+)
+
+type matchesTagsTest struct {
+	conditions map[string]*string
+	tags       map[string]string
+	expected   bool
+}
+
+func stringPtr(s string) *string { return &s }
+
+var testCasesMatchesTags = map[string]matchesTagsTest{
+	"key and value match": {
+		conditions: map[string]*string{"severity": stringPtr("critical")},
+		tags:       map[string]string{"severity": "critical", "service": "aws/s3"},
+		expected:   true,
+	},
+	"value mismatch": {
+		conditions: map[string]*string{"severity": stringPtr("critical")},
+		tags:       map[string]string{"severity": "high"},
+		expected:   false,
+	},
+	"key only": {
+		conditions: map[string]*string{"cis": nil},
+		tags:       map[string]string{"cis": "true"},
+		expected:   true,
+	},
+	"key missing": {
+		conditions: map[string]*string{"cis": nil},
+		tags:       map[string]string{"severity": "critical"},
+		expected:   false,
+	},
+	"all conditions must match": {
+		conditions: map[string]*string{"severity": stringPtr("critical"), "cis": nil},
+		tags:       map[string]string{"severity": "critical"},
+		expected:   false,
+	},
+	"no conditions": {
+		conditions: map[string]*string{},
+		tags:       map[string]string{"severity": "critical"},
+		expected:   false,
+	},
+}
+
+func TestMatchesTags(t *testing.T) {
+	for name, test := range testCasesMatchesTags {
+		s := &selector{tagConditions: test.conditions}
+		if actual := s.matchesTags(test.tags); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/filepaths"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/powerpipe/internal/ack"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+// a workspace backup is a gzipped tar archive of the state of a workspace, so that it can be moved between machines
+// or restored after a workstation rebuild. It contains:
+//   - the lock file (.mod.cache.json) and the installed mods (.powerpipe/mods)
+//   - the variable files of the workspace (*.ppvars, *.spvars)
+//   - the snapshot files of the workspace (*.pps)
+//   - the finding acknowledgements of the workspace (.finding_acks.json)
+//   - the control run history and the verified mod sums, from the internal directory of the installation
+//
+// workspace files are stored under 'workspace/' and internal files under 'internal/'. The archive starts with a
+// manifest which records the files it contains, so a restore can check for conflicts before writing anything
+const (
+	manifestName    = "manifest.json"
+	workspacePrefix = "workspace/"
+	internalPrefix  = "internal/"
+)
+
+// internalFiles are the files of the internal directory which are part of the workspace state
+var internalFiles = []string{"control_history.json", "mod_sums.json"}
+
+// Manifest describes the contents of a backup
+type Manifest struct {
+	PowerpipeVersion string    `json:"powerpipe_version"`
+	CreatedAt        time.Time `json:"created_at"`
+	// the archive paths of the files in the backup
+	Files []string `json:"files"`
+}
+
+// Paths are the directories which are backed up and restored
+type Paths struct {
+	Workspace string
+	// the internal directory of the installation
+	Internal string
+}
+
+// Backup writes a backup of the workspace state to w
+func Backup(paths Paths, w io.Writer) (*Manifest, error) {
+	files, err := collectFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{CreatedAt: time.Now().UTC()}
+	if app_specific.AppVersion != nil {
+		manifest.PowerpipeVersion = app_specific.AppVersion.String()
+	}
+	for _, f := range files {
+		manifest.Files = append(manifest.Files, f.name)
+	}
+
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	header := &tar.Header{Name: manifestName, Mode: 0644, Size: int64(len(manifestData)), ModTime: manifest.CreatedAt}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return nil, err
+	}
+	if _, err := tarWriter.Write(manifestData); err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if err := writeFile(tarWriter, f); err != nil {
+			return nil, fmt.Errorf("failed to back up '%s': %w", f.path, err)
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return nil, err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// backupFile is a file to back up, with its archive path
+type backupFile struct {
+	name string
+	path string
+}
+
+// collectFiles returns the files of the workspace state which exist, sorted by archive path
+func collectFiles(paths Paths) ([]backupFile, error) {
+	var res []backupFile
+	addWorkspaceFile := func(filePath string) error {
+		rel, err := filepath.Rel(paths.Workspace, filePath)
+		if err != nil {
+			return err
+		}
+		res = append(res, backupFile{name: workspacePrefix + filepath.ToSlash(rel), path: filePath})
+		return nil
+	}
+
+	// the lock file, variable files and snapshots are in the root of the workspace
+	entries, err := os.ReadDir(paths.Workspace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace directory: %w", err)
+	}
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !isWorkspaceStateFile(e.Name()) {
+			continue
+		}
+		if err := addWorkspaceFile(filepath.Join(paths.Workspace, e.Name())); err != nil {
+			return nil, err
+		}
+	}
+
+	// the installed mods
+	modDir := filepaths.WorkspaceModPath(paths.Workspace)
+	err = filepath.WalkDir(modDir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && filePath == modDir {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			slog.Debug("skipping file which is not a regular file", "path", filePath)
+			return nil
+		}
+		return addWorkspaceFile(filePath)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read installed mods: %w", err)
+	}
+
+	for _, name := range internalFiles {
+		filePath := filepath.Join(paths.Internal, name)
+		if info, err := os.Stat(filePath); err == nil && info.Mode().IsRegular() {
+			res = append(res, backupFile{name: internalPrefix + name, path: filePath})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].name < res[j].name })
+	return res, nil
+}
+
+// isWorkspaceStateFile returns whether a file in the root of the workspace is part of the workspace state
+func isWorkspaceStateFile(name string) bool {
+	if name == filepaths.WorkspaceLockFileName || name == ack.FileName || strings.HasSuffix(name, localconstants.SnapshotExtension) {
+		return true
+	}
+	for _, ext := range app_specific.VariablesExtensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWorkspaceBackupPath returns whether the (clean, slash separated) path relative to the workspace is one which
+// is backed up, i.e. a state file in the root of the workspace or a file of the installed mods
+func isWorkspaceBackupPath(rel string) bool {
+	if !strings.Contains(rel, "/") {
+		return isWorkspaceStateFile(rel)
+	}
+	return strings.HasPrefix(rel, path.Join(app_specific.WorkspaceDataDir, filepaths.WorkspaceModDir)+"/")
+}
+
+func writeFile(tarWriter *tar.Writer, f backupFile) error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{Name: f.name, Mode: int64(info.Mode().Perm()), Size: info.Size(), ModTime: info.ModTime()}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tarWriter, file)
+	return err
+}
+
+// Restore restores the workspace state from a backup read from r
+// unless overwrite is set, it is an error for any file of the backup to exist already - this is checked before
+// any file is written
+func Restore(paths Paths, r io.Reader, overwrite bool) (*Manifest, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	defer gzipReader.Close()
+	tarReader := tar.NewReader(gzipReader)
+
+	manifest, err := readManifest(tarReader)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string]string, len(manifest.Files))
+	var existing []string
+	for _, name := range manifest.Files {
+		target, err := targetPath(paths, name)
+		if err != nil {
+			return nil, err
+		}
+		targets[name] = target
+		if _, err := os.Stat(target); err == nil {
+			existing = append(existing, target)
+		}
+	}
+	if len(existing) > 0 && !overwrite {
+		return nil, fmt.Errorf("%d %s of the backup already exist, e.g. '%s' - use --force to overwrite them", len(existing), utils.Pluralize("file", len(existing)), existing[0])
+	}
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup: %w", err)
+		}
+		target, ok := targets[header.Name]
+		if !ok || header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("backup contains unexpected file '%s'", header.Name)
+		}
+		if err := restoreFile(tarReader, target, os.FileMode(header.Mode).Perm()); err != nil {
+			return nil, fmt.Errorf("failed to restore '%s': %w", target, err)
+		}
+	}
+	return manifest, nil
+}
+
+func readManifest(tarReader *tar.Reader) (*Manifest, error) {
+	header, err := tarReader.Next()
+	if err != nil || header.Name != manifestName {
+		return nil, fmt.Errorf("not a workspace backup - the archive has no manifest")
+	}
+	manifest := &Manifest{}
+	if err := json.NewDecoder(tarReader).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("failed to read backup manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// targetPath returns the path to restore the file with the given archive path to
+// only the files which are backed up (the workspace state files and the installed mods) may be restored - any other
+// archive path, e.g. a mod file or a path outside the workspace or internal directory, is rejected
+func targetPath(paths Paths, name string) (string, error) {
+	if rel, ok := strings.CutPrefix(name, workspacePrefix); ok {
+		clean := path.Clean(rel)
+		if clean != rel || clean == "." || strings.Contains(clean, `\`) || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return "", fmt.Errorf("backup contains invalid path '%s'", name)
+		}
+		if !isWorkspaceBackupPath(clean) {
+			return "", fmt.Errorf("backup contains invalid path '%s' - only workspace state files and installed mods are restored", name)
+		}
+		return filepath.Join(paths.Workspace, filepath.FromSlash(clean)), nil
+	}
+	if rel, ok := strings.CutPrefix(name, internalPrefix); ok {
+		for _, f := range internalFiles {
+			if rel == f {
+				return filepath.Join(paths.Internal, f), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("backup contains invalid path '%s'", name)
+}
+
+func restoreFile(r io.Reader, target string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if mode == 0 {
+		mode = 0644
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,168 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/app_specific"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	app_specific.WorkspaceDataDir = ".powerpipe"
+	app_specific.VariablesExtensions = []string{".ppvars", ".spvars"}
+
+	source := Paths{Workspace: t.TempDir(), Internal: t.TempDir()}
+	writeTestFiles(t, source.Workspace, map[string]string{
+		".mod.cache.json":  "{}",
+		"mod.pp":           "mod \"local\" {}",
+		"powerpipe.ppvars": "region = \"us-east-1\"",
+		"dev.auto.ppvars":  "env = \"dev\"",
+		"run.pps":          "{}",
+		".powerpipe/mods/github.com/turbot/aws@v1.0.0/mod.pp": "mod \"aws\" {}",
+		".powerpipe/.mods.abc/mod.pp":                         "interrupted install",
+	})
+	writeTestFiles(t, source.Internal, map[string]string{
+		"control_history.json": "{}",
+		"update_check.json":    "{}",
+	})
+
+	var buf bytes.Buffer
+	manifest, err := Backup(source, &buf)
+	if err != nil {
+		t.Fatalf("Test: 'backup' FAILED : unexpected error %v", err)
+	}
+	expected := []string{
+		"internal/control_history.json",
+		"workspace/.mod.cache.json",
+		"workspace/.powerpipe/mods/github.com/turbot/aws@v1.0.0/mod.pp",
+		"workspace/dev.auto.ppvars",
+		"workspace/powerpipe.ppvars",
+		"workspace/run.pps",
+	}
+	if !reflect.DeepEqual(manifest.Files, expected) {
+		t.Errorf("Test: 'backup' FAILED : expected %v, got %v", expected, manifest.Files)
+	}
+
+	target := Paths{Workspace: t.TempDir(), Internal: t.TempDir()}
+	if _, err := Restore(target, bytes.NewReader(buf.Bytes()), false); err != nil {
+		t.Fatalf("Test: 'restore' FAILED : unexpected error %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(target.Workspace, ".powerpipe", "mods", "github.com", "turbot", "aws@v1.0.0", "mod.pp"))
+	if err != nil || string(data) != "mod \"aws\" {}" {
+		t.Errorf("Test: 'restore' FAILED : expected the installed mod to be restored, got '%s' (%v)", data, err)
+	}
+
+	// restoring again conflicts with the restored files, unless overwriting
+	if _, err := Restore(target, bytes.NewReader(buf.Bytes()), false); err == nil || !strings.Contains(err.Error(), "already exist") {
+		t.Errorf("Test: 'restore conflict' FAILED : expected conflict error, got %v", err)
+	}
+	if _, err := Restore(target, bytes.NewReader(buf.Bytes()), true); err != nil {
+		t.Errorf("Test: 'restore overwrite' FAILED : unexpected error %v", err)
+	}
+}
+
+type targetPathTest struct {
+	name     string
+	expected string
+	// if set, an error is expected
+	err bool
+}
+
+var testCasesTargetPath = map[string]targetPathTest{
+	"workspace file":      {name: "workspace/powerpipe.ppvars", expected: filepath.Join("ws", "powerpipe.ppvars")},
+	"installed mod":       {name: "workspace/.powerpipe/mods/a@v1.0.0/mod.pp", expected: filepath.Join("ws", ".powerpipe", "mods", "a@v1.0.0", "mod.pp")},
+	"internal file":       {name: "internal/control_history.json", expected: filepath.Join("internal", "control_history.json")},
+	"unknown internal":    {name: "internal/update_check.json", err: true},
+	"parent directory":    {name: "workspace/../outside.txt", err: true},
+	"nested parent":       {name: "workspace/.powerpipe/../../outside.txt", err: true},
+	"absolute":            {name: "workspace//etc/passwd", err: true},
+	"windows separator":   {name: `workspace/..\outside.txt`, err: true},
+	"unknown prefix":      {name: "other/file.txt", err: true},
+	"workspace directory": {name: "workspace/", err: true},
+	"mod file":            {name: "workspace/evil.pp", err: true},
+	"nested state file":   {name: "workspace/dashboards/powerpipe.ppvars", err: true},
+	"workspace data":      {name: "workspace/.powerpipe/other/file.txt", err: true},
+	"mod directory":       {name: "workspace/.powerpipe/mods", err: true},
+}
+
+func TestTargetPath(t *testing.T) {
+	app_specific.WorkspaceDataDir = ".powerpipe"
+	app_specific.VariablesExtensions = []string{".ppvars", ".spvars"}
+
+	paths := Paths{Workspace: "ws", Internal: "internal"}
+	for name, test := range testCasesTargetPath {
+		target, err := targetPath(paths, test.name)
+		if test.err {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error, got '%s'", name, target)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		if target != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, target)
+		}
+	}
+}
+
+func TestRestoreRejectedFile(t *testing.T) {
+	app_specific.WorkspaceDataDir = ".powerpipe"
+	app_specific.VariablesExtensions = []string{".ppvars", ".spvars"}
+
+	// a backup containing a mod file, which would be loaded by the workspace if restored
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	files := []struct{ name, content string }{
+		{manifestName, `{"files": ["workspace/powerpipe.ppvars", "workspace/evil.pp"]}`},
+		{"workspace/powerpipe.ppvars", "region = \"us-east-1\""},
+		{"workspace/evil.pp", "query \"evil\" {}"},
+	}
+	for _, f := range files {
+		if err := tarWriter.WriteHeader(&tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tarWriter.Write([]byte(f.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	target := Paths{Workspace: t.TempDir(), Internal: t.TempDir()}
+	expected := "backup contains invalid path 'workspace/evil.pp' - only workspace state files and installed mods are restored"
+	if _, err := Restore(target, &buf, false); err == nil || err.Error() != expected {
+		t.Errorf("Test: 'rejected file' FAILED : expected error '%s', got %v", expected, err)
+	}
+	// nothing is restored
+	for _, name := range []string{"powerpipe.ppvars", "evil.pp"} {
+		if _, err := os.Stat(filepath.Join(target.Workspace, name)); !os.IsNotExist(err) {
+			t.Errorf("Test: 'rejected file' FAILED : expected '%s' not to be restored, got %v", name, err)
+		}
+	}
+}
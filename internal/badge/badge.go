@@ -0,0 +1,112 @@
+package badge
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+)
+
+const (
+	ColorGood    = "#97ca00"
+	ColorWarning = "#dfb317"
+	ColorBad     = "#e05d44"
+	ColorNone    = "#9f9f9f"
+
+	// the minimum pass percentages for the good and warning colors
+	goodPercent    = 90
+	warningPercent = 75
+)
+
+// Badge is a summary of the results of a benchmark run, for embedding in READMEs and wikis, e.g.
+//
+//	CIS AWS | 92% / 14 alarms
+//
+// the JSON representation is compatible with the shields.io endpoint schema
+type Badge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+	// the percentage of ok results, out of the ok, alarm and error results
+	Percent int `json:"percent"`
+	Ok      int `json:"ok"`
+	Alarm   int `json:"alarm"`
+	Error   int `json:"error"`
+	Info    int `json:"info"`
+	Skip    int `json:"skip"`
+}
+
+// NewBadge builds a badge with the given label from a benchmark status summary
+func NewBadge(label string, summary controlstatus.StatusSummary) *Badge {
+	b := &Badge{
+		SchemaVersion: 1,
+		Label:         label,
+		Ok:            summary.Ok,
+		Alarm:         summary.Alarm,
+		Error:         summary.Error,
+		Info:          summary.Info,
+		Skip:          summary.Skip,
+	}
+
+	total := summary.Ok + summary.Alarm + summary.Error
+	if total == 0 {
+		b.Message = "no results"
+		b.Color = ColorNone
+		return b
+	}
+
+	// round down, so 100% is only shown if there are no alarms or errors
+	b.Percent = summary.Ok * 100 / total
+	parts := []string{
+		fmt.Sprintf("%d%%", b.Percent),
+		fmt.Sprintf("%d %s", summary.Alarm, utils.Pluralize("alarm", summary.Alarm)),
+	}
+	if summary.Error > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", summary.Error, utils.Pluralize("error", summary.Error)))
+	}
+	b.Message = strings.Join(parts, " / ")
+
+	switch {
+	case b.Percent >= goodPercent:
+		b.Color = ColorGood
+	case b.Percent >= warningPercent:
+		b.Color = ColorWarning
+	default:
+		b.Color = ColorBad
+	}
+	return b
+}
+
+// SVG renders the badge as a flat style SVG image
+func (b *Badge) SVG() string {
+	labelWidth := textWidth(b.Label)
+	messageWidth := textWidth(b.Message)
+	width := labelWidth + messageWidth
+	label := html.EscapeString(b.Label)
+	message := html.EscapeString(b.Message)
+
+	var s strings.Builder
+	s.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, width, label, message))
+	s.WriteString(fmt.Sprintf(`<title>%s: %s</title>`, label, message))
+	s.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	s.WriteString(fmt.Sprintf(`<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, width))
+	s.WriteString(`<g clip-path="url(#r)">`)
+	s.WriteString(fmt.Sprintf(`<rect width="%d" height="20" fill="#555"/>`, labelWidth))
+	s.WriteString(fmt.Sprintf(`<rect x="%d" width="%d" height="20" fill="%s"/>`, labelWidth, messageWidth, b.Color))
+	s.WriteString(fmt.Sprintf(`<rect width="%d" height="20" fill="url(#s)"/>`, width))
+	s.WriteString(`</g>`)
+	s.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	s.WriteString(fmt.Sprintf(`<text x="%d" y="14">%s</text>`, labelWidth/2, label))
+	s.WriteString(fmt.Sprintf(`<text x="%d" y="14">%s</text>`, labelWidth+messageWidth/2, message))
+	s.WriteString(`</g></svg>`)
+	return s.String()
+}
+
+// textWidth returns the approximate width in pixels of a badge section containing the text
+// (11px Verdana averages around 7px per character), including padding
+func textWidth(text string) int {
+	return len([]rune(text))*7 + 10
+}
@@ -0,0 +1,58 @@
+package badge
+
+import (
+	"testing"
+
+	"github.com/turbot/powerpipe/internal/controlstatus"
+)
+
+type newBadgeTest struct {
+	summary controlstatus.StatusSummary
+	message string
+	color   string
+}
+
+var testCasesNewBadge = map[string]newBadgeTest{
+	"all ok": {
+		summary: controlstatus.StatusSummary{Ok: 10, Info: 2, Skip: 1},
+		message: "100% / 0 alarms",
+		color:   ColorGood,
+	},
+	"mostly ok": {
+		summary: controlstatus.StatusSummary{Ok: 161, Alarm: 14},
+		message: "92% / 14 alarms",
+		color:   ColorGood,
+	},
+	"single alarm rounds down": {
+		summary: controlstatus.StatusSummary{Ok: 999, Alarm: 1},
+		message: "99% / 1 alarm",
+		color:   ColorGood,
+	},
+	"warning": {
+		summary: controlstatus.StatusSummary{Ok: 8, Alarm: 2},
+		message: "80% / 2 alarms",
+		color:   ColorWarning,
+	},
+	"errors": {
+		summary: controlstatus.StatusSummary{Ok: 5, Alarm: 3, Error: 2},
+		message: "50% / 3 alarms / 2 errors",
+		color:   ColorBad,
+	},
+	"no results": {
+		summary: controlstatus.StatusSummary{Info: 3, Skip: 4},
+		message: "no results",
+		color:   ColorNone,
+	},
+}
+
+func TestNewBadge(t *testing.T) {
+	for name, test := range testCasesNewBadge {
+		b := NewBadge("CIS AWS", test.summary)
+		if b.Message != test.message {
+			t.Errorf("Test: '%s' FAILED : expected message '%s', got '%s'", name, test.message, b.Message)
+		}
+		if b.Color != test.color {
+			t.Errorf("Test: '%s' FAILED : expected color '%s', got '%s'", name, test.color, b.Color)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package batch
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/export"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+// batch mode (--once) runs benchmarks as a one-shot job, e.g. a Kubernetes CronJob:
+//   - there are no prompts, progress spinners or colors
+//   - exports have deterministic paths - the timestamp is omitted from the default file name, e.g. 'benchmark.cis.json'
+//     rather than 'benchmark.cis.20240102T030405.json', so the files may be collected by a later step of the job
+//   - the lifecycle of the run is logged to stderr as JSON lines (the run starting, each benchmark completing, each
+//     file exported and the exit code), whatever the log level, so it may be collected by a log shipper
+//   - the run is cancelled on SIGTERM (sent when a pod is deleted or its job exceeds its deadline), as on SIGINT
+//
+// with --exit-after-export, the exit code reports whether the exports were written, rather than the control results
+
+// the timestamp added to default export file names by the export manager
+var exportTimestampRegex = regexp.MustCompile(`\.\d{8}T\d{6}$`)
+
+var (
+	loggerOnce sync.Once
+	logger     *slog.Logger
+	// the log destination - a variable so tests may replace it
+	logWriter io.Writer = os.Stderr
+)
+
+// Enabled returns whether the run is in batch mode
+func Enabled() bool {
+	return viper.GetBool(localconstants.ArgOnce)
+}
+
+// Log logs an event of the run lifecycle in batch mode - it is a no-op otherwise
+func Log(msg string, args ...any) {
+	if !Enabled() {
+		return
+	}
+	loggerOnce.Do(func() {
+		logger = slog.New(slog.NewJSONHandler(logWriter, nil))
+	})
+	logger.Info(msg, args...)
+}
+
+// DeterministicPath returns the export path without the timestamp added to default file names - paths given
+// explicitly (e.g. --export report.json) are returned unchanged
+func DeterministicPath(path, extension string) string {
+	name, ok := strings.CutSuffix(path, extension)
+	if !ok || extension == "" {
+		return path
+	}
+	return exportTimestampRegex.ReplaceAllString(name, "") + extension
+}
+
+// WrapExporter returns an exporter which writes to the deterministic path of each export, logging each file exported
+func WrapExporter(exporter export.Exporter) export.Exporter {
+	return &deterministicExporter{Exporter: exporter}
+}
+
+type deterministicExporter struct {
+	export.Exporter
+}
+
+func (e *deterministicExporter) Export(ctx context.Context, input export.ExportSourceData, destPath string) error {
+	destPath = DeterministicPath(destPath, e.FileExtension())
+	if err := e.Exporter.Export(ctx, input, destPath); err != nil {
+		return err
+	}
+	Log("exported", "path", destPath, "format", e.Name())
+	return nil
+}
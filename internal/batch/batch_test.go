@@ -0,0 +1,45 @@
+package batch
+
+import "testing"
+
+type deterministicPathTest struct {
+	path      string
+	extension string
+	expected  string
+}
+
+var testCasesDeterministicPath = map[string]deterministicPathTest{
+	"default file name": {
+		path:      "benchmark.cis_v300.20240102T030405.json",
+		extension: ".json",
+		expected:  "benchmark.cis_v300.json",
+	},
+	"multi part extension": {
+		path:      "check.aws_compliance.20240102T030405.badge.svg",
+		extension: ".badge.svg",
+		expected:  "check.aws_compliance.badge.svg",
+	},
+	"named export": {
+		path:      "reports/cis.json",
+		extension: ".json",
+		expected:  "reports/cis.json",
+	},
+	"other extension": {
+		path:      "benchmark.cis_v300.20240102T030405.html",
+		extension: ".json",
+		expected:  "benchmark.cis_v300.20240102T030405.html",
+	},
+	"no extension": {
+		path:     "benchmark.cis_v300.20240102T030405",
+		expected: "benchmark.cis_v300.20240102T030405",
+	},
+}
+
+func TestDeterministicPath(t *testing.T) {
+	for name, test := range testCasesDeterministicPath {
+		actual := DeterministicPath(test.path, test.extension)
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, actual)
+		}
+	}
+}
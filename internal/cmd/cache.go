@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/utils"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/display"
+	"github.com/turbot/powerpipe/internal/modcache"
+)
+
+func cacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache [command]",
+		Args:  cobra.NoArgs,
+		Short: "Manage the installed mod cache of the workspace",
+		Long: `Manage the installed mod cache of the workspace.
+
+Each installed mod version is cloned into the .powerpipe directory of the workspace. Versions which are no
+longer required, and the temporary directories of interrupted installs, are left behind - use 'cache info'
+to report their disk usage, and 'cache gc' to remove them.`,
+	}
+	cmd.AddCommand(cacheInfoCmd(), cacheGcCmd())
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for cache", cmdconfig.FlagOptions.WithShortHand("h"))
+
+	return cmd
+}
+
+func cacheInfoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "info",
+		Args:  cobra.NoArgs,
+		Run:   runCacheInfoCmd,
+		Short: "Report the disk usage of each installed mod version",
+		Long: `Report the disk usage of each installed mod version, and of the temporary directories of interrupted installs.
+
+Example:
+
+  # Report the disk usage of the mod cache
+  powerpipe cache info`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for cache info", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddStringFlag(constants.ArgOutput, constants.OutputFormatTable, "Output format; one of: table, json").
+		AddModLocationFlag()
+
+	return cmd
+}
+
+func runCacheInfoCmd(cmd *cobra.Command, _ []string) {
+	ctx := cmd.Context()
+
+	entries, err := modcache.Scan(viper.GetString(constants.ArgModLocation))
+	if err != nil {
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	switch viper.GetString(constants.ArgOutput) {
+	case constants.OutputFormatJSON:
+		if entries == nil {
+			entries = []*modcache.Entry{}
+		}
+		jsonOutput, err := json.MarshalIndent(entries, "", "  ")
+		error_helpers.FailOnError(err)
+		fmt.Println(string(jsonOutput)) //nolint:forbidigo // intended output
+	case constants.OutputFormatTable:
+		if len(entries) == 0 {
+			fmt.Println("No mods installed.") //nolint:forbidigo // intended output
+			return
+		}
+		showCacheEntries(entries)
+	default:
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, fmt.Errorf("invalid output format '%s' - must be one of: table, json", viper.GetString(constants.ArgOutput)))
+	}
+}
+
+func cacheGcCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Args:  cobra.NoArgs,
+		Run:   runCacheGcCmd,
+		Short: "Remove unused mod versions and the temporary directories of interrupted installs",
+		Long: `Remove installed mod versions which are not required by the workspace lock file, and the temporary directories
+of interrupted installs. Mod versions which are in use are never removed.
+
+Examples:
+
+  # Remove all unused mod versions
+  powerpipe cache gc
+
+  # Remove unused mod versions installed more than 30 days ago, and any others needed to keep the cache under 1GB
+  powerpipe cache gc --max-age 30d --max-size 1GB
+
+  # Show what would be removed, without removing anything
+  powerpipe cache gc --dry-run`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for cache gc", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddStringFlag(localconstants.ArgMaxAge, "0s", "Remove unused mod versions installed longer ago than this, e.g. '72h' or '30d'").
+		AddStringFlag(localconstants.ArgMaxSize, "", "Remove the oldest unused mod versions until the cache is no larger than this, e.g. '500MB'").
+		AddBoolFlag(constants.ArgDryRun, false, "Show what would be removed without removing it").
+		AddModLocationFlag()
+
+	return cmd
+}
+
+func runCacheGcCmd(cmd *cobra.Command, _ []string) {
+	ctx := cmd.Context()
+
+	policy, err := cacheGcPolicy()
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	workspacePath := viper.GetString(constants.ArgModLocation)
+	entries, err := modcache.Scan(workspacePath)
+	if err != nil {
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	removed := modcache.Collect(entries, policy, time.Now())
+	if len(removed) == 0 {
+		fmt.Println("Nothing to remove.") //nolint:forbidigo // intended output
+		return
+	}
+
+	verb := "Removed"
+	if viper.GetBool(constants.ArgDryRun) {
+		verb = "Would remove"
+	} else if err := modcache.Remove(workspacePath, removed); err != nil {
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	showCacheEntries(removed)
+	var freed int64
+	for _, e := range removed {
+		freed += e.Size
+	}
+	fmt.Printf("\n%s %d %s, freeing %s.\n", verb, len(removed), utils.Pluralize("directory", len(removed)), humanize.Bytes(uint64(freed))) //nolint:forbidigo // intended output
+}
+
+func cacheGcPolicy() (modcache.Policy, error) {
+	var policy modcache.Policy
+	maxAge, err := modcache.ParseAge(viper.GetString(localconstants.ArgMaxAge))
+	if err != nil {
+		return policy, fmt.Errorf("invalid value for '--%s': %s", localconstants.ArgMaxAge, err.Error())
+	}
+	policy.MaxAge = maxAge
+	if maxSize := viper.GetString(localconstants.ArgMaxSize); maxSize != "" {
+		size, err := humanize.ParseBytes(maxSize)
+		if err != nil {
+			return policy, fmt.Errorf("invalid value for '--%s': '%s' - must be a size, e.g. '500MB'", localconstants.ArgMaxSize, maxSize)
+		}
+		policy.MaxSize = int64(size)
+	}
+	return policy, nil
+}
+
+func showCacheEntries(entries []*modcache.Entry) {
+	headers := []string{"PATH", "KIND", "SIZE", "MODIFIED", "IN USE"}
+	var rows [][]string
+	var total int64
+	for _, e := range entries {
+		inUse := ""
+		if e.Kind == modcache.KindMod {
+			inUse = fmt.Sprintf("%t", e.InUse)
+		}
+		rows = append(rows, []string{e.Path, e.Kind, humanize.Bytes(uint64(e.Size)), e.ModTime.Format(time.RFC3339), inUse})
+		total += e.Size
+	}
+	rows = append(rows, []string{"TOTAL", "", humanize.Bytes(uint64(total)), "", ""})
+	display.ShowWrappedTable(headers, rows, nil)
+}
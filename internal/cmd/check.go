@@ -4,14 +4,17 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/thediveo/enumflag/v2"
-	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/app_specific"
 	"github.com/turbot/pipe-fittings/cmdconfig"
 	"github.com/turbot/pipe-fittings/constants"
@@ -20,14 +23,23 @@ import (
 	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/statushooks"
 	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/powerpipe/internal/batch"
 	localcmdconfig "github.com/turbot/powerpipe/internal/cmdconfig"
 	localconstants "github.com/turbot/powerpipe/internal/constants"
 	"github.com/turbot/powerpipe/internal/controldisplay"
 	"github.com/turbot/powerpipe/internal/controlexecute"
 	"github.com/turbot/powerpipe/internal/controlinit"
 	"github.com/turbot/powerpipe/internal/controlstatus"
+	"github.com/turbot/powerpipe/internal/crash"
 	"github.com/turbot/powerpipe/internal/display"
+	"github.com/turbot/powerpipe/internal/exitcodes"
+	"github.com/turbot/powerpipe/internal/publish"
 	localqueryresult "github.com/turbot/powerpipe/internal/queryresult"
+	"github.com/turbot/powerpipe/internal/routing"
+	"github.com/turbot/powerpipe/internal/ticketing"
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+	"github.com/turbot/powerpipe/internal/upload"
+	"github.com/turbot/powerpipe/internal/verbosity"
 	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
 )
 
@@ -81,12 +93,35 @@ func checkCmd[T controlinit.CheckTarget]() *cobra.Command {
 			constants.ArgOutput,
 			fmt.Sprintf("Output format; one of: %s", strings.Join(constants.FlagValues(localconstants.CheckOutputModeIds), ", "))).
 		AddStringFlag(constants.ArgSeparator, ",", "Separator string for csv output").
-		AddStringFlag(constants.ArgSnapshotLocation, "", "The location to write snapshots - either a local file path or a Turbot Pipes workspace").
+		AddStringFlag(localconstants.ArgHtmlTheme, controldisplay.HtmlThemeDefault, fmt.Sprintf("The theme of html output and exports; one of: %s", strings.Join(controldisplay.HtmlThemes, ", "))).
+		AddStringFlag(constants.ArgSnapshotLocation, "", "The location to write snapshots - either a local file path, a Turbot Pipes workspace, or a cloud storage url (s3://, gs:// or azblob://)").
 		AddStringFlag(constants.ArgSnapshotTitle, "", "The title to give a snapshot").
-		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported formats: csv, html, json, md, nunit3, pps (snapshot), asff").
+		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported formats: csv, html, json, md, nunit3, pps (snapshot), asff, remediation.md, badge.svg, junit, sarif, custom:<format> (custom exporter), email:<integration> (send the report by email); defaults to the default_export tag of the benchmark").
+		AddStringSliceFlag(localconstants.ArgPublish, nil, "Upload the exported files to these s3 integrations (comma-separated)").
+		AddIntFlag(localconstants.ArgUploadConcurrency, upload.DefaultConcurrency, "The maximum number of snapshots and exported files to upload concurrently").
+		AddIntFlag(localconstants.ArgUploadRetries, upload.DefaultRetries, "The number of times to retry a failed upload of a snapshot or exported file").
+		AddStringFlag(localconstants.ArgUploadBandwidth, "", "Limit the total bandwidth of the uploads of snapshots and exported files to this size per second, e.g. '10MB'").
 		AddStringSliceFlag(constants.ArgSearchPath, nil, "Set a custom search_path (comma-separated)").
 		AddStringSliceFlag(constants.ArgSearchPathPrefix, nil, "Set a prefix to the current search path (comma-separated)").
-		AddIntFlag(constants.ArgBenchmarkTimeout, 0, "Set the benchmark execution timeout")
+		AddStringArrayFlag(localconstants.ArgDatabaseSearchPath, nil, "Set a custom search_path for a database, in the form 'database=schema1,schema2'").
+		AddStringArrayFlag(localconstants.ArgDatabaseSearchPathPrefix, nil, "Set a prefix to the search path for a database, in the form 'database=schema1,schema2'").
+		AddStringArrayFlag(localconstants.ArgDatabaseAttach, nil, "Attach a database file to a DuckDB or SQLite database, in the form 'database=alias:path'").
+		AddStringArrayFlag(localconstants.ArgTerraformInput, nil, "Load a Terraform plan (the output of 'terraform show -json') or state file, and attach it to each DuckDB database, in the form 'alias:path' or 'path' (attached as 'terraform')").
+		AddStringArrayFlag(localconstants.ArgDatabaseOnConnect, nil, "Run the SQL in a file on each new connection to a database, in the form 'database=path'").
+		AddStringArrayFlag(localconstants.ArgDatabaseCredentialCmd, nil, "Run a command to get the current connection string of a database with rotated credentials, in the form 'database=path'").
+		AddBoolFlag(localconstants.ArgStrict, false, "Treat workspace warnings, and resources of the workspace mod with no description, as errors").
+		AddStringFlag(localconstants.ArgQueryRewriteConfig, "", "Path to a query rewrite config file, which remaps the schemas and tables of control and dashboard queries").
+		AddStringSliceFlag(localconstants.ArgDimension, nil, "Additional columns to include as control dimensions when present, e.g. 'region,tags.owner' (comma-separated)").
+		AddStringSliceFlag(localconstants.ArgFanOutConnections, nil, "Run each control once per Steampipe connection matching these names or globs, adding a 'connection' dimension to each result; aggregators are expanded to their connections (comma-separated)").
+		AddStringSliceFlag(localconstants.ArgTicketIntegration, nil, "Open, update and close tickets for alarm findings using these jira or servicenow integrations (comma-separated)").
+		AddIntFlag(constants.ArgBenchmarkTimeout, 0, "Set the benchmark execution timeout").
+		AddStringFlag(localconstants.ArgMaxDuration, "", "Abort the run if it takes longer than this duration, e.g. '30m', retaining the results returned so far").
+		AddIntFlag(localconstants.ArgMaxCostRows, 0, "Abort the run if the controls return more than this number of result rows, retaining the results returned so far").
+		AddIntFlag(localconstants.ArgMaxRowsPerControl, 0, "Store at most this number of result rows per control in output, snapshots and exports - the summary counts all rows").
+		AddBoolFlag(localconstants.ArgCaptureQueryPlans, false, "Record the query plan and timing of each control query in snapshot and json output").
+		AddIntFlag(localconstants.ArgSeed, 0, "Make the run deterministic, so repeated runs over identical data produce identical output and exports - results are ordered, and the run time is the seed (in seconds since the Unix epoch) with no durations").
+		AddBoolFlag(localconstants.ArgOnce, false, "Run as a one-shot batch job, e.g. a Kubernetes CronJob: no prompts, progress or colors, exports have deterministic file names, the run is logged to stderr as JSON lines, and SIGTERM cancels the run").
+		AddBoolFlag(localconstants.ArgExitAfterExport, false, fmt.Sprintf("Exit once the exports are written, with exit code 0 if they were written (whatever the control results), or %d if the run or its exports failed", exitcodes.ExitCodeExportFailed))
 
 	// for control command, add --arg
 	switch typeName {
@@ -96,7 +131,12 @@ func checkCmd[T controlinit.CheckTarget]() *cobra.Command {
 		builder.
 			AddStringFlag(constants.ArgWhere, "", "SQL 'where' clause, or named query, used to filter controls (cannot be used with '--tag')").
 			AddBoolFlag(constants.ArgDryRun, false, "Show which controls will be run without running them").
+			AddBoolFlag(localconstants.ArgPlan, false, "Show the execution plan, with durations estimated from previous runs, without running any controls").
 			AddStringSliceFlag(constants.ArgTag, nil, "Filter controls based on their tag values ('--tag key=value')").
+			AddStringSliceFlag(localconstants.ArgGroupBy, nil, "Group results in text, html and md output; any of: benchmark, severity, service, tag:<key> (comma-separated)").
+			AddStringSliceFlag(localconstants.ArgStatus, nil, "Only include results with these statuses in text, html and md output; any of: ok, alarm, info, skip, error (comma-separated)").
+			AddStringFlag(localconstants.ArgRoutingConfig, "", "Path to a routing config file, which sends alarm and error findings to notifiers based on their tags").
+			AddBoolFlag(localconstants.ArgDeduplicate, true, "Run controls shared by several benchmarks once, reusing their results in each benchmark").
 			AddIntFlag(constants.ArgMaxParallel, constants.DefaultMaxConnections, "The maximum number of concurrent database connections to open")
 	}
 
@@ -131,10 +171,14 @@ func runCheckCmd[T controlinit.CheckTarget](cmd *cobra.Command, args []string) {
 	ctx, cancel = context.WithCancel(cmd.Context())
 	contexthelpers.StartCancelHandler(cancel)
 
+	// in batch mode, log the exit code once it is set
+	defer func() {
+		batch.Log("run finished", "exit_code", exitCode, "class", exitcodes.Lookup(exitCode).Class)
+	}()
 	defer func() {
 		utils.LogTime("runCheckCmd end")
 		if r := recover(); r != nil {
-			error_helpers.ShowError(ctx, helpers.ToError(r))
+			_ = crash.Recover(ctx, r)
 			exitCode = constants.ExitCodeUnknownErrorPanic
 		}
 	}()
@@ -152,6 +196,36 @@ func runCheckCmd[T controlinit.CheckTarget](cmd *cobra.Command, args []string) {
 		return
 	}
 
+	if batch.Enabled() {
+		applyBatchMode(cancel)
+		batch.Log("run started", "targets", args)
+	}
+
+	routingConfig, err := loadRoutingConfig()
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	ticketIntegrations, err := loadTicketIntegrations()
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	publisher, err := publish.NewPublisher(viper.GetStringSlice(localconstants.ArgPublish))
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	uploadOptions, err := getUploadOptions()
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
 	// show the status spinner
 	statushooks.Show(ctx)
 
@@ -161,12 +235,24 @@ func runCheckCmd[T controlinit.CheckTarget](cmd *cobra.Command, args []string) {
 	// initialise
 	initData := controlinit.NewInitData[T](initCtx, cmd, args)
 	if initData.Result.Error != nil {
-		exitCode = constants.ExitCodeInitializationFailed
+		exitCode = exitcodes.FromError(initData.Result.Error, constants.ExitCodeInitializationFailed)
 		error_helpers.ShowError(ctx, initData.Result.Error)
 		return
 	}
 	defer initData.Cleanup(ctx)
 
+	// validate --publish now the exports are known - they may be the default exports of the target benchmarks
+	if len(viper.GetStringSlice(localconstants.ArgPublish)) > 0 && len(viper.GetStringSlice(constants.ArgExport)) == 0 {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, fmt.Errorf("'--%s' requires '--%s' - only exported files are published", localconstants.ArgPublish, constants.ArgExport))
+		return
+	}
+	if viper.GetBool(localconstants.ArgExitAfterExport) && len(viper.GetStringSlice(constants.ArgExport)) == 0 {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, fmt.Errorf("'--%s' requires '--%s'", localconstants.ArgExitAfterExport, constants.ArgExport))
+		return
+	}
+
 	// hide the spinner so that warning messages can be shown
 	statushooks.Done(ctx)
 
@@ -178,30 +264,67 @@ func runCheckCmd[T controlinit.CheckTarget](cmd *cobra.Command, args []string) {
 	trees, err := getExecutionTrees[T](ctx, initData)
 	error_helpers.FailOnError(err)
 
+	// if --plan is set, display the execution plan of each tree rather than executing it
+	if viper.GetBool(localconstants.ArgPlan) {
+		displayExecutionPlans(trees)
+		return
+	}
+
 	// pull out useful properties
 	totalAlarms, totalErrors := 0, 0
+	// whether every tree was run, and its exports written and published
+	exported := true
 	defer func() {
+		// with --exit-after-export, the exit code reports whether the exports were written
+		if viper.GetBool(localconstants.ArgExitAfterExport) {
+			exitCode = getExportExitCode(exported)
+			return
+		}
 		// set the defined exit code after successful execution
 		exitCode = getExitCode(totalAlarms, totalErrors)
 	}()
 
+	// snapshots and exports are uploaded as each tree completes, concurrently with the execution of the next tree
+	// (the uploads are waited for before the exit code is set)
+	uploads := upload.NewPipeline(ctx, uploadOptions)
+	defer func() {
+		statushooks.Show(ctx)
+		statushooks.SetStatus(ctx, "Waiting for uploads")
+		statuses := uploads.Wait()
+		statushooks.Done(ctx)
+		if !reportUploads(ctx, statuses) {
+			totalErrors++
+			exported = false
+		}
+	}()
+
 	for _, namedTree := range trees {
 		// execute controls synchronously (execute returns the number of alarms and errors)
 		err = executeTree(ctx, namedTree.tree, initData)
 		if err != nil {
 			totalErrors++
+			exported = false
 			error_helpers.ShowError(ctx, err)
 			return
 		}
+		logTreeCompleted(namedTree)
+
+		verbosity.Detailf("Executed %s: %d controls in %s", namedTree.name, namedTree.tree.Root.Summary.Status.TotalCount(), namedTree.tree.EndTime.Sub(namedTree.tree.StartTime).Round(time.Millisecond))
+
+		// record the control durations, used to estimate the duration of future runs
+		if !viper.GetBool(constants.ArgDryRun) {
+			recordControlHistory(namedTree.tree)
+		}
 
 		// append the total number of alarms and errors for multiple runs
 		totalAlarms = namedTree.tree.Root.Summary.Status.Alarm
 		totalErrors = namedTree.tree.Root.Summary.Status.Error
 
-		err = publishSnapshot(ctx, namedTree.tree, viper.GetBool(constants.ArgShare), viper.GetBool(constants.ArgSnapshot))
+		err = submitSnapshot(uploads, namedTree.tree, viper.GetBool(constants.ArgShare), viper.GetBool(constants.ArgSnapshot))
 		if err != nil {
 			error_helpers.ShowError(ctx, err)
 			totalErrors++
+			exported = false
 			return
 		}
 		if shouldPrintCheckTiming() {
@@ -214,10 +337,66 @@ func runCheckCmd[T controlinit.CheckTarget](cmd *cobra.Command, args []string) {
 		if err != nil {
 			error_helpers.ShowError(ctx, err)
 			totalErrors++
+			exported = false
+		}
+
+		if publisher != nil {
+			err = publishExports(ctx, publisher, uploads, namedTree, initData)
+			if err != nil {
+				error_helpers.ShowError(ctx, err)
+				totalErrors++
+				exported = false
+			}
+		}
+
+		if routingConfig != nil {
+			err = routeFindings(ctx, routingConfig, namedTree)
+			if err != nil {
+				error_helpers.ShowError(ctx, err)
+				totalErrors++
+			}
+		}
+
+		// do not sync tickets for an aborted run - tickets would be closed for findings which were not evaluated
+		if namedTree.tree.AbortReason != "" && len(ticketIntegrations) > 0 {
+			error_helpers.ShowWarning("not syncing tickets as the run was aborted")
+			continue
+		}
+		for _, integration := range ticketIntegrations {
+			err = syncTickets(ctx, integration, namedTree)
+			if err != nil {
+				error_helpers.ShowError(ctx, err)
+				totalErrors++
+			}
 		}
 	}
 }
 
+// displayExecutionPlans displays the execution plan of each tree, with control durations estimated from previous runs
+func displayExecutionPlans(trees []*namedExecutionTree) {
+	history, err := controlexecute.LoadControlHistory(controlexecute.ControlHistoryPath())
+	if err != nil {
+		error_helpers.ShowWarning(fmt.Sprintf("could not load control history - durations will not be estimated: %s", err.Error()))
+		history = controlexecute.NewControlHistory(controlexecute.ControlHistoryPath())
+	}
+	for _, namedTree := range trees {
+		fmt.Printf("Execution plan for %s\n\n%s\n", namedTree.name, controldisplay.RenderPlan(namedTree.tree.Plan(history))) //nolint:forbidigo // we want to print
+	}
+}
+
+// recordControlHistory records the durations of the controls of an executed tree in the control history
+func recordControlHistory(tree *controlexecute.ExecutionTree) {
+	history, err := controlexecute.LoadControlHistory(controlexecute.ControlHistoryPath())
+	if err != nil {
+		slog.Warn("could not load control history", "error", err)
+		return
+	}
+	history.Record(tree)
+	if err := history.Save(); err != nil {
+		slog.Warn("could not save control history", "error", err)
+	}
+}
+
 // exportExecutionTree relies on the fact that the given tree is already executed
 func exportExecutionTree[T controlinit.CheckTarget](ctx context.Context, namedTree *namedExecutionTree, initData *controlinit.InitData[T], exportArgs []string) error {
 	statushooks.Show(ctx)
@@ -240,6 +419,137 @@ func exportExecutionTree[T controlinit.CheckTarget](ctx context.Context, namedTr
 	return nil
 }
 
+// publishExports adds the upload of the files exported for the tree using the publisher to the upload pipeline
+func publishExports[T controlinit.CheckTarget](ctx context.Context, publisher *publish.Publisher, uploads *upload.Pipeline, namedTree *namedExecutionTree, initData *controlinit.InitData[T]) error {
+	// always flush the recorded files, so that they are not published with the exports of the next tree
+	artifacts := initData.ExportRecorder.Flush()
+	if error_helpers.IsContextCanceled(ctx) {
+		return ctx.Err()
+	}
+	return publisher.Submit(ctx, uploads, namedTree.name, artifacts)
+}
+
+// getUploadOptions returns the options of the upload pipeline set by the upload args
+func getUploadOptions() (upload.Options, error) {
+	options := upload.Options{
+		Concurrency: viper.GetInt(localconstants.ArgUploadConcurrency),
+		Retries:     viper.GetInt(localconstants.ArgUploadRetries),
+	}
+	if options.Concurrency < 1 {
+		return options, fmt.Errorf("invalid value for '--%s': must be at least 1", localconstants.ArgUploadConcurrency)
+	}
+	if options.Retries < 0 {
+		return options, fmt.Errorf("invalid value for '--%s': must not be negative", localconstants.ArgUploadRetries)
+	}
+	// (0 retries are set as a negative value, as 0 is the default of the options)
+	if options.Retries == 0 {
+		options.Retries = -1
+	}
+	if bandwidth := viper.GetString(localconstants.ArgUploadBandwidth); bandwidth != "" {
+		size, err := humanize.ParseBytes(bandwidth)
+		if err != nil || size == 0 {
+			return options, fmt.Errorf("invalid value for '--%s': '%s' - must be a size, e.g. '10MB'", localconstants.ArgUploadBandwidth, bandwidth)
+		}
+		options.BandwidthLimit = int64(size)
+	}
+	return options, nil
+}
+
+// reportUploads displays the status of each upload of the run, and shows the error of each failed upload, returning
+// whether all uploads succeeded
+// the locations of the uploads are the result of the command, so are displayed even if --quiet is set
+func reportUploads(ctx context.Context, statuses []*upload.Status) bool {
+	if len(statuses) == 0 {
+		return true
+	}
+	fmt.Println() //nolint:forbidigo // we want to print
+	headers := []string{"ARTIFACT", "STATUS", "LOCATION", "SIZE", "ATTEMPTS", "DURATION"}
+	var rows [][]string
+	for _, status := range statuses {
+		state := "uploaded"
+		if status.Error != nil {
+			state = "failed"
+		}
+		rows = append(rows, []string{status.Name, state, status.Location, humanize.Bytes(uint64(status.Size)), fmt.Sprintf("%d", status.Attempts), status.Duration.Round(time.Millisecond).String()})
+	}
+	display.ShowWrappedTable(headers, rows, nil)
+	failed := upload.Failed(statuses)
+	for _, status := range failed {
+		error_helpers.ShowError(ctx, status.Error)
+	}
+	return len(failed) == 0
+}
+
+// loadRoutingConfig loads the routing config, if one is set
+func loadRoutingConfig() (*routing.Config, error) {
+	configPath := viper.GetString(localconstants.ArgRoutingConfig)
+	if configPath == "" {
+		return nil, nil
+	}
+	return routing.LoadConfig(configPath)
+}
+
+// routeFindings sends the alarm and error findings of the tree to the notifiers of the routes they match,
+// and warns of any findings which match no route
+func routeFindings(ctx context.Context, config *routing.Config, namedTree *namedExecutionTree) error {
+	if error_helpers.IsContextCanceled(ctx) {
+		return ctx.Err()
+	}
+
+	result := config.Route(namedTree.tree)
+	statushooks.Show(ctx)
+	statushooks.SetStatus(ctx, "Sending findings to notifiers")
+	err := config.Notify(ctx, tlspolicy.HTTPClient(), namedTree.name, result)
+	statushooks.Done(ctx)
+
+	if report := result.UnroutedReport(); report != "" {
+		error_helpers.ShowWarning(report)
+	}
+	return err
+}
+
+// loadTicketIntegrations loads the ticketing integrations set by --ticket-integration from the workspace config
+func loadTicketIntegrations() ([]*ticketing.Integration, error) {
+	names := viper.GetStringSlice(localconstants.ArgTicketIntegration)
+	if len(names) == 0 {
+		return nil, nil
+	}
+	configPaths, err := cmdconfig.GetConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	integrations, err := ticketing.LoadIntegrations(configPaths)
+	if err != nil {
+		return nil, err
+	}
+	var res []*ticketing.Integration
+	for _, name := range names {
+		integration, ok := integrations[name]
+		if !ok {
+			return nil, fmt.Errorf("ticket integration '%s' not found - integrations must be defined in the workspace config", name)
+		}
+		res = append(res, integration)
+	}
+	return res, nil
+}
+
+// syncTickets opens, updates and closes the tickets of an integration, to match the alarm findings of the tree
+func syncTickets(ctx context.Context, integration *ticketing.Integration, namedTree *namedExecutionTree) error {
+	if error_helpers.IsContextCanceled(ctx) {
+		return ctx.Err()
+	}
+
+	statushooks.Show(ctx)
+	statushooks.SetStatus(ctx, fmt.Sprintf("Syncing %s tickets", integration.Name))
+	result, err := ticketing.Sync(ctx, integration.Tracker(), namedTree.tree)
+	statushooks.Done(ctx)
+
+	if viper.GetBool(constants.ArgProgress) {
+		fmt.Printf("\nTickets (%s): %s\n", integration.Name, result) //nolint:forbidigo // we want to print
+	}
+	return err
+}
+
 // executeTree executes and displays the (table) results of an execution
 func executeTree[T controlinit.CheckTarget](ctx context.Context, tree *controlexecute.ExecutionTree, initData *controlinit.InitData[T]) error {
 	// create a context with check status hooks
@@ -265,19 +575,21 @@ func executeTree[T controlinit.CheckTarget](ctx context.Context, tree *controlex
 	if err != nil {
 		return err
 	}
+
+	// if the run exceeded its budget, warn that the results are incomplete
+	// (the partial results are still exported)
+	if incomplete := tree.IncompleteMessage(); incomplete != "" {
+		error_helpers.ShowWarning(incomplete)
+	}
 	return nil
 }
 
-func publishSnapshot(ctx context.Context, executionTree *controlexecute.ExecutionTree, shouldShare bool, shouldUpload bool) error {
-	if error_helpers.IsContextCanceled(ctx) {
-		return ctx.Err()
+// submitSnapshot adds the upload of the snapshot of the tree to the upload pipeline, if the share args are set
+func submitSnapshot(uploads *upload.Pipeline, executionTree *controlexecute.ExecutionTree, shouldShare bool, shouldUpload bool) error {
+	if !(shouldShare || shouldUpload) {
+		return nil
 	}
-	// if the share args are set, create a snapshot and share it
-	if shouldShare || shouldUpload {
-		statushooks.SetStatus(ctx, "Publishing snapshot")
-		return controldisplay.PublishSnapshot(ctx, executionTree, shouldShare)
-	}
-	return nil
+	return controldisplay.SubmitSnapshot(uploads, executionTree, shouldShare)
 }
 
 func getExecutionTrees[T controlinit.CheckTarget](ctx context.Context, initData *controlinit.InitData[T]) ([]*namedExecutionTree, error) {
@@ -296,6 +608,11 @@ func getExecutionTrees[T controlinit.CheckTarget](ctx context.Context, initData
 		trees = append(trees, newNamedExecutionTree(name, executionTree))
 	} else {
 		// otherwise return multiple trees
+		// controls shared by the targets are run once, unless deduplication is disabled
+		var resultCache *controlexecute.ResultCache
+		if viper.GetBool(localconstants.ArgDeduplicate) {
+			resultCache = controlexecute.NewResultCache()
+		}
 		for _, target := range initData.Targets {
 			if error_helpers.IsContextCanceled(ctx) {
 				return nil, ctx.Err()
@@ -304,6 +621,7 @@ func getExecutionTrees[T controlinit.CheckTarget](ctx context.Context, initData
 			if err != nil {
 				return nil, sperr.WrapWithMessage(err, "could not create execution tree for %s", target)
 			}
+			executionTree.SetResultCache(resultCache)
 
 			trees = append(trees, newNamedExecutionTree(target.Name(), executionTree))
 		}
@@ -312,6 +630,14 @@ func getExecutionTrees[T controlinit.CheckTarget](ctx context.Context, initData
 	return trees, ctx.Err()
 }
 
+// get the exit code of a run with --exit-after-export - the control results do not affect the exit code
+func getExportExitCode(exported bool) int {
+	if !exported {
+		return exitcodes.ExitCodeExportFailed
+	}
+	return constants.ExitCodeSuccessful
+}
+
 // get the exit code for successful check run
 func getExitCode(alarms int, errors int) int {
 	// 1 or more control errors, return exitCode=2
@@ -326,6 +652,42 @@ func getExitCode(alarms int, errors int) int {
 	return constants.ExitCodeSuccessful
 }
 
+// applyBatchMode disables prompts, progress and colors for a batch run, and cancels the run on SIGTERM as well as
+// SIGINT (a pod is sent SIGTERM when it is deleted, or its job exceeds its deadline)
+func applyBatchMode(cancel context.CancelFunc) {
+	viper.Set(constants.ArgInput, false)
+	viper.Set(constants.ArgProgress, false)
+	viper.Set(constants.ConfigKeyIsTerminalTTY, false)
+
+	sigTermChannel := make(chan os.Signal, 1)
+	signal.Notify(sigTermChannel, syscall.SIGTERM)
+	go func() {
+		<-sigTermChannel
+		batch.Log("run cancelled", "signal", "SIGTERM")
+		cancel()
+	}()
+}
+
+// logTreeCompleted logs the results of an executed tree in batch mode
+func logTreeCompleted(namedTree *namedExecutionTree) {
+	tree := namedTree.tree
+	status := tree.Root.Summary.Status
+	args := []any{
+		"name", namedTree.name,
+		"controls", len(tree.ControlRuns),
+		"ok", status.Ok,
+		"alarm", status.Alarm,
+		"error", status.Error,
+		"info", status.Info,
+		"skip", status.Skip,
+		"duration", tree.EndTime.Sub(tree.StartTime).Round(time.Millisecond).String(),
+	}
+	if tree.AbortReason != "" {
+		args = append(args, "abort_reason", tree.AbortReason)
+	}
+	batch.Log("run completed", args...)
+}
+
 // create the context for the check run - add a control status renderer
 func createCheckContext(ctx context.Context) (context.Context, context.CancelFunc) {
 	var cancel context.CancelFunc
@@ -350,11 +712,27 @@ func validateCheckArgs(ctx context.Context) error {
 		return fmt.Errorf("only one of --search-path or --search-path-prefix may be set")
 	}
 
+	if maxDuration := viper.GetString(localconstants.ArgMaxDuration); maxDuration != "" {
+		if d, err := time.ParseDuration(maxDuration); err != nil || d <= 0 {
+			return fmt.Errorf("invalid value for '--%s': '%s' - must be a positive duration, e.g. '30m'", localconstants.ArgMaxDuration, maxDuration)
+		}
+	}
+	if viper.GetInt(localconstants.ArgMaxCostRows) < 0 {
+		return fmt.Errorf("'--%s' must not be negative", localconstants.ArgMaxCostRows)
+	}
+	if viper.GetInt(localconstants.ArgMaxRowsPerControl) < 0 {
+		return fmt.Errorf("'--%s' must not be negative", localconstants.ArgMaxRowsPerControl)
+	}
+
 	// only 1 character is allowed for '--separator'
 	if len(viper.GetString(constants.ArgSeparator)) > 1 {
 		return fmt.Errorf("'--%s' can be 1 character long at most", constants.ArgSeparator)
 	}
 
+	if err := controldisplay.ValidateHtmlTheme(viper.GetString(localconstants.ArgHtmlTheme)); err != nil {
+		return err
+	}
+
 	// only 1 of 'share' and 'snapshot' may be set
 	if viper.GetBool(constants.ArgShare) && viper.GetBool(constants.ArgSnapshot) {
 		return fmt.Errorf("only 1 of '--%s' and '--%s' may be set", constants.ArgShare, constants.ArgSnapshot)
@@ -365,6 +743,11 @@ func validateCheckArgs(ctx context.Context) error {
 		return fmt.Errorf("only 1 of '--%s' and '--%s' may be set", constants.ArgWhere, constants.ArgTag)
 	}
 
+	// validate '--group-by' and '--status'
+	if _, err := controlexecute.ConfiguredView(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
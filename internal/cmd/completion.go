@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/powerpipe/internal/completion"
+)
+
+var completionShells = []string{"bash", "zsh", "fish", "nushell"}
+
+func completionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:       "completion [bash|zsh|fish|nushell]",
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: completionShells,
+		Run:       runCompletionCmd,
+		Short:     "Generate the autocompletion script for the specified shell",
+		Long: `Generate the autocompletion script for the specified shell.
+
+Commands, resource names and flag values are completed - including output and export formats,
+workspace profile names and the databases of the workspace profiles.
+
+Examples:
+
+  # Load completions in the current bash session
+  source <(powerpipe completion bash)
+
+  # Load completions for every zsh session
+  powerpipe completion zsh > "${fpath[1]}/_powerpipe"
+
+  # Load completions for every fish session
+  powerpipe completion fish > ~/.config/fish/completions/powerpipe.fish
+
+  # Load completions for every nushell session (then source the file from config.nu)
+  powerpipe completion nushell | save -f ~/.config/nushell/powerpipe_completion.nu`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for completion", cmdconfig.FlagOptions.WithShortHand("h"))
+
+	return cmd
+}
+
+func runCompletionCmd(cmd *cobra.Command, args []string) {
+	root := cmd.Root()
+	var err error
+	switch args[0] {
+	case "bash":
+		err = root.GenBashCompletionV2(os.Stdout, true)
+	case "zsh":
+		err = root.GenZshCompletion(os.Stdout)
+	case "fish":
+		err = root.GenFishCompletion(os.Stdout, true)
+	case "nushell":
+		fmt.Print(completion.NushellCompletion(root.Name())) //nolint:forbidigo // intended output
+	}
+	if err != nil {
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(cmd.Context(), err)
+	}
+}
@@ -3,17 +3,15 @@ package cmd
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/thediveo/enumflag/v2"
-	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/app_specific"
-	"github.com/turbot/pipe-fittings/cloud"
 	"github.com/turbot/pipe-fittings/cmdconfig"
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/contexthelpers"
@@ -23,12 +21,19 @@ import (
 	"github.com/turbot/pipe-fittings/schema"
 	"github.com/turbot/pipe-fittings/statushooks"
 	"github.com/turbot/pipe-fittings/steampipeconfig"
-	"github.com/turbot/pipe-fittings/workspace"
 	localcmdconfig "github.com/turbot/powerpipe/internal/cmdconfig"
 	localconstants "github.com/turbot/powerpipe/internal/constants"
 	"github.com/turbot/powerpipe/internal/controlstatus"
+	"github.com/turbot/powerpipe/internal/crash"
+	"github.com/turbot/powerpipe/internal/customexport"
 	"github.com/turbot/powerpipe/internal/dashboardexecute"
+	"github.com/turbot/powerpipe/internal/email"
+	"github.com/turbot/powerpipe/internal/exitcodes"
 	"github.com/turbot/powerpipe/internal/initialisation"
+	"github.com/turbot/powerpipe/internal/publish"
+	"github.com/turbot/powerpipe/internal/report"
+	"github.com/turbot/powerpipe/internal/snapshotdest"
+	"github.com/turbot/powerpipe/internal/verbosity"
 	"github.com/turbot/steampipe-plugin-sdk/v5/logging"
 )
 
@@ -54,12 +59,15 @@ The current mod is the working directory, or the directory specified by the --mo
 		AddCloudFlags().
 		AddModLocationFlag().
 		AddStringArrayFlag(constants.ArgArg, nil, "Specify the value of a dashboard argument").
-		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported format: pps (snapshot)").
+		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported formats: pps (snapshot), html (report), pdf (report), custom:<format> (custom exporter), email:<integration> (send the report by email)").
+		AddStringSliceFlag(localconstants.ArgPublish, nil, "Upload the exported files to these s3 integrations (comma-separated)").
 		AddStringFlag(constants.ArgDatabase, app_specific.DefaultDatabase, "Turbot Pipes workspace database").
 		AddIntFlag(constants.ArgDatabaseQueryTimeout, localconstants.DatabaseDefaultQueryTimeout, "The query timeout").
 		AddBoolFlag(constants.ArgHelp, false, "Help for dashboard", cmdconfig.FlagOptions.WithShortHand("h")).
 		AddBoolFlag(constants.ArgInput, true, "Enable interactive prompts").
 		AddIntFlag(constants.ArgMaxParallel, constants.DefaultMaxConnections, "The maximum number of concurrent database connections to open").
+		AddBoolFlag(localconstants.ArgAllowInputCommands, false, "Allow inputs to source their options by running the local command of their 'options_command' tag").
+		AddIntFlag(localconstants.ArgDashboardConcurrency, 0, "The maximum number of panel queries of the dashboard to execute at once; further panels are queued (0 for no limit)").
 		AddBoolFlag(constants.ArgModInstall, true, "Specify whether to install mod dependencies before running the dashboard").
 		AddVarFlag(enumflag.New(&updateStrategy, constants.ArgPull, constants.ModUpdateStrategyIds, enumflag.EnumCaseInsensitive),
 			constants.ArgPull,
@@ -70,18 +78,27 @@ The current mod is the working directory, or the directory specified by the --mo
 		AddBoolFlag(constants.ArgProgress, true, "Display dashboard execution progress respected when a dashboard name argument is passed").
 		AddStringSliceFlag(constants.ArgSearchPath, nil, "Set a custom search_path for the steampipe user for a dashboard session (comma-separated)").
 		AddStringSliceFlag(constants.ArgSearchPathPrefix, nil, "Set a prefix to the current search path for a dashboard session (comma-separated)").
+		AddStringArrayFlag(localconstants.ArgDatabaseSearchPath, nil, "Set a custom search_path for a database, in the form 'database=schema1,schema2'").
+		AddStringArrayFlag(localconstants.ArgDatabaseSearchPathPrefix, nil, "Set a prefix to the search path for a database, in the form 'database=schema1,schema2'").
+		AddStringArrayFlag(localconstants.ArgDatabaseAttach, nil, "Attach a database file to a DuckDB or SQLite database, in the form 'database=alias:path'").
+		AddStringArrayFlag(localconstants.ArgTerraformInput, nil, "Load a Terraform plan (the output of 'terraform show -json') or state file, and attach it to each DuckDB database, in the form 'alias:path' or 'path' (attached as 'terraform')").
+		AddStringArrayFlag(localconstants.ArgDatabaseOnConnect, nil, "Run the SQL in a file on each new connection to a database, in the form 'database=path'").
+		AddStringArrayFlag(localconstants.ArgDatabaseCredentialCmd, nil, "Run a command to get the current connection string of a database with rotated credentials, in the form 'database=path'").
+		AddBoolFlag(localconstants.ArgStrict, false, "Treat workspace warnings, and resources of the workspace mod with no description, as errors").
+		AddStringFlag(localconstants.ArgQueryRewriteConfig, "", "Path to a query rewrite config file, which remaps the schemas and tables of control and dashboard queries").
 		AddBoolFlag(constants.ArgSnapshot, false, "Create snapshot in Turbot Pipes with the default (workspace) visibility").
 		AddBoolFlag(constants.ArgShare, false, "Create snapshot in Turbot Pipes with 'anyone_with_link' visibility").
 		AddStringFlag(constants.ArgSnapshotTitle, "", "The title to give a snapshot").
 		// NOTE: use StringArrayFlag for ArgDashboardInput, not StringSliceFlag
 		// Cobra will interpret values passed to a StringSliceFlag as CSV, where args passed to StringArrayFlag are not parsed and used raw
 		AddStringArrayFlag(constants.ArgSnapshotTag, nil, "Specify tags to set on the snapshot").
-		AddStringFlag(constants.ArgSnapshotLocation, "", "The location to write snapshots - either a local file path or a Turbot Pipes workspace").
+		AddStringFlag(constants.ArgSnapshotLocation, "", "The location to write snapshots - either a local file path, a Turbot Pipes workspace, or a cloud storage url (s3://, gs:// or azblob://)").
 		// NOTE: use StringArrayFlag for ArgVariable, not StringSliceFlag
 		// Cobra will interpret values passed to a StringSliceFlag as CSV, where args passed to StringArrayFlag are not parsed and used raw
 		AddStringArrayFlag(constants.ArgVariable, nil, "Specify the value of a variable").
 		AddStringSliceFlag(constants.ArgVarFile, nil, "Specify an .ppvar file containing variable values").
-		AddIntFlag(constants.ArgDashboardTimeout, 0, "Set the dashboard execution timeout")
+		AddIntFlag(constants.ArgDashboardTimeout, 0, "Set the dashboard execution timeout").
+		AddBoolFlag(constants.ArgWatch, false, "Re-run the dashboard on its refresh_interval until cancelled")
 
 	return cmd
 }
@@ -97,9 +114,7 @@ func dashboardRun(cmd *cobra.Command, args []string) {
 	defer func() {
 		logging.LogTime("dashboardRun end")
 		if r := recover(); r != nil {
-			err = helpers.ToError(r)
-			error_helpers.ShowError(ctx, err)
-
+			err = crash.Recover(ctx, r)
 		}
 		setExitCodeForDashboardError(err)
 	}()
@@ -113,6 +128,9 @@ func dashboardRun(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	publisher, err := publish.NewPublisher(viper.GetStringSlice(localconstants.ArgPublish))
+	error_helpers.FailOnError(err)
+
 	inputs, err := collectInputs()
 	error_helpers.FailOnError(err)
 
@@ -123,7 +141,9 @@ func dashboardRun(cmd *cobra.Command, args []string) {
 	initData := initialisation.NewInitData[*modconfig.Dashboard](ctx, cmd, dashboardName)
 
 	if len(viper.GetStringSlice(constants.ArgExport)) > 0 {
-		err := initData.RegisterExporters(dashboardExporters()...)
+		exporters, err := dashboardExporters()
+		error_helpers.FailOnError(err)
+		err = initData.RegisterExporters(exporters...)
 		error_helpers.FailOnError(err)
 
 		// validate required export formats
@@ -143,8 +163,22 @@ func dashboardRun(cmd *cobra.Command, args []string) {
 	// so a dashboard name was specified - just call GenerateSnapshot
 	target, err := initData.GetSingleTarget()
 	error_helpers.FailOnError(err)
+
+	// if --watch is set, re-run the dashboard on its refresh interval until cancelled
+	if viper.GetBool(constants.ArgWatch) {
+		watchDashboard(ctx, initData, target, inputs, publisher)
+		return
+	}
+	runDashboard(ctx, initData, target, inputs, publisher)
+}
+
+// runDashboard generates a snapshot for the target, then displays, publishes and exports it
+// (the exports are uploaded by the publisher, if set)
+func runDashboard(ctx context.Context, initData *initialisation.InitData[*modconfig.Dashboard], target modconfig.ModTreeItem, inputs map[string]any, publisher *publish.Publisher) {
+	startTime := time.Now()
 	snap, err := dashboardexecute.GenerateSnapshot(ctx, initData.WorkspaceEvents, target, inputs)
 	error_helpers.FailOnError(err)
+	verbosity.Detailf("Executed %s in %s", target.Name(), time.Since(startTime).Round(time.Millisecond))
 	// display the snapshot result (if needed)
 	displaySnapshot(snap)
 
@@ -160,6 +194,13 @@ func dashboardRun(cmd *cobra.Command, args []string) {
 	exportMsg, err := initData.ExportManager.DoExport(ctx, snap.FileNameRoot, snap, exportArgs)
 	error_helpers.FailOnErrorWithMessage(err, "failed to export snapshot")
 
+	// upload the exported files (if needed)
+	if publisher != nil {
+		publishMsg, err := publisher.Publish(ctx, snap.FileNameRoot, initData.ExportRecorder.Flush())
+		exportMsg = append(exportMsg, publishMsg...)
+		error_helpers.FailOnErrorWithMessage(err, "failed to publish exports")
+	}
+
 	// print the location where the file is exported
 	if len(exportMsg) > 0 && viper.GetBool(constants.ArgProgress) {
 		//nolint:forbidigo // Intentional UI output
@@ -167,6 +208,29 @@ func dashboardRun(cmd *cobra.Command, args []string) {
 	}
 }
 
+// watchDashboard runs the dashboard on its refresh interval until the command is cancelled
+func watchDashboard(ctx context.Context, initData *initialisation.InitData[*modconfig.Dashboard], target modconfig.ModTreeItem, inputs map[string]any, publisher *publish.Publisher) {
+	interval, err := dashboardexecute.GetWatchInterval(target)
+	error_helpers.FailOnError(err)
+	if interval == 0 {
+		error_helpers.FailOnError(fmt.Errorf("cannot watch %s - neither the dashboard nor its panels have a '%s' tag", target.Name(), dashboardexecute.TagRefreshInterval))
+	}
+
+	for {
+		runDashboard(ctx, initData, target, inputs, publisher)
+		if viper.GetBool(constants.ArgProgress) {
+			//nolint:forbidigo // Intentional UI output
+			fmt.Printf("\nRan %s at %s - next run in %s\n", target.Name(), time.Now().Format(time.TimeOnly), interval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
 // validate the args and extract a dashboard name, if provided
 func validateDashboardArgs(ctx context.Context) error {
 	err := localcmdconfig.ValidateSnapshotArgs(ctx)
@@ -185,6 +249,10 @@ func validateDashboardArgs(ctx context.Context) error {
 		return fmt.Errorf("only one of --share or --snapshot may be set")
 	}
 
+	if len(viper.GetStringSlice(localconstants.ArgPublish)) > 0 && len(viper.GetStringSlice(constants.ArgExport)) == 0 {
+		return fmt.Errorf("'--%s' requires '--%s' - only exported files are published", localconstants.ArgPublish, constants.ArgExport)
+	}
+
 	return nil
 }
 
@@ -199,8 +267,22 @@ func displaySnapshot(snapshot *steampipeconfig.SteampipeSnapshot) {
 	}
 }
 
-func dashboardExporters() []export.Exporter {
-	return []export.Exporter{&export.SnapshotExporter{}}
+func dashboardExporters() ([]export.Exporter, error) {
+	exportArgs := viper.GetStringSlice(constants.ArgExport)
+	// add any custom exporters used by the export args
+	customExporters, err := customexport.GetExporters(exportArgs, customexport.SnapshotJson)
+	if err != nil {
+		return nil, err
+	}
+	exporters := []export.Exporter{&export.SnapshotExporter{}, &report.HtmlExporter{}, &report.PdfExporter{}}
+	exporters = append(exporters, customExporters...)
+
+	// email exporters send the html report, with exports of the other formats (e.g. pdf) attached
+	emailExporters, err := email.GetExporters(exportArgs, exporters)
+	if err != nil {
+		return nil, err
+	}
+	return append(exporters, emailExporters...), nil
 }
 
 func publishSnapshotIfNeeded(ctx context.Context, snapshot *steampipeconfig.SteampipeSnapshot) error {
@@ -211,12 +293,13 @@ func publishSnapshotIfNeeded(ctx context.Context, snapshot *steampipeconfig.Stea
 		return nil
 	}
 
-	message, err := cloud.PublishSnapshot(ctx, snapshot, shouldShare)
+	message, err := snapshotdest.PublishSnapshot(ctx, snapshot, shouldShare)
 	if err != nil {
 		// reword "402 Payment Required" error
 		return handlePublishSnapshotError(err)
 	}
-	if viper.GetBool(constants.ArgProgress) {
+	// the location of the snapshot is the result of the command, so is displayed even if --quiet is set
+	if viper.GetBool(constants.ArgProgress) || verbosity.Quiet() {
 		//nolint:forbidigo // Intentional UI output
 		fmt.Println(message)
 	}
@@ -236,28 +319,29 @@ func setExitCodeForDashboardError(err error) {
 		return
 	}
 
-	if errors.Is(err, workspace.ErrorNoModDefinition) {
-		exitCode = constants.ExitCodeNoModFile
-	} else {
-		exitCode = constants.ExitCodeUnknownErrorPanic
-	}
+	exitCode = exitcodes.FromError(err, constants.ExitCodeUnknownErrorPanic)
 }
 
 // gather the arg values provided with the --args flag
 func collectInputs() (map[string]interface{}, error) {
+	return parseInputArgs(constants.ArgArg)
+}
+
+// parseInputArgs parses the name=value input values passed using the given flag
+func parseInputArgs(argName string) (map[string]interface{}, error) {
 	res := make(map[string]interface{})
-	inputArgs := viper.GetStringSlice(constants.ArgArg)
+	inputArgs := viper.GetStringSlice(argName)
 	for _, variableArg := range inputArgs {
 		// Value should be in the form "name=value", where value is a string
 		raw := variableArg
 		eq := strings.Index(raw, "=")
 		if eq == -1 {
-			return nil, fmt.Errorf("the --arg argument '%s' is not correctly specified. It must be an input name and value separated an equals sign: --arg key=value", raw)
+			return nil, fmt.Errorf("the --%s argument '%s' is not correctly specified. It must be an input name and value separated an equals sign: --%s key=value", argName, raw, argName)
 		}
 		name := raw[:eq]
 		rawVal := raw[eq+1:]
 		if _, ok := res[name]; ok {
-			return nil, fmt.Errorf("the arg option '%s' is provided more than once", name)
+			return nil, fmt.Errorf("the %s option '%s' is provided more than once", argName, name)
 		}
 		// add `input. to start of name
 		key := name
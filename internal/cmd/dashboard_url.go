@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/parse"
+	"github.com/turbot/pipe-fittings/schema"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/dashboardserver"
+)
+
+func dashboardUrlCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "url [flags] [dashboard]",
+		Args:  cobra.ExactArgs(1),
+		Run:   runDashboardUrlCmd,
+		Short: "Print a shareable URL for a dashboard",
+		Long: `Print a URL which opens a dashboard on a running Powerpipe server with the given input values.
+
+The dashboard may be a fully qualified name, or the short name of a dashboard in the current mod.
+
+Examples:
+
+  # Print a URL for a dashboard with an input value set
+  powerpipe dashboard url aws_insights.dashboard.vpc_detail --input vpc_id=vpc-1234
+
+  # Print a URL for a dashboard on another server
+  powerpipe dashboard url vpc_detail --input vpc_id=vpc-1234 --host powerpipe.example.com --port 80`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddModLocationFlag().
+		AddBoolFlag(constants.ArgHelp, false, "Help for dashboard url", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddStringArrayFlag(localconstants.ArgDashboardInput, nil, "Specify the value of a dashboard input, in the form name=value").
+		AddStringFlag(constants.ArgHost, "localhost", "Host of the Powerpipe server").
+		AddIntFlag(constants.ArgPort, dashboardserver.DashboardServerDefaultPort, "Port of the Powerpipe server")
+
+	return cmd
+}
+
+func runDashboardUrlCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+
+	dashboardName, err := qualifyDashboardName(args[0])
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	inputs, err := parseInputArgs(localconstants.ArgDashboardInput)
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	dashboardUrl := buildDashboardUrl(viper.GetString(constants.ArgHost), viper.GetInt(constants.ArgPort), dashboardName, inputs)
+	fmt.Println(dashboardUrl) //nolint:forbidigo // intended output
+}
+
+// qualifyDashboardName returns the full name of the dashboard
+// - short names (and names of the form dashboard.<name>) are resolved against the mod in the mod location
+func qualifyDashboardName(name string) (string, error) {
+	parts := strings.Split(name, ".")
+	switch len(parts) {
+	case 1:
+		name = fmt.Sprintf("%s.%s", schema.BlockTypeDashboard, name)
+	case 2, 3:
+		if parts[len(parts)-2] != schema.BlockTypeDashboard {
+			return "", fmt.Errorf("'%s' is not a dashboard name", name)
+		}
+		if len(parts) == 3 {
+			return name, nil
+		}
+	default:
+		return "", fmt.Errorf("'%s' is not a dashboard name", name)
+	}
+
+	workspaceMod, err := parse.LoadModfile(viper.GetString(constants.ArgModLocation))
+	if err != nil {
+		return "", fmt.Errorf("failed to load mod definition: %w", err)
+	}
+	if workspaceMod == nil {
+		return "", fmt.Errorf("no mod found in '%s' - specify the fully qualified dashboard name", viper.GetString(constants.ArgModLocation))
+	}
+	return fmt.Sprintf("%s.%s", workspaceMod.ShortName, name), nil
+}
+
+// buildDashboardUrl returns the URL of the dashboard on the server - the dashboard UI reads
+// input values from query parameters of the form input.<name>=<value>
+func buildDashboardUrl(host string, port int, dashboardName string, inputs map[string]any) string {
+	query := url.Values{}
+	for name, value := range inputs {
+		query.Set(name, fmt.Sprintf("%v", value))
+	}
+	dashboardUrl := url.URL{
+		Scheme:   "http",
+		Host:     fmt.Sprintf("%s:%d", host, port),
+		Path:     "/" + dashboardName,
+		RawQuery: query.Encode(),
+	}
+	return dashboardUrl.String()
+}
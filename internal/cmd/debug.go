@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/dashboardserver"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+func debugCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "debug [command]",
+		Args:  cobra.NoArgs,
+		Short: "Tools for reproducing and diagnosing issues",
+		Long: `Tools for reproducing and diagnosing issues.
+
+To reproduce an issue with the dashboard UI or server, run 'powerpipe server --record-session <file>', reproduce
+the issue in the browser, then replay the recording against a server running the same mod using
+'powerpipe debug replay <file>'.`,
+	}
+	cmd.AddCommand(debugReplayCmd())
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for debug", cmdconfig.FlagOptions.WithShortHand("h"))
+
+	return cmd
+}
+
+func debugReplayCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay <file>",
+		Args:  cobra.ExactArgs(1),
+		Run:   runDebugReplayCmd,
+		Short: "Replay a recorded dashboard session against a dashboard server",
+		Long: `Replay a recorded dashboard session against a dashboard server.
+
+The messages sent by each recorded dashboard client are resent to the server in order, using the recorded timing
+(scaled by --speed). Once complete, the actions the server sent to each replayed session are compared with those
+sent to the recorded session.
+
+To capture the replayed messages, start the server being replayed against with --record-session.`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for debug replay", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddStringFlag(localconstants.ArgReplayURL, fmt.Sprintf("ws://localhost:%d/ws", dashboardserver.DashboardServerDefaultPort), "Websocket URL of the dashboard server").
+		AddStringFlag(localconstants.ArgReplaySpeed, "1", "Speed multiplier of the recorded timing, e.g. 2 replays twice as fast; 0 sends all messages without delay").
+		AddStringFlag(localconstants.ArgReplayWait, "5s", "Time to wait for the responses to the last message")
+
+	return cmd
+}
+
+func runDebugReplayCmd(cmd *cobra.Command, args []string) {
+	ctx, stopFn := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stopFn()
+
+	opts, err := replayOptions()
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	recording, err := dashboardserver.LoadRecording(args[0])
+	if err != nil {
+		exitCode = constants.ExitCodeFileSystemAccessFailure
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	results, err := dashboardserver.Replay(ctx, recording, opts)
+	if err != nil {
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	for i, r := range results {
+		fmt.Printf("Session %d (recorded as %s): sent %d messages\n", i+1, r.SessionId, r.Sent) //nolint:forbidigo // intended output
+		fmt.Printf("  %-30s %10s %10s\n", "ACTION", "RECORDED", "REPLAYED")                     //nolint:forbidigo // intended output
+		for _, action := range r.Actions() {
+			marker := ""
+			if r.Recorded[action] != r.Replayed[action] {
+				marker = " *"
+			}
+			fmt.Printf("  %-30s %10d %10d%s\n", action, r.Recorded[action], r.Replayed[action], marker) //nolint:forbidigo // intended output
+		}
+		if !r.Matches() {
+			fmt.Println("  * the server sent a different number of messages to the replayed session") //nolint:forbidigo // intended output
+		}
+	}
+}
+
+// build the replay options from the args
+func replayOptions() (dashboardserver.ReplayOptions, error) {
+	speed, err := strconv.ParseFloat(viper.GetString(localconstants.ArgReplaySpeed), 64)
+	if err != nil || speed < 0 {
+		return dashboardserver.ReplayOptions{}, sperr.New("invalid value for '--%s': '%s' - must be a number, 0 or greater", localconstants.ArgReplaySpeed, viper.GetString(localconstants.ArgReplaySpeed))
+	}
+	wait, err := time.ParseDuration(viper.GetString(localconstants.ArgReplayWait))
+	if err != nil || wait < 0 {
+		return dashboardserver.ReplayOptions{}, sperr.New("invalid value for '--%s': '%s' - must be a duration, e.g. '5s'", localconstants.ArgReplayWait, viper.GetString(localconstants.ArgReplayWait))
+	}
+	return dashboardserver.ReplayOptions{
+		URL:   viper.GetString(localconstants.ArgReplayURL),
+		Speed: speed,
+		Wait:  wait,
+	}, nil
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/turbot/powerpipe/internal/exitcodes"
+)
+
+// exitCodesHelpTopic returns the 'exit-codes' help topic, which documents the exit codes and their classes
+func exitCodesHelpTopic() *cobra.Command {
+	var b strings.Builder
+	b.WriteString(`Powerpipe exits with a code for the class of the failure. Exit codes and classes are stable, so scripts
+can branch on them. If --error-format is json, a single json object containing the exit code, class and description
+of the failure, and the errors and warnings displayed, is written to stderr once the command completes, e.g.
+
+  {"exit_code":64,"class":"parse","description":"...","errors":["failed to load workspace: ..."]}
+
+Exit codes:
+
+`)
+	for _, e := range exitcodes.Registry {
+		fmt.Fprintf(&b, "  %-4d %-22s %s\n", e.Code, e.Class, e.Description)
+	}
+
+	return &cobra.Command{
+		Use:   "exit-codes",
+		Short: "Exit codes and their classes",
+		Long:  strings.TrimSuffix(b.String(), "\n"),
+	}
+}
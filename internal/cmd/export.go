@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/workspace"
+	"github.com/turbot/powerpipe/internal/assemble"
+	localcmdconfig "github.com/turbot/powerpipe/internal/cmdconfig"
+	"github.com/turbot/powerpipe/internal/conditional"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/eventbus"
+	"github.com/turbot/powerpipe/internal/grafana"
+	"github.com/turbot/powerpipe/internal/modsource"
+)
+
+func exportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export [command]",
+		Args:  cobra.NoArgs,
+		Short: "Export dashboards to other tools",
+		Long:  `Export Powerpipe dashboards for display in other tools.`,
+	}
+	cmd.AddCommand(exportGrafanaCmd())
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for export", cmdconfig.FlagOptions.WithShortHand("h"))
+
+	return cmd
+}
+
+func exportGrafanaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "grafana <dashboard>",
+		Args:  cobra.ExactArgs(1),
+		Run:   runExportGrafanaCmd,
+		Short: "Export a dashboard as a Grafana dashboard",
+		Long: `Export a dashboard as Grafana dashboard JSON, with panels querying a SQL datasource connected to the
+Powerpipe database.
+
+Cards, tables, charts, text and titled containers are converted to the equivalent Grafana panels and rows, and inputs
+to dashboard variables. Panels which cannot be converted are listed as warnings.
+
+If no datasource is given, the datasource is chosen when the dashboard is imported into Grafana.
+
+Examples:
+
+  # Export a dashboard to a file, to import into Grafana
+  powerpipe export grafana aws_insights.dashboard.s3_bucket_dashboard > s3_bucket_dashboard.json
+
+  # Export a dashboard using an existing Grafana datasource
+  powerpipe export grafana s3_bucket_dashboard --datasource steampipe`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for export grafana", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddStringFlag(localconstants.ArgDatasource, "", "The uid of the Grafana datasource queried by the panels").
+		AddStringFlag(localconstants.ArgDatasourceType, grafana.DefaultDatasourceType, "The type of the Grafana datasource").
+		AddModLocationFlag()
+
+	return cmd
+}
+
+func runExportGrafanaCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+
+	_, err := modsource.RestoreVendoredMods(viper.GetString(constants.ArgModLocation))
+	error_helpers.FailOnError(err)
+	w, errAndWarnings := workspace.LoadWorkspacePromptingForVariables(ctx, viper.GetString(constants.ArgModLocation), workspace.WithVariableValidation(false))
+	error_helpers.FailOnError(errAndWarnings.GetError())
+	eventbus.Publish(eventbus.NewEvent(eventbus.EventWorkspaceLoaded, w.Mod.Name(), map[string]any{"path": w.Path}))
+	error_helpers.FailOnError(conditional.RemoveDisabled(w.GetResourceMaps()))
+	error_helpers.FailOnError(assemble.Benchmarks(w.GetResourceMaps()))
+	if !w.ModfileExists() {
+		error_helpers.FailOnError(localconstants.ErrorNoModDefinition{})
+	}
+
+	targets, err := localcmdconfig.ResolveTargets[*modconfig.Dashboard](args, w)
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	dashboard := targets[0].(*modconfig.Dashboard)
+
+	res := grafana.Export(dashboard, grafana.ExportOptions{
+		DatasourceUid:  viper.GetString(localconstants.ArgDatasource),
+		DatasourceType: viper.GetString(localconstants.ArgDatasourceType),
+	})
+	for _, warning := range res.Warnings {
+		error_helpers.ShowWarning(warning)
+	}
+
+	// SQL is written unescaped (json escapes <, > and & by default)
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	error_helpers.FailOnError(encoder.Encode(res.Dashboard))
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/user"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/powerpipe/internal/ack"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/display"
+	"github.com/turbot/powerpipe/internal/locale"
+)
+
+func findingCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "finding [command]",
+		Args:  cobra.NoArgs,
+		Short: "Acknowledge control findings",
+		Long: `Acknowledge control findings, to temporarily silence known issues.
+
+While a finding is acknowledged, runs of the workspace report its alarm or error result as info, with the
+acknowledgement in the reason. The acknowledgement expires at the given time, after which the finding is reported
+as normal. Findings are identified by the fingerprint of the control result, which is included in exports and
+snapshots.
+
+The acknowledgements are stored in the ` + ack.FileName + ` file in the root of the workspace.`,
+	}
+	cmd.AddCommand(findingAckCmd(), findingUnackCmd(), findingAcksCmd())
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for finding", cmdconfig.FlagOptions.WithShortHand("h"))
+
+	return cmd
+}
+
+func findingAckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ack <fingerprint>",
+		Args:  cobra.ExactArgs(1),
+		Run:   runFindingAckCmd,
+		Short: "Acknowledge a finding until the given time",
+		Long: `Acknowledge a finding until the given time. Acknowledging a finding again replaces its acknowledgement.
+
+Examples:
+
+  # Acknowledge a finding until the start of 1 January 2025
+  powerpipe finding ack 63ac7e199dc796a67ba227e9cfa75ae7 --until 2025-01-01 --reason "Fix scheduled - INC-1234"
+
+  # Acknowledge a finding for 3 days
+  powerpipe finding ack 63ac7e199dc796a67ba227e9cfa75ae7 --until 3d`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for finding ack", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddStringFlag(localconstants.ArgUntil, "", "When the acknowledgement expires; a date (2025-01-01), a timestamp (2025-01-01T09:00:00Z) or a duration (7d, 12h)").
+		AddStringFlag(localconstants.ArgReason, "", "The reason the finding is acknowledged").
+		AddStringFlag(localconstants.ArgAuthor, "", "Who acknowledged the finding (defaults to the current user)").
+		AddModLocationFlag()
+
+	return cmd
+}
+
+func findingUnackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unack <fingerprint>",
+		Args:  cobra.ExactArgs(1),
+		Run:   runFindingUnackCmd,
+		Short: "Remove the acknowledgement of a finding",
+		Long:  `Remove the acknowledgement of a finding, so that it is reported as normal by subsequent runs.`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for finding unack", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddModLocationFlag()
+
+	return cmd
+}
+
+func findingAcksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "acks",
+		Args:  cobra.NoArgs,
+		Run:   runFindingAcksCmd,
+		Short: "List the acknowledged findings",
+		Long:  `List the findings of the workspace whose acknowledgement has not expired, in order of expiry.`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for finding acks", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddStringFlag(constants.ArgOutput, constants.OutputFormatTable, "Output format; one of: table, json").
+		AddModLocationFlag()
+
+	return cmd
+}
+
+func runFindingAckCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+
+	if !viper.IsSet(localconstants.ArgUntil) {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, fmt.Errorf("'--%s' must be set - acknowledgements are temporary", localconstants.ArgUntil))
+		return
+	}
+	now := time.Now()
+	until, err := ack.ParseUntil(viper.GetString(localconstants.ArgUntil), now)
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	author := viper.GetString(localconstants.ArgAuthor)
+	if author == "" {
+		if u, err := user.Current(); err == nil {
+			author = u.Username
+		}
+	}
+
+	acknowledgements, ok := loadWorkspaceAcknowledgements(cmd)
+	if !ok {
+		return
+	}
+	err = acknowledgements.Add(&ack.Acknowledgement{
+		Fingerprint:    args[0],
+		Until:          until,
+		Reason:         viper.GetString(localconstants.ArgReason),
+		AcknowledgedBy: author,
+		AcknowledgedAt: now,
+	})
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	if err := acknowledgements.Save(); err != nil {
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	fmt.Printf("Acknowledged %s until %s.\n", args[0], locale.Current().FormatTimeWithZone(until)) //nolint:forbidigo // intended output
+}
+
+func runFindingUnackCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+
+	acknowledgements, ok := loadWorkspaceAcknowledgements(cmd)
+	if !ok {
+		return
+	}
+	if !acknowledgements.Remove(args[0]) {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, fmt.Errorf("finding '%s' is not acknowledged", args[0]))
+		return
+	}
+	if err := acknowledgements.Save(); err != nil {
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	fmt.Printf("Removed the acknowledgement of %s.\n", args[0]) //nolint:forbidigo // intended output
+}
+
+func runFindingAcksCmd(cmd *cobra.Command, _ []string) {
+	ctx := cmd.Context()
+
+	acknowledgements, ok := loadWorkspaceAcknowledgements(cmd)
+	if !ok {
+		return
+	}
+	list := acknowledgements.List(time.Now())
+
+	switch viper.GetString(constants.ArgOutput) {
+	case constants.OutputFormatTable:
+		headers := []string{"FINGERPRINT", "UNTIL", "REASON", "ACKNOWLEDGED BY"}
+		var rows [][]string
+		for _, a := range list {
+			rows = append(rows, []string{a.Fingerprint, locale.Current().FormatTimeWithZone(a.Until), a.Reason, a.AcknowledgedBy})
+		}
+		display.ShowWrappedTable(headers, rows, nil)
+	case constants.OutputFormatJSON:
+		if list == nil {
+			list = []*ack.Acknowledgement{}
+		}
+		jsonOutput, err := json.MarshalIndent(list, "", "  ")
+		error_helpers.FailOnError(err)
+		fmt.Println(string(jsonOutput)) //nolint:forbidigo // intended output
+	default:
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, fmt.Errorf("invalid output format '%s' - must be one of: table, json", viper.GetString(constants.ArgOutput)))
+	}
+}
+
+// loadWorkspaceAcknowledgements loads the acknowledgements of the workspace, showing any error
+func loadWorkspaceAcknowledgements(cmd *cobra.Command) (*ack.Acknowledgements, bool) {
+	acknowledgements, err := ack.Load(ack.Path(viper.GetString(constants.ArgModLocation)))
+	if err != nil {
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(cmd.Context(), err)
+		return nil, false
+	}
+	return acknowledgements, true
+}
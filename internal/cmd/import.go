@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/utils"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/grafana"
+)
+
+func importCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import [command]",
+		Args:  cobra.NoArgs,
+		Short: "Import dashboards from other tools",
+		Long:  `Import dashboards from other tools as Powerpipe dashboards.`,
+	}
+	cmd.AddCommand(importGrafanaCmd())
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for import", cmdconfig.FlagOptions.WithShortHand("h"))
+
+	return cmd
+}
+
+func importGrafanaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "grafana <dashboard.json>",
+		Args:  cobra.ExactArgs(1),
+		Run:   runImportGrafanaCmd,
+		Short: "Import a Grafana dashboard",
+		Long: `Import a Grafana dashboard, exported as JSON, as a Powerpipe dashboard in the mod.
+
+Table, stat, gauge, time series, bar chart, pie chart, text and row panels with SQL queries are converted to the
+equivalent Powerpipe panels. Panels which cannot be converted, and queries using Grafana macros or dashboard variables,
+are marked with TODO comments.
+
+Examples:
+
+  # Import a Grafana dashboard into the mod in the current directory
+  powerpipe import grafana aws_overview.json
+
+  # Import a Grafana dashboard with a given dashboard name
+  powerpipe import grafana 1234.json --name aws_overview`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for import grafana", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddStringFlag(localconstants.ArgName, "", "The name of the dashboard (defaults to a name derived from the dashboard title)").
+		AddModLocationFlag()
+
+	return cmd
+}
+
+func runImportGrafanaCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	res, err := grafana.Import(data, viper.GetString(localconstants.ArgName))
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	// write the dashboard to a file named after it, which must not already exist
+	path := filepath.Join(viper.GetString(constants.ArgModLocation), res.Name+app_specific.ModDataExtensions[0])
+	if _, err := os.Stat(path); err == nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, fmt.Errorf("'%s' already exists", path))
+		return
+	}
+	if err := os.WriteFile(path, res.Hcl, 0644); err != nil { //nolint:gosec // dashboard files are not sensitive
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	fmt.Printf("Imported %d %s to %s\n", res.Converted, utils.Pluralize("panel", res.Converted), path) //nolint:forbidigo // intended output
+	if res.Todo > 0 {
+		fmt.Printf("%d %s marked with TODO to complete by hand\n", res.Todo, utils.Pluralize("item", res.Todo)) //nolint:forbidigo // intended output
+	}
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/utils"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/crash"
+	"github.com/turbot/powerpipe/internal/introspect"
+)
+
+func introspectCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "introspect",
+		Args:  cobra.NoArgs,
+		Run:   runIntrospectCmd,
+		Short: "Describe the capabilities of Powerpipe in a machine-readable form",
+		Long: `Describe the capabilities of Powerpipe in a machine-readable form.
+
+The commands and their flags, the output and export formats of each command, the resource types which may be
+defined in mods, the schema versions of the files and payloads Powerpipe writes, and the exit codes are described,
+so wrapper tooling may detect the available features rather than parsing the version. The same document is
+served by 'powerpipe server' at /api/v0/introspect.
+
+Examples:
+
+  # Describe the capabilities
+  powerpipe introspect
+
+  # Check whether benchmark run supports the k8s-event output format
+  powerpipe introspect | jq '.commands[] | select(.name == "benchmark run") | .output_formats | index("k8s-event")'`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for introspect", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddStringFlag(constants.ArgOutput, constants.OutputFormatJSON, "Output format; one of: json, yaml")
+
+	return cmd
+}
+
+func runIntrospectCmd(cmd *cobra.Command, _ []string) {
+	ctx := cmd.Context()
+	utils.LogTime("cmd.runIntrospectCmd")
+	defer func() {
+		utils.LogTime("cmd.runIntrospectCmd end")
+		if r := recover(); r != nil {
+			_ = crash.Recover(ctx, r)
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	var output []byte
+	var err error
+	capabilities := introspect.Describe(cmd.Root(), viper.GetString(localconstants.ConfigKeyVersion))
+	switch format := viper.GetString(constants.ArgOutput); format {
+	case constants.OutputFormatJSON:
+		output, err = json.MarshalIndent(capabilities, "", "  ")
+		output = append(output, '\n')
+	case constants.OutputFormatYAML:
+		output, err = yaml.Marshal(capabilities)
+	default:
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, fmt.Errorf("invalid output format '%s' - must be one of: json, yaml", format))
+		return
+	}
+	error_helpers.FailOnError(err)
+	fmt.Print(string(output)) //nolint:forbidigo // intended output
+}
@@ -2,14 +2,16 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"path/filepath"
 	"strings"
 
+	"github.com/goccy/go-yaml"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/thediveo/enumflag/v2"
-	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/app_specific"
 	"github.com/turbot/pipe-fittings/backend"
 	cmdconfig "github.com/turbot/pipe-fittings/cmdconfig"
@@ -19,9 +21,16 @@ import (
 	"github.com/turbot/pipe-fittings/modinstaller"
 	"github.com/turbot/pipe-fittings/parse"
 	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/pipe-fittings/versionmap"
 	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/crash"
 	"github.com/turbot/powerpipe/internal/db_client"
 	"github.com/turbot/powerpipe/internal/display"
+	"github.com/turbot/powerpipe/internal/exitcodes"
+	"github.com/turbot/powerpipe/internal/modsource"
+	"github.com/turbot/powerpipe/internal/verbosity"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+	"github.com/xlab/treeprint"
 )
 
 func modCmd() *cobra.Command {
@@ -49,6 +58,15 @@ Examples:
     # List installed mods
     powerpipe mod list
     
+    # Verify the installed mods against the lockfile
+    powerpipe mod verify
+    
+    # Vendor the installed mods into the workspace
+    powerpipe mod vendor
+    
+    # Search the public registry for mods
+    powerpipe mod search aws
+    
     # Uninstall a mod
     powerpipe mod uninstall github.com/turbot/steampipe-mod-aws-compliance 
 	`,
@@ -57,6 +75,9 @@ Examples:
 		modUninstallCmd(),
 		modUpdateCmd(),
 		modListCmd(),
+		modVerifyCmd(),
+		modVendorCmd(),
+		modSearchCmd(),
 		showCmd[*modconfig.Mod](),
 		modInitCmd(),
 	)
@@ -87,11 +108,35 @@ Examples:
   # Install a version of a mod using a semver constraint
   powerpipe mod install github.com/turbot/steampipe-mod-aws-compliance@'^1'
 
+  # Install a mod hosted in Azure DevOps or Bitbucket, using its clone URL
+  powerpipe mod install https://acme@dev.azure.com/acme/security/_git/powerpipe-mod-controls
+  powerpipe mod install git@bitbucket.org:acme/powerpipe-mod-controls.git
+
+  # Install a mod hosted in a self-hosted GitLab instance (set GITLAB_TOKEN to authenticate)
+  powerpipe mod install gitlab.acme.internal/security/compliance/powerpipe-mod-controls
+
+  # Install a mod from a private repository, using the credentials configured for its host or repository
+  # (e.g. a line 'github.com/acme ssh-key ~/.ssh/deploy_key', or 'github.com/acme token ${ACME_TOKEN}')
+  POWERPIPE_GIT_CREDENTIALS=~/.powerpipe/git_credentials powerpipe mod install github.com/acme/powerpipe-mod-controls
+
+  # Install a mod from a local directory or archive, without network access
+  powerpipe mod install ./vendor/mods/aws-compliance
+  powerpipe mod install ./vendor/mods/aws-compliance.tar.gz
+
+  # Resolve dependencies against a local mirror of mod repositories
+  POWERPIPE_MOD_MIRROR=/opt/mods powerpipe mod install ./vendor/mods/aws-compliance.tar.gz
+
   # Install all mods specified in the mod.pp and their dependencies
   powerpipe mod install
 
   # Preview what powerpipw mod install will do, without actually installing anything
-  powerpipe mod install --dry-run`,
+  powerpipe mod install --dry-run
+
+  # Install the dependencies pinned in powerpipe.pins, failing if they cannot be installed exactly
+  powerpipe mod install --frozen
+
+  # Fetch up to 16 mod repositories concurrently
+  powerpipe mod install --max-parallel 16`,
 	}
 
 	// default update strategy to minimal for mod install
@@ -104,6 +149,8 @@ Examples:
 		AddBoolFlag(constants.ArgForce, false, "Install mods even if plugin/cli version requirements are not met (cannot be used with --dry-run)").
 		AddBoolFlag(constants.ArgHelp, false, "Help for install", cmdconfig.FlagOptions.WithShortHand("h")).
 		AddBoolFlag(constants.ArgPrune, true, "Remove unused dependencies after installation is complete").
+		AddBoolFlag(localconstants.ArgFrozen, false, fmt.Sprintf("Install the dependency versions pinned in %s, failing if the installed versions would deviate from the pins", modsource.PinsFileName)).
+		AddIntFlag(constants.ArgMaxParallel, modsource.DefaultPrefetchParallelism, "The maximum number of mod repositories to fetch concurrently").
 		AddVarFlag(enumflag.New(&updateStrategy, constants.ArgPull, constants.ModUpdateStrategyIds, enumflag.EnumCaseInsensitive),
 			constants.ArgPull,
 			fmt.Sprintf("Update strategy; one of: %s", strings.Join(constants.FlagValues(constants.ModUpdateStrategyIds), ", "))).
@@ -118,7 +165,7 @@ func runModInstallCmd(cmd *cobra.Command, args []string) {
 	defer func() {
 		utils.LogTime("cmd.runModInstallCmd end")
 		if r := recover(); r != nil {
-			error_helpers.ShowError(ctx, helpers.ToError(r))
+			_ = crash.Recover(ctx, r)
 			// exitCode = constants.ExitCodeUnknownErrorPanic
 		}
 	}()
@@ -135,23 +182,131 @@ func runModInstallCmd(cmd *cobra.Command, args []string) {
 		if err != nil {
 			error_helpers.FailOnError(err)
 		}
-		fmt.Printf("Initializing mod, created %s.\n", app_specific.DefaultModFileName()) //nolint:forbidigo // acceptable output
+		verbosity.Printf("Initializing mod, created %s.\n", app_specific.DefaultModFileName())
 	}
 
+	restoreVendoredMods(workspacePath)
+
+	// install any local mod directories and archives from their absolute paths
+	args, err = modsource.LocalModArgs(args, workspacePath)
+	error_helpers.FailOnError(err)
+
 	// if any mod names were passed as args, convert into formed mod names
-	installOpts := modinstaller.NewInstallOpts(workspaceMod, args...)
+	installOpts := newModInstallOpts(workspaceMod, args)
 	installOpts.PluginVersions = getPluginVersions(ctx)
+	verbosity.Detailf("Installing the dependencies of %s (update strategy: %s)", workspaceMod.Name(), installOpts.UpdateStrategy)
+	defer prefetchDependencies(ctx, installOpts)()
+
+	if viper.GetBool(localconstants.ArgFrozen) {
+		error_helpers.FailOnError(verifyFrozenInstall(ctx, installOpts))
+	}
+
+	verified := verifyModSums(ctx, installOpts)
 
 	installData, err := modinstaller.InstallWorkspaceDependencies(ctx, installOpts)
 	if err != nil {
 		// exitCode = constants.ExitCodeModInstallFailed
-		error_helpers.FailOnError(err)
+		error_helpers.FailOnError(modsource.ExplainInstallError(err, workspaceMod))
 	}
+	error_helpers.FailOnError(checkVerifiedModSums(verified, installData))
+	updatePins(workspaceMod, installData)
+	modsource.PublishModInstalled(installOpts, installData)
 
 	summary := modinstaller.BuildInstallSummary(installData)
 	// tactical: remove trailing newline
 	summary = strings.TrimRight(summary, "\n")
-	fmt.Println(summary) //nolint:forbidigo // intended output
+	verbosity.Println(summary)
+}
+
+// newModInstallOpts returns the install options for the mod args, converting any mod source URLs into mod names
+func newModInstallOpts(workspaceMod *modconfig.Mod, args []string) *modinstaller.InstallOpts {
+	modsource.InstallGitAuth()
+	return modinstaller.NewInstallOpts(workspaceMod, modsource.NormaliseModArgs(args)...)
+}
+
+// restoreVendoredMods installs any vendored dependencies of the lock file which are not installed, so the installer
+// does not fetch them
+func restoreVendoredMods(workspacePath string) {
+	restored, err := modsource.RestoreVendoredMods(workspacePath)
+	error_helpers.FailOnErrorWithMessage(err, "failed to restore vendored mods")
+	if len(restored) > 0 {
+		verbosity.Detailf("Restored %d vendored %s", len(restored), utils.Pluralize("mod", len(restored)))
+	}
+}
+
+// prefetchDependencies fetches the repositories of the dependencies of the install concurrently (up to --max-parallel
+// at a time), so the installer resolves and installs them locally - the returned function removes the fetched repositories
+func prefetchDependencies(ctx context.Context, installOpts *modinstaller.InstallOpts) func() {
+	cleanup, err := modsource.PrefetchDependencies(ctx, installOpts, viper.GetInt(constants.ArgMaxParallel))
+	error_helpers.FailOnError(err)
+	return cleanup
+}
+
+// verifyModSums resolves the mod versions which would be installed (without installing them), and verifies their
+// commits against the mod checksum database (unless verification is disabled) - this fails if any commit does not
+// match or cannot be verified, before the mods and lock file are written
+// the verified versions are returned, or nil if verification is disabled
+func verifyModSums(ctx context.Context, installOpts *modinstaller.InstallOpts) []modsource.ModSum {
+	sumDB, err := modsource.NewSumDB()
+	error_helpers.FailOnError(err)
+	if sumDB == nil || viper.GetBool(constants.ArgDryRun) {
+		return nil
+	}
+	dryRunOpts := *installOpts
+	dryRunOpts.DryRun = true
+	installData, err := modinstaller.InstallWorkspaceDependencies(ctx, &dryRunOpts)
+	if err != nil {
+		error_helpers.FailOnError(modsource.ExplainInstallError(err, installOpts.WorkspaceMod))
+	}
+	warnings, err := sumDB.Verify(ctx, installData.Lock)
+	for _, w := range warnings {
+		error_helpers.ShowWarning(w)
+	}
+	error_helpers.FailOnError(err)
+	return modsource.LockedModSums(installData.Lock)
+}
+
+// checkVerifiedModSums returns an error if the install installed a mod version which was not verified by verifyModSums
+func checkVerifiedModSums(verified []modsource.ModSum, installData *modinstaller.InstallData) error {
+	if verified == nil || installData.Lock == nil {
+		return nil
+	}
+	verifiedMap := make(map[modsource.ModSum]struct{}, len(verified))
+	for _, sum := range verified {
+		verifiedMap[sum] = struct{}{}
+	}
+	for _, sum := range modsource.LockedModSums(installData.Lock) {
+		if _, ok := verifiedMap[sum]; !ok {
+			return sperr.New("%s@%s (commit %s) was installed, but was not the version verified against the checksum database - the mod has not been trusted", sum.Name, sum.Version, sum.Commit)
+		}
+	}
+	return nil
+}
+
+// verifyFrozenInstall resolves the dependencies which would be installed (without installing them),
+// and returns an error if they deviate from the pins file of the workspace mod
+func verifyFrozenInstall(ctx context.Context, installOpts *modinstaller.InstallOpts) error {
+	if len(installOpts.ModArgs) > 0 {
+		return sperr.New("mods cannot be installed with --frozen - install them without --frozen to update %s", modsource.PinsFileName)
+	}
+	if viper.GetBool(constants.ArgDryRun) {
+		return sperr.New("--frozen cannot be used with --dry-run")
+	}
+	dryRunOpts := *installOpts
+	dryRunOpts.DryRun = true
+	installData, err := modinstaller.InstallWorkspaceDependencies(ctx, &dryRunOpts)
+	if err != nil {
+		return modsource.ExplainInstallError(err, installOpts.WorkspaceMod)
+	}
+	return modsource.VerifyPins(installOpts.WorkspaceMod.ModPath, installData.Lock)
+}
+
+// updatePins writes the pins file of the workspace mod from the installed dependencies (unless this is a dry run)
+func updatePins(workspaceMod *modconfig.Mod, installData *modinstaller.InstallData) {
+	if viper.GetBool(constants.ArgDryRun) {
+		return
+	}
+	error_helpers.FailOnErrorWithMessage(modsource.UpdatePins(workspaceMod.ModPath, installData.Lock), "failed to update "+modsource.PinsFileName)
 }
 
 func getPluginVersions(ctx context.Context) *modconfig.PluginVersionMap {
@@ -202,7 +357,7 @@ func runModUninstallCmd(cmd *cobra.Command, args []string) {
 	defer func() {
 		utils.LogTime("cmd.runModInstallCmd end")
 		if r := recover(); r != nil {
-			error_helpers.ShowError(ctx, helpers.ToError(r))
+			_ = crash.Recover(ctx, r)
 			exitCode = constants.ExitCodeUnknownErrorPanic
 		}
 	}()
@@ -212,17 +367,16 @@ func runModUninstallCmd(cmd *cobra.Command, args []string) {
 	workspaceMod, err := parse.LoadModfile(viper.GetString(constants.ArgModLocation))
 	error_helpers.FailOnErrorWithMessage(err, "failed to load mod definition")
 	if workspaceMod == nil {
-		//nolint:forbidigo // acceptable output
-		fmt.Println("No mods installed.")
+		verbosity.Println("No mods installed.")
 		return
 	}
 
-	opts := modinstaller.NewInstallOpts(workspaceMod, args...)
+	opts := newModInstallOpts(workspaceMod, args)
 
 	installData, err := modinstaller.UninstallWorkspaceDependencies(ctx, opts)
 	error_helpers.FailOnError(err)
-	//nolint:forbidigo // acceptable
-	fmt.Println(modinstaller.BuildUninstallSummary(installData))
+	updatePins(workspaceMod, installData)
+	verbosity.Println(modinstaller.BuildUninstallSummary(installData))
 }
 
 func modUpdateCmd() *cobra.Command {
@@ -249,6 +403,7 @@ Example:
 		AddBoolFlag(constants.ArgForce, false, "Update mods even if plugin/cli version requirements are not met (cannot be used with --dry-run)").
 		AddBoolFlag(constants.ArgDryRun, false, "Show which mods would be updated without modifying them").
 		AddBoolFlag(constants.ArgHelp, false, "Help for update", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddIntFlag(constants.ArgMaxParallel, modsource.DefaultPrefetchParallelism, "The maximum number of mod repositories to fetch concurrently").
 		AddVarFlag(enumflag.New(&updateStrategy, constants.ArgPull, constants.ModUpdateStrategyIds, enumflag.EnumCaseInsensitive),
 			constants.ArgPull,
 			fmt.Sprintf("Update strategy; one of: %s", strings.Join(constants.FlagValues(constants.ModUpdateStrategyIds), ", "))).
@@ -263,7 +418,7 @@ func runModUpdateCmd(cmd *cobra.Command, args []string) {
 	defer func() {
 		utils.LogTime("cmd.runModUpdateCmd end")
 		if r := recover(); r != nil {
-			error_helpers.ShowError(ctx, helpers.ToError(r))
+			_ = crash.Recover(ctx, r)
 			exitCode = constants.ExitCodeUnknownErrorPanic
 		}
 	}()
@@ -273,40 +428,57 @@ func runModUpdateCmd(cmd *cobra.Command, args []string) {
 	workspaceMod, err := parse.LoadModfile(viper.GetString(constants.ArgModLocation))
 	error_helpers.FailOnErrorWithMessage(err, "failed to load mod definition")
 	if workspaceMod == nil {
-		//nolint:forbidigo // acceptable output
-		fmt.Println("No mods installed.")
+		verbosity.Println("No mods installed.")
 		return
 	}
+	restoreVendoredMods(workspaceMod.ModPath)
+
+	opts := newModInstallOpts(workspaceMod, args)
+	defer prefetchDependencies(ctx, opts)()
 
-	opts := modinstaller.NewInstallOpts(workspaceMod, args...)
+	verified := verifyModSums(ctx, opts)
 
 	// do this update
 	installData, err := modinstaller.InstallWorkspaceDependencies(ctx, opts)
-	error_helpers.FailOnError(err)
+	error_helpers.FailOnError(modsource.ExplainInstallError(err, workspaceMod))
+	error_helpers.FailOnError(checkVerifiedModSums(verified, installData))
+	updatePins(workspaceMod, installData)
+	modsource.PublishModInstalled(opts, installData)
 
-	//nolint:forbidigo // acceptable
-	fmt.Println(modinstaller.BuildInstallSummary(installData))
+	verbosity.Println(modinstaller.BuildInstallSummary(installData))
 }
 
 // list
+var modListOutputMode = localconstants.ModListOutputModeTree
+
 func modListCmd() *cobra.Command {
 	var cmd = &cobra.Command{
 		Use:   "list",
 		Run:   runModListCmd,
 		Short: "List currently installed mods",
 		Long: `List currently installed mods.
-		
+
+Each installed mod is listed with the version it was resolved to, the version constraint of the mod requiring it,
+its install path, and the newest version satisfying the constraint, if newer than the installed version (i.e. the
+version 'powerpipe mod update' would install).
+
 Example:
 
-  # List installed mods
-  powerpipe mod list`,
+  # List installed mods as a dependency tree
+  powerpipe mod list
+
+  # List installed mods as a table
+  powerpipe mod list --output table
+
+  # List installed mods as json, e.g. to find the mods with a newer version
+  powerpipe mod list --output json`,
 	}
 
 	cmdconfig.OnCmd(cmd).
 		AddBoolFlag(constants.ArgHelp, false, "Help for list", cmdconfig.FlagOptions.WithShortHand("h")).
-		AddVarFlag(enumflag.New(&outputMode, constants.ArgOutput, localconstants.OutputModeIds, enumflag.EnumCaseInsensitive),
+		AddVarFlag(enumflag.New(&modListOutputMode, constants.ArgOutput, localconstants.ModListOutputModeIds, enumflag.EnumCaseInsensitive),
 			constants.ArgOutput,
-			fmt.Sprintf("Output format; one of: %s", strings.Join(constants.FlagValues(localconstants.OutputModeIds), ", "))).
+			fmt.Sprintf("Output format; one of: %s", strings.Join(constants.FlagValues(localconstants.ModListOutputModeIds), ", "))).
 		AddModLocationFlag()
 	return cmd
 }
@@ -317,39 +489,331 @@ func runModListCmd(cmd *cobra.Command, _ []string) {
 	defer func() {
 		utils.LogTime("cmd.runModListCmd end")
 		if r := recover(); r != nil {
-			error_helpers.ShowError(ctx, helpers.ToError(r))
+			_ = crash.Recover(ctx, r)
 			exitCode = constants.ExitCodeUnknownErrorPanic
 		}
 	}()
 
-	// if the output format is json or yaml, call the generic list function
-	output := viper.GetString(constants.ArgOutput)
-	if output == constants.OutputFormatJSON || output == constants.OutputFormatYAML {
-		display.ListResources[*modconfig.Mod](cmd)
+	// try to load the workspace mod definition
+	// - if it does not exist, this will return a nil mod and a nil error
+	workspacePath := viper.GetString(constants.ArgModLocation)
+	workspaceMod, err := parse.LoadModfile(workspacePath)
+	error_helpers.FailOnErrorWithMessage(err, "failed to load mod definition")
+
+	var mods []*modsource.InstalledMod
+	if workspaceMod != nil {
+		lock, err := versionmap.LoadWorkspaceLock(workspacePath)
+		error_helpers.FailOnErrorWithMessage(err, "failed to load the lockfile")
+		mods, err = modsource.ListInstalledMods(workspaceMod, lock)
+		error_helpers.FailOnError(err)
+
+		// list the versions of each mod as for an install, using the configured git credentials and mirror
+		modsource.InstallGitAuth()
+		modsource.SetNewerVersions(ctx, mods)
+	}
+
+	switch output := viper.GetString(constants.ArgOutput); output {
+	case constants.OutputFormatJSON:
+		if mods == nil {
+			mods = []*modsource.InstalledMod{}
+		}
+		jsonOutput, err := json.MarshalIndent(mods, "", "  ")
+		error_helpers.FailOnError(err)
+		fmt.Println(string(jsonOutput)) //nolint:forbidigo // intended output
+		return
+	case constants.OutputFormatYAML:
+		if mods == nil {
+			mods = []*modsource.InstalledMod{}
+		}
+		yamlOutput, err := yaml.Marshal(mods)
+		error_helpers.FailOnError(err)
+		fmt.Print(string(yamlOutput)) //nolint:forbidigo // intended output
 		return
 	}
 
-	// try to load the workspace mod definition
-	// - if it does not exist, this will return a nil mod and a nil error
-	workspaceMod, err := parse.LoadModfile(viper.GetString(constants.ArgModLocation))
+	if len(mods) == 0 {
+		//nolint:forbidigo // acceptable
+		fmt.Println("No mods installed.")
+		return
+	}
+	if viper.GetString(constants.ArgOutput) == constants.OutputFormatTable {
+		headers := []string{"NAME", "VERSION", "CONSTRAINT", "NEWER VERSION", "INSTALL PATH"}
+		var rows [][]string
+		for _, mod := range mods {
+			for _, m := range mod.Flatten() {
+				rows = append(rows, []string{m.Name, m.Version, m.Constraint, newerVersionString(m), m.InstallPath})
+			}
+		}
+		display.ShowWrappedTable(headers, rows, nil)
+	} else {
+		//nolint:forbidigo // acceptable
+		fmt.Println(modListTreeString(workspaceMod.GetInstallCacheKey(), mods))
+	}
+	showNewerVersionErrors(mods)
+}
+
+// modListTreeString returns the dependency tree of the installed mods, rooted at the workspace mod
+func modListTreeString(rootName string, mods []*modsource.InstalledMod) string {
+	tree := treeprint.NewWithRoot(rootName)
+	var addBranches func(tree treeprint.Tree, mods []*modsource.InstalledMod)
+	addBranches = func(tree treeprint.Tree, mods []*modsource.InstalledMod) {
+		for _, mod := range mods {
+			var details []string
+			if mod.Constraint != "" {
+				details = append(details, "constraint "+mod.Constraint)
+			}
+			if mod.NewerVersion != "" {
+				details = append(details, mod.NewerVersion+" available")
+			}
+			label := mod.Key()
+			if len(details) > 0 {
+				label = fmt.Sprintf("%s (%s)", label, strings.Join(details, ", "))
+			}
+			addBranches(tree.AddBranch(label), mod.Dependencies)
+		}
+	}
+	addBranches(tree, mods)
+	return strings.TrimSuffix(tree.String(), "\n")
+}
+
+// newerVersionString returns the newer version of the mod for the table, or 'unknown' if its versions could not be listed
+func newerVersionString(mod *modsource.InstalledMod) string {
+	if mod.NewerVersionError != "" {
+		return "unknown"
+	}
+	return mod.NewerVersion
+}
+
+// showNewerVersionErrors warns of the mods whose versions could not be listed (e.g. with no network access), so
+// whether a newer version exists is unknown
+func showNewerVersionErrors(mods []*modsource.InstalledMod) {
+	// (the versions of each mod are listed once, however many mods require it)
+	shown := make(map[string]struct{})
+	for _, mod := range mods {
+		for _, m := range mod.Flatten() {
+			if _, ok := shown[m.Name]; !ok && m.NewerVersionError != "" {
+				shown[m.Name] = struct{}{}
+				error_helpers.ShowWarning(m.NewerVersionError)
+			}
+		}
+	}
+}
+
+// verify
+func modVerifyCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "verify",
+		Args:  cobra.NoArgs,
+		Run:   runModVerifyCmd,
+		Short: "Verify installed mods against the lockfile",
+		Long: fmt.Sprintf(`Verify installed mods against the lockfile.
+
+The checksum of each file of each installed dependency is recomputed and compared with the locked commit of the
+dependency, and the locked versions are compared with the versions pinned in %s (if it exists). Dependencies which
+are missing, drifted (not installed at the locked commit, or not locked at the pinned commit) or tampered with
+(installed files modified, added or removed) are reported, and the command exits with code %d.
+
+Example:
+
+  # Verify the installed mods, e.g. in a CI pipeline
+  powerpipe mod verify`, modsource.PinsFileName, exitcodes.ExitCodeModVerifyFailed),
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for verify", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddModLocationFlag()
+	return cmd
+}
+
+func runModVerifyCmd(cmd *cobra.Command, _ []string) {
+	ctx := cmd.Context()
+	utils.LogTime("cmd.runModVerifyCmd")
+	defer func() {
+		utils.LogTime("cmd.runModVerifyCmd end")
+		if r := recover(); r != nil {
+			_ = crash.Recover(ctx, r)
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	workspacePath := viper.GetString(constants.ArgModLocation)
+	workspaceMod, err := parse.LoadModfile(workspacePath)
 	error_helpers.FailOnErrorWithMessage(err, "failed to load mod definition")
 	if workspaceMod == nil {
 		//nolint:forbidigo // acceptable
 		fmt.Println("No mods installed.")
 		return
 	}
+	lock, err := versionmap.LoadWorkspaceLock(workspacePath)
+	error_helpers.FailOnErrorWithMessage(err, "failed to load the lockfile")
+	pins, err := modsource.LoadPins(workspaceMod.ModPath)
+	error_helpers.FailOnErrorWithMessage(err, "failed to load "+modsource.PinsFileName)
 
-	opts := modinstaller.NewInstallOpts(workspaceMod)
-	installer, err := modinstaller.NewModInstaller(opts)
-	error_helpers.FailOnError(err)
-
-	treeString := installer.GetModList()
-	if len(strings.Split(treeString, "\n")) > 1 {
+	verifications := modsource.VerifyDependencies(workspaceMod, lock, pins)
+	if len(verifications) == 0 {
 		//nolint:forbidigo // acceptable
-		fmt.Println()
+		fmt.Println("No mods installed.")
+		return
 	}
 	//nolint:forbidigo // acceptable
-	fmt.Println(treeString)
+	fmt.Println(verificationsString(verifications))
+
+	failed := 0
+	for _, v := range verifications {
+		if v.Failed() {
+			failed++
+		}
+	}
+	if failed > 0 {
+		exitCode = exitcodes.ExitCodeModVerifyFailed
+		error_helpers.ShowError(ctx, fmt.Errorf("%d of %d dependencies failed verification", failed, len(verifications)))
+	}
+}
+
+// verificationsString returns a line for the status of each dependency, followed by the details of the status
+func verificationsString(verifications []modsource.Verification) string {
+	width := 0
+	for _, v := range verifications {
+		width = max(width, len(verificationName(v)))
+	}
+	var b strings.Builder
+	for _, v := range verifications {
+		fmt.Fprintf(&b, "%-*s  %s\n", width, verificationName(v), v.Status)
+		for _, detail := range v.Details {
+			fmt.Fprintf(&b, "    %s\n", detail)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// verificationName returns the name and version of the dependency (file path dependencies have no version)
+func verificationName(v modsource.Verification) string {
+	if v.Version == "" {
+		return v.Name
+	}
+	return v.Name + "@" + v.Version
+}
+
+func modVendorCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "vendor",
+		Args:  cobra.NoArgs,
+		Run:   runModVendorCmd,
+		Short: "Copy the installed mods into the workspace vendor directory",
+		Long: fmt.Sprintf(`Copy the installed mods into the workspace vendor directory.
+
+Each installed dependency of the lockfile is copied into %s (replacing its contents), along with the
+lockfile. When the workspace is loaded or its mods installed, any locked dependency which is not installed is
+restored from the vendor directory rather than fetched, so a workspace which commits its vendor directory (and
+lockfile) can be built reproducibly without access to the hosts of its dependencies.
+
+Dependencies are only fetched if they are not vendored, or the lockfile no longer satisfies the mod requirements -
+run 'powerpipe mod vendor' again after installing or updating mods. Local file path dependencies are not vendored.
+
+Example:
+
+  # Vendor the installed mods
+  powerpipe mod install
+  powerpipe mod vendor`, filepath.Join(app_specific.WorkspaceDataDir, modsource.VendorDirName)),
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for vendor", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddModLocationFlag()
+	return cmd
+}
+
+func runModVendorCmd(cmd *cobra.Command, _ []string) {
+	ctx := cmd.Context()
+	utils.LogTime("cmd.runModVendorCmd")
+	defer func() {
+		utils.LogTime("cmd.runModVendorCmd end")
+		if r := recover(); r != nil {
+			_ = crash.Recover(ctx, r)
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	workspacePath := viper.GetString(constants.ArgModLocation)
+	res, err := modsource.Vendor(workspacePath)
+	error_helpers.FailOnErrorWithMessage(err, "failed to vendor mods")
+	for _, name := range res.Skipped {
+		error_helpers.ShowWarning(fmt.Sprintf("%s is a local file path dependency, so is not vendored", name))
+	}
+	verbosity.Printf("Vendored %d %s into %s\n", len(res.Vendored), utils.Pluralize("mod", len(res.Vendored)), modsource.VendorPath(workspacePath))
+	for _, name := range res.Vendored {
+		verbosity.Detailf("  %s", name)
+	}
+}
+
+func modSearchCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "search <term>",
+		Args:  cobra.ExactArgs(1),
+		Run:   runModSearchCmd,
+		Short: "Search the public registry for mods",
+		Long: `Search the public registry at hub.powerpipe.io for mods whose name, title or description match the term.
+
+Example:
+
+  # Search for AWS mods
+  powerpipe mod search aws
+
+  # Search for compliance mods, as json
+  powerpipe mod search compliance --output json`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for search", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddStringFlag(constants.ArgOutput, constants.OutputFormatTable, "Output format; one of: table, json")
+	return cmd
+}
+
+func runModSearchCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	utils.LogTime("cmd.runModSearchCmd")
+	defer func() {
+		utils.LogTime("cmd.runModSearchCmd end")
+		if r := recover(); r != nil {
+			_ = crash.Recover(ctx, r)
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	output := viper.GetString(constants.ArgOutput)
+	if output != constants.OutputFormatTable && output != constants.OutputFormatJSON {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, fmt.Errorf("invalid output format '%s' - must be one of: table, json", output))
+		return
+	}
+
+	mods, err := modsource.NewRegistry().Search(ctx, args[0])
+	error_helpers.FailOnError(err)
+
+	if output == constants.OutputFormatJSON {
+		if mods == nil {
+			mods = []*modsource.RegistryMod{}
+		}
+		jsonOutput, err := json.MarshalIndent(mods, "", "  ")
+		error_helpers.FailOnError(err)
+		fmt.Println(string(jsonOutput)) //nolint:forbidigo // intended output
+		return
+	}
+
+	if len(mods) == 0 {
+		fmt.Printf("No mods found matching '%s'.\n", args[0]) //nolint:forbidigo // intended output
+		return
+	}
+	headers := []string{"NAME", "VERSION", "DESCRIPTION", "MAINTAINERS"}
+	var rows [][]string
+	for _, mod := range mods {
+		description := mod.Description
+		if description == "" {
+			description = mod.Title
+		}
+		rows = append(rows, []string{mod.Name, mod.Version, description, strings.Join(mod.Maintainers, ", ")})
+	}
+	display.ShowWrappedTable(headers, rows, nil)
 }
 
 func modInitCmd() *cobra.Command {
@@ -378,7 +842,7 @@ func runModInitCmd(cmd *cobra.Command, args []string) {
 	defer func() {
 		utils.LogTime("cmd.runModInitCmd end")
 		if r := recover(); r != nil {
-			error_helpers.ShowError(ctx, helpers.ToError(r))
+			_ = crash.Recover(ctx, r)
 			exitCode = constants.ExitCodeUnknownErrorPanic
 		}
 	}()
@@ -404,7 +868,7 @@ func createWorkspaceMod(ctx context.Context, cmd *cobra.Command, workspacePath s
 	}
 	// only print message for mod init (not for mod install)
 	if cmd.Name() == "init" {
-		fmt.Printf("Created mod definition file '%s'\n", app_specific.DefaultModFilePath(workspacePath)) //nolint:forbidigo // acceptable
+		verbosity.Printf("Created mod definition file '%s'\n", app_specific.DefaultModFilePath(workspacePath))
 	}
 
 	// load up the written mod file so that we get the updated
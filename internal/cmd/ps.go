@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/dashboardserver"
+	"github.com/turbot/powerpipe/internal/display"
+	"github.com/turbot/powerpipe/internal/exitcodes"
+	"github.com/turbot/powerpipe/internal/rbac"
+	"github.com/turbot/powerpipe/internal/serverclient"
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+)
+
+func psCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ps",
+		Args:  cobra.NoArgs,
+		Run:   runPsCmd,
+		Short: "List in-flight runs on a Powerpipe server",
+		Long: `List the benchmark, dashboard and query runs currently executing on a Powerpipe server.
+
+Use 'powerpipe cancel <run-id>' to cancel a run.
+
+If the server uses HTTPS, set --tls (and --tls-ca if its certificate is not issued by a trusted CA). If the server
+has an auth policy, set the identity of the user with --identity and --groups.`,
+	}
+
+	addServerClientFlags(cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for ps", cmdconfig.FlagOptions.WithShortHand("h"))).
+		AddStringFlag(constants.ArgOutput, constants.OutputFormatTable, "Output format; one of: table, json")
+
+	return cmd
+}
+
+func runPsCmd(cmd *cobra.Command, _ []string) {
+	ctx := cmd.Context()
+
+	client, err := newServerClient()
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	runs, err := client.ListRuns(ctx)
+	if err != nil {
+		exitCode = exitcodes.FromError(err, constants.ExitCodeUnknownErrorPanic)
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	switch viper.GetString(constants.ArgOutput) {
+	case constants.OutputFormatJSON:
+		jsonOutput, err := json.MarshalIndent(runs, "", "  ")
+		error_helpers.FailOnError(err)
+		fmt.Println(string(jsonOutput)) //nolint:forbidigo // intended output
+	case constants.OutputFormatTable:
+		if len(runs) == 0 {
+			fmt.Println("No runs in progress.") //nolint:forbidigo // intended output
+			return
+		}
+		headers := []string{"RUN ID", "TYPE", "TARGET", "STATUS", "STARTED", "DURATION", "INITIATOR"}
+		var rows [][]string
+		for _, r := range runs {
+			rows = append(rows, []string{
+				r.RunId,
+				r.RunType,
+				r.Target,
+				r.Status,
+				r.StartTime.Format(time.RFC3339),
+				time.Since(r.StartTime).Round(time.Second).String(),
+				r.Initiator,
+			})
+		}
+		display.ShowWrappedTable(headers, rows, nil)
+	default:
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, fmt.Errorf("invalid output format '%s' - must be one of: table, json", viper.GetString(constants.ArgOutput)))
+	}
+}
+
+func cancelCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cancel <run-id>",
+		Args:  cobra.ExactArgs(1),
+		Run:   runCancelCmd,
+		Short: "Cancel an in-flight run on a Powerpipe server",
+		Long: `Cancel a benchmark, dashboard or query run executing on a Powerpipe server.
+
+Use 'powerpipe ps' to list the in-flight runs.
+
+If the server uses HTTPS, set --tls (and --tls-ca if its certificate is not issued by a trusted CA). If the server
+has an auth policy, set the identity of the user with --identity and --groups.`,
+	}
+
+	addServerClientFlags(cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for cancel", cmdconfig.FlagOptions.WithShortHand("h")))
+
+	return cmd
+}
+
+func runCancelCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+
+	client, err := newServerClient()
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	run, err := client.CancelRun(ctx, args[0])
+	if err != nil {
+		exitCode = exitcodes.FromError(err, constants.ExitCodeUnknownErrorPanic)
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	fmt.Printf("Cancelled %s run %s (%s)\n", run.RunType, run.RunId, run.Target) //nolint:forbidigo // intended output
+}
+
+// addServerClientFlags adds the flags of the connection to a Powerpipe server
+func addServerClientFlags(builder *cmdconfig.CmdBuilder) *cmdconfig.CmdBuilder {
+	return builder.
+		AddStringFlag(constants.ArgHost, "localhost", "Host of the Powerpipe server").
+		AddIntFlag(constants.ArgPort, dashboardserver.DashboardServerDefaultPort, "Port of the Powerpipe server").
+		AddBoolFlag(localconstants.ArgTLS, false, "Connect to the Powerpipe server using HTTPS").
+		AddStringFlag(localconstants.ArgTLSCA, "", "PEM file of the CA certificate of the Powerpipe server, if it is not trusted by the system roots (implies --tls)").
+		AddStringFlag(localconstants.ArgIdentity, "", "The identity of the user, if the Powerpipe server has an auth policy").
+		AddStringFlag(localconstants.ArgGroups, "", "The groups of the user (comma-separated), if the Powerpipe server has an auth policy").
+		AddStringFlag(localconstants.ArgIdentityHeader, rbac.DefaultIdentityHeader, "The header the identity is sent in, if the auth policy of the Powerpipe server sets identity_header").
+		AddStringFlag(localconstants.ArgGroupsHeader, rbac.DefaultGroupsHeader, "The header the groups are sent in, if the auth policy of the Powerpipe server sets groups_header")
+}
+
+// newServerClient returns a client of the Powerpipe server set by the flags of the command
+func newServerClient() (*serverclient.Client, error) {
+	opts := []serverclient.ClientOption{
+		serverclient.WithHeader(viper.GetString(localconstants.ArgIdentityHeader), viper.GetString(localconstants.ArgIdentity)),
+		serverclient.WithHeader(viper.GetString(localconstants.ArgGroupsHeader), viper.GetString(localconstants.ArgGroups)),
+	}
+	if caFile := viper.GetString(localconstants.ArgTLSCA); caFile != "" {
+		rootCAs, err := loadServerCA(caFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, serverclient.WithTLS(rootCAs))
+	} else if viper.GetBool(localconstants.ArgTLS) {
+		opts = append(opts, serverclient.WithTLS(nil))
+	}
+	return serverclient.NewClient(viper.GetString(constants.ArgHost), viper.GetInt(constants.ArgPort), opts...), nil
+}
+
+// loadServerCA returns a pool of the CAs trusted by outbound connections (the system roots and the CA bundles of the TLS
+// policy), and the CA certificate of the server read from the PEM file
+func loadServerCA(caFile string) (*x509.CertPool, error) {
+	var pool *x509.CertPool
+	if policyCAs := tlspolicy.Current().RootCAs; policyCAs != nil {
+		pool = policyCAs.Clone()
+	} else if systemCAs, err := x509.SystemCertPool(); err == nil {
+		pool = systemCAs
+	} else {
+		// (the system roots are not available on all platforms)
+		pool = x509.NewCertPool()
+	}
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value of '%s': failed to read CA certificate: %s", localconstants.ArgTLSCA, err.Error())
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("invalid value of '%s': %s does not contain any PEM encoded certificates", localconstants.ArgTLSCA, caFile)
+	}
+	return pool, nil
+}
@@ -3,7 +3,6 @@ package cmd
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -12,7 +11,6 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/thediveo/enumflag/v2"
-	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/app_specific"
 	"github.com/turbot/pipe-fittings/cmdconfig"
 	"github.com/turbot/pipe-fittings/constants"
@@ -20,13 +18,16 @@ import (
 	"github.com/turbot/pipe-fittings/export"
 	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/steampipeconfig"
-	"github.com/turbot/pipe-fittings/workspace"
 	localcmdconfig "github.com/turbot/powerpipe/internal/cmdconfig"
 	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/crash"
+	"github.com/turbot/powerpipe/internal/customexport"
 	"github.com/turbot/powerpipe/internal/dashboardexecute"
 	"github.com/turbot/powerpipe/internal/display"
+	"github.com/turbot/powerpipe/internal/exitcodes"
 	"github.com/turbot/powerpipe/internal/initialisation"
 	"github.com/turbot/powerpipe/internal/queryresult"
+	"github.com/turbot/powerpipe/internal/verbosity"
 	"github.com/turbot/steampipe-plugin-sdk/v5/logging"
 	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
 )
@@ -43,7 +44,12 @@ func queryRunCmd() *cobra.Command {
 		Short:            "Run a named query",
 		Long: `Runs the named query.
 
-The current mod is the working directory, or the directory specified by the --mod-location flag.`,
+The current mod is the working directory, or the directory specified by the --mod-location flag.
+
+The state of Powerpipe itself (the powerpipe_mod, powerpipe_resource, powerpipe_control_run and powerpipe_snapshot
+tables) is queried using the database powerpipe://, e.g.
+
+  powerpipe query run "select control_name, last_alarm from powerpipe_control_run" --database powerpipe://`,
 	}
 
 	cmdconfig.OnCmd(cmd).
@@ -54,7 +60,7 @@ The current mod is the working directory, or the directory specified by the --mo
 		AddStringArrayFlag(constants.ArgArg, nil, "Specify the value of a query argument").
 		AddStringFlag(constants.ArgDatabase, app_specific.DefaultDatabase, "Turbot Pipes workspace database").
 		AddIntFlag(constants.ArgDatabaseQueryTimeout, localconstants.DatabaseDefaultQueryTimeout, "The query timeout").
-		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported formats: csv, html, json, md, nunit3, pps (snapshot), asff").
+		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported formats: csv, html, json, md, nunit3, pps (snapshot), asff, custom:<format> (custom exporter)").
 		AddBoolFlag(constants.ArgHeader, true, "Include column headers for csv and table output").
 		AddBoolFlag(constants.ArgHelp, false, "Help for query", cmdconfig.FlagOptions.WithShortHand("h")).
 		AddBoolFlag(constants.ArgInput, true, "Enable interactive prompts").
@@ -65,10 +71,18 @@ The current mod is the working directory, or the directory specified by the --mo
 		AddBoolFlag(constants.ArgProgress, true, "Display snapshot upload status").
 		AddStringSliceFlag(constants.ArgSearchPath, nil, "Set a custom search_path for the steampipe user for a query session (comma-separated)").
 		AddStringSliceFlag(constants.ArgSearchPathPrefix, nil, "Set a prefix to the current search path for a query session (comma-separated)").
+		AddStringArrayFlag(localconstants.ArgDatabaseSearchPath, nil, "Set a custom search_path for a database, in the form 'database=schema1,schema2'").
+		AddStringArrayFlag(localconstants.ArgDatabaseSearchPathPrefix, nil, "Set a prefix to the search path for a database, in the form 'database=schema1,schema2'").
+		AddStringArrayFlag(localconstants.ArgDatabaseAttach, nil, "Attach a database file to a DuckDB or SQLite database, in the form 'database=alias:path'").
+		AddStringArrayFlag(localconstants.ArgTerraformInput, nil, "Load a Terraform plan (the output of 'terraform show -json') or state file, and attach it to each DuckDB database, in the form 'alias:path' or 'path' (attached as 'terraform')").
+		AddStringArrayFlag(localconstants.ArgDatabaseOnConnect, nil, "Run the SQL in a file on each new connection to a database, in the form 'database=path'").
+		AddStringArrayFlag(localconstants.ArgDatabaseCredentialCmd, nil, "Run a command to get the current connection string of a database with rotated credentials, in the form 'database=path'").
+		AddBoolFlag(localconstants.ArgStrict, false, "Treat workspace warnings, and resources of the workspace mod with no description, as errors").
+		AddStringFlag(localconstants.ArgQueryRewriteConfig, "", "Path to a query rewrite config file, which remaps the schemas and tables of control and dashboard queries").
 		AddStringFlag(constants.ArgSeparator, ",", "Separator string for csv output").
 		AddBoolFlag(constants.ArgShare, false, "Create snapshot in Turbot Pipes with 'anyone_with_link' visibility").
 		AddBoolFlag(constants.ArgSnapshot, false, "Create snapshot in Turbot Pipes with the default (workspace) visibility").
-		AddStringFlag(constants.ArgSnapshotLocation, "", "The location to write snapshots - either a local file path or a Turbot Pipes workspace").
+		AddStringFlag(constants.ArgSnapshotLocation, "", "The location to write snapshots - either a local file path, a Turbot Pipes workspace, or a cloud storage url (s3://, gs:// or azblob://)").
 		AddStringArrayFlag(constants.ArgSnapshotTag, nil, "Specify tags to set on the snapshot").
 		AddStringFlag(constants.ArgSnapshotTitle, "", "The title to give a snapshot").
 		AddBoolFlag(constants.ArgTiming, false, "Turn on the query timer").
@@ -90,9 +104,7 @@ func queryRun(cmd *cobra.Command, args []string) {
 	defer func() {
 		logging.LogTime("queryRun end")
 		if r := recover(); r != nil {
-			err = helpers.ToError(r)
-			error_helpers.ShowError(ctx, err)
-
+			err = crash.Recover(ctx, r)
 		}
 		setExitCodeForQueryError(err)
 	}()
@@ -115,13 +127,15 @@ func queryRun(cmd *cobra.Command, args []string) {
 	initData.Result.DisplayMessages()
 
 	if err := initData.Result.Error; err != nil {
-		exitCode = constants.ExitCodeInitializationFailed
+		exitCode = exitcodes.FromError(err, constants.ExitCodeInitializationFailed)
 		error_helpers.FailOnError(err)
 	}
 
 	// register the query exporters if necessary
 	if len(viper.GetStringSlice(constants.ArgExport)) > 0 {
-		err := initData.RegisterExporters(queryExporters()...)
+		exporters, err := queryExporters()
+		error_helpers.FailOnError(err)
+		err = initData.RegisterExporters(exporters...)
 		error_helpers.FailOnError(err)
 
 		// validate required export formats
@@ -140,6 +154,7 @@ func queryRun(cmd *cobra.Command, args []string) {
 		exitCode = constants.ExitCodeSnapshotCreationFailed
 		error_helpers.FailOnError(err)
 	}
+	verbosity.Detailf("Executed %s in %s", target.Name(), time.Since(startTime).Round(time.Millisecond))
 
 	// display the result
 	switch viper.GetString(constants.ArgOutput) {
@@ -200,8 +215,13 @@ func validateQueryArgs(ctx context.Context) error {
 	return nil
 }
 
-func queryExporters() []export.Exporter {
-	return []export.Exporter{&export.SnapshotExporter{}}
+func queryExporters() ([]export.Exporter, error) {
+	// add any custom exporters used by the export args
+	customExporters, err := customexport.GetExporters(viper.GetStringSlice(constants.ArgExport), customexport.SnapshotJson)
+	if err != nil {
+		return nil, err
+	}
+	return append([]export.Exporter{&export.SnapshotExporter{}}, customExporters...), nil
 }
 
 func setExitCodeForQueryError(err error) {
@@ -210,11 +230,7 @@ func setExitCodeForQueryError(err error) {
 		return
 	}
 
-	if errors.Is(err, workspace.ErrorNoModDefinition) {
-		exitCode = constants.ExitCodeNoModFile
-	} else {
-		exitCode = constants.ExitCodeUnknownErrorPanic
-	}
+	exitCode = exitcodes.FromError(err, constants.ExitCodeUnknownErrorPanic)
 }
 
 func snapshotToQueryResult(snap *steampipeconfig.SteampipeSnapshot, startTime time.Time) (*queryresult.Result, error) {
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/powerpipe/internal/display"
+	"github.com/turbot/powerpipe/internal/report"
+)
+
+func reportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report [command]",
+		Args:  cobra.NoArgs,
+		Short: "Build reports from snapshots",
+		Long:  `Build reports from snapshots.`,
+	}
+	cmd.AddCommand(reportAggregateCmd())
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for report", cmdconfig.FlagOptions.WithShortHand("h"))
+
+	return cmd
+}
+
+func reportAggregateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "aggregate [name=]<snapshot> ...",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runReportAggregateCmd,
+		Short: "Roll up benchmark snapshots from multiple environments into one report",
+		Long: `Roll up the benchmark results of snapshots from multiple environments (e.g. accounts or regions)
+into one report, with a column for each environment.
+
+Each environment is named after its snapshot file, or may be named explicitly using name=path, e.g.
+
+  powerpipe report aggregate prod=prod.pps staging=staging.pps --output html > rollup.html`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for report aggregate", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddStringFlag(constants.ArgOutput, constants.OutputFormatTable, "Output format; one of: table, csv, json, html")
+
+	return cmd
+}
+
+func runReportAggregateCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+
+	var environments []*report.Environment
+	for _, arg := range args {
+		name, path := report.ParseEnvironmentArg(arg)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			exitCode = constants.ExitCodeInsufficientOrWrongInputs
+			error_helpers.ShowError(ctx, fmt.Errorf("failed to read snapshot '%s': %w", path, err))
+			return
+		}
+		environments = append(environments, &report.Environment{Name: name, Snapshot: data})
+	}
+
+	aggregate, err := report.NewAggregate(environments)
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	var output io.Reader
+	switch viper.GetString(constants.ArgOutput) {
+	case constants.OutputFormatTable:
+		headers, rows := aggregate.Table()
+		display.ShowWrappedTable(headers, rows, nil)
+		return
+	case constants.OutputFormatJSON:
+		jsonOutput, err := json.MarshalIndent(aggregate, "", "  ")
+		error_helpers.FailOnError(err)
+		fmt.Println(string(jsonOutput)) //nolint:forbidigo // intended output
+		return
+	case constants.OutputFormatCSV:
+		output, err = aggregate.RenderCsv()
+	case constants.OutputFormatHTML:
+		output, err = aggregate.RenderHtml()
+	default:
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, fmt.Errorf("invalid output format '%s' - must be one of: table, csv, json, html", viper.GetString(constants.ArgOutput)))
+		return
+	}
+	if err != nil {
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	_, err = io.Copy(os.Stdout, output)
+	error_helpers.FailOnError(err)
+}
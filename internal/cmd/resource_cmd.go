@@ -69,11 +69,16 @@ func listCmd[T modconfig.ModTreeItem]() *cobra.Command {
 		Short: listCommandShortDescription(typeName),
 		Long:  listCommandLongDescription(typeName)}
 	// initialize hooks
-	cmdconfig.OnCmd(cmd).
+	builder := cmdconfig.OnCmd(cmd).
 		AddVarFlag(enumflag.New(&outputMode, constants.ArgOutput, localconstants.OutputModeIds, enumflag.EnumCaseInsensitive),
 			constants.ArgOutput,
 			fmt.Sprintf("Output format; one of: %s", strings.Join(constants.FlagValues(localconstants.OutputModeIds), ", ")))
 
+	// variables may be listed for a single dependency mod
+	if typeName == schema.BlockTypeVariable {
+		builder.AddStringFlag(localconstants.ArgDependencyMod, "", "Only list the variables of this dependency mod, specified by its alias or name, e.g. aws_compliance or github.com/turbot/steampipe-mod-aws-compliance")
+	}
+
 	return cmd
 }
 
@@ -109,6 +114,7 @@ func getResourceCommands[T modconfig.ModTreeItem]() []*cobra.Command {
 
 	// spacial case for dashboard
 	if typeName == schema.BlockTypeDashboard {
+		res = append(res, dashboardUrlCmd())
 		res = append(res, dashboardChildCommands()...)
 	}
 
@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"context"
+	"log/slog"
 	"os"
+	"time"
 
+	"github.com/fatih/color"
 	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -14,7 +17,15 @@ import (
 	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/statushooks"
 	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/powerpipe/internal/completion"
 	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/crash"
+	"github.com/turbot/powerpipe/internal/eventbus"
+	"github.com/turbot/powerpipe/internal/exitcodes"
+	"github.com/turbot/powerpipe/internal/i18n"
+	"github.com/turbot/powerpipe/internal/locale"
+	"github.com/turbot/powerpipe/internal/telemetry"
+	"github.com/turbot/powerpipe/internal/tlspolicy"
 )
 
 var exitCode int
@@ -47,12 +58,39 @@ func rootCommand() *cobra.Command {
 		AddPersistentStringFlag(constants.ArgConfigPath, "", "Colon separated list of paths to search for workspace files, in order of decreasing precedence").
 		AddPersistentStringFlag(constants.ArgInstallDir, app_specific.DefaultInstallDir, "Path to the installation directory").
 		AddPersistentStringFlag(constants.ArgModLocation, wd, "Path to the workspace working directory").
-		AddPersistentStringFlag(constants.ArgWorkspaceProfile, "default", "Sets the Powerpipe workspace profile")
+		AddPersistentStringFlag(constants.ArgWorkspaceProfile, "default", "Sets the Powerpipe workspace profile").
+		AddPersistentStringFlag(localconstants.ArgTimezone, "", "The timezone used to display timestamps, e.g. Europe/London").
+		AddPersistentStringFlag(localconstants.ArgDateFormat, locale.DateFormatISO, "The format used to display timestamps; one of: iso, us, eu, rfc3339").
+		AddPersistentStringFlag(localconstants.ArgNumberFormat, "", "The format used to display numbers; one of: '1,234.5', '1.234,5', '1 234,5', '1234.5'").
+		AddPersistentStringFlag(localconstants.ArgCurrency, "", "The currency symbol used to display monetary values, e.g. cost or price columns").
+		AddPersistentStringFlag(localconstants.ArgLanguage, i18n.DefaultLanguage, "The language of CLI summaries and report text, or the path of a json catalog file; one of: de, en, es, fr, ja").
+		AddPersistentStringFlag(localconstants.ArgErrorFormat, exitcodes.ErrorFormatText, "The format of errors written to stderr; one of: text, json. If json, a single object containing the exit code, its class and the errors is written once the command completes - see 'powerpipe help exit-codes'").
+		AddPersistentStringFlag(localconstants.ArgTLSMinVersion, tlspolicy.TLSVersion12, "The minimum TLS version of the server and outbound connections; one of: 1.2, 1.3").
+		AddPersistentStringFlag(localconstants.ArgTLSCipherSuites, "", "The cipher suites allowed for TLS 1.2 connections of the server and outbound connections (comma-separated), e.g. 'TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384'; defaults to the secure Go cipher suites").
+		AddPersistentStringFlag(localconstants.ArgCABundle, "", "PEM files of CA certificates to trust for outbound connections, in addition to the system roots (comma-separated), e.g. the CA of a TLS intercepting proxy").
+		AddPersistentBoolFlag(localconstants.ArgFIPS, false, "Require the FIPS 140-3 Go Cryptographic Module, and restrict TLS to FIPS approved versions and cipher suites").
+		AddPersistentBoolFlag(localconstants.ArgQuiet, false, "Only output the results of the command, suppressing progress, timing, summaries and informational messages").
+		AddPersistentBoolFlag(constants.ArgVerbose, false, "Display timing, and details of what the command is doing on stderr")
 
 	rootCmd.AddCommand(
 		serverCmd(),
 		modCmd(),
 		loginCmd(),
+		psCmd(),
+		cancelCmd(),
+		reportCmd(),
+		findingCmd(),
+		snapshotCmd(),
+		cacheCmd(),
+		workspaceCmd(),
+		importCmd(),
+		exportCmd(),
+		updateCliCmd(),
+		telemetryCmd(),
+		debugCmd(),
+		introspectCmd(),
+		completionCmd(),
+		exitCodesHelpTopic(),
 		resourceCmd[*modconfig.Benchmark](),
 		resourceCmd[*modconfig.Control](),
 		resourceCmd[*modconfig.Dashboard](),
@@ -62,20 +100,48 @@ func rootCommand() *cobra.Command {
 
 	// disable auto completion generation, since we don't want to support
 	// powershell yet - and there's no way to disable powershell in the default generator
+	// (completionCmd generates the supported shells instead)
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	completion.RegisterFlagCompletions(rootCmd)
 
 	return rootCmd
 }
 
-func Execute() int {
+func Execute() (res int) {
 	rootCmd := rootCommand()
 	utils.LogTime("cmd.root.Execute start")
 	defer utils.LogTime("cmd.root.Execute end")
 
 	ctx := createRootContext()
 
-	if err := rootCmd.ExecuteContext(ctx); err != nil {
-		exitCode = -1
+	// capture the errors and warnings displayed, so they can be reported as json if --error-format is json
+	errorReporter := exitcodes.CaptureErrors()
+	rootCmd.SetErr(color.Error)
+
+	startTime := time.Now()
+	var cmd *cobra.Command
+	defer func() {
+		// errors initialising the command (e.g. invalid config) are raised as panics
+		if r := recover(); r != nil {
+			err := crash.Recover(ctx, r)
+			if exitCode == 0 {
+				exitCode = exitcodes.FromError(err, constants.ExitCodeUnknownErrorPanic)
+			}
+		}
+		// if telemetry is enabled, record the command
+		telemetry.RecordCommand(ctx, cmd, exitCode, time.Since(startTime))
+		// deliver any queued workspace events
+		eventbus.Flush()
+		if err := errorReporter.Flush(exitCode); err != nil {
+			slog.Debug("could not write error report", "error", err)
+		}
+		res = exitCode
+	}()
+
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	if err != nil {
+		// cobra has displayed the usage error
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
 	}
 	return exitCode
 }
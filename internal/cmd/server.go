@@ -3,8 +3,11 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -14,12 +17,23 @@ import (
 	"github.com/turbot/pipe-fittings/error_helpers"
 	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/powerpipe/internal/approval"
 	localcmdconfig "github.com/turbot/powerpipe/internal/cmdconfig"
 	localconstants "github.com/turbot/powerpipe/internal/constants"
 	"github.com/turbot/powerpipe/internal/dashboardassets"
+	"github.com/turbot/powerpipe/internal/dashboardevents"
 	"github.com/turbot/powerpipe/internal/dashboardserver"
+	"github.com/turbot/powerpipe/internal/db_client"
+	"github.com/turbot/powerpipe/internal/detection"
 	"github.com/turbot/powerpipe/internal/initialisation"
+	"github.com/turbot/powerpipe/internal/introspect"
+	"github.com/turbot/powerpipe/internal/materialize"
+	"github.com/turbot/powerpipe/internal/rbac"
+	"github.com/turbot/powerpipe/internal/routing"
+	"github.com/turbot/powerpipe/internal/schedule"
 	"github.com/turbot/powerpipe/internal/service/api"
+	"github.com/turbot/powerpipe/internal/snapshotdest"
+	"github.com/turbot/powerpipe/internal/tlspolicy"
 	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
 	"gopkg.in/olahol/melody.v1"
 )
@@ -45,7 +59,33 @@ Powerpipe server runs in the foreground; Press Ctrl-C to exit.`,
 		AddStringSliceFlag(constants.ArgVariable, []string{}, "Specify the value of a variable. Multiple --var arguments may be passed.").
 		AddStringFlag(constants.ArgVarFile, "", "Specify a .ppvar file containing variable values.").
 		AddStringFlag(constants.ArgDatabase, app_specific.DefaultDatabase, "Turbot Pipes workspace database").
-		AddIntFlag(constants.ArgDashboardTimeout, 0, "Set a the dashboard execution timeout")
+		AddStringArrayFlag(localconstants.ArgDatabaseSearchPath, nil, "Set a custom search_path for a database, in the form 'database=schema1,schema2'").
+		AddStringArrayFlag(localconstants.ArgDatabaseSearchPathPrefix, nil, "Set a prefix to the search path for a database, in the form 'database=schema1,schema2'").
+		AddStringArrayFlag(localconstants.ArgDatabaseAttach, nil, "Attach a database file to a DuckDB or SQLite database, in the form 'database=alias:path'").
+		AddStringArrayFlag(localconstants.ArgTerraformInput, nil, "Load a Terraform plan (the output of 'terraform show -json') or state file, and attach it to each DuckDB database, in the form 'alias:path' or 'path' (attached as 'terraform')").
+		AddStringArrayFlag(localconstants.ArgDatabaseOnConnect, nil, "Run the SQL in a file on each new connection to a database, in the form 'database=path'").
+		AddStringArrayFlag(localconstants.ArgDatabaseCredentialCmd, nil, "Run a command to get the current connection string of a database with rotated credentials, in the form 'database=path'").
+		AddBoolFlag(localconstants.ArgStrict, false, "Treat workspace warnings, and resources of the workspace mod with no description, as errors").
+		AddStringFlag(localconstants.ArgQueryRewriteConfig, "", "Path to a query rewrite config file, which remaps the schemas and tables of control and dashboard queries").
+		AddIntFlag(constants.ArgDashboardTimeout, 0, "Set a the dashboard execution timeout").
+		AddStringFlag(localconstants.ArgWebhookSecret, "", "Secret used to verify the signature of webhook requests; webhook runs are disabled if not set").
+		AddStringSliceFlag(localconstants.ArgDimension, nil, "Additional columns to include as control dimensions when present, e.g. 'region,tags.owner' (comma-separated)").
+		AddBoolFlag(localconstants.ArgAllowInputCommands, false, "Allow inputs to source their options by running the local command of their 'options_command' tag").
+		AddIntFlag(localconstants.ArgTablePageSize, 0, "Return table data in pages of this many rows, with sorting and filtering performed by the database (0 to disable)").
+		AddIntFlag(localconstants.ArgPanelConcurrency, 0, "The maximum number of panel queries to execute at once across all dashboards; further panels are queued (0 for no limit)").
+		AddIntFlag(localconstants.ArgDashboardConcurrency, 0, "The maximum number of panel queries of each dashboard to execute at once; further panels are queued (0 for no limit)").
+		AddIntFlag(localconstants.ArgMaxConnectionsPerOrigin, 0, "The maximum number of database connections each dashboard session or scheduled job may use at once; contended connections are shared fairly between them (0 for no limit)").
+		AddStringSliceFlag(localconstants.ArgWarmUp, nil, "Dashboards and benchmarks to execute when the server starts, and when they change, to populate caches before they are visited (comma-separated); dashboards and benchmarks tagged 'warm_up = \"true\"' are also warmed up").
+		AddStringFlag(localconstants.ArgAuthPolicy, "", "Path to an auth policy file restricting the dashboards, benchmarks and API operations available to each user; requires an authenticating proxy").
+		AddStringFlag(localconstants.ArgApprovalWebhook, "", "URL to post requests for approval to push the results of scheduled runs to external systems").
+		AddStringFlag(localconstants.ArgApprovalTimeout, "24h", "Duration after which pending approval requests expire, and the results are not pushed").
+		AddStringFlag(localconstants.ArgRecordSession, "", "Record the websocket messages of all dashboard sessions to this file, for replay using 'powerpipe debug replay'; the recording contains the data displayed").
+		AddStringFlag(localconstants.ArgTLSCertificate, "", "Path to a PEM encoded certificate file; if set, the server is served over HTTPS, with the minimum TLS version and cipher suites set by --tls-min-version and --tls-cipher-suites").
+		AddStringFlag(localconstants.ArgTLSKey, "", "Path to the PEM encoded private key file of the --tls-certificate").
+		AddStringFlag(localconstants.ArgContentSecurityPolicy, "", "The Content-Security-Policy header of the dashboard, or 'off' to omit the header; defaults to a strict policy only allowing the scripts of the dashboard").
+		AddStringArrayFlag(localconstants.ArgSchedule, nil, "Run a benchmark or dashboard on a cron schedule, in the form 'target=cron', e.g. 'aws_compliance.benchmark.cis_v300=0 6 * * *'; benchmarks and dashboards tagged 'schedule = \"<cron>\"' are also scheduled").
+		AddStringFlag(localconstants.ArgScheduleSnapshotLocation, "", "The local directory, or cloud storage url (s3://, gs:// or azblob://), the snapshots of scheduled runs and detections are written to; defaults to the snapshots directory of the install dir").
+		AddStringFlag(localconstants.ArgRoutingConfig, "", "Path to a routing config file, which sends the findings of detections to notifiers based on their tags")
 
 	return cmd
 }
@@ -82,18 +122,80 @@ func runServerCmd(cmd *cobra.Command, _ []string) {
 	err := dashboardassets.Ensure(ctx)
 	error_helpers.FailOnError(err)
 
+	// validate the TLS certificate args
+	certFile, keyFile := viper.GetString(localconstants.ArgTLSCertificate), viper.GetString(localconstants.ArgTLSKey)
+	if (certFile == "") != (keyFile == "") {
+		error_helpers.FailOnError(sperr.New("--%s and --%s must be set together", localconstants.ArgTLSCertificate, localconstants.ArgTLSKey))
+	}
+
+	// load the auth policy (if any)
+	authorizer, err := loadAuthorizer()
+	error_helpers.FailOnError(err)
+
 	// setup a new webSocket service
 	webSocket := melody.New()
+	// (the default maximum message size of 512 bytes is too small for dataset uploads)
+	webSocket.Config.MaxMessageSize = dashboardserver.MaxMessageSize
 	// create the dashboardServer
-	dashboardServer, err := dashboardserver.NewServer(ctx, modInitData.WorkspaceEvents, webSocket)
+	dashboardServer, err := dashboardserver.NewServer(ctx, modInitData.WorkspaceEvents, webSocket, authorizer)
+	error_helpers.FailOnError(err)
+
+	// record the dashboard sessions (if enabled)
+	if recordingPath := viper.GetString(localconstants.ArgRecordSession); recordingPath != "" {
+		recorder, err := dashboardserver.NewSessionRecorder(recordingPath)
+		error_helpers.FailOnError(err)
+		defer recorder.Close()
+		dashboardServer.RecordSessions(recorder)
+		dashboardserver.OutputMessage(ctx, fmt.Sprintf("Recording dashboard sessions to %s", recordingPath))
+	}
+
+	// create the gate holding the results of scheduled runs for approval
+	approvalGate, err := newApprovalGate(dashboardServer)
 	error_helpers.FailOnError(err)
 
+	apiOpts := []api.APIServiceOption{
+		api.WithWebSocket(webSocket),
+		api.WithWorkspace(modInitData.Workspace),
+		api.WithDashboardServer(dashboardServer),
+		api.WithHttpPort(serverPort),
+		api.WithAuthorizer(authorizer),
+		api.WithApprovalGate(approvalGate),
+		api.WithCapabilities(introspect.Describe(cmd.Root(), viper.GetString(localconstants.ConfigKeyVersion))),
+		api.WithContentSecurityPolicy(viper.GetString(localconstants.ArgContentSecurityPolicy)),
+	}
+
+	scheme := "http"
+	if certFile != "" {
+		apiOpts = append(apiOpts, api.WithTLSCertificate(certFile, keyFile))
+		scheme = "https"
+	}
+
+	// start any detections defined in the workspace
+	detectionScheduler, err := startDetections(ctx, modInitData, dashboardServer)
+	error_helpers.FailOnError(err)
+	if detectionScheduler != nil {
+		apiOpts = append(apiOpts, api.WithDetectionScheduler(detectionScheduler))
+	}
+
+	// start running any scheduled benchmarks and dashboards
+	scheduler, err := startSchedules(ctx, modInitData, dashboardServer, approvalGate)
+	error_helpers.FailOnError(err)
+	apiOpts = append(apiOpts, api.WithScheduler(scheduler))
+
+	// start maintaining any materialized tables defined in the workspace
+	materializationRefresher, err := startMaterializations(ctx, modInitData)
+	error_helpers.FailOnError(err)
+	if materializationRefresher != nil {
+		apiOpts = append(apiOpts, api.WithMaterializationRefresher(materializationRefresher))
+	}
+
 	// send it over to the powerpipe API Server
-	powerpipeService, err := api.NewAPIService(ctx, api.WithWebSocket(webSocket), api.WithWorkspace(modInitData.Workspace), api.WithHttpPort(serverPort))
+	powerpipeService, err := api.NewAPIService(ctx, apiOpts...)
 	if err != nil {
 		error_helpers.FailOnError(err)
 	}
 	dashboardServer.InitAsync(ctx)
+	dashboardServer.StartWarmUp(ctx)
 
 	//start the API server
 	err = powerpipeService.Start()
@@ -102,8 +204,150 @@ func runServerCmd(cmd *cobra.Command, _ []string) {
 	}
 
 	dashboardserver.OutputReady(ctx, fmt.Sprintf("Dashboard server started on %d and listening on %s", serverPort, viper.GetString(constants.ArgListen)))
-	dashboardserver.OutputMessage(ctx, fmt.Sprintf("Visit http://localhost:%d", serverPort))
+	dashboardserver.OutputMessage(ctx, fmt.Sprintf("Visit %s://localhost:%d", scheme, serverPort))
 	dashboardserver.OutputMessage(ctx, "Press Ctrl+C to exit")
 
 	<-ctx.Done()
 }
+
+// create the approval gate from the approval webhook and timeout args - approval requests and decisions
+// are sent to connected dashboard clients
+func newApprovalGate(dashboardServer *dashboardserver.Server) (*approval.Gate, error) {
+	timeout, err := time.ParseDuration(viper.GetString(localconstants.ArgApprovalTimeout))
+	if err != nil || timeout <= 0 {
+		return nil, sperr.New("invalid value for '--%s': '%s' - must be a positive duration, e.g. '24h'", localconstants.ArgApprovalTimeout, viper.GetString(localconstants.ArgApprovalTimeout))
+	}
+	return approval.NewGate(viper.GetString(localconstants.ArgApprovalWebhook), timeout, tlspolicy.HTTPClient(), dashboardServer.OnApprovalChanged), nil
+}
+
+// if an auth policy is configured, create an authorizer to apply it
+// (if there is no policy, this returns nil and access is not restricted)
+func loadAuthorizer() (*rbac.Authorizer, error) {
+	policyPath := viper.GetString(localconstants.ArgAuthPolicy)
+	if policyPath == "" {
+		return nil, nil
+	}
+	policy, err := rbac.LoadPolicy(policyPath)
+	if err != nil {
+		return nil, err
+	}
+	return rbac.NewAuthorizer(policy), nil
+}
+
+// create and start a detection scheduler if the workspace contains any detections
+func startDetections(ctx context.Context, modInitData *initialisation.InitData[*modconfig.Dashboard], dashboardServer *dashboardserver.Server) (*detection.Scheduler, error) {
+	detections, err := detection.GetDetections(modInitData.Workspace.GetResourceMaps())
+	if err != nil || len(detections) == 0 {
+		return nil, err
+	}
+
+	// the findings of each run are written to a snapshot, as the results of scheduled runs are, and sent to the
+	// notifiers of the routing config (if set)
+	snapshotLocation, err := getScheduleSnapshotLocation()
+	if err != nil {
+		return nil, err
+	}
+	routingConfig, err := loadRoutingConfig()
+	if err != nil {
+		return nil, err
+	}
+	onFindings := func(ctx context.Context, d *detection.Detection, findings []*detection.Finding, err error) {
+		dashboardServer.OnDetectionComplete(ctx, d, findings, err)
+		if routingConfig != nil && err == nil {
+			notifyDetectionFindings(ctx, routingConfig, d, findings)
+		}
+	}
+
+	_, searchPathConfig := db_client.GetDefaultDatabaseConfig()
+	clientMap := db_client.NewClientMap().Add(modInitData.DefaultClient, searchPathConfig)
+	scheduler := detection.NewScheduler(detections, modInitData.Workspace.Mod, clientMap, snapshotLocation, onFindings)
+	scheduler.Start(ctx)
+
+	dashboardserver.OutputMessage(ctx, fmt.Sprintf("Scheduled %d %s", len(detections), utils.Pluralize("detection", len(detections))))
+	return scheduler, nil
+}
+
+// notifyDetectionFindings sends the findings of a detection run to the notifiers of the routes they match
+// (failures are logged, as the detection runs in the background)
+func notifyDetectionFindings(ctx context.Context, config *routing.Config, d *detection.Detection, findings []*detection.Finding) {
+	result := config.RouteDetection(d, findings)
+	if err := config.Notify(ctx, tlspolicy.HTTPClient(), d.Name, result); err != nil {
+		slog.Warn("failed to send detection findings to notifiers", "detection", d.Name, "error", err)
+	}
+	if report := result.UnroutedReport(); report != "" {
+		slog.Warn(report, "detection", d.Name)
+	}
+}
+
+// getScheduleSnapshotLocation returns the location the snapshots of scheduled runs and detections are written to
+func getScheduleSnapshotLocation() (string, error) {
+	snapshotLocation := viper.GetString(localconstants.ArgScheduleSnapshotLocation)
+	if snapshotLocation == "" {
+		return filepath.Join(app_specific.InstallDir, "snapshots"), nil
+	}
+	if snapshotdest.IsDestination(snapshotLocation) {
+		if _, err := snapshotdest.New(snapshotLocation); err != nil {
+			return "", err
+		}
+	}
+	return snapshotLocation, nil
+}
+
+// create and start the scheduler of the benchmarks and dashboards scheduled by tags and the --schedule arg - the
+// scheduler is always started, as schedules may also be created through the API
+func startSchedules(ctx context.Context, modInitData *initialisation.InitData[*modconfig.Dashboard], dashboardServer *dashboardserver.Server, approvalGate *approval.Gate) (*schedule.Scheduler, error) {
+	snapshotLocation, err := getScheduleSnapshotLocation()
+	if err != nil {
+		return nil, err
+	}
+	scheduler := schedule.NewScheduler(dashboardServer, snapshotLocation, approvalGate, schedule.SchedulesPath())
+
+	tagSchedules, err := schedule.GetTagSchedules(modInitData.Workspace.GetResourceMaps())
+	if err != nil {
+		return nil, err
+	}
+	argSchedules, err := schedule.ParseArgSchedules(viper.GetStringSlice(localconstants.ArgSchedule))
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range append(tagSchedules, argSchedules...) {
+		if err := scheduler.Add(s); err != nil {
+			return nil, err
+		}
+	}
+	scheduler.Start(ctx)
+
+	// update the tagged schedules when the workspace changes
+	modInitData.WorkspaceEvents.RegisterDashboardEventHandler(ctx, func(ctx context.Context, event dashboardevents.DashboardEvent) {
+		if _, ok := event.(*dashboardevents.DashboardChanged); !ok {
+			return
+		}
+		tagSchedules, err := schedule.GetTagSchedules(modInitData.WorkspaceEvents.GetResourceMaps())
+		if err != nil {
+			slog.Warn("failed to update schedules", "error", err)
+			return
+		}
+		scheduler.SetTagSchedules(tagSchedules)
+	})
+
+	if count := len(scheduler.List()); count > 0 {
+		dashboardserver.OutputMessage(ctx, fmt.Sprintf("Scheduled %d %s", count, utils.Pluralize("run", count)))
+	}
+	return scheduler, nil
+}
+
+// create and start a materialization refresher if the workspace contains any materializations
+func startMaterializations(ctx context.Context, modInitData *initialisation.InitData[*modconfig.Dashboard]) (*materialize.Refresher, error) {
+	materializations, err := materialize.GetMaterializations(modInitData.Workspace.GetResourceMaps())
+	if err != nil || len(materializations) == 0 {
+		return nil, err
+	}
+
+	_, searchPathConfig := db_client.GetDefaultDatabaseConfig()
+	clientMap := db_client.NewClientMap().Add(modInitData.DefaultClient, searchPathConfig)
+	refresher := materialize.NewRefresher(materializations, modInitData.Workspace.Mod, clientMap)
+	refresher.Start(ctx)
+
+	dashboardserver.OutputMessage(ctx, fmt.Sprintf("Maintaining %d materialized %s", len(materializations), utils.Pluralize("table", len(materializations))))
+	return refresher, nil
+}
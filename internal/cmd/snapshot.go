@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/user"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/display"
+	"github.com/turbot/powerpipe/internal/locale"
+	"github.com/turbot/powerpipe/internal/snapshot"
+)
+
+func snapshotCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot [command]",
+		Args:  cobra.NoArgs,
+		Short: "Manage snapshots",
+		Long:  `Manage snapshots.`,
+	}
+	cmd.AddCommand(snapshotAnnotationCmd())
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for snapshot", cmdconfig.FlagOptions.WithShortHand("h"))
+
+	return cmd
+}
+
+func snapshotAnnotationCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "annotation [command]",
+		Args:  cobra.NoArgs,
+		Short: "Annotate snapshots and their findings",
+		Long: `Annotate snapshots, and the findings within them, with free-text notes such as triage notes or ticket links.
+
+The annotations are stored in the snapshot file, and the annotations of each finding are shown with its
+control results when the snapshot is opened in the dashboard server.`,
+	}
+	cmd.AddCommand(snapshotAnnotationAddCmd())
+	cmd.AddCommand(snapshotAnnotationListCmd())
+	cmd.AddCommand(snapshotAnnotationRemoveCmd())
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for snapshot annotation", cmdconfig.FlagOptions.WithShortHand("h"))
+
+	return cmd
+}
+
+func snapshotAnnotationAddCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add <snapshot> <text>",
+		Args:  cobra.ExactArgs(2),
+		Run:   runSnapshotAnnotationAddCmd,
+		Short: "Annotate a snapshot, or a finding within it",
+		Long: `Annotate a snapshot, or a finding within it, e.g.
+
+  powerpipe snapshot annotation add prod.pps "Accepted risk - see JIRA-123" --finding 63ac7e199dc796a67ba227e9cfa75ae7
+
+Findings are identified by the fingerprint of the control result.`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for snapshot annotation add", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddStringFlag(localconstants.ArgFinding, "", "The fingerprint of the finding to annotate - the snapshot is annotated if not set").
+		AddStringFlag(localconstants.ArgAuthor, "", "The author of the annotation (defaults to the current user)")
+
+	return cmd
+}
+
+func snapshotAnnotationListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list <snapshot>",
+		Args:  cobra.ExactArgs(1),
+		Run:   runSnapshotAnnotationListCmd,
+		Short: "List the annotations of a snapshot",
+		Long:  `List the annotations of a snapshot, and of the findings within it, oldest first.`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for snapshot annotation list", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddStringFlag(constants.ArgOutput, constants.OutputFormatTable, "Output format; one of: table, json")
+
+	return cmd
+}
+
+func snapshotAnnotationRemoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove <snapshot> <id>",
+		Args:  cobra.ExactArgs(2),
+		Run:   runSnapshotAnnotationRemoveCmd,
+		Short: "Remove an annotation from a snapshot",
+		Long:  `Remove an annotation from a snapshot, using the id shown by 'powerpipe snapshot annotation list'.`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for snapshot annotation remove", cmdconfig.FlagOptions.WithShortHand("h"))
+
+	return cmd
+}
+
+func runSnapshotAnnotationAddCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+
+	author := viper.GetString(localconstants.ArgAuthor)
+	if author == "" {
+		if u, err := user.Current(); err == nil {
+			author = u.Username
+		}
+	}
+	annotation, err := snapshot.AddAnnotation(args[0], &snapshot.Annotation{
+		Finding: viper.GetString(localconstants.ArgFinding),
+		Text:    args[1],
+		Author:  author,
+	})
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	fmt.Printf("Added annotation %s to %s\n", annotation.Id, args[0]) //nolint:forbidigo // intended output
+}
+
+func runSnapshotAnnotationListCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+
+	annotations, err := snapshot.ReadAnnotations(args[0])
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	switch viper.GetString(constants.ArgOutput) {
+	case constants.OutputFormatTable:
+		headers := []string{"ID", "CONTROL", "FINDING", "AUTHOR", "CREATED", "TEXT"}
+		var rows [][]string
+		for _, a := range annotations {
+			rows = append(rows, []string{a.Id, a.Control, a.Finding, a.Author, locale.Current().FormatTimeWithZone(a.CreatedAt), a.Text})
+		}
+		display.ShowWrappedTable(headers, rows, nil)
+	case constants.OutputFormatJSON:
+		if annotations == nil {
+			annotations = []*snapshot.Annotation{}
+		}
+		jsonOutput, err := json.MarshalIndent(annotations, "", "  ")
+		error_helpers.FailOnError(err)
+		fmt.Println(string(jsonOutput)) //nolint:forbidigo // intended output
+	default:
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, fmt.Errorf("invalid output format '%s' - must be one of: table, json", viper.GetString(constants.ArgOutput)))
+	}
+}
+
+func runSnapshotAnnotationRemoveCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+
+	if _, err := snapshot.RemoveAnnotation(args[0], args[1]); err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	fmt.Printf("Removed annotation %s from %s\n", args[1], args[0]) //nolint:forbidigo // intended output
+}
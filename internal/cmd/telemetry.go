@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/powerpipe/internal/telemetry"
+)
+
+func telemetryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "telemetry [command]",
+		Args:  cobra.NoArgs,
+		Short: "Manage anonymous usage telemetry",
+		Long: fmt.Sprintf(`Manage anonymous usage telemetry.
+
+Telemetry is disabled unless enabled using 'telemetry enable', and is only sent if a telemetry endpoint is
+configured - powerpipe releases have none, so one must be set using POWERPIPE_TELEMETRY_ENDPOINT, or built into
+the CLI by the maintainers of your build. Setting POWERPIPE_TELEMETRY=off or DO_NOT_TRACK=1 disables telemetry.
+
+Categories:
+  %s  the command run, the names (never the values) of the flags set, its duration and exit code
+  %s    the class of error of a command which failed, e.g. 'mod_install' or 'invalid_input'
+
+Each event also contains a random installation id, the powerpipe version, and the OS and architecture. Use
+'telemetry status' to see the last event sent.`, telemetry.CategoryCommands, telemetry.CategoryErrors),
+	}
+	cmd.AddCommand(telemetryStatusCmd(), telemetryEnableCmd(), telemetryDisableCmd())
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for telemetry", cmdconfig.FlagOptions.WithShortHand("h"))
+
+	return cmd
+}
+
+func telemetryStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Args:  cobra.NoArgs,
+		Run:   runTelemetryStatusCmd,
+		Short: "Show the telemetry settings and the last event sent",
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for telemetry status", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddStringFlag(constants.ArgOutput, constants.OutputFormatTable, "Output format; one of: table, json")
+
+	return cmd
+}
+
+func runTelemetryStatusCmd(cmd *cobra.Command, _ []string) {
+	ctx := cmd.Context()
+
+	settings, err := telemetry.LoadSettings(telemetry.SettingsPath())
+	if err != nil {
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	switch viper.GetString(constants.ArgOutput) {
+	case constants.OutputFormatJSON:
+		status := map[string]any{
+			"categories":              settings.Categories,
+			"endpoint":                telemetry.ConfiguredEndpoint(),
+			"disabled_by_environment": telemetry.DisabledByEnvironment(),
+			"last_event":              settings.LastEvent,
+		}
+		jsonOutput, err := json.MarshalIndent(status, "", "  ")
+		error_helpers.FailOnError(err)
+		fmt.Println(string(jsonOutput)) //nolint:forbidigo // intended output
+	case constants.OutputFormatTable:
+		for _, c := range telemetry.Categories {
+			state := "disabled"
+			if settings.Enabled(c) {
+				state = "enabled"
+			}
+			fmt.Printf("%-10s %s\n", c+":", state) //nolint:forbidigo // intended output
+		}
+		endpoint := telemetry.ConfiguredEndpoint()
+		if endpoint == "" {
+			endpoint = "none - events are not sent"
+		}
+		fmt.Printf("%-10s %s\n", "endpoint:", endpoint) //nolint:forbidigo // intended output
+		if telemetry.DisabledByEnvironment() {
+			fmt.Println("\nTelemetry is disabled by the environment.") //nolint:forbidigo // intended output
+		}
+		if settings.LastEvent != nil {
+			jsonOutput, err := json.MarshalIndent(settings.LastEvent, "", "  ")
+			error_helpers.FailOnError(err)
+			fmt.Printf("\nLast event sent:\n%s\n", jsonOutput) //nolint:forbidigo // intended output
+		}
+	default:
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, fmt.Errorf("invalid output format '%s' - must be one of: table, json", viper.GetString(constants.ArgOutput)))
+	}
+}
+
+func telemetryEnableCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:       "enable [category...]",
+		Args:      cobra.OnlyValidArgs,
+		ValidArgs: telemetry.Categories,
+		Run: func(cmd *cobra.Command, args []string) {
+			runTelemetrySetCmd(cmd, args, true)
+		},
+		Short: "Enable telemetry categories",
+		Long: fmt.Sprintf(`Enable telemetry categories; any of: %s. If no category is given, all are enabled.
+
+Examples:
+
+  # Enable all telemetry
+  powerpipe telemetry enable
+
+  # Only send the class of errors of failed commands
+  powerpipe telemetry enable errors`, strings.Join(telemetry.Categories, ", ")),
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for telemetry enable", cmdconfig.FlagOptions.WithShortHand("h"))
+
+	return cmd
+}
+
+func telemetryDisableCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:       "disable [category...]",
+		Args:      cobra.OnlyValidArgs,
+		ValidArgs: telemetry.Categories,
+		Run: func(cmd *cobra.Command, args []string) {
+			runTelemetrySetCmd(cmd, args, false)
+		},
+		Short: "Disable telemetry categories",
+		Long: fmt.Sprintf(`Disable telemetry categories; any of: %s. If no category is given, all are disabled.
+
+Once all categories are disabled, the installation id and the last event sent are deleted.`, strings.Join(telemetry.Categories, ", ")),
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for telemetry disable", cmdconfig.FlagOptions.WithShortHand("h"))
+
+	return cmd
+}
+
+func runTelemetrySetCmd(cmd *cobra.Command, categories []string, enabled bool) {
+	ctx := cmd.Context()
+
+	settings, err := telemetry.LoadSettings(telemetry.SettingsPath())
+	if err == nil {
+		if enabled {
+			err = settings.Enable(categories...)
+		} else {
+			err = settings.Disable(categories...)
+		}
+	}
+	if err == nil {
+		err = settings.Save()
+	}
+	if err != nil {
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	var enabledCategories []string
+	for _, c := range telemetry.Categories {
+		if settings.Enabled(c) {
+			enabledCategories = append(enabledCategories, c)
+		}
+	}
+	if len(enabledCategories) == 0 {
+		fmt.Println("Telemetry is disabled.") //nolint:forbidigo // intended output
+		return
+	}
+	fmt.Printf("Telemetry is enabled for: %s.\n", strings.Join(enabledCategories, ", ")) //nolint:forbidigo // intended output
+	if telemetry.ConfiguredEndpoint() == "" {
+		fmt.Println("No telemetry endpoint is configured, so no events will be sent.") //nolint:forbidigo // intended output
+	}
+}
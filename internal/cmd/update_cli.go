@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/statushooks"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/selfupdate"
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+)
+
+func updateCliCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update-cli",
+		Args:  cobra.NoArgs,
+		Run:   runUpdateCliCmd,
+		Short: "Update Powerpipe to the latest release",
+		Long: `Update Powerpipe to the latest release of a channel - 'stable', or 'beta' which includes prereleases.
+
+The release is verified using its signed checksums before it is installed. The new binary is checked before and
+after it replaces the current one, and the current binary is restored if either check fails. The previous binary
+is kept, so the update can be undone using --rollback.
+
+Use your package manager to update Powerpipe if it was installed with one, e.g. Homebrew.
+
+Examples:
+
+  # Update to the latest stable release
+  powerpipe update-cli
+
+  # Show the latest beta release, without installing it
+  powerpipe update-cli --channel beta --check
+
+  # Restore the version which was installed before the last update
+  powerpipe update-cli --rollback`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for update-cli", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddStringFlag(localconstants.ArgChannel, selfupdate.ChannelStable, fmt.Sprintf("The release channel to update from; one of: %s", strings.Join(selfupdate.Channels, ", "))).
+		AddBoolFlag(localconstants.ArgCheckOnly, false, "Show the latest release of the channel without installing it").
+		AddBoolFlag(constants.ArgForce, false, "Install the latest release of the channel even if it is not newer than the current version").
+		AddBoolFlag(localconstants.ArgRollback, false, "Restore the version which was installed before the last update")
+
+	return cmd
+}
+
+func runUpdateCliCmd(cmd *cobra.Command, _ []string) {
+	ctx := cmd.Context()
+
+	exePath, err := executablePath()
+	if err != nil {
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	if viper.GetBool(localconstants.ArgRollback) {
+		if err := selfupdate.Rollback(ctx, exePath); err != nil {
+			exitCode = constants.ExitCodeUnknownErrorPanic
+			error_helpers.ShowError(ctx, updateError(err))
+			return
+		}
+		fmt.Printf("Rolled back %s to the previous version.\n", exePath) //nolint:forbidigo // intended output
+		return
+	}
+
+	statushooks.SetStatus(ctx, "Checking for updates…")
+	release, err := selfupdate.LatestRelease(ctx, tlspolicy.HTTPClient(), viper.GetString(localconstants.ArgChannel))
+	statushooks.Done(ctx)
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	current := app_specific.AppVersion
+	isNewer := current == nil || release.Version.GreaterThan(current)
+	if viper.GetBool(localconstants.ArgCheckOnly) {
+		if isNewer {
+			fmt.Printf("Powerpipe %s is available (current version %s).\n", release.Version, current) //nolint:forbidigo // intended output
+		} else {
+			fmt.Printf("Powerpipe %s is up to date.\n", current) //nolint:forbidigo // intended output
+		}
+		return
+	}
+	if !isNewer && !viper.GetBool(constants.ArgForce) {
+		fmt.Printf("Powerpipe %s is up to date.\n", current) //nolint:forbidigo // intended output
+		return
+	}
+	if strings.Contains(filepath.ToSlash(exePath), "/Cellar/") {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, fmt.Errorf("powerpipe was installed using Homebrew - run 'brew upgrade powerpipe' to update it"))
+		return
+	}
+
+	statushooks.SetStatus(ctx, fmt.Sprintf("Installing Powerpipe %s…", release.Version))
+	err = selfupdate.Install(ctx, tlspolicy.HTTPClient(), release, exePath)
+	statushooks.Done(ctx)
+	if err != nil {
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(ctx, updateError(err))
+		return
+	}
+	fmt.Printf("Updated %s to Powerpipe %s.\n", exePath, release.Version) //nolint:forbidigo // intended output
+}
+
+// executablePath returns the path of the running executable, resolving any symlinks
+func executablePath() (string, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exePath)
+}
+
+func updateError(err error) error {
+	if os.IsPermission(err) || strings.Contains(err.Error(), "permission denied") {
+		return fmt.Errorf("%w - the installation directory is not writable, try running the command with sudo", err)
+	}
+	return err
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/filepaths"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/powerpipe/internal/backup"
+)
+
+func workspaceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "workspace [command]",
+		Args:  cobra.NoArgs,
+		Short: "Back up and restore the state of the workspace",
+		Long: `Back up and restore the state of the workspace.
+
+A backup is a single archive containing the lock file and installed mods, the variable files, snapshots and finding
+acknowledgements of the workspace, and the control run history - so a workspace can be moved between machines, or restored after a
+workstation rebuild.`,
+	}
+	cmd.AddCommand(workspaceBackupCmd(), workspaceRestoreCmd())
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for workspace", cmdconfig.FlagOptions.WithShortHand("h"))
+
+	return cmd
+}
+
+func workspaceBackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup [archive]",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   runWorkspaceBackupCmd,
+		Short: "Back up the state of the workspace to an archive",
+		Long: `Back up the state of the workspace to an archive. If no archive path is given, the backup is written to
+powerpipe-workspace-<timestamp>.tar.gz in the current directory.
+
+Examples:
+
+  # Back up the workspace
+  powerpipe workspace backup
+
+  # Back up the workspace to a given file
+  powerpipe workspace backup ~/backups/compliance.tar.gz`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for workspace backup", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddModLocationFlag()
+
+	return cmd
+}
+
+func runWorkspaceBackupCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+
+	archivePath := fmt.Sprintf("powerpipe-workspace-%s.tar.gz", time.Now().Format("20060102150405"))
+	if len(args) > 0 {
+		archivePath = args[0]
+	}
+	if _, err := os.Stat(archivePath); err == nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, fmt.Errorf("'%s' already exists", archivePath))
+		return
+	}
+
+	file, err := os.Create(archivePath)
+	if err != nil {
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	manifest, err := backup.Backup(workspaceBackupPaths(), file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// do not leave a partial backup behind
+		_ = os.Remove(archivePath)
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	fmt.Printf("Backed up %d %s to %s.\n", len(manifest.Files), utils.Pluralize("file", len(manifest.Files)), archivePath) //nolint:forbidigo // intended output
+}
+
+func workspaceRestoreCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore <archive>",
+		Args:  cobra.ExactArgs(1),
+		Run:   runWorkspaceRestoreCmd,
+		Short: "Restore the state of the workspace from an archive",
+		Long: `Restore the state of the workspace from an archive created by 'workspace backup'.
+
+No files are written if any file of the backup already exists, unless --force is set.
+
+Examples:
+
+  # Restore the workspace in the current directory
+  powerpipe workspace restore powerpipe-workspace-20240601120000.tar.gz
+
+  # Restore the workspace to a given directory, overwriting existing files
+  powerpipe workspace restore backup.tar.gz --mod-location ~/compliance --force`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for workspace restore", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddBoolFlag(constants.ArgForce, false, "Overwrite existing files").
+		AddModLocationFlag()
+
+	return cmd
+}
+
+func runWorkspaceRestoreCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	defer file.Close()
+
+	manifest, err := backup.Restore(workspaceBackupPaths(), file, viper.GetBool(constants.ArgForce))
+	if err != nil {
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	fmt.Printf("Restored %d %s from %s, backed up by Powerpipe %s at %s.\n", //nolint:forbidigo // intended output
+		len(manifest.Files), utils.Pluralize("file", len(manifest.Files)), args[0], manifest.PowerpipeVersion, manifest.CreatedAt.Format(time.RFC3339))
+}
+
+func workspaceBackupPaths() backup.Paths {
+	return backup.Paths{
+		Workspace: viper.GetString(constants.ArgModLocation),
+		Internal:  filepaths.EnsureInternalDir(),
+	}
+}
@@ -23,7 +23,14 @@ import (
 	"github.com/turbot/pipe-fittings/steampipeconfig"
 	"github.com/turbot/pipe-fittings/task"
 	"github.com/turbot/pipe-fittings/utils"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/eventbus"
+	"github.com/turbot/powerpipe/internal/exitcodes"
+	"github.com/turbot/powerpipe/internal/i18n"
+	"github.com/turbot/powerpipe/internal/locale"
 	"github.com/turbot/powerpipe/internal/logger"
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+	"github.com/turbot/powerpipe/internal/verbosity"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
 )
@@ -68,6 +75,9 @@ func preRunHook(cmd *cobra.Command, args []string) error {
 
 	logger.Initialize()
 
+	// configure the sinks of workspace lifecycle events (if any)
+	error_helpers.FailOnError(eventbus.Configure(viper.GetString(constants.ArgModLocation), os.Getenv(localconstants.EnvEventSinks)))
+
 	// runScheduledTasks skips running tasks if this instance is the plugin manager
 	waitForTasksChannel = runScheduledTasks(cmd.Context(), cmd, args)
 
@@ -135,6 +145,15 @@ func initGlobalConfig() error_helpers.ErrorAndWarnings {
 	// set global containing the configured install dir (create directory if needed)
 	ensureInstallDirs()
 
+	// set the defaults from the dashboard options in the workspace config
+	if err := setDefaultsFromDashboardOptions(); err != nil {
+		return error_helpers.NewErrorsAndWarning(err)
+	}
+	// and the network options
+	if err := setDefaultsFromNetworkOptions(); err != nil {
+		return error_helpers.NewErrorsAndWarning(err)
+	}
+
 	// set the rest of the defaults from ENV
 	// ENV takes precedence over any default configuration
 	cmdconfig.SetDefaultsFromEnv(envMappings())
@@ -192,7 +211,8 @@ func setPipesTokenDefault(loader *steampipeconfig.WorkspaceProfileLoader[*modcon
 }
 
 // now validate  config values have appropriate values
-// (currently validates telemetry)
+// (currently validates telemetry, the error format, the panel concurrency limits, the output formatting options,
+// the language, the verbosity and the TLS policy)
 func validateConfig() error_helpers.ErrorAndWarnings {
 	var res = error_helpers.ErrorAndWarnings{}
 	telemetry := viper.GetString(constants.ArgTelemetry)
@@ -200,9 +220,36 @@ func validateConfig() error_helpers.ErrorAndWarnings {
 		res.Error = sperr.New(`invalid value of 'telemetry' (%s), must be one of: %s`, telemetry, strings.Join(constants.TelemetryLevels, ", "))
 		return res
 	}
+	errorFormat := viper.GetString(localconstants.ArgErrorFormat)
+	if !helpers.StringSliceContains(exitcodes.ErrorFormats, errorFormat) {
+		res.Error = exitcodes.WithExitCode(sperr.New(`invalid value of '%s' (%s), must be one of: %s`, localconstants.ArgErrorFormat, errorFormat, strings.Join(exitcodes.ErrorFormats, ", ")), constants.ExitCodeInsufficientOrWrongInputs)
+		return res
+	}
+	for _, arg := range []string{localconstants.ArgPanelConcurrency, localconstants.ArgDashboardConcurrency} {
+		if viper.GetInt(arg) < 0 {
+			res.Error = exitcodes.WithExitCode(sperr.New(`invalid value of '%s' (%d), must be 0 (unlimited) or greater`, arg, viper.GetInt(arg)), constants.ExitCodeInsufficientOrWrongInputs)
+			return res
+		}
+	}
 	if _, legacyDiagnosticsSet := os.LookupEnv(plugin.EnvLegacyDiagnosticsLevel); legacyDiagnosticsSet {
 		res.AddWarning(fmt.Sprintf("Environment variable %s is deprecated - use %s", plugin.EnvLegacyDiagnosticsLevel, plugin.EnvDiagnosticsLevel))
 	}
+	if err := locale.Init(); err != nil {
+		res.Error = err
+		return res
+	}
+	if err := i18n.Init(); err != nil {
+		res.Error = err
+		return res
+	}
+	if err := verbosity.Init(); err != nil {
+		res.Error = exitcodes.WithExitCode(err, constants.ExitCodeInsufficientOrWrongInputs)
+		return res
+	}
+	if err := tlspolicy.Init(); err != nil {
+		res.Error = exitcodes.WithExitCode(err, constants.ExitCodeInsufficientOrWrongInputs)
+		return res
+	}
 	res.Error = plugin.ValidateDiagnosticsEnvVar()
 
 	return res
@@ -0,0 +1,70 @@
+package cmdconfig
+
+import (
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+// the dashboard options are set in the workspace config using an options block, e.g.
+//
+//	options "dashboard" {
+//	  max_concurrent_panels               = 20
+//	  max_concurrent_panels_per_dashboard = 5
+//	}
+//
+// these are defaults - they are overridden by the equivalent env vars and command line args
+const dashboardOptionsType = "dashboard"
+
+// DashboardOptions are the options set in an options "dashboard" block
+type DashboardOptions struct {
+	MaxConcurrentPanels             *int `hcl:"max_concurrent_panels,optional"`
+	MaxConcurrentPanelsPerDashboard *int `hcl:"max_concurrent_panels_per_dashboard,optional"`
+}
+
+// set viper defaults from the dashboard options in the workspace config
+func setDefaultsFromDashboardOptions() error {
+	configPaths, err := cmdconfig.GetConfigPath()
+	if err != nil {
+		return err
+	}
+	options, err := LoadDashboardOptions(configPaths)
+	if err != nil {
+		return err
+	}
+	if options.MaxConcurrentPanels != nil {
+		viper.SetDefault(localconstants.ArgPanelConcurrency, *options.MaxConcurrentPanels)
+	}
+	if options.MaxConcurrentPanelsPerDashboard != nil {
+		viper.SetDefault(localconstants.ArgDashboardConcurrency, *options.MaxConcurrentPanelsPerDashboard)
+	}
+	return nil
+}
+
+// LoadDashboardOptions loads the dashboard options from the config files in the given paths
+// paths are in order of decreasing precedence - if an option is set in more than one path, the first is used
+func LoadDashboardOptions(configPaths []string) (*DashboardOptions, error) {
+	res := &DashboardOptions{}
+	for _, configPath := range configPaths {
+		filePaths, err := configFilePaths(configPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, filePath := range filePaths {
+			options, err := loadOptionsFile[DashboardOptions](filePath, dashboardOptionsType)
+			if err != nil {
+				return nil, err
+			}
+			if options == nil {
+				continue
+			}
+			if res.MaxConcurrentPanels == nil {
+				res.MaxConcurrentPanels = options.MaxConcurrentPanels
+			}
+			if res.MaxConcurrentPanelsPerDashboard == nil {
+				res.MaxConcurrentPanelsPerDashboard = options.MaxConcurrentPanelsPerDashboard
+			}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,92 @@
+package cmdconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/app_specific"
+)
+
+type loadDashboardOptionsTest struct {
+	// the content of the config file in each config path, in order of decreasing precedence
+	configs []string
+	// the expected options (-1 if not set)
+	expectedPanels          int
+	expectedPanelsDashboard int
+	err                     bool
+}
+
+var testCasesLoadDashboardOptions = map[string]loadDashboardOptionsTest{
+	"not set": {
+		configs:                 []string{`workspace "default" {}`},
+		expectedPanels:          -1,
+		expectedPanelsDashboard: -1,
+	},
+	"set with other blocks": {
+		configs: []string{`
+workspace "default" {
+  max_parallel = 5
+}
+options "database" {
+  cache = true
+}
+options "dashboard" {
+  max_concurrent_panels               = 20
+  max_concurrent_panels_per_dashboard = 4
+}`},
+		expectedPanels:          20,
+		expectedPanelsDashboard: 4,
+	},
+	"precedence": {
+		configs: []string{
+			`options "dashboard" { max_concurrent_panels_per_dashboard = 2 }`,
+			`options "dashboard" {
+  max_concurrent_panels               = 10
+  max_concurrent_panels_per_dashboard = 5
+}`,
+		},
+		expectedPanels:          10,
+		expectedPanelsDashboard: 2,
+	},
+	"unknown option": {
+		configs: []string{`options "dashboard" { max_panels = 2 }`},
+		err:     true,
+	},
+}
+
+func TestLoadDashboardOptions(t *testing.T) {
+	app_specific.ConfigExtension = ".ppc"
+	for name, test := range testCasesLoadDashboardOptions {
+		var configPaths []string
+		for _, config := range test.configs {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "default.ppc"), []byte(config), 0600); err != nil {
+				t.Fatal(err)
+			}
+			configPaths = append(configPaths, dir)
+		}
+
+		options, err := LoadDashboardOptions(configPaths)
+		if (err != nil) != test.err {
+			t.Errorf("Test: '%s' FAILED : expected error %v, got %v", name, test.err, err)
+			continue
+		}
+		if test.err {
+			continue
+		}
+		if actual := optionValue(options.MaxConcurrentPanels); actual != test.expectedPanels {
+			t.Errorf("Test: '%s' FAILED : expected max_concurrent_panels %d, got %d", name, test.expectedPanels, actual)
+		}
+		if actual := optionValue(options.MaxConcurrentPanelsPerDashboard); actual != test.expectedPanelsDashboard {
+			t.Errorf("Test: '%s' FAILED : expected max_concurrent_panels_per_dashboard %d, got %d", name, test.expectedPanelsDashboard, actual)
+		}
+	}
+}
+
+func optionValue(v *int) int {
+	if v == nil {
+		return -1
+	}
+	return *v
+}
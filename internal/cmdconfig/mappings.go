@@ -72,9 +72,47 @@ func envMappings() map[string]cmdconfig.EnvMapping {
 		constants.EnvPipesHost:       {ConfigVar: []string{constants.ArgPipesHost}, VarType: cmdconfig.EnvVarTypeString},
 		constants.EnvPipesToken:      {ConfigVar: []string{constants.ArgPipesToken}, VarType: cmdconfig.EnvVarTypeString},
 		// powerpipe specific constants
-		localconstants.EnvListen:           {ConfigVar: []string{constants.ArgListen}, VarType: cmdconfig.EnvVarTypeString},
-		localconstants.EnvPort:             {ConfigVar: []string{constants.ArgPort}, VarType: cmdconfig.EnvVarTypeInt},
-		localconstants.EnvBenchmarkTimeout: {ConfigVar: []string{constants.ArgBenchmarkTimeout}, VarType: cmdconfig.EnvVarTypeInt},
-		localconstants.EnvDashboardTimeout: {ConfigVar: []string{constants.ArgDashboardTimeout}, VarType: cmdconfig.EnvVarTypeInt},
+		localconstants.EnvListen:                   {ConfigVar: []string{constants.ArgListen}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvPort:                     {ConfigVar: []string{constants.ArgPort}, VarType: cmdconfig.EnvVarTypeInt},
+		localconstants.EnvBenchmarkTimeout:         {ConfigVar: []string{constants.ArgBenchmarkTimeout}, VarType: cmdconfig.EnvVarTypeInt},
+		localconstants.EnvDashboardTimeout:         {ConfigVar: []string{constants.ArgDashboardTimeout}, VarType: cmdconfig.EnvVarTypeInt},
+		localconstants.EnvWebhookSecret:            {ConfigVar: []string{localconstants.ArgWebhookSecret}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvDimensions:               {ConfigVar: []string{localconstants.ArgDimension}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvTablePageSize:            {ConfigVar: []string{localconstants.ArgTablePageSize}, VarType: cmdconfig.EnvVarTypeInt},
+		localconstants.EnvAuthPolicy:               {ConfigVar: []string{localconstants.ArgAuthPolicy}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvDatabaseSearchPath:       {ConfigVar: []string{localconstants.ArgDatabaseSearchPath}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvDatabaseSearchPathPrefix: {ConfigVar: []string{localconstants.ArgDatabaseSearchPathPrefix}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvRoutingConfig:            {ConfigVar: []string{localconstants.ArgRoutingConfig}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvMaxDuration:              {ConfigVar: []string{localconstants.ArgMaxDuration}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvMaxCostRows:              {ConfigVar: []string{localconstants.ArgMaxCostRows}, VarType: cmdconfig.EnvVarTypeInt},
+		localconstants.EnvTimezone:                 {ConfigVar: []string{localconstants.ArgTimezone}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvDateFormat:               {ConfigVar: []string{localconstants.ArgDateFormat}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvNumberFormat:             {ConfigVar: []string{localconstants.ArgNumberFormat}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvCurrency:                 {ConfigVar: []string{localconstants.ArgCurrency}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvCaptureQueryPlans:        {ConfigVar: []string{localconstants.ArgCaptureQueryPlans}, VarType: cmdconfig.EnvVarTypeBool},
+		localconstants.EnvMaxRowsPerControl:        {ConfigVar: []string{localconstants.ArgMaxRowsPerControl}, VarType: cmdconfig.EnvVarTypeInt},
+		localconstants.EnvApprovalWebhook:          {ConfigVar: []string{localconstants.ArgApprovalWebhook}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvApprovalTimeout:          {ConfigVar: []string{localconstants.ArgApprovalTimeout}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvLanguage:                 {ConfigVar: []string{localconstants.ArgLanguage}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvDatabaseAttach:           {ConfigVar: []string{localconstants.ArgDatabaseAttach}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvDatabaseOnConnect:        {ConfigVar: []string{localconstants.ArgDatabaseOnConnect}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvDatabaseCredentialCmd:    {ConfigVar: []string{localconstants.ArgDatabaseCredentialCmd}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvTerraformInput:           {ConfigVar: []string{localconstants.ArgTerraformInput}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvQueryRewriteConfig:       {ConfigVar: []string{localconstants.ArgQueryRewriteConfig}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvFanOutConnections:        {ConfigVar: []string{localconstants.ArgFanOutConnections}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvErrorFormat:              {ConfigVar: []string{localconstants.ArgErrorFormat}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvRecordSession:            {ConfigVar: []string{localconstants.ArgRecordSession}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvPanelConcurrency:         {ConfigVar: []string{localconstants.ArgPanelConcurrency}, VarType: cmdconfig.EnvVarTypeInt},
+		localconstants.EnvDashboardConcurrency:     {ConfigVar: []string{localconstants.ArgDashboardConcurrency}, VarType: cmdconfig.EnvVarTypeInt},
+		localconstants.EnvStrict:                   {ConfigVar: []string{localconstants.ArgStrict}, VarType: cmdconfig.EnvVarTypeBool},
+		localconstants.EnvMaxConnectionsPerOrigin:  {ConfigVar: []string{localconstants.ArgMaxConnectionsPerOrigin}, VarType: cmdconfig.EnvVarTypeInt},
+		localconstants.EnvTLSMinVersion:            {ConfigVar: []string{localconstants.ArgTLSMinVersion}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvTLSCipherSuites:          {ConfigVar: []string{localconstants.ArgTLSCipherSuites}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvFIPS:                     {ConfigVar: []string{localconstants.ArgFIPS}, VarType: cmdconfig.EnvVarTypeBool},
+		localconstants.EnvTLSCertificate:           {ConfigVar: []string{localconstants.ArgTLSCertificate}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvTLSKey:                   {ConfigVar: []string{localconstants.ArgTLSKey}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvCABundle:                 {ConfigVar: []string{localconstants.ArgCABundle}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvContentSecurityPolicy:    {ConfigVar: []string{localconstants.ArgContentSecurityPolicy}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvScheduleSnapshotLocation: {ConfigVar: []string{localconstants.ArgScheduleSnapshotLocation}, VarType: cmdconfig.EnvVarTypeString},
 	}
 }
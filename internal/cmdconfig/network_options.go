@@ -0,0 +1,73 @@
+package cmdconfig
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+// the network options are set in the workspace config using an options block, e.g.
+//
+//	options "network" {
+//	  ca_bundles = ["/etc/ssl/certs/acme-proxy-ca.pem"]
+//	}
+//
+// ca_bundles are PEM files of CA certificates trusted by outbound connections in addition to the system roots, e.g.
+// the CA of a TLS intercepting proxy - relative paths are relative to the config file
+// these are defaults - they are overridden by the equivalent env vars and command line args
+const networkOptionsType = "network"
+
+// NetworkOptions are the options set in an options "network" block
+type NetworkOptions struct {
+	CABundles []string `hcl:"ca_bundles,optional"`
+}
+
+// set viper defaults from the network options in the workspace config
+func setDefaultsFromNetworkOptions() error {
+	configPaths, err := cmdconfig.GetConfigPath()
+	if err != nil {
+		return err
+	}
+	options, err := LoadNetworkOptions(configPaths)
+	if err != nil {
+		return err
+	}
+	if len(options.CABundles) > 0 {
+		viper.SetDefault(localconstants.ArgCABundle, strings.Join(options.CABundles, ","))
+	}
+	return nil
+}
+
+// LoadNetworkOptions loads the network options from the config files in the given paths
+// paths are in order of decreasing precedence - if an option is set in more than one path, the first is used
+func LoadNetworkOptions(configPaths []string) (*NetworkOptions, error) {
+	res := &NetworkOptions{}
+	for _, configPath := range configPaths {
+		filePaths, err := configFilePaths(configPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, filePath := range filePaths {
+			options, err := loadOptionsFile[NetworkOptions](filePath, networkOptionsType)
+			if err != nil {
+				return nil, err
+			}
+			if options == nil {
+				continue
+			}
+			if res.CABundles == nil && options.CABundles != nil {
+				res.CABundles = make([]string, len(options.CABundles))
+				for i, caBundle := range options.CABundles {
+					if !filepath.IsAbs(caBundle) {
+						caBundle = filepath.Join(filepath.Dir(filePath), caBundle)
+					}
+					res.CABundles[i] = caBundle
+				}
+			}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,76 @@
+package cmdconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/app_specific"
+)
+
+type loadNetworkOptionsTest struct {
+	// the content of the config file in each config path, in order of decreasing precedence
+	configs []string
+	// the expected ca bundles - relative paths are relative to the first config path
+	expected []string
+	err      bool
+}
+
+var testCasesLoadNetworkOptions = map[string]loadNetworkOptionsTest{
+	"not set": {
+		configs: []string{`options "dashboard" { max_concurrent_panels = 5 }`},
+	},
+	"absolute": {
+		configs:  []string{`options "network" { ca_bundles = ["/etc/ssl/certs/acme-proxy-ca.pem"] }`},
+		expected: []string{"/etc/ssl/certs/acme-proxy-ca.pem"},
+	},
+	"relative": {
+		configs:  []string{`options "network" { ca_bundles = ["certs/acme-proxy-ca.pem", "/etc/ssl/acme.pem"] }`},
+		expected: []string{"certs/acme-proxy-ca.pem", "/etc/ssl/acme.pem"},
+	},
+	"precedence": {
+		configs: []string{
+			`options "network" { ca_bundles = ["/etc/ssl/a.pem"] }`,
+			`options "network" { ca_bundles = ["/etc/ssl/b.pem"] }`,
+		},
+		expected: []string{"/etc/ssl/a.pem"},
+	},
+	"unknown option": {
+		configs: []string{`options "network" { proxy = "http://proxy.acme.com:3128" }`},
+		err:     true,
+	},
+}
+
+func TestLoadNetworkOptions(t *testing.T) {
+	app_specific.ConfigExtension = ".ppc"
+	for name, test := range testCasesLoadNetworkOptions {
+		var configPaths []string
+		for _, config := range test.configs {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "default.ppc"), []byte(config), 0600); err != nil {
+				t.Fatal(err)
+			}
+			configPaths = append(configPaths, dir)
+		}
+
+		options, err := LoadNetworkOptions(configPaths)
+		if (err != nil) != test.err {
+			t.Errorf("Test: '%s' FAILED : expected error %v, got %v", name, test.err, err)
+			continue
+		}
+		if test.err {
+			continue
+		}
+		var expected []string
+		for _, caBundle := range test.expected {
+			if !filepath.IsAbs(caBundle) {
+				caBundle = filepath.Join(configPaths[0], caBundle)
+			}
+			expected = append(expected, caBundle)
+		}
+		if !reflect.DeepEqual(options.CABundles, expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, expected, options.CABundles)
+		}
+	}
+}
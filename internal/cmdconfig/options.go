@@ -0,0 +1,67 @@
+package cmdconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/schema"
+)
+
+var optionsFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       schema.BlockTypeOptions,
+			LabelNames: []string{"type"},
+		},
+	},
+}
+
+// configFilePaths returns the config files in the config path, sorted
+func configFilePaths(configPath string) ([]string, error) {
+	filePaths, err := filepath.Glob(filepath.Join(configPath, "*"+app_specific.ConfigExtension))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(filePaths)
+	return filePaths, nil
+}
+
+// loadOptionsFile decodes the options block of the given type in the config file - nil is returned if the file does
+// not contain an options block of the type
+func loadOptionsFile[T any](filePath, optionsType string) (*T, error) {
+	fileData, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	file, diags := hclparse.NewParser().ParseHCL(fileData, filePath)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %s: %s", filePath, diags.Error())
+	}
+	// the file may contain other blocks, which are loaded elsewhere
+	content, _, diags := file.Body.PartialContent(optionsFileSchema)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %s: %s", filePath, diags.Error())
+	}
+
+	var res *T
+	for _, block := range content.Blocks {
+		// other options types are loaded elsewhere
+		if block.Labels[0] != optionsType {
+			continue
+		}
+		if res != nil {
+			return nil, fmt.Errorf("failed to parse %s: duplicate options type '%s'", filePath, optionsType)
+		}
+		res = new(T)
+		if diags := gohcl.DecodeBody(block.Body, nil, res); diags.HasErrors() {
+			return nil, fmt.Errorf("failed to parse %s: %s", filePath, diags.Error())
+		}
+	}
+	return res, nil
+}
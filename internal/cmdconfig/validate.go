@@ -11,6 +11,7 @@ import (
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/error_helpers"
 	"github.com/turbot/pipe-fittings/steampipeconfig"
+	"github.com/turbot/powerpipe/internal/snapshotdest"
 )
 
 func ValidateSnapshotArgs(ctx context.Context) error {
@@ -62,6 +63,15 @@ func validateSnapshotLocation(ctx context.Context, cloudToken string) error {
 		return setSnapshotLocationFromDefaultWorkspace(ctx, cloudToken)
 	}
 
+	// if it is the url of a cloud storage bucket, check the url is valid
+	if snapshotdest.IsDestination(snapshotLocation) {
+		if viper.GetBool(constants.ArgShare) {
+			return fmt.Errorf("snapshots may only be shared with a Turbot Pipes workspace - use --snapshot to write the snapshot to %s", snapshotLocation)
+		}
+		_, err := snapshotdest.New(snapshotLocation)
+		return err
+	}
+
 	// if it is NOT a workspace handle, assume it is a local file location:
 	// tildefy it and ensure it exists
 	if !steampipeconfig.IsCloudWorkspaceIdentifier(snapshotLocation) {
@@ -0,0 +1,211 @@
+package completion
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/parse"
+)
+
+// the value lists of flags are documented in their usage, e.g. "Output format; one of: table, json" -
+// the values are completed from this, so the completions always match the documented values
+var usageValuesPattern = regexp.MustCompile(`(?:one of|any of|supported formats): (.*)$`)
+
+// a value in a usage value list - either a quoted value (which may contain commas) or a comma separated value
+var usageValuePattern = regexp.MustCompile(`\s*'([^']*)'|([^,]+)`)
+
+// RegisterFlagCompletions registers value completions for the flags of the command and all of its subcommands:
+//   - flags which document their values in their usage complete those values
+//   - '--workspace' completes the workspace profile names, and '--database' the databases of the workspace profiles
+//   - '--mod-location' completes directories, and '--var-file' variable files
+func RegisterFlagCompletions(cmd *cobra.Command) {
+	register := func(flag *pflag.Flag) {
+		if _, ok := cmd.GetFlagCompletionFunc(flag.Name); ok {
+			return
+		}
+		var completionFunc func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective)
+		switch flag.Name {
+		case constants.ArgWorkspaceProfile:
+			completionFunc = staticCompletion(ProfileNames, false)
+		case constants.ArgDatabase:
+			completionFunc = staticCompletion(DatabaseNames, false)
+		case constants.ArgModLocation:
+			completionFunc = func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+				return nil, cobra.ShellCompDirectiveFilterDirs
+			}
+		case constants.ArgVarFile:
+			completionFunc = func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+				var extensions []string
+				for _, ext := range app_specific.VariablesExtensions {
+					extensions = append(extensions, strings.TrimPrefix(ext, "."))
+				}
+				return extensions, cobra.ShellCompDirectiveFilterFileExt
+			}
+		default:
+			values := UsageValues(flag.Usage)
+			if len(values) == 0 {
+				return
+			}
+			// slice flags may be given a comma separated list of values
+			slice := strings.HasSuffix(flag.Value.Type(), "Slice")
+			completionFunc = staticCompletion(func() []string { return values }, slice)
+		}
+		// the flag may be registered by a parent command (persistent flags)
+		_ = cmd.RegisterFlagCompletionFunc(flag.Name, completionFunc)
+	}
+	cmd.LocalFlags().VisitAll(register)
+	for _, child := range cmd.Commands() {
+		RegisterFlagCompletions(child)
+	}
+}
+
+func staticCompletion(getValues func() []string, slice bool) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return CompleteValues(getValues(), toComplete, slice)
+	}
+}
+
+// UsageValues returns the values documented in a flag usage, e.g. "Output format; one of: table, json"
+// annotations are removed, e.g. 'pps (snapshot)' completes as 'pps', and placeholders are completed as their
+// prefix, e.g. 'tag:<key>' completes as 'tag:'
+func UsageValues(usage string) []string {
+	match := usageValuesPattern.FindStringSubmatch(usage)
+	if match == nil {
+		return nil
+	}
+	var res []string
+	seen := make(map[string]struct{})
+	for _, m := range usageValuePattern.FindAllStringSubmatch(match[1], -1) {
+		value := m[1]
+		if value == "" {
+			value = strings.TrimSpace(m[2])
+			if i := strings.Index(value, " ("); i >= 0 {
+				value = value[:i]
+			}
+			if i := strings.Index(value, "<"); i >= 0 {
+				value = value[:i]
+			}
+		}
+		if _, ok := seen[value]; ok || value == "" {
+			continue
+		}
+		seen[value] = struct{}{}
+		res = append(res, value)
+	}
+	return res
+}
+
+// CompleteValues returns the values matching the value being completed
+// if slice is set, the value being completed may be a comma separated list, and only its last element is completed
+// values ending in ':' are a prefix for a user provided value, so no space is added after them
+func CompleteValues(values []string, toComplete string, slice bool) ([]string, cobra.ShellCompDirective) {
+	prefix := ""
+	if slice {
+		if i := strings.LastIndex(toComplete, ","); i >= 0 {
+			prefix, toComplete = toComplete[:i+1], toComplete[i+1:]
+		}
+	}
+	directive := cobra.ShellCompDirectiveNoFileComp
+	var res []string
+	for _, v := range values {
+		if strings.HasPrefix(v, toComplete) {
+			res = append(res, prefix+v)
+			if strings.HasSuffix(v, ":") {
+				directive |= cobra.ShellCompDirectiveNoSpace
+			}
+		}
+	}
+	return res, directive
+}
+
+// ProfileNames returns the names of the workspace profiles in the config path, and the default profile
+func ProfileNames() []string {
+	names := map[string]struct{}{"default": {}}
+	for _, profile := range loadProfiles() {
+		names[profile.ProfileName] = struct{}{}
+	}
+	return sortedKeys(names)
+}
+
+// DatabaseNames returns the databases of the workspace profiles in the config path, and the default database
+func DatabaseNames() []string {
+	names := map[string]struct{}{app_specific.DefaultDatabase: {}}
+	for _, profile := range loadProfiles() {
+		if profile.Database != nil && *profile.Database != "" {
+			names[*profile.Database] = struct{}{}
+		}
+	}
+	return sortedKeys(names)
+}
+
+// loadProfiles loads the workspace profiles of each directory of the config path - directories which fail to load are ignored
+func loadProfiles() []*modconfig.PowerpipeWorkspaceProfile {
+	configPaths, err := cmdconfig.GetConfigPath()
+	if err != nil {
+		return nil
+	}
+	var res []*modconfig.PowerpipeWorkspaceProfile
+	for _, p := range configPaths {
+		profiles, err := parse.LoadWorkspaceProfiles[*modconfig.PowerpipeWorkspaceProfile](p)
+		if err != nil {
+			continue
+		}
+		for _, profile := range profiles {
+			res = append(res, profile)
+		}
+	}
+	return res
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// NushellCompletion returns a nushell script which adds an external completer for the command, using the cobra
+// '__complete' command - completion of other commands is passed to any existing external completer
+func NushellCompletion(name string) string {
+	return fmt.Sprintf(`# nushell completion for %[1]s
+#
+# to load completions in every session, save this script and source it from your config.nu, e.g.
+#   %[1]s completion nushell | save -f ~/.config/nushell/%[1]s_completion.nu
+#   source ~/.config/nushell/%[1]s_completion.nu
+
+let %[2]s_completer = {|spans|
+    # the last span is the word being completed (which may be empty)
+    ^%[1]s __complete ...($spans | skip 1)
+    | lines
+    # the final line is the completion directive
+    | where {|line| not ($line | str starts-with ":") and ($line | str trim) != "" }
+    | each {|line|
+        let parts = ($line | split row "\t")
+        { value: ($parts | first), description: ($parts | get -i 1 | default "") }
+    }
+}
+
+let %[2]s_previous_completer = ($env.config.completions.external.completer? | default null)
+
+$env.config.completions.external.enable = true
+$env.config.completions.external.completer = {|spans|
+    if ($spans | first) == "%[1]s" {
+        do $%[2]s_completer $spans
+    } else if $%[2]s_previous_completer != null {
+        do $%[2]s_previous_completer $spans
+    } else {
+        null
+    }
+}
+`, name, strings.ReplaceAll(name, "-", "_"))
+}
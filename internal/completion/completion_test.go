@@ -0,0 +1,93 @@
+package completion
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+type usageValuesTest struct {
+	usage    string
+	expected []string
+}
+
+var testCasesUsageValues = map[string]usageValuesTest{
+	"one of": {
+		usage:    "Output format; one of: table, json",
+		expected: []string{"table", "json"},
+	},
+	"duplicates": {
+		usage:    "Output format; one of: text, json, text",
+		expected: []string{"text", "json"},
+	},
+	"annotations and placeholders": {
+		usage:    "Export output to file, supported formats: csv, pps (snapshot), custom:<format> (custom exporter)",
+		expected: []string{"csv", "pps", "custom:"},
+	},
+	"any of": {
+		usage:    "Group results; any of: benchmark, severity, tag:<key> (comma-separated)",
+		expected: []string{"benchmark", "severity", "tag:"},
+	},
+	"quoted values": {
+		usage:    "The format used to display numbers; one of: '1,234.5', '1.234,5'",
+		expected: []string{"1,234.5", "1.234,5"},
+	},
+	"no values": {
+		usage: "Web server port",
+	},
+}
+
+func TestUsageValues(t *testing.T) {
+	for name, test := range testCasesUsageValues {
+		values := UsageValues(test.usage)
+		if !reflect.DeepEqual(values, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, values)
+		}
+	}
+}
+
+type completeValuesTest struct {
+	toComplete      string
+	slice           bool
+	expected        []string
+	expectDirective cobra.ShellCompDirective
+}
+
+var testCompleteValuesValues = []string{"csv", "html", "json", "tag:"}
+
+var testCasesCompleteValues = map[string]completeValuesTest{
+	"all": {
+		expected:        []string{"csv", "html", "json", "tag:"},
+		expectDirective: cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace,
+	},
+	"prefix": {
+		toComplete:      "h",
+		expected:        []string{"html"},
+		expectDirective: cobra.ShellCompDirectiveNoFileComp,
+	},
+	"slice element": {
+		toComplete:      "csv,j",
+		slice:           true,
+		expected:        []string{"csv,json"},
+		expectDirective: cobra.ShellCompDirectiveNoFileComp,
+	},
+	"comma in non slice value": {
+		toComplete:      "csv,j",
+		expectDirective: cobra.ShellCompDirectiveNoFileComp,
+	},
+	"placeholder prefix": {
+		toComplete:      "t",
+		expected:        []string{"tag:"},
+		expectDirective: cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace,
+	},
+}
+
+func TestCompleteValues(t *testing.T) {
+	for name, test := range testCasesCompleteValues {
+		values, directive := CompleteValues(testCompleteValuesValues, test.toComplete, test.slice)
+		if !reflect.DeepEqual(values, test.expected) || directive != test.expectDirective {
+			t.Errorf("Test: '%s' FAILED : expected %v (%d), got %v (%d)", name, test.expected, test.expectDirective, values, directive)
+		}
+	}
+}
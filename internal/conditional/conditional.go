@@ -0,0 +1,223 @@
+package conditional
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+// resources may be conditionally included using an 'enabled' tag. As tags are evaluated when the mod is
+// loaded, this may reference variables, e.g.
+//
+//	benchmark "govcloud" {
+//	  children = [...]
+//	  tags = {
+//	    enabled = var.govcloud ? "true" : "false"
+//	  }
+//	}
+//
+// (a tag is used rather than an 'enabled' attribute, as the resource schemas are defined by pipe-fittings, so do not
+// accept additional attributes - the tag value must be 'true' or 'false')
+//
+// disabled controls, benchmarks, dashboards and dashboard panels are removed from the resource maps, and from the
+// children of their parents and of the mod. The descendants of a disabled resource are also removed, unless they are
+// reachable from an enabled top level resource, i.e. are also children of an enabled parent
+const TagEnabled = "enabled"
+
+// RemoveDisabled removes all resources with an 'enabled' tag which evaluates to false, and their descendants which
+// are not also descendants of enabled resources
+func RemoveDisabled(resourceMaps *modconfig.ResourceMaps) error {
+	disabled, err := getDisabled(resourceMaps)
+	if err != nil {
+		return err
+	}
+	if len(disabled) == 0 {
+		return nil
+	}
+	removed, err := getRemoved(resourceMaps, disabled)
+	if err != nil {
+		return err
+	}
+
+	// remove the removed resources from their parents
+	for _, b := range resourceMaps.Benchmarks {
+		if children, changed := enabledChildren(b.GetChildren(), removed); changed {
+			b.SetChildren(children)
+			b.ChildNameStrings = childNames(children)
+		}
+	}
+	for _, d := range resourceMaps.Dashboards {
+		if children, changed := enabledChildren(d.GetChildren(), removed); changed {
+			d.SetChildren(children)
+			d.ChildNames = childNames(children)
+		}
+	}
+	for _, c := range resourceMaps.DashboardContainers {
+		if children, changed := enabledChildren(c.GetChildren(), removed); changed {
+			c.SetChildren(children)
+			c.ChildNames = childNames(children)
+		}
+	}
+	for _, mod := range getMods(resourceMaps) {
+		if children, changed := enabledChildren(mod.GetChildren(), removed); changed {
+			setModChildren(mod, children)
+		}
+	}
+
+	// now remove from the resource maps
+	for name, resource := range removed {
+		removeResource(resourceMaps, name, resource)
+	}
+	return nil
+}
+
+// getDisabled returns a map of all disabled resources, keyed by name
+func getDisabled(resourceMaps *modconfig.ResourceMaps) (map[string]modconfig.HclResource, error) {
+	disabled := make(map[string]modconfig.HclResource)
+	err := resourceMaps.WalkResources(func(resource modconfig.HclResource) (bool, error) {
+		if !supportsEnabled(resource) {
+			return true, nil
+		}
+		value, ok := resource.GetTags()[TagEnabled]
+		if !ok {
+			return true, nil
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, fmt.Errorf("invalid %s tag '%s' for %s: expected 'true' or 'false'", TagEnabled, value, resource.Name())
+		}
+		if !enabled {
+			disabled[resource.Name()] = resource
+		}
+		return true, nil
+	})
+	return disabled, err
+}
+
+// getRemoved returns a map of the resources to remove, keyed by name - these are the resources which support the
+// 'enabled' tag and are not reachable from an enabled top level resource (i.e. a resource which is not the child of
+// another resource) through enabled children
+func getRemoved(resourceMaps *modconfig.ResourceMaps, disabled map[string]modconfig.HclResource) (map[string]modconfig.HclResource, error) {
+	removed := make(map[string]modconfig.HclResource)
+	children := make(map[string]struct{})
+	err := resourceMaps.WalkResources(func(resource modconfig.HclResource) (bool, error) {
+		if supportsEnabled(resource) {
+			removed[resource.Name()] = resource
+		}
+		// (the children of a mod are its top level resources)
+		if treeItem, ok := resource.(modconfig.ModTreeItem); ok {
+			if _, isMod := resource.(*modconfig.Mod); !isMod {
+				for _, child := range treeItem.GetChildren() {
+					children[child.Name()] = struct{}{}
+				}
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// walk the enabled descendants of the enabled top level resources - these are not removed
+	var reach func(resource modconfig.HclResource)
+	reach = func(resource modconfig.HclResource) {
+		if _, ok := disabled[resource.Name()]; ok {
+			return
+		}
+		delete(removed, resource.Name())
+		if treeItem, ok := resource.(modconfig.ModTreeItem); ok {
+			for _, child := range treeItem.GetChildren() {
+				if _, ok := removed[child.Name()]; ok {
+					reach(child)
+				}
+			}
+		}
+	}
+	for name, resource := range maps.Clone(removed) {
+		if _, ok := children[name]; !ok {
+			reach(resource)
+		}
+	}
+	return removed, nil
+}
+
+// getMods returns the mods of the resource maps
+func getMods(resourceMaps *modconfig.ResourceMaps) []*modconfig.Mod {
+	var res []*modconfig.Mod
+	for _, mod := range resourceMaps.Mods {
+		res = append(res, mod)
+	}
+	if resourceMaps.Mod != nil && !slices.Contains(res, resourceMaps.Mod) {
+		res = append(res, resourceMaps.Mod)
+	}
+	return res
+}
+
+// setModChildren sets the children of the mod - Mod has no setter, so the children are set on a copy of its
+// ModTreeItemImpl (using the setter of Benchmark), which then replaces it
+func setModChildren(mod *modconfig.Mod, children []modconfig.ModTreeItem) {
+	b := &modconfig.Benchmark{ModTreeItemImpl: *mod.GetModTreeItemImpl()}
+	b.SetChildren(children)
+	*mod.GetModTreeItemImpl() = b.ModTreeItemImpl
+}
+
+// enabledChildren returns the children which are not removed, and whether any were removed
+func enabledChildren(children []modconfig.ModTreeItem, removed map[string]modconfig.HclResource) ([]modconfig.ModTreeItem, bool) {
+	var res []modconfig.ModTreeItem
+	for _, child := range children {
+		if _, ok := removed[child.Name()]; !ok {
+			res = append(res, child)
+		}
+	}
+	return res, len(res) != len(children)
+}
+
+func childNames(children []modconfig.ModTreeItem) []string {
+	res := make([]string, len(children))
+	for i, child := range children {
+		res[i] = child.Name()
+	}
+	return res
+}
+
+func supportsEnabled(resource modconfig.HclResource) bool {
+	switch resource.(type) {
+	case *modconfig.Benchmark, *modconfig.Control, *modconfig.Dashboard, *modconfig.DashboardCard,
+		*modconfig.DashboardChart, *modconfig.DashboardContainer, *modconfig.DashboardFlow, *modconfig.DashboardGraph,
+		*modconfig.DashboardHierarchy, *modconfig.DashboardImage, *modconfig.DashboardTable, *modconfig.DashboardText:
+		return true
+	}
+	return false
+}
+
+func removeResource(resourceMaps *modconfig.ResourceMaps, name string, resource modconfig.HclResource) {
+	switch resource.(type) {
+	case *modconfig.Benchmark:
+		delete(resourceMaps.Benchmarks, name)
+	case *modconfig.Control:
+		delete(resourceMaps.Controls, name)
+	case *modconfig.Dashboard:
+		delete(resourceMaps.Dashboards, name)
+	case *modconfig.DashboardCard:
+		delete(resourceMaps.DashboardCards, name)
+	case *modconfig.DashboardChart:
+		delete(resourceMaps.DashboardCharts, name)
+	case *modconfig.DashboardContainer:
+		delete(resourceMaps.DashboardContainers, name)
+	case *modconfig.DashboardFlow:
+		delete(resourceMaps.DashboardFlows, name)
+	case *modconfig.DashboardGraph:
+		delete(resourceMaps.DashboardGraphs, name)
+	case *modconfig.DashboardHierarchy:
+		delete(resourceMaps.DashboardHierarchies, name)
+	case *modconfig.DashboardImage:
+		delete(resourceMaps.DashboardImages, name)
+	case *modconfig.DashboardTable:
+		delete(resourceMaps.DashboardTables, name)
+	case *modconfig.DashboardText:
+		delete(resourceMaps.DashboardTexts, name)
+	}
+}
@@ -0,0 +1,198 @@
+package conditional
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+// newTestResourceMaps returns the resource maps of a mod containing:
+//
+//	benchmark.root (benchmark.child (control.two), control.one)
+//	dashboard.one (container.one (chart.two), chart.one)
+//	dashboard.two
+//
+// (the top level resources being the children of the mod) with the given 'enabled' tag values
+func newTestResourceMaps(enabled map[string]string) *modconfig.ResourceMaps {
+	tags := func(name string) map[string]string {
+		if value, ok := enabled[name]; ok {
+			return map[string]string{TagEnabled: value}
+		}
+		return nil
+	}
+
+	controlOne, controlTwo := &modconfig.Control{}, &modconfig.Control{}
+	benchmarkRoot, benchmarkChild := &modconfig.Benchmark{}, &modconfig.Benchmark{}
+	dashboardOne, dashboardTwo := &modconfig.Dashboard{}, &modconfig.Dashboard{}
+	containerOne := &modconfig.DashboardContainer{}
+	chartOne, chartTwo := &modconfig.DashboardChart{}, &modconfig.DashboardChart{}
+	for name, r := range map[string]*modconfig.HclResourceImpl{
+		"m.control.one":     &controlOne.HclResourceImpl,
+		"m.control.two":     &controlTwo.HclResourceImpl,
+		"m.benchmark.root":  &benchmarkRoot.HclResourceImpl,
+		"m.benchmark.child": &benchmarkChild.HclResourceImpl,
+		"m.dashboard.one":   &dashboardOne.HclResourceImpl,
+		"m.dashboard.two":   &dashboardTwo.HclResourceImpl,
+		"m.container.one":   &containerOne.HclResourceImpl,
+		"m.chart.one":       &chartOne.HclResourceImpl,
+		"m.chart.two":       &chartTwo.HclResourceImpl,
+	} {
+		r.FullName = name
+		r.Tags = tags(name)
+	}
+	benchmarkRoot.SetChildren([]modconfig.ModTreeItem{benchmarkChild, controlOne})
+	benchmarkChild.SetChildren([]modconfig.ModTreeItem{controlTwo})
+	dashboardOne.SetChildren([]modconfig.ModTreeItem{containerOne, chartOne})
+	containerOne.SetChildren([]modconfig.ModTreeItem{chartTwo})
+	mod := modconfig.NewMod("m", "", hcl.Range{})
+	setModChildren(mod, []modconfig.ModTreeItem{benchmarkRoot, dashboardOne, dashboardTwo})
+
+	return &modconfig.ResourceMaps{
+		Mod:                 mod,
+		Mods:                map[string]*modconfig.Mod{"mod.m": mod},
+		Controls:            map[string]*modconfig.Control{"m.control.one": controlOne, "m.control.two": controlTwo},
+		Benchmarks:          map[string]*modconfig.Benchmark{"m.benchmark.root": benchmarkRoot, "m.benchmark.child": benchmarkChild},
+		Dashboards:          map[string]*modconfig.Dashboard{"m.dashboard.one": dashboardOne, "m.dashboard.two": dashboardTwo},
+		DashboardContainers: map[string]*modconfig.DashboardContainer{"m.container.one": containerOne},
+		DashboardCharts:     map[string]*modconfig.DashboardChart{"m.chart.one": chartOne, "m.chart.two": chartTwo},
+	}
+}
+
+// describeResourceMaps returns the names of the resources of the maps, sorted, with the children of each parent
+func describeResourceMaps(resourceMaps *modconfig.ResourceMaps) string {
+	var res []string
+	describe := func(name string, children []modconfig.ModTreeItem) {
+		if len(children) == 0 {
+			res = append(res, name)
+			return
+		}
+		res = append(res, fmt.Sprintf("%s(%s)", name, strings.Join(childNames(children), ",")))
+	}
+	describe(resourceMaps.Mod.Name(), resourceMaps.Mod.GetChildren())
+	for name, b := range resourceMaps.Benchmarks {
+		describe(name, b.GetChildren())
+	}
+	for name := range resourceMaps.Controls {
+		describe(name, nil)
+	}
+	for name, d := range resourceMaps.Dashboards {
+		describe(name, d.GetChildren())
+	}
+	for name, c := range resourceMaps.DashboardContainers {
+		describe(name, c.GetChildren())
+	}
+	for name := range resourceMaps.DashboardCharts {
+		describe(name, nil)
+	}
+	sort.Strings(res)
+	return strings.Join(res, " ")
+}
+
+type removeDisabledTest struct {
+	enabled  map[string]string
+	expected string
+}
+
+var testCasesRemoveDisabled = map[string]removeDisabledTest{
+	"no enabled tags": {
+		expected: "m.benchmark.child(m.control.two) m.benchmark.root(m.benchmark.child,m.control.one) m.chart.one m.chart.two m.container.one(m.chart.two) m.control.one m.control.two m.dashboard.one(m.container.one,m.chart.one) m.dashboard.two mod.m(m.benchmark.root,m.dashboard.one,m.dashboard.two)",
+	},
+	"enabled": {
+		enabled:  map[string]string{"m.benchmark.child": "true", "m.control.one": "TRUE", "m.chart.one": "1"},
+		expected: "m.benchmark.child(m.control.two) m.benchmark.root(m.benchmark.child,m.control.one) m.chart.one m.chart.two m.container.one(m.chart.two) m.control.one m.control.two m.dashboard.one(m.container.one,m.chart.one) m.dashboard.two mod.m(m.benchmark.root,m.dashboard.one,m.dashboard.two)",
+	},
+	"disabled control": {
+		enabled:  map[string]string{"m.control.one": "false"},
+		expected: "m.benchmark.child(m.control.two) m.benchmark.root(m.benchmark.child) m.chart.one m.chart.two m.container.one(m.chart.two) m.control.two m.dashboard.one(m.container.one,m.chart.one) m.dashboard.two mod.m(m.benchmark.root,m.dashboard.one,m.dashboard.two)",
+	},
+	// the descendants of a disabled resource are removed, unless they are also descendants of an enabled resource
+	"disabled child benchmark": {
+		enabled:  map[string]string{"m.benchmark.child": "false"},
+		expected: "m.benchmark.root(m.control.one) m.chart.one m.chart.two m.container.one(m.chart.two) m.control.one m.dashboard.one(m.container.one,m.chart.one) m.dashboard.two mod.m(m.benchmark.root,m.dashboard.one,m.dashboard.two)",
+	},
+	"disabled root benchmark": {
+		enabled:  map[string]string{"m.benchmark.root": "false"},
+		expected: "m.chart.one m.chart.two m.container.one(m.chart.two) m.dashboard.one(m.container.one,m.chart.one) m.dashboard.two mod.m(m.dashboard.one,m.dashboard.two)",
+	},
+	"disabled dashboard": {
+		enabled:  map[string]string{"m.dashboard.two": "false"},
+		expected: "m.benchmark.child(m.control.two) m.benchmark.root(m.benchmark.child,m.control.one) m.chart.one m.chart.two m.container.one(m.chart.two) m.control.one m.control.two m.dashboard.one(m.container.one,m.chart.one) mod.m(m.benchmark.root,m.dashboard.one)",
+	},
+	"disabled dashboard with panels": {
+		enabled:  map[string]string{"m.dashboard.one": "false"},
+		expected: "m.benchmark.child(m.control.two) m.benchmark.root(m.benchmark.child,m.control.one) m.control.one m.control.two m.dashboard.two mod.m(m.benchmark.root,m.dashboard.two)",
+	},
+	"disabled container": {
+		enabled:  map[string]string{"m.container.one": "false"},
+		expected: "m.benchmark.child(m.control.two) m.benchmark.root(m.benchmark.child,m.control.one) m.chart.one m.control.one m.control.two m.dashboard.one(m.chart.one) m.dashboard.two mod.m(m.benchmark.root,m.dashboard.one,m.dashboard.two)",
+	},
+	"disabled panel of container": {
+		enabled:  map[string]string{"m.chart.two": "false"},
+		expected: "m.benchmark.child(m.control.two) m.benchmark.root(m.benchmark.child,m.control.one) m.chart.one m.container.one m.control.one m.control.two m.dashboard.one(m.container.one,m.chart.one) m.dashboard.two mod.m(m.benchmark.root,m.dashboard.one,m.dashboard.two)",
+	},
+	// an enabled descendant of a disabled resource is still removed
+	"enabled child of disabled benchmark": {
+		enabled:  map[string]string{"m.benchmark.child": "false", "m.control.two": "true"},
+		expected: "m.benchmark.root(m.control.one) m.chart.one m.chart.two m.container.one(m.chart.two) m.control.one m.dashboard.one(m.container.one,m.chart.one) m.dashboard.two mod.m(m.benchmark.root,m.dashboard.one,m.dashboard.two)",
+	},
+	"disabled and enabled": {
+		enabled:  map[string]string{"m.control.one": "false", "m.control.two": "true", "m.chart.one": "false", "m.chart.two": "true"},
+		expected: "m.benchmark.child(m.control.two) m.benchmark.root(m.benchmark.child) m.chart.two m.container.one(m.chart.two) m.control.two m.dashboard.one(m.container.one) m.dashboard.two mod.m(m.benchmark.root,m.dashboard.one,m.dashboard.two)",
+	},
+}
+
+func TestRemoveDisabled(t *testing.T) {
+	for name, test := range testCasesRemoveDisabled {
+		resourceMaps := newTestResourceMaps(test.enabled)
+		if err := RemoveDisabled(resourceMaps); err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		if actual := describeResourceMaps(resourceMaps); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, actual)
+		}
+	}
+}
+
+func TestRemoveDisabledChildNames(t *testing.T) {
+	resourceMaps := newTestResourceMaps(map[string]string{"m.control.one": "false", "m.chart.two": "false"})
+	if err := RemoveDisabled(resourceMaps); err != nil {
+		t.Fatal(err)
+	}
+	// the child names of the parents are updated with their children
+	if actual := strings.Join(resourceMaps.Benchmarks["m.benchmark.root"].ChildNameStrings, ","); actual != "m.benchmark.child" {
+		t.Errorf("Test: 'benchmark child names' FAILED : expected m.benchmark.child, got %s", actual)
+	}
+	if actual := resourceMaps.DashboardContainers["m.container.one"].ChildNames; len(actual) != 0 {
+		t.Errorf("Test: 'container child names' FAILED : expected no children, got %v", actual)
+	}
+}
+
+func TestRemoveDisabledSharedChild(t *testing.T) {
+	resourceMaps := newTestResourceMaps(map[string]string{"m.benchmark.child": "false"})
+	// control.two is also a child of an enabled top level benchmark
+	other := &modconfig.Benchmark{}
+	other.FullName = "m.benchmark.other"
+	other.SetChildren([]modconfig.ModTreeItem{resourceMaps.Controls["m.control.two"]})
+	resourceMaps.Benchmarks[other.FullName] = other
+
+	if err := RemoveDisabled(resourceMaps); err != nil {
+		t.Fatal(err)
+	}
+	expected := "m.benchmark.other(m.control.two) m.benchmark.root(m.control.one) m.chart.one m.chart.two m.container.one(m.chart.two) m.control.one m.control.two m.dashboard.one(m.container.one,m.chart.one) m.dashboard.two mod.m(m.benchmark.root,m.dashboard.one,m.dashboard.two)"
+	if actual := describeResourceMaps(resourceMaps); actual != expected {
+		t.Errorf("Test: 'shared child' FAILED : expected %s, got %s", expected, actual)
+	}
+}
+
+func TestRemoveDisabledInvalidTag(t *testing.T) {
+	err := RemoveDisabled(newTestResourceMaps(map[string]string{"m.control.one": "maybe"}))
+	expected := "invalid enabled tag 'maybe' for m.control.one: expected 'true' or 'false'"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Test: 'invalid tag' FAILED : expected error '%s', got %v", expected, err)
+	}
+}
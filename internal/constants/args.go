@@ -0,0 +1,85 @@
+package constants
+
+// Argument name constants specific to powerpipe
+// (common arguments are defined in pipe-fittings)
+const (
+	ArgWebhookSecret            = "webhook-secret"
+	ArgDimension                = "dimension"
+	ArgTablePageSize            = "table-page-size"
+	ArgAuthPolicy               = "auth-policy"
+	ArgDashboardInput           = "input"
+	ArgGroupBy                  = "group-by"
+	ArgStatus                   = "status"
+	ArgDatabaseSearchPath       = "database-search-path"
+	ArgDatabaseSearchPathPrefix = "database-search-path-prefix"
+	ArgRoutingConfig            = "routing-config"
+	ArgTicketIntegration        = "ticket-integration"
+	ArgMaxDuration              = "max-duration"
+	ArgMaxCostRows              = "max-cost-rows"
+	ArgPlan                     = "plan"
+	ArgTimezone                 = "timezone"
+	ArgDateFormat               = "date-format"
+	ArgNumberFormat             = "number-format"
+	ArgCurrency                 = "currency"
+	ArgFrozen                   = "frozen"
+	ArgMaxAge                   = "max-age"
+	ArgMaxSize                  = "max-size"
+	ArgCaptureQueryPlans        = "capture-query-plans"
+	ArgMaxRowsPerControl        = "max-rows-per-control"
+	ArgApprovalWebhook          = "approval-webhook"
+	ArgApprovalTimeout          = "approval-timeout"
+	ArgLanguage                 = "language"
+	ArgDatabaseAttach           = "database-attach"
+	ArgDatabaseOnConnect        = "database-on-connect"
+	ArgDatabaseCredentialCmd    = "database-credential-command"
+	ArgTerraformInput           = "terraform-input"
+	ArgQueryRewriteConfig       = "query-rewrite-config"
+	ArgFanOutConnections        = "fan-out-connections"
+	ArgChannel                  = "channel"
+	ArgRollback                 = "rollback"
+	ArgCheckOnly                = "check"
+	ArgErrorFormat              = "error-format"
+	ArgQuiet                    = "quiet"
+	ArgRecordSession            = "record-session"
+	ArgReplaySpeed              = "speed"
+	ArgReplayWait               = "wait"
+	ArgReplayURL                = "url"
+	ArgPanelConcurrency         = "max-concurrent-panels"
+	ArgDashboardConcurrency     = "max-concurrent-panels-per-dashboard"
+	ArgStrict                   = "strict"
+	ArgDependencyMod            = "mod"
+	ArgHtmlTheme                = "html-theme"
+	ArgPublish                  = "publish"
+	ArgMaxConnectionsPerOrigin  = "max-connections-per-origin"
+	ArgFinding                  = "finding"
+	ArgAuthor                   = "author"
+	ArgUntil                    = "until"
+	ArgReason                   = "reason"
+	ArgWarmUp                   = "warm-up"
+	ArgDeduplicate              = "deduplicate"
+	ArgSeed                     = "seed"
+	ArgOnce                     = "once"
+	ArgExitAfterExport          = "exit-after-export"
+	ArgName                     = "name"
+	ArgDatasource               = "datasource"
+	ArgDatasourceType           = "datasource-type"
+	ArgTLSMinVersion            = "tls-min-version"
+	ArgTLSCipherSuites          = "tls-cipher-suites"
+	ArgFIPS                     = "fips"
+	ArgTLSCertificate           = "tls-certificate"
+	ArgTLSKey                   = "tls-key"
+	ArgCABundle                 = "ca-bundle"
+	ArgContentSecurityPolicy    = "content-security-policy"
+	ArgSchedule                 = "schedule"
+	ArgScheduleSnapshotLocation = "schedule-snapshot-location"
+	ArgUploadConcurrency        = "upload-concurrency"
+	ArgUploadRetries            = "upload-retries"
+	ArgUploadBandwidth          = "upload-bandwidth"
+	ArgAllowInputCommands       = "allow-input-commands"
+	ArgTLS                      = "tls"
+	ArgTLSCA                    = "tls-ca"
+	ArgIdentity                 = "identity"
+	ArgGroups                   = "groups"
+	ArgIdentityHeader           = "identity-header"
+	ArgGroupsHeader             = "groups-header"
+)
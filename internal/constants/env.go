@@ -1,10 +1,63 @@
 package constants
 
 const (
-	EnvListen           = "POWERPIPE_LISTEN"
-	EnvPort             = "POWERPIPE_PORT"
-	EnvBenchmarkTimeout = "POWERPIPE_BENCHMARK_TIMEOUT"
-	EnvDashboardTimeout = "POWERPIPE_DASHBOARD_TIMEOUT"
+	EnvListen                   = "POWERPIPE_LISTEN"
+	EnvPort                     = "POWERPIPE_PORT"
+	EnvBenchmarkTimeout         = "POWERPIPE_BENCHMARK_TIMEOUT"
+	EnvDashboardTimeout         = "POWERPIPE_DASHBOARD_TIMEOUT"
+	EnvWebhookSecret            = "POWERPIPE_WEBHOOK_SECRET"
+	EnvDimensions               = "POWERPIPE_DIMENSIONS"
+	EnvTablePageSize            = "POWERPIPE_TABLE_PAGE_SIZE"
+	EnvAuthPolicy               = "POWERPIPE_AUTH_POLICY"
+	EnvDatabaseSearchPath       = "POWERPIPE_DATABASE_SEARCH_PATH"
+	EnvDatabaseSearchPathPrefix = "POWERPIPE_DATABASE_SEARCH_PATH_PREFIX"
+	EnvChromePath               = "POWERPIPE_CHROME_PATH"
+	EnvRoutingConfig            = "POWERPIPE_ROUTING_CONFIG"
+	EnvMaxDuration              = "POWERPIPE_MAX_DURATION"
+	EnvMaxCostRows              = "POWERPIPE_MAX_COST_ROWS"
+	EnvTimezone                 = "POWERPIPE_TIMEZONE"
+	EnvDateFormat               = "POWERPIPE_DATE_FORMAT"
+	EnvNumberFormat             = "POWERPIPE_NUMBER_FORMAT"
+	EnvCurrency                 = "POWERPIPE_CURRENCY"
+	EnvGitLabHosts              = "POWERPIPE_GITLAB_HOSTS"
+	EnvModSumDB                 = "POWERPIPE_MODSUMDB"
+	EnvModSumDBKey              = "POWERPIPE_MODSUMDB_KEY"
+	EnvModNoSumDB               = "POWERPIPE_MODNOSUMDB"
+	EnvCaptureQueryPlans        = "POWERPIPE_CAPTURE_QUERY_PLANS"
+	EnvMaxRowsPerControl        = "POWERPIPE_MAX_ROWS_PER_CONTROL"
+	EnvApprovalWebhook          = "POWERPIPE_APPROVAL_WEBHOOK"
+	EnvApprovalTimeout          = "POWERPIPE_APPROVAL_TIMEOUT"
+	EnvLanguage                 = "POWERPIPE_LANGUAGE"
+	EnvDatabaseAttach           = "POWERPIPE_DATABASE_ATTACH"
+	EnvDatabaseOnConnect        = "POWERPIPE_DATABASE_ON_CONNECT"
+	EnvDatabaseCredentialCmd    = "POWERPIPE_DATABASE_CREDENTIAL_COMMAND"
+	EnvTerraformInput           = "POWERPIPE_TERRAFORM_INPUT"
+	EnvQueryRewriteConfig       = "POWERPIPE_QUERY_REWRITE_CONFIG"
+	EnvFanOutConnections        = "POWERPIPE_FAN_OUT_CONNECTIONS"
+	EnvTelemetry                = "POWERPIPE_TELEMETRY"
+	EnvTelemetryEndpoint        = "POWERPIPE_TELEMETRY_ENDPOINT"
+	EnvErrorFormat              = "POWERPIPE_ERROR_FORMAT"
+	EnvRecordSession            = "POWERPIPE_RECORD_SESSION"
+	EnvPanelConcurrency         = "POWERPIPE_MAX_CONCURRENT_PANELS"
+	EnvDashboardConcurrency     = "POWERPIPE_MAX_CONCURRENT_PANELS_PER_DASHBOARD"
+	EnvStrict                   = "POWERPIPE_STRICT"
+	EnvMaxConnectionsPerOrigin  = "POWERPIPE_MAX_CONNECTIONS_PER_ORIGIN"
+	EnvModRegistry              = "POWERPIPE_MOD_REGISTRY"
+	EnvModMirror                = "POWERPIPE_MOD_MIRROR"
+	EnvGitCredentials           = "POWERPIPE_GIT_CREDENTIALS"
+	EnvEventSinks               = "POWERPIPE_EVENT_SINKS"
+	EnvTLSMinVersion            = "POWERPIPE_TLS_MIN_VERSION"
+	EnvTLSCipherSuites          = "POWERPIPE_TLS_CIPHER_SUITES"
+	EnvFIPS                     = "POWERPIPE_FIPS"
+	EnvTLSCertificate           = "POWERPIPE_TLS_CERTIFICATE"
+	EnvTLSKey                   = "POWERPIPE_TLS_KEY"
+	EnvCABundle                 = "POWERPIPE_CA_BUNDLE"
+	EnvContentSecurityPolicy    = "POWERPIPE_CONTENT_SECURITY_POLICY"
+	EnvScheduleSnapshotLocation = "POWERPIPE_SCHEDULE_SNAPSHOT_LOCATION"
+	// EnvDoNotTrack is the standard variable to opt out of telemetry, so has no POWERPIPE_ prefix
+	EnvDoNotTrack = "DO_NOT_TRACK"
+	// EnvGitLabToken is the standard GitLab token variable, so has no POWERPIPE_ prefix
+	EnvGitLabToken = "GITLAB_TOKEN"
 	// EnvConfigDump is an undocumented variable is subject to change in the future
 	EnvConfigDump = "POWERPIPE_CONFIG_DUMP"
 )
@@ -75,8 +75,12 @@ const (
 	CheckOutputModeSnapshot
 	CheckOutputModeSnapshotShort
 	CheckOutputModeNone
+	CheckOutputModeK8sEvent
 )
 
+// results are emitted as Kubernetes events, when running in a cluster
+const OutputFormatK8sEvent = "k8s-event"
+
 var CheckOutputModeIds = map[CheckOutputMode][]string{
 	CheckOutputModeText:          {constants.OutputFormatText},
 	CheckOutputModeBrief:         {constants.OutputFormatBrief},
@@ -88,4 +92,25 @@ var CheckOutputModeIds = map[CheckOutputMode][]string{
 	CheckOutputModeSnapshot:      {constants.OutputFormatSnapshot},
 	CheckOutputModeSnapshotShort: {OutputFormatPpSnapshotShort},
 	CheckOutputModeNone:          {constants.OutputFormatNone},
+	CheckOutputModeK8sEvent:      {OutputFormatK8sEvent},
+}
+
+type ModListOutputMode enumflag.Flag
+
+const (
+	ModListOutputModeTree ModListOutputMode = iota
+	ModListOutputModeTable
+	ModListOutputModeJson
+	ModListOutputModeYaml
+)
+
+// the installed mods are listed as a dependency tree
+const OutputFormatTree = "tree"
+
+var ModListOutputModeIds = map[ModListOutputMode][]string{
+	// (pretty and plain previously listed the dependency tree, so are kept as aliases)
+	ModListOutputModeTree:  {OutputFormatTree, constants.OutputFormatPretty, constants.OutputFormatPlain},
+	ModListOutputModeTable: {constants.OutputFormatTable},
+	ModListOutputModeJson:  {constants.OutputFormatJSON},
+	ModListOutputModeYaml:  {constants.OutputFormatYAML},
 }
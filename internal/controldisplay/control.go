@@ -37,9 +37,14 @@ func NewControlRenderer(run *controlexecute.ControlRun, parent *GroupRenderer) *
 // are we the last child of our parent?
 // this affects the tree rendering
 func (r ControlRenderer) isLastChild() bool {
-	if r.parent.group == nil || r.parent.group.GroupItem == nil {
+	if r.parent.group == nil {
 		return true
 	}
+	// if a view has been applied (i.e. --group-by), children are displayed in order
+	if r.parent.resultTree.View != nil || r.parent.group.GroupItem == nil {
+		children := r.parent.group.Children
+		return len(children) == 0 || children[len(children)-1] == r.run
+	}
 	siblings := r.parent.group.GroupItem.GetChildren()
 	return r.run.Control.Name() == siblings[len(siblings)-1].Name()
 }
@@ -120,6 +125,11 @@ func (r ControlRenderer) Render() string {
 		}
 	}
 
+	if r.run.Truncated {
+		truncatedString := fmt.Sprintf("%d more results not shown (limited by --max-rows-per-control)", r.run.TotalRows-len(r.run.Rows))
+		resultStrings = append(resultStrings, fmt.Sprintf("%s%s", ControlColors.Indent(r.resultIndent()), ControlColors.ReasonSkip(truncatedString)))
+	}
+
 	// newline after results
 	if len(resultStrings) > 0 {
 		controlStrings = append(controlStrings, resultStrings...)
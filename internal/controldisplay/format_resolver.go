@@ -7,6 +7,7 @@ import (
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/export"
 	"github.com/turbot/pipe-fittings/filepaths"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
 )
 
 type FormatResolver struct {
@@ -25,6 +26,11 @@ func NewFormatResolver() (*FormatResolver, error) {
 		&NullFormatter{},
 		&TextFormatter{},
 		&SnapshotFormatter{},
+		&RemediationFormatter{},
+		&BadgeFormatter{},
+		&JUnitFormatter{},
+		&SarifFormatter{},
+		&K8sEventFormatter{},
 	}
 
 	res := &FormatResolver{
@@ -72,8 +78,8 @@ func (r *FormatResolver) registerFormatter(f Formatter) error {
 		}
 		r.formatterByName[alias] = f
 	}
-	// add to exportFormatters list (exclude 'None')
-	if f.Name() != constants.OutputFormatNone {
+	// add to exportFormatters list (exclude 'None', and formatters which emit the results rather than writing them)
+	if f.Name() != constants.OutputFormatNone && f.Name() != localconstants.OutputFormatK8sEvent {
 		r.exportFormatters = append(r.exportFormatters, f)
 	}
 	return nil
@@ -0,0 +1,57 @@
+package controldisplay
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/turbot/powerpipe/internal/badge"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+const (
+	OutputFormatBadge      = "badge"
+	OutputFormatBadgeShort = "badge.svg"
+	badgeExtension         = ".badge.svg"
+)
+
+// BadgeFormatter renders an SVG badge summarising the results of the run, e.g. "CIS AWS | 92% / 14 alarms"
+type BadgeFormatter struct {
+	FormatterBase
+}
+
+func (f BadgeFormatter) Format(_ context.Context, tree *controlexecute.ExecutionTree) (io.Reader, error) {
+	b := badge.NewBadge(badgeLabel(tree), tree.Root.Summary.Status)
+	return strings.NewReader(b.SVG()), nil
+}
+
+// badgeLabel returns the title of the benchmark (or control) which was run
+// - if multiple were run, the label is the generic 'benchmarks'
+func badgeLabel(tree *controlexecute.ExecutionTree) string {
+	root := tree.Root
+	switch {
+	case len(root.Groups) == 1 && len(root.ControlRuns) == 0:
+		if title := root.Groups[0].Title; title != "" {
+			return title
+		}
+		return root.Groups[0].GroupId
+	case len(root.ControlRuns) == 1 && len(root.Groups) == 0:
+		if title := root.ControlRuns[0].Title; title != "" {
+			return title
+		}
+		return root.ControlRuns[0].ControlId
+	}
+	return "benchmarks"
+}
+
+func (f BadgeFormatter) FileExtension() string {
+	return badgeExtension
+}
+
+func (f BadgeFormatter) Name() string {
+	return OutputFormatBadge
+}
+
+func (f BadgeFormatter) Alias() string {
+	return OutputFormatBadgeShort
+}
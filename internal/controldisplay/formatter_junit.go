@@ -0,0 +1,227 @@
+package controldisplay
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+)
+
+const (
+	OutputFormatJUnit      = "junit"
+	OutputFormatJUnitShort = "junit.xml"
+	junitExtension         = ".junit.xml"
+)
+
+// JUnitFormatter writes the results of the run as JUnit XML, so they are shown in the test reports of CI systems
+// (e.g. Jenkins, GitLab and GitHub Actions) - each benchmark containing controls is a test suite and each control a
+// test case, which fails if the control has alarms and errors if the control (or any of its rows) is in error
+type JUnitFormatter struct {
+	FormatterBase
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Name     string            `xml:"name,attr"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Errors   int               `xml:"errors,attr"`
+	Skipped  int               `xml:"skipped,attr"`
+	Time     string            `xml:"time,attr"`
+	Suites   []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Id        string           `xml:"id,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Errors    int              `xml:"errors,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	Time      string           `xml:"time,attr"`
+	Timestamp string           `xml:"timestamp,attr,omitempty"`
+	Cases     []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name       string           `xml:"name,attr"`
+	ClassName  string           `xml:"classname,attr"`
+	Time       string           `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Failure    *junitResult     `xml:"failure,omitempty"`
+	Error      *junitResult     `xml:"error,omitempty"`
+	Skipped    *junitResult     `xml:"skipped,omitempty"`
+	SystemOut  string           `xml:"system-out,omitempty"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitResult struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+func (f JUnitFormatter) Format(_ context.Context, tree *controlexecute.ExecutionTree) (io.Reader, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(junitReport(tree)); err != nil {
+		return nil, fmt.Errorf("failed to write JUnit XML: %s", err.Error())
+	}
+	buf.WriteString("\n")
+	return &buf, nil
+}
+
+// junitReport returns the test suites of the run - a suite for each group (i.e. benchmark) which directly contains
+// control runs, in the order of the tree
+func junitReport(tree *controlexecute.ExecutionTree) *junitTestSuites {
+	report := &junitTestSuites{
+		Name: badgeLabel(tree),
+		Time: junitSeconds(tree.EndTime.Sub(tree.StartTime)),
+	}
+	var addGroup func(group *controlexecute.ResultGroup, path []string)
+	addGroup = func(group *controlexecute.ResultGroup, path []string) {
+		if group != tree.Root {
+			title := group.Title
+			if title == "" {
+				title = group.GroupId
+			}
+			path = append(path, title)
+		}
+		if len(group.ControlRuns) > 0 {
+			report.Suites = append(report.Suites, junitSuite(tree, group, path))
+		}
+		for _, child := range group.Groups {
+			addGroup(child, path)
+		}
+	}
+	addGroup(tree.Root, nil)
+
+	for _, suite := range report.Suites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+	}
+	return report
+}
+
+func junitSuite(tree *controlexecute.ExecutionTree, group *controlexecute.ResultGroup, path []string) *junitTestSuite {
+	name := strings.Join(path, " / ")
+	if name == "" {
+		name = badgeLabel(tree)
+	}
+	suite := &junitTestSuite{
+		Name:  name,
+		Id:    group.GroupId,
+		Tests: len(group.ControlRuns),
+		Time:  junitSeconds(group.Duration),
+	}
+	if !tree.StartTime.IsZero() {
+		suite.Timestamp = tree.StartTime.UTC().Format(time.RFC3339)
+	}
+	for _, run := range group.ControlRuns {
+		testCase := junitCase(run, group.GroupId)
+		switch {
+		case testCase.Error != nil:
+			suite.Errors++
+		case testCase.Failure != nil:
+			suite.Failures++
+		case testCase.Skipped != nil:
+			suite.Skipped++
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	return suite
+}
+
+// junitCase returns the test case of the control run - the case is in error if the control failed to run or any row
+// is in error, fails if any row is in alarm, and is skipped if all rows were skipped
+// the rows which did not pass are listed in the body of the error or failure
+func junitCase(run *controlexecute.ControlRun, className string) *junitTestCase {
+	name := run.Title
+	if name == "" {
+		name = run.ControlId
+	}
+	testCase := &junitTestCase{
+		Name:      name,
+		ClassName: className,
+		Time:      junitSeconds(run.Duration),
+	}
+
+	properties := []junitProperty{{Name: "control", Value: run.FullName}}
+	if run.Severity != "" {
+		properties = append(properties, junitProperty{Name: "severity", Value: run.Severity})
+	}
+	testCase.Properties = &junitProperties{Properties: properties}
+
+	summary := run.Summary
+	if summary == nil {
+		summary = &controlstatus.StatusSummary{}
+	}
+	body := junitFailedRows(run)
+	switch {
+	case run.RunErrorString != "":
+		testCase.Error = &junitResult{Message: run.RunErrorString, Type: constants.ControlError, Body: body}
+	case summary.Error > 0:
+		testCase.Error = &junitResult{Message: fmt.Sprintf("%d alarm, %d error", summary.Alarm, summary.Error), Type: constants.ControlError, Body: body}
+	case summary.Alarm > 0:
+		testCase.Failure = &junitResult{Message: fmt.Sprintf("%d alarm", summary.Alarm), Type: constants.ControlAlarm, Body: body}
+	case summary.Skip > 0 && summary.Skip == summary.TotalCount():
+		testCase.Skipped = &junitResult{Message: fmt.Sprintf("%d skip", summary.Skip)}
+	}
+	testCase.SystemOut = fmt.Sprintf("%d ok, %d alarm, %d error, %d info, %d skip", summary.Ok, summary.Alarm, summary.Error, summary.Info, summary.Skip)
+	return testCase
+}
+
+// junitFailedRows returns a line for each row of the control run in alarm or error: '<status>: <resource>: <reason>'
+func junitFailedRows(run *controlexecute.ControlRun) string {
+	var lines []string
+	for _, row := range run.Rows {
+		if row.Status != constants.ControlAlarm && row.Status != constants.ControlError {
+			continue
+		}
+		line := fmt.Sprintf("%s: %s", row.Status, row.Reason)
+		if row.Resource != "" {
+			line = fmt.Sprintf("%s: %s: %s", row.Status, row.Resource, row.Reason)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// junitSeconds formats the duration as seconds, as expected by the time attributes
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func (f JUnitFormatter) FileExtension() string {
+	return junitExtension
+}
+
+func (f JUnitFormatter) Name() string {
+	return OutputFormatJUnit
+}
+
+func (f JUnitFormatter) Alias() string {
+	return OutputFormatJUnitShort
+}
@@ -0,0 +1,151 @@
+package controldisplay
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+)
+
+type junitCaseTest struct {
+	summary  controlstatus.StatusSummary
+	runError string
+	rows     []*controlexecute.ResultRow
+	// the expected result of the case, in the form '<element> <message>' (or 'pass')
+	expected string
+	body     string
+}
+
+var testCasesJUnitCase = map[string]junitCaseTest{
+	"ok": {
+		summary:  controlstatus.StatusSummary{Ok: 2, Info: 1},
+		expected: "pass",
+	},
+	"no rows": {
+		expected: "pass",
+	},
+	"alarm": {
+		summary: controlstatus.StatusSummary{Ok: 1, Alarm: 2},
+		rows: []*controlexecute.ResultRow{
+			{Status: "alarm", Resource: "arn:aws:s3:::a", Reason: "a is public"},
+			{Status: "ok", Resource: "arn:aws:s3:::b", Reason: "b is private"},
+			{Status: "alarm", Reason: "c is public"},
+		},
+		expected: "failure 2 alarm",
+		body:     "alarm: arn:aws:s3:::a: a is public\nalarm: c is public\n",
+	},
+	"error rows": {
+		summary: controlstatus.StatusSummary{Alarm: 1, Error: 1},
+		rows: []*controlexecute.ResultRow{
+			{Status: "error", Resource: "a", Reason: "access denied"},
+			{Status: "alarm", Resource: "b", Reason: "b is public"},
+		},
+		expected: "error 1 alarm, 1 error",
+		body:     "error: a: access denied\nalarm: b: b is public\n",
+	},
+	"run error": {
+		runError: "relation \"aws_s3_bucket\" does not exist",
+		expected: "error relation \"aws_s3_bucket\" does not exist",
+	},
+	"skip": {
+		summary:  controlstatus.StatusSummary{Skip: 3},
+		expected: "skipped 3 skip",
+	},
+	"partly skipped": {
+		summary:  controlstatus.StatusSummary{Skip: 3, Ok: 1},
+		expected: "pass",
+	},
+}
+
+func TestJUnitCase(t *testing.T) {
+	for name, test := range testCasesJUnitCase {
+		summary := test.summary
+		run := &controlexecute.ControlRun{
+			FullName:       "test.control.a",
+			ControlId:      "test.control.a",
+			Summary:        &summary,
+			RunErrorString: test.runError,
+			Rows:           test.rows,
+			Duration:       1500 * time.Millisecond,
+		}
+		testCase := junitCase(run, "test.benchmark.cis")
+
+		actual, body := "pass", ""
+		switch {
+		case testCase.Error != nil:
+			actual, body = "error "+testCase.Error.Message, testCase.Error.Body
+		case testCase.Failure != nil:
+			actual, body = "failure "+testCase.Failure.Message, testCase.Failure.Body
+		case testCase.Skipped != nil:
+			actual, body = "skipped "+testCase.Skipped.Message, testCase.Skipped.Body
+		}
+		if actual != test.expected || body != test.body {
+			t.Errorf("Test: '%s' FAILED : expected %s (%q), got %s (%q)", name, test.expected, test.body, actual, body)
+		}
+		if testCase.Name != "test.control.a" || testCase.ClassName != "test.benchmark.cis" || testCase.Time != "1.500" {
+			t.Errorf("Test: '%s' FAILED : unexpected case attributes %+v", name, testCase)
+		}
+	}
+}
+
+func TestJUnitFormat(t *testing.T) {
+	controlRun := func(name string, summary controlstatus.StatusSummary) *controlexecute.ControlRun {
+		return &controlexecute.ControlRun{FullName: "test.control." + name, ControlId: "test.control." + name, Title: "Control " + name, Summary: &summary}
+	}
+	section := &controlexecute.ResultGroup{
+		GroupId:     "test.benchmark.cis_1",
+		Title:       "1 Identity & Access",
+		ControlRuns: []*controlexecute.ControlRun{controlRun("a", controlstatus.StatusSummary{Alarm: 1}), controlRun("b", controlstatus.StatusSummary{Ok: 1})},
+	}
+	empty := &controlexecute.ResultGroup{GroupId: "test.benchmark.cis_2", Title: "2 Storage"}
+	benchmark := &controlexecute.ResultGroup{
+		GroupId:     "test.benchmark.cis",
+		Title:       "CIS",
+		Groups:      []*controlexecute.ResultGroup{section, empty},
+		ControlRuns: []*controlexecute.ControlRun{controlRun("c", controlstatus.StatusSummary{Skip: 1})},
+	}
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	tree := &controlexecute.ExecutionTree{
+		Root:      &controlexecute.ResultGroup{Groups: []*controlexecute.ResultGroup{benchmark}},
+		StartTime: start,
+		EndTime:   start.Add(90 * time.Second),
+	}
+
+	reader, err := JUnitFormatter{}.Format(context.Background(), tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), xml.Header) {
+		t.Errorf("Test: 'header' FAILED : expected the XML header, got %s", data)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal(data, &report); err != nil {
+		t.Fatalf("Test: 'parse' FAILED : %v\n%s", err, data)
+	}
+	if report.Name != "CIS" || report.Tests != 3 || report.Failures != 1 || report.Errors != 0 || report.Skipped != 1 || report.Time != "90.000" {
+		t.Errorf("Test: 'totals' FAILED : unexpected report %+v", report)
+	}
+	var suites []string
+	for _, suite := range report.Suites {
+		suites = append(suites, suite.Name)
+	}
+	if expected := "CIS,CIS / 1 Identity & Access"; strings.Join(suites, ",") != expected {
+		t.Errorf("Test: 'suites' FAILED : expected %s, got %s", expected, strings.Join(suites, ","))
+	}
+	if len(report.Suites) == 2 {
+		suite := report.Suites[1]
+		if suite.Id != "test.benchmark.cis_1" || suite.Tests != 2 || suite.Failures != 1 || suite.Timestamp != "2026-10-16T09:00:00Z" {
+			t.Errorf("Test: 'suite' FAILED : unexpected suite %+v", suite)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package controldisplay
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/utils"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+	"github.com/turbot/powerpipe/internal/k8sevents"
+)
+
+const (
+	k8sEventReasonCompleted = "BenchmarkCompleted"
+	k8sEventReasonAlarm     = "ControlAlarm"
+	k8sEventReasonError     = "ControlError"
+
+	k8sAnnotationTarget   = "powerpipe.io/target"
+	k8sAnnotationControl  = "powerpipe.io/control"
+	k8sAnnotationSeverity = "powerpipe.io/severity"
+
+	// the maximum number of control events emitted for a run - the API server may rate limit events
+	maxK8sControlEvents = 100
+)
+
+// K8sEventFormatter emits the results of the run as Kubernetes events, when running in a cluster - an event
+// summarising the run, and an event for each control in alarm or error
+// (the events are emitted rather than written, so the formatter is not available as an export)
+type K8sEventFormatter struct {
+	FormatterBase
+}
+
+func (f K8sEventFormatter) Format(ctx context.Context, tree *controlexecute.ExecutionTree) (io.Reader, error) {
+	client, err := k8sevents.InClusterClient()
+	if err != nil {
+		return nil, err
+	}
+	events := k8sEvents(tree)
+	for _, event := range events {
+		if err := client.Create(ctx, event); err != nil {
+			return nil, fmt.Errorf("failed to create kubernetes event: %s", err.Error())
+		}
+	}
+	return strings.NewReader(fmt.Sprintf("Created %d Kubernetes %s in namespace %s\n", len(events), utils.Pluralize("event", len(events)), client.Namespace())), nil
+}
+
+// k8sEvents returns the events of the run - the summary event first, then the control events ordered by control name
+func k8sEvents(tree *controlexecute.ExecutionTree) []k8sevents.Event {
+	label := badgeLabel(tree)
+	var controlEvents []k8sevents.Event
+	for _, run := range sortedControlRuns(tree) {
+		summary := run.Summary
+		if summary == nil {
+			summary = &controlstatus.StatusSummary{}
+		}
+		title := run.Title
+		if title == "" {
+			title = run.ControlId
+		}
+
+		event := k8sevents.Event{
+			Type:        k8sevents.TypeWarning,
+			Annotations: map[string]string{k8sAnnotationTarget: label, k8sAnnotationControl: run.FullName},
+		}
+		if run.Severity != "" {
+			event.Annotations[k8sAnnotationSeverity] = run.Severity
+		}
+		switch {
+		case run.RunErrorString != "":
+			event.Reason, event.Message = k8sEventReasonError, fmt.Sprintf("%s: %s", title, run.RunErrorString)
+		case summary.Error > 0:
+			event.Reason, event.Message = k8sEventReasonError, fmt.Sprintf("%s: %d alarm, %d error", title, summary.Alarm, summary.Error)
+		case summary.Alarm > 0:
+			event.Reason, event.Message = k8sEventReasonAlarm, fmt.Sprintf("%s: %d alarm", title, summary.Alarm)
+		default:
+			continue
+		}
+		controlEvents = append(controlEvents, event)
+	}
+
+	status := tree.Root.Summary.Status
+	message := fmt.Sprintf("%s: %d controls - %d ok, %d alarm, %d error, %d info, %d skip", label, len(tree.ControlRuns), status.Ok, status.Alarm, status.Error, status.Info, status.Skip)
+	if tree.AbortReason != "" {
+		message += fmt.Sprintf(" (incomplete: %s)", tree.AbortReason)
+	}
+	if omitted := len(controlEvents) - maxK8sControlEvents; omitted > 0 {
+		controlEvents = controlEvents[:maxK8sControlEvents]
+		message += fmt.Sprintf(" - events were not created for %d further %s in alarm or error", omitted, utils.Pluralize("control", omitted))
+	}
+	summaryEvent := k8sevents.Event{
+		Type:        k8sevents.TypeNormal,
+		Reason:      k8sEventReasonCompleted,
+		Message:     message,
+		Annotations: map[string]string{k8sAnnotationTarget: label},
+	}
+	if status.FailedCount() > 0 || tree.AbortReason != "" {
+		summaryEvent.Type = k8sevents.TypeWarning
+	}
+	return append([]k8sevents.Event{summaryEvent}, controlEvents...)
+}
+
+func sortedControlRuns(tree *controlexecute.ExecutionTree) []*controlexecute.ControlRun {
+	res := make([]*controlexecute.ControlRun, 0, len(tree.ControlRuns))
+	for _, run := range tree.ControlRuns {
+		res = append(res, run)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].FullName < res[j].FullName })
+	return res
+}
+
+func (f K8sEventFormatter) FileExtension() string {
+	// will not be called
+	return ""
+}
+
+func (f K8sEventFormatter) Name() string {
+	return localconstants.OutputFormatK8sEvent
+}
@@ -0,0 +1,98 @@
+package controldisplay
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+	"github.com/turbot/powerpipe/internal/k8sevents"
+)
+
+type k8sEventsTest struct {
+	controls    map[string]controlstatus.StatusSummary
+	runError    string
+	abortReason string
+	// each expected event, in the form '<type> <reason>: <message>'
+	expected []string
+}
+
+var testCasesK8sEvents = map[string]k8sEventsTest{
+	"ok": {
+		controls: map[string]controlstatus.StatusSummary{"a": {Ok: 3}, "b": {Ok: 1, Skip: 1}},
+		expected: []string{"Normal BenchmarkCompleted: CIS: 2 controls - 4 ok, 0 alarm, 0 error, 0 info, 1 skip"},
+	},
+	"alarm and error": {
+		controls: map[string]controlstatus.StatusSummary{"b": {Alarm: 2, Ok: 1}, "a": {Alarm: 1, Error: 1}, "c": {Info: 1}},
+		expected: []string{
+			"Warning BenchmarkCompleted: CIS: 3 controls - 1 ok, 3 alarm, 1 error, 1 info, 0 skip",
+			"Warning ControlError: Control a: 1 alarm, 1 error",
+			"Warning ControlAlarm: Control b: 2 alarm",
+		},
+	},
+	"run error": {
+		controls: map[string]controlstatus.StatusSummary{"a": {}},
+		runError: "relation \"aws_s3_bucket\" does not exist",
+		expected: []string{
+			"Normal BenchmarkCompleted: CIS: 1 controls - 0 ok, 0 alarm, 0 error, 0 info, 0 skip",
+			"Warning ControlError: Control a: relation \"aws_s3_bucket\" does not exist",
+		},
+	},
+	"aborted": {
+		controls:    map[string]controlstatus.StatusSummary{"a": {Ok: 1}},
+		abortReason: "the run exceeded --max-duration 30m",
+		expected:    []string{"Warning BenchmarkCompleted: CIS: 1 controls - 1 ok, 0 alarm, 0 error, 0 info, 0 skip (incomplete: the run exceeded --max-duration 30m)"},
+	},
+}
+
+func TestK8sEvents(t *testing.T) {
+	for name, test := range testCasesK8sEvents {
+		tree := testK8sTree(test.controls)
+		tree.AbortReason = test.abortReason
+		if test.runError != "" {
+			for _, run := range tree.ControlRuns {
+				run.RunErrorString = test.runError
+			}
+		}
+
+		var actual []string
+		for _, event := range k8sEvents(tree) {
+			actual = append(actual, fmt.Sprintf("%s %s: %s", event.Type, event.Reason, event.Message))
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected\n%s\ngot\n%s", name, strings.Join(test.expected, "\n"), strings.Join(actual, "\n"))
+		}
+	}
+}
+
+func TestK8sEventsLimit(t *testing.T) {
+	controls := make(map[string]controlstatus.StatusSummary)
+	for i := 0; i < maxK8sControlEvents+5; i++ {
+		controls[fmt.Sprintf("c%03d", i)] = controlstatus.StatusSummary{Alarm: 1}
+	}
+	events := k8sEvents(testK8sTree(controls))
+	if len(events) != maxK8sControlEvents+1 || !strings.HasSuffix(events[0].Message, "events were not created for 5 further controls in alarm or error") {
+		t.Errorf("Test: 'limit' FAILED : expected %d events, got %d: %s", maxK8sControlEvents+1, len(events), events[0].Message)
+	}
+	if events[1].Annotations[k8sAnnotationControl] != "test.control.c000" || events[1].Type != k8sevents.TypeWarning {
+		t.Errorf("Test: 'limit' FAILED : expected the first control event to be for test.control.c000, got %v", events[1])
+	}
+}
+
+// testK8sTree returns an executed tree of a benchmark with a control run of each given summary
+func testK8sTree(controls map[string]controlstatus.StatusSummary) *controlexecute.ExecutionTree {
+	benchmark := &controlexecute.ResultGroup{GroupId: "test.benchmark.cis", Title: "CIS", Summary: controlexecute.NewGroupSummary()}
+	tree := &controlexecute.ExecutionTree{
+		Root:        &controlexecute.ResultGroup{Groups: []*controlexecute.ResultGroup{benchmark}, Summary: controlexecute.NewGroupSummary()},
+		ControlRuns: make(map[string]*controlexecute.ControlRun),
+	}
+	for name, summary := range controls {
+		summary := summary
+		fullName := "test.control." + name
+		tree.ControlRuns[fullName] = &controlexecute.ControlRun{FullName: fullName, ControlId: fullName, Title: "Control " + name, Summary: &summary}
+		tree.Root.Summary.Status.Merge(&summary)
+	}
+	return tree
+}
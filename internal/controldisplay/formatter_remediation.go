@@ -0,0 +1,98 @@
+package controldisplay
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/locale"
+)
+
+const (
+	OutputFormatRemediation      = "remediation"
+	OutputFormatRemediationShort = "remediation.md"
+	remediationExtension         = ".remediation.md"
+)
+
+// RemediationFormatter renders a markdown action list of the failing control results, grouped by the owner tag,
+// along with the remediation for each control
+type RemediationFormatter struct {
+	FormatterBase
+}
+
+func (f RemediationFormatter) Format(_ context.Context, tree *controlexecute.ExecutionTree) (io.Reader, error) {
+	var b strings.Builder
+	b.WriteString("# Remediation actions\n\n")
+	b.WriteString(fmt.Sprintf("_Generated %s_\n", locale.Current().FormatTimeWithZone(tree.EndTime)))
+
+	owners := tree.RemediationActions()
+	if len(owners) == 0 {
+		b.WriteString("\nNo actions required - there are no alarm or error results.\n")
+		return strings.NewReader(b.String()), nil
+	}
+
+	for _, owner := range owners {
+		title := owner.Owner
+		if title == "" {
+			title = "No owner"
+		}
+		b.WriteString(fmt.Sprintf("\n## %s\n", title))
+		for _, action := range owner.Actions {
+			writeRemediationAction(&b, action)
+		}
+	}
+	return strings.NewReader(b.String()), nil
+}
+
+func writeRemediationAction(b *strings.Builder, action *controlexecute.RemediationAction) {
+	run := action.Run
+	title := run.Title
+	if title == "" {
+		title = run.ControlId
+	}
+	if run.Severity != "" {
+		title = fmt.Sprintf("%s (%s)", title, run.Severity)
+	}
+	b.WriteString(fmt.Sprintf("\n### %s\n\n", title))
+	b.WriteString(fmt.Sprintf("Control: `%s`\n", run.FullName))
+
+	if r := action.Remediation; r == nil {
+		b.WriteString("\nNo remediation guidance is defined for this control.\n")
+	} else {
+		if r.Description != "" {
+			b.WriteString(fmt.Sprintf("\n%s\n", r.Description))
+		}
+		if r.DocUrl != "" {
+			b.WriteString(fmt.Sprintf("\nDocumentation: %s\n", r.DocUrl))
+		}
+		if r.Cli != "" {
+			b.WriteString(fmt.Sprintf("\n```sh\n%s\n```\n", r.Cli))
+		}
+		if r.Terraform != "" {
+			b.WriteString(fmt.Sprintf("\n```hcl\n%s\n```\n", r.Terraform))
+		}
+	}
+
+	b.WriteString("\n")
+	for _, row := range action.Rows {
+		reason := row.Reason
+		if row.Href != "" {
+			reason = fmt.Sprintf("[%s](%s)", reason, row.Href)
+		}
+		b.WriteString(fmt.Sprintf("- [ ] %s `%s`: %s\n", row.Status, row.Resource, reason))
+	}
+}
+
+func (f RemediationFormatter) FileExtension() string {
+	return remediationExtension
+}
+
+func (f RemediationFormatter) Name() string {
+	return OutputFormatRemediation
+}
+
+func (f RemediationFormatter) Alias() string {
+	return OutputFormatRemediationShort
+}
@@ -0,0 +1,357 @@
+package controldisplay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+const (
+	OutputFormatSarif = "sarif"
+	sarifExtension    = ".sarif"
+
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// the key of the fingerprint of each result, used to track results across runs
+	sarifFingerprintKey = "powerpipe/v1"
+)
+
+// SarifFormatter writes the results of the run as SARIF 2.1.0, e.g. for upload to GitHub Code Scanning - each control
+// is a rule, and each resource in alarm or error is a result of the rule, with a level (and GitHub security severity)
+// derived from the severity of the control
+// results are located at the declaration of the control in the mod, as SARIF consumers require a file location
+type SarifFormatter struct {
+	FormatterBase
+}
+
+type sarifLog struct {
+	Version string      `json:"version"`
+	Schema  string      `json:"$schema"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool              sarifTool               `json:"tool"`
+	AutomationDetails *sarifAutomationDetails `json:"automationDetails,omitempty"`
+	Invocations       []*sarifInvocation      `json:"invocations"`
+	Results           []*sarifResult          `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string       `json:"name"`
+	Version        string       `json:"version,omitempty"`
+	InformationUri string       `json:"informationUri"`
+	Rules          []*sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	Id                   string             `json:"id"`
+	Name                 string             `json:"name,omitempty"`
+	ShortDescription     *sarifMessage      `json:"shortDescription,omitempty"`
+	FullDescription      *sarifMessage      `json:"fullDescription,omitempty"`
+	Help                 *sarifMessage      `json:"help,omitempty"`
+	HelpUri              string             `json:"helpUri,omitempty"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+	Properties           map[string]any     `json:"properties,omitempty"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+type sarifAutomationDetails struct {
+	Id string `json:"id"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                 `json:"executionSuccessful"`
+	StartTimeUtc               string               `json:"startTimeUtc,omitempty"`
+	EndTimeUtc                 string               `json:"endTimeUtc,omitempty"`
+	ToolExecutionNotifications []*sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level      string                    `json:"level"`
+	Message    sarifMessage              `json:"message"`
+	Descriptor *sarifReportingDescriptor `json:"associatedRule,omitempty"`
+}
+
+type sarifReportingDescriptor struct {
+	Id    string `json:"id"`
+	Index int    `json:"index"`
+}
+
+type sarifResult struct {
+	RuleId              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []*sarifLocation  `json:"locations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+	Properties          map[string]any    `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation  `json:"physicalLocation,omitempty"`
+	LogicalLocations []*sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	Uri       string `json:"uri"`
+	UriBaseId string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// the SARIF level and GitHub security severity of each control severity - controls with no (or another) severity are
+// warnings, with no security severity
+var sarifSeverities = map[string]struct {
+	level            string
+	securitySeverity string
+}{
+	"critical": {level: "error", securitySeverity: "9.5"},
+	"high":     {level: "error", securitySeverity: "8.0"},
+	"medium":   {level: "warning", securitySeverity: "5.5"},
+	"low":      {level: "note", securitySeverity: "2.0"},
+}
+
+func (f SarifFormatter) Format(_ context.Context, tree *controlexecute.ExecutionTree) (io.Reader, error) {
+	data, err := json.MarshalIndent(sarifReport(tree, viper.GetString(constants.ArgModLocation)), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write SARIF: %s", err.Error())
+	}
+	return bytes.NewReader(append(data, '\n')), nil
+}
+
+// sarifReport returns the SARIF log of the run - control declarations are located relative to the mod location
+func sarifReport(tree *controlexecute.ExecutionTree, modLocation string) *sarifLog {
+	run := &sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "Powerpipe",
+			InformationUri: "https://powerpipe.io",
+			Rules:          []*sarifRule{},
+		}},
+		Results: []*sarifResult{},
+	}
+	if app_specific.AppVersion != nil {
+		run.Tool.Driver.Version = app_specific.AppVersion.String()
+	}
+	// (a category distinguishing the results of different benchmarks uploaded for the same commit)
+	if len(tree.Root.Groups) == 1 && len(tree.Root.ControlRuns) == 0 {
+		run.AutomationDetails = &sarifAutomationDetails{Id: tree.Root.Groups[0].GroupId + "/"}
+	}
+
+	invocation := &sarifInvocation{ExecutionSuccessful: true}
+	if !tree.StartTime.IsZero() {
+		invocation.StartTimeUtc = tree.StartTime.UTC().Format(time.RFC3339)
+		invocation.EndTimeUtc = tree.EndTime.UTC().Format(time.RFC3339)
+	}
+	if tree.AbortReason != "" {
+		invocation.ExecutionSuccessful = false
+		invocation.ToolExecutionNotifications = append(invocation.ToolExecutionNotifications, &sarifNotification{
+			Level:   "error",
+			Message: sarifMessage{Text: fmt.Sprintf("the run is incomplete: %s", tree.AbortReason)},
+		})
+	}
+
+	for index, controlRun := range sortedControlRuns(tree) {
+		rule := sarifControlRule(controlRun)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+
+		if controlRun.RunErrorString != "" {
+			invocation.ExecutionSuccessful = false
+			invocation.ToolExecutionNotifications = append(invocation.ToolExecutionNotifications, &sarifNotification{
+				Level:      "error",
+				Message:    sarifMessage{Text: controlRun.RunErrorString},
+				Descriptor: &sarifReportingDescriptor{Id: rule.Id, Index: index},
+			})
+		}
+
+		location := sarifControlLocation(controlRun, modLocation)
+		for _, row := range controlRun.Rows {
+			if row.Status != constants.ControlAlarm && row.Status != constants.ControlError {
+				continue
+			}
+			result := &sarifResult{
+				RuleId:    rule.Id,
+				RuleIndex: index,
+				Level:     rule.DefaultConfiguration.Level,
+				Message:   sarifMessage{Text: sarifResultMessage(row)},
+				Properties: map[string]any{
+					"status":   row.Status,
+					"resource": row.Resource,
+				},
+			}
+			if row.Fingerprint != "" {
+				result.PartialFingerprints = map[string]string{sarifFingerprintKey: row.Fingerprint}
+			}
+			if len(row.Dimensions) > 0 {
+				dimensions := make(map[string]string, len(row.Dimensions))
+				for _, dimension := range row.Dimensions {
+					dimensions[dimension.Key] = dimension.Value
+				}
+				result.Properties["dimensions"] = dimensions
+			}
+			resultLocation := &sarifLocation{}
+			if location != nil {
+				resultLocation.PhysicalLocation = location
+			}
+			if row.Resource != "" {
+				resultLocation.LogicalLocations = []*sarifLogicalLocation{{FullyQualifiedName: row.Resource, Kind: "resource"}}
+			}
+			if resultLocation.PhysicalLocation != nil || resultLocation.LogicalLocations != nil {
+				result.Locations = []*sarifLocation{resultLocation}
+			}
+			run.Results = append(run.Results, result)
+		}
+	}
+	run.Invocations = []*sarifInvocation{invocation}
+
+	return &sarifLog{Version: sarifVersion, Schema: sarifSchema, Runs: []*sarifRun{run}}
+}
+
+// sarifControlRule returns the rule of the control
+func sarifControlRule(run *controlexecute.ControlRun) *sarifRule {
+	rule := &sarifRule{
+		Id:                   run.FullName,
+		DefaultConfiguration: sarifConfiguration{Level: "warning"},
+		Properties:           map[string]any{},
+	}
+	if run.Control != nil {
+		rule.Name = run.Control.ShortName
+	}
+	title := run.Title
+	if title == "" {
+		title = run.ControlId
+	}
+	rule.ShortDescription = &sarifMessage{Text: title}
+	if run.Description != "" {
+		rule.FullDescription = &sarifMessage{Text: run.Description}
+	}
+	if severity, ok := sarifSeverities[strings.ToLower(run.Severity)]; ok {
+		rule.DefaultConfiguration.Level = severity.level
+		rule.Properties["security-severity"] = severity.securitySeverity
+	}
+	if run.Severity != "" {
+		rule.Properties["severity"] = run.Severity
+	}
+
+	if remediation := run.Remediation; remediation != nil {
+		rule.Help = sarifRemediationHelp(remediation)
+		rule.HelpUri = remediation.DocUrl
+	} else if run.Documentation != "" {
+		rule.Help = &sarifMessage{Text: run.Documentation, Markdown: run.Documentation}
+	}
+
+	// (tags are shown by GitHub as a list, so are formatted as key:value)
+	var tags []string
+	for key, value := range run.Tags {
+		if strings.HasPrefix(key, controlexecute.TagRemediation) {
+			continue
+		}
+		tags = append(tags, fmt.Sprintf("%s:%s", key, value))
+	}
+	if len(tags) > 0 {
+		sort.Strings(tags)
+		rule.Properties["tags"] = tags
+	}
+	if len(rule.Properties) == 0 {
+		rule.Properties = nil
+	}
+	return rule
+}
+
+// sarifRemediationHelp returns the help text of the remediation, with the commands and terraform as code blocks
+func sarifRemediationHelp(remediation *controlexecute.Remediation) *sarifMessage {
+	var text, markdown []string
+	if remediation.Description != "" {
+		text = append(text, remediation.Description)
+		markdown = append(markdown, remediation.Description)
+	}
+	if remediation.DocUrl != "" {
+		text = append(text, "Documentation: "+remediation.DocUrl)
+		markdown = append(markdown, fmt.Sprintf("[Documentation](%s)", remediation.DocUrl))
+	}
+	if remediation.Cli != "" {
+		text = append(text, "CLI:\n"+remediation.Cli)
+		markdown = append(markdown, fmt.Sprintf("CLI:\n```sh\n%s\n```", remediation.Cli))
+	}
+	if remediation.Terraform != "" {
+		text = append(text, "Terraform:\n"+remediation.Terraform)
+		markdown = append(markdown, fmt.Sprintf("Terraform:\n```hcl\n%s\n```", remediation.Terraform))
+	}
+	return &sarifMessage{Text: strings.Join(text, "\n\n"), Markdown: strings.Join(markdown, "\n\n")}
+}
+
+// sarifControlLocation returns the location of the declaration of the control, relative to the mod location - nil is
+// returned if the control has no declaration
+func sarifControlLocation(run *controlexecute.ControlRun, modLocation string) *sarifPhysicalLocation {
+	if run.Control == nil {
+		return nil
+	}
+	declRange := run.Control.GetDeclRange()
+	if declRange == nil || declRange.Filename == "" {
+		return nil
+	}
+	location := &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{Uri: filepath.ToSlash(declRange.Filename)}}
+	if modLocation != "" {
+		if rel, err := filepath.Rel(modLocation, declRange.Filename); err == nil && !strings.HasPrefix(rel, "..") {
+			location.ArtifactLocation = sarifArtifactLocation{Uri: filepath.ToSlash(rel), UriBaseId: "%SRCROOT%"}
+		}
+	}
+	if declRange.Start.Line > 0 {
+		location.Region = &sarifRegion{StartLine: declRange.Start.Line}
+	}
+	return location
+}
+
+// sarifResultMessage returns the message of the result of the row - the resource and reason
+func sarifResultMessage(row *controlexecute.ResultRow) string {
+	switch {
+	case row.Resource == "":
+		return row.Reason
+	case row.Reason == "":
+		return row.Resource
+	}
+	return fmt.Sprintf("%s: %s", row.Resource, row.Reason)
+}
+
+func (f SarifFormatter) FileExtension() string {
+	return sarifExtension
+}
+
+func (f SarifFormatter) Name() string {
+	return OutputFormatSarif
+}
@@ -0,0 +1,174 @@
+package controldisplay
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+type sarifRuleTest struct {
+	severity    string
+	remediation *controlexecute.Remediation
+	// the expected level and security severity of the rule
+	level            string
+	securitySeverity any
+	help             string
+}
+
+var testCasesSarifRule = map[string]sarifRuleTest{
+	"critical": {
+		severity:         "critical",
+		level:            "error",
+		securitySeverity: "9.5",
+	},
+	"high": {
+		severity:         "HIGH",
+		level:            "error",
+		securitySeverity: "8.0",
+	},
+	"medium": {
+		severity:         "medium",
+		level:            "warning",
+		securitySeverity: "5.5",
+	},
+	"low": {
+		severity:         "low",
+		level:            "note",
+		securitySeverity: "2.0",
+	},
+	"no severity": {
+		level: "warning",
+	},
+	"other severity": {
+		severity: "none",
+		level:    "warning",
+	},
+	"remediation": {
+		severity: "high",
+		remediation: &controlexecute.Remediation{
+			Description: "Block public access.",
+			DocUrl:      "https://example.com/s3",
+			Cli:         "aws s3api put-public-access-block",
+		},
+		level:            "error",
+		securitySeverity: "8.0",
+		help:             "Block public access.\n\n[Documentation](https://example.com/s3)\n\nCLI:\n```sh\naws s3api put-public-access-block\n```",
+	},
+}
+
+func TestSarifControlRule(t *testing.T) {
+	for name, test := range testCasesSarifRule {
+		run := &controlexecute.ControlRun{
+			FullName:    "test.control.a",
+			ControlId:   "test.control.a",
+			Title:       "Control a",
+			Severity:    test.severity,
+			Remediation: test.remediation,
+		}
+		rule := sarifControlRule(run)
+
+		if rule.DefaultConfiguration.Level != test.level {
+			t.Errorf("Test: '%s' FAILED : expected level %s, got %s", name, test.level, rule.DefaultConfiguration.Level)
+		}
+		if actual := rule.Properties["security-severity"]; actual != test.securitySeverity {
+			t.Errorf("Test: '%s' FAILED : expected security severity %v, got %v", name, test.securitySeverity, actual)
+		}
+		var help string
+		if rule.Help != nil {
+			help = rule.Help.Markdown
+		}
+		if help != test.help {
+			t.Errorf("Test: '%s' FAILED : expected help %q, got %q", name, test.help, help)
+		}
+		if rule.Id != "test.control.a" || rule.ShortDescription == nil || rule.ShortDescription.Text != "Control a" {
+			t.Errorf("Test: '%s' FAILED : unexpected rule %+v", name, rule)
+		}
+	}
+}
+
+func TestSarifFormat(t *testing.T) {
+	benchmark := &controlexecute.ResultGroup{
+		GroupId: "test.benchmark.cis",
+		Title:   "CIS",
+		ControlRuns: []*controlexecute.ControlRun{
+			{
+				FullName:  "test.control.b",
+				ControlId: "test.control.b",
+				Severity:  "high",
+				Rows: []*controlexecute.ResultRow{
+					{Status: "alarm", Resource: "arn:aws:s3:::a", Reason: "a is public", Fingerprint: "f1"},
+					{Status: "ok", Resource: "arn:aws:s3:::b", Reason: "b is private"},
+					{Status: "error", Resource: "arn:aws:s3:::c", Reason: "access denied"},
+				},
+			},
+			{
+				FullName:       "test.control.a",
+				ControlId:      "test.control.a",
+				RunErrorString: "relation \"aws_s3_bucket\" does not exist",
+			},
+		},
+	}
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	tree := &controlexecute.ExecutionTree{
+		Root:      &controlexecute.ResultGroup{Groups: []*controlexecute.ResultGroup{benchmark}},
+		StartTime: start,
+		EndTime:   start.Add(90 * time.Second),
+		ControlRuns: map[string]*controlexecute.ControlRun{
+			"test.control.b": benchmark.ControlRuns[0],
+			"test.control.a": benchmark.ControlRuns[1],
+		},
+	}
+
+	reader, err := SarifFormatter{}.Format(context.Background(), tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("Test: 'parse' FAILED : %v\n%s", err, data)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Test: 'log' FAILED : unexpected log %s", data)
+	}
+	run := log.Runs[0]
+
+	var rules []string
+	for _, rule := range run.Tool.Driver.Rules {
+		rules = append(rules, rule.Id)
+	}
+	if expected := "test.control.a,test.control.b"; strings.Join(rules, ",") != expected {
+		t.Errorf("Test: 'rules' FAILED : expected %s, got %s", expected, strings.Join(rules, ","))
+	}
+	var results []string
+	for _, result := range run.Results {
+		results = append(results, result.RuleId+" "+result.Level+" "+result.Message.Text)
+	}
+	if expected := "test.control.b error arn:aws:s3:::a: a is public,test.control.b error arn:aws:s3:::c: access denied"; strings.Join(results, ",") != expected {
+		t.Errorf("Test: 'results' FAILED : expected %s, got %s", expected, strings.Join(results, ","))
+	}
+	if len(run.Results) == 2 {
+		result := run.Results[0]
+		if result.RuleIndex != 1 || result.PartialFingerprints[sarifFingerprintKey] != "f1" || len(result.Locations) != 1 || result.Locations[0].LogicalLocations[0].FullyQualifiedName != "arn:aws:s3:::a" {
+			t.Errorf("Test: 'result' FAILED : unexpected result %+v", result)
+		}
+	}
+	if run.AutomationDetails == nil || run.AutomationDetails.Id != "test.benchmark.cis/" {
+		t.Errorf("Test: 'automation details' FAILED : expected test.benchmark.cis/, got %+v", run.AutomationDetails)
+	}
+	if len(run.Invocations) != 1 {
+		t.Fatalf("Test: 'invocations' FAILED : expected 1 invocation, got %d", len(run.Invocations))
+	}
+	invocation := run.Invocations[0]
+	if invocation.ExecutionSuccessful || len(invocation.ToolExecutionNotifications) != 1 || invocation.StartTimeUtc != "2026-10-16T09:00:00Z" {
+		t.Errorf("Test: 'invocation' FAILED : unexpected invocation %+v", invocation)
+	}
+}
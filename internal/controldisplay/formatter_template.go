@@ -12,6 +12,7 @@ import (
 	"github.com/spf13/viper"
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/utils"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
 	"github.com/turbot/powerpipe/internal/controlexecute"
 )
 
@@ -43,6 +44,10 @@ func NewTemplateFormatter(input *OutputTemplate) (*TemplateFormatter, error) {
 }
 
 func (tf TemplateFormatter) Format(ctx context.Context, tree *controlexecute.ExecutionTree) (io.Reader, error) {
+	if tf.supportsView() {
+		tree = applyConfiguredView(tree)
+	}
+
 	reader, writer := io.Pipe()
 	go func() {
 		workingDirectory, err := os.Getwd()
@@ -58,6 +63,7 @@ func (tf TemplateFormatter) Format(ctx context.Context, tree *controlexecute.Exe
 			Config: TemplateRenderConfig{
 				RenderHeader: viper.GetBool(constants.ArgHeader),
 				Separator:    viper.GetString(constants.ArgSeparator),
+				Theme:        viper.GetString(localconstants.ArgHtmlTheme),
 			},
 			Data: tree,
 		}
@@ -103,6 +109,12 @@ func (tf TemplateFormatter) Alias() string {
 	return ""
 }
 
+// supportsView returns whether the results may be grouped and filtered using --group-by and --status
+// (machine readable formats always contain all results)
+func (tf TemplateFormatter) supportsView() bool {
+	return tf.Name() == constants.OutputFormatHTML || tf.Name() == constants.OutputFormatMD
+}
+
 func (tf TemplateFormatter) shouldPrettify() bool {
 	return tf.Name() == constants.OutputFormatJSON
 }
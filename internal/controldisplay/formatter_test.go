@@ -77,6 +77,46 @@ var formatterTestCase = []testCase{
 			name:      "nunit3",
 		},
 	},
+	{
+		input: "remediation.md",
+		expected: testFormatter{
+			alias:     "remediation.md",
+			extension: ".remediation.md",
+			name:      "remediation",
+		},
+	},
+	{
+		input: "badge.svg",
+		expected: testFormatter{
+			alias:     "badge.svg",
+			extension: ".badge.svg",
+			name:      "badge",
+		},
+	},
+	{
+		input: "junit",
+		expected: testFormatter{
+			alias:     "junit.xml",
+			extension: ".junit.xml",
+			name:      "junit",
+		},
+	},
+	{
+		input: "junit.xml",
+		expected: testFormatter{
+			alias:     "junit.xml",
+			extension: ".junit.xml",
+			name:      "junit",
+		},
+	},
+	{
+		input: "sarif",
+		expected: testFormatter{
+			alias:     "",
+			extension: ".sarif",
+			name:      "sarif",
+		},
+	},
 }
 
 func TestFormatResolver(t *testing.T) {
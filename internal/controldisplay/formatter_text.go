@@ -18,7 +18,7 @@ type TextFormatter struct {
 }
 
 func (tf TextFormatter) Format(_ context.Context, tree *controlexecute.ExecutionTree) (io.Reader, error) {
-	renderer := NewTableRenderer(tree)
+	renderer := NewTableRenderer(applyConfiguredView(tree))
 	widthConstraint := utils.NewRangeConstraint(renderer.MinimumWidth(), MaxColumns)
 	renderedText := renderer.Render(widthConstraint.Constrain(GetMaxCols()))
 	res := strings.NewReader(fmt.Sprintf("\n%s\n", renderedText))
@@ -1,15 +1,49 @@
 package controldisplay
 
 import (
+	"fmt"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/export"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/customexport"
+	"github.com/turbot/powerpipe/internal/email"
 )
 
 // GetExporters returns an array of ControlExporters corresponding to the available output formats
+// along with any custom and email exporters used by the export args
 func GetExporters() ([]export.Exporter, error) {
 	formatResolver, err := NewFormatResolver()
 	if err != nil {
 		return nil, err
 	}
 	exporters := formatResolver.controlExporters()
-	return exporters, nil
+
+	exportArgs := viper.GetStringSlice(constants.ArgExport)
+	customExporters, err := customexport.GetExporters(exportArgs, executionTreeSnapshotJson)
+	if err != nil {
+		return nil, err
+	}
+	exporters = append(exporters, customExporters...)
+
+	// email exporters send the html export, with exports of the other formats attached
+	emailExporters, err := email.GetExporters(exportArgs, exporters)
+	if err != nil {
+		return nil, err
+	}
+	return append(exporters, emailExporters...), nil
+}
+
+// executionTreeSnapshotJson converts a control execution tree into snapshot JSON, for custom exporters
+func executionTreeSnapshotJson(input export.ExportSourceData) ([]byte, error) {
+	tree, ok := input.(*controlexecute.ExecutionTree)
+	if !ok {
+		return nil, fmt.Errorf("custom exporter input must be *controlexecute.ExecutionTree")
+	}
+	snapshot, err := executionTreeToSnapshot(tree)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.AsStrippedJson(false)
 }
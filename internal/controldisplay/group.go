@@ -36,9 +36,17 @@ func NewGroupRenderer(group *controlexecute.ResultGroup, parent *GroupRenderer,
 // are we the last child of our parent?
 // this affects the tree rendering
 func (r GroupRenderer) isLastChild(group *controlexecute.ResultGroup) bool {
-	if group.Parent == nil || group.Parent.GroupItem == nil {
+	if group.Parent == nil {
 		return true
 	}
+	// if a view has been applied (i.e. --group-by), children are displayed in order
+	if r.resultTree.View != nil || group.Parent.GroupItem == nil {
+		if group.Parent.GroupId == controlexecute.RootResultGroupName {
+			return true
+		}
+		children := group.Parent.Children
+		return len(children) > 0 && children[len(children)-1] == group
+	}
 	siblings := group.Parent.GroupItem.GetChildren()
 	// get the name of the last sibling which has controls (or is a control)
 	var finalSiblingName string
@@ -156,9 +164,24 @@ func (r GroupRenderer) renderRootResultGroup() string {
 
 // render the children of this group, in the order they are specified in the hcl
 func (r GroupRenderer) renderChildren() []string {
-	children := r.group.GroupItem.GetChildren()
 	var childStrings []string
 
+	// if a view has been applied (i.e. --group-by), render the children in order
+	if r.resultTree.View != nil || r.group.GroupItem == nil {
+		for _, child := range r.group.Children {
+			switch c := child.(type) {
+			case *controlexecute.ControlRun:
+				childStrings = append(childStrings, NewControlRenderer(c, &r).Render())
+			case *controlexecute.ResultGroup:
+				groupRenderer := NewGroupRenderer(c, &r, r.maxFailedControls, r.maxTotalControls, r.resultTree, r.width)
+				childStrings = append(childStrings, groupRenderer.Render())
+			}
+		}
+		return childStrings
+	}
+
+	children := r.group.GroupItem.GetChildren()
+
 	for _, child := range children {
 		if control, ok := child.(*modconfig.Control); ok {
 			// get Result group with a matching name
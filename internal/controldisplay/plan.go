@@ -0,0 +1,64 @@
+package controldisplay
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+// RenderPlan returns a text representation of an execution plan - the benchmark tree with the estimated duration
+// of each control, followed by the total estimated duration of the run
+func RenderPlan(plan *controlexecute.ExecutionPlan) string {
+	var b strings.Builder
+	if plan.Database != "" {
+		b.WriteString(fmt.Sprintf("Database:    %s\n", plan.Database))
+	}
+	if len(plan.SearchPath) > 0 {
+		b.WriteString(fmt.Sprintf("Search path: %s\n", strings.Join(plan.SearchPath, ", ")))
+	}
+	b.WriteString(fmt.Sprintf("Parallelism: %d\n\n", plan.Parallelism))
+
+	for _, group := range plan.Groups {
+		renderPlanGroup(&b, group, 0)
+	}
+
+	b.WriteString(fmt.Sprintf("\n%d %s, estimated duration %s", plan.ControlCount, utils.Pluralize("control", plan.ControlCount), formatEstimate(plan.EstimatedDuration)))
+	if plan.Unestimated > 0 {
+		b.WriteString(fmt.Sprintf(" - %d of these with no previous runs are not included in the estimate", plan.Unestimated))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func renderPlanGroup(b *strings.Builder, group *controlexecute.PlanGroup, depth int) {
+	// the group for controls run directly has no name - render these at the top level
+	if group.Name != "" {
+		b.WriteString(fmt.Sprintf("%s%s", strings.Repeat("  ", depth), group.Name))
+		if group.Title != "" {
+			b.WriteString(fmt.Sprintf("  %s", group.Title))
+		}
+		b.WriteString("\n")
+		depth++
+	}
+
+	for _, control := range group.Controls {
+		estimate := "no previous runs"
+		if control.HasEstimate {
+			estimate = formatEstimate(control.Estimate)
+		}
+		b.WriteString(fmt.Sprintf("%s%s  [%s]\n", strings.Repeat("  ", depth), control.Name, estimate))
+	}
+	for _, child := range group.Groups {
+		renderPlanGroup(b, child, depth)
+	}
+}
+
+func formatEstimate(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
@@ -1,16 +1,18 @@
 package controldisplay
 
 import (
-	"context"
 	"fmt"
 	"github.com/turbot/pipe-fittings/steampipeconfig"
 
-	"github.com/turbot/pipe-fittings/cloud"
 	"github.com/turbot/pipe-fittings/modconfig"
-	"github.com/turbot/pipe-fittings/statushooks"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
 	"github.com/turbot/powerpipe/internal/controlexecute"
 	"github.com/turbot/powerpipe/internal/dashboardexecute"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
 	"github.com/turbot/powerpipe/internal/dashboardworkspace"
+	"github.com/turbot/powerpipe/internal/snapshot"
+	"github.com/turbot/powerpipe/internal/snapshotdest"
+	"github.com/turbot/powerpipe/internal/upload"
 )
 
 func executionTreeToSnapshot(e *controlexecute.ExecutionTree) (*steampipeconfig.SteampipeSnapshot, error) {
@@ -37,8 +39,24 @@ func executionTreeToSnapshot(e *controlexecute.ExecutionTree) (*steampipeconfig.
 	}
 	checkRun.DashboardTreeRunImpl = dashboardexecute.NewDashboardTreeRunImpl(dashboardNode, nil, checkRun, nil)
 
+	// if the run was aborted, mark the root panel as incomplete
+	if incomplete := e.IncompleteMessage(); incomplete != "" {
+		switch root := checkRun.Root.(type) {
+		case *controlexecute.ControlRun:
+			if root.RunErrorString == "" {
+				root.RunErrorString = incomplete
+			}
+		default:
+			checkRun.ErrorString = incomplete
+			checkRun.Status = dashboardtypes.RunCanceled
+		}
+	}
+
 	// populate the panels
 	panels = checkRun.BuildSnapshotPanels(make(map[string]steampipeconfig.SnapshotPanel))
+	if e.Environment != nil {
+		panels[snapshot.EnvironmentPanelName] = e.Environment
+	}
 
 	vars, err := dashboardexecute.GetReferencedVariables(checkRun, dashboardworkspace.NewWorkspaceEvents(e.Workspace))
 	if err != nil {
@@ -60,21 +78,20 @@ func executionTreeToSnapshot(e *controlexecute.ExecutionTree) (*steampipeconfig.
 	return res, nil
 }
 
-func PublishSnapshot(ctx context.Context, e *controlexecute.ExecutionTree, shouldShare bool) error {
-	statushooks.Show(ctx)
-	defer statushooks.Done(ctx)
-
+// SubmitSnapshot adds the upload of the snapshot of the tree to the snapshot location to the upload pipeline
+func SubmitSnapshot(pipeline *upload.Pipeline, e *controlexecute.ExecutionTree, shouldShare bool) error {
 	snapshot, err := executionTreeToSnapshot(e)
 	if err != nil {
 		return err
 	}
-
-	message, err := cloud.PublishSnapshot(ctx, snapshot, shouldShare)
+	data, err := snapshot.AsStrippedJson(false)
 	if err != nil {
 		return err
 	}
-	statushooks.Done(ctx)
-	fmt.Println(message) //nolint:forbidigo // acceptable
+	pipeline.Submit(upload.Task{
+		Name:   snapshot.FileNameRoot + localconstants.SnapshotExtension,
+		Size:   len(data),
+		Upload: snapshotdest.SnapshotUpload(snapshot, shouldShare),
+	})
 	return nil
-
 }
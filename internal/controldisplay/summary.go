@@ -6,6 +6,7 @@ import (
 
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/i18n"
 )
 
 type SummaryRenderer struct {
@@ -49,7 +50,7 @@ func (r SummaryRenderer) Render() string {
 	alarmStatusRow := NewSummaryStatusRowRenderer(r.resultTree, availableWidth, "alarm").Render()
 	errorStatusRow := NewSummaryStatusRowRenderer(r.resultTree, availableWidth, "error").Render()
 
-	titleLine := fmt.Sprintf("%s\n", ControlColors.GroupTitle("Summary"))
+	titleLine := fmt.Sprintf("%s\n", ControlColors.GroupTitle(i18n.T("report.summary")))
 
 	// build the summary
 	var summaryLines = []string{
@@ -6,6 +6,7 @@ import (
 
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/i18n"
 )
 
 type SummarySeverityRowRenderer struct {
@@ -29,7 +30,7 @@ func (r *SummarySeverityRowRenderer) Render() string {
 		return ""
 	}
 	colorFunc := ControlColors.Severity
-	severityStr := fmt.Sprintf("%s ", colorFunc(strings.ToUpper(r.severity)))
+	severityStr := fmt.Sprintf("%s ", colorFunc(strings.ToUpper(i18n.Severity(r.severity))))
 
 	count := NewCounterRenderer(
 		severitySummary.FailedCount(),
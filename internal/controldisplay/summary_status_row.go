@@ -7,6 +7,7 @@ import (
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/i18n"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 )
@@ -57,7 +58,7 @@ func (r *SummaryStatusRowRenderer) Render() string {
 		},
 	).Render()
 
-	statusStr := fmt.Sprintf("%s ", txtColorFunction(strings.ToUpper(r.status)))
+	statusStr := fmt.Sprintf("%s ", txtColorFunction(strings.ToUpper(i18n.Status(r.status))))
 	spaceAvailableForSpacer := r.width - (helpers.PrintableLength(statusStr) + helpers.PrintableLength(countString) + helpers.PrintableLength(graph))
 	spacer := NewSpacerRenderer(spaceAvailableForSpacer)
 
@@ -2,9 +2,11 @@ package controldisplay
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/i18n"
 )
 
 type SummaryTotalRowRenderer struct {
@@ -21,7 +23,7 @@ func NewSummaryTotalRowRenderer(resultTree *controlexecute.ExecutionTree, width
 
 func (r *SummaryTotalRowRenderer) Render() string {
 
-	head := fmt.Sprintf("%s ", ControlColors.GroupTitle("TOTAL"))
+	head := fmt.Sprintf("%s ", ControlColors.GroupTitle(strings.ToUpper(i18n.T("report.total"))))
 	count := NewCounterRenderer(
 		r.resultTree.Root.Summary.Status.FailedCount(),
 		r.resultTree.Root.Summary.Status.TotalCount(),
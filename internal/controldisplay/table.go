@@ -6,6 +6,7 @@ import (
 	"github.com/spf13/viper"
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/verbosity"
 )
 
 type TableRenderer struct {
@@ -66,8 +67,8 @@ func (r TableRenderer) Render(width int) string {
 }
 
 func (r TableRenderer) renderSummary() string {
-	// no need to render the summary when the dry-run flag is set
-	if viper.GetBool(constants.ArgDryRun) {
+	// no need to render the summary when the dry-run or quiet flag is set
+	if viper.GetBool(constants.ArgDryRun) || verbosity.Quiet() {
 		return ""
 	}
 	return NewSummaryRenderer(r.resultTree, r.width).Render()
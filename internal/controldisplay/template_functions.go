@@ -10,12 +10,14 @@ import (
 	"time"
 
 	"github.com/Masterminds/sprig/v3"
+	"github.com/turbot/powerpipe/internal/i18n"
+	"github.com/turbot/powerpipe/internal/locale"
 )
 
 // templateFuncs merges desired functions from sprig with custom functions that we
 // define in steampipe
 func templateFuncs(renderContext TemplateRenderContext) template.FuncMap {
-	useFromSprigMap := []string{"upper", "toJson", "quote", "dict", "add", "now", "toPrettyJson"}
+	useFromSprigMap := []string{"upper", "toJson", "quote", "dict", "add", "now", "toPrettyJson", "int", "min"}
 
 	var funcs template.FuncMap = template.FuncMap{}
 	sprigMap := sprig.TxtFuncMap()
@@ -34,6 +36,10 @@ func templateFuncs(renderContext TemplateRenderContext) template.FuncMap {
 	formatterTemplateFuncMap := template.FuncMap{
 		"durationInSeconds": durationInSeconds,
 		"toCsvCell":         toCSVCellFnFactory(renderContext.Config.Separator),
+		"formatTime":        formatTime,
+		"t":                 i18n.T,
+		"status":            i18n.Status,
+		"language":          currentLanguage,
 	}
 	for k, v := range formatterTemplateFuncMap {
 		funcs[k] = v
@@ -65,5 +71,11 @@ func toCSVCellFnFactory(comma string) func(interface{}) string {
 	}
 }
 
+// formatTime formats a timestamp using the timezone and date format settings
+func formatTime(t time.Time) string { return locale.Current().FormatTime(t) }
+
 // durationInSeconds returns the passed in duration as seconds
 func durationInSeconds(t time.Duration) float64 { return t.Seconds() }
+
+// currentLanguage returns the language tag of the current language, e.g. for the lang attribute of html documents
+func currentLanguage() string { return i18n.Current().Language }
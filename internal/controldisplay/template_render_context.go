@@ -1,10 +1,33 @@
 package controldisplay
 
-import "github.com/turbot/powerpipe/internal/controlexecute"
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+// the themes of html output - the high contrast theme is for readers who need stronger contrast than the default
+const (
+	HtmlThemeDefault      = "default"
+	HtmlThemeHighContrast = "high-contrast"
+)
+
+var HtmlThemes = []string{HtmlThemeDefault, HtmlThemeHighContrast}
+
+// ValidateHtmlTheme returns an error if the theme is not one of HtmlThemes
+func ValidateHtmlTheme(theme string) error {
+	if !slices.Contains(HtmlThemes, theme) {
+		return fmt.Errorf("invalid html theme '%s' - must be one of: %s", theme, strings.Join(HtmlThemes, ", "))
+	}
+	return nil
+}
 
 type TemplateRenderConfig struct {
 	RenderHeader bool
 	Separator    string
+	Theme        string
 }
 
 type TemplateRenderConstants struct {
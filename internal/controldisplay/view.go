@@ -0,0 +1,18 @@
+package controldisplay
+
+import (
+	"log/slog"
+
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+// applyConfiguredView groups and filters the results of the tree as configured using --group-by and --status
+func applyConfiguredView(tree *controlexecute.ExecutionTree) *controlexecute.ExecutionTree {
+	view, err := controlexecute.ConfiguredView()
+	if err != nil {
+		// the view is validated before execution, so this should never happen
+		slog.Warn("ignoring invalid view", "error", err)
+		return tree
+	}
+	return tree.ApplyView(view)
+}
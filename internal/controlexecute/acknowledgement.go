@@ -0,0 +1,47 @@
+package controlexecute
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/workspace"
+	"github.com/turbot/powerpipe/internal/ack"
+	"github.com/turbot/powerpipe/internal/locale"
+)
+
+// loadAcknowledgements loads the acknowledged findings of the workspace
+// failure to load the acknowledgements is logged and does not fail the run
+func loadAcknowledgements(w *workspace.Workspace) *ack.Acknowledgements {
+	if w == nil || w.Path == "" {
+		return nil
+	}
+	res, err := ack.Load(ack.Path(w.Path))
+	if err != nil {
+		slog.Warn("failed to load finding acknowledgements - acknowledged findings will be reported", "error", err)
+		return nil
+	}
+	return res
+}
+
+// acknowledge reports the result as info if it is an acknowledged alarm or error, so that known issues are silenced
+// until the acknowledgement expires
+func (r *ResultRow) acknowledge(acknowledgements *ack.Acknowledgements, at time.Time) {
+	if acknowledgements == nil || (r.Status != constants.ControlAlarm && r.Status != constants.ControlError) {
+		return
+	}
+	a, ok := acknowledgements.Get(r.Fingerprint, at)
+	if !ok {
+		return
+	}
+	r.Acknowledgement = a
+	r.AcknowledgedStatus = r.Status
+	r.Status = constants.ControlInfo
+
+	acknowledged := fmt.Sprintf("acknowledged %s until %s", r.AcknowledgedStatus, locale.Current().FormatTimeWithZone(a.Until))
+	if a.Reason != "" {
+		acknowledged += ": " + a.Reason
+	}
+	r.Reason = fmt.Sprintf("%s (%s)", r.Reason, acknowledged)
+}
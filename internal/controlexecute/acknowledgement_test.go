@@ -0,0 +1,63 @@
+package controlexecute
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/ack"
+)
+
+type acknowledgeTest struct {
+	status            string
+	fingerprint       string
+	expectedStatus    string
+	expectedAckStatus string
+}
+
+var testCasesAcknowledge = map[string]acknowledgeTest{
+	"acknowledged alarm": {
+		status:            "alarm",
+		fingerprint:       "63ac7e199dc796a67ba227e9cfa75ae7",
+		expectedStatus:    "info",
+		expectedAckStatus: "alarm",
+	},
+	"acknowledged error": {
+		status:            "error",
+		fingerprint:       "63ac7e199dc796a67ba227e9cfa75ae7",
+		expectedStatus:    "info",
+		expectedAckStatus: "error",
+	},
+	"acknowledged ok": {
+		status:         "ok",
+		fingerprint:    "63ac7e199dc796a67ba227e9cfa75ae7",
+		expectedStatus: "ok",
+	},
+	"expired": {
+		status:         "alarm",
+		fingerprint:    "a347e9341180f4f75fa0cde9697bc2d6",
+		expectedStatus: "alarm",
+	},
+	"not acknowledged": {
+		status:         "alarm",
+		fingerprint:    "0f0e3bb4d7ad5e0e84a2b5ab4cbd0a51",
+		expectedStatus: "alarm",
+	},
+}
+
+func TestAcknowledge(t *testing.T) {
+	now := time.Now()
+	acknowledgements := &ack.Acknowledgements{Findings: map[string]*ack.Acknowledgement{
+		"63ac7e199dc796a67ba227e9cfa75ae7": {Fingerprint: "63ac7e199dc796a67ba227e9cfa75ae7", Until: now.Add(time.Hour), Reason: "INC-1234"},
+		"a347e9341180f4f75fa0cde9697bc2d6": {Fingerprint: "a347e9341180f4f75fa0cde9697bc2d6", Until: now.Add(-time.Hour)},
+	}}
+	for name, test := range testCasesAcknowledge {
+		row := &ResultRow{Status: test.status, Reason: "bucket is public", Fingerprint: test.fingerprint}
+		row.acknowledge(acknowledgements, now)
+		if row.Status != test.expectedStatus || row.AcknowledgedStatus != test.expectedAckStatus {
+			t.Errorf("Test: '%s' FAILED : expected status %s (acknowledged %q), got %s (acknowledged %q)", name, test.expectedStatus, test.expectedAckStatus, row.Status, row.AcknowledgedStatus)
+		}
+		if (row.Acknowledgement != nil) != (test.expectedAckStatus != "") || (row.Acknowledgement == nil) != (row.Reason == "bucket is public") {
+			t.Errorf("Test: '%s' FAILED : unexpected acknowledgement %+v with reason '%s'", name, row.Acknowledgement, row.Reason)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package controlexecute
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+// RunBudget limits the duration of a run and the number of result rows it returns
+// when a limit is exceeded the run is aborted - controls which have not completed are cancelled,
+// and the results returned so far are retained
+type RunBudget struct {
+	MaxDuration time.Duration
+	MaxRows     int64
+
+	rows        atomic.Int64
+	cancel      context.CancelFunc
+	abortReason string
+	abortLock   sync.Mutex
+}
+
+// NewRunBudget creates a RunBudget from the '--max-duration' and '--max-cost-rows' args
+// a zero value for either limit means the limit is not applied
+func NewRunBudget() *RunBudget {
+	// the duration is validated by the check command
+	maxDuration, _ := time.ParseDuration(viper.GetString(localconstants.ArgMaxDuration))
+	return &RunBudget{
+		MaxDuration: maxDuration,
+		MaxRows:     viper.GetInt64(localconstants.ArgMaxCostRows),
+	}
+}
+
+// start returns a context which is cancelled when the budget is exceeded
+// the returned CancelFunc must be called when the run completes
+func (b *RunBudget) start(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, b.cancel = context.WithCancel(ctx)
+	if b.MaxDuration <= 0 {
+		return ctx, b.cancel
+	}
+	timer := time.AfterFunc(b.MaxDuration, func() {
+		b.abort(fmt.Sprintf("the run exceeded the max duration of %s", b.MaxDuration))
+	})
+	return ctx, func() {
+		timer.Stop()
+		b.cancel()
+	}
+}
+
+// addRows adds to the number of result rows returned by the run, aborting the run if this exceeds the max rows
+func (b *RunBudget) addRows(count int64) {
+	if b.MaxRows <= 0 {
+		return
+	}
+	if rows := b.rows.Add(count); rows > b.MaxRows {
+		b.abort(fmt.Sprintf("the run exceeded the max cost of %d result rows", b.MaxRows))
+	}
+}
+
+// abort cancels the run - only the first reason is retained
+func (b *RunBudget) abort(reason string) {
+	b.abortLock.Lock()
+	defer b.abortLock.Unlock()
+	if b.abortReason != "" {
+		return
+	}
+	b.abortReason = reason
+	if b.cancel != nil {
+		b.cancel()
+	}
+}
+
+// AbortReason returns the reason the run was aborted, or an empty string if the run was within budget
+func (b *RunBudget) AbortReason() string {
+	b.abortLock.Lock()
+	defer b.abortLock.Unlock()
+	return b.abortReason
+}
+
+// IncompleteMessage returns a message explaining why the results of the tree are incomplete,
+// or an empty string if the run was not aborted
+func (e *ExecutionTree) IncompleteMessage() string {
+	if e.AbortReason == "" {
+		return ""
+	}
+	return fmt.Sprintf("run aborted as %s - results are incomplete", e.AbortReason)
+}
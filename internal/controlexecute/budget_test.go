@@ -0,0 +1,62 @@
+package controlexecute
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type budgetTest struct {
+	budget       *RunBudget
+	rows         int64
+	wait         time.Duration
+	expectAbort  bool
+	expectReason string
+}
+
+var testCasesRunBudget = map[string]budgetTest{
+	"no limits": {
+		budget: &RunBudget{},
+		rows:   1000,
+	},
+	"within max rows": {
+		budget: &RunBudget{MaxRows: 10},
+		rows:   10,
+	},
+	"max rows exceeded": {
+		budget:       &RunBudget{MaxRows: 10},
+		rows:         11,
+		expectAbort:  true,
+		expectReason: "the run exceeded the max cost of 10 result rows",
+	},
+	"max duration exceeded": {
+		budget:       &RunBudget{MaxDuration: 10 * time.Millisecond},
+		wait:         time.Second,
+		expectAbort:  true,
+		expectReason: "the run exceeded the max duration of 10ms",
+	},
+}
+
+func TestRunBudget(t *testing.T) {
+	for name, test := range testCasesRunBudget {
+		ctx, cancel := test.budget.start(context.Background())
+		for i := int64(0); i < test.rows; i++ {
+			test.budget.addRows(1)
+		}
+		if test.wait > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(test.wait):
+			}
+		}
+		aborted := ctx.Err() != nil
+		cancel()
+
+		if aborted != test.expectAbort {
+			t.Errorf("Test: '%s' FAILED : expected aborted %v, got %v", name, test.expectAbort, aborted)
+		}
+		if reason := test.budget.AbortReason(); reason != test.expectReason {
+			t.Errorf("Test: '%s' FAILED : expected reason '%s', got '%s'", name, test.expectReason, reason)
+		}
+	}
+}
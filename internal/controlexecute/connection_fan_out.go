@@ -0,0 +1,241 @@
+package controlexecute
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/backend"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/modconfig"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/db_client"
+	localqueryresult "github.com/turbot/powerpipe/internal/queryresult"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// when the database is Steampipe, controls may be fanned out over connections using --fan-out-connections, e.g.
+//
+//	--fan-out-connections aws_all
+//
+// each control query is then run once per connection, with the connection schema first in the search path, so the
+// unqualified tables of the query are resolved by that connection alone. Each result row is given a 'connection'
+// dimension naming its source connection, so per-account breakdowns do not rely on the query selecting account_id
+// values are connection names or globs - aggregator connections are expanded to the connections they aggregate
+
+// ConnectionDimension is the dimension which attributes a result row to its source connection
+const ConnectionDimension = "connection"
+
+const steampipeConnectionsQuery = `select name, coalesce(type, ''), coalesce(state, ''), coalesce(to_jsonb(connections)::text, '[]')
+from steampipe_internal.steampipe_connection
+order by name`
+
+// steampipeConnection is a row of the steampipe_internal.steampipe_connection table
+type steampipeConnection struct {
+	Name  string
+	Type  string
+	State string
+	// for an aggregator, the names (or globs) of the connections it aggregates
+	Connections []string
+}
+
+func (c steampipeConnection) isAggregator() bool {
+	return c.Type == "aggregator"
+}
+
+// connectionClient is a client whose search path resolves tables from a single connection
+type connectionClient struct {
+	connection string
+	client     *db_client.DbClient
+}
+
+// FanOutConnections returns the connection names and globs set by --fan-out-connections or
+// POWERPIPE_FAN_OUT_CONNECTIONS. Values may be comma-separated.
+func FanOutConnections() []string {
+	var res []string
+	for _, arg := range viper.GetStringSlice(localconstants.ArgFanOutConnections) {
+		for _, c := range strings.Split(arg, ",") {
+			if c = strings.TrimSpace(c); c != "" && !helpers.StringSliceContains(res, c) {
+				res = append(res, c)
+			}
+		}
+	}
+	return res
+}
+
+// resolveConnectionClients creates a client for each fan out connection, if any
+func (e *ExecutionTree) resolveConnectionClients(ctx context.Context) error {
+	patterns := FanOutConnections()
+	if len(patterns) == 0 {
+		return nil
+	}
+	if e.client.Backend.Name() != constants.SteampipeBackendName {
+		return sperr.New("--%s is only supported for Steampipe databases", localconstants.ArgFanOutConnections)
+	}
+
+	connections, err := listSteampipeConnections(ctx, e.client)
+	if err != nil {
+		return err
+	}
+	names, err := fanOutConnectionNames(patterns, connections)
+	if err != nil {
+		return err
+	}
+
+	connectionString := e.client.GetConnectionString()
+	searchPathConfig := db_client.SearchPathConfigForDatabase(connectionString)
+	e.connectionClientMap = db_client.NewClientMap()
+	for _, name := range names {
+		client, err := e.connectionClientMap.GetOrCreate(ctx, connectionString, connectionSearchPathConfig(name, searchPathConfig))
+		if err != nil {
+			return sperr.WrapWithMessage(err, "failed to connect to Steampipe connection '%s'", name)
+		}
+		e.connectionClients = append(e.connectionClients, &connectionClient{connection: name, client: client})
+	}
+	slog.Debug("fanning out controls over connections", "connections", names)
+	return nil
+}
+
+// closeConnectionClients closes the fan out connection clients, if any
+func (e *ExecutionTree) closeConnectionClients(ctx context.Context) {
+	if e.connectionClientMap == nil {
+		return
+	}
+	if err := e.connectionClientMap.Close(ctx); err != nil {
+		slog.Warn("failed to close connection clients", "error", err)
+	}
+}
+
+// connectionSearchPathConfig returns the search path config for a connection - the connection schema is put first,
+// ahead of any configured search path or prefix
+func connectionSearchPathConfig(connection string, config backend.SearchPathConfig) backend.SearchPathConfig {
+	if len(config.SearchPath) > 0 {
+		return backend.SearchPathConfig{SearchPath: append([]string{connection}, config.SearchPath...)}
+	}
+	return backend.SearchPathConfig{SearchPathPrefix: append([]string{connection}, config.SearchPathPrefix...)}
+}
+
+// listSteampipeConnections returns the connections of the Steampipe database
+func listSteampipeConnections(ctx context.Context, client *db_client.DbClient) ([]steampipeConnection, error) {
+	result, err := client.ExecuteSync(ctx, steampipeConnectionsQuery)
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "failed to list Steampipe connections")
+	}
+	var res []steampipeConnection
+	for _, r := range result.Rows {
+		row, ok := r.(*localqueryresult.RowResult)
+		if !ok || len(row.Data) < 4 {
+			continue
+		}
+		c := steampipeConnection{
+			Name:  fmt.Sprintf("%v", row.Data[0]),
+			Type:  fmt.Sprintf("%v", row.Data[1]),
+			State: fmt.Sprintf("%v", row.Data[2]),
+		}
+		if err := json.Unmarshal([]byte(fmt.Sprintf("%v", row.Data[3])), &c.Connections); err != nil {
+			slog.Debug("could not parse the connections of an aggregator", "connection", c.Name, "error", err)
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+// fanOutConnectionNames returns the sorted names of the connections matching the patterns
+// aggregators are expanded to the connections they aggregate, and connections which are in error are skipped
+// it is an error for a pattern to match no connections
+func fanOutConnectionNames(patterns []string, connections []steampipeConnection) ([]string, error) {
+	matches := make(map[string]struct{})
+	add := func(c steampipeConnection) {
+		if c.State == "error" {
+			slog.Warn("skipping Steampipe connection which is in error", "connection", c.Name)
+			return
+		}
+		matches[c.Name] = struct{}{}
+	}
+
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, sperr.New("invalid --%s value '%s': %s", localconstants.ArgFanOutConnections, pattern, err.Error())
+		}
+		matched := false
+		for _, c := range connections {
+			if ok, _ := path.Match(pattern, c.Name); !ok {
+				continue
+			}
+			matched = true
+			if !c.isAggregator() {
+				add(c)
+				continue
+			}
+			for _, child := range connections {
+				if !child.isAggregator() && matchesAny(c.Connections, child.Name) {
+					add(child)
+				}
+			}
+		}
+		if !matched {
+			return nil, sperr.New("no Steampipe connections match --%s value '%s'", localconstants.ArgFanOutConnections, pattern)
+		}
+	}
+
+	res := make([]string, 0, len(matches))
+	for name := range matches {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// executePerConnection runs the control query once for each fan out connection, attributing each result row to
+// the connection which returned it
+func (r *ControlRun) executePerConnection(ctx, queryCtx context.Context, resolvedQuery *modconfig.ResolvedQuery) {
+	defer func() {
+		// convert the data to snapshot format
+		r.Data = r.Rows.ToLeafData(r.getDimensionSchema())
+	}()
+
+	for _, c := range r.Tree.connectionClients {
+		slog.Debug("execute start", "name", r.Control.Name(), "connection", c.connection)
+		queryResult, err := c.client.Execute(queryCtx, resolvedQuery.ExecuteSQL, resolvedQuery.Args...)
+		slog.Debug("execute finish", "name", r.Control.Name(), "connection", c.connection)
+		if err != nil {
+			r.setError(ctx, sperr.WrapWithMessage(err, "connection '%s'", c.connection))
+			return
+		}
+		r.queryResult = queryResult
+		if !r.readResults(ctx, queryResult, c.connection) {
+			return
+		}
+	}
+	r.onResultsComplete(ctx)
+}
+
+// setConnection attributes the row to its source connection
+// the connection replaces any 'connection' column returned by the query
+func (r *ResultRow) setConnection(connection string) {
+	dimensions := make([]Dimension, 0, len(r.Dimensions)+1)
+	for _, dim := range r.Dimensions {
+		if dim.Key != ConnectionDimension {
+			dimensions = append(dimensions, dim)
+		}
+	}
+	r.Dimensions = append(dimensions, Dimension{Key: ConnectionDimension, Value: connection, SqlType: "TEXT"})
+	if r.Run != nil {
+		r.Fingerprint = FindingFingerprint(r.Run.FullName, r.Resource, r.Reason, r.Dimensions)
+	}
+}
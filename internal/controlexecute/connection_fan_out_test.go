@@ -0,0 +1,91 @@
+package controlexecute
+
+import (
+	"reflect"
+	"testing"
+)
+
+type fanOutConnectionNamesTest struct {
+	patterns []string
+	expected []string
+	// if set, an error is expected
+	err bool
+}
+
+var testFanOutConnections = []steampipeConnection{
+	{Name: "aws_all", Type: "aggregator", State: "ready", Connections: []string{"aws_*"}},
+	{Name: "aws_dev", State: "ready"},
+	{Name: "aws_prod", State: "ready"},
+	{Name: "aws_broken", State: "error"},
+	{Name: "gcp_all", Type: "aggregator", State: "ready", Connections: []string{"gcp_prod", "gcp_dev"}},
+	{Name: "gcp_dev", State: "ready"},
+	{Name: "gcp_prod", State: "ready"},
+	{Name: "gcp_test", State: "ready"},
+}
+
+var testCasesFanOutConnectionNames = map[string]fanOutConnectionNamesTest{
+	"name": {
+		patterns: []string{"aws_prod"},
+		expected: []string{"aws_prod"},
+	},
+	"glob skips connections in error": {
+		patterns: []string{"aws_*"},
+		expected: []string{"aws_dev", "aws_prod"},
+	},
+	"aggregator with glob": {
+		patterns: []string{"aws_all"},
+		expected: []string{"aws_dev", "aws_prod"},
+	},
+	"aggregator with names": {
+		patterns: []string{"gcp_all"},
+		expected: []string{"gcp_dev", "gcp_prod"},
+	},
+	"overlapping patterns": {
+		patterns: []string{"gcp_all", "gcp_*"},
+		expected: []string{"gcp_dev", "gcp_prod", "gcp_test"},
+	},
+	"no match": {
+		patterns: []string{"azure_*"},
+		err:      true,
+	},
+	"invalid glob": {
+		patterns: []string{"aws_["},
+		err:      true,
+	},
+}
+
+func TestFanOutConnectionNames(t *testing.T) {
+	for name, test := range testCasesFanOutConnectionNames {
+		names, err := fanOutConnectionNames(test.patterns, testFanOutConnections)
+		if test.err {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error, got %v", name, names)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, names)
+		}
+	}
+}
+
+func TestResultRowSetConnection(t *testing.T) {
+	row := &ResultRow{
+		Resource:   "r1",
+		Dimensions: []Dimension{{Key: "connection", Value: "queried"}, {Key: "region", Value: "us-east-1"}},
+		Run:        &ControlRun{FullName: "c1"},
+	}
+	row.setConnection("aws_prod")
+
+	expected := []Dimension{{Key: "region", Value: "us-east-1"}, {Key: "connection", Value: "aws_prod", SqlType: "TEXT"}}
+	if !reflect.DeepEqual(row.Dimensions, expected) {
+		t.Errorf("Test: 'set connection' FAILED : expected %v, got %v", expected, row.Dimensions)
+	}
+	if fingerprint := FindingFingerprint("c1", "r1", "", expected); row.Fingerprint != fingerprint {
+		t.Errorf("Test: 'set connection' FAILED : expected fingerprint '%s', got '%s'", fingerprint, row.Fingerprint)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/spf13/viper"
 	typehelpers "github.com/turbot/go-kit/types"
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/error_helpers"
@@ -16,6 +17,7 @@ import (
 	"github.com/turbot/pipe-fittings/statushooks"
 	"github.com/turbot/pipe-fittings/steampipeconfig"
 	"github.com/turbot/pipe-fittings/utils"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
 	"github.com/turbot/powerpipe/internal/controlstatus"
 	"github.com/turbot/powerpipe/internal/dashboardtypes"
 	"github.com/turbot/powerpipe/internal/db_client"
@@ -38,6 +40,8 @@ type ControlRun struct {
 
 	// this will be serialised under 'properties'
 	Severity string `json:"-"`
+	// remediation guidance, from the control remediation tags
+	Remediation *Remediation `json:"remediation,omitempty"`
 
 	// "control"
 	NodeType string `json:"panel_type"`
@@ -52,6 +56,10 @@ type ControlRun struct {
 	RunStatus dashboardtypes.RunStatus     `json:"status"`
 	// result rows
 	Rows ResultRows `json:"-"`
+	// if the result rows were truncated by '--max-rows-per-control', the number of result rows returned by the control
+	// (the summary counts all of the rows)
+	TotalRows int  `json:"total_rows,omitempty"`
+	Truncated bool `json:"truncated,omitempty"`
 
 	// the results in snapshot format
 	Data *dashboardtypes.LeafData `json:"data"`
@@ -65,6 +73,10 @@ type ControlRun struct {
 	Parents []*ResultGroup `json:"-"`
 	// execution tree
 	Tree *ExecutionTree `json:"-"`
+	// the query plan and timing of the control query, if '--capture-query-plans' is set
+	QueryPlan *QueryPlan `json:"query_plan,omitempty"`
+	// if set, the results were reused from a run of the control by another tree which shares the result cache
+	ResultsReused bool `json:"-"`
 	// save run error as string for JSON export
 	RunErrorString string `json:"error,omitempty"`
 	runError       error
@@ -134,6 +146,7 @@ func NewControlRun(control *modconfig.Control, group *ResultGroup, executionTree
 		doneChan:   make(chan bool, 1),
 		Properties: make(map[string]any),
 	}
+	res.Remediation = GetRemediation(res.Tags)
 	if err := res.populateProperties(); err != nil {
 		return nil, err
 	}
@@ -265,6 +278,7 @@ func (r *ControlRun) execute(ctx context.Context, client *db_client.DbClient) {
 				parent.updateSeverityCounts(r.Severity, r.Summary)
 			}
 		}
+		r.publishControlCompleted()
 	}()
 
 	// update the current running control in the Progress renderer
@@ -278,6 +292,14 @@ func (r *ControlRun) execute(ctx context.Context, client *db_client.DbClient) {
 		}
 	}()
 
+	// if the control has been run by another tree which shares our result cache, reuse its results
+	if r.reuseCachedResults(ctx) {
+		return
+	}
+	if r.Tree.resultCache != nil {
+		defer r.Tree.resultCache.add(r)
+	}
+
 	// resolve the control query
 	resolvedQuery, err := r.resolveControlQuery(control)
 	if err != nil {
@@ -287,6 +309,12 @@ func (r *ControlRun) execute(ctx context.Context, client *db_client.DbClient) {
 
 	controlExecutionCtx := r.getControlQueryContext(ctx)
 
+	// if fanning out over connections, run the query once per connection
+	if len(r.Tree.connectionClients) > 0 {
+		r.executePerConnection(ctx, controlExecutionCtx, resolvedQuery)
+		return
+	}
+
 	// execute the control query
 	// NOTE no need to pass an OnComplete callback - we are already closing our session after waiting for results
 	slog.Debug("execute start", "name", r.Control.Name())
@@ -317,6 +345,10 @@ func (r *ControlRun) execute(ctx context.Context, client *db_client.DbClient) {
 	slog.Debug("wait result", "name", r.Control.Name())
 	r.waitForResults(ctx)
 	slog.Debug("finish result", "name", r.Control.Name())
+
+	if captureQueryPlans() && r.GetRunStatus() == dashboardtypes.RunComplete {
+		r.QueryPlan = captureQueryPlan(controlExecutionCtx, client, resolvedQuery.ExecuteSQL, resolvedQuery.Args, time.Since(startTime), len(r.Rows))
+	}
 }
 
 // create a context with status updates disabled (we do not want to show 'loading' results)
@@ -342,32 +374,50 @@ func (r *ControlRun) waitForResults(ctx context.Context) {
 		r.Data = r.Rows.ToLeafData(dimensionsSchema)
 	}()
 
+	if r.readResults(ctx, r.queryResult, "") {
+		r.onResultsComplete(ctx)
+	}
+}
+
+// readResults adds a result row for each row of the query result, returning whether all rows were read
+// if the connection is set, the rows are attributed to that connection
+func (r *ControlRun) readResults(ctx context.Context, queryResult *localqueryresult.Result, connection string) bool {
 	for {
 		select {
 		case <-ctx.Done():
 			r.setError(ctx, ctx.Err())
-			return
-		case row := <-*r.queryResult.RowChan:
-			// nil row means control run is complete
+			return false
+		case row := <-*queryResult.RowChan:
+			// nil row means we are done
 			if row == nil {
-				// nil row means we are done
-				r.setRunStatus(ctx, dashboardtypes.RunComplete)
-				r.createdOrderedResultRows()
-				return
+				return true
 			}
 			// create a result row
-			result, err := NewResultRow(r, row, r.queryResult.Cols)
+			result, err := NewResultRow(r, row, queryResult.Cols)
 			if err != nil {
 				r.setError(ctx, err)
-				return
+				return false
+			}
+			if connection != "" {
+				result.setConnection(connection)
+			}
+			if r.Tree != nil {
+				result.acknowledge(r.Tree.acknowledgements, time.Now())
 			}
 			r.addResultRow(result)
 		case <-r.doneChan:
-			return
+			return false
 		}
 	}
 }
 
+// onResultsComplete marks the run complete once all results have been read
+func (r *ControlRun) onResultsComplete(ctx context.Context) {
+	r.setRunStatus(ctx, dashboardtypes.RunComplete)
+	r.createdOrderedResultRows()
+	r.truncateRows(viper.GetInt(localconstants.ArgMaxRowsPerControl))
+}
+
 func (r *ControlRun) getDimensionSchema() map[string]*queryresult.ColumnDef {
 	var dimensionsSchema = make(map[string]*queryresult.ColumnDef)
 
@@ -395,6 +445,9 @@ func (r *ControlRun) getDimensionSchema() map[string]*queryresult.ColumnDef {
 func (r *ControlRun) addResultRow(row *ResultRow) {
 	// update results
 	r.rowMap[row.Status] = append(r.rowMap[row.Status], row)
+	if r.Tree != nil && r.Tree.budget != nil {
+		r.Tree.budget.addRows(1)
+	}
 
 	// update summary
 	switch row.Status {
@@ -411,10 +464,27 @@ func (r *ControlRun) addResultRow(row *ResultRow) {
 	}
 }
 
+// truncateRows retains at most maxRows result rows - as rows are ordered by status, failures are retained first
+// a maxRows of zero means there is no limit
+func (r *ControlRun) truncateRows(maxRows int) {
+	if maxRows <= 0 || len(r.Rows) <= maxRows {
+		return
+	}
+	r.TotalRows = len(r.Rows)
+	r.Truncated = true
+	r.Rows = r.Rows[:maxRows]
+}
+
+// the order of result rows, by status - failures first
+var resultStatusOrder = []string{constants.ControlError, constants.ControlAlarm, constants.ControlInfo, constants.ControlOk, constants.ControlSkip}
+
 // populate ordered list of rows
 func (r *ControlRun) createdOrderedResultRows() {
-	statusOrder := []string{constants.ControlError, constants.ControlAlarm, constants.ControlInfo, constants.ControlOk, constants.ControlSkip}
-	for _, status := range statusOrder {
+	for _, status := range resultStatusOrder {
+		// a seeded run orders rows of the same status, rather than using the order of the query results
+		if r.Tree != nil && r.Tree.seed != nil {
+			sortResultRows(r.rowMap[status])
+		}
 		r.Rows = append(r.Rows, r.rowMap[status]...)
 	}
 }
@@ -0,0 +1,60 @@
+package controlexecute
+
+import (
+	"testing"
+
+	"github.com/turbot/pipe-fittings/constants"
+)
+
+type truncateRowsTest struct {
+	statuses        []string
+	maxRows         int
+	expectStatuses  []string
+	expectTotal     int
+	expectTruncated bool
+}
+
+var testCasesTruncateRows = map[string]truncateRowsTest{
+	"no limit": {
+		statuses:       []string{constants.ControlOk, constants.ControlAlarm},
+		expectStatuses: []string{constants.ControlAlarm, constants.ControlOk},
+	},
+	"within limit": {
+		statuses:       []string{constants.ControlOk, constants.ControlAlarm},
+		maxRows:        2,
+		expectStatuses: []string{constants.ControlAlarm, constants.ControlOk},
+	},
+	"failures retained first": {
+		statuses:        []string{constants.ControlOk, constants.ControlSkip, constants.ControlAlarm, constants.ControlOk, constants.ControlError},
+		maxRows:         2,
+		expectStatuses:  []string{constants.ControlError, constants.ControlAlarm},
+		expectTotal:     5,
+		expectTruncated: true,
+	},
+}
+
+func TestTruncateRows(t *testing.T) {
+	for name, test := range testCasesTruncateRows {
+		r := &ControlRun{rowMap: make(map[string]ResultRows)}
+		for _, status := range test.statuses {
+			r.rowMap[status] = append(r.rowMap[status], &ResultRow{Status: status})
+		}
+		r.createdOrderedResultRows()
+		r.truncateRows(test.maxRows)
+
+		var statuses []string
+		for _, row := range r.Rows {
+			statuses = append(statuses, row.Status)
+		}
+		if len(statuses) != len(test.expectStatuses) || r.TotalRows != test.expectTotal || r.Truncated != test.expectTruncated {
+			t.Errorf("Test: '%s' FAILED : expected %v (total %d, truncated %t), got %v (total %d, truncated %t)", name, test.expectStatuses, test.expectTotal, test.expectTruncated, statuses, r.TotalRows, r.Truncated)
+			continue
+		}
+		for i := range statuses {
+			if statuses[i] != test.expectStatuses[i] {
+				t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expectStatuses, statuses)
+				break
+			}
+		}
+	}
+}
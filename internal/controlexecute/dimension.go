@@ -1,9 +1,73 @@
 package controlexecute
 
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/go-kit/helpers"
+	typehelpers "github.com/turbot/go-kit/types"
+	"github.com/turbot/pipe-fittings/queryresult"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
 // Dimension is a struct representing an attribute returned by a control run.
 // An attribute is stored as a dimension if it's not a standard attribute (reason, resource, status).
 type Dimension struct {
 	Key     string `json:"key"`
 	Value   string `json:"value"`
 	SqlType string `json:"-"`
+	// whether the dimension was configured for the workspace, rather than returned by the control
+	Configured bool `json:"-"`
+}
+
+// ConfiguredDimensions returns the additional dimensions configured for the workspace (using --dimension or
+// POWERPIPE_DIMENSIONS). Values may be comma-separated.
+// A dimension is either a column name, e.g. "account_id", or a path into a json column, e.g. "tags.owner"
+func ConfiguredDimensions() []string {
+	var res []string
+	for _, arg := range viper.GetStringSlice(localconstants.ArgDimension) {
+		for _, d := range strings.Split(arg, ",") {
+			if d = strings.TrimSpace(d); d != "" && !helpers.StringSliceContains(res, d) {
+				res = append(res, d)
+			}
+		}
+	}
+	return res
+}
+
+// resolveConfiguredDimension returns the value of a configured dimension for the given row
+// - if the dimension is a path into a json column, extract the nested value
+// returns false if the row does not contain the dimension
+func resolveConfiguredDimension(dimension string, data []any, cols []*queryresult.ColumnDef) (string, bool) {
+	columnName, path, _ := strings.Cut(dimension, ".")
+	for i, c := range cols {
+		if c.Name != columnName || i >= len(data) {
+			continue
+		}
+		val := data[i]
+		for _, key := range strings.Split(path, ".") {
+			if key == "" {
+				break
+			}
+			m, ok := val.(map[string]any)
+			if !ok {
+				return "", false
+			}
+			val = m[key]
+		}
+		if val == nil {
+			return "", false
+		}
+		switch val.(type) {
+		case map[string]any, []any:
+			jsonBytes, err := json.Marshal(val)
+			if err != nil {
+				return "", false
+			}
+			return string(jsonBytes), true
+		}
+		return typehelpers.ToString(val), true
+	}
+	return "", false
 }
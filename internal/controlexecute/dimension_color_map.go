@@ -2,6 +2,7 @@ package controlexecute
 
 import (
 	"fmt"
+	"slices"
 )
 
 type DimensionColorGenerator struct {
@@ -63,13 +64,19 @@ func (g *DimensionColorGenerator) reset() {
 }
 
 func (g *DimensionColorGenerator) populate(e *ExecutionTree) {
+	var dimensions []Dimension
 	for _, run := range e.ControlRuns {
 		for _, r := range run.Rows {
-			for _, d := range r.Dimensions {
-				if !g.hasDimensionValue(d) {
-					g.addDimensionValue(d)
-				}
-			}
+			dimensions = append(dimensions, r.Dimensions...)
+		}
+	}
+	// a seeded run allocates colors in a fixed order, rather than in map order
+	if e.seed != nil {
+		slices.SortStableFunc(dimensions, compareDimensions)
+	}
+	for _, d := range dimensions {
+		if !g.hasDimensionValue(d) {
+			g.addDimensionValue(d)
 		}
 	}
 }
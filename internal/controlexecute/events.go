@@ -0,0 +1,50 @@
+package controlexecute
+
+import (
+	"time"
+
+	"github.com/turbot/powerpipe/internal/eventbus"
+)
+
+// publishRunStarted publishes the run_started event of the tree
+func (e *ExecutionTree) publishRunStarted() {
+	if !eventbus.Enabled() {
+		return
+	}
+	eventbus.Publish(eventbus.NewEvent(eventbus.EventRunStarted, e.target, map[string]any{
+		"kind":     "benchmark",
+		"controls": len(e.ControlRuns),
+	}))
+}
+
+// publishRunFinished publishes the run_finished event of the tree, with the status summary of the run
+func (e *ExecutionTree) publishRunFinished() {
+	if !eventbus.Enabled() {
+		return
+	}
+	data := map[string]any{
+		"kind":     "benchmark",
+		"summary":  e.Root.Summary.Status,
+		"duration": e.EndTime.Sub(e.StartTime).Round(time.Millisecond).String(),
+	}
+	if e.AbortReason != "" {
+		data["abort_reason"] = e.AbortReason
+	}
+	eventbus.Publish(eventbus.NewEvent(eventbus.EventRunFinished, e.target, data))
+}
+
+// publishControlCompleted publishes the control_completed event of the control run
+func (r *ControlRun) publishControlCompleted() {
+	if !eventbus.Enabled() {
+		return
+	}
+	data := map[string]any{
+		"status":   r.GetRunStatus(),
+		"summary":  *r.GetStatusSummary(),
+		"duration": r.Duration.Round(time.Millisecond).String(),
+	}
+	if err := r.GetError(); err != nil {
+		data["error"] = err.Error()
+	}
+	eventbus.Publish(eventbus.NewEvent(eventbus.EventControlCompleted, r.Control.FullName, data))
+}
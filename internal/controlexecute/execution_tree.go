@@ -11,8 +11,11 @@ import (
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/workspace"
+	"github.com/turbot/powerpipe/internal/ack"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
 	"github.com/turbot/powerpipe/internal/controlstatus"
 	"github.com/turbot/powerpipe/internal/db_client"
+	"github.com/turbot/powerpipe/internal/snapshot"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -34,6 +37,26 @@ type ExecutionTree struct {
 	client              *db_client.DbClient
 	// an optional map of control names used to filter the controls which are run
 	controlNameFilterMap map[string]struct{}
+	// additional dimensions configured for the workspace, added to result rows when present
+	configuredDimensions []string
+	// if set, the tree is a copy of an executed tree, grouped and filtered for output
+	View *View `json:"-"`
+	// if the run exceeded its budget, the reason it was aborted - the results of the tree are incomplete
+	AbortReason string `json:"abort_reason,omitempty"`
+	// the environment of the run, recorded in snapshots
+	Environment *snapshot.Environment `json:"-"`
+	budget      *RunBudget
+	// if set, the run is deterministic - see applySeed
+	seed *int64
+	// the acknowledged findings of the workspace, reported as info until the acknowledgement expires
+	acknowledgements *ack.Acknowledgements
+	// if controls are fanned out over connections, a client for each connection
+	connectionClients   []*connectionClient
+	connectionClientMap *db_client.ClientMap
+	// if set, the results of controls are shared with the other trees of the run
+	resultCache *ResultCache
+	// the name of the target of the run, used as the subject of its events
+	target string
 }
 
 func NewExecutionTree(ctx context.Context, workspace *workspace.Workspace, client *db_client.DbClient, controlFilter workspace.ResourceFilter, targets ...modconfig.ModTreeItem) (*ExecutionTree, error) {
@@ -42,6 +65,16 @@ func NewExecutionTree(ctx context.Context, workspace *workspace.Workspace, clien
 		Workspace:   workspace,
 		client:      client,
 		ControlRuns: make(map[string]*ControlRun),
+		// additional dimensions to add to result rows
+		configuredDimensions: ConfiguredDimensions(),
+		// the duration and row limits of the run
+		budget:           NewRunBudget(),
+		acknowledgements: loadAcknowledgements(workspace),
+	}
+
+	if viper.IsSet(localconstants.ArgSeed) {
+		seed := viper.GetInt64(localconstants.ArgSeed)
+		executionTree.seed = &seed
 	}
 
 	// if backend supports search path, get it
@@ -64,6 +97,7 @@ func NewExecutionTree(ctx context.Context, workspace *workspace.Workspace, clien
 		resolvedItem = modconfig.NewRootBenchmarkWithChildren(workspace.Mod, targets).(modconfig.ModTreeItem)
 	}
 
+	executionTree.target = resolvedItem.Name()
 	// build tree of result groups, starting with a synthetic 'root' node
 	executionTree.Root, err = NewRootResultGroup(ctx, executionTree, resolvedItem)
 	if err != nil {
@@ -86,6 +120,10 @@ func (tree *ExecutionTree) PopulateControlRunInstances() {
 			controlRunInstances = append(controlRunInstances, &flatControlRun)
 		}
 	}
+	// a seeded run orders the instances by parent and control, rather than in map order
+	if tree.seed != nil {
+		sortControlRunInstances(controlRunInstances)
+	}
 
 	tree.ControlRunInstances = controlRunInstances
 }
@@ -93,6 +131,12 @@ func (tree *ExecutionTree) PopulateControlRunInstances() {
 // IsExportSourceData implements ExportSourceData
 func (*ExecutionTree) IsExportSourceData() {}
 
+// SetResultCache shares the results of the controls of the tree with the other trees using the cache - a control
+// which has already been run by one of those trees is not run again
+func (e *ExecutionTree) SetResultCache(cache *ResultCache) {
+	e.resultCache = cache
+}
+
 // AddControl checks whether control should be included in the tree
 // if so, creates a ControlRun, which is added to the parent group
 func (e *ExecutionTree) AddControl(ctx context.Context, control *modconfig.Control, group *ResultGroup) error {
@@ -129,11 +173,23 @@ func (e *ExecutionTree) Execute(ctx context.Context) error {
 	slog.Debug("begin ExecutionTree.Execute")
 	defer slog.Debug("end ExecutionTree.Execute")
 	e.StartTime = time.Now()
+	e.Environment = snapshot.CaptureEnvironment(ctx, e.Workspace.Path, e.client)
+	// if controls are fanned out over connections, connect to each connection before starting
+	if err := e.resolveConnectionClients(ctx); err != nil {
+		e.closeConnectionClients(ctx)
+		return err
+	}
+	defer e.closeConnectionClients(ctx)
 	e.Progress.Start(ctx)
+	e.publishRunStarted()
 
 	defer func() {
 		e.EndTime = time.Now()
+		if e.seed != nil {
+			e.applySeed()
+		}
 		e.Progress.Finish(ctx)
+		e.publishRunFinished()
 	}()
 
 	// the number of goroutines parallel to start
@@ -145,12 +201,17 @@ func (e *ExecutionTree) Execute(ctx context.Context) error {
 	// to limit the number of parallel controls go routines started
 	parallelismLock := semaphore.NewWeighted(maxParallelGoRoutines)
 
+	// cancel the run if it exceeds its budget
+	budgetCtx, cancel := e.budget.start(ctx)
+	defer cancel()
+
 	// just execute the root - it will traverse the tree
-	e.Root.execute(ctx, e.client, parallelismLock)
+	e.Root.execute(budgetCtx, e.client, parallelismLock)
 
-	if err := e.waitForActiveRunsToComplete(ctx, parallelismLock, maxParallelGoRoutines); err != nil {
+	if err := e.waitForActiveRunsToComplete(budgetCtx, parallelismLock, maxParallelGoRoutines); err != nil {
 		slog.Warn("timed out waiting for active runs to complete")
 	}
+	e.AbortReason = e.budget.AbortReason()
 
 	// now build map of dimension property name to property value to color map
 	e.DimensionColorGenerator, _ = NewDimensionColorGenerator(4, 27)
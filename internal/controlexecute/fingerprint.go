@@ -0,0 +1,42 @@
+package controlexecute
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+// ColumnFingerprint is the snapshot data column containing the result fingerprint
+const ColumnFingerprint = "fingerprint"
+
+// fingerprintEscaper escapes the separators of the fingerprint values, so that values containing them cannot collide
+// - values without separators are unchanged, so their fingerprints match those of tickets raised before values were escaped
+var fingerprintEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// FindingFingerprint returns a fingerprint identifying a control result across runs - a hash of the control name,
+// resource and the dimensions returned by the control (in key order), joined by newlines
+// the fingerprint does not depend on the result status or ordering, or on the dimensions configured for the
+// workspace (--dimension), so may be used to track the lifecycle of a finding, e.g. when it was first seen and when
+// it was resolved
+// results without a resource (e.g. errors) are identified by their reason instead
+func FindingFingerprint(control, resource, reason string, dimensions []Dimension) string {
+	dimensionValues := make([]string, 0, len(dimensions)+1)
+	for _, d := range dimensions {
+		if d.Configured {
+			continue
+		}
+		dimensionValues = append(dimensionValues, strings.ReplaceAll(d.Key, "=", `\=`)+"="+d.Value)
+	}
+	// (reason is never a dimension, so cannot collide with one)
+	if resource == "" && reason != "" {
+		dimensionValues = append(dimensionValues, "reason="+reason)
+	}
+	sort.Strings(dimensionValues)
+	values := append([]string{control, resource}, dimensionValues...)
+	for i, v := range values {
+		values[i] = fingerprintEscaper.Replace(v)
+	}
+	hash := sha256.Sum256([]byte(strings.Join(values, "\n")))
+	return hex.EncodeToString(hash[:])[:32]
+}
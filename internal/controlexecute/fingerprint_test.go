@@ -0,0 +1,61 @@
+package controlexecute
+
+import "testing"
+
+type fingerprintTest struct {
+	a, b  ResultRow
+	equal bool
+}
+
+var testCasesFindingFingerprint = map[string]fingerprintTest{
+	"dimension order": {
+		a:     ResultRow{Resource: "r1", Dimensions: []Dimension{{Key: "region", Value: "us-east-1"}, {Key: "account", Value: "1"}}},
+		b:     ResultRow{Resource: "r1", Dimensions: []Dimension{{Key: "account", Value: "1"}, {Key: "region", Value: "us-east-1"}}},
+		equal: true,
+	},
+	"status and reason": {
+		a:     ResultRow{Resource: "r1", Status: "alarm", Reason: "r1 is not encrypted"},
+		b:     ResultRow{Resource: "r1", Status: "ok", Reason: "r1 is encrypted"},
+		equal: true,
+	},
+	"resource": {
+		a: ResultRow{Resource: "r1"},
+		b: ResultRow{Resource: "r2"},
+	},
+	"dimension value": {
+		a: ResultRow{Resource: "r1", Dimensions: []Dimension{{Key: "account", Value: "1"}}},
+		b: ResultRow{Resource: "r1", Dimensions: []Dimension{{Key: "account", Value: "2"}}},
+	},
+	"ambiguous concatenation": {
+		a: ResultRow{Resource: "r1\nregion=a"},
+		b: ResultRow{Resource: "r1", Dimensions: []Dimension{{Key: "region", Value: "a"}}},
+	},
+	"configured dimension": {
+		a:     ResultRow{Resource: "r1", Dimensions: []Dimension{{Key: "account", Value: "1"}}},
+		b:     ResultRow{Resource: "r1", Dimensions: []Dimension{{Key: "account", Value: "1"}, {Key: "tags.owner", Value: "ops", Configured: true}}},
+		equal: true,
+	},
+	"reason without resource": {
+		a: ResultRow{Status: "error", Reason: "relation \"aws_s3_bucket\" does not exist"},
+		b: ResultRow{Status: "error", Reason: "permission denied for table aws_iam_role"},
+	},
+}
+
+func TestFindingFingerprint(t *testing.T) {
+	for name, test := range testCasesFindingFingerprint {
+		a := FindingFingerprint("c1", test.a.Resource, test.a.Reason, test.a.Dimensions)
+		b := FindingFingerprint("c1", test.b.Resource, test.b.Reason, test.b.Dimensions)
+		if (a == b) != test.equal {
+			t.Errorf("Test: '%s' FAILED : expected equal %v, got '%s' and '%s'", name, test.equal, a, b)
+		}
+	}
+}
+
+// the fingerprints of tickets raised for findings are the hash of the newline joined values, so must not change
+func TestFindingFingerprintStable(t *testing.T) {
+	expected := "45bd34ecf375daadaf1298fa1fded6f0"
+	actual := FindingFingerprint("aws_compliance.control.s3_bucket_versioning_enabled", "arn:aws:s3:::a", "a is not versioned", []Dimension{{Key: "region", Value: "us-east-1"}, {Key: "account_id", Value: "123"}})
+	if actual != expected {
+		t.Errorf("Test: 'stable' FAILED : expected '%s', got '%s'", expected, actual)
+	}
+}
@@ -0,0 +1,107 @@
+package controlexecute
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/turbot/pipe-fittings/filepaths"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+// the weight given to the most recent run when updating the average duration of a control
+const historyRecentRunWeight = 0.3
+
+// ControlDuration is the average execution duration of a control over its previous runs, and the status summary of
+// its last run
+type ControlDuration struct {
+	Average     time.Duration                `json:"average"`
+	Runs        int                          `json:"runs"`
+	LastRun     time.Time                    `json:"last_run"`
+	LastSummary *controlstatus.StatusSummary `json:"last_summary,omitempty"`
+}
+
+// ControlHistory is the execution duration history of all controls which have been run, used to estimate run times
+// (and exposed as the powerpipe_control_run table of the metadata database)
+type ControlHistory struct {
+	// map of control full name to duration
+	Controls map[string]*ControlDuration `json:"controls"`
+	path     string
+}
+
+// ControlHistoryPath returns the path of the control history file in the internal directory
+func ControlHistoryPath() string {
+	return filepath.Join(filepaths.EnsureInternalDir(), "control_history.json")
+}
+
+// NewControlHistory returns an empty control history, saved to the given path
+func NewControlHistory(path string) *ControlHistory {
+	return &ControlHistory{
+		Controls: make(map[string]*ControlDuration),
+		path:     path,
+	}
+}
+
+// LoadControlHistory loads the control history from the given path - if the file does not exist, the history is empty
+func LoadControlHistory(path string) (*ControlHistory, error) {
+	h := NewControlHistory(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, err
+	}
+	if h.Controls == nil {
+		h.Controls = make(map[string]*ControlDuration)
+	}
+	return h, nil
+}
+
+// Record updates the average duration of each control of the executed tree which completed successfully
+// (the duration of failed and cancelled controls does not reflect a normal run)
+func (h *ControlHistory) Record(tree *ExecutionTree) {
+	for name, run := range tree.ControlRuns {
+		// a run which reused the results of another tree did not execute the control (and a seeded run has no durations)
+		if run.GetRunStatus() != dashboardtypes.RunComplete || run.Duration == 0 || run.ResultsReused {
+			continue
+		}
+		d, ok := h.Controls[name]
+		if !ok {
+			d = &ControlDuration{}
+			h.Controls[name] = d
+		}
+		if d.Runs == 0 {
+			d.Average = run.Duration
+		} else {
+			d.Average = time.Duration(math.Round(float64(d.Average)*(1-historyRecentRunWeight) + float64(run.Duration)*historyRecentRunWeight))
+		}
+		d.Runs++
+		d.LastRun = tree.StartTime
+		d.LastSummary = run.Summary
+	}
+}
+
+// Estimate returns the estimated duration of the given control, and whether the control has been run before
+func (h *ControlHistory) Estimate(controlName string) (time.Duration, bool) {
+	d, ok := h.Controls[controlName]
+	if !ok || d.Runs == 0 {
+		return 0, false
+	}
+	return d.Average, true
+}
+
+// Save writes the history to the file it was loaded from
+func (h *ControlHistory) Save() error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(h.path, data, 0600)
+}
@@ -0,0 +1,120 @@
+package controlexecute
+
+import (
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/constants"
+)
+
+// ExecutionPlan is the controls which an execution tree will run, with their durations estimated from previous runs
+type ExecutionPlan struct {
+	Groups      []*PlanGroup
+	Database    string
+	SearchPath  []string
+	Parallelism int64
+	// the number of distinct controls to run
+	ControlCount int
+	// the estimated duration of the run, assuming controls are run in tree order with the given parallelism
+	EstimatedDuration time.Duration
+	// the number of controls which have not been run before - these are not included in the estimated duration
+	Unestimated int
+}
+
+// PlanGroup is a benchmark of the plan
+type PlanGroup struct {
+	Name     string
+	Title    string
+	Groups   []*PlanGroup
+	Controls []*PlannedControl
+}
+
+// PlannedControl is a control of the plan
+type PlannedControl struct {
+	Name        string
+	Title       string
+	Estimate    time.Duration
+	HasEstimate bool
+}
+
+// Plan returns the execution plan for the tree - the tree is not executed
+func (e *ExecutionTree) Plan(history *ControlHistory) *ExecutionPlan {
+	plan := &ExecutionPlan{
+		SearchPath:  e.SearchPath,
+		Parallelism: constants.DefaultMaxConnections,
+	}
+	if viper.IsSet(constants.ArgMaxParallel) {
+		plan.Parallelism = viper.GetInt64(constants.ArgMaxParallel)
+	}
+	if e.client != nil {
+		plan.Database = redactConnectionString(e.client.GetConnectionString())
+	}
+
+	// build the plan groups, and the estimates of the controls in execution order
+	// (a control with multiple parents is only run once)
+	var estimates []time.Duration
+	planned := make(map[string]*PlannedControl)
+	var planGroup func(group *ResultGroup) *PlanGroup
+	planGroup = func(group *ResultGroup) *PlanGroup {
+		res := &PlanGroup{Name: group.GroupId, Title: group.Title}
+		for _, run := range group.ControlRuns {
+			control, ok := planned[run.Control.Name()]
+			if !ok {
+				control = &PlannedControl{Name: run.Control.Name(), Title: run.Title}
+				control.Estimate, control.HasEstimate = history.Estimate(run.Control.Name())
+				planned[control.Name] = control
+				estimates = append(estimates, control.Estimate)
+				if !control.HasEstimate {
+					plan.Unestimated++
+				}
+			}
+			res.Controls = append(res.Controls, control)
+		}
+		for _, child := range group.Groups {
+			res.Groups = append(res.Groups, planGroup(child))
+		}
+		return res
+	}
+	// the root may contain controls directly (i.e. when running a single control) - these are run first
+	if len(e.Root.ControlRuns) > 0 {
+		plan.Groups = append(plan.Groups, planGroup(&ResultGroup{ControlRuns: e.Root.ControlRuns}))
+	}
+	for _, group := range e.Root.Groups {
+		plan.Groups = append(plan.Groups, planGroup(group))
+	}
+
+	plan.ControlCount = len(planned)
+	plan.EstimatedDuration = estimateDuration(estimates, plan.Parallelism)
+	return plan
+}
+
+// estimateDuration returns the time taken to run tasks with the given durations, in order, with the given parallelism
+// - each task starts as soon as a slot is free
+func estimateDuration(durations []time.Duration, parallelism int64) time.Duration {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	// the time at which each slot is next free
+	slots := make([]time.Duration, parallelism)
+	for _, d := range durations {
+		// start the task in the first free slot
+		sort.Slice(slots, func(i, j int) bool { return slots[i] < slots[j] })
+		slots[0] += d
+	}
+	var res time.Duration
+	for _, s := range slots {
+		res = max(res, s)
+	}
+	return res
+}
+
+// redactConnectionString removes any password from a connection string
+func redactConnectionString(connectionString string) string {
+	u, err := url.Parse(connectionString)
+	if err != nil || u.User == nil {
+		return connectionString
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,76 @@
+package controlexecute
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+type estimateDurationTest struct {
+	durations   []time.Duration
+	parallelism int64
+	expected    time.Duration
+}
+
+var testCasesEstimateDuration = map[string]estimateDurationTest{
+	"no controls": {
+		parallelism: 5,
+		expected:    0,
+	},
+	"sequential": {
+		durations:   []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+		parallelism: 1,
+		expected:    6 * time.Second,
+	},
+	"parallel": {
+		durations:   []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+		parallelism: 5,
+		expected:    3 * time.Second,
+	},
+	"next free slot": {
+		// the third control starts when the first completes
+		durations:   []time.Duration{time.Second, 4 * time.Second, 2 * time.Second},
+		parallelism: 2,
+		expected:    4 * time.Second,
+	},
+}
+
+func TestEstimateDuration(t *testing.T) {
+	for name, test := range testCasesEstimateDuration {
+		if actual := estimateDuration(test.durations, test.parallelism); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, actual)
+		}
+	}
+}
+
+func TestControlHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "control_history.json")
+	history, err := LoadControlHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree := &ExecutionTree{ControlRuns: map[string]*ControlRun{
+		"c1": {RunStatus: dashboardtypes.RunComplete, Duration: 10 * time.Second},
+		"c2": {RunStatus: dashboardtypes.RunError, Duration: 1 * time.Second},
+	}}
+	history.Record(tree)
+	tree.ControlRuns["c1"].Duration = 20 * time.Second
+	history.Record(tree)
+	if err := history.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err = LoadControlHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate, ok := history.Estimate("c1"); !ok || estimate != 13*time.Second {
+		t.Errorf("Test: 'average' FAILED : expected 13s, got %s", estimate)
+	}
+	if _, ok := history.Estimate("c2"); ok {
+		t.Errorf("Test: 'failed control' FAILED : expected no estimate")
+	}
+}
@@ -0,0 +1,130 @@
+package controlexecute
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/constants"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/db_client"
+	localqueryresult "github.com/turbot/powerpipe/internal/queryresult"
+)
+
+// QueryPlan is the query plan and timing of a control query - when '--capture-query-plans' is set, this is
+// captured for each control after its query completes, and saved with the control in snapshots and json exports
+// so performance regressions can be diagnosed from the artifacts of a run
+type QueryPlan struct {
+	Backend string `json:"backend"`
+	// the statement used to retrieve the plan
+	Statement string `json:"statement"`
+	// the plan - the parsed plan document for backends which return the plan as json,
+	// otherwise the plan rows, as a map of column name to value
+	Plan any `json:"plan,omitempty"`
+	// the duration of the control query (the plan is retrieved with EXPLAIN, without executing the query again)
+	DurationMs int64 `json:"duration_ms"`
+	Rows       int   `json:"rows"`
+	// the error retrieving the plan, if any
+	Error string `json:"error,omitempty"`
+}
+
+// captureQueryPlans returns whether the '--capture-query-plans' arg is set
+func captureQueryPlans() bool {
+	return viper.GetBool(localconstants.ArgCaptureQueryPlans)
+}
+
+// explainStatement returns the statement which retrieves the plan of the query for the given backend,
+// or false if plans cannot be retrieved for the backend
+func explainStatement(backendName, query string) (string, bool) {
+	switch backendName {
+	case constants.PostgresBackendName, constants.SteampipeBackendName:
+		return "EXPLAIN (FORMAT JSON) " + query, true
+	case constants.MySQLBackendName:
+		return "EXPLAIN FORMAT=JSON " + query, true
+	case constants.SQLiteBackendName:
+		return "EXPLAIN QUERY PLAN " + query, true
+	case constants.DuckDBBackendName:
+		return "EXPLAIN " + query, true
+	default:
+		return "", false
+	}
+}
+
+// captureQueryPlan retrieves the plan of the control query - failure to retrieve the plan is recorded in the plan,
+// and does not fail the control
+func captureQueryPlan(ctx context.Context, client *db_client.DbClient, query string, args []any, duration time.Duration, rows int) *QueryPlan {
+	plan := &QueryPlan{
+		Backend:    client.Backend.Name(),
+		DurationMs: duration.Milliseconds(),
+		Rows:       rows,
+	}
+	statement, ok := explainStatement(plan.Backend, query)
+	if !ok {
+		plan.Error = fmt.Sprintf("query plans are not supported for the %s backend", plan.Backend)
+		return plan
+	}
+	plan.Statement = statement
+
+	result, err := client.ExecuteSync(ctx, statement, args...)
+	if err != nil {
+		plan.Error = err.Error()
+		return plan
+	}
+	plan.Plan = planFromResult(result)
+	return plan
+}
+
+// planFromResult converts the result of an EXPLAIN statement into a plan
+// - a single json value is parsed, otherwise each row is converted to a map of column name to value
+func planFromResult(result *localqueryresult.SyncQueryResult) any {
+	var rows [][]any
+	for _, r := range result.Rows {
+		if row, ok := r.(*localqueryresult.RowResult); ok {
+			rows = append(rows, row.Data)
+		}
+	}
+	if len(result.Cols) == 1 && len(rows) == 1 && len(rows[0]) == 1 {
+		if plan, ok := parseJsonPlan(rows[0][0]); ok {
+			return plan
+		}
+	}
+
+	res := make([]map[string]any, 0, len(rows))
+	for _, row := range rows {
+		m := make(map[string]any, len(result.Cols))
+		for i, col := range result.Cols {
+			if i < len(row) {
+				m[col.Name] = row[i]
+			}
+		}
+		res = append(res, m)
+	}
+	return res
+}
+
+// parseJsonPlan returns the plan if the value is a json document (or has already been decoded by the driver)
+func parseJsonPlan(value any) (any, bool) {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	case map[string]any, []any:
+		return v, true
+	default:
+		return nil, false
+	}
+	var plan any
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, false
+	}
+	switch plan.(type) {
+	case map[string]any, []any:
+		return plan, true
+	default:
+		return nil, false
+	}
+}
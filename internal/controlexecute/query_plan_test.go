@@ -0,0 +1,86 @@
+package controlexecute
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/queryresult"
+	localqueryresult "github.com/turbot/powerpipe/internal/queryresult"
+)
+
+type planFromResultTest struct {
+	cols     []string
+	rows     [][]any
+	expected any
+}
+
+var testCasesPlanFromResult = map[string]planFromResultTest{
+	"json document": {
+		cols:     []string{"QUERY PLAN"},
+		rows:     [][]any{{`[{"Plan": {"Node Type": "Seq Scan"}}]`}},
+		expected: []any{map[string]any{"Plan": map[string]any{"Node Type": "Seq Scan"}}},
+	},
+	"decoded json document": {
+		cols:     []string{"QUERY PLAN"},
+		rows:     [][]any{{[]any{map[string]any{"Plan": "x"}}}},
+		expected: []any{map[string]any{"Plan": "x"}},
+	},
+	"single text value": {
+		cols:     []string{"explain_value"},
+		rows:     [][]any{{"SEQ_SCAN foo"}},
+		expected: []map[string]any{{"explain_value": "SEQ_SCAN foo"}},
+	},
+	"plan rows": {
+		cols: []string{"id", "parent", "detail"},
+		rows: [][]any{{int64(2), int64(0), "SCAN foo"}, {int64(3), int64(0), "USE TEMP B-TREE FOR ORDER BY"}},
+		expected: []map[string]any{
+			{"id": int64(2), "parent": int64(0), "detail": "SCAN foo"},
+			{"id": int64(3), "parent": int64(0), "detail": "USE TEMP B-TREE FOR ORDER BY"},
+		},
+	},
+	"no rows": {
+		cols:     []string{"QUERY PLAN"},
+		expected: []map[string]any{},
+	},
+}
+
+func TestPlanFromResult(t *testing.T) {
+	for name, test := range testCasesPlanFromResult {
+		result := &localqueryresult.SyncQueryResult{}
+		for _, c := range test.cols {
+			result.Cols = append(result.Cols, &queryresult.ColumnDef{Name: c})
+		}
+		for _, r := range test.rows {
+			result.Rows = append(result.Rows, &localqueryresult.RowResult{Data: r})
+		}
+		plan := planFromResult(result)
+		if !reflect.DeepEqual(plan, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, plan)
+		}
+	}
+}
+
+type explainStatementTest struct {
+	backend  string
+	expected string
+	ok       bool
+}
+
+var testCasesExplainStatement = map[string]explainStatementTest{
+	"postgres":  {backend: constants.PostgresBackendName, expected: "EXPLAIN (FORMAT JSON) select 1", ok: true},
+	"steampipe": {backend: constants.SteampipeBackendName, expected: "EXPLAIN (FORMAT JSON) select 1", ok: true},
+	"mysql":     {backend: constants.MySQLBackendName, expected: "EXPLAIN FORMAT=JSON select 1", ok: true},
+	"sqlite":    {backend: constants.SQLiteBackendName, expected: "EXPLAIN QUERY PLAN select 1", ok: true},
+	"duckdb":    {backend: constants.DuckDBBackendName, expected: "EXPLAIN select 1", ok: true},
+	"unknown":   {backend: "other", ok: false},
+}
+
+func TestExplainStatement(t *testing.T) {
+	for name, test := range testCasesExplainStatement {
+		statement, ok := explainStatement(test.backend, "select 1")
+		if statement != test.expected || ok != test.ok {
+			t.Errorf("Test: '%s' FAILED : expected %s (%t), got %s (%t)", name, test.expected, test.ok, statement, ok)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package controlexecute
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/constants"
+)
+
+// controls may describe how to remediate the resources they alarm for, e.g.
+//
+//	control "bucket_versioning_enabled" {
+//	  sql = "..."
+//	  tags = {
+//	    owner                 = "platform"
+//	    remediation           = "Enable versioning on the bucket."
+//	    remediation_url       = "https://docs.aws.amazon.com/AmazonS3/latest/userguide/manage-versioning-examples.html"
+//	    remediation_cli       = "aws s3api put-bucket-versioning --bucket <bucket> --versioning-configuration Status=Enabled"
+//	    remediation_terraform = <<-EOT
+//	      resource "aws_s3_bucket_versioning" "this" { ... }
+//	    EOT
+//	  }
+//	}
+//
+// the remediation is included in the control data (for the dashboard UI) and in html, md and json exports,
+// and the remediation export lists the required actions, grouped by the owner tag
+const (
+	TagRemediation          = "remediation"
+	TagRemediationUrl       = "remediation_url"
+	TagRemediationCli       = "remediation_cli"
+	TagRemediationTerraform = "remediation_terraform"
+	TagOwner                = "owner"
+)
+
+// Remediation describes how to remediate the failing results of a control
+type Remediation struct {
+	Description string `json:"description,omitempty"`
+	DocUrl      string `json:"doc_url,omitempty"`
+	Cli         string `json:"cli,omitempty"`
+	Terraform   string `json:"terraform,omitempty"`
+}
+
+// GetRemediation returns the remediation defined by the control tags, or nil if there is none
+func GetRemediation(tags map[string]string) *Remediation {
+	res := &Remediation{
+		Description: strings.TrimSpace(tags[TagRemediation]),
+		DocUrl:      strings.TrimSpace(tags[TagRemediationUrl]),
+		Cli:         strings.TrimSpace(tags[TagRemediationCli]),
+		Terraform:   strings.TrimSpace(tags[TagRemediationTerraform]),
+	}
+	if *res == (Remediation{}) {
+		return nil
+	}
+	return res
+}
+
+// RemediationOwner is the list of remediation actions for a single owner
+type RemediationOwner struct {
+	// the owner tag value - empty if the controls have no owner
+	Owner   string
+	Actions []*RemediationAction
+}
+
+// RemediationAction is a control with failing results, along with its remediation
+type RemediationAction struct {
+	Run         *ControlRun
+	Remediation *Remediation
+	// the alarm and error results of the control
+	Rows []*ResultRow
+}
+
+// RemediationActions returns the controls with alarm or error results, grouped by the owner tag
+// owners are sorted by name, with controls which have no owner last
+// within each owner, actions are sorted by severity (most severe first) and then by control name
+func (e *ExecutionTree) RemediationActions() []*RemediationOwner {
+	ownerMap := make(map[string]*RemediationOwner)
+	for _, run := range e.ControlRuns {
+		var rows []*ResultRow
+		for _, row := range run.Rows {
+			if row.Status == constants.ControlAlarm || row.Status == constants.ControlError {
+				rows = append(rows, row)
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		owner := strings.TrimSpace(run.Tags[TagOwner])
+		if ownerMap[owner] == nil {
+			ownerMap[owner] = &RemediationOwner{Owner: owner}
+		}
+		ownerMap[owner].Actions = append(ownerMap[owner].Actions, &RemediationAction{
+			Run:         run,
+			Remediation: run.Remediation,
+			Rows:        rows,
+		})
+	}
+
+	res := make([]*RemediationOwner, 0, len(ownerMap))
+	for _, owner := range ownerMap {
+		slices.SortFunc(owner.Actions, func(a, b *RemediationAction) int {
+			if rankA, rankB := severityRank(a.Run.Severity), severityRank(b.Run.Severity); rankA != rankB {
+				return rankA - rankB
+			}
+			return strings.Compare(a.Run.FullName, b.Run.FullName)
+		})
+		res = append(res, owner)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Owner == "" || res[j].Owner == "" {
+			return res[j].Owner == ""
+		}
+		return res[i].Owner < res[j].Owner
+	})
+	return res
+}
@@ -0,0 +1,83 @@
+package controlexecute
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type remediationActionsTest struct {
+	runs     []*ControlRun
+	expected string
+}
+
+func newTestRemediationRun(name, severity, owner string, statuses ...string) *ControlRun {
+	run := &ControlRun{FullName: name, Severity: severity, Tags: map[string]string{}}
+	if owner != "" {
+		run.Tags[TagOwner] = owner
+	}
+	for i, status := range statuses {
+		run.Rows = append(run.Rows, &ResultRow{Resource: fmt.Sprintf("r%d", i), Status: status})
+	}
+	return run
+}
+
+var testCasesRemediationActions = map[string]remediationActionsTest{
+	"no failures": {
+		runs:     []*ControlRun{newTestRemediationRun("c1", "high", "platform", "ok", "skip")},
+		expected: "",
+	},
+	"group by owner": {
+		runs: []*ControlRun{
+			newTestRemediationRun("c1", "low", "security", "alarm"),
+			newTestRemediationRun("c2", "high", "", "error"),
+			newTestRemediationRun("c3", "high", "platform", "ok", "alarm"),
+			newTestRemediationRun("c4", "low", "platform", "alarm", "info", "alarm"),
+		},
+		expected: "platform[c3:r1 c4:r0,r2] security[c1:r0] [c2:r0]",
+	},
+	"sort by severity then name": {
+		runs: []*ControlRun{
+			newTestRemediationRun("c1", "", "", "alarm"),
+			newTestRemediationRun("c2", "low", "", "alarm"),
+			newTestRemediationRun("c3", "critical", "", "alarm"),
+			newTestRemediationRun("c0", "low", "", "alarm"),
+		},
+		expected: "[c3:r0 c0:r0 c2:r0 c1:r0]",
+	},
+}
+
+func TestRemediationActions(t *testing.T) {
+	for name, test := range testCasesRemediationActions {
+		tree := &ExecutionTree{ControlRuns: make(map[string]*ControlRun)}
+		for _, run := range test.runs {
+			tree.ControlRuns[run.FullName] = run
+		}
+
+		var owners []string
+		for _, owner := range tree.RemediationActions() {
+			var actions []string
+			for _, action := range owner.Actions {
+				var resources []string
+				for _, row := range action.Rows {
+					resources = append(resources, row.Resource)
+				}
+				actions = append(actions, action.Run.FullName+":"+strings.Join(resources, ","))
+			}
+			owners = append(owners, fmt.Sprintf("%s[%s]", owner.Owner, strings.Join(actions, " ")))
+		}
+		if actual := strings.Join(owners, " "); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, actual)
+		}
+	}
+}
+
+func TestGetRemediation(t *testing.T) {
+	if res := GetRemediation(map[string]string{TagOwner: "platform"}); res != nil {
+		t.Errorf("Test: 'no remediation' FAILED : expected nil, got %v", res)
+	}
+	res := GetRemediation(map[string]string{TagRemediation: " Enable versioning. ", TagRemediationCli: "aws s3api put-bucket-versioning"})
+	if res == nil || res.Description != "Enable versioning." || res.Cli != "aws s3api put-bucket-versioning" || res.DocUrl != "" {
+		t.Errorf("Test: 'remediation' FAILED : expected description and cli, got %v", res)
+	}
+}
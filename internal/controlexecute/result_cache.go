@@ -0,0 +1,77 @@
+package controlexecute
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+// when several benchmarks are run in one invocation, each is executed as a separate tree - a control shared by the
+// benchmarks (e.g. CIS and Foundational Security) would be executed once for each tree
+// trees which share a result cache execute each control once - the results of a control are reused by the runs of
+// the control in later trees
+// (within a tree, a control with several parents is already only executed once)
+
+// ResultCache is the results of the completed control runs of the trees which share it, keyed by control full name
+type ResultCache struct {
+	results map[string]*cachedControlResult
+	mut     sync.Mutex
+}
+
+// cachedControlResult is the result rows of a completed control run, before any truncation
+type cachedControlResult struct {
+	rows      ResultRows
+	queryPlan *QueryPlan
+}
+
+func NewResultCache() *ResultCache {
+	return &ResultCache{results: make(map[string]*cachedControlResult)}
+}
+
+// add caches the results of the run, if it completed successfully
+func (c *ResultCache) add(run *ControlRun) {
+	if run.GetRunStatus() != dashboardtypes.RunComplete || run.runError != nil {
+		return
+	}
+	var rows ResultRows
+	for _, status := range resultStatusOrder {
+		rows = append(rows, run.rowMap[status]...)
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.results[run.Control.Name()] = &cachedControlResult{rows: rows, queryPlan: run.QueryPlan}
+}
+
+func (c *ResultCache) get(controlName string) (*cachedControlResult, bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	res, ok := c.results[controlName]
+	return res, ok
+}
+
+// reuseCachedResults completes the run with the cached results of the control, if it has already been run by
+// another tree, returning whether the results were reused
+func (r *ControlRun) reuseCachedResults(ctx context.Context) bool {
+	if r.Tree == nil || r.Tree.resultCache == nil {
+		return false
+	}
+	cached, ok := r.Tree.resultCache.get(r.Control.Name())
+	if !ok {
+		return false
+	}
+	slog.Debug("reusing the results of a previous run of the control", "name", r.Control.Name())
+
+	for _, row := range cached.rows {
+		reused := *row
+		reused.Run = r
+		r.addResultRow(&reused)
+	}
+	r.QueryPlan = cached.queryPlan
+	r.ResultsReused = true
+	r.onResultsComplete(ctx)
+	r.Data = r.Rows.ToLeafData(r.getDimensionSchema())
+	return true
+}
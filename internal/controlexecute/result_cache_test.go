@@ -0,0 +1,112 @@
+package controlexecute
+
+import (
+	"context"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+type resultCacheTest struct {
+	// the statuses of the rows of the first run of the control
+	statuses []string
+	// the status of the first run
+	runStatus dashboardtypes.RunStatus
+	// if set, the trees do not share a cache
+	noCache        bool
+	expectReused   bool
+	expectStatuses []string
+}
+
+var testCasesResultCache = map[string]resultCacheTest{
+	"reused": {
+		statuses:       []string{constants.ControlOk, constants.ControlAlarm, constants.ControlOk},
+		runStatus:      dashboardtypes.RunComplete,
+		expectReused:   true,
+		expectStatuses: []string{constants.ControlAlarm, constants.ControlOk, constants.ControlOk},
+	},
+	"no rows reused": {
+		runStatus:    dashboardtypes.RunComplete,
+		expectReused: true,
+	},
+	"error not cached": {
+		statuses:  []string{constants.ControlOk},
+		runStatus: dashboardtypes.RunError,
+	},
+	"no cache": {
+		statuses:  []string{constants.ControlOk},
+		runStatus: dashboardtypes.RunComplete,
+		noCache:   true,
+	},
+}
+
+func TestResultCache(t *testing.T) {
+	for name, test := range testCasesResultCache {
+		control := &modconfig.Control{}
+		control.FullName = "mod.control.shared"
+
+		cache := NewResultCache()
+		if test.noCache {
+			cache = nil
+		}
+		first := newTestCacheControlRun(control, cache)
+		for _, status := range test.statuses {
+			first.rowMap[status] = append(first.rowMap[status], &ResultRow{Status: status, Run: first, Control: control})
+		}
+		first.RunStatus = test.runStatus
+		if cache != nil {
+			cache.add(first)
+		}
+
+		second := newTestCacheControlRun(control, cache)
+		reused := second.reuseCachedResults(context.Background())
+		if reused != test.expectReused || second.ResultsReused != test.expectReused {
+			t.Errorf("Test: '%s' FAILED : expected reused %t, got %t", name, test.expectReused, reused)
+			continue
+		}
+		if !reused {
+			continue
+		}
+
+		if second.GetRunStatus() != dashboardtypes.RunComplete {
+			t.Errorf("Test: '%s' FAILED : expected status %s, got %s", name, dashboardtypes.RunComplete, second.GetRunStatus())
+		}
+		var statuses []string
+		for _, row := range second.Rows {
+			statuses = append(statuses, row.Status)
+			if row.Run != second {
+				t.Errorf("Test: '%s' FAILED : expected reused rows to belong to the reusing run", name)
+			}
+		}
+		if len(statuses) != len(test.expectStatuses) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expectStatuses, statuses)
+			continue
+		}
+		for i := range statuses {
+			if statuses[i] != test.expectStatuses[i] {
+				t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expectStatuses, statuses)
+				break
+			}
+		}
+		if total := second.Summary.Ok + second.Summary.Alarm + second.Summary.Info + second.Summary.Skip + second.Summary.Error; total != len(test.expectStatuses) {
+			t.Errorf("Test: '%s' FAILED : expected summary total %d, got %d", name, len(test.expectStatuses), total)
+		}
+	}
+}
+
+// newTestCacheControlRun returns a run of the control in a new tree using the cache
+func newTestCacheControlRun(control *modconfig.Control, cache *ResultCache) *ControlRun {
+	tree := &ExecutionTree{ControlRuns: make(map[string]*ControlRun)}
+	tree.SetResultCache(cache)
+	return &ControlRun{
+		Control:   control,
+		Tree:      tree,
+		Summary:   &controlstatus.StatusSummary{},
+		RunStatus: dashboardtypes.RunInitialized,
+		rowMap:    make(map[string]ResultRows),
+		doneChan:  make(chan bool, 1),
+	}
+}
@@ -0,0 +1,42 @@
+package controlexecute
+
+import (
+	"github.com/turbot/pipe-fittings/queryresult"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+// each result row of a control may link to the resource, e.g. the cloud provider console page for the resource
+// the link is either returned by the control query as an href column, or resolved from the control href tag,
+// a template which references the columns of the row, e.g.
+//
+//	control "bucket_versioning_enabled" {
+//	  sql = "select arn as resource, name, region, ..."
+//	  tags = {
+//	    href = "https://s3.console.aws.amazon.com/s3/buckets/{{ .name | @uri }}?region={{ .region }}"
+//	  }
+//	}
+//
+// the href is included in the control data (for the dashboard UI) and in html, md and json exports
+const (
+	ColumnHref = "href"
+	TagHref    = "href"
+)
+
+// resolveResultRowHref resolves the href template for a result row
+// returns an empty string if there is no template, or it uses expressions which are not column references
+func resolveResultRowHref(template string, data []any, cols []*queryresult.ColumnDef) string {
+	if template == "" {
+		return ""
+	}
+	row := make(map[string]any, len(cols))
+	for i, c := range cols {
+		if i < len(data) {
+			row[c.Name] = data[i]
+		}
+	}
+	href, ok := dashboardtypes.ResolveHrefTemplate(template, row)
+	if !ok {
+		return ""
+	}
+	return href
+}
@@ -2,6 +2,7 @@ package controlexecute
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/turbot/go-kit/helpers"
 	typehelpers "github.com/turbot/go-kit/types"
@@ -10,6 +11,7 @@ import (
 	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/queryresult"
 	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/powerpipe/internal/ack"
 	"github.com/turbot/powerpipe/internal/dashboardtypes"
 	localqueryresult "github.com/turbot/powerpipe/internal/queryresult"
 )
@@ -23,17 +25,27 @@ func (r ResultRows) ToLeafData(dimensionSchema map[string]*queryresult.ColumnDef
 			{Name: "reason", DataType: "TEXT"},
 			{Name: "resource", DataType: "TEXT"},
 			{Name: "status", DataType: "TEXT"},
+			{Name: ColumnFingerprint, DataType: "TEXT"},
 		},
 		Rows: make([]map[string]interface{}, len(r)),
 	}
 	for _, d := range dimensionSchema {
 		res.Columns = append(res.Columns, d)
 	}
+	// only include the href column if any row has an href
+	hasHref := slices.ContainsFunc(r, func(row *ResultRow) bool { return row.Href != "" })
+	if hasHref {
+		res.Columns = append(res.Columns, &queryresult.ColumnDef{Name: ColumnHref, DataType: "TEXT"})
+	}
 	for i, row := range r {
 		res.Rows[i] = map[string]interface{}{
-			"reason":   row.Reason,
-			"resource": row.Resource,
-			"status":   row.Status,
+			"reason":          row.Reason,
+			"resource":        row.Resource,
+			"status":          row.Status,
+			ColumnFingerprint: row.Fingerprint,
+		}
+		if hasHref {
+			res.Rows[i][ColumnHref] = row.Href
 		}
 		// flatten dimensions
 		for _, d := range row.Dimensions {
@@ -51,6 +63,13 @@ type ResultRow struct {
 	Resource string `json:"resource" csv:"resource"`
 	// status of the row (ok, info, alarm, error, skip)
 	Status string `json:"status" csv:"status"`
+	// link to the resource, e.g. in the cloud provider console
+	Href string `json:"href,omitempty"`
+	// identifies the result across runs
+	Fingerprint string `json:"fingerprint"`
+	// if the finding is acknowledged, the acknowledgement and the status of the result before it was acknowledged
+	Acknowledgement    *ack.Acknowledgement `json:"acknowledgement,omitempty"`
+	AcknowledgedStatus string               `json:"acknowledged_status,omitempty"`
 	// dimensions for this row
 	Dimensions []Dimension `json:"dimensions"`
 	// parent control run
@@ -94,6 +113,7 @@ func NewResultRow(run *ControlRun, row *localqueryresult.RowResult, cols []*quer
 	if row.Error != nil {
 		res.Status = constants.ControlError
 		res.Reason = error_helpers.TransformErrorToSteampipe(row.Error).Error()
+		res.Fingerprint = FindingFingerprint(run.FullName, "", res.Reason, nil)
 
 		//nolint:nilerr // no need to return the error - we have created an error row
 		return res, nil
@@ -111,6 +131,8 @@ func NewResultRow(run *ControlRun, row *localqueryresult.RowResult, cols []*quer
 				return nil, fmt.Errorf("invalid control status '%s'", status)
 			}
 			res.Status = status
+		case ColumnHref:
+			res.Href = typehelpers.ToString(row.Data[i])
 		default:
 			// if this is a scalar type, add to dimensions
 			val := row.Data[i]
@@ -120,9 +142,47 @@ func NewResultRow(run *ControlRun, row *localqueryresult.RowResult, cols []*quer
 			}
 		}
 	}
+
+	// if the query did not return an href, resolve the control href template (if any)
+	if res.Href == "" && run.Control != nil {
+		res.Href = resolveResultRowHref(run.Control.Tags[TagHref], row.Data, cols)
+	}
+
+	// add any dimensions configured for the workspace
+	if run.Tree != nil {
+		res.addConfiguredDimensions(run.Tree.configuredDimensions, row.Data, cols)
+	}
+	res.Fingerprint = FindingFingerprint(run.FullName, res.Resource, res.Reason, res.Dimensions)
 	return res, nil
 }
 
+// addConfiguredDimensions adds each configured dimension which is present in the row and has not already been added
+// (configured dimensions are not part of the result fingerprint)
+func (r *ResultRow) addConfiguredDimensions(dimensions []string, data []any, cols []*queryresult.ColumnDef) {
+	for _, dimension := range dimensions {
+		if r.hasDimension(dimension) {
+			continue
+		}
+		if value, ok := resolveConfiguredDimension(dimension, data, cols); ok {
+			r.Dimensions = append(r.Dimensions, Dimension{
+				Key:        dimension,
+				Value:      value,
+				SqlType:    "TEXT",
+				Configured: true,
+			})
+		}
+	}
+}
+
+func (r *ResultRow) hasDimension(key string) bool {
+	for _, dim := range r.Dimensions {
+		if dim.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
 func IsValidControlStatus(status string) bool {
 	return helpers.StringSliceContains([]string{constants.ControlOk, constants.ControlAlarm, constants.ControlInfo, constants.ControlError, constants.ControlSkip}, status)
 }
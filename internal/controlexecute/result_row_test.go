@@ -0,0 +1,99 @@
+package controlexecute
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/queryresult"
+)
+
+type addConfiguredDimensionsTest struct {
+	dimensions []string
+	existing   []Dimension
+	data       []any
+	expected   []Dimension
+}
+
+var testCasesAddConfiguredDimensions = map[string]addConfiguredDimensionsTest{
+	"scalar column": {
+		dimensions: []string{"region"},
+		data:       []any{"us-east-1", nil},
+		expected:   []Dimension{{Key: "region", Value: "us-east-1", SqlType: "TEXT", Configured: true}},
+	},
+	"json path": {
+		dimensions: []string{"tags.owner"},
+		data:       []any{nil, map[string]any{"owner": "platform"}},
+		expected:   []Dimension{{Key: "tags.owner", Value: "platform", SqlType: "TEXT", Configured: true}},
+	},
+	"json column": {
+		dimensions: []string{"tags"},
+		data:       []any{nil, map[string]any{"owner": "platform"}},
+		expected:   []Dimension{{Key: "tags", Value: `{"owner":"platform"}`, SqlType: "TEXT", Configured: true}},
+	},
+	"missing column": {
+		dimensions: []string{"account_id"},
+		data:       []any{"us-east-1", nil},
+	},
+	"missing json key": {
+		dimensions: []string{"tags.owner"},
+		data:       []any{nil, map[string]any{"env": "prod"}},
+	},
+	"null value": {
+		dimensions: []string{"region"},
+		data:       []any{nil, nil},
+	},
+	"already added": {
+		dimensions: []string{"region"},
+		existing:   []Dimension{{Key: "region", Value: "us-east-1", SqlType: "TEXT"}},
+		data:       []any{"us-east-1", nil},
+		expected:   []Dimension{{Key: "region", Value: "us-east-1", SqlType: "TEXT"}},
+	},
+}
+
+func TestAddConfiguredDimensions(t *testing.T) {
+	cols := []*queryresult.ColumnDef{
+		{Name: "region", DataType: "TEXT"},
+		{Name: "tags", DataType: "JSONB"},
+	}
+	for name, test := range testCasesAddConfiguredDimensions {
+		row := &ResultRow{Dimensions: test.existing}
+		row.addConfiguredDimensions(test.dimensions, test.data, cols)
+		if !reflect.DeepEqual(row.Dimensions, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected:\n\n%v\n\ngot:\n\n%v", name, test.expected, row.Dimensions)
+		}
+	}
+}
+
+type resolveResultRowHrefTest struct {
+	template string
+	data     []any
+	expected string
+}
+
+var testCasesResolveResultRowHref = map[string]resolveResultRowHrefTest{
+	"no template": {
+		data: []any{"arn:aws:s3:::my-bucket", "my bucket", "us-east-1"},
+	},
+	"columns": {
+		template: "https://s3.console.aws.amazon.com/s3/buckets/{{ .name | @uri }}?region={{ .region }}",
+		data:     []any{"arn:aws:s3:::my-bucket", "my bucket", "us-east-1"},
+		expected: "https://s3.console.aws.amazon.com/s3/buckets/my%20bucket?region=us-east-1",
+	},
+	"unsupported expression": {
+		template: "https://example.com/{{ .name | ascii_downcase }}",
+		data:     []any{"arn:aws:s3:::my-bucket", "my bucket", "us-east-1"},
+	},
+}
+
+func TestResolveResultRowHref(t *testing.T) {
+	cols := []*queryresult.ColumnDef{
+		{Name: "resource", DataType: "TEXT"},
+		{Name: "name", DataType: "TEXT"},
+		{Name: "region", DataType: "TEXT"},
+	}
+	for name, test := range testCasesResolveResultRowHref {
+		if actual := resolveResultRowHref(test.template, test.data, cols); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package controlexecute
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// a seeded run (--seed) is deterministic, so that repeated runs over identical data produce byte-comparable exports,
+// which can be diffed in CI:
+//   - the result rows of each control are ordered by status, then by resource, reason, dimensions and fingerprint,
+//     rather than in the order they were returned by the database (or by each connection, if fanned out)
+//   - dimension colors are allocated in order of dimension key and value
+//   - the start and end time of the run are the seed, as seconds since the Unix epoch, and all durations are zero
+//
+// the seed is usually a fixed value, or the time of the commit being checked, e.g. --seed $(git log -1 --format=%ct)
+// as seeded runs have no durations, they are not recorded in the control history
+// default export file names include the current time - to compare exports, name the files, e.g. --export results.json
+
+// applySeed sets the times of a seeded run to the seed, and clears its durations
+func (e *ExecutionTree) applySeed() {
+	runTime := time.Unix(*e.seed, 0).UTC()
+	e.StartTime = runTime
+	e.EndTime = runTime
+	if e.Root != nil {
+		e.Root.clearDurations()
+	}
+	for _, run := range e.ControlRuns {
+		run.Duration = 0
+	}
+}
+
+func (r *ResultGroup) clearDurations() {
+	r.Duration = 0
+	for _, group := range r.Groups {
+		group.clearDurations()
+	}
+}
+
+// sortResultRows orders result rows of the same status by resource, reason, dimensions and fingerprint
+func sortResultRows(rows ResultRows) {
+	slices.SortStableFunc(rows, func(a, b *ResultRow) int {
+		if c := cmp.Compare(a.Resource, b.Resource); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Reason, b.Reason); c != 0 {
+			return c
+		}
+		if c := slices.CompareFunc(a.Dimensions, b.Dimensions, compareDimensions); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Fingerprint, b.Fingerprint)
+	})
+}
+
+// sortControlRunInstances orders control run instances by parent group, then by control
+func sortControlRunInstances(instances []*ControlRunInstance) {
+	slices.SortStableFunc(instances, func(a, b *ControlRunInstance) int {
+		if c := cmp.Compare(a.Group.GroupId, b.Group.GroupId); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Control.FullName, b.Control.FullName)
+	})
+}
+
+func compareDimensions(a, b Dimension) int {
+	if c := cmp.Compare(a.Key, b.Key); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Value, b.Value)
+}
@@ -0,0 +1,84 @@
+package controlexecute
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/constants"
+)
+
+type seededRowOrderTest struct {
+	seed   *int64
+	rows   []*ResultRow
+	expect []string
+}
+
+var testSeed int64 = 1700000000
+
+var testCasesSeededRowOrder = map[string]seededRowOrderTest{
+	"unseeded keeps query order": {
+		rows: []*ResultRow{
+			{Status: constants.ControlOk, Resource: "b"},
+			{Status: constants.ControlOk, Resource: "a"},
+			{Status: constants.ControlAlarm, Resource: "c"},
+		},
+		expect: []string{"alarm c", "ok b", "ok a"},
+	},
+	"seeded orders by resource": {
+		seed: &testSeed,
+		rows: []*ResultRow{
+			{Status: constants.ControlOk, Resource: "b"},
+			{Status: constants.ControlOk, Resource: "a"},
+			{Status: constants.ControlAlarm, Resource: "c"},
+		},
+		expect: []string{"alarm c", "ok a", "ok b"},
+	},
+	"seeded orders by reason then dimensions": {
+		seed: &testSeed,
+		rows: []*ResultRow{
+			{Status: constants.ControlOk, Resource: "a", Reason: "2"},
+			{Status: constants.ControlOk, Resource: "a", Reason: "1", Dimensions: []Dimension{{Key: "region", Value: "us-west-2"}}},
+			{Status: constants.ControlOk, Resource: "a", Reason: "1", Dimensions: []Dimension{{Key: "region", Value: "us-east-1"}}},
+		},
+		expect: []string{"ok a 1 us-east-1", "ok a 1 us-west-2", "ok a 2"},
+	},
+}
+
+func TestSeededRowOrder(t *testing.T) {
+	for name, test := range testCasesSeededRowOrder {
+		r := &ControlRun{rowMap: make(map[string]ResultRows), Tree: &ExecutionTree{seed: test.seed}}
+		for _, row := range test.rows {
+			r.rowMap[row.Status] = append(r.rowMap[row.Status], row)
+		}
+		r.createdOrderedResultRows()
+
+		var got []string
+		for _, row := range r.Rows {
+			s := fmt.Sprintf("%s %s", row.Status, row.Resource)
+			if row.Reason != "" {
+				s += " " + row.Reason
+			}
+			for _, d := range row.Dimensions {
+				s += " " + d.Value
+			}
+			got = append(got, s)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.expect) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expect, got)
+		}
+	}
+}
+
+func TestApplySeed(t *testing.T) {
+	root := &ResultGroup{Duration: 5, Groups: []*ResultGroup{{Duration: 3}}}
+	run := &ControlRun{Duration: 2}
+	tree := &ExecutionTree{Root: root, ControlRuns: map[string]*ControlRun{"c": run}, seed: &testSeed}
+	tree.applySeed()
+
+	if tree.StartTime.Unix() != testSeed || !tree.EndTime.Equal(tree.StartTime) {
+		t.Errorf("Test: 'apply seed' FAILED : expected run times %d, got %s - %s", testSeed, tree.StartTime, tree.EndTime)
+	}
+	if root.Duration != 0 || root.Groups[0].Duration != 0 || run.Duration != 0 {
+		t.Errorf("Test: 'apply seed' FAILED : expected no durations, got %s, %s, %s", root.Duration, root.Groups[0].Duration, run.Duration)
+	}
+}
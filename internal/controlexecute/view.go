@@ -0,0 +1,353 @@
+package controlexecute
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/schema"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+// a view reorganises the results of an execution tree for output, in the same way as the benchmark view of the
+// dashboard UI, e.g. --group-by severity,tag:service --status alarm,error
+//
+// results are grouped by each group-by dimension in turn:
+//   - benchmark: the benchmark hierarchy (the default)
+//   - severity: the control severity
+//   - service: the value of the control 'service' tag
+//   - tag:<key>: the value of the given control tag
+//
+// and may be filtered to only include result rows with the given statuses
+const (
+	GroupByBenchmark = "benchmark"
+	GroupBySeverity  = "severity"
+	GroupByService   = "service"
+	GroupByTagPrefix = "tag:"
+)
+
+var severityOrder = []string{"critical", "high", "medium", "low", "none"}
+
+var validStatuses = []string{constants.ControlOk, constants.ControlAlarm, constants.ControlInfo, constants.ControlSkip, constants.ControlError}
+
+type View struct {
+	GroupBy  []string
+	Statuses []string
+}
+
+// ConfiguredView returns the view configured using --group-by and --status. Values may be comma-separated.
+func ConfiguredView() (*View, error) {
+	view := &View{
+		GroupBy:  splitViewArg(viper.GetStringSlice(localconstants.ArgGroupBy)),
+		Statuses: splitViewArg(viper.GetStringSlice(localconstants.ArgStatus)),
+	}
+	if err := view.Validate(); err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+func splitViewArg(args []string) []string {
+	var res []string
+	for _, arg := range args {
+		for _, v := range strings.Split(arg, ",") {
+			if v = strings.TrimSpace(v); v != "" && !slices.Contains(res, v) {
+				res = append(res, v)
+			}
+		}
+	}
+	return res
+}
+
+func (v *View) Validate() error {
+	for _, g := range v.GroupBy {
+		switch {
+		case g == GroupByBenchmark, g == GroupBySeverity, g == GroupByService:
+		case strings.HasPrefix(g, GroupByTagPrefix) && len(g) > len(GroupByTagPrefix):
+		default:
+			return fmt.Errorf("invalid group by '%s' - must be one of: %s, %s, %s, %s<key>", g, GroupByBenchmark, GroupBySeverity, GroupByService, GroupByTagPrefix)
+		}
+	}
+	for _, s := range v.Statuses {
+		if !slices.Contains(validStatuses, s) {
+			return fmt.Errorf("invalid status '%s' - must be one of: %s", s, strings.Join(validStatuses, ", "))
+		}
+	}
+	return nil
+}
+
+// IsDefault returns whether the view leaves the execution tree unchanged
+func (v *View) IsDefault() bool {
+	return len(v.Statuses) == 0 && (len(v.GroupBy) == 0 || slices.Equal(v.GroupBy, []string{GroupByBenchmark}))
+}
+
+// viewGroupKey identifies the group a control is placed in for a single group-by dimension
+type viewGroupKey struct {
+	id    string
+	title string
+	// the benchmark result group this key was derived from (if any)
+	source *ResultGroup
+}
+
+// ApplyView returns a copy of the (executed) tree, with its results grouped and filtered according to the view
+func (e *ExecutionTree) ApplyView(view *View) *ExecutionTree {
+	if view == nil || view.IsDefault() {
+		return e
+	}
+	groupBy := view.GroupBy
+	if len(groupBy) == 0 {
+		groupBy = []string{GroupByBenchmark}
+	}
+
+	res := &ExecutionTree{
+		ControlRuns:             make(map[string]*ControlRun),
+		StartTime:               e.StartTime,
+		EndTime:                 e.EndTime,
+		Progress:                e.Progress,
+		DimensionColorGenerator: e.DimensionColorGenerator,
+		SearchPath:              e.SearchPath,
+		Workspace:               e.Workspace,
+		client:                  e.client,
+		configuredDimensions:    e.configuredDimensions,
+		View:                    view,
+		AbortReason:             e.AbortReason,
+	}
+	res.Root = newViewResultGroup(viewGroupKey{id: RootResultGroupName, title: e.Root.Title}, nil)
+
+	// add each instance of each control run (i.e. each control run and parent pair), in tree order
+	for _, instance := range e.Root.controlRunInstances() {
+		run, ok := res.ControlRuns[instance.run.Control.Name()]
+		if !ok {
+			run = instance.run.filtered(view.Statuses, res)
+			if run == nil {
+				continue
+			}
+			res.ControlRuns[run.Control.Name()] = run
+		}
+
+		group := res.Root
+		for _, dimension := range groupBy {
+			for _, key := range viewGroupKeys(dimension, instance) {
+				group = group.getOrAddViewGroup(key)
+			}
+		}
+		if !slices.Contains(run.Parents, group) {
+			run.Parents = append(run.Parents, group)
+			group.addControl(run)
+		}
+	}
+
+	// order the groups for each dimension which is not the benchmark hierarchy
+	res.Root.sortViewGroups()
+
+	// now update the summaries of all groups
+	for _, run := range res.ControlRuns {
+		for _, parent := range run.Parents {
+			parent.updateSummary(run.Summary)
+			if len(run.Severity) != 0 {
+				parent.updateSeverityCounts(run.Severity, run.Summary)
+			}
+			parent.addDimensionKeys(run.DimensionKeys...)
+		}
+	}
+	res.PopulateControlRunInstances()
+	return res
+}
+
+type viewControlRunInstance struct {
+	run    *ControlRun
+	parent *ResultGroup
+}
+
+// controlRunInstances returns each control run in the group and its descendants, along with its parent, in tree order
+func (r *ResultGroup) controlRunInstances() []viewControlRunInstance {
+	var res []viewControlRunInstance
+	for _, child := range r.Children {
+		switch c := child.(type) {
+		case *ControlRun:
+			res = append(res, viewControlRunInstance{run: c, parent: r})
+		case *ResultGroup:
+			res = append(res, c.controlRunInstances()...)
+		}
+	}
+	return res
+}
+
+// viewGroupKeys returns the keys of the nested groups for the control instance for a group-by dimension
+func viewGroupKeys(dimension string, instance viewControlRunInstance) []viewGroupKey {
+	switch {
+	case dimension == GroupByBenchmark:
+		// the benchmark hierarchy, excluding the root group
+		var res []viewGroupKey
+		for g := instance.parent; g != nil && g.Parent != nil; g = g.Parent {
+			res = append([]viewGroupKey{{id: g.GroupId, title: g.Title, source: g}}, res...)
+		}
+		return res
+	case dimension == GroupBySeverity:
+		return []viewGroupKey{newViewGroupKey(dimension, "severity", instance.run.Severity)}
+	case dimension == GroupByService:
+		return []viewGroupKey{newViewGroupKey(dimension, "service", instance.run.Tags["service"])}
+	default:
+		tag := strings.TrimPrefix(dimension, GroupByTagPrefix)
+		return []viewGroupKey{newViewGroupKey(dimension, fmt.Sprintf("%s tag", tag), instance.run.Tags[tag])}
+	}
+}
+
+func newViewGroupKey(dimension, label, value string) viewGroupKey {
+	key := viewGroupKey{id: fmt.Sprintf("%s.%s", dimension, value), title: value}
+	if value == "" {
+		key.title = fmt.Sprintf("No %s", label)
+	}
+	return key
+}
+
+func newViewResultGroup(key viewGroupKey, parent *ResultGroup) *ResultGroup {
+	group := &ResultGroup{
+		GroupId:    key.id,
+		Title:      key.title,
+		Tags:       make(map[string]string),
+		Parent:     parent,
+		Groups:     []*ResultGroup{},
+		Summary:    NewGroupSummary(),
+		Severity:   make(map[string]controlstatus.StatusSummary),
+		updateLock: new(sync.Mutex),
+		NodeType:   schema.BlockTypeBenchmark,
+	}
+	if key.source != nil {
+		group.Description = key.source.Description
+		group.Tags = key.source.Tags
+		group.Documentation = key.source.Documentation
+		group.Display = key.source.Display
+		group.Type = key.source.Type
+		group.GroupItem = key.source.GroupItem
+		group.Duration = key.source.Duration
+	}
+	return group
+}
+
+func (r *ResultGroup) getOrAddViewGroup(key viewGroupKey) *ResultGroup {
+	if group := r.GetGroupByName(key.id); group != nil {
+		return group
+	}
+	group := newViewResultGroup(key, r)
+	r.addResultGroup(group)
+	return group
+}
+
+// sortViewGroups orders groups which were not derived from benchmarks - severities from most to least severe,
+// otherwise alphabetically (with the group for controls with no value last)
+// - any benchmark groups follow, in tree order
+func (r *ResultGroup) sortViewGroups() {
+	var viewGroups, benchmarkGroups []*ResultGroup
+	for _, g := range r.Groups {
+		if g.GroupItem == nil {
+			viewGroups = append(viewGroups, g)
+		} else {
+			benchmarkGroups = append(benchmarkGroups, g)
+		}
+	}
+	if len(viewGroups) > 0 {
+		sort.SliceStable(viewGroups, func(i, j int) bool {
+			return viewGroupLess(viewGroups[i], viewGroups[j])
+		})
+		r.Groups = append(viewGroups, benchmarkGroups...)
+		// rebuild the children list
+		r.Children = make([]ExecutionTreeNode, 0, len(r.Groups)+len(r.ControlRuns))
+		for _, g := range r.Groups {
+			r.Children = append(r.Children, g)
+		}
+		for _, run := range r.ControlRuns {
+			r.Children = append(r.Children, run)
+		}
+	}
+	for _, g := range r.Groups {
+		g.sortViewGroups()
+	}
+}
+
+func viewGroupLess(a, b *ResultGroup) bool {
+	dimensionA, valueA, _ := strings.Cut(a.GroupId, ".")
+	_, valueB, _ := strings.Cut(b.GroupId, ".")
+	if dimensionA == GroupBySeverity {
+		return severityRank(valueA) < severityRank(valueB)
+	}
+	if valueA == "" || valueB == "" {
+		return valueB == "" && valueA != ""
+	}
+	return valueA < valueB
+}
+
+func severityRank(severity string) int {
+	if severity == "" {
+		return len(severityOrder) + 1
+	}
+	if i := slices.Index(severityOrder, severity); i >= 0 {
+		return i
+	}
+	return len(severityOrder)
+}
+
+// filtered returns a copy of the control run, only including the result rows with the given statuses
+// returns nil if the control has no results with these statuses (a control which failed to run is included if
+// the statuses include error)
+func (r *ControlRun) filtered(statuses []string, tree *ExecutionTree) *ControlRun {
+	res := &ControlRun{
+		ControlId:      r.ControlId,
+		FullName:       r.FullName,
+		Title:          r.Title,
+		Description:    r.Description,
+		Documentation:  r.Documentation,
+		Tags:           r.Tags,
+		Display:        r.Display,
+		Type:           r.Type,
+		Severity:       r.Severity,
+		NodeType:       r.NodeType,
+		Control:        r.Control,
+		Properties:     r.Properties,
+		Summary:        &controlstatus.StatusSummary{},
+		RunStatus:      r.GetRunStatus(),
+		DimensionKeys:  r.DimensionKeys,
+		Duration:       r.Duration,
+		Tree:           tree,
+		RunErrorString: r.RunErrorString,
+		QueryPlan:      r.QueryPlan,
+		TotalRows:      r.TotalRows,
+		Truncated:      r.Truncated,
+		runError:       r.runError,
+		rowMap:         make(map[string]ResultRows),
+	}
+
+	include := func(status string) bool {
+		return len(statuses) == 0 || slices.Contains(statuses, status)
+	}
+	for _, row := range r.Rows {
+		if include(row.Status) {
+			res.Rows = append(res.Rows, row)
+			res.addResultRow(row)
+		}
+	}
+	if r.Data != nil {
+		res.Data = &dashboardtypes.LeafData{Columns: r.Data.Columns}
+		for _, row := range r.Data.Rows {
+			if status, ok := row["status"].(string); !ok || include(status) {
+				res.Data.Rows = append(res.Data.Rows, row)
+			}
+		}
+	}
+	if res.runError != nil {
+		if !include(constants.ControlError) {
+			return nil
+		}
+		res.Summary.Error = r.Summary.Error
+	}
+	if len(res.Rows) == 0 && res.runError == nil {
+		return nil
+	}
+	return res
+}
@@ -0,0 +1,134 @@
+package controlexecute
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+)
+
+type applyViewTest struct {
+	view     View
+	expected string
+	alarms   int
+}
+
+var testCasesApplyView = map[string]applyViewTest{
+	"default": {
+		view:     View{},
+		expected: "B[c1 c2 c3]",
+		alarms:   2,
+	},
+	"group by severity": {
+		view:     View{GroupBy: []string{"severity"}},
+		expected: "high[c1 c3] low[c2]",
+		alarms:   2,
+	},
+	"group by service then severity": {
+		view:     View{GroupBy: []string{"service", "severity"}},
+		expected: "ec2[high[c3]] s3[high[c1]] No service[low[c2]]",
+		alarms:   2,
+	},
+	"group by severity then benchmark": {
+		view:     View{GroupBy: []string{"severity", "benchmark"}},
+		expected: "high[B[c1 c3]] low[B[c2]]",
+		alarms:   2,
+	},
+	"filter by status": {
+		view:     View{Statuses: []string{"ok"}},
+		expected: "B[c1 c2]",
+		alarms:   0,
+	},
+	"group by tag and filter by status": {
+		view:     View{GroupBy: []string{"tag:service"}, Statuses: []string{"alarm"}},
+		expected: "ec2[c3] s3[c1]",
+		alarms:   2,
+	},
+}
+
+func newTestViewTree() *ExecutionTree {
+	root := newViewResultGroup(viewGroupKey{id: RootResultGroupName}, nil)
+	benchmark := newViewResultGroup(viewGroupKey{id: "mod.benchmark.b", title: "B"}, root)
+	root.addResultGroup(benchmark)
+
+	tree := &ExecutionTree{Root: root, ControlRuns: make(map[string]*ControlRun)}
+	for _, c := range []struct {
+		name, severity, service string
+		statuses                []string
+	}{
+		{"c1", "high", "s3", []string{"alarm", "ok"}},
+		{"c2", "low", "", []string{"ok"}},
+		{"c3", "high", "ec2", []string{"alarm"}},
+	} {
+		control := &modconfig.Control{}
+		control.FullName = c.name
+		run := &ControlRun{
+			Control:  control,
+			Severity: c.severity,
+			Tags:     map[string]string{},
+			Summary:  &controlstatus.StatusSummary{},
+			Parents:  []*ResultGroup{benchmark},
+			rowMap:   make(map[string]ResultRows),
+		}
+		if c.service != "" {
+			run.Tags["service"] = c.service
+		}
+		for _, status := range c.statuses {
+			row := &ResultRow{Status: status, Run: run, Control: control}
+			run.Rows = append(run.Rows, row)
+			run.addResultRow(row)
+		}
+		benchmark.addControl(run)
+		tree.ControlRuns[c.name] = run
+	}
+	return tree
+}
+
+// describeViewGroup describes the group hierarchy, e.g. "high[c1 c3] low[c2]"
+func describeViewGroup(group *ResultGroup) string {
+	var children []string
+	for _, child := range group.Children {
+		switch c := child.(type) {
+		case *ControlRun:
+			children = append(children, c.Control.Name())
+		case *ResultGroup:
+			children = append(children, fmt.Sprintf("%s[%s]", c.Title, describeViewGroup(c)))
+		}
+	}
+	return strings.Join(children, " ")
+}
+
+func TestApplyView(t *testing.T) {
+	for name, test := range testCasesApplyView {
+		view := test.view
+		if err := view.Validate(); err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		tree := newTestViewTree().ApplyView(&view)
+		if actual := describeViewGroup(tree.Root); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, actual)
+		}
+		if view.IsDefault() {
+			continue
+		}
+		if tree.Root.Summary.Status.Alarm != test.alarms {
+			t.Errorf("Test: '%s' FAILED : expected %d alarms, got %d", name, test.alarms, tree.Root.Summary.Status.Alarm)
+		}
+	}
+}
+
+func TestValidateView(t *testing.T) {
+	for name, view := range map[string]View{
+		"invalid group by":    {GroupBy: []string{"region"}},
+		"empty tag":           {GroupBy: []string{"tag:"}},
+		"invalid status":      {Statuses: []string{"failed"}},
+		"invalid status case": {Statuses: []string{"ALARM"}},
+	} {
+		if err := view.Validate(); err == nil {
+			t.Errorf("Test: '%s' FAILED : expected error", name)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package controlinit
+
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+// benchmarks may declare the exports to produce whenever they are run, so the export formats live with the mod
+// rather than in every pipeline which runs it, e.g.
+//
+//	benchmark "cis_v300" {
+//	  tags = {
+//	    default_export = "html,json,cis_v300.nunit3.xml"
+//	  }
+//	}
+//
+// default_export is a comma-separated list of exports, as passed to --export
+// the default exports are only used if --export is not set - '--export=' runs the benchmark with no exports
+// when several benchmarks are run, the default exports of all of them are used
+const TagDefaultExport = "default_export"
+
+// applyDefaultExports sets --export to the default exports of the target benchmarks, if it is not set
+func applyDefaultExports(targets []modconfig.ModTreeItem) {
+	if viper.IsSet(constants.ArgExport) {
+		return
+	}
+	if exports := defaultExports(targets); len(exports) > 0 {
+		viper.Set(constants.ArgExport, exports)
+	}
+}
+
+// defaultExports returns the default exports of the target benchmarks, in order and without duplicates
+func defaultExports(targets []modconfig.ModTreeItem) []string {
+	var res []string
+	added := make(map[string]struct{})
+	for _, target := range targets {
+		benchmark, ok := target.(*modconfig.Benchmark)
+		if !ok {
+			continue
+		}
+		for _, export := range strings.Split(benchmark.Tags[TagDefaultExport], ",") {
+			export = strings.TrimSpace(export)
+			if _, ok := added[export]; ok || export == "" {
+				continue
+			}
+			added[export] = struct{}{}
+			res = append(res, export)
+		}
+	}
+	return res
+}
@@ -0,0 +1,47 @@
+package controlinit
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+type defaultExportsTest struct {
+	targets  []modconfig.ModTreeItem
+	expected []string
+}
+
+func testExportBenchmark(defaultExport string) *modconfig.Benchmark {
+	return &modconfig.Benchmark{
+		ModTreeItemImpl: modconfig.ModTreeItemImpl{
+			HclResourceImpl: modconfig.HclResourceImpl{Tags: map[string]string{TagDefaultExport: defaultExport}},
+		},
+	}
+}
+
+var testCasesDefaultExports = map[string]defaultExportsTest{
+	"no default exports": {
+		targets: []modconfig.ModTreeItem{&modconfig.Benchmark{}},
+	},
+	"single benchmark": {
+		targets:  []modconfig.ModTreeItem{testExportBenchmark("html, json,,cis.nunit3.xml")},
+		expected: []string{"html", "json", "cis.nunit3.xml"},
+	},
+	"multiple benchmarks": {
+		targets:  []modconfig.ModTreeItem{testExportBenchmark("html,json"), testExportBenchmark("json,csv")},
+		expected: []string{"html", "json", "csv"},
+	},
+	"control": {
+		targets: []modconfig.ModTreeItem{&modconfig.Control{}},
+	},
+}
+
+func TestDefaultExports(t *testing.T) {
+	for name, test := range testCasesDefaultExports {
+		res := defaultExports(test.targets)
+		if !slices.Equal(res, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, res)
+		}
+	}
+}
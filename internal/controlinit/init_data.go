@@ -68,6 +68,8 @@ func NewInitData[T CheckTarget](ctx context.Context, cmd *cobra.Command, args []
 		return i
 	}
 
+	// use the default exports of the target benchmarks if --export is not set
+	applyDefaultExports(i.Targets)
 	if len(viper.GetStringSlice(constants.ArgExport)) > 0 {
 		if err := i.registerCheckExporters(); err != nil {
 			i.Result.Error = err
@@ -0,0 +1,230 @@
+package crash
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/viper"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/constants"
+	pruntime "github.com/turbot/pipe-fittings/constants/runtime"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/sanitize"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/logger"
+	"github.com/turbot/powerpipe/internal/snapshot"
+)
+
+const (
+	crashDirName = "crash"
+	issuesURL    = "https://github.com/turbot/powerpipe/issues/new"
+)
+
+// Recover handles a value recovered from a panic in a command, returning it as an error
+//
+// errors raised using error_helpers.FailOnError (and similar) are expected, so are just displayed. Any other panic,
+// i.e. a runtime error, is a crash - as well as displaying the error, a diagnostic bundle containing the stack,
+// the most recent logs, the version and the sanitized config is written to disk, and the user is told how to
+// attach it to an issue
+func Recover(ctx context.Context, r any) error {
+	err := helpers.ToError(r)
+	error_helpers.ShowError(ctx, err)
+	if !IsCrash(r) {
+		return err
+	}
+
+	bundlePath, bundleErr := WriteBundle(r, debug.Stack())
+	if bundleErr != nil {
+		fmt.Fprintf(color.Error, "Powerpipe crashed, and the diagnostic bundle could not be written: %v\n", bundleErr)
+		return err
+	}
+	fmt.Fprintf(color.Error, "\nPowerpipe crashed - a diagnostic bundle has been written to %s\n"+
+		"Please report this at %s, attaching the bundle.\n"+
+		"Sensitive config values are redacted, but check the bundle contents before sharing it.\n", bundlePath, issuesURL)
+	return err
+}
+
+// IsCrash returns whether a recovered panic value is a crash, rather than an error raised
+// using error_helpers.FailOnError (which panics with an error) or FailOnErrorWithMessage (which panics with a string)
+func IsCrash(r any) bool {
+	_, isRuntimeError := r.(runtime.Error)
+	return isRuntimeError
+}
+
+// Environment is the environment of a crash
+type Environment struct {
+	Version     string    `json:"version"`
+	Commit      string    `json:"commit"`
+	Date        string    `json:"date"`
+	BuiltBy     string    `json:"built_by"`
+	GoVersion   string    `json:"go_version"`
+	OS          string    `json:"os"`
+	Arch        string    `json:"arch"`
+	Args        []string  `json:"args"`
+	ExecutionID string    `json:"execution_id"`
+	Time        time.Time `json:"time"`
+}
+
+// WriteBundle writes a diagnostic bundle for a crash to the crash directory, returning its path
+func WriteBundle(r any, stack []byte) (string, error) {
+	dir := crashDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	bundlePath := filepath.Join(dir, fmt.Sprintf("crash-%s.zip", now.Format("20060102-150405")))
+
+	f, err := os.OpenFile(bundlePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	zipWriter := zip.NewWriter(f)
+	files := []struct {
+		name string
+		data func() ([]byte, error)
+	}{
+		{"panic.txt", func() ([]byte, error) {
+			return []byte(fmt.Sprintf("%v\n\n%s", r, stack)), nil
+		}},
+		{"environment.json", func() ([]byte, error) {
+			return json.MarshalIndent(environment(now), "", "  ")
+		}},
+		{"config.json", func() ([]byte, error) {
+			return json.MarshalIndent(sanitizedConfig(), "", "  ")
+		}},
+		{"logs.jsonl", func() ([]byte, error) {
+			return []byte(strings.Join(redactedLogs(), "\n")), nil
+		}},
+	}
+	for _, file := range files {
+		data, err := file.data()
+		if err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+		w, err := zipWriter.Create(file.name)
+		if err != nil {
+			return "", err
+		}
+		if _, err := w.Write(data); err != nil {
+			return "", err
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		return "", err
+	}
+	return bundlePath, nil
+}
+
+// crashDir returns the directory crash bundles are written to - the crash directory of the install dir,
+// or the temp dir if the install dir has not been set
+func crashDir() string {
+	if app_specific.InstallDir == "" {
+		return os.TempDir()
+	}
+	return filepath.Join(app_specific.InstallDir, crashDirName)
+}
+
+func environment(now time.Time) *Environment {
+	return &Environment{
+		Version:     viper.GetString(localconstants.ConfigKeyVersion),
+		Commit:      viper.GetString(localconstants.ConfigKeyCommit),
+		Date:        viper.GetString(localconstants.ConfigKeyDate),
+		BuiltBy:     viper.GetString(localconstants.ConfigKeyBuiltBy),
+		GoVersion:   runtime.Version(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		Args:        snapshot.RedactArgs(os.Args),
+		ExecutionID: pruntime.ExecutionID,
+		Time:        now.UTC(),
+	}
+}
+
+// sanitizedConfig returns the config settings, with sensitive values redacted
+func sanitizedConfig() map[string]any {
+	res := make(map[string]any)
+	for _, key := range viper.AllKeys() {
+		// the command args are included, redacted, in the environment
+		if key == constants.ConfigKeyActiveCommand || key == constants.ConfigKeyActiveCommandArgs {
+			continue
+		}
+		value := viper.Get(key)
+		switch v := value.(type) {
+		case string:
+			value = snapshot.RedactValue(key, v)
+		case []string:
+			// e.g. variable assignments
+			value = snapshot.RedactArgs(v)
+		}
+		value = sanitize.Instance.SanitizeKeyValue(key, value)
+		// skip values which cannot be serialised, e.g. the workspace profile
+		if _, err := json.Marshal(value); err != nil {
+			continue
+		}
+		res[key] = value
+	}
+	return res
+}
+
+// redactedLogs returns the most recent logs, with sensitive values redacted as they are in the config
+func redactedLogs() []string {
+	lines := logger.RecentLogs()
+	res := make([]string, len(lines))
+	for i, line := range lines {
+		res[i] = redactLogLine(line)
+	}
+	return res
+}
+
+// redactLogLine redacts the sensitive values of a json log line - a line which cannot be parsed is redacted as args
+func redactLogLine(line string) string {
+	decoder := json.NewDecoder(strings.NewReader(line))
+	// (so numbers are unchanged)
+	decoder.UseNumber()
+	var entry map[string]any
+	if err := decoder.Decode(&entry); err != nil {
+		return strings.Join(snapshot.RedactArgs(strings.Fields(line)), " ")
+	}
+	data, err := json.Marshal(redactLogValue("", entry))
+	if err != nil {
+		return strings.Join(snapshot.RedactArgs(strings.Fields(line)), " ")
+	}
+	return string(data)
+}
+
+// redactLogValue redacts the value of a log attribute with the given key - the values of groups are redacted by their
+// own keys, and lists of strings (e.g. the args of a command) are redacted as args
+func redactLogValue(key string, value any) any {
+	switch v := value.(type) {
+	case string:
+		return snapshot.RedactValue(key, v)
+	case map[string]any:
+		res := make(map[string]any, len(v))
+		for k, attrValue := range v {
+			res[k] = redactLogValue(k, attrValue)
+		}
+		return res
+	case []any:
+		args := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return v
+			}
+			args[i] = s
+		}
+		return snapshot.RedactArgs(args)
+	}
+	return value
+}
@@ -0,0 +1,165 @@
+package customexport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/export"
+	"github.com/turbot/pipe-fittings/steampipeconfig"
+)
+
+// organisations may add their own export formats using an external command, e.g. --export custom:myformat
+//
+// the command for a format is an executable named powerpipe-export-<format>, found in the exporters directory of
+// the install dir (e.g. ~/.powerpipe/exporters) or in the PATH
+//
+// the command is passed the snapshot JSON on stdin and the destination path as its only argument
+// the target config is also set in the environment:
+//   - POWERPIPE_EXPORT_FORMAT: the format name
+//   - POWERPIPE_EXPORT_PATH: the destination path
+//   - POWERPIPE_EXPORT_EXECUTION_NAME: the name of the execution being exported, e.g. dashboard.my_dashboard
+//
+// anything the command writes to stdout is written to the destination path - a command may instead write the file itself
+// a non-zero exit code fails the export, and any stderr output is included in the error
+const (
+	ExportPrefix        = "custom:"
+	CommandPrefix       = "powerpipe-export-"
+	ExportersDirName    = "exporters"
+	EnvExportFormat     = "POWERPIPE_EXPORT_FORMAT"
+	EnvExportPath       = "POWERPIPE_EXPORT_PATH"
+	EnvExportExecution  = "POWERPIPE_EXPORT_EXECUTION_NAME"
+	maxStderrErrorBytes = 1024
+)
+
+var formatRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// SnapshotJsonFunc converts the export source data into snapshot JSON
+type SnapshotJsonFunc func(export.ExportSourceData) ([]byte, error)
+
+// SnapshotJson returns the JSON for a snapshot export source
+func SnapshotJson(input export.ExportSourceData) ([]byte, error) {
+	snapshot, ok := input.(*steampipeconfig.SteampipeSnapshot)
+	if !ok {
+		return nil, fmt.Errorf("custom exporter input must be a SteampipeSnapshot")
+	}
+	return snapshot.AsStrippedJson(false)
+}
+
+// CommandExporter exports the snapshot JSON using an external command
+type CommandExporter struct {
+	export.ExporterBase
+	format  string
+	command string
+	toJson  SnapshotJsonFunc
+}
+
+// GetExporters returns an exporter for each custom format in the export args
+// an error is returned if the command for a format cannot be found
+func GetExporters(exportArgs []string, toJson SnapshotJsonFunc) ([]export.Exporter, error) {
+	var res []export.Exporter
+	seen := make(map[string]struct{})
+	for _, arg := range exportArgs {
+		format, ok := ParseExportArg(arg)
+		if !ok {
+			continue
+		}
+		if _, ok := seen[format]; ok {
+			continue
+		}
+		seen[format] = struct{}{}
+
+		if !formatRegex.MatchString(format) {
+			return nil, fmt.Errorf("invalid custom export format '%s': must contain only letters, digits, '-' and '_'", format)
+		}
+		command, err := findCommand(format)
+		if err != nil {
+			return nil, err
+		}
+		slog.Debug("registering custom exporter", "format", format, "command", command)
+		res = append(res, &CommandExporter{format: format, command: command, toJson: toJson})
+	}
+	return res, nil
+}
+
+// ParseExportArg returns the format of a custom export arg, e.g. custom:myformat
+func ParseExportArg(arg string) (string, bool) {
+	arg = strings.TrimSpace(arg)
+	if !strings.HasPrefix(arg, ExportPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(arg, ExportPrefix), true
+}
+
+func findCommand(format string) (string, error) {
+	name := CommandPrefix + format
+	if app_specific.InstallDir != "" {
+		command := filepath.Join(app_specific.InstallDir, ExportersDirName, name)
+		if info, err := os.Stat(command); err == nil && !info.IsDir() {
+			return command, nil
+		}
+	}
+	command, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("custom export format '%s' not found: no '%s' executable in %s or the PATH", format, name, filepath.Join(app_specific.InstallDir, ExportersDirName))
+	}
+	return command, nil
+}
+
+func (e *CommandExporter) Export(ctx context.Context, input export.ExportSourceData, destPath string) error {
+	snapshotJson, err := e.toJson(input)
+	if err != nil {
+		return err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.command, destPath)
+	cmd.Stdin = bytes.NewReader(snapshotJson)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("%s=%s", EnvExportFormat, e.format),
+		fmt.Sprintf("%s=%s", EnvExportPath, destPath),
+		fmt.Sprintf("%s=%s", EnvExportExecution, executionName(destPath, e.FileExtension())),
+	)
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > maxStderrErrorBytes {
+			msg = msg[:maxStderrErrorBytes] + "…"
+		}
+		if msg != "" {
+			return fmt.Errorf("custom exporter '%s' failed: %w: %s", e.format, err, msg)
+		}
+		return fmt.Errorf("custom exporter '%s' failed: %w", e.format, err)
+	}
+
+	if stdout.Len() == 0 {
+		return nil
+	}
+	return export.Write(destPath, &stdout)
+}
+
+// executionName derives the execution name from the default export file name, <execution name>.<timestamp>.<format>
+func executionName(destPath, extension string) string {
+	name := strings.TrimSuffix(filepath.Base(destPath), extension)
+	if idx := strings.LastIndex(name, "."); idx > 0 {
+		return name[:idx]
+	}
+	return name
+}
+
+func (e *CommandExporter) FileExtension() string {
+	return "." + e.format
+}
+
+func (e *CommandExporter) Name() string {
+	return ExportPrefix + e.format
+}
@@ -0,0 +1,90 @@
+package customexport
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/export"
+)
+
+type commandExporterTest struct {
+	script   string
+	expected string
+	err      bool
+}
+
+var testCasesCommandExporter = map[string]commandExporterTest{
+	"stdout": {
+		script:   "#!/bin/sh\necho \"$POWERPIPE_EXPORT_FORMAT $POWERPIPE_EXPORT_EXECUTION_NAME\"\ncat\n",
+		expected: "test dashboard.d\n{\"a\":1}",
+	},
+	"writes file": {
+		script:   "#!/bin/sh\ncat > \"$1\"\n",
+		expected: "{\"a\":1}",
+	},
+	"fails": {
+		script: "#!/bin/sh\necho 'bad input' >&2\nexit 1\n",
+		err:    true,
+	},
+}
+
+func rawJson(export.ExportSourceData) ([]byte, error) {
+	return []byte(`{"a":1}`), nil
+}
+
+func TestCommandExporter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("custom exporter tests use shell scripts")
+	}
+	for name, test := range testCasesCommandExporter {
+		dir := t.TempDir()
+		command := filepath.Join(dir, CommandPrefix+"test")
+		if err := os.WriteFile(command, []byte(test.script), 0755); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+		exporters, err := GetExporters([]string{"json", "custom:test", "custom:test"}, rawJson)
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		if len(exporters) != 1 || exporters[0].Name() != "custom:test" || exporters[0].FileExtension() != ".test" {
+			t.Errorf("Test: '%s' FAILED : expected a single custom:test exporter, got %v", name, exporters)
+			continue
+		}
+
+		destPath := filepath.Join(dir, export.GenerateDefaultExportFileName("dashboard.d", exporters[0].FileExtension()))
+		err = exporters[0].Export(context.Background(), nil, destPath)
+		if test.err {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		actual, _ := os.ReadFile(destPath)
+		if string(actual) != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, string(actual))
+		}
+	}
+}
+
+func TestGetExportersInvalid(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	for name, args := range map[string][]string{
+		"missing command": {"custom:missing"},
+		"invalid format":  {"custom:../test"},
+		"empty format":    {"custom:"},
+	} {
+		if _, err := GetExporters(args, rawJson); err == nil {
+			t.Errorf("Test: '%s' FAILED : expected error", name)
+		}
+	}
+}
@@ -4,13 +4,16 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"context"
+	"crypto/sha512"
 	"embed"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/Masterminds/semver/v3"
 	filehelpers "github.com/turbot/go-kit/files"
@@ -56,10 +59,14 @@ func Ensure(ctx context.Context) error {
 	}
 	defer tarGz.Close()
 
-	err = extractTarGz(ctx, tarGz, reportAssetsPath)
+	manifest := newManifest(app_specific.AppVersion.String())
+	err = extractTarGz(ctx, tarGz, reportAssetsPath, manifest)
 	if err != nil {
 		return sperr.WrapWithMessage(err, "could not extract embedded dashboard assets archive")
 	}
+	if err := manifest.save(); err != nil {
+		return err
+	}
 	err = updateAssetVersionFile()
 	if err != nil {
 		return sperr.WrapWithMessage(err, "could not update dashboard assets version file")
@@ -109,6 +116,10 @@ func installedAssetsMatchAppVersion() bool {
 	if err != nil {
 		return false
 	}
+	// assets extracted without a manifest (i.e. by an earlier version) are extracted again
+	if manifest, err := LoadManifest(); err != nil || manifest == nil || manifest.Version != versionFile.Version {
+		return false
+	}
 
 	assetVersion, err := semver.NewVersion(versionFile.Version)
 	if err != nil {
@@ -141,8 +152,9 @@ func LoadDashboardAssetVersion() (*ReportAssetsVersion, error) {
 	return &versionFile, nil
 }
 
-// extractTarGz extracts a .tar.gz archive to a destination directory.
-func extractTarGz(ctx context.Context, gzipStream io.Reader, dest string) error {
+// extractTarGz extracts a .tar.gz archive to a destination directory, recording the integrity of each file in the
+// manifest
+func extractTarGz(ctx context.Context, gzipStream io.Reader, dest string, manifest *Manifest) error {
 	slog.Info("dashboardassets.extractTarGz start")
 	defer slog.Info("dashboardassets.extractTarGz end")
 
@@ -180,12 +192,14 @@ func extractTarGz(ctx context.Context, gzipStream io.Reader, dest string) error
 			if err != nil {
 				return err
 			}
+			hash := sha512.New384()
 			//nolint:gosec // known archive
-			if _, err := io.Copy(outFile, tarReader); err != nil {
+			if _, err := io.Copy(io.MultiWriter(outFile, hash), tarReader); err != nil {
 				outFile.Close()
 				return err
 			}
 			outFile.Close()
+			manifest.Files[path.Clean(strings.TrimPrefix(header.Name, "./"))] = integrity(hash.Sum(nil))
 		default:
 			return sperr.New("ExtractTarGz: uknown type: %b in %s", header.Typeflag, header.Name)
 		}
@@ -0,0 +1,133 @@
+package dashboardassets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/filepaths"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+	"golang.org/x/net/html"
+)
+
+const indexFileName = "index.html"
+
+// IndexPage is the index page of the dashboard, with the integrity of the assets in the manifest added to the tags
+// loading them
+type IndexPage struct {
+	HTML []byte
+	// the hash sources of the inline scripts of the page, for the script-src of the content security policy,
+	// e.g. 'sha256-...'
+	ScriptHashes []string
+}
+
+// LoadIndexPage loads the index page of the dashboard assets, adding the integrity of the assets in the manifest
+// (which may be nil)
+func LoadIndexPage(manifest *Manifest) (*IndexPage, error) {
+	data, err := os.ReadFile(filepath.Join(filepaths.EnsureDashboardAssetsDir(), indexFileName))
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "could not read dashboard index page")
+	}
+	return renderIndexPage(data, manifest)
+}
+
+// renderIndexPage adds an integrity attribute to each script and stylesheet (or preload) tag of the page which loads
+// an asset in the manifest, and hashes the inline scripts - the rest of the page is unchanged
+func renderIndexPage(data []byte, manifest *Manifest) (*IndexPage, error) {
+	res := &IndexPage{}
+	var buf bytes.Buffer
+	tokenizer := html.NewTokenizer(bytes.NewReader(data))
+	inlineScript := false
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if err := tokenizer.Err(); err != io.EOF {
+				return nil, sperr.WrapWithMessage(err, "could not parse dashboard index page")
+			}
+			break
+		}
+		raw := tokenizer.Raw()
+
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			if token.Data == "script" && tokenType == html.StartTagToken {
+				_, hasSrc := attribute(token, "src")
+				inlineScript = !hasSrc
+			}
+			if addIntegrity(&token, manifest) {
+				buf.WriteString(token.String())
+				continue
+			}
+		case html.TextToken:
+			if inlineScript && len(bytes.TrimSpace(raw)) > 0 {
+				sum := sha256.Sum256(raw)
+				res.ScriptHashes = append(res.ScriptHashes, "'sha256-"+base64.StdEncoding.EncodeToString(sum[:])+"'")
+			}
+		case html.EndTagToken:
+			inlineScript = false
+		}
+		buf.Write(raw)
+	}
+	res.HTML = buf.Bytes()
+	return res, nil
+}
+
+// addIntegrity adds the integrity of the asset loaded by the script or link tag, returning whether it was added
+func addIntegrity(token *html.Token, manifest *Manifest) bool {
+	var ref string
+	switch token.Data {
+	case "script":
+		ref, _ = attribute(*token, "src")
+	case "link":
+		rel, _ := attribute(*token, "rel")
+		for _, r := range strings.Fields(strings.ToLower(rel)) {
+			if r == "stylesheet" || r == "preload" || r == "modulepreload" {
+				ref, _ = attribute(*token, "href")
+				break
+			}
+		}
+	}
+	if ref == "" {
+		return false
+	}
+	if _, ok := attribute(*token, "integrity"); ok {
+		return false
+	}
+	assetPath, ok := localAssetPath(ref)
+	if !ok {
+		return false
+	}
+	value, ok := manifest.Integrity(assetPath)
+	if !ok {
+		return false
+	}
+	token.Attr = append(token.Attr, html.Attribute{Key: "integrity", Val: value})
+	return true
+}
+
+// localAssetPath returns the path in the assets directory of an asset reference of the index page - false is returned
+// if the reference is not to an asset of the server
+func localAssetPath(ref string) (string, bool) {
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return "", false
+	}
+	// (the index page is served for all paths, so relative references are relative to the root)
+	return strings.TrimPrefix(path.Clean("/"+u.Path), "/"), true
+}
+
+func attribute(token html.Token, key string) (string, bool) {
+	for _, attr := range token.Attr {
+		if attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,132 @@
+package dashboardassets
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var testManifest = &Manifest{Files: map[string]string{
+	"static/js/main.js":   "sha384-main",
+	"static/css/main.css": "sha384-css",
+	"static/js/chunk.js":  "sha384-chunk",
+}}
+
+type renderIndexPageTest struct {
+	page         string
+	manifest     *Manifest
+	expected     string
+	scriptHashes []string
+}
+
+const inlineScript = `!function(){window.__runtime=1}()`
+
+var testCasesRenderIndexPage = map[string]renderIndexPageTest{
+	"scripts and stylesheets": {
+		page:     `<!doctype html><html><head><script defer="defer" src="/static/js/main.js"></script><link href="/static/css/main.css" rel="stylesheet"></head><body><div id="root"></div></body></html>`,
+		manifest: testManifest,
+		expected: `<!doctype html><html><head><script defer="defer" src="/static/js/main.js" integrity="sha384-main"></script><link href="/static/css/main.css" rel="stylesheet" integrity="sha384-css"></head><body><div id="root"></div></body></html>`,
+	},
+	"relative and preload": {
+		page:     `<link rel="modulepreload" href="./static/js/chunk.js?v=1"/><script src="static/js/main.js"></script>`,
+		manifest: testManifest,
+		expected: `<link rel="modulepreload" href="./static/js/chunk.js?v=1" integrity="sha384-chunk"/><script src="static/js/main.js" integrity="sha384-main"></script>`,
+	},
+	"not in manifest": {
+		page:     `<script src="/static/js/other.js"></script><link rel="icon" href="/static/js/main.js"><script src="https://cdn.acme.com/static/js/main.js"></script>`,
+		manifest: testManifest,
+		expected: `<script src="/static/js/other.js"></script><link rel="icon" href="/static/js/main.js"><script src="https://cdn.acme.com/static/js/main.js"></script>`,
+	},
+	"existing integrity": {
+		page:     `<script src="/static/js/main.js" integrity="sha384-other"></script>`,
+		manifest: testManifest,
+		expected: `<script src="/static/js/main.js" integrity="sha384-other"></script>`,
+	},
+	"no manifest": {
+		page:     `<script src="/static/js/main.js"></script>`,
+		expected: `<script src="/static/js/main.js"></script>`,
+	},
+	"inline script": {
+		page:         `<head><script>` + inlineScript + `</script><style>body{margin:0}</style><script src="/static/js/main.js"></script></head>`,
+		manifest:     testManifest,
+		expected:     `<head><script>` + inlineScript + `</script><style>body{margin:0}</style><script src="/static/js/main.js" integrity="sha384-main"></script></head>`,
+		scriptHashes: []string{"'sha256-" + sha256Base64(inlineScript) + "'"},
+	},
+}
+
+func TestRenderIndexPage(t *testing.T) {
+	for name, test := range testCasesRenderIndexPage {
+		page, err := renderIndexPage([]byte(test.page), test.manifest)
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		if string(page.HTML) != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected\n%s\ngot\n%s", name, test.expected, page.HTML)
+		}
+		if !reflect.DeepEqual(page.ScriptHashes, test.scriptHashes) {
+			t.Errorf("Test: '%s' FAILED : expected script hashes %v, got %v", name, test.scriptHashes, page.ScriptHashes)
+		}
+	}
+}
+
+func TestExtractTarGzManifest(t *testing.T) {
+	files := map[string]string{
+		"./index.html":        "<html></html>",
+		"./static/js/main.js": "console.log('main')",
+	}
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, dir := range []string{"./static/", "./static/js/"} {
+		if err := tarWriter.WriteHeader(&tar.Header{Name: dir, Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range files {
+		if err := tarWriter.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tarWriter.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := t.TempDir()
+	manifest := newManifest("1.0.0")
+	if err := extractTarGz(context.Background(), &buf, dest, manifest); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		sum := sha512.Sum384([]byte(content))
+		assetPath := filepath.ToSlash(filepath.Clean(name))
+		if expected := "sha384-" + base64.StdEncoding.EncodeToString(sum[:]); manifest.Files[assetPath] != expected {
+			t.Errorf("Test: '%s' FAILED : expected integrity %s, got %s", name, expected, manifest.Files[assetPath])
+		}
+		if data, err := os.ReadFile(filepath.Join(dest, name)); err != nil || string(data) != content {
+			t.Errorf("Test: '%s' FAILED : expected the file to be extracted", name)
+		}
+	}
+	if len(manifest.Files) != len(files) {
+		t.Errorf("Test: 'manifest' FAILED : expected %d files, got %v", len(files), manifest.Files)
+	}
+}
+
+func sha256Base64(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,77 @@
+package dashboardassets
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/turbot/pipe-fittings/filepaths"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+const manifestFileName = "manifest.json"
+
+// Manifest records the subresource integrity hash of each dashboard asset extracted from the embedded archive - the
+// hashes are added to the script and stylesheet tags of the index page, so assets modified after extraction are not
+// loaded by the browser
+// (the manifest is written next to the assets directory, rather than in it, so it is not served)
+type Manifest struct {
+	// the app version the assets were extracted by
+	Version string `json:"version"`
+	// the integrity of each asset, keyed by the slash separated path of the asset in the assets directory,
+	// e.g. "static/js/main.js": "sha384-..."
+	Files map[string]string `json:"files"`
+}
+
+func newManifest(version string) *Manifest {
+	return &Manifest{Version: version, Files: make(map[string]string)}
+}
+
+// ManifestFilePath returns the path of the manifest of the extracted dashboard assets
+func ManifestFilePath() string {
+	return filepath.Join(filepath.Dir(filepaths.EnsureDashboardAssetsDir()), manifestFileName)
+}
+
+// LoadManifest loads the manifest of the extracted dashboard assets - nil is returned if there is no manifest, e.g.
+// when running in development
+func LoadManifest() (*Manifest, error) {
+	data, err := os.ReadFile(ManifestFilePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, sperr.WrapWithMessage(err, "could not read dashboard assets manifest")
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, sperr.WrapWithMessage(err, "could not parse dashboard assets manifest")
+	}
+	return &manifest, nil
+}
+
+func (m *Manifest) save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return sperr.WrapWithMessage(err, "could not marshal dashboard assets manifest")
+	}
+	if err := os.WriteFile(ManifestFilePath(), data, 0600); err != nil {
+		return sperr.WrapWithMessage(err, "could not write dashboard assets manifest")
+	}
+	return nil
+}
+
+// Integrity returns the integrity of the asset with the given slash separated path
+func (m *Manifest) Integrity(assetPath string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	integrity, ok := m.Files[assetPath]
+	return integrity, ok
+}
+
+// integrity returns the subresource integrity value of the sha384 hash
+func integrity(sum []byte) string {
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum)
+}
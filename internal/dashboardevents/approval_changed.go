@@ -0,0 +1,12 @@
+package dashboardevents
+
+import (
+	"github.com/turbot/powerpipe/internal/approval"
+)
+
+type ApprovalChanged struct {
+	Request *approval.Request
+}
+
+// IsDashboardEvent implements DashboardEvent interface
+func (*ApprovalChanged) IsDashboardEvent() {}
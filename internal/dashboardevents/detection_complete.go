@@ -0,0 +1,17 @@
+package dashboardevents
+
+import (
+	"time"
+
+	"github.com/turbot/powerpipe/internal/detection"
+)
+
+type DetectionComplete struct {
+	Detection *detection.Detection
+	Findings  []*detection.Finding
+	Error     error
+	Timestamp time.Time
+}
+
+// IsDashboardEvent implements DashboardEvent interface
+func (*DetectionComplete) IsDashboardEvent() {}
@@ -11,8 +11,12 @@ type ExecutionStarted struct {
 	Session     string
 	ExecutionId string
 	Inputs      map[string]any
-	Variables   map[string]string
-	StartTime   time.Time
+	// map of input name to the names of the inputs it depends on
+	InputDependencies map[string][]string
+	Variables         map[string]string
+	// warnings for the deprecated resources the dashboard is or references
+	Warnings  []string
+	StartTime time.Time
 	// immutable representation of event data - to avoid mutation before we send it
 	JsonData []byte
 }
@@ -0,0 +1,12 @@
+package dashboardevents
+
+import (
+	"github.com/turbot/powerpipe/internal/modsource"
+)
+
+type ModInstallEvent struct {
+	Event modsource.InstallEvent
+}
+
+// IsDashboardEvent implements DashboardEvent interface
+func (*ModInstallEvent) IsDashboardEvent() {}
@@ -61,6 +61,7 @@ func (r *CheckRun) resolveDatabaseConfig() error {
 	if c, ok := r.resource.(modconfig.DatabaseItem); ok {
 		if resourceDatabase := c.GetDatabase(); resourceDatabase != nil {
 			database = *resourceDatabase
+			searchPathConfig = db_client.SearchPathConfigForDatabase(database)
 		}
 		if resourceSearchPath := c.GetSearchPath(); len(resourceSearchPath) > 0 {
 			searchPathConfig.SearchPath = resourceSearchPath
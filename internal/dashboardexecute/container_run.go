@@ -112,7 +112,8 @@ func (r *DashboardContainerRun) Execute(ctx context.Context) {
 	r.setRunning(ctx)
 
 	// wait for children to complete
-	err := <-r.waitForChildrenAsync(ctx)
+	// (failed panels do not fail the run)
+	err := r.childrenCompleteError(ctx, <-r.waitForChildrenAsync(ctx))
 	if err == nil {
 		slog.Debug("Execute waitForChildrenAsync returned success", "name", r.Name)
 		// set complete status on dashboard
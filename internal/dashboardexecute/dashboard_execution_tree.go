@@ -19,6 +19,8 @@ import (
 	"github.com/turbot/powerpipe/internal/dashboardtypes"
 	"github.com/turbot/powerpipe/internal/dashboardworkspace"
 	"github.com/turbot/powerpipe/internal/db_client"
+	"github.com/turbot/powerpipe/internal/deprecation"
+	"github.com/turbot/powerpipe/internal/snapshot"
 )
 
 // DashboardExecutionTree is a structure representing the control result hierarchy
@@ -44,6 +46,18 @@ type DashboardExecutionTree struct {
 	// active database and search path config (unless overridden at the resource level)
 	database         string
 	searchPathConfig backend.SearchPathConfig
+	// the block type of the root resource (dashboard, benchmark or query)
+	runType string
+	// who started this execution, if known
+	initiator string
+	startTime time.Time
+	// datasets uploaded by the session, available to our queries
+	datasets map[string]*Dataset
+	// stops refreshing our panels after execution (on their refresh interval or notification channels)
+	stopRefreshing context.CancelFunc
+	// limit the number of our panel queries executing at once, and the number across all executions
+	panelLimiter       *panelLimiter
+	globalPanelLimiter *panelLimiter
 }
 
 func newDashboardExecutionTree(rootResource modconfig.ModTreeItem, sessionId string, workspace *dashboardworkspace.WorkspaceEvents, defaultClientMap *db_client.ClientMap, opts ...backend.ConnectOption) (*DashboardExecutionTree, error) {
@@ -51,12 +65,15 @@ func newDashboardExecutionTree(rootResource modconfig.ModTreeItem, sessionId str
 	executionTree := &DashboardExecutionTree{
 		dashboardName:    rootResource.Name(),
 		sessionId:        sessionId,
+		runType:          rootResource.BlockType(),
+		startTime:        time.Now(),
 		defaultClientMap: defaultClientMap,
 		clientMap:        db_client.NewClientMap(),
 		runs:             make(map[string]dashboardtypes.DashboardTreeRun),
 		workspace:        workspace,
 		runComplete:      make(chan dashboardtypes.DashboardTreeRun, 1),
 		inputValues:      make(map[string]any),
+		panelLimiter:     newDashboardPanelLimiter(),
 	}
 	executionTree.id = fmt.Sprintf("%p", executionTree)
 
@@ -88,7 +105,15 @@ func newDashboardExecutionTree(rootResource modconfig.ModTreeItem, sessionId str
 func (e *DashboardExecutionTree) createRootItem(rootResource modconfig.ModTreeItem) (dashboardtypes.DashboardTreeRun, error) {
 	switch r := rootResource.(type) {
 	case *modconfig.Dashboard:
-		return NewDashboardRun(r, e, e)
+		dashboardRun, err := NewDashboardRun(r, e, e)
+		if err != nil {
+			return nil, err
+		}
+		// validate that cascading inputs can be resolved
+		if err := validateInputDependencies(dashboardRun.getInputDependencies()); err != nil {
+			return nil, err
+		}
+		return dashboardRun, nil
 	case *modconfig.Benchmark:
 		return NewCheckRun(r, e, e)
 	case *modconfig.Query:
@@ -120,6 +145,9 @@ func (e *DashboardExecutionTree) Execute(ctx context.Context) {
 	e.cancel = cancel
 	workspace := e.workspace
 
+	// schedule the database connections of the execution fairly with those of other sessions
+	ctx = e.withOrigin(ctx)
+
 	// if the default database backend supports search path, retrieve it
 	defaultClient, err := e.getClient(ctx, e.database, e.searchPathConfig)
 	if err != nil {
@@ -139,6 +167,8 @@ func (e *DashboardExecutionTree) Execute(ctx context.Context) {
 	}
 
 	panels := e.BuildSnapshotPanels()
+	// record the environment of the run
+	panels[snapshot.EnvironmentPanelName] = snapshot.CaptureEnvironment(ctx, workspace.Path, defaultClient)
 	// build map of those variables referenced by the dashboard run
 	referencedVariables, err := GetReferencedVariables(e.Root, e.workspace)
 	if err != nil {
@@ -156,9 +186,13 @@ func (e *DashboardExecutionTree) Execute(ctx context.Context) {
 		ExecutionId: e.id,
 		Panels:      immutablePanels,
 		Inputs:      e.inputValues,
-		Variables:   referencedVariables,
-		StartTime:   startTime,
+		// include the input dependencies so the UI can disable dependent inputs until their parents are set
+		InputDependencies: e.getInputDependencies(),
+		Variables:         referencedVariables,
+		Warnings:          deprecation.Warnings(e.Root.GetResource()),
+		StartTime:         startTime,
 	})
+	e.publishRunStarted()
 	defer func() {
 
 		ev := &dashboardevents.ExecutionComplete{
@@ -174,6 +208,7 @@ func (e *DashboardExecutionTree) Execute(ctx context.Context) {
 		}
 
 		workspace.PublishDashboardEvent(ctx, ev)
+		e.publishRunFinished(startTime)
 	}()
 
 	slog.Debug("begin DashboardExecutionTree.Execute")
@@ -239,6 +274,15 @@ func (e *DashboardExecutionTree) SetInputValues(inputValues map[string]any) {
 	}
 }
 
+// getInputDependencies returns the input dependencies of the root dashboard (if the root is a dashboard)
+func (e *DashboardExecutionTree) getInputDependencies() map[string][]string {
+	dashboardRun, ok := e.Root.(*DashboardRun)
+	if !ok {
+		return nil
+	}
+	return dashboardRun.getInputDependencies()
+}
+
 // ChildCompleteChan implements DashboardParent
 func (e *DashboardExecutionTree) ChildCompleteChan() chan dashboardtypes.DashboardTreeRun {
 	return e.runComplete
@@ -248,6 +292,11 @@ func (e *DashboardExecutionTree) ChildCompleteChan() chan dashboardtypes.Dashboa
 func (*DashboardExecutionTree) ChildStatusChanged(context.Context) {}
 
 func (e *DashboardExecutionTree) Cancel() {
+	// stop refreshing panels (if we are)
+	if e.stopRefreshing != nil {
+		e.stopRefreshing()
+	}
+
 	// if we have not completed, and already have a cancel function - cancel
 	if e.GetRunStatus().IsFinished() || e.cancel == nil {
 		slog.Debug("DashboardExecutionTree Cancel NOT cancelling", "status", e.GetRunStatus(), "cancel func", e.cancel)
@@ -343,6 +392,11 @@ func (*DashboardExecutionTree) GetResource() modconfig.DashboardLeafNode {
 }
 
 // function to get a client from one of the client maps
+// withOrigin returns a context whose queries are scheduled as the session of the execution
+func (e *DashboardExecutionTree) withOrigin(ctx context.Context) context.Context {
+	return db_client.WithOrigin(ctx, "session:"+e.sessionId)
+}
+
 func (e *DashboardExecutionTree) getClient(ctx context.Context, connectionString string, searchPathConfig backend.SearchPathConfig) (*db_client.DbClient, error) {
 	// if the default map already contains a client for this connection string, use that
 	if client := e.defaultClientMap.Get(connectionString, searchPathConfig); client != nil {
@@ -15,6 +15,8 @@ import (
 
 type DashboardParentImpl struct {
 	DashboardTreeRunImpl
+	// the names of any failed panels within this dashboard or container
+	FailedPanels      []string `json:"failed_panels,omitempty"`
 	children          []dashboardtypes.DashboardTreeRun
 	childCompleteChan chan dashboardtypes.DashboardTreeRun
 	// are we blocked by a child run
@@ -0,0 +1,293 @@
+package dashboardexecute
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/powerpipe/internal/db_client"
+)
+
+// in server mode, a user may upload a CSV or JSON file as a dataset, scoped to their session, e.g. a list of exception ARNs
+//
+// queries executed for the session may reference the dataset as the table upload_<name>, e.g.
+//
+//	select * from aws_s3_bucket where arn not in (select arn from upload_exceptions)
+//
+// the dataset is provided to the query as a common table expression, so no write access to the database is required
+const (
+	DatasetTablePrefix = "upload_"
+
+	DatasetFormatCSV  = "csv"
+	DatasetFormatJSON = "json"
+
+	// datasets are added to the query, so limit their size
+	MaxDatasetSize = 4 * 1024 * 1024
+	MaxDatasetRows = 10000
+	// the values of a dataset are passed to the query as bind parameters, and backends limit the number of parameters
+	// of a query (e.g. SQLite allows 32766)
+	MaxDatasetValues = 32000
+)
+
+var datasetNameRegex = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// withQueryRegex matches the start of a query which already has common table expressions
+var withQueryRegex = regexp.MustCompile(`(?is)^\s*with\s+(recursive\s+)?`)
+
+// Dataset is a user-supplied table of data
+// all values are strings (or nil) - queries may cast them as required
+type Dataset struct {
+	Name    string
+	Columns []string
+	Rows    [][]*string
+	// the prefix of the table name queries use to reference the dataset - defaults to DatasetTablePrefix
+	tablePrefix string
+}
+
+// ParseDataset parses a dataset from CSV (with a header row) or JSON (an array of objects) content
+func ParseDataset(name, format string, content []byte) (*Dataset, error) {
+	name = strings.ToLower(name)
+	if !datasetNameRegex.MatchString(name) {
+		return nil, fmt.Errorf("invalid dataset name '%s': must contain only letters, digits and underscores", name)
+	}
+	if len(content) > MaxDatasetSize {
+		return nil, fmt.Errorf("dataset '%s' is %d bytes - the maximum is %d", name, len(content), MaxDatasetSize)
+	}
+
+	var dataset *Dataset
+	var err error
+	switch strings.ToLower(format) {
+	case DatasetFormatCSV:
+		dataset, err = parseCSVDataset(content)
+	case DatasetFormatJSON:
+		dataset, err = parseJSONDataset(content)
+	default:
+		return nil, fmt.Errorf("invalid dataset format '%s' - must be one of: %s, %s", format, DatasetFormatCSV, DatasetFormatJSON)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dataset '%s': %w", name, err)
+	}
+	if len(dataset.Columns) == 0 {
+		return nil, fmt.Errorf("dataset '%s' has no columns", name)
+	}
+	if len(dataset.Rows) > MaxDatasetRows {
+		return nil, fmt.Errorf("dataset '%s' has %d rows - the maximum is %d", name, len(dataset.Rows), MaxDatasetRows)
+	}
+	if values := dataset.valueCount(); values > MaxDatasetValues {
+		return nil, fmt.Errorf("dataset '%s' has %d values - the maximum is %d", name, values, MaxDatasetValues)
+	}
+	dataset.Name = name
+	return dataset, nil
+}
+
+func parseCSVDataset(content []byte) (*Dataset, error) {
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no header row")
+	}
+	dataset := &Dataset{Columns: records[0]}
+	for _, record := range records[1:] {
+		row := make([]*string, len(record))
+		for i := range record {
+			row[i] = &record[i]
+		}
+		dataset.Rows = append(dataset.Rows, row)
+	}
+	return dataset, nil
+}
+
+func parseJSONDataset(content []byte) (*Dataset, error) {
+	var objects []map[string]any
+	if err := json.Unmarshal(content, &objects); err != nil {
+		return nil, fmt.Errorf("content must be an array of objects: %w", err)
+	}
+
+	// the columns are the union of the object keys
+	columnMap := make(map[string]struct{})
+	for _, o := range objects {
+		for k := range o {
+			columnMap[k] = struct{}{}
+		}
+	}
+	dataset := &Dataset{}
+	for c := range columnMap {
+		dataset.Columns = append(dataset.Columns, c)
+	}
+	sort.Strings(dataset.Columns)
+
+	for _, o := range objects {
+		row := make([]*string, len(dataset.Columns))
+		for i, c := range dataset.Columns {
+			row[i] = datasetValue(o[c])
+		}
+		dataset.Rows = append(dataset.Rows, row)
+	}
+	return dataset, nil
+}
+
+// datasetValue converts a value to the text representation stored in a dataset
+func datasetValue(value any) *string {
+	if value == nil {
+		return nil
+	}
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case time.Time:
+		s = v.Format(time.RFC3339Nano)
+	case map[string]any, []any:
+		jsonBytes, _ := json.Marshal(v)
+		s = string(jsonBytes)
+	default:
+		s = fmt.Sprintf("%v", v)
+	}
+	return &s
+}
+
+// TableName returns the name queries use to reference the dataset
+func (d *Dataset) TableName() string {
+	if d.tablePrefix != "" {
+		return d.tablePrefix + d.Name
+	}
+	return DatasetTablePrefix + d.Name
+}
+
+// valueCount returns the number of (non null) values of the dataset, each of which is a bind parameter of queries
+// referencing the dataset
+func (d *Dataset) valueCount() int {
+	count := 0
+	for _, row := range d.Rows {
+		for _, value := range row {
+			if value != nil {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// commonTableExpression returns the dataset as a common table expression for the backend, with its values as bind
+// parameters numbered from nextArg, and the args of the parameters
+func (d *Dataset) commonTableExpression(backendName string, nextArg int) (string, []any) {
+	columns := make([]string, len(d.Columns))
+	for i, c := range d.Columns {
+		columns[i] = db_client.EscapeName(backendName, c)
+	}
+
+	var body string
+	var args []any
+	if len(d.Rows) == 0 {
+		// select no rows, with the correct number of columns
+		nulls := make([]string, len(d.Columns))
+		for i := range nulls {
+			nulls[i] = "null"
+		}
+		body = fmt.Sprintf("select %s where 1 = 0", strings.Join(nulls, ", "))
+	} else {
+		// (MySQL requires each row of a table value constructor to be a ROW)
+		rowPrefix := ""
+		if backendName == constants.MySQLBackendName {
+			rowPrefix = "row"
+		}
+		// (the parameters are cast, as some backends cannot infer the type of parameters in a values list)
+		textType := db_client.TextType(backendName)
+		rows := make([]string, len(d.Rows))
+		for i, row := range d.Rows {
+			values := make([]string, len(d.Columns))
+			for j := range values {
+				values[j] = "null"
+				if j < len(row) && row[j] != nil {
+					args = append(args, *row[j])
+					values[j] = fmt.Sprintf("cast(%s as %s)", db_client.Placeholder(backendName, nextArg+len(args)-1), textType)
+				}
+			}
+			rows[i] = fmt.Sprintf("%s(%s)", rowPrefix, strings.Join(values, ", "))
+		}
+		body = "values " + strings.Join(rows, ", ")
+	}
+	return fmt.Sprintf("%s(%s) as (%s)", d.TableName(), strings.Join(columns, ", "), body), args
+}
+
+// withDatasets adds a common table expression to the sql for each dataset it references, returning the sql and its
+// args - the args of the sql, with the values of the datasets
+// the values of the datasets follow the args of the sql, except for MySQL, whose positional parameters must be in
+// the order of the sql, where the datasets come first
+func withDatasets(backendName, sql string, args []any, datasets map[string]*Dataset) (string, []any) {
+	var ctes []string
+	var datasetArgs []any
+	for _, name := range sortedDatasetNames(datasets) {
+		dataset := datasets[name]
+		if regexp.MustCompile(`(?i)\b` + dataset.TableName() + `\b`).MatchString(sql) {
+			cte, cteArgs := dataset.commonTableExpression(backendName, len(args)+len(datasetArgs)+1)
+			ctes = append(ctes, cte)
+			datasetArgs = append(datasetArgs, cteArgs...)
+		}
+	}
+	if len(ctes) == 0 {
+		return sql, args
+	}
+	if backendName == constants.MySQLBackendName {
+		args = append(datasetArgs, args...)
+	} else {
+		args = append(slices.Clone(args), datasetArgs...)
+	}
+
+	// if the query already has common table expressions, add ours to the start of the list
+	if loc := withQueryRegex.FindStringIndex(sql); loc != nil {
+		return fmt.Sprintf("%s%s, %s", sql[:loc[1]], strings.Join(ctes, ", "), sql[loc[1]:]), args
+	}
+	return fmt.Sprintf("with %s %s", strings.Join(ctes, ", "), sql), args
+}
+
+func sortedDatasetNames(datasets map[string]*Dataset) []string {
+	names := make([]string, 0, len(datasets))
+	for name := range datasets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetDataset adds (or replaces) a dataset for the session - it is available to executions started after this call
+func (e *DashboardExecutor) SetDataset(sessionId string, dataset *Dataset) {
+	e.datasetLock.Lock()
+	defer e.datasetLock.Unlock()
+
+	if e.datasets[sessionId] == nil {
+		e.datasets[sessionId] = make(map[string]*Dataset)
+	}
+	e.datasets[sessionId][dataset.Name] = dataset
+}
+
+// ClearDatasets removes all datasets for the session
+func (e *DashboardExecutor) ClearDatasets(sessionId string) {
+	e.datasetLock.Lock()
+	defer e.datasetLock.Unlock()
+
+	delete(e.datasets, sessionId)
+}
+
+// getDatasets returns a copy of the datasets for the session
+func (e *DashboardExecutor) getDatasets(sessionId string) map[string]*Dataset {
+	e.datasetLock.Lock()
+	defer e.datasetLock.Unlock()
+
+	res := make(map[string]*Dataset, len(e.datasets[sessionId]))
+	for name, dataset := range e.datasets[sessionId] {
+		res[name] = dataset
+	}
+	return res
+}
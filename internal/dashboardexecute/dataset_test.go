@@ -0,0 +1,137 @@
+package dashboardexecute
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/constants"
+)
+
+type withDatasetsTest struct {
+	backend  string
+	name     string
+	format   string
+	content  string
+	sql      string
+	args     []any
+	expected string
+	// the expected args of the sql
+	expectedArgs []any
+	err          bool
+}
+
+var testCasesWithDatasets = map[string]withDatasetsTest{
+	"csv": {
+		name:         "exceptions",
+		format:       "csv",
+		content:      "arn,reason\narn:a,o'brien\narn:b,\n",
+		sql:          "select * from t where arn not in (select arn from upload_exceptions)",
+		expected:     `with upload_exceptions("arn", "reason") as (values (cast($1 as text), cast($2 as text)), (cast($3 as text), cast($4 as text))) select * from t where arn not in (select arn from upload_exceptions)`,
+		expectedArgs: []any{"arn:a", "o'brien", "arn:b", ""},
+	},
+	"json": {
+		name:         "Owners",
+		format:       "json",
+		content:      `[{"id": 1, "owner": "a"}, {"id": 2}]`,
+		sql:          "select * from upload_owners",
+		expected:     `with upload_owners("id", "owner") as (values (cast($1 as text), cast($2 as text)), (cast($3 as text), null)) select * from upload_owners`,
+		expectedArgs: []any{"1", "a", "2"},
+	},
+	"existing ctes": {
+		name:         "exceptions",
+		format:       "csv",
+		content:      "arn\narn:a\n",
+		sql:          "WITH RECURSIVE x as (select 1) select * from x, upload_exceptions",
+		expected:     `WITH RECURSIVE upload_exceptions("arn") as (values (cast($1 as text))), x as (select 1) select * from x, upload_exceptions`,
+		expectedArgs: []any{"arn:a"},
+	},
+	"query args": {
+		name:         "exceptions",
+		format:       "csv",
+		content:      "arn\narn:a\n",
+		sql:          "select * from t where region = $1 and arn not in (select arn from upload_exceptions)",
+		args:         []any{"us-east-1"},
+		expected:     `with upload_exceptions("arn") as (values (cast($2 as text))) select * from t where region = $1 and arn not in (select arn from upload_exceptions)`,
+		expectedArgs: []any{"us-east-1", "arn:a"},
+	},
+	"mysql": {
+		backend:      constants.MySQLBackendName,
+		name:         "exceptions",
+		format:       "csv",
+		content:      "arn,reason\narn:a,\\' or 1=1 -- \n",
+		sql:          "select * from t where region = ? and arn not in (select arn from upload_exceptions)",
+		args:         []any{"us-east-1"},
+		expected:     "with upload_exceptions(`arn`, `reason`) as (values row(cast(? as char), cast(? as char))) select * from t where region = ? and arn not in (select arn from upload_exceptions)",
+		expectedArgs: []any{"arn:a", `\' or 1=1 -- `, "us-east-1"},
+	},
+	"no rows": {
+		name:     "exceptions",
+		format:   "csv",
+		content:  "arn,reason\n",
+		sql:      "select * from upload_exceptions",
+		expected: `with upload_exceptions("arn", "reason") as (select null, null where 1 = 0) select * from upload_exceptions`,
+	},
+	"not referenced": {
+		name:     "exceptions",
+		format:   "csv",
+		content:  "arn\narn:a\n",
+		sql:      "select * from upload_exceptions_2",
+		expected: "select * from upload_exceptions_2",
+	},
+	"invalid name": {
+		name:    "exceptions; drop table t",
+		format:  "csv",
+		content: "arn\n",
+		err:     true,
+	},
+	"too large": {
+		name:    "exceptions",
+		format:  "csv",
+		content: "arn\n" + strings.Repeat("arn:aws:s3:::bucket\n", MaxDatasetSize/20),
+		err:     true,
+	},
+	"invalid format": {
+		name:    "exceptions",
+		format:  "xml",
+		content: "<arn/>",
+		err:     true,
+	},
+	"invalid json": {
+		name:    "exceptions",
+		format:  "json",
+		content: `{"arn": "a"}`,
+		err:     true,
+	},
+}
+
+func TestWithDatasets(t *testing.T) {
+	for name, test := range testCasesWithDatasets {
+		dataset, err := ParseDataset(test.name, test.format, []byte(test.content))
+		if test.err {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		backend := test.backend
+		if backend == "" {
+			backend = constants.DuckDBBackendName
+		}
+		actual, args := withDatasets(backend, test.sql, test.args, map[string]*Dataset{dataset.Name: dataset})
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, actual)
+		}
+		expectedArgs := test.expectedArgs
+		if expectedArgs == nil {
+			expectedArgs = test.args
+		}
+		if !reflect.DeepEqual(args, expectedArgs) {
+			t.Errorf("Test: '%s' FAILED : expected args %q, got %q", name, expectedArgs, args)
+		}
+	}
+}
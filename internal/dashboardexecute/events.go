@@ -0,0 +1,43 @@
+package dashboardexecute
+
+import (
+	"time"
+
+	"github.com/turbot/powerpipe/internal/eventbus"
+)
+
+// the run events of benchmarks are published by their control execution tree, so are only published here for
+// dashboards (and other resources) - see controlexecute.ExecutionTree
+
+// publishRunStarted publishes the run_started event of the execution
+func (e *DashboardExecutionTree) publishRunStarted() {
+	if !eventbus.Enabled() || e.isCheckRun() {
+		return
+	}
+	eventbus.Publish(eventbus.NewEvent(eventbus.EventRunStarted, e.dashboardName, map[string]any{
+		"kind":         "dashboard",
+		"execution_id": e.id,
+	}))
+}
+
+// publishRunFinished publishes the run_finished event of the execution
+func (e *DashboardExecutionTree) publishRunFinished(startTime time.Time) {
+	if !eventbus.Enabled() || e.isCheckRun() {
+		return
+	}
+	data := map[string]any{
+		"kind":         "dashboard",
+		"execution_id": e.id,
+		"status":       e.GetRunStatus(),
+		"duration":     time.Since(startTime).Round(time.Millisecond).String(),
+	}
+	if err := e.Root.GetError(); err != nil {
+		data["error"] = err.Error()
+	}
+	eventbus.Publish(eventbus.NewEvent(eventbus.EventRunFinished, e.dashboardName, data))
+}
+
+func (e *DashboardExecutionTree) isCheckRun() bool {
+	_, ok := e.Root.(*CheckRun)
+	return ok
+}
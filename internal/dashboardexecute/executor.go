@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/turbot/powerpipe/internal/db_client"
+	"log/slog"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -17,6 +19,7 @@ import (
 	"github.com/turbot/powerpipe/internal/dashboardevents"
 	"github.com/turbot/powerpipe/internal/dashboardtypes"
 	"github.com/turbot/powerpipe/internal/dashboardworkspace"
+	"github.com/turbot/powerpipe/internal/snapshot"
 )
 
 type DashboardExecutor struct {
@@ -30,14 +33,21 @@ type DashboardExecutor struct {
 	// store the default client which is created during initData creation
 	// - this is to avoid creating a new client for each dashboard execution if the database/search path is NOT overridden
 	defaultClient *db_client.ClientMap
+	// map of uploaded datasets, keyed by session id and then dataset name
+	datasets    map[string]map[string]*Dataset
+	datasetLock sync.Mutex
+	// limits the number of panel queries executing at once across all executions
+	panelLimiter *panelLimiter
 }
 
 func NewDashboardExecutor(defaultClient *db_client.ClientMap) *DashboardExecutor {
 	return &DashboardExecutor{
 		executions: make(map[string]*DashboardExecutionTree),
+		datasets:   make(map[string]map[string]*Dataset),
 		// default to interactive execution
 		interactive:   true,
 		defaultClient: defaultClient,
+		panelLimiter:  newGlobalPanelLimiter(),
 	}
 }
 
@@ -72,12 +82,25 @@ func (e *DashboardExecutor) ExecuteDashboard(ctx context.Context, sessionId stri
 	if err != nil {
 		return err
 	}
+	executionTree.initiator = RunInitiatorFromContext(ctx)
+	executionTree.datasets = e.getDatasets(sessionId)
+	executionTree.globalPanelLimiter = e.panelLimiter
 
 	// if inputs must be provided before execution (i.e. this is a batch dashboard execution),
 	// verify all required inputs are provided
 	if err = e.validateInputs(executionTree, inputs); err != nil {
 		return err
 	}
+	// batch executions use the default time range, unless one is provided
+	if !e.interactive {
+		if inputs, err = executionTree.setTimeRangeDefaults(inputs); err != nil {
+			return err
+		}
+	}
+
+	// the refresh context must be set before the execution is added to the map, as it may then be cancelled
+	refreshCtx, stopRefreshing := context.WithCancel(ctx)
+	executionTree.stopRefreshing = stopRefreshing
 
 	// add to execution map
 	e.setExecution(sessionId, executionTree)
@@ -87,7 +110,14 @@ func (e *DashboardExecutor) ExecuteDashboard(ctx context.Context, sessionId stri
 		executionTree.SetInputValues(inputs)
 	}
 
-	go executionTree.Execute(ctx)
+	// for interactive executions, once the execution is complete, keep its panels refreshed
+	// (until the execution is cancelled)
+	go func() {
+		executionTree.Execute(ctx)
+		if e.interactive && executionTree.GetRunStatus() == dashboardtypes.RunComplete {
+			executionTree.keepRefreshed(refreshCtx)
+		}
+	}()
 
 	return nil
 }
@@ -137,6 +167,10 @@ func (e *DashboardExecutor) LoadSnapshot(ctx context.Context, sessionId, snapsho
 	if err != nil {
 		return nil, err
 	}
+	// show the annotations of the findings of the snapshot in their control results
+	if err := snapshot.ApplyAnnotations(snap); err != nil {
+		slog.Warn("failed to apply snapshot annotations", "snapshot", snapshotName, "error", err)
+	}
 
 	return snap, nil
 }
@@ -177,21 +211,17 @@ func (e *DashboardExecutor) OnInputChanged(ctx context.Context, sessionId string
 	return nil
 }
 
+// clearDependentInputs clears the values of all inputs which depend (directly or transitively) on the changed input
+// - their option queries will be re-executed using the new value of the changed input
+// returns the names of all dependent inputs
 func (e *DashboardExecutor) clearDependentInputs(root dashboardtypes.DashboardTreeRun, changedInput string, inputs map[string]any) []string {
-	dependentInputs := root.GetInputsDependingOn(changedInput)
-	clearedInputs := dependentInputs
-	if len(dependentInputs) > 0 {
-		for _, inputName := range dependentInputs {
-			if inputs[inputName] != nil {
-				// clear the input value
-				inputs[inputName] = nil
-				childDependentInputs := e.clearDependentInputs(root, inputName, inputs)
-				clearedInputs = append(clearedInputs, childDependentInputs...)
-			}
+	dependentInputs := getDependentInputs(changedInput, root.GetInputsDependingOn)
+	for _, inputName := range dependentInputs {
+		if _, ok := inputs[inputName]; ok {
+			inputs[inputName] = nil
 		}
 	}
-
-	return clearedInputs
+	return dependentInputs
 }
 
 func (e *DashboardExecutor) CancelExecutionForSession(_ context.Context, sessionId string) {
@@ -208,6 +238,69 @@ func (e *DashboardExecutor) CancelExecutionForSession(_ context.Context, session
 	e.removeExecution(sessionId)
 }
 
+// ListRuns returns details of all in-flight executions
+func (e *DashboardExecutor) ListRuns() []RunInfo {
+	e.executionLock.Lock()
+	defer e.executionLock.Unlock()
+
+	res := make([]RunInfo, 0, len(e.executions))
+	for _, executionTree := range e.executions {
+		// only include executions which are still in progress
+		if executionTree.Root != nil && executionTree.GetRunStatus().IsFinished() {
+			continue
+		}
+		res = append(res, newRunInfo(executionTree))
+	}
+	// order by start time, oldest first
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].StartTime.Before(res[j].StartTime)
+	})
+	return res
+}
+
+// RunForSession returns details of the execution for the given session (if any)
+func (e *DashboardExecutor) RunForSession(sessionId string) (RunInfo, bool) {
+	executionTree, found := e.getExecution(sessionId)
+	if !found {
+		return RunInfo{}, false
+	}
+	return newRunInfo(executionTree), true
+}
+
+// CancelRun cancels the execution with the given run id
+// it returns false if no execution with this id exists
+func (e *DashboardExecutor) CancelRun(ctx context.Context, runId string) (RunInfo, bool) {
+	sessionId, executionTree, found := e.getExecutionByRunId(runId)
+	if !found {
+		return RunInfo{}, false
+	}
+	slog.Info("Cancelling run", "run_id", runId, "session", sessionId)
+	e.CancelExecutionForSession(ctx, sessionId)
+
+	return newRunInfo(executionTree), true
+}
+
+// GetRun returns details of the execution with the given run id (if any)
+func (e *DashboardExecutor) GetRun(runId string) (RunInfo, bool) {
+	_, executionTree, found := e.getExecutionByRunId(runId)
+	if !found {
+		return RunInfo{}, false
+	}
+	return newRunInfo(executionTree), true
+}
+
+func (e *DashboardExecutor) getExecutionByRunId(runId string) (string, *DashboardExecutionTree, bool) {
+	e.executionLock.Lock()
+	defer e.executionLock.Unlock()
+
+	for sessionId, executionTree := range e.executions {
+		if executionTree.id == runId {
+			return sessionId, executionTree, true
+		}
+	}
+	return "", nil, false
+}
+
 // find the execution for the given session id
 func (e *DashboardExecutor) getExecution(sessionId string) (*DashboardExecutionTree, bool) {
 	e.executionLock.Lock()
@@ -0,0 +1,60 @@
+package dashboardexecute
+
+import (
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+// the key used for the resolved href of resources which have a single href (cards and nodes)
+const hrefKey = "href"
+
+// resolveHrefs populates the resolved hrefs for each row of our data
+// templates are resolved server-side (see dashboardtypes.ResolveHrefTemplate), so links also work in snapshots and exports
+func (r *LeafRun) resolveHrefs() {
+	if r.Data == nil {
+		return
+	}
+	templates := getHrefTemplates(r.resource)
+	if len(templates) == 0 {
+		return
+	}
+	r.Data.Hrefs = resolveHrefTemplates(templates, r.Data.Rows)
+}
+
+// getHrefTemplates returns the href templates for a resource, keyed by the column name for tables,
+// or by 'href' for cards and nodes
+func getHrefTemplates(resource modconfig.DashboardLeafNode) map[string]string {
+	res := make(map[string]string)
+	switch r := resource.(type) {
+	case *modconfig.DashboardCard:
+		if r.HREF != nil {
+			res[hrefKey] = *r.HREF
+		}
+	case *modconfig.DashboardTable:
+		for name, column := range r.Columns {
+			if column.HREF != nil {
+				res[name] = *column.HREF
+			}
+		}
+	case *modconfig.DashboardNode:
+		if r.Category != nil && r.Category.HREF != nil {
+			res[hrefKey] = *r.Category.HREF
+		}
+	}
+	return res
+}
+
+// resolveHrefTemplates resolves each template for each row - any template which cannot be resolved is omitted
+func resolveHrefTemplates(templates map[string]string, rows []map[string]any) []map[string]string {
+	res := make([]map[string]string, len(rows))
+	for i, row := range rows {
+		rowHrefs := make(map[string]string, len(templates))
+		for key, template := range templates {
+			if href, ok := dashboardtypes.ResolveHrefTemplate(template, row); ok {
+				rowHrefs[key] = href
+			}
+		}
+		res[i] = rowHrefs
+	}
+	return res
+}
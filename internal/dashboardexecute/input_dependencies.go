@@ -0,0 +1,94 @@
+package dashboardexecute
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// getInputDependencies returns a map of input name to the names of the inputs it directly depends on,
+// i.e. the inputs whose value is used by the input's option query
+func (r *DashboardRun) getInputDependencies() map[string][]string {
+	res := make(map[string][]string)
+	for _, input := range r.dashboard.Inputs {
+		for _, other := range r.dashboard.Inputs {
+			if input.DependsOnInput(other.UnqualifiedName) {
+				res[input.UnqualifiedName] = append(res[input.UnqualifiedName], other.UnqualifiedName)
+			}
+		}
+	}
+	for _, deps := range res {
+		sort.Strings(deps)
+	}
+	return res
+}
+
+// getDependentInputs returns all inputs which depend (directly or transitively) on the given input,
+// in breadth first order - so inputs closest to the changed input are first
+func getDependentInputs(changedInput string, getDirectDependents func(string) []string) []string {
+	var res []string
+	visited := map[string]struct{}{changedInput: {}}
+	queue := []string{changedInput}
+	for len(queue) > 0 {
+		inputName := queue[0]
+		queue = queue[1:]
+		for _, dependent := range getDirectDependents(inputName) {
+			if _, ok := visited[dependent]; ok {
+				continue
+			}
+			visited[dependent] = struct{}{}
+			res = append(res, dependent)
+			queue = append(queue, dependent)
+		}
+	}
+	return res
+}
+
+// validateInputDependencies returns an error if the input dependencies contain a cycle
+// (e.g. input.a depends on input.b, which depends on input.a) - such inputs could never be resolved
+func validateInputDependencies(dependencies map[string][]string) error {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int)
+	var path []string
+
+	var visit func(string) error
+	visit = func(inputName string) error {
+		switch state[inputName] {
+		case visited:
+			return nil
+		case visiting:
+			// build the cycle from the first occurrence of this input in the path
+			for i, p := range path {
+				if p == inputName {
+					return fmt.Errorf("inputs have a circular dependency: %s", strings.Join(append(path[i:], inputName), " -> "))
+				}
+			}
+		}
+		state[inputName] = visiting
+		path = append(path, inputName)
+		for _, dep := range dependencies[inputName] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[inputName] = visited
+		return nil
+	}
+
+	// visit in sorted order for a consistent error message
+	names := make([]string, 0, len(dependencies))
+	for name := range dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
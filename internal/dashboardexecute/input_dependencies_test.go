@@ -0,0 +1,89 @@
+package dashboardexecute
+
+import (
+	"reflect"
+	"testing"
+)
+
+type getDependentInputsTest struct {
+	dependents   map[string][]string
+	changedInput string
+	expected     []string
+}
+
+var testCasesGetDependentInputs = map[string]getDependentInputsTest{
+	"no dependents": {
+		dependents:   map[string][]string{},
+		changedInput: "input.region",
+	},
+	"cascade": {
+		dependents: map[string][]string{
+			"input.region": {"input.vpc"},
+			"input.vpc":    {"input.subnet"},
+		},
+		changedInput: "input.region",
+		expected:     []string{"input.vpc", "input.subnet"},
+	},
+	"shared dependent": {
+		dependents: map[string][]string{
+			"input.account": {"input.region", "input.vpc"},
+			"input.region":  {"input.vpc"},
+		},
+		changedInput: "input.account",
+		expected:     []string{"input.region", "input.vpc"},
+	},
+	"cycle": {
+		dependents: map[string][]string{
+			"input.a": {"input.b"},
+			"input.b": {"input.a"},
+		},
+		changedInput: "input.a",
+		expected:     []string{"input.b"},
+	},
+}
+
+func TestGetDependentInputs(t *testing.T) {
+	for name, test := range testCasesGetDependentInputs {
+		actual := getDependentInputs(test.changedInput, func(inputName string) []string {
+			return test.dependents[inputName]
+		})
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
+
+type validateInputDependenciesTest struct {
+	dependencies map[string][]string
+	expected     string
+}
+
+var testCasesValidateInputDependencies = map[string]validateInputDependenciesTest{
+	"valid": {
+		dependencies: map[string][]string{
+			"input.vpc":    {"input.region"},
+			"input.subnet": {"input.region", "input.vpc"},
+		},
+	},
+	"cycle": {
+		dependencies: map[string][]string{
+			"input.a": {"input.b"},
+			"input.b": {"input.c"},
+			"input.c": {"input.a"},
+		},
+		expected: "inputs have a circular dependency: input.a -> input.b -> input.c -> input.a",
+	},
+}
+
+func TestValidateInputDependencies(t *testing.T) {
+	for name, test := range testCasesValidateInputDependencies {
+		err := validateInputDependencies(test.dependencies)
+		actual := ""
+		if err != nil {
+			actual = err.Error()
+		}
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, actual)
+		}
+	}
+}
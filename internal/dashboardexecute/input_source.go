@@ -0,0 +1,238 @@
+package dashboardexecute
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/queryresult"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+)
+
+// inputs with no sql (which must therefore have a placeholder) may source their options from an http endpoint
+// or an external command, e.g.
+//
+//	input "team" {
+//	  type        = "select"
+//	  placeholder = "Select a team"
+//	  tags = {
+//	    options_url       = "https://catalog.internal/api/teams"
+//	    options_cache_ttl = "10m"
+//	  }
+//	}
+//
+// the source must return a JSON array - each item is either a string, or an object with a value and
+// optional label and tags, e.g. [{"label": "Platform", "value": "platform", "tags": {"owner": "jane"}}]
+//
+// options_command is run directly (not using a shell), with its arguments separated by whitespace - as this runs a
+// command of the mod on the local machine, it is only allowed if the --allow-input-commands flag is set
+// results are cached for options_cache_ttl (default 5m) - a ttl of 0 disables caching
+const (
+	TagInputOptionsUrl      = "options_url"
+	TagInputOptionsCommand  = "options_command"
+	TagInputOptionsCacheTtl = "options_cache_ttl"
+
+	defaultInputSourceCacheTtl = 5 * time.Minute
+	inputSourceTimeout         = 30 * time.Second
+	maxInputSourceBytes        = 10 * 1024 * 1024
+)
+
+type inputSourceCacheEntry struct {
+	data    *dashboardtypes.LeafData
+	expires time.Time
+}
+
+var (
+	inputSourceCache     = make(map[string]inputSourceCacheEntry)
+	inputSourceCacheLock sync.Mutex
+)
+
+// inputSource describes where an input sources its options
+type inputSource struct {
+	url      string
+	command  string
+	cacheTtl time.Duration
+	// the client of url requests
+	client *http.Client
+}
+
+// getInputSource returns the options source for the resource, or nil if it is not an input with a source
+func getInputSource(resource modconfig.DashboardLeafNode) (*inputSource, error) {
+	input, ok := resource.(*modconfig.DashboardInput)
+	if !ok {
+		return nil, nil
+	}
+	tags := input.GetTags()
+	source := &inputSource{
+		url:      strings.TrimSpace(tags[TagInputOptionsUrl]),
+		command:  strings.TrimSpace(tags[TagInputOptionsCommand]),
+		cacheTtl: defaultInputSourceCacheTtl,
+		client:   tlspolicy.HTTPClient(),
+	}
+	switch {
+	case source.url == "" && source.command == "":
+		return nil, nil
+	case source.url != "" && source.command != "":
+		return nil, fmt.Errorf("%s: only one of the '%s' and '%s' tags may be set", input.Name(), TagInputOptionsUrl, TagInputOptionsCommand)
+	case input.SQL != nil || input.Query != nil:
+		return nil, fmt.Errorf("%s: the '%s' and '%s' tags may not be used with sql or query", input.Name(), TagInputOptionsUrl, TagInputOptionsCommand)
+	case source.command != "" && !viper.GetBool(localconstants.ArgAllowInputCommands):
+		return nil, fmt.Errorf("%s: the '%s' tag runs a local command, and requires the --%s flag", input.Name(), TagInputOptionsCommand, localconstants.ArgAllowInputCommands)
+	}
+	if ttl, ok := tags[TagInputOptionsCacheTtl]; ok {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("%s: invalid '%s' tag '%s' - must be a duration, e.g. 10m", input.Name(), TagInputOptionsCacheTtl, ttl)
+		}
+		source.cacheTtl = d
+	}
+	return source, nil
+}
+
+func (s *inputSource) cacheKey() string {
+	if s.url != "" {
+		return "url:" + s.url
+	}
+	return "command:" + s.command
+}
+
+// executeInputSource sets the data for an input which sources its options from an http endpoint or command
+func (r *LeafRun) executeInputSource(ctx context.Context) error {
+	source, err := getInputSource(r.resource)
+	if err != nil || source == nil {
+		return err
+	}
+
+	data, err := source.getOptions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load options for %s: %w", r.resource.Name(), err)
+	}
+	r.Data = data
+	return nil
+}
+
+// getOptions returns the options from the cache, if present and not expired, otherwise loads them from the source
+func (s *inputSource) getOptions(ctx context.Context) (*dashboardtypes.LeafData, error) {
+	key := s.cacheKey()
+	if s.cacheTtl > 0 {
+		inputSourceCacheLock.Lock()
+		entry, ok := inputSourceCache[key]
+		inputSourceCacheLock.Unlock()
+		if ok && time.Now().Before(entry.expires) {
+			slog.Debug("using cached input options", "source", key)
+			return entry.data, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, inputSourceTimeout)
+	defer cancel()
+
+	var content []byte
+	var err error
+	if s.url != "" {
+		content, err = s.fetchUrl(ctx)
+	} else {
+		content, err = s.runCommand(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := parseInputOptions(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cacheTtl > 0 {
+		inputSourceCacheLock.Lock()
+		inputSourceCache[key] = inputSourceCacheEntry{data: data, expires: time.Now().Add(s.cacheTtl)}
+		inputSourceCacheLock.Unlock()
+	}
+	return data, nil
+}
+
+func (s *inputSource) fetchUrl(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s returned status %s", s.url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxInputSourceBytes))
+}
+
+func (s *inputSource) runCommand(ctx context.Context) ([]byte, error) {
+	args := strings.Fields(s.command)
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("'%s' failed: %w: %s", s.command, err, msg)
+		}
+		return nil, fmt.Errorf("'%s' failed: %w", s.command, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// parseInputOptions converts a JSON array of options into input data, with label, value and tags columns
+func parseInputOptions(content []byte) (*dashboardtypes.LeafData, error) {
+	var items []any
+	if err := json.Unmarshal(content, &items); err != nil {
+		return nil, fmt.Errorf("options must be a JSON array: %w", err)
+	}
+
+	data := &dashboardtypes.LeafData{
+		Columns: []*queryresult.ColumnDef{
+			{Name: "label", DataType: "TEXT"},
+			{Name: "value", DataType: "TEXT"},
+			{Name: "tags", DataType: "JSONB"},
+		},
+		Rows: make([]map[string]any, len(items)),
+	}
+	for i, item := range items {
+		row := map[string]any{"tags": map[string]any{}}
+		switch v := item.(type) {
+		case string:
+			row["label"] = v
+			row["value"] = v
+		case map[string]any:
+			value, ok := v["value"]
+			if !ok || value == nil {
+				return nil, fmt.Errorf("option %d has no value", i)
+			}
+			row["value"] = value
+			row["label"] = v["label"]
+			if row["label"] == nil {
+				row["label"] = fmt.Sprintf("%v", value)
+			}
+			if tags, ok := v["tags"].(map[string]any); ok {
+				row["tags"] = tags
+			}
+		default:
+			return nil, fmt.Errorf("option %d must be a string or an object", i)
+		}
+		data.Rows[i] = row
+	}
+	return data, nil
+}
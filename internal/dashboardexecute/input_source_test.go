@@ -0,0 +1,128 @@
+package dashboardexecute
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/modconfig"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+)
+
+func newTestInput(tags map[string]string) *modconfig.DashboardInput {
+	i := &modconfig.DashboardInput{}
+	i.FullName = "dashboard.d.input.team"
+	i.Tags = tags
+	return i
+}
+
+type getInputSourceTest struct {
+	tags          map[string]string
+	allowCommands bool
+	expected      *inputSource
+	err           bool
+}
+
+var testCasesGetInputSource = map[string]getInputSourceTest{
+	"no source": {
+		tags: map[string]string{"service": "aws"},
+	},
+	"url": {
+		tags:     map[string]string{TagInputOptionsUrl: "https://catalog.internal/api/teams", TagInputOptionsCacheTtl: "10m"},
+		expected: &inputSource{url: "https://catalog.internal/api/teams", cacheTtl: 10 * time.Minute, client: tlspolicy.HTTPClient()},
+	},
+	"command": {
+		tags:          map[string]string{TagInputOptionsCommand: "list-teams --json"},
+		allowCommands: true,
+		expected:      &inputSource{command: "list-teams --json", cacheTtl: defaultInputSourceCacheTtl, client: tlspolicy.HTTPClient()},
+	},
+	"command not allowed": {
+		tags: map[string]string{TagInputOptionsCommand: "list-teams --json"},
+		err:  true,
+	},
+	"url and command": {
+		tags:          map[string]string{TagInputOptionsUrl: "https://catalog.internal/api/teams", TagInputOptionsCommand: "list-teams"},
+		allowCommands: true,
+		err:           true,
+	},
+	"invalid ttl": {
+		tags: map[string]string{TagInputOptionsUrl: "https://catalog.internal/api/teams", TagInputOptionsCacheTtl: "soon"},
+		err:  true,
+	},
+}
+
+func TestGetInputSource(t *testing.T) {
+	defer viper.Set(localconstants.ArgAllowInputCommands, false)
+	for name, test := range testCasesGetInputSource {
+		viper.Set(localconstants.ArgAllowInputCommands, test.allowCommands)
+		source, err := getInputSource(newTestInput(test.tags))
+		if test.err {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		if (source == nil) != (test.expected == nil) || (source != nil && *source != *test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %+v, got %+v", name, test.expected, source)
+		}
+	}
+}
+
+type parseInputOptionsTest struct {
+	content  string
+	expected string
+	err      bool
+}
+
+var testCasesParseInputOptions = map[string]parseInputOptionsTest{
+	"strings": {
+		content:  `["platform", "security"]`,
+		expected: `[{"label":"platform","tags":{},"value":"platform"},{"label":"security","tags":{},"value":"security"}]`,
+	},
+	"objects": {
+		content:  `[{"label": "Platform", "value": "platform", "tags": {"owner": "jane"}}, {"value": 2}]`,
+		expected: `[{"label":"Platform","tags":{"owner":"jane"},"value":"platform"},{"label":"2","tags":{},"value":2}]`,
+	},
+	"empty": {
+		content:  `[]`,
+		expected: `[]`,
+	},
+	"no value": {
+		content: `[{"label": "Platform"}]`,
+		err:     true,
+	},
+	"not an array": {
+		content: `{"value": "platform"}`,
+		err:     true,
+	},
+	"invalid item": {
+		content: `[1]`,
+		err:     true,
+	},
+}
+
+func TestParseInputOptions(t *testing.T) {
+	for name, test := range testCasesParseInputOptions {
+		data, err := parseInputOptions([]byte(test.content))
+		if test.err {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		actual, _ := json.Marshal(data.Rows)
+		if string(actual) != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, string(actual))
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"golang.org/x/exp/maps"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/turbot/pipe-fittings/backend"
@@ -30,9 +31,21 @@ type LeafRun struct {
 	Data       *dashboardtypes.LeafData `json:"data,omitempty"`
 	// function called when the run is complete
 	// this property populated for 'with' runs
-	onComplete       func()
+	onComplete func()
+	// closed when a 'with' run is complete
+	withComplete     chan struct{}
 	database         string
 	searchPathConfig backend.SearchPathConfig
+	// the results of the dashboard 'with' blocks referenced by our sql, keyed by table name
+	sharedDatasets map[string]*Dataset
+	// if our sql uses the time range macros, the value of the dashboard time range input is published to this channel
+	timeRangeChan    chan *dashboardtypes.ResolvedRuntimeDependencyValue
+	timeRangeDefault string
+	timeRange        *TimeRange
+	timeFrom         time.Time
+	timeTo           time.Time
+	// held while the run is refreshed or retried after execution
+	refreshLock sync.Mutex
 }
 
 func (r *LeafRun) AsTreeNode() *steampipeconfig.SnapshotTreeNode {
@@ -75,6 +88,22 @@ func NewLeafRun(resource modconfig.DashboardLeafNode, parent dashboardtypes.Dash
 			return nil, err
 		}
 	}
+	// text panels may use the results of a query in their template
+	if err := r.resolveTextQuery(); err != nil {
+		return nil, err
+	}
+	// validate the options source of an input
+	if _, err := getInputSource(resource); err != nil {
+		return nil, err
+	}
+	// validate the default of a time range input
+	if _, err := getTimeRangeDefault(resource); err != nil {
+		return nil, err
+	}
+	// if our sql uses the time range macros, we must wait for the time range input
+	if err := r.subscribeToTimeRange(); err != nil {
+		return nil, err
+	}
 	// add r into execution tree
 	executionTree.runs[r.Name] = r
 
@@ -106,6 +135,7 @@ func (r *LeafRun) resolveDatabaseConfig() error {
 	if c, ok := r.resource.(modconfig.DatabaseItem); ok {
 		if resourceDatabase := c.GetDatabase(); resourceDatabase != nil {
 			database = *resourceDatabase
+			searchPathConfig = db_client.SearchPathConfigForDatabase(database)
 		}
 		if resourceSearchPath := c.GetSearchPath(); len(resourceSearchPath) > 0 {
 			searchPathConfig.SearchPath = resourceSearchPath
@@ -171,6 +201,10 @@ func (r *LeafRun) Execute(ctx context.Context) {
 		r.SetError(ctx, err)
 		return
 	}
+	if err := r.waitForTimeRange(ctx); err != nil {
+		r.SetError(ctx, err)
+		return
+	}
 
 	// set status to running (this sends update event)
 	// (if we have blocked children, this will be changed to blocked)
@@ -184,6 +218,13 @@ func (r *LeafRun) Execute(ctx context.Context) {
 			return
 		}
 	}
+	// an input may source its options from an http endpoint or command
+	if err := r.executeInputSource(ctx); err != nil {
+		r.SetError(ctx, err)
+		return
+	}
+	// resolve any templates in a text panel value
+	r.renderTextTemplate()
 
 	// wait for all children and withs
 	err := <-doneChan
@@ -237,8 +278,33 @@ func (r *LeafRun) executeQuery(ctx context.Context) error {
 		return err
 	}
 
+	// for paged tables, only retrieve the first page
+	executeSQL := r.executeSQL
+	var pageRequest *dashboardtypes.TablePageRequest
+	if r.isPagedTable() {
+		pageRequest = &dashboardtypes.TablePageRequest{PageSize: tablePageSize()}
+		executeSQL, _, err = buildTablePageSQL(client.Backend.Name(), r.executeSQL, len(r.Args), nil, pageRequest)
+		if err != nil {
+			return err
+		}
+	}
+
+	// wait for any shared datasets we reference
+	if err := r.resolveSharedDatasets(ctx); err != nil {
+		return err
+	}
+	r.setTimeRangeBounds()
+	executeSQL, args := r.expandSQL(client.Backend.Name(), executeSQL, r.Args)
+
+	// wait until the panel concurrency limits allow the query to execute
+	release, err := r.executionTree.acquirePanelSlot(ctx, r.Name)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	startTime := time.Now()
-	queryResult, err := client.ExecuteSync(ctx, r.executeSQL, r.Args...)
+	queryResult, err := client.ExecuteSync(ctx, executeSQL, args...)
 	if err != nil {
 		if err.Error() == context.DeadlineExceeded.Error() {
 			err = fmt.Errorf("query execution timed out after running for %0.2fs", time.Since(startTime).Seconds())
@@ -253,9 +319,23 @@ func (r *LeafRun) executeQuery(ctx context.Context) error {
 		return err
 
 	}
+	if pageRequest != nil {
+		setTablePage(r.Data, pageRequest)
+	}
+	// resolve any href templates for the rows
+	r.resolveHrefs()
 	return nil
 }
 
+// expandSQL substitutes the time range macros in the sql, and adds the datasets it references, returning the sql
+// and its args (the given args of the sql, with the values of the datasets)
+func (r *LeafRun) expandSQL(backendName, sql string, args []any) (string, []any) {
+	if r.timeRange != nil {
+		sql = substituteTimeRangeMacros(sql, r.timeFrom, r.timeTo)
+	}
+	return withDatasets(backendName, sql, args, r.getDatasets())
+}
+
 func (r *LeafRun) combineChildData() {
 	// we either have children OR a query
 	// if there are no children, do nothing
@@ -266,6 +346,9 @@ func (r *LeafRun) combineChildData() {
 	r.Data = &dashboardtypes.LeafData{}
 	// build map of columns for the schema
 	schemaMap := make(map[string]*queryresult.ColumnDef)
+	// hrefs must be aligned with the rows - only populate if any child has hrefs
+	var hrefs []map[string]string
+	var hasHrefs bool
 	for _, c := range r.children {
 		childLeafRun := c.(*LeafRun)
 		data := childLeafRun.Data
@@ -279,8 +362,17 @@ func (r *LeafRun) combineChildData() {
 			}
 		}
 		r.Data.Rows = append(r.Data.Rows, data.Rows...)
+		if data.Hrefs != nil {
+			hrefs = append(hrefs, data.Hrefs...)
+			hasHrefs = true
+		} else {
+			hrefs = append(hrefs, make([]map[string]string, len(data.Rows))...)
+		}
 	}
 	r.Data.Columns = maps.Values(schemaMap)
+	if hasHrefs {
+		r.Data.Hrefs = hrefs
+	}
 }
 
 func (r *LeafRun) populateProperties() error {
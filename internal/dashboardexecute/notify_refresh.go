@@ -0,0 +1,214 @@
+package dashboardexecute
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turbot/pipe-fittings/backend"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+	"golang.org/x/exp/maps"
+)
+
+// panels may be refreshed by postgres notifications, by declaring a notification channel, e.g.
+//
+//	chart "orders_by_status" {
+//	  sql  = "select status, count(*) from orders group by status"
+//	  tags = {
+//	    notify_channel = "orders_changed"
+//	  }
+//	}
+//
+// once a dashboard has executed in server mode, the server listens on the channels declared by its panels
+// (a channel declared by a dashboard or container applies to all the panels it contains), and re-executes
+// the affected panels when a notification is received, e.g. from a trigger calling pg_notify('orders_changed', 'order 42 updated')
+// notifications received within notifyRefreshDelay of each other are coalesced into a single refresh
+const (
+	TagNotifyChannel = "notify_channel"
+
+	notifyRefreshDelay   = 500 * time.Millisecond
+	notifyReconnectDelay = 5 * time.Second
+)
+
+// notifySubscription is the set of channels listened to on a database, and the panels refreshed by each channel
+type notifySubscription struct {
+	database         string
+	searchPathConfig backend.SearchPathConfig
+	// map of channel to the panels refreshed by a notification on that channel
+	panels map[string][]*LeafRun
+
+	pendingLock sync.Mutex
+	// panels awaiting refresh, keyed by name
+	pending      map[string]*LeafRun
+	pendingTimer *time.Timer
+}
+
+// parseNotifyChannels parses a (comma-separated) notify_channel tag
+func parseNotifyChannels(tag string) []string {
+	var res []string
+	for _, channel := range strings.Split(tag, ",") {
+		if channel = strings.TrimSpace(channel); channel != "" && !slices.Contains(res, channel) {
+			res = append(res, channel)
+		}
+	}
+	return res
+}
+
+// getNotifyChannels returns the notification channels declared by the run and its ancestors
+func getNotifyChannels(run dashboardtypes.DashboardTreeRun) []string {
+	var res []string
+	for run != nil {
+		if resource := run.GetResource(); resource != nil {
+			for _, channel := range parseNotifyChannels(resource.GetTags()[TagNotifyChannel]) {
+				if !slices.Contains(res, channel) {
+					res = append(res, channel)
+				}
+			}
+		}
+		run = getParentRun(run)
+	}
+	return res
+}
+
+// getNotifySubscriptions returns the notification subscriptions for the panels of the execution, keyed by database
+func (e *DashboardExecutionTree) getNotifySubscriptions() map[string]*notifySubscription {
+	res := make(map[string]*notifySubscription)
+	for _, run := range e.runs {
+		leafRun, ok := run.(*LeafRun)
+		if !ok || !leafRun.canRefresh() {
+			continue
+		}
+		channels := getNotifyChannels(leafRun)
+		if len(channels) == 0 {
+			continue
+		}
+		key := leafRun.database + leafRun.searchPathConfig.String()
+		subscription, ok := res[key]
+		if !ok {
+			subscription = &notifySubscription{
+				database:         leafRun.database,
+				searchPathConfig: leafRun.searchPathConfig,
+				panels:           make(map[string][]*LeafRun),
+				pending:          make(map[string]*LeafRun),
+			}
+			res[key] = subscription
+		}
+		for _, channel := range channels {
+			subscription.panels[channel] = append(subscription.panels[channel], leafRun)
+		}
+	}
+	return res
+}
+
+// listenForNotifications listens on the notification channels declared by the panels of the (completed) execution,
+// refreshing the affected panels when a notification is received - this continues until the context is cancelled
+func (e *DashboardExecutionTree) listenForNotifications(ctx context.Context) {
+	subscriptions := e.getNotifySubscriptions()
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, subscription := range subscriptions {
+		wg.Add(1)
+		go func(s *notifySubscription) {
+			defer wg.Done()
+			e.listen(ctx, s)
+		}(subscription)
+	}
+	wg.Wait()
+}
+
+// listen listens on the channels of the subscription, reconnecting if the connection fails
+func (e *DashboardExecutionTree) listen(ctx context.Context, s *notifySubscription) {
+	channels := maps.Keys(s.panels)
+	sort.Strings(channels)
+
+	for ctx.Err() == nil {
+		slog.Debug("listening for notifications", "dashboard", e.dashboardName, "channels", channels)
+		client, err := e.getClient(ctx, s.database, s.searchPathConfig)
+		if err == nil {
+			err = client.Listen(ctx, channels, func(channel, _ string) {
+				s.notify(ctx, channel)
+			})
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("failed listening for notifications", "dashboard", e.dashboardName, "channels", channels, "error", err)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(notifyReconnectDelay):
+		}
+	}
+}
+
+// notify schedules a refresh of the panels affected by a notification on the given channel
+func (s *notifySubscription) notify(ctx context.Context, channel string) {
+	s.pendingLock.Lock()
+	defer s.pendingLock.Unlock()
+
+	for _, panel := range s.panels[channel] {
+		s.pending[panel.Name] = panel
+	}
+	if s.pendingTimer == nil {
+		s.pendingTimer = time.AfterFunc(notifyRefreshDelay, func() {
+			s.refreshPending(ctx)
+		})
+	}
+}
+
+// refreshPending refreshes all panels awaiting refresh
+func (s *notifySubscription) refreshPending(ctx context.Context) {
+	s.pendingLock.Lock()
+	panels := s.pending
+	s.pending = make(map[string]*LeafRun)
+	s.pendingTimer = nil
+	s.pendingLock.Unlock()
+
+	for _, panel := range panels {
+		if ctx.Err() != nil {
+			return
+		}
+		panel.refresh(ctx)
+	}
+}
+
+// canRefresh returns whether this run is a panel with a query, or with child nodes and edges with queries
+// - nodes and edges are refreshed with their parent, and 'with' runs are not refreshed
+// (as this would require re-resolving the runs which depend on them)
+func (r *LeafRun) canRefresh() bool {
+	if r.NodeType == schema.BlockTypeWith {
+		return false
+	}
+	if _, isChild := r.parent.(*LeafRun); isChild {
+		return false
+	}
+	return r.executeSQL != "" || len(r.queryChildren()) > 0
+}
+
+func (r *LeafRun) queryChildren() []*LeafRun {
+	var res []*LeafRun
+	for _, c := range r.children {
+		if child, ok := c.(*LeafRun); ok && child.NodeType != schema.BlockTypeWith && (child.executeSQL != "" || len(child.queryChildren()) > 0) {
+			res = append(res, child)
+		}
+	}
+	return res
+}
+
+// refresh re-executes the query of this run and its children
+// (a panel is refreshed by both notifications and its refresh interval, so refreshes are serialised)
+func (r *LeafRun) refresh(ctx context.Context) {
+	r.refreshLock.Lock()
+	defer r.refreshLock.Unlock()
+
+	slog.Debug("LeafRun refresh", "name", r.Name)
+	r.reexecute(ctx, (*LeafRun).queryChildren)
+}
@@ -0,0 +1,39 @@
+package dashboardexecute
+
+import (
+	"strings"
+	"testing"
+)
+
+type parseNotifyChannelsTest struct {
+	tag      string
+	expected string
+}
+
+var testCasesParseNotifyChannels = map[string]parseNotifyChannelsTest{
+	"single": {
+		tag:      "orders_changed",
+		expected: "orders_changed",
+	},
+	"multiple": {
+		tag:      "orders_changed, customers_changed",
+		expected: "orders_changed,customers_changed",
+	},
+	"duplicates and empty": {
+		tag:      "orders_changed,,orders_changed ,",
+		expected: "orders_changed",
+	},
+	"empty": {
+		tag:      "",
+		expected: "",
+	},
+}
+
+func TestParseNotifyChannels(t *testing.T) {
+	for name, test := range testCasesParseNotifyChannels {
+		actual := strings.Join(parseNotifyChannels(test.tag), ",")
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package dashboardexecute
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"golang.org/x/sync/semaphore"
+)
+
+// the queries of dashboard panels are executed concurrently - so that dashboards with many panels do not overwhelm
+// small databases, the number of panel queries executing at once may be limited, both across all dashboards
+// (--max-concurrent-panels) and for each dashboard execution (--max-concurrent-panels-per-dashboard)
+//
+// panels which would exceed a limit are queued, and executed in the order they were queued as running panels complete
+
+// panelLimiter limits the number of panel queries executing at once - a nil limiter is unlimited
+type panelLimiter struct {
+	sem *semaphore.Weighted
+}
+
+func newPanelLimiter(limit int) *panelLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &panelLimiter{sem: semaphore.NewWeighted(int64(limit))}
+}
+
+// wait for a slot (or the context to be cancelled)
+func (l *panelLimiter) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	return l.sem.Acquire(ctx, 1)
+}
+
+func (l *panelLimiter) release() {
+	if l == nil {
+		return
+	}
+	l.sem.Release(1)
+}
+
+// the limit across all dashboard executions
+func newGlobalPanelLimiter() *panelLimiter {
+	return newPanelLimiter(viper.GetInt(localconstants.ArgPanelConcurrency))
+}
+
+// the limit for each dashboard execution
+func newDashboardPanelLimiter() *panelLimiter {
+	return newPanelLimiter(viper.GetInt(localconstants.ArgDashboardConcurrency))
+}
+
+// acquirePanelSlot waits until the panel may execute its query without exceeding the concurrency limits
+// it returns a function which must be called once the query completes
+func (e *DashboardExecutionTree) acquirePanelSlot(ctx context.Context, panelName string) (func(), error) {
+	if e.panelLimiter == nil && e.globalPanelLimiter == nil {
+		return func() {}, nil
+	}
+
+	slog.Debug("panel waiting for execution slot", "dashboard", e.dashboardName, "panel", panelName)
+	// acquire the dashboard slot first, so a dashboard queued for a global slot holds no more than its own limit
+	if err := e.panelLimiter.acquire(ctx); err != nil {
+		return nil, err
+	}
+	if err := e.globalPanelLimiter.acquire(ctx); err != nil {
+		e.panelLimiter.release()
+		return nil, err
+	}
+	slog.Debug("panel acquired execution slot", "dashboard", e.dashboardName, "panel", panelName)
+
+	return func() {
+		e.globalPanelLimiter.release()
+		e.panelLimiter.release()
+	}, nil
+}
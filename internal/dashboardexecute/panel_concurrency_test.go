@@ -0,0 +1,113 @@
+package dashboardexecute
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type panelConcurrencyTest struct {
+	dashboardLimit int
+	globalLimit    int
+	executions     int
+	panels         int
+	// the maximum number of panels expected to execute at once
+	expected int
+}
+
+var testCasesPanelConcurrency = map[string]panelConcurrencyTest{
+	"unlimited": {
+		executions: 2,
+		panels:     5,
+		expected:   10,
+	},
+	"dashboard limit": {
+		dashboardLimit: 2,
+		executions:     2,
+		panels:         5,
+		expected:       4,
+	},
+	"global limit": {
+		globalLimit: 3,
+		executions:  2,
+		panels:      5,
+		expected:    3,
+	},
+	"both limits": {
+		dashboardLimit: 2,
+		globalLimit:    3,
+		executions:     3,
+		panels:         4,
+		expected:       3,
+	},
+}
+
+func TestAcquirePanelSlot(t *testing.T) {
+	for name, test := range testCasesPanelConcurrency {
+		globalLimiter := newPanelLimiter(test.globalLimit)
+
+		var mut sync.Mutex
+		var running, maxRunning int
+		var wg sync.WaitGroup
+		for i := 0; i < test.executions; i++ {
+			executionTree := &DashboardExecutionTree{
+				panelLimiter:       newPanelLimiter(test.dashboardLimit),
+				globalPanelLimiter: globalLimiter,
+			}
+			for j := 0; j < test.panels; j++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					release, err := executionTree.acquirePanelSlot(context.Background(), "panel")
+					if err != nil {
+						t.Errorf("Test: '%s' FAILED : expected no error, got %v", name, err)
+						return
+					}
+					defer release()
+
+					mut.Lock()
+					running++
+					maxRunning = max(maxRunning, running)
+					mut.Unlock()
+					time.Sleep(20 * time.Millisecond)
+					mut.Lock()
+					running--
+					mut.Unlock()
+				}()
+			}
+		}
+		wg.Wait()
+
+		if maxRunning != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected at most %d panels executing at once, got %d", name, test.expected, maxRunning)
+		}
+	}
+}
+
+func TestAcquirePanelSlotCancelled(t *testing.T) {
+	executionTree := &DashboardExecutionTree{
+		panelLimiter:       newPanelLimiter(1),
+		globalPanelLimiter: newPanelLimiter(1),
+	}
+	release, err := executionTree.acquirePanelSlot(context.Background(), "running")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a queued panel stops waiting when its execution is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := executionTree.acquirePanelSlot(ctx, "queued"); err == nil {
+		t.Errorf("Test: 'cancelled' FAILED : expected an error, got nil")
+	}
+
+	// once the running panel completes, the slot is available again
+	release()
+	release, err = executionTree.acquirePanelSlot(context.Background(), "next")
+	if err != nil {
+		t.Errorf("Test: 'released' FAILED : expected no error, got %v", err)
+		return
+	}
+	release()
+}
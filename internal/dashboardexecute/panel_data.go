@@ -0,0 +1,82 @@
+package dashboardexecute
+
+import (
+	"context"
+
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+// PanelData is the latest data of a panel of an execution
+type PanelData struct {
+	ExecutionId string
+	// the root resource of the execution
+	Target string
+	Panel  string
+	Status dashboardtypes.RunStatus
+	Data   *dashboardtypes.LeafData
+}
+
+// GetExecutionRoot returns the root run (i.e. the dashboard or benchmark) of the execution with the given id, or false
+// if there is no such execution - so that access to the target may be checked before the data of the execution is read
+func (e *DashboardExecutor) GetExecutionRoot(executionId string) (dashboardtypes.DashboardTreeRun, bool) {
+	_, executionTree, found := e.getExecutionByRunId(executionId)
+	if !found || executionTree.Root == nil {
+		return nil, false
+	}
+	return executionTree.Root, true
+}
+
+// GetPanelData returns the latest data of a panel of the execution with the given id
+// it returns false if there is no such execution (executions are replaced when their session runs another dashboard),
+// or the execution has no such panel
+//
+// the data of a paged table is only a single page, so the query of a complete paged table is executed in full
+func (e *DashboardExecutor) GetPanelData(ctx context.Context, executionId, panelName string) (*PanelData, bool, error) {
+	_, executionTree, found := e.getExecutionByRunId(executionId)
+	if !found {
+		return nil, false, nil
+	}
+	leafRun, ok := executionTree.runs[panelName].(*LeafRun)
+	if !ok {
+		return nil, false, nil
+	}
+
+	// do not read the data while the panel is being refreshed
+	leafRun.refreshLock.Lock()
+	defer leafRun.refreshLock.Unlock()
+
+	res := &PanelData{
+		ExecutionId: executionId,
+		Target:      executionTree.dashboardName,
+		Panel:       panelName,
+		Status:      leafRun.GetRunStatus(),
+		Data:        leafRun.Data,
+	}
+	if res.Status == dashboardtypes.RunComplete && leafRun.isPagedTable() {
+		data, err := leafRun.getAllData(ctx)
+		if err != nil {
+			return nil, true, err
+		}
+		res.Data = data
+	}
+	return res, true, nil
+}
+
+// getAllData executes our query without paging
+func (r *LeafRun) getAllData(ctx context.Context) (*dashboardtypes.LeafData, error) {
+	client, err := r.executionTree.getClient(ctx, r.database, r.searchPathConfig)
+	if err != nil {
+		return nil, err
+	}
+	release, err := r.executionTree.acquirePanelSlot(ctx, r.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	sql, args := r.expandSQL(client.Backend.Name(), r.executeSQL, r.Args)
+	queryResult, err := client.ExecuteSync(r.executionTree.withOrigin(ctx), sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	return dashboardtypes.NewLeafData(queryResult)
+}
@@ -0,0 +1,147 @@
+package dashboardexecute
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+// a failed panel does not fail its dashboard or container - the rest of the dashboard is still rendered,
+// the error is shown inline by the failed panel, and the names of all failed panels are recorded
+// in the FailedPanels property of the dashboard and its containers (so partial completion is visible in snapshots)
+
+// childrenCompleteError converts the error returned by waitForChildrenAsync into the error for
+// a dashboard or container - only a cancellation or timeout fails the run
+func (r *DashboardParentImpl) childrenCompleteError(ctx context.Context, err error) error {
+	r.FailedPanels = getFailedPanels(r.children)
+	if err != nil && ctx.Err() == nil && len(r.FailedPanels) > 0 {
+		slog.Debug("children failed - completing with partial results", "name", r.Name, "failed panels", r.FailedPanels)
+		return nil
+	}
+	return err
+}
+
+// getFailedPanels returns the names of all failed panels in the given runs, including those in child containers
+func getFailedPanels(runs []dashboardtypes.DashboardTreeRun) []string {
+	var res []string
+	for _, run := range runs {
+		switch r := run.(type) {
+		case *DashboardRun:
+			res = append(res, getFailedPanels(r.children)...)
+		case *DashboardContainerRun:
+			res = append(res, getFailedPanels(r.children)...)
+		default:
+			if run.GetRunStatus() == dashboardtypes.RunError {
+				res = append(res, run.GetName())
+			}
+		}
+	}
+	return res
+}
+
+// updateFailedPanels recalculates the failed panels of the given run and all its ancestors
+// (this is called after a panel is retried)
+func updateFailedPanels(parent dashboardtypes.DashboardParent) {
+	for parent != nil {
+		switch r := parent.(type) {
+		case *DashboardRun:
+			r.FailedPanels = getFailedPanels(r.children)
+		case *DashboardContainerRun:
+			r.FailedPanels = getFailedPanels(r.children)
+		}
+		parent = parent.GetParent()
+	}
+}
+
+// RetryPanel re-executes a failed panel of the dashboard running for the given session
+// - the rest of the dashboard is not re-executed, the result is sent as a LeafNodeUpdated event
+func (e *DashboardExecutor) RetryPanel(ctx context.Context, sessionId, panelName string) error {
+	executionTree, found := e.getExecution(sessionId)
+	if !found {
+		return fmt.Errorf("no dashboard running for session %s", sessionId)
+	}
+	run, ok := executionTree.runs[panelName]
+	if !ok {
+		return fmt.Errorf("panel %s not found in dashboard %s", panelName, executionTree.GetName())
+	}
+	leafRun, ok := run.(*LeafRun)
+	if !ok {
+		return fmt.Errorf("panel %s cannot be retried", panelName)
+	}
+	return leafRun.startRetry(ctx)
+}
+
+// startRetry starts re-executing this run in the background - an error is returned if the run cannot be retried, or is
+// already being retried or refreshed
+// (the refresh lock is held until the retry completes, so retries and refreshes of the run are not concurrent)
+func (r *LeafRun) startRetry(ctx context.Context) error {
+	if !r.refreshLock.TryLock() {
+		return fmt.Errorf("panel %s is already being retried", r.Name)
+	}
+	if !r.canRetry() {
+		r.refreshLock.Unlock()
+		return fmt.Errorf("panel %s cannot be retried", r.Name)
+	}
+	go func() {
+		defer r.refreshLock.Unlock()
+		r.retry(ctx)
+	}()
+	return nil
+}
+
+// canRetry returns whether this run failed executing its query, or the query of one of its children
+// (runs which failed before their query was resolved, e.g. due to a failed dependency, cannot be retried)
+func (r *LeafRun) canRetry() bool {
+	if r.GetRunStatus() != dashboardtypes.RunError {
+		return false
+	}
+	if r.executeSQL != "" {
+		return true
+	}
+	return len(r.failedChildren()) > 0
+}
+
+func (r *LeafRun) failedChildren() []*LeafRun {
+	var res []*LeafRun
+	for _, c := range r.children {
+		if child, ok := c.(*LeafRun); ok && child.canRetry() {
+			res = append(res, child)
+		}
+	}
+	return res
+}
+
+// retry re-executes the query of this run and any failed children
+func (r *LeafRun) retry(ctx context.Context) {
+	slog.Debug("LeafRun retry", "name", r.Name)
+	r.reexecute(ctx, (*LeafRun).failedChildren)
+}
+
+// reexecute re-executes the query of this (completed) run, first re-executing the children returned by getChildren
+// - the result is sent as a LeafNodeUpdated event
+func (r *LeafRun) reexecute(ctx context.Context, getChildren func(*LeafRun) []*LeafRun) {
+	r.err = nil
+	r.ErrorString = ""
+	r.setStatus(ctx, dashboardtypes.RunRunning)
+
+	for _, child := range getChildren(r) {
+		child.reexecute(ctx, getChildren)
+		if err := child.GetError(); err != nil {
+			r.SetError(ctx, err)
+			return
+		}
+	}
+	if r.executeSQL != "" {
+		if err := r.executeQuery(ctx); err != nil {
+			r.SetError(ctx, err)
+			updateFailedPanels(r.parent)
+			return
+		}
+	}
+	r.renderTextTemplate()
+	r.combineChildData()
+	r.SetComplete(ctx)
+	updateFailedPanels(r.parent)
+}
@@ -0,0 +1,85 @@
+package dashboardexecute
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+func newTestLeafRun(name string, status dashboardtypes.RunStatus, executeSQL string) *LeafRun {
+	r := &LeafRun{}
+	r.executeSQL = executeSQL
+	r.Name = name
+	r.Status = status
+	return r
+}
+
+type startRetryTest struct {
+	run *LeafRun
+	// whether the run is being retried (or refreshed) when the retry is requested
+	inProgress bool
+	expected   string
+}
+
+var testCasesStartRetry = map[string]startRetryTest{
+	"in progress": {
+		run:        newTestLeafRun("dashboard.d.chart.c", dashboardtypes.RunError, "select 1"),
+		inProgress: true,
+		expected:   "panel dashboard.d.chart.c is already being retried",
+	},
+	"not failed": {
+		run:      newTestLeafRun("dashboard.d.chart.c", dashboardtypes.RunComplete, "select 1"),
+		expected: "panel dashboard.d.chart.c cannot be retried",
+	},
+	"no query": {
+		run:      newTestLeafRun("dashboard.d.chart.c", dashboardtypes.RunError, ""),
+		expected: "panel dashboard.d.chart.c cannot be retried",
+	},
+}
+
+func TestStartRetry(t *testing.T) {
+	for name, test := range testCasesStartRetry {
+		if test.inProgress {
+			test.run.refreshLock.Lock()
+		}
+		err := test.run.startRetry(context.Background())
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected error '%s', got '%v'", name, test.expected, err)
+		}
+		if test.inProgress {
+			test.run.refreshLock.Unlock()
+		}
+		// a rejected retry must not hold the lock
+		if !test.run.refreshLock.TryLock() {
+			t.Errorf("Test: '%s' FAILED : expected the refresh lock to be released", name)
+			continue
+		}
+		test.run.refreshLock.Unlock()
+	}
+}
+
+func TestStartRetryConcurrent(t *testing.T) {
+	run := newTestLeafRun("dashboard.d.chart.c", dashboardtypes.RunError, "select 1")
+	// hold the lock, as an in progress retry does
+	run.refreshLock.Lock()
+	defer run.refreshLock.Unlock()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- run.startRetry(context.Background())
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err == nil {
+			t.Errorf("Test: 'concurrent' FAILED : expected the retries to be rejected while a retry is in progress")
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package dashboardexecute
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+// panels may be refreshed on an interval, by declaring a refresh interval, e.g.
+//
+//	dashboard "noc" {
+//	  tags = {
+//	    refresh_interval = "1m"
+//	  }
+//	  ...
+//	}
+//
+// once a dashboard has executed in server mode, the server re-executes each panel on its refresh interval,
+// sending the updated results to the UI - a panel uses its own refresh interval, or that of its nearest
+// container or dashboard which declares one
+// 'dashboard run --watch' re-runs the dashboard on the refresh interval of the dashboard
+const (
+	TagRefreshInterval = "refresh_interval"
+
+	MinRefreshInterval = 5 * time.Second
+)
+
+// GetRefreshInterval returns the refresh interval declared by the resource, or zero if none is declared
+func GetRefreshInterval(resource modconfig.HclResource) (time.Duration, error) {
+	tag, ok := resource.GetTags()[TagRefreshInterval]
+	if !ok {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(tag)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s tag '%s' for %s: %s", TagRefreshInterval, tag, resource.Name(), err.Error())
+	}
+	if interval < MinRefreshInterval {
+		return 0, fmt.Errorf("invalid %s tag '%s' for %s: must be at least %s", TagRefreshInterval, tag, resource.Name(), MinRefreshInterval)
+	}
+	return interval, nil
+}
+
+// GetWatchInterval returns the interval on which 'dashboard run --watch' re-runs a dashboard - the refresh interval
+// of the dashboard or, if it does not declare one, the shortest refresh interval of its panels
+func GetWatchInterval(resource modconfig.ModTreeItem) (time.Duration, error) {
+	interval, err := GetRefreshInterval(resource)
+	if err != nil || interval > 0 {
+		return interval, err
+	}
+	for _, child := range resource.GetChildren() {
+		childInterval, err := GetWatchInterval(child)
+		if err != nil {
+			return 0, err
+		}
+		if childInterval > 0 && (interval == 0 || childInterval < interval) {
+			interval = childInterval
+		}
+	}
+	return interval, nil
+}
+
+// getPanelRefreshInterval returns the refresh interval declared by the run or its nearest ancestor which declares one
+func getPanelRefreshInterval(run dashboardtypes.DashboardTreeRun) time.Duration {
+	for run != nil {
+		if resource := run.GetResource(); resource != nil {
+			interval, err := GetRefreshInterval(resource)
+			if err != nil {
+				slog.Warn("ignoring refresh interval", "error", err)
+			}
+			if interval > 0 {
+				return interval
+			}
+		}
+		run = getParentRun(run)
+	}
+	return 0
+}
+
+// getParentRun returns the parent run of the run, or nil if its parent is the execution tree
+// (the execution tree has no resource)
+func getParentRun(run dashboardtypes.DashboardTreeRun) dashboardtypes.DashboardTreeRun {
+	if _, ok := run.GetParent().(*DashboardExecutionTree); ok {
+		return nil
+	}
+	parent, _ := run.GetParent().(dashboardtypes.DashboardTreeRun)
+	return parent
+}
+
+// keepRefreshed refreshes the panels of the (completed) execution on their refresh interval,
+// and when notifications are received on their notification channels - this continues until the context is cancelled
+func (e *DashboardExecutionTree) keepRefreshed(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		e.listenForNotifications(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		e.pollForRefresh(ctx)
+	}()
+	wg.Wait()
+
+	// close any clients created for the refreshes
+	_ = e.clientMap.Close(context.Background())
+}
+
+// pollForRefresh refreshes each panel with a refresh interval on that interval
+func (e *DashboardExecutionTree) pollForRefresh(ctx context.Context) {
+	// group panels by interval
+	panels := make(map[time.Duration][]*LeafRun)
+	for _, run := range e.runs {
+		leafRun, ok := run.(*LeafRun)
+		if !ok || !leafRun.canRefresh() {
+			continue
+		}
+		if interval := getPanelRefreshInterval(leafRun); interval > 0 {
+			panels[interval] = append(panels[interval], leafRun)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for interval, intervalPanels := range panels {
+		wg.Add(1)
+		go func(interval time.Duration, intervalPanels []*LeafRun) {
+			defer wg.Done()
+			slog.Debug("refreshing panels on interval", "dashboard", e.dashboardName, "interval", interval, "panels", len(intervalPanels))
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+				// (panels are refreshed sequentially, so a slow refresh delays the next tick rather than overlapping it)
+				for _, panel := range intervalPanels {
+					if ctx.Err() != nil {
+						return
+					}
+					panel.refresh(ctx)
+				}
+			}
+		}(interval, intervalPanels)
+	}
+	wg.Wait()
+}
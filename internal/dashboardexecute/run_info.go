@@ -0,0 +1,49 @@
+package dashboardexecute
+
+import (
+	"context"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+// RunInfo describes an in-flight execution - this is returned by the run state API
+type RunInfo struct {
+	RunId     string    `json:"run_id"`
+	RunType   string    `json:"run_type"`
+	Target    string    `json:"target"`
+	Session   string    `json:"session"`
+	Initiator string    `json:"initiator,omitempty"`
+	Status    string    `json:"status"`
+	StartTime time.Time `json:"start_time"`
+}
+
+type runInitiatorKey struct{}
+
+// WithRunInitiator returns a context which records the initiator of any executions started using it
+// (e.g. the remote address of a websocket client)
+func WithRunInitiator(ctx context.Context, initiator string) context.Context {
+	return context.WithValue(ctx, runInitiatorKey{}, initiator)
+}
+
+// RunInitiatorFromContext returns the run initiator stored in the context (if any)
+func RunInitiatorFromContext(ctx context.Context) string {
+	initiator, _ := ctx.Value(runInitiatorKey{}).(string)
+	return initiator
+}
+
+func newRunInfo(executionTree *DashboardExecutionTree) RunInfo {
+	status := dashboardtypes.RunInitialized
+	if executionTree.Root != nil {
+		status = executionTree.GetRunStatus()
+	}
+	return RunInfo{
+		RunId:     executionTree.id,
+		RunType:   executionTree.runType,
+		Target:    executionTree.dashboardName,
+		Session:   executionTree.sessionId,
+		Initiator: executionTree.initiator,
+		Status:    string(status),
+		StartTime: executionTree.startTime,
+	}
+}
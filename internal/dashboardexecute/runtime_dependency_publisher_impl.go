@@ -271,8 +271,13 @@ func (p *runtimeDependencyPublisherImpl) createWithRuns(withs []*modconfig.Dashb
 		if err != nil {
 			return err
 		}
-		// set an onComplete function to populate 'with' data
-		withRun.onComplete = func() { p.setWithValue(withRun) }
+		// set an onComplete function to populate 'with' data,
+		// then notify any panels waiting to use the data as a shared dataset
+		withRun.withComplete = make(chan struct{})
+		withRun.onComplete = func() {
+			p.setWithValue(withRun)
+			close(withRun.withComplete)
+		}
 
 		p.withRuns[w.UnqualifiedName] = withRun
 		p.children = append(p.children, withRun)
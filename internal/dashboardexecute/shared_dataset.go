@@ -0,0 +1,129 @@
+package dashboardexecute
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+// the result of a dashboard level 'with' block is computed once, and may be shared by any number of panels of the
+// dashboard - rather than each panel executing the same expensive base query - by referencing it as the table
+// with_<name>, e.g.
+//
+//	dashboard "buckets" {
+//	  with "buckets" {
+//	    sql = "select name, region, versioning_enabled from aws_s3_bucket"
+//	  }
+//	  card {
+//	    sql = "select count(*) as value, 'Unversioned' as label from with_buckets where versioning_enabled::bool = false"
+//	  }
+//	  table {
+//	    sql = "select region, count(*) from with_buckets group by region"
+//	  }
+//	}
+//
+// a panel which references a shared dataset waits for the 'with' to complete, then the rows are provided to its query
+// as a common table expression, in the same way as uploaded datasets - so all values are text, and queries may cast
+// them as required
+const SharedDatasetTablePrefix = "with_"
+
+// resolveSharedDatasets waits for the dashboard 'with' runs referenced by our sql to complete,
+// and sets our shared datasets to their results
+func (r *LeafRun) resolveSharedDatasets(ctx context.Context) error {
+	// 'with' runs may not reference each other, as this could deadlock
+	if r.NodeType == schema.BlockTypeWith {
+		return nil
+	}
+	withRuns := r.getReferencedDashboardWithRuns()
+	if len(withRuns) == 0 {
+		return nil
+	}
+
+	sharedDatasets := make(map[string]*Dataset, len(withRuns))
+	for name, withRun := range withRuns {
+		slog.Debug("waiting for shared dataset", "name", r.Name, "dataset", name)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-withRun.withComplete:
+		}
+		if err := withRun.GetError(); err != nil {
+			return fmt.Errorf("shared dataset '%s' failed: %s", name, err.Error())
+		}
+		dataset, err := newSharedDataset(name, withRun.Data)
+		if err != nil {
+			return err
+		}
+		sharedDatasets[dataset.TableName()] = dataset
+	}
+	r.sharedDatasets = sharedDatasets
+	return nil
+}
+
+// getReferencedDashboardWithRuns returns the 'with' runs of our dashboard which are referenced by our sql,
+// keyed by 'with' name
+func (r *LeafRun) getReferencedDashboardWithRuns() map[string]*LeafRun {
+	if r.executeSQL == "" {
+		return nil
+	}
+	dashboardRun, ok := r.executionTree.runs[r.DashboardName].(RuntimeDependencyPublisher)
+	if !ok {
+		return nil
+	}
+	res := make(map[string]*LeafRun)
+	for _, withRun := range dashboardRun.GetWithRuns() {
+		name := withRun.Resource.GetShortName()
+		if regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(SharedDatasetTablePrefix+name) + `\b`).MatchString(r.executeSQL) {
+			res[name] = withRun
+		}
+	}
+	return res
+}
+
+// newSharedDataset converts the result of a 'with' into a dataset
+func newSharedDataset(name string, data *dashboardtypes.LeafData) (*Dataset, error) {
+	if data == nil {
+		return nil, fmt.Errorf("shared dataset '%s' has no data", name)
+	}
+	if len(data.Rows) > MaxDatasetRows {
+		return nil, fmt.Errorf("shared dataset '%s' has %d rows - the maximum is %d", name, len(data.Rows), MaxDatasetRows)
+	}
+	dataset := &Dataset{
+		Name:        name,
+		Columns:     make([]string, len(data.Columns)),
+		Rows:        make([][]*string, len(data.Rows)),
+		tablePrefix: SharedDatasetTablePrefix,
+	}
+	for i, c := range data.Columns {
+		dataset.Columns[i] = c.Name
+	}
+	for i, row := range data.Rows {
+		dataset.Rows[i] = make([]*string, len(data.Columns))
+		for j, c := range data.Columns {
+			dataset.Rows[i][j] = datasetValue(row[c.Name])
+		}
+	}
+	if values := dataset.valueCount(); values > MaxDatasetValues {
+		return nil, fmt.Errorf("shared dataset '%s' has %d values - the maximum is %d", name, values, MaxDatasetValues)
+	}
+	return dataset, nil
+}
+
+// getDatasets returns the uploaded and shared datasets available to our query
+func (r *LeafRun) getDatasets() map[string]*Dataset {
+	if len(r.sharedDatasets) == 0 {
+		return r.executionTree.datasets
+	}
+	res := make(map[string]*Dataset, len(r.executionTree.datasets)+len(r.sharedDatasets))
+	for _, dataset := range r.executionTree.datasets {
+		res[dataset.TableName()] = dataset
+	}
+	for _, dataset := range r.sharedDatasets {
+		res[dataset.TableName()] = dataset
+	}
+	return res
+}
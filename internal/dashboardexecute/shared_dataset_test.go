@@ -0,0 +1,90 @@
+package dashboardexecute
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/queryresult"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+type sharedDatasetTest struct {
+	data     *dashboardtypes.LeafData
+	sql      string
+	expected string
+	args     []any
+	err      bool
+}
+
+var testCasesSharedDataset = map[string]sharedDatasetTest{
+	"values": {
+		data: &dashboardtypes.LeafData{
+			Columns: []*queryresult.ColumnDef{{Name: "name"}, {Name: "count"}, {Name: "created"}, {Name: "tags"}},
+			Rows: []map[string]any{
+				{"name": "o'brien", "count": int64(2), "created": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "tags": `{"a":1}`},
+				{"name": nil, "count": 1.5, "created": nil, "tags": nil},
+			},
+		},
+		sql:      "select count(*) from with_buckets where count::int > 1",
+		expected: `with with_buckets("name", "count", "created", "tags") as (values (cast($1 as text), cast($2 as text), cast($3 as text), cast($4 as text)), (null, cast($5 as text), null, null)) select count(*) from with_buckets where count::int > 1`,
+		args:     []any{"o'brien", "2", "2024-01-02T03:04:05Z", `{"a":1}`, "1.5"},
+	},
+	"existing ctes": {
+		data: &dashboardtypes.LeafData{
+			Columns: []*queryresult.ColumnDef{{Name: "name"}},
+			Rows:    []map[string]any{{"name": "a"}},
+		},
+		sql:      "with x as (select 1) select * from x, WITH_BUCKETS",
+		expected: `with with_buckets("name") as (values (cast($1 as text))), x as (select 1) select * from x, WITH_BUCKETS`,
+		args:     []any{"a"},
+	},
+	"no rows": {
+		data: &dashboardtypes.LeafData{
+			Columns: []*queryresult.ColumnDef{{Name: "name"}},
+		},
+		sql:      "select * from with_buckets",
+		expected: `with with_buckets("name") as (select null where 1 = 0) select * from with_buckets`,
+	},
+	"not referenced": {
+		data: &dashboardtypes.LeafData{
+			Columns: []*queryresult.ColumnDef{{Name: "name"}},
+		},
+		sql:      "select * from upload_buckets",
+		expected: "select * from upload_buckets",
+	},
+	"too many rows": {
+		data: &dashboardtypes.LeafData{
+			Columns: []*queryresult.ColumnDef{{Name: "name"}},
+			Rows:    make([]map[string]any, MaxDatasetRows+1),
+		},
+		err: true,
+	},
+	"no data": {
+		err: true,
+	},
+}
+
+func TestSharedDataset(t *testing.T) {
+	for name, test := range testCasesSharedDataset {
+		dataset, err := newSharedDataset("buckets", test.data)
+		if test.err {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		actual, args := withDatasets(constants.DuckDBBackendName, test.sql, nil, map[string]*Dataset{dataset.TableName(): dataset})
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, actual)
+		}
+		if !reflect.DeepEqual(args, test.args) {
+			t.Errorf("Test: '%s' FAILED : expected args %q, got %q", name, test.args, args)
+		}
+	}
+}
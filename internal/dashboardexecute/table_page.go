@@ -0,0 +1,162 @@
+package dashboardexecute
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/queryresult"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+	"github.com/turbot/powerpipe/internal/db_client"
+)
+
+// the maximum page size a client may request - larger pages are reduced to this (or the configured page size,
+// if that is larger)
+const maxTablePageSize = 1000
+
+// tablePageSize returns the configured table page size - if this is zero, tables are not paged
+func tablePageSize() int {
+	return max(viper.GetInt(localconstants.ArgTablePageSize), 0)
+}
+
+// isPagedTable returns whether the data for this leaf run should be retrieved a page at a time
+func (r *LeafRun) isPagedTable() bool {
+	_, isTable := r.resource.(*modconfig.DashboardTable)
+	return isTable && tablePageSize() > 0
+}
+
+// GetTablePage retrieves a page of data for a table panel of the dashboard running for the given session
+// sorting, filtering and paging are all performed by the database
+func (e *DashboardExecutor) GetTablePage(ctx context.Context, sessionId, panelName string, req *dashboardtypes.TablePageRequest) (*dashboardtypes.LeafData, error) {
+	executionTree, found := e.getExecution(sessionId)
+	if !found {
+		return nil, fmt.Errorf("no dashboard running for session %s", sessionId)
+	}
+	run, ok := executionTree.runs[panelName]
+	if !ok {
+		return nil, fmt.Errorf("panel %s not found in dashboard %s", panelName, executionTree.GetName())
+	}
+	leafRun, ok := run.(*LeafRun)
+	if !ok || !leafRun.isPagedTable() {
+		return nil, fmt.Errorf("panel %s is not a paged table", panelName)
+	}
+	return leafRun.getTablePage(ctx, req)
+}
+
+// getTablePage executes our query, wrapped to apply the sorting, filtering and paging of the request
+func (r *LeafRun) getTablePage(ctx context.Context, req *dashboardtypes.TablePageRequest) (*dashboardtypes.LeafData, error) {
+	// the page request must only reference columns returned by our query
+	// (we only know these once the query has been executed)
+	var columns []*queryresult.ColumnDef
+	if r.Data != nil {
+		columns = r.Data.Columns
+	}
+
+	pageRequest := *req
+	if pageRequest.PageSize <= 0 {
+		pageRequest.PageSize = tablePageSize()
+	}
+	pageRequest.PageSize = min(pageRequest.PageSize, max(maxTablePageSize, tablePageSize()))
+
+	client, err := r.executionTree.getClient(ctx, r.database, r.searchPathConfig)
+	if err != nil {
+		return nil, err
+	}
+	pageSql, pageArgs, err := buildTablePageSQL(client.Backend.Name(), r.executeSQL, len(r.Args), columns, &pageRequest)
+	if err != nil {
+		return nil, err
+	}
+	release, err := r.executionTree.acquirePanelSlot(ctx, r.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	pageSql, args := r.expandSQL(client.Backend.Name(), pageSql, append(slices.Clone(r.Args), pageArgs...))
+	queryResult, err := client.ExecuteSync(r.executionTree.withOrigin(ctx), pageSql, args...)
+	if err != nil {
+		return nil, err
+	}
+	data, err := dashboardtypes.NewLeafData(queryResult)
+	if err != nil {
+		return nil, err
+	}
+	setTablePage(data, &pageRequest)
+
+	if templates := getHrefTemplates(r.resource); len(templates) > 0 {
+		data.Hrefs = resolveHrefTemplates(templates, data.Rows)
+	}
+	return data, nil
+}
+
+// setTablePage sets the pagination of the data - we request one more row than the page size
+// to determine whether there are more rows, so remove this if present
+func setTablePage(data *dashboardtypes.LeafData, req *dashboardtypes.TablePageRequest) {
+	hasMore := len(data.Rows) > req.PageSize
+	if hasMore {
+		data.Rows = data.Rows[:req.PageSize]
+	}
+	data.Pagination = &dashboardtypes.TablePagination{
+		TablePageRequest: *req,
+		HasMore:          hasMore,
+	}
+}
+
+// buildTablePageSQL wraps the query to apply the sorting, filtering and paging of the request, returning the wrapped
+// query and the args of the wrapped query, which follow the args of the query (of which there are argCount)
+// sort and filter columns must be one of the given query columns, and the filter value is passed as an arg
+func buildTablePageSQL(backendName, sql string, argCount int, columns []*queryresult.ColumnDef, req *dashboardtypes.TablePageRequest) (string, []any, error) {
+	if req.Page < 0 {
+		return "", nil, fmt.Errorf("invalid page %d", req.Page)
+	}
+	if req.PageSize <= 0 {
+		return "", nil, fmt.Errorf("invalid page size %d", req.PageSize)
+	}
+
+	var sb strings.Builder
+	var args []any
+	// remove any trailing semicolon so the query can be used as a subquery
+	sb.WriteString(fmt.Sprintf("select * from (%s) as q", strings.TrimRight(strings.TrimSpace(sql), "; \n\t")))
+
+	if req.FilterColumn != "" && req.FilterValue != "" {
+		column, err := tablePageColumn(backendName, req.FilterColumn, columns)
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, db_client.ContainsPattern(strings.ToLower(req.FilterValue)))
+		sb.WriteString(fmt.Sprintf(" where lower(cast(%s as %s)) like %s %s", column, db_client.TextType(backendName), db_client.Placeholder(backendName, argCount+len(args)), db_client.LikeEscapeClause))
+	}
+
+	if req.SortColumn != "" {
+		column, err := tablePageColumn(backendName, req.SortColumn, columns)
+		if err != nil {
+			return "", nil, err
+		}
+		direction := strings.ToLower(req.SortDirection)
+		switch direction {
+		case "":
+			direction = dashboardtypes.SortAscending
+		case dashboardtypes.SortAscending, dashboardtypes.SortDescending:
+		default:
+			return "", nil, fmt.Errorf("invalid sort direction '%s': expected '%s' or '%s'", req.SortDirection, dashboardtypes.SortAscending, dashboardtypes.SortDescending)
+		}
+		sb.WriteString(fmt.Sprintf(" order by %s %s", column, direction))
+	}
+
+	// retrieve an extra row so we know whether there are more pages
+	sb.WriteString(fmt.Sprintf(" limit %d offset %d", req.PageSize+1, req.Page*req.PageSize))
+	return sb.String(), args, nil
+}
+
+// tablePageColumn returns the quoted identifier for the column, which must be one of the query columns
+func tablePageColumn(backendName, name string, columns []*queryresult.ColumnDef) (string, error) {
+	for _, c := range columns {
+		if c.Name == name {
+			return db_client.EscapeName(backendName, name), nil
+		}
+	}
+	return "", fmt.Errorf("column '%s' not found", name)
+}
@@ -0,0 +1,110 @@
+package dashboardexecute
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/queryresult"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+type buildTablePageSQLTest struct {
+	backend  string
+	sql      string
+	argCount int
+	request  dashboardtypes.TablePageRequest
+	expected string
+	args     []any
+	err      bool
+}
+
+var testTablePageColumns = []*queryresult.ColumnDef{{Name: "name"}, {Name: "Account ID"}}
+
+var testCasesBuildTablePageSQL = map[string]buildTablePageSQLTest{
+	"first page": {
+		sql:      "select name from t;",
+		request:  dashboardtypes.TablePageRequest{PageSize: 10},
+		expected: "select * from (select name from t) as q limit 11 offset 0",
+	},
+	"sorted": {
+		sql:      "select name from t",
+		request:  dashboardtypes.TablePageRequest{Page: 2, PageSize: 10, SortColumn: "Account ID", SortDirection: "DESC"},
+		expected: `select * from (select name from t) as q order by "Account ID" desc limit 11 offset 20`,
+	},
+	"filtered": {
+		sql:      "select name from t",
+		request:  dashboardtypes.TablePageRequest{PageSize: 5, FilterColumn: "name", FilterValue: "O'Brien"},
+		expected: `select * from (select name from t) as q where lower(cast("name" as text)) like $1 escape '!' limit 6 offset 0`,
+		args:     []any{"%o'brien%"},
+	},
+	"filtered with args": {
+		sql:      "select name from t where a = $1 and b = $2",
+		argCount: 2,
+		request:  dashboardtypes.TablePageRequest{PageSize: 5, FilterColumn: "name", FilterValue: "a"},
+		expected: `select * from (select name from t where a = $1 and b = $2) as q where lower(cast("name" as text)) like $3 escape '!' limit 6 offset 0`,
+		args:     []any{"%a%"},
+	},
+	"filter wildcards": {
+		sql:      "select name from t",
+		request:  dashboardtypes.TablePageRequest{PageSize: 5, FilterColumn: "name", FilterValue: `50%_off!\`},
+		expected: `select * from (select name from t) as q where lower(cast("name" as text)) like $1 escape '!' limit 6 offset 0`,
+		args:     []any{`%50!%!_off!!\%`},
+	},
+	"mysql": {
+		backend:  constants.MySQLBackendName,
+		sql:      "select name from t where a = ?",
+		argCount: 1,
+		request:  dashboardtypes.TablePageRequest{PageSize: 5, FilterColumn: "Account ID", FilterValue: `\' or 1=1 -- `, SortColumn: "name"},
+		expected: "select * from (select name from t where a = ?) as q where lower(cast(`Account ID` as char)) like ? escape '!' order by `name` asc limit 6 offset 0",
+		args:     []any{`%\' or 1=1 -- %`},
+	},
+	"sqlite": {
+		backend:  constants.SQLiteBackendName,
+		sql:      "select name from t",
+		request:  dashboardtypes.TablePageRequest{PageSize: 5, FilterColumn: "name", FilterValue: "a"},
+		expected: `select * from (select name from t) as q where lower(cast("name" as text)) like ?1 escape '!' limit 6 offset 0`,
+		args:     []any{"%a%"},
+	},
+	"unknown sort column": {
+		sql:     "select name from t",
+		request: dashboardtypes.TablePageRequest{PageSize: 10, SortColumn: "name; drop table t"},
+		err:     true,
+	},
+	"invalid sort direction": {
+		sql:     "select name from t",
+		request: dashboardtypes.TablePageRequest{PageSize: 10, SortColumn: "name", SortDirection: "sideways"},
+		err:     true,
+	},
+	"invalid page size": {
+		sql:     "select name from t",
+		request: dashboardtypes.TablePageRequest{},
+		err:     true,
+	},
+}
+
+func TestBuildTablePageSQL(t *testing.T) {
+	for name, test := range testCasesBuildTablePageSQL {
+		backend := test.backend
+		if backend == "" {
+			backend = constants.DuckDBBackendName
+		}
+		actual, args, err := buildTablePageSQL(backend, test.sql, test.argCount, testTablePageColumns, &test.request)
+		if test.err {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error, got '%s'", name, actual)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, actual)
+		}
+		if !reflect.DeepEqual(args, test.args) {
+			t.Errorf("Test: '%s' FAILED : expected args %q, got %q", name, test.args, args)
+		}
+	}
+}
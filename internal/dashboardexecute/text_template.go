@@ -0,0 +1,150 @@
+package dashboardexecute
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+// text panels may embed query results and variable values in their value using templates, e.g.
+//
+//	text {
+//	  value = "There are {{ .rows[0].count }} public buckets in {{ .var.environment }}"
+//	  tags = {
+//	    query = "query.public_bucket_count"
+//	  }
+//	}
+//
+// the query tag names the query whose rows are available to the template
+const TagTextQuery = "query"
+
+// textTemplateRegex matches template expressions - the path is parsed by parseTextTemplatePath
+var textTemplateRegex = regexp.MustCompile(`{{\s*(\.[^{}]*?)\s*}}`)
+
+// textTemplatePathRegex matches a single segment of a template path: .name, ."quoted name" or [index]
+var textTemplatePathRegex = regexp.MustCompile(`^(?:\.([A-Za-z_][A-Za-z0-9_]*)|\."([^"]+)"|\[(\d+)\])`)
+
+// resolveTextQuery sets the sql and args for a text panel which uses query results in its template
+func (r *LeafRun) resolveTextQuery() error {
+	text, ok := r.resource.(*modconfig.DashboardText)
+	if !ok {
+		return nil
+	}
+	queryName, ok := text.GetTags()[TagTextQuery]
+	if !ok {
+		return nil
+	}
+	queryProvider, ok := r.executionTree.workspace.GetQueryProvider(queryName)
+	if !ok {
+		return fmt.Errorf("%s: query '%s' not found", text.Name(), queryName)
+	}
+	resolvedQuery, err := r.executionTree.workspace.ResolveQueryFromQueryProvider(queryProvider, nil)
+	if err != nil {
+		return err
+	}
+	r.RawSQL = resolvedQuery.RawSQL
+	r.executeSQL = resolvedQuery.ExecuteSQL
+	r.Args = resolvedQuery.Args
+	return nil
+}
+
+// renderTextTemplate resolves any templates in the value of a text panel, using our query results
+// and the variables of the text panel's mod
+func (r *LeafRun) renderTextTemplate() {
+	text, ok := r.resource.(*modconfig.DashboardText)
+	if !ok || text.Value == nil || !strings.Contains(*text.Value, "{{") {
+		return
+	}
+	r.Properties["value"] = renderTextTemplate(*text.Value, r.textTemplateData(text))
+}
+
+// textTemplateData builds the data available to text templates
+func (r *LeafRun) textTemplateData(text *modconfig.DashboardText) map[string]any {
+	vars := make(map[string]any)
+	for _, v := range r.executionTree.workspace.GetResourceMaps().Variables {
+		if text.GetMod() != nil && v.ModName == text.GetMod().ShortName {
+			vars[v.ShortName] = v.ValueGo
+		}
+	}
+
+	data := map[string]any{"var": vars}
+	if r.Data != nil {
+		rows := make([]any, len(r.Data.Rows))
+		for i, row := range r.Data.Rows {
+			rows[i] = row
+		}
+		data["rows"] = rows
+		data["columns"] = textTemplateColumns(r.Data)
+	}
+	return data
+}
+
+func textTemplateColumns(data *dashboardtypes.LeafData) []any {
+	res := make([]any, len(data.Columns))
+	for i, c := range data.Columns {
+		res[i] = c.Name
+	}
+	return res
+}
+
+// renderTextTemplate replaces each template expression with the value it references
+// - expressions which are not valid paths are left unchanged, paths which do not exist resolve to an empty string
+func renderTextTemplate(template string, data map[string]any) string {
+	return textTemplateRegex.ReplaceAllStringFunc(template, func(match string) string {
+		path, ok := parseTextTemplatePath(textTemplateRegex.FindStringSubmatch(match)[1])
+		if !ok {
+			return match
+		}
+		return dashboardtypes.HrefValueString(resolveTextTemplatePath(data, path))
+	})
+}
+
+// parseTextTemplatePath parses a path such as .rows[0]."Account ID" into its segments
+// - map keys are returned as strings and slice indices as ints
+func parseTextTemplatePath(path string) ([]any, bool) {
+	var res []any
+	for len(path) > 0 {
+		groups := textTemplatePathRegex.FindStringSubmatch(path)
+		if groups == nil {
+			return nil, false
+		}
+		switch {
+		case groups[1] != "":
+			res = append(res, groups[1])
+		case groups[2] != "":
+			res = append(res, groups[2])
+		default:
+			index, err := strconv.Atoi(groups[3])
+			if err != nil {
+				return nil, false
+			}
+			res = append(res, index)
+		}
+		path = path[len(groups[0]):]
+	}
+	return res, len(res) > 0
+}
+
+func resolveTextTemplatePath(value any, path []any) any {
+	for _, segment := range path {
+		switch s := segment.(type) {
+		case string:
+			m, ok := value.(map[string]any)
+			if !ok {
+				return nil
+			}
+			value = m[s]
+		case int:
+			l, ok := value.([]any)
+			if !ok || s >= len(l) {
+				return nil
+			}
+			value = l[s]
+		}
+	}
+	return value
+}
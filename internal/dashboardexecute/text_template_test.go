@@ -0,0 +1,49 @@
+package dashboardexecute
+
+import (
+	"testing"
+)
+
+type renderTextTemplateTest struct {
+	template string
+	expected string
+}
+
+var testTextTemplateData = map[string]any{
+	"rows": []any{
+		map[string]any{"count": int64(12), "Account ID": "123456"},
+	},
+	"var": map[string]any{"environment": "production"},
+}
+
+var testCasesRenderTextTemplate = map[string]renderTextTemplateTest{
+	"no template": {
+		template: "No public buckets",
+		expected: "No public buckets",
+	},
+	"row value": {
+		template: "There are {{.rows[0].count}} public buckets",
+		expected: "There are 12 public buckets",
+	},
+	"quoted column and variable": {
+		template: `Account {{ .rows[0]."Account ID" }} ({{ .var.environment }})`,
+		expected: "Account 123456 (production)",
+	},
+	"missing row": {
+		template: "Count: {{ .rows[1].count }}",
+		expected: "Count: ",
+	},
+	"invalid path": {
+		template: "Count: {{ .rows | length }}",
+		expected: "Count: {{ .rows | length }}",
+	},
+}
+
+func TestRenderTextTemplate(t *testing.T) {
+	for name, test := range testCasesRenderTextTemplate {
+		actual := renderTextTemplate(test.template, testTextTemplateData)
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, actual)
+		}
+	}
+}
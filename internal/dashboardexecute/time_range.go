@@ -0,0 +1,251 @@
+package dashboardexecute
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	typehelpers "github.com/turbot/go-kit/types"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+// a dashboard may have a time range input, which is either a relative range ending now, e.g. 24h or 7d,
+// or an absolute range, e.g. 2024-01-01T00:00:00Z/2024-01-08T00:00:00Z
+//
+//	input "period" {
+//	  type        = "time_range"
+//	  placeholder = "Select a time range"
+//	  tags = {
+//	    time_range_default = "7d"
+//	  }
+//	}
+//
+// the UI offers a picker for an absolute range, and presets for relative ranges - the input options, if it has any,
+// replace the default presets (as the input has no query, it must have a placeholder or options)
+//
+// the queries of the dashboard panels may use the macros $__timeFrom and $__timeTo, which are substituted with
+// the bounds of the range as timestamp literals, e.g.
+//
+//	select * from aws_cloudtrail_trail_event where timestamp between $__timeFrom and $__timeTo
+//
+// panels which use the macros wait for the input to be set - if it is not provided to a batch execution,
+// the default range is used
+const (
+	InputTypeTimeRange       = "time_range"
+	TagInputTimeRangeDefault = "time_range_default"
+	DefaultTimeRange         = "24h"
+
+	TimeFromMacro = "$__timeFrom"
+	TimeToMacro   = "$__timeTo"
+)
+
+var relativeTimeRangeRegex = regexp.MustCompile(`^(\d+)([mhdw])$`)
+
+var relativeTimeRangeUnits = map[string]time.Duration{
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// TimeRange is the value of a time range input
+type TimeRange struct {
+	// the duration of a relative range, which ends now
+	Relative time.Duration
+	// the bounds of an absolute range
+	From time.Time
+	To   time.Time
+}
+
+// ParseTimeRange parses a relative range, e.g. 24h, or an absolute range of two RFC3339 times or dates
+// separated by a '/', e.g. 2024-01-01/2024-02-01
+func ParseTimeRange(value string) (*TimeRange, error) {
+	value = strings.TrimSpace(value)
+	invalidErr := fmt.Errorf("invalid time range '%s' - must be a relative range, e.g. 24h or 7d, or an absolute range, e.g. 2024-01-01T00:00:00Z/2024-01-08T00:00:00Z", value)
+
+	if match := relativeTimeRangeRegex.FindStringSubmatch(value); match != nil {
+		count, err := strconv.Atoi(match[1])
+		if err != nil || count == 0 {
+			return nil, invalidErr
+		}
+		return &TimeRange{Relative: time.Duration(count) * relativeTimeRangeUnits[match[2]]}, nil
+	}
+
+	bounds := strings.Split(value, "/")
+	if len(bounds) != 2 {
+		return nil, invalidErr
+	}
+	from, err := parseTimeRangeBound(bounds[0])
+	if err != nil {
+		return nil, invalidErr
+	}
+	to, err := parseTimeRangeBound(bounds[1])
+	if err != nil {
+		return nil, invalidErr
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("invalid time range '%s' - the start must be before the end", value)
+	}
+	return &TimeRange{From: from, To: to}, nil
+}
+
+func parseTimeRangeBound(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// Bounds returns the start and end of the range - a relative range ends at the given time
+func (t *TimeRange) Bounds(now time.Time) (time.Time, time.Time) {
+	if t.Relative > 0 {
+		return now.Add(-t.Relative), now
+	}
+	return t.From, t.To
+}
+
+func hasTimeRangeMacros(sql string) bool {
+	return strings.Contains(sql, TimeFromMacro) || strings.Contains(sql, TimeToMacro)
+}
+
+// substituteTimeRangeMacros replaces the time range macros in the sql with timestamp literals
+// (the literals are formatted times, so contain no quotes or escapes)
+func substituteTimeRangeMacros(sql string, from, to time.Time) string {
+	return strings.NewReplacer(
+		TimeFromMacro, "'"+from.UTC().Format(time.RFC3339)+"'",
+		TimeToMacro, "'"+to.UTC().Format(time.RFC3339)+"'",
+	).Replace(sql)
+}
+
+// getTimeRangeDefault returns the default value of a time range input, or an empty string
+// if the resource is not a time range input
+func getTimeRangeDefault(resource modconfig.DashboardLeafNode) (string, error) {
+	input, ok := resource.(*modconfig.DashboardInput)
+	if !ok || typehelpers.SafeString(input.Type) != InputTypeTimeRange {
+		return "", nil
+	}
+	value, ok := input.GetTags()[TagInputTimeRangeDefault]
+	if !ok {
+		return DefaultTimeRange, nil
+	}
+	if _, err := ParseTimeRange(value); err != nil {
+		return "", fmt.Errorf("%s: invalid '%s' tag: %s", input.Name(), TagInputTimeRangeDefault, err.Error())
+	}
+	return value, nil
+}
+
+// getTimeRangeInput returns the time range input of the dashboard, if it has one
+func (r *DashboardRun) getTimeRangeInput() (*modconfig.DashboardInput, error) {
+	var res *modconfig.DashboardInput
+	for _, input := range r.dashboard.Inputs {
+		if typehelpers.SafeString(input.Type) != InputTypeTimeRange {
+			continue
+		}
+		if res != nil {
+			return nil, fmt.Errorf("dashboard %s has more than one %s input: %s, %s", r.dashboard.Name(), InputTypeTimeRange, res.UnqualifiedName, input.UnqualifiedName)
+		}
+		res = input
+	}
+	return res, nil
+}
+
+// subscribeToTimeRange subscribes to the value of the dashboard time range input, if our sql uses the time range macros
+// (this must be called when the run is created, before input values are set)
+func (r *LeafRun) subscribeToTimeRange() error {
+	queryProvider, ok := r.resource.(modconfig.QueryProvider)
+	if !ok || !hasTimeRangeMacros(typehelpers.SafeString(queryProvider.GetSQL())) {
+		return nil
+	}
+	timeRangeErr := fmt.Errorf("%s uses the time range macros %s and %s, but the dashboard has no %s input", r.resource.Name(), TimeFromMacro, TimeToMacro, InputTypeTimeRange)
+
+	dashboardRun, ok := r.executionTree.runs[r.DashboardName].(*DashboardRun)
+	if !ok {
+		return timeRangeErr
+	}
+	input, err := dashboardRun.getTimeRangeInput()
+	if err != nil {
+		return err
+	}
+	if input == nil {
+		return timeRangeErr
+	}
+	r.timeRangeDefault, err = getTimeRangeDefault(input)
+	if err != nil {
+		return err
+	}
+	r.timeRangeChan = dashboardRun.SubscribeToRuntimeDependency(input.UnqualifiedName)
+	return nil
+}
+
+// waitForTimeRange waits for the value of the dashboard time range input, if we subscribed to it
+func (r *LeafRun) waitForTimeRange(ctx context.Context) error {
+	if r.timeRangeChan == nil || r.timeRange != nil {
+		return nil
+	}
+
+	var value *dashboardtypes.ResolvedRuntimeDependencyValue
+	select {
+	case value = <-r.timeRangeChan:
+	default:
+		// the input has not been set yet
+		r.setStatus(ctx, dashboardtypes.RunBlocked)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case value = <-r.timeRangeChan:
+		}
+	}
+	if value.Error != nil {
+		return value.Error
+	}
+
+	// if the input value was cleared, use the default
+	rangeString := r.timeRangeDefault
+	if value.Value != nil {
+		rangeString = fmt.Sprintf("%v", value.Value)
+	}
+	timeRange, err := ParseTimeRange(rangeString)
+	if err != nil {
+		return err
+	}
+	r.timeRange = timeRange
+	return nil
+}
+
+// setTimeRangeBounds fixes the bounds of our time range - a relative range is fixed until the run is next executed,
+// so the pages of a table are consistent
+func (r *LeafRun) setTimeRangeBounds() {
+	if r.timeRange != nil {
+		r.timeFrom, r.timeTo = r.timeRange.Bounds(time.Now())
+	}
+}
+
+// setTimeRangeDefaults adds the default value of the dashboard time range input to the input values, if it is not set
+func (e *DashboardExecutionTree) setTimeRangeDefaults(inputs map[string]any) (map[string]any, error) {
+	dashboardRun, ok := e.Root.(*DashboardRun)
+	if !ok {
+		return inputs, nil
+	}
+	input, err := dashboardRun.getTimeRangeInput()
+	if err != nil || input == nil {
+		return inputs, err
+	}
+	if _, ok := inputs[input.UnqualifiedName]; ok {
+		return inputs, nil
+	}
+	defaultValue, err := getTimeRangeDefault(input)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]any, len(inputs)+1)
+	for k, v := range inputs {
+		res[k] = v
+	}
+	res[input.UnqualifiedName] = defaultValue
+	return res, nil
+}
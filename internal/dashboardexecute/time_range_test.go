@@ -0,0 +1,82 @@
+package dashboardexecute
+
+import (
+	"testing"
+	"time"
+)
+
+type timeRangeTest struct {
+	value    string
+	expected string
+	err      bool
+}
+
+var testNow = time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+var testCasesTimeRange = map[string]timeRangeTest{
+	"minutes": {
+		value:    "15m",
+		expected: `select * from t where ts between '2024-03-10T11:45:00Z' and '2024-03-10T12:00:00Z'`,
+	},
+	"hours": {
+		value:    "24h",
+		expected: `select * from t where ts between '2024-03-09T12:00:00Z' and '2024-03-10T12:00:00Z'`,
+	},
+	"days": {
+		value:    " 7d ",
+		expected: `select * from t where ts between '2024-03-03T12:00:00Z' and '2024-03-10T12:00:00Z'`,
+	},
+	"weeks": {
+		value:    "2w",
+		expected: `select * from t where ts between '2024-02-25T12:00:00Z' and '2024-03-10T12:00:00Z'`,
+	},
+	"absolute": {
+		value:    "2024-01-01T00:00:00+01:00/2024-01-08T00:00:00Z",
+		expected: `select * from t where ts between '2023-12-31T23:00:00Z' and '2024-01-08T00:00:00Z'`,
+	},
+	"absolute dates": {
+		value:    "2024-01-01/2024-02-01",
+		expected: `select * from t where ts between '2024-01-01T00:00:00Z' and '2024-02-01T00:00:00Z'`,
+	},
+	"zero": {
+		value: "0h",
+		err:   true,
+	},
+	"unknown unit": {
+		value: "5y",
+		err:   true,
+	},
+	"end before start": {
+		value: "2024-02-01/2024-01-01",
+		err:   true,
+	},
+	"single bound": {
+		value: "2024-01-01",
+		err:   true,
+	},
+	"empty": {
+		value: "",
+		err:   true,
+	},
+}
+
+func TestTimeRange(t *testing.T) {
+	for name, test := range testCasesTimeRange {
+		timeRange, err := ParseTimeRange(test.value)
+		if test.err {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		from, to := timeRange.Bounds(testNow)
+		actual := substituteTimeRangeMacros("select * from t where ts between $__timeFrom and $__timeTo", from, to)
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, actual)
+		}
+	}
+}
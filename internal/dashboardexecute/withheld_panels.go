@@ -0,0 +1,66 @@
+package dashboardexecute
+
+import (
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/pipe-fittings/steampipeconfig"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+// WithheldPanels returns the names of the panels of the run whose data may not be viewed, given a function which
+// determines whether a panel may be viewed - a panel may be viewed if it, or any panel containing it, may be viewed
+//
+// only panels with data (i.e. queries and benchmarks) are withheld - containers and inputs are always viewable, so
+// the layout of the dashboard is unchanged and it may still be executed
+func WithheldPanels(root dashboardtypes.DashboardTreeRun, canView func(panelName string) bool) map[string]struct{} {
+	res := make(map[string]struct{})
+	addWithheldPanels(root, false, canView, res)
+	return res
+}
+
+func addWithheldPanels(run dashboardtypes.DashboardTreeRun, viewable bool, canView func(string) bool, res map[string]struct{}) {
+	viewable = viewable || canView(run.GetName())
+	if !viewable && hasData(run) {
+		res[run.GetName()] = struct{}{}
+	}
+	if parent, ok := run.(dashboardtypes.DashboardParent); ok {
+		for _, child := range parent.GetChildren() {
+			addWithheldPanels(child, viewable, canView, res)
+		}
+	}
+}
+
+// WithheldSnapshotPanels returns the names of the panels of a snapshot whose data may not be viewed, given the layout
+// of the snapshot and a function which determines whether a panel may be viewed (as WithheldPanels)
+func WithheldSnapshotPanels(layout *steampipeconfig.SnapshotTreeNode, canView func(panelName string) bool) map[string]struct{} {
+	res := make(map[string]struct{})
+	addWithheldSnapshotPanels(layout, false, canView, res)
+	return res
+}
+
+func addWithheldSnapshotPanels(node *steampipeconfig.SnapshotTreeNode, viewable bool, canView func(string) bool, res map[string]struct{}) {
+	if node == nil {
+		return
+	}
+	viewable = viewable || canView(node.Name)
+	// (the panels of a snapshot have data unless they are dashboards, containers or inputs)
+	switch node.NodeType {
+	case schema.BlockTypeDashboard, schema.BlockTypeContainer, schema.BlockTypeInput:
+	default:
+		if !viewable {
+			res[node.Name] = struct{}{}
+		}
+	}
+	for _, child := range node.Children {
+		addWithheldSnapshotPanels(child, viewable, canView, res)
+	}
+}
+
+func hasData(run dashboardtypes.DashboardTreeRun) bool {
+	switch r := run.(type) {
+	case *LeafRun:
+		return r.NodeType != schema.BlockTypeInput
+	case *CheckRun:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package dashboardexecute
+
+import (
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/steampipeconfig"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+	"golang.org/x/exp/maps"
+)
+
+func newTestPanelRun(name, nodeType string, children ...dashboardtypes.DashboardTreeRun) *LeafRun {
+	r := &LeafRun{}
+	r.Name = name
+	r.NodeType = nodeType
+	r.children = children
+	return r
+}
+
+func newTestContainerRun(name string, children ...dashboardtypes.DashboardTreeRun) *DashboardContainerRun {
+	r := &DashboardContainerRun{}
+	r.Name = name
+	r.children = children
+	return r
+}
+
+var testWithheldPanelsRoot = newTestContainerRun("d.dashboard.report",
+	newTestPanelRun("d.input.region", "input"),
+	newTestPanelRun("d.chart.cost", "chart"),
+	newTestPanelRun("d.table.users", "table"),
+	newTestPanelRun("d.graph.relationships", "graph",
+		newTestPanelRun("d.node.user", "node"),
+		newTestPanelRun("d.edge.user_to_group", "edge"),
+	),
+	newTestContainerRun("d.container.costs",
+		newTestPanelRun("d.card.total", "card"),
+	),
+)
+
+type withheldPanelsTest struct {
+	patterns []string
+	expected string
+}
+
+var testCasesWithheldPanels = map[string]withheldPanelsTest{
+	"all viewable": {
+		patterns: []string{"*"},
+		expected: "",
+	},
+	"panel": {
+		patterns: []string{"d.chart.cost"},
+		expected: "d.card.total,d.edge.user_to_group,d.graph.relationships,d.node.user,d.table.users",
+	},
+	"children of viewable panel": {
+		patterns: []string{"d.graph.*"},
+		expected: "d.card.total,d.chart.cost,d.table.users",
+	},
+	"children of viewable container": {
+		patterns: []string{"d.container.costs", "d.chart.*"},
+		expected: "d.edge.user_to_group,d.graph.relationships,d.node.user,d.table.users",
+	},
+	"none viewable": {
+		expected: "d.card.total,d.chart.cost,d.edge.user_to_group,d.graph.relationships,d.node.user,d.table.users",
+	},
+}
+
+func TestWithheldPanels(t *testing.T) {
+	for name, test := range testCasesWithheldPanels {
+		withheld := WithheldPanels(testWithheldPanelsRoot, func(panelName string) bool {
+			return slices.ContainsFunc(test.patterns, func(pattern string) bool {
+				match, _ := path.Match(pattern, panelName)
+				return match
+			})
+		})
+		names := maps.Keys(withheld)
+		sort.Strings(names)
+		if actual := strings.Join(names, ","); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, actual)
+		}
+	}
+}
+
+var testWithheldSnapshotLayout = &steampipeconfig.SnapshotTreeNode{
+	Name:     "d.dashboard.report",
+	NodeType: "dashboard",
+	Children: []*steampipeconfig.SnapshotTreeNode{
+		{Name: "d.input.region", NodeType: "input"},
+		{Name: "d.chart.cost", NodeType: "chart"},
+		{Name: "d.table.users", NodeType: "table"},
+		{Name: "d.graph.relationships", NodeType: "graph", Children: []*steampipeconfig.SnapshotTreeNode{
+			{Name: "d.node.user", NodeType: "node"},
+			{Name: "d.edge.user_to_group", NodeType: "edge"},
+		}},
+		{Name: "d.container.costs", NodeType: "container", Children: []*steampipeconfig.SnapshotTreeNode{
+			{Name: "d.card.total", NodeType: "card"},
+		}},
+	},
+}
+
+func TestWithheldSnapshotPanels(t *testing.T) {
+	// the snapshot layout has the same panels as the run, so the same panels are withheld
+	for name, test := range testCasesWithheldPanels {
+		withheld := WithheldSnapshotPanels(testWithheldSnapshotLayout, func(panelName string) bool {
+			return slices.ContainsFunc(test.patterns, func(pattern string) bool {
+				match, _ := path.Match(pattern, panelName)
+				return match
+			})
+		})
+		names := maps.Keys(withheld)
+		sort.Strings(names)
+		if actual := strings.Join(names, ","); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, actual)
+		}
+	}
+}
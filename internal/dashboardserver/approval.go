@@ -0,0 +1,14 @@
+package dashboardserver
+
+import (
+	"context"
+
+	"github.com/turbot/powerpipe/internal/approval"
+	"github.com/turbot/powerpipe/internal/dashboardevents"
+)
+
+// OnApprovalChanged is the approval.ChangeHandler of the server approval gate
+// it publishes an ApprovalChanged event so pending and decided approval requests are sent to all connected clients
+func (s *Server) OnApprovalChanged(ctx context.Context, request *approval.Request) {
+	s.workspace.PublishDashboardEvent(ctx, &dashboardevents.ApprovalChanged{Request: request})
+}
@@ -0,0 +1,217 @@
+package dashboardserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/steampipeconfig"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/powerpipe/internal/dashboardevents"
+	"github.com/turbot/powerpipe/internal/dashboardexecute"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+	"gopkg.in/olahol/melody.v1"
+)
+
+// canAccess returns whether the user of the session may view and execute the resource
+func (s *Server) canAccess(session *melody.Session, resource modconfig.ModTreeItem) bool {
+	return s.authorizer.CanAccess(s.authorizer.GetIdentity(session.Request), resource)
+}
+
+// accessFilter returns a function which determines whether the user of the session may access a resource
+// (or nil if auth is not enabled)
+func (s *Server) accessFilter(session *melody.Session) func(modconfig.ModTreeItem) bool {
+	if !s.authorizer.Enabled() {
+		return nil
+	}
+	identity := s.authorizer.GetIdentity(session.Request)
+	return func(resource modconfig.ModTreeItem) bool {
+		return s.authorizer.CanAccess(identity, resource)
+	}
+}
+
+// writeAccessDenied sends an execution error to the session for a resource the user may not access
+// (the error does not distinguish between resources which are denied and those which do not exist)
+func (s *Server) writeAccessDenied(ctx context.Context, session *melody.Session, resourceName string) {
+	s.writeExecutionError(ctx, session, fmt.Errorf("%s not found", resourceName))
+}
+
+// writeExecutionError sends an execution error to the session
+func (s *Server) writeExecutionError(ctx context.Context, session *melody.Session, executionErr error) {
+	payload, err := buildExecutionErrorPayload(&dashboardevents.ExecutionError{
+		Error:     executionErr,
+		Session:   s.getSessionId(session),
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		OutputError(ctx, sperr.WrapWithMessage(err, "error building payload for execution_error"))
+		return
+	}
+	_ = session.Write(payload)
+}
+
+// broadcastAvailableDashboards sends the available dashboards to all sessions
+// - if auth is enabled, each session only receives the dashboards and benchmarks its user may access
+func (s *Server) broadcastAvailableDashboards() error {
+	if !s.authorizer.Enabled() {
+		payload, err := buildAvailableDashboardsPayload(s.workspace.GetResourceMaps(), nil)
+		if err != nil {
+			return err
+		}
+		_ = s.webSocket.Broadcast(payload)
+		return nil
+	}
+
+	for _, clientInfo := range s.getDashboardClients() {
+		payload, err := buildAvailableDashboardsPayload(s.workspace.GetResourceMaps(), s.accessFilter(clientInfo.Session))
+		if err != nil {
+			return err
+		}
+		_ = clientInfo.Session.Write(payload)
+	}
+	return nil
+}
+
+// CanAccessTarget returns whether the user making the request may view and execute the named benchmark or dashboard
+// (false is returned if it does not exist)
+func (s *Server) CanAccessTarget(request *http.Request, name string) bool {
+	resource := s.getResource(name)
+	return resource != nil && s.authorizer.CanAccess(s.authorizer.GetIdentity(request), resource)
+}
+
+// withheldPanelError is the error of a panel whose data the user may not view
+const withheldPanelError = "you are not authorized to view this panel"
+
+// withheldPanel replaces a panel whose data the user may not view - it retains only the properties required to lay
+// out the panel
+type withheldPanel map[string]any
+
+func newWithheldPanel(panel map[string]any) withheldPanel {
+	res := withheldPanel{"status": dashboardtypes.RunError, "error": withheldPanelError}
+	for _, key := range []string{"name", "panel_type", "display_type", "dashboard", "title", "width"} {
+		if value, ok := panel[key]; ok {
+			res[key] = value
+		}
+	}
+	return res
+}
+
+// IsSnapshotPanel implements SnapshotPanel
+func (withheldPanel) IsSnapshotPanel() {}
+
+// getWithheldPanels returns the names of the panels of the execution root whose data the user making the request may
+// not view (or nil if auth is not enabled)
+func (s *Server) getWithheldPanels(request *http.Request, root dashboardtypes.DashboardTreeRun) map[string]struct{} {
+	if !s.authorizer.Enabled() {
+		return nil
+	}
+	identity := s.authorizer.GetIdentity(request)
+	resource := s.getResource(root.GetName())
+	if resource == nil {
+		return nil
+	}
+	return dashboardexecute.WithheldPanels(root, func(panelName string) bool {
+		return s.authorizer.CanViewPanel(identity, resource, panelName)
+	})
+}
+
+// setSessionWithheldPanels determines the panels of the execution root whose data the user of the session may not
+// view, so they are withheld from all payloads of the execution sent to the session
+func (s *Server) setSessionWithheldPanels(sessionId string, root dashboardtypes.DashboardTreeRun) map[string]struct{} {
+	s.mutex.Lock()
+	sessionInfo, ok := s.dashboardClients[sessionId]
+	s.mutex.Unlock()
+	if !ok {
+		return nil
+	}
+
+	withheld := s.getWithheldPanels(sessionInfo.Session.Request, root)
+	s.mutex.Lock()
+	sessionInfo.WithheldPanels = withheld
+	s.mutex.Unlock()
+	return withheld
+}
+
+// isWithheldFromSession returns whether the data of the panel of the current execution is withheld from the session
+func (s *Server) isWithheldFromSession(sessionId, panelName string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if sessionInfo, ok := s.dashboardClients[sessionId]; ok {
+		_, withheld := sessionInfo.WithheldPanels[panelName]
+		return withheld
+	}
+	return false
+}
+
+// withholdSnapshot withholds the data of the panels of the snapshot (as loaded by LoadSnapshot) which the user of
+// the session may not view - false is returned if the user may not access the dashboard or benchmark of the snapshot
+// (or it is no longer in the workspace, so its access cannot be determined)
+func (s *Server) withholdSnapshot(session *melody.Session, snap map[string]any) (bool, error) {
+	if !s.authorizer.Enabled() {
+		return true, nil
+	}
+	layoutData, err := json.Marshal(snap["layout"])
+	if err != nil {
+		return false, err
+	}
+	var layout steampipeconfig.SnapshotTreeNode
+	if err := json.Unmarshal(layoutData, &layout); err != nil {
+		return false, err
+	}
+	if layout.Name == "" {
+		return false, nil
+	}
+	resource := s.getResource(layout.Name)
+	if resource == nil || !s.canAccess(session, resource) {
+		return false, nil
+	}
+
+	identity := s.authorizer.GetIdentity(session.Request)
+	withheld := dashboardexecute.WithheldSnapshotPanels(&layout, func(panelName string) bool {
+		return s.authorizer.CanViewPanel(identity, resource, panelName)
+	})
+	if panels, ok := snap["panels"].(map[string]any); ok {
+		snap["panels"] = withholdPanels(panels, withheld)
+	}
+	return true, nil
+}
+
+// withholdPanels returns a copy of the panels of an execution_started payload, with the withheld panels replaced
+func withholdPanels(panels map[string]any, withheld map[string]struct{}) map[string]any {
+	if len(withheld) == 0 {
+		return panels
+	}
+	res := make(map[string]any, len(panels))
+	for name, panel := range panels {
+		if _, ok := withheld[name]; ok {
+			panelMap, _ := panel.(map[string]any)
+			panel = newWithheldPanel(panelMap)
+		}
+		res[name] = panel
+	}
+	return res
+}
+
+// withholdSnapshotPanels returns a copy of the panels of a snapshot, with the withheld panels replaced
+func withholdSnapshotPanels(panels map[string]steampipeconfig.SnapshotPanel, withheld map[string]struct{}) (map[string]steampipeconfig.SnapshotPanel, error) {
+	if len(withheld) == 0 {
+		return panels, nil
+	}
+	res := make(map[string]steampipeconfig.SnapshotPanel, len(panels))
+	for name, panel := range panels {
+		if _, ok := withheld[name]; ok {
+			panelMap, err := utils.JsonCloneToMap(panel)
+			if err != nil {
+				return nil, err
+			}
+			panel = newWithheldPanel(panelMap)
+		}
+		res[name] = panel
+	}
+	return res, nil
+}
@@ -0,0 +1,124 @@
+package dashboardserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/workspace"
+	"github.com/turbot/powerpipe/internal/dashboardworkspace"
+	"github.com/turbot/powerpipe/internal/rbac"
+	"gopkg.in/olahol/melody.v1"
+)
+
+func TestWithholdPanels(t *testing.T) {
+	panels := map[string]any{
+		"d.chart.cost": map[string]any{"name": "d.chart.cost", "panel_type": "chart", "width": 6, "sql": "select cost", "data": "rows"},
+		"d.card.total": map[string]any{"name": "d.card.total", "panel_type": "card", "sql": "select total"},
+	}
+	res, err := json.Marshal(withholdPanels(panels, map[string]struct{}{"d.chart.cost": {}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"d.card.total":{"name":"d.card.total","panel_type":"card","sql":"select total"},` +
+		`"d.chart.cost":{"error":"you are not authorized to view this panel","name":"d.chart.cost","panel_type":"chart","status":"error","width":6}}`
+	if string(res) != expected {
+		t.Errorf("Test: 'withheld' FAILED : expected %s, got %s", expected, res)
+	}
+	// the panels of the event are unchanged
+	if _, ok := panels["d.chart.cost"].(map[string]any)["sql"]; !ok {
+		t.Errorf("Test: 'unchanged' FAILED : expected the panels of the event to be unchanged")
+	}
+}
+
+// newTestSnapshot returns a snapshot (as loaded by LoadSnapshot) of the dashboard, with a chart and a table
+func newTestSnapshot(dashboardName string) map[string]any {
+	var snap map[string]any
+	_ = json.Unmarshal([]byte(`{
+		"layout": {"name": "`+dashboardName+`", "panel_type": "dashboard", "children": [
+			{"name": "d.chart.cost", "panel_type": "chart"},
+			{"name": "d.table.users", "panel_type": "table"}
+		]},
+		"panels": {
+			"`+dashboardName+`": {"name": "`+dashboardName+`", "panel_type": "dashboard"},
+			"d.chart.cost": {"name": "d.chart.cost", "panel_type": "chart", "data": "cost rows"},
+			"d.table.users": {"name": "d.table.users", "panel_type": "table", "data": "user rows"}
+		}
+	}`), &snap)
+	return snap
+}
+
+type withholdSnapshotTest struct {
+	identity  string
+	dashboard string
+	// the expected accessibility of the snapshot, and the panels which are withheld (if accessible)
+	expected         bool
+	expectedWithheld []string
+}
+
+var testCasesWithholdSnapshot = map[string]withholdSnapshotTest{
+	"panels withheld": {
+		identity:         "bob",
+		dashboard:        "d.dashboard.report",
+		expected:         true,
+		expectedWithheld: []string{"d.table.users"},
+	},
+	"dashboard not allowed": {
+		identity:  "carol",
+		dashboard: "d.dashboard.report",
+	},
+	"no identity": {
+		dashboard: "d.dashboard.report",
+	},
+	"dashboard not in workspace": {
+		identity:  "bob",
+		dashboard: "d.dashboard.removed",
+	},
+}
+
+func TestWithholdSnapshot(t *testing.T) {
+	mod := modconfig.NewMod("d", "", hcl.Range{})
+	mod.ResourceMaps = modconfig.NewResourceMaps(mod)
+	dashboard := &modconfig.Dashboard{}
+	dashboard.FullName = "d.dashboard.report"
+	mod.ResourceMaps.Dashboards[dashboard.FullName] = dashboard
+	s := &Server{
+		workspace: dashboardworkspace.NewWorkspaceEvents(&workspace.Workspace{Mod: mod}),
+		authorizer: rbac.NewAuthorizer(&rbac.Policy{Roles: []*rbac.Role{
+			{Name: "finance", Identities: []string{"bob"}, Dashboards: []string{"d.dashboard.*"}, Panels: []string{"d.chart.*"}},
+		}}),
+	}
+
+	for name, test := range testCasesWithholdSnapshot {
+		request := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		if test.identity != "" {
+			request.Header.Set(rbac.DefaultIdentityHeader, test.identity)
+		}
+		snap := newTestSnapshot(test.dashboard)
+		accessible, err := s.withholdSnapshot(&melody.Session{Request: request}, snap)
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		if accessible != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected accessible %v, got %v", name, test.expected, accessible)
+			continue
+		}
+		if !accessible {
+			continue
+		}
+		var withheld []string
+		for panelName, panel := range snap["panels"].(map[string]any) {
+			if _, ok := panel.(withheldPanel); ok {
+				withheld = append(withheld, panelName)
+			}
+		}
+		if !reflect.DeepEqual(withheld, test.expectedWithheld) {
+			t.Errorf("Test: '%s' FAILED : expected withheld panels %v, got %v", name, test.expectedWithheld, withheld)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package dashboardserver
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/powerpipe/internal/badge"
+	"github.com/turbot/powerpipe/internal/dashboardevents"
+	"github.com/turbot/powerpipe/internal/dashboardexecute"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+// recordBadge stores the badge for a completed benchmark run, replacing the badge of any previous run
+func (s *Server) recordBadge(e *dashboardevents.ExecutionComplete) {
+	checkRun, ok := e.Root.(*dashboardexecute.CheckRun)
+	if !ok || checkRun.Summary == nil || checkRun.GetRunStatus() != dashboardtypes.RunComplete {
+		return
+	}
+	label := checkRun.GetTitle()
+	if label == "" {
+		label = checkRun.GetName()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.badges[checkRun.GetName()] = badge.NewBadge(label, checkRun.Summary.Status)
+}
+
+// LatestBadge returns the badge for the latest run of the named benchmark
+// a not found error is returned if the benchmark has not been run since the server started,
+// or if the user making the request may not access it
+func (s *Server) LatestBadge(request *http.Request, name string) (*badge.Badge, error) {
+	notFound := perr.NotFoundWithMessage(fmt.Sprintf("no runs of %s found", name))
+
+	resource, ok := s.getResource(name).(*modconfig.Benchmark)
+	if !ok || !s.authorizer.CanAccess(s.authorizer.GetIdentity(request), resource) {
+		return nil, notFound
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	b, ok := s.badges[resource.Name()]
+	if !ok {
+		return nil, notFound
+	}
+	return b, nil
+}
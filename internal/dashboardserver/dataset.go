@@ -0,0 +1,43 @@
+package dashboardserver
+
+import (
+	"context"
+	"log/slog"
+
+	typeHelpers "github.com/turbot/go-kit/types"
+	"github.com/turbot/powerpipe/internal/dashboardexecute"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+	"gopkg.in/olahol/melody.v1"
+)
+
+// uploadDataset parses an uploaded dataset and makes it available to queries executed for the session
+// if a dashboard is selected, it is re-executed so its panels may use the dataset
+func (s *Server) uploadDataset(ctx context.Context, session *melody.Session, request *ClientRequestDatasetPayload) {
+	sessionId := s.getSessionId(session)
+
+	dataset, parseErr := dashboardexecute.ParseDataset(request.Name, request.Format, []byte(request.Content))
+	if parseErr == nil {
+		slog.Debug("dataset uploaded", "session", sessionId, "table", dataset.TableName(), "rows", len(dataset.Rows))
+		dashboardexecute.Executor.SetDataset(sessionId, dataset)
+	}
+
+	payload, err := buildDatasetUploadedPayload(request.Name, dataset, parseErr)
+	if err != nil {
+		OutputError(ctx, sperr.WrapWithMessage(err, "error building payload for upload_dataset"))
+		return
+	}
+	_ = session.Write(payload)
+
+	if parseErr != nil {
+		return
+	}
+	dashboardClientInfo, ok := s.getDashboardClients()[sessionId]
+	if !ok || dashboardClientInfo.Dashboard == nil {
+		return
+	}
+	dashboard := s.getResource(typeHelpers.SafeString(dashboardClientInfo.Dashboard))
+	if dashboard == nil || !s.canAccess(session, dashboard) {
+		return
+	}
+	_ = dashboardexecute.Executor.ExecuteDashboard(ctx, sessionId, dashboard, dashboardClientInfo.DashboardInputs, s.workspace)
+}
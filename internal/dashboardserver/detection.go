@@ -0,0 +1,20 @@
+package dashboardserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/dashboardevents"
+	"github.com/turbot/powerpipe/internal/detection"
+)
+
+// OnDetectionComplete is the detection.FindingsHandler for the server
+// it publishes a DetectionComplete event so findings reach all dashboard event handlers
+func (s *Server) OnDetectionComplete(ctx context.Context, d *detection.Detection, findings []*detection.Finding, err error) {
+	s.workspace.PublishDashboardEvent(ctx, &dashboardevents.DetectionComplete{
+		Detection: d,
+		Findings:  findings,
+		Error:     err,
+		Timestamp: time.Now(),
+	})
+}
@@ -0,0 +1,14 @@
+package dashboardserver
+
+import (
+	"context"
+
+	"github.com/turbot/powerpipe/internal/dashboardevents"
+	"github.com/turbot/powerpipe/internal/modsource"
+)
+
+// OnModInstallEvent is the modsource.InstallEventHandler for installs started through the API
+// it publishes a ModInstallEvent so the progress of the install is sent to all connected clients
+func (s *Server) OnModInstallEvent(ctx context.Context, event modsource.InstallEvent) {
+	s.workspace.PublishDashboardEvent(ctx, &dashboardevents.ModInstallEvent{Event: event})
+}
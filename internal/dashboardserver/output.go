@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/fatih/color"
 	"github.com/mattn/go-isatty"
+	"github.com/turbot/powerpipe/internal/verbosity"
 	"log/slog"
 	"os"
 )
@@ -22,7 +23,9 @@ func output(_ context.Context, prefix string, msg interface{}) {
 }
 
 func OutputMessage(ctx context.Context, msg string) {
-	output(ctx, applyColor(messagePrefix, color.HiGreenString), msg)
+	if !verbosity.Quiet() {
+		output(ctx, applyColor(messagePrefix, color.HiGreenString), msg)
+	}
 	slog.Info(msg)
 }
 
@@ -42,7 +45,9 @@ func OutputReady(ctx context.Context, msg string) {
 }
 
 func OutputWait(ctx context.Context, msg string) {
-	output(ctx, applyColor(waitPrefix, color.CyanString), msg)
+	if !verbosity.Quiet() {
+		output(ctx, applyColor(waitPrefix, color.CyanString), msg)
+	}
 	slog.Info(msg)
 }
 
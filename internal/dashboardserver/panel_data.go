@@ -0,0 +1,119 @@
+package dashboardserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/queryresult"
+	"github.com/turbot/powerpipe/internal/dashboardexecute"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+	"github.com/turbot/powerpipe/internal/display"
+)
+
+// PanelDataResponse is the json export of the data of a panel
+type PanelDataResponse struct {
+	ExecutionId string                   `json:"execution_id"`
+	Dashboard   string                   `json:"dashboard"`
+	Panel       string                   `json:"panel"`
+	Columns     []*queryresult.ColumnDef `json:"columns"`
+	Rows        []map[string]any         `json:"rows"`
+}
+
+// PanelData returns the latest data of a panel of a dashboard execution, for download by the UI or scripted extraction
+// a not found error is returned if the execution or panel do not exist, or if the user making the request may not
+// access the dashboard or view the panel
+func (s *Server) PanelData(ctx context.Context, request *http.Request, executionId, panelName string) (*dashboardexecute.PanelData, error) {
+	notFound := perr.NotFoundWithMessage(fmt.Sprintf("panel %s not found in execution %s", panelName, executionId))
+	if dashboardexecute.Executor == nil {
+		return nil, notFound
+	}
+
+	// check access to the dashboard before reading the data (which may execute the query of a paged table)
+	root, found := dashboardexecute.Executor.GetExecutionRoot(executionId)
+	if !found {
+		return nil, notFound
+	}
+	if s.authorizer.Enabled() {
+		resource := s.getResource(root.GetName())
+		if resource == nil || !s.authorizer.CanAccess(s.authorizer.GetIdentity(request), resource) {
+			return nil, notFound
+		}
+		if _, withheld := s.getWithheldPanels(request, root)[panelName]; withheld {
+			return nil, notFound
+		}
+	}
+
+	panelData, found, err := dashboardexecute.Executor.GetPanelData(ctx, executionId, panelName)
+	if !found {
+		return nil, notFound
+	}
+	// (execution ids are unique, so the execution is the one checked above)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case panelData.Status == dashboardtypes.RunError:
+		return nil, perr.ConflictWithMessage(fmt.Sprintf("panel %s failed", panelName))
+	case panelData.Status != dashboardtypes.RunComplete:
+		return nil, perr.ConflictWithMessage(fmt.Sprintf("panel %s is %s - data is available once it is complete", panelName, panelData.Status))
+	case panelData.Data == nil:
+		return nil, perr.BadRequestWithMessage(fmt.Sprintf("panel %s has no data", panelName))
+	}
+	return panelData, nil
+}
+
+// PanelDataJSON returns the json export of the data of a panel
+func PanelDataJSON(panelData *dashboardexecute.PanelData) ([]byte, error) {
+	return json.Marshal(PanelDataResponse{
+		ExecutionId: panelData.ExecutionId,
+		Dashboard:   panelData.Target,
+		Panel:       panelData.Panel,
+		Columns:     panelData.Data.Columns,
+		Rows:        panelData.Data.Rows,
+	})
+}
+
+// PanelDataCSV returns the csv export of the data of a panel, with a header row
+// values are formatted as they are by 'query --output csv'
+func PanelDataCSV(panelData *dashboardexecute.PanelData) ([]byte, error) {
+	var buf bytes.Buffer
+	csvWriter := csv.NewWriter(&buf)
+
+	columns := panelData.Data.Columns
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		// respect the original name of duplicate columns
+		header[i] = c.Name
+		if c.OriginalName != "" {
+			header[i] = c.OriginalName
+		}
+	}
+	if err := csvWriter.Write(header); err != nil {
+		return nil, err
+	}
+
+	for _, row := range panelData.Data.Rows {
+		record := make([]string, len(columns))
+		for i, c := range columns {
+			val, err := display.ColumnValueAsString(row[c.Name], c, display.WithNullString(""))
+			if err != nil {
+				return nil, err
+			}
+			record[i] = val
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
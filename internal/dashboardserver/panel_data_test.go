@@ -0,0 +1,53 @@
+package dashboardserver
+
+import (
+	"testing"
+
+	"github.com/turbot/pipe-fittings/queryresult"
+	"github.com/turbot/powerpipe/internal/dashboardexecute"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+type panelDataCSVTest struct {
+	data     *dashboardtypes.LeafData
+	expected string
+}
+
+var testCasesPanelDataCSV = map[string]panelDataCSVTest{
+	"values": {
+		data: &dashboardtypes.LeafData{
+			Columns: []*queryresult.ColumnDef{{Name: "id", DataType: "INT8"}, {Name: "name", DataType: "TEXT"}, {Name: "tags", DataType: "JSONB"}},
+			Rows: []map[string]any{
+				{"id": int64(1), "name": "a,b", "tags": map[string]any{"owner": "ops"}},
+				{"id": int64(2), "name": nil, "tags": nil},
+			},
+		},
+		expected: "id,name,tags\n1,\"a,b\",\"{\"\"owner\"\":\"\"ops\"\"}\"\n2,,\n",
+	},
+	"duplicate columns": {
+		data: &dashboardtypes.LeafData{
+			Columns: []*queryresult.ColumnDef{{Name: "id", DataType: "TEXT"}, {Name: "id_1", OriginalName: "id", DataType: "TEXT"}},
+			Rows:    []map[string]any{{"id": "x", "id_1": "y"}},
+		},
+		expected: "id,id\nx,y\n",
+	},
+	"no rows": {
+		data: &dashboardtypes.LeafData{
+			Columns: []*queryresult.ColumnDef{{Name: "id", DataType: "TEXT"}},
+		},
+		expected: "id\n",
+	},
+}
+
+func TestPanelDataCSV(t *testing.T) {
+	for name, test := range testCasesPanelDataCSV {
+		actual, err := PanelDataCSV(&dashboardexecute.PanelData{Data: test.data})
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : expected no error, got %v", name, err)
+			continue
+		}
+		if string(actual) != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %q, got %q", name, test.expected, string(actual))
+		}
+	}
+}
@@ -16,8 +16,11 @@ import (
 	"github.com/turbot/powerpipe/internal/dashboardassets"
 	"github.com/turbot/powerpipe/internal/dashboardevents"
 	"github.com/turbot/powerpipe/internal/dashboardexecute"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
 	"github.com/turbot/powerpipe/internal/dashboardworkspace"
 	"github.com/turbot/powerpipe/internal/db_client"
+	"github.com/turbot/powerpipe/internal/locale"
+	"github.com/turbot/powerpipe/internal/report"
 	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
 )
 
@@ -57,6 +60,7 @@ func buildServerMetadataPayload(workspaceResources *modconfig.ResourceMaps, clou
 			},
 			InstalledMods: installedMods,
 			Telemetry:     viper.GetString(constants.ArgTelemetry),
+			Locale:        buildLocaleMetadata(locale.Current()),
 		},
 	}
 
@@ -82,6 +86,20 @@ func buildServerMetadataPayload(workspaceResources *modconfig.ResourceMaps, clou
 	return json.Marshal(payload)
 }
 
+func buildLocaleMetadata(settings *locale.Settings) *LocaleMetadata {
+	res := &LocaleMetadata{
+		Timezone:   settings.Timezone,
+		DateFormat: locale.DateFormats[settings.DateFormat].UiLayout,
+		Currency:   settings.Currency,
+	}
+	// if no number format is set, the UI formats numbers for the browser locale
+	if settings.NumberFormat != "" {
+		res.ThousandsSeparator = settings.ThousandsSeparator
+		res.DecimalSeparator = settings.DecimalSeparator
+	}
+	return res
+}
+
 func buildDashboardMetadataPayload(ctx context.Context, dashboard modconfig.ModTreeItem, w *dashboardworkspace.WorkspaceEvents) ([]byte, error) {
 	defaultDatabase, defaultSearchPathConfig := db_client.GetDefaultDatabaseConfig()
 	database, searchPathConfig, err := db_client.GetDatabaseConfigForResource(dashboard, w.Mod, defaultDatabase, defaultSearchPathConfig)
@@ -151,7 +169,9 @@ func addBenchmarkChildren(benchmark *modconfig.Benchmark, recordTrunk bool, trun
 	return children
 }
 
-func buildAvailableDashboardsPayload(workspaceResources *modconfig.ResourceMaps) ([]byte, error) {
+// buildAvailableDashboardsPayload builds the available dashboards and benchmarks payload
+// - if canAccess is set, only the dashboards and benchmarks it allows are included
+func buildAvailableDashboardsPayload(workspaceResources *modconfig.ResourceMaps, canAccess func(modconfig.ModTreeItem) bool) ([]byte, error) {
 
 	payload := AvailableDashboardsPayload{
 		Action:     "available_dashboards",
@@ -166,6 +186,13 @@ func buildAvailableDashboardsPayload(workspaceResources *modconfig.ResourceMaps)
 
 		// iterate over the dashboards for the top level mod - this will include the dashboards from dependency mods
 		for _, dashboard := range workspaceResources.Mod.ResourceMaps.Dashboards {
+			if canAccess != nil && !canAccess(dashboard) {
+				continue
+			}
+			// reports are rendered for print using 'dashboard run --export', rather than in the UI
+			if report.IsReport(dashboard.Tags) {
+				continue
+			}
 			mod := dashboard.Mod
 			// add this dashboard
 			payload.Dashboards[dashboard.FullName] = ModAvailableDashboard{
@@ -179,7 +206,7 @@ func buildAvailableDashboardsPayload(workspaceResources *modconfig.ResourceMaps)
 
 		benchmarkTrunks := make(map[string][][]string)
 		for _, benchmark := range workspaceResources.Mod.ResourceMaps.Benchmarks {
-			if benchmark.IsAnonymous() {
+			if benchmark.IsAnonymous() || (canAccess != nil && !canAccess(benchmark)) {
 				continue
 			}
 
@@ -265,16 +292,19 @@ func buildLeafNodeUpdatedPayload(event *dashboardevents.LeafNodeUpdated) ([]byte
 	return json.Marshal(payload)
 }
 
-func buildExecutionStartedPayload(event *dashboardevents.ExecutionStarted) ([]byte, error) {
+// the withheld panels are those whose data the user of the session may not view
+func buildExecutionStartedPayload(event *dashboardevents.ExecutionStarted, withheld map[string]struct{}) ([]byte, error) {
 	payload := ExecutionStartedPayload{
-		SchemaVersion: fmt.Sprintf("%d", ExecutionStartedSchemaVersion),
-		Action:        "execution_started",
-		ExecutionId:   event.ExecutionId,
-		Panels:        event.Panels,
-		Layout:        event.Root.AsTreeNode(),
-		Inputs:        event.Inputs,
-		Variables:     event.Variables,
-		StartTime:     event.StartTime,
+		SchemaVersion:     fmt.Sprintf("%d", ExecutionStartedSchemaVersion),
+		Action:            "execution_started",
+		ExecutionId:       event.ExecutionId,
+		Panels:            withholdPanels(event.Panels, withheld),
+		Layout:            event.Root.AsTreeNode(),
+		Inputs:            event.Inputs,
+		InputDependencies: event.InputDependencies,
+		Variables:         event.Variables,
+		Warnings:          event.Warnings,
+		StartTime:         event.StartTime,
 	}
 	return json.Marshal(payload)
 }
@@ -288,8 +318,14 @@ func buildExecutionErrorPayload(event *dashboardevents.ExecutionError) ([]byte,
 	return json.Marshal(payload)
 }
 
-func buildExecutionCompletePayload(event *dashboardevents.ExecutionComplete) ([]byte, error) {
+// the withheld panels are those whose data the user of the session may not view
+func buildExecutionCompletePayload(event *dashboardevents.ExecutionComplete, withheld map[string]struct{}) ([]byte, error) {
 	snap := dashboardexecute.ExecutionCompleteToSnapshot(event)
+	panels, err := withholdSnapshotPanels(snap.Panels, withheld)
+	if err != nil {
+		return nil, err
+	}
+	snap.Panels = panels
 	payload := &ExecutionCompletePayload{
 		Action:        "execution_complete",
 		SchemaVersion: fmt.Sprintf("%d", ExecutionCompletePayloadSchemaVersion),
@@ -316,3 +352,60 @@ func buildInputValuesClearedPayload(event *dashboardevents.InputValuesCleared) (
 	}
 	return json.Marshal(payload)
 }
+
+func buildDetectionCompletePayload(event *dashboardevents.DetectionComplete) ([]byte, error) {
+	payload := DetectionCompletePayload{
+		Action:    "detection_complete",
+		Detection: event.Detection.Name,
+		Severity:  event.Detection.Severity,
+		Findings:  event.Findings,
+		Timestamp: event.Timestamp,
+	}
+	if event.Error != nil {
+		payload.Error = event.Error.Error()
+	}
+	return json.Marshal(payload)
+}
+
+func buildModInstallEventPayload(event *dashboardevents.ModInstallEvent) ([]byte, error) {
+	payload := ModInstallEventPayload{
+		Action: "mod_install_event",
+		Event:  event.Event,
+	}
+	return json.Marshal(payload)
+}
+
+func buildApprovalChangedPayload(event *dashboardevents.ApprovalChanged) ([]byte, error) {
+	payload := ApprovalChangedPayload{
+		Action:  "approval_changed",
+		Request: event.Request,
+	}
+	return json.Marshal(payload)
+}
+
+func buildTablePagePayload(panel string, data *dashboardtypes.LeafData, err error) ([]byte, error) {
+	payload := TablePagePayload{
+		Action: "table_page",
+		Panel:  panel,
+		Data:   data,
+	}
+	if err != nil {
+		payload.Error = err.Error()
+	}
+	return json.Marshal(payload)
+}
+
+func buildDatasetUploadedPayload(name string, dataset *dashboardexecute.Dataset, err error) ([]byte, error) {
+	payload := DatasetUploadedPayload{
+		Action: "dataset_uploaded",
+		Name:   name,
+	}
+	if err != nil {
+		payload.Error = err.Error()
+	} else {
+		payload.Table = dataset.TableName()
+		payload.Columns = dataset.Columns
+		payload.RowCount = len(dataset.Rows)
+	}
+	return json.Marshal(payload)
+}
@@ -0,0 +1,141 @@
+package dashboardserver
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/olahol/melody.v1"
+)
+
+// the kinds of recorded websocket event
+const (
+	RecordedConnect    = "connect"
+	RecordedDisconnect = "disconnect"
+	// a message sent by the client to the server
+	RecordedInbound = "in"
+	// a message sent by the server to the client
+	RecordedOutbound = "out"
+)
+
+// RecordedMessage is a single websocket event of a recorded session
+// recordings are written as json lines, one RecordedMessage per line, in the order the events occurred
+type RecordedMessage struct {
+	Time      time.Time       `json:"time"`
+	SessionId string          `json:"session_id"`
+	Direction string          `json:"direction"`
+	Message   json.RawMessage `json:"message,omitempty"`
+}
+
+// SessionRecorder records the websocket message exchange of all dashboard sessions to a file, so that
+// UI/server bugs can be reproduced by replaying the session (see Replay)
+//
+// NOTE: the recording contains the input values entered and the data displayed by the dashboards
+type SessionRecorder struct {
+	mut    sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+}
+
+// NewSessionRecorder creates a recorder writing to the given path - any existing recording is overwritten
+func NewSessionRecorder(path string) (*SessionRecorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session recording: %w", err)
+	}
+	return &SessionRecorder{file: file, writer: bufio.NewWriter(file)}, nil
+}
+
+// Record writes an event of the given session to the recording
+// it is a no-op for a nil recorder, so callers need not check whether recording is enabled
+func (r *SessionRecorder) Record(sessionId, direction string, msg []byte) {
+	if r == nil {
+		return
+	}
+	entry := RecordedMessage{
+		Time:      time.Now(),
+		SessionId: sessionId,
+		Direction: direction,
+	}
+	// messages are json - anything else is recorded as a string so the recording remains valid
+	if len(msg) > 0 {
+		if json.Valid(msg) {
+			entry.Message = msg
+		} else {
+			entry.Message, _ = json.Marshal(string(msg))
+		}
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	_, _ = r.writer.Write(append(data, '\n'))
+	// flush each event, so the recording is complete even if the server is killed
+	_ = r.writer.Flush()
+}
+
+// Close flushes and closes the recording
+func (r *SessionRecorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if err := r.writer.Flush(); err != nil {
+		_ = r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+// RecordSessions records the websocket message exchange of all dashboard sessions using the recorder
+// this must be called before InitAsync
+func (s *Server) RecordSessions(recorder *SessionRecorder) {
+	s.recorder = recorder
+}
+
+// register the handlers which record the events not seen by the message handler
+func (s *Server) registerRecordingHandlers() {
+	if s.recorder == nil {
+		return
+	}
+	s.webSocket.HandleSentMessage(func(session *melody.Session, msg []byte) {
+		s.recorder.Record(s.getSessionId(session), RecordedOutbound, msg)
+	})
+}
+
+// LoadRecording reads a session recording written by a SessionRecorder
+func LoadRecording(path string) ([]RecordedMessage, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var res []RecordedMessage
+	scanner := bufio.NewScanner(file)
+	// payloads containing query results may be large
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry RecordedMessage
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid session recording '%s': line %d: %w", path, line, err)
+		}
+		res = append(res, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read session recording '%s': %w", path, err)
+	}
+	return res, nil
+}
@@ -0,0 +1,103 @@
+package dashboardserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/olahol/melody.v1"
+)
+
+type replayTest struct {
+	// the recorded messages sent by the client, and the actions of the responses of the recorded server
+	sent     []string
+	received []string
+	// the actions the replayed server responds to each message with
+	responses map[string][]string
+	matches   bool
+}
+
+var testCasesReplay = map[string]replayTest{
+	"same responses": {
+		sent:     []string{`{"action":"get_server_metadata"}`, `{"action":"get_available_dashboards"}`},
+		received: []string{"server_metadata", "available_dashboards"},
+		responses: map[string][]string{
+			"get_server_metadata":      {"server_metadata"},
+			"get_available_dashboards": {"available_dashboards"},
+		},
+		matches: true,
+	},
+	"different responses": {
+		sent:     []string{`{"action":"select_dashboard"}`},
+		received: []string{"execution_started", "execution_complete"},
+		responses: map[string][]string{
+			"select_dashboard": {"execution_started", "execution_error"},
+		},
+		matches: false,
+	},
+}
+
+func TestReplay(t *testing.T) {
+	for name, test := range testCasesReplay {
+		// record the session
+		path := filepath.Join(t.TempDir(), "session.jsonl")
+		recorder, err := NewSessionRecorder(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder.Record("session", RecordedConnect, nil)
+		for _, msg := range test.sent {
+			recorder.Record("session", RecordedInbound, []byte(msg))
+		}
+		for _, action := range test.received {
+			recorder.Record("session", RecordedOutbound, []byte(`{"action":"`+action+`"}`))
+		}
+		recorder.Record("session", RecordedDisconnect, nil)
+		if err := recorder.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		recording, err := LoadRecording(path)
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : expected the recording to load, got %v", name, err)
+			continue
+		}
+		if expected := len(test.sent) + len(test.received) + 2; len(recording) != expected {
+			t.Errorf("Test: '%s' FAILED : expected %d recorded messages, got %d", name, expected, len(recording))
+			continue
+		}
+
+		// replay it against a server which responds to each action
+		webSocket := melody.New()
+		webSocket.HandleMessage(func(session *melody.Session, msg []byte) {
+			for _, response := range test.responses[messageAction(msg)] {
+				_ = session.Write([]byte(`{"action":"` + response + `"}`))
+			}
+		})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = webSocket.HandleRequest(w, r)
+		}))
+		url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+		results, err := Replay(context.Background(), recording, ReplayOptions{URL: url, Wait: 200 * time.Millisecond})
+		server.Close()
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : expected no error, got %v", name, err)
+			continue
+		}
+		if len(results) != 1 {
+			t.Errorf("Test: '%s' FAILED : expected 1 session, got %d", name, len(results))
+			continue
+		}
+		if results[0].Sent != len(test.sent) {
+			t.Errorf("Test: '%s' FAILED : expected %d messages sent, got %d", name, len(test.sent), results[0].Sent)
+		}
+		if actual := results[0].Matches(); actual != test.matches {
+			t.Errorf("Test: '%s' FAILED : expected matches %v, got %v (recorded %v, replayed %v)", name, test.matches, actual, results[0].Recorded, results[0].Replayed)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package dashboardserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+)
+
+// ReplayOptions controls how a session recording is replayed
+type ReplayOptions struct {
+	// the websocket url of the dashboard server, e.g. ws://localhost:9033/ws
+	URL string
+	// the speed multiplier of the recorded timing - 0 sends the messages without delay
+	Speed float64
+	// how long to wait for the responses to the last message
+	Wait time.Duration
+}
+
+// ReplaySessionResult compares the messages sent to a replayed session with those sent to the recorded session
+// messages are compared by action, as the execution ids and timestamps of each run differ
+type ReplaySessionResult struct {
+	SessionId string
+	// the number of messages sent to the server
+	Sent int
+	// the count of each action the server sent to the recorded and replayed sessions
+	Recorded map[string]int
+	Replayed map[string]int
+
+	mut sync.Mutex
+}
+
+// Actions returns the actions sent to either the recorded or replayed session, in order
+func (r *ReplaySessionResult) Actions() []string {
+	var res []string
+	for action := range r.Recorded {
+		res = append(res, action)
+	}
+	for action := range r.Replayed {
+		if _, ok := r.Recorded[action]; !ok {
+			res = append(res, action)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Matches returns whether the server sent the same actions to the replayed session as the recorded session
+func (r *ReplaySessionResult) Matches() bool {
+	for _, action := range r.Actions() {
+		if r.Recorded[action] != r.Replayed[action] {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *ReplaySessionResult) addReplayed(action string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.Replayed[action]++
+}
+
+type replaySession struct {
+	result *ReplaySessionResult
+	conn   *websocket.Conn
+	done   chan struct{}
+}
+
+// Replay re-drives a session recording against the dashboard server at opts.URL
+// each recorded session is replayed using its own websocket connection, and the messages the client sent are
+// resent in the recorded order and (scaled) timing
+//
+// if the recorded timing is not preserved (opts.Speed is 0) the connections are closed once all messages are
+// sent, rather than when the recorded sessions disconnected, so the server has time to respond
+func Replay(ctx context.Context, recording []RecordedMessage, opts ReplayOptions) ([]*ReplaySessionResult, error) {
+	sessions := make(map[string]*replaySession)
+	var res []*ReplaySessionResult
+	defer func() {
+		for _, s := range sessions {
+			s.close()
+		}
+	}()
+
+	getSession := func(sessionId string) *replaySession {
+		if s, ok := sessions[sessionId]; ok {
+			return s
+		}
+		s := &replaySession{
+			result: &ReplaySessionResult{
+				SessionId: sessionId,
+				Recorded:  make(map[string]int),
+				Replayed:  make(map[string]int),
+			},
+		}
+		sessions[sessionId] = s
+		res = append(res, s.result)
+		return s
+	}
+
+	var previous time.Time
+	for _, entry := range recording {
+		if err := waitForEntry(ctx, previous, entry.Time, opts.Speed); err != nil {
+			return res, err
+		}
+		previous = entry.Time
+
+		s := getSession(entry.SessionId)
+		switch entry.Direction {
+		case RecordedConnect:
+			if err := s.connect(ctx, opts.URL); err != nil {
+				return res, err
+			}
+		case RecordedInbound:
+			// recordings started while a client was already connected have no connect event
+			if err := s.connect(ctx, opts.URL); err != nil {
+				return res, err
+			}
+			if err := s.conn.WriteMessage(websocket.TextMessage, entry.Message); err != nil {
+				return res, fmt.Errorf("failed to send message to dashboard server: %w", err)
+			}
+			s.result.Sent++
+		case RecordedOutbound:
+			s.result.Recorded[messageAction(entry.Message)]++
+		case RecordedDisconnect:
+			if opts.Speed > 0 {
+				s.close()
+			}
+		}
+	}
+
+	// wait for the responses to the last messages
+	select {
+	case <-ctx.Done():
+		return res, ctx.Err()
+	case <-time.After(opts.Wait):
+	}
+	for _, s := range sessions {
+		s.close()
+	}
+	return res, nil
+}
+
+// wait for the (scaled) interval between two recorded events
+func waitForEntry(ctx context.Context, previous, next time.Time, speed float64) error {
+	if speed <= 0 || previous.IsZero() || !next.After(previous) {
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Duration(float64(next.Sub(previous)) / speed)):
+		return nil
+	}
+}
+
+// dial the server (if not already connected) and count the actions of the messages it sends
+func (s *replaySession) connect(ctx context.Context, url string) error {
+	if s.conn != nil {
+		return nil
+	}
+	// (the server may be remote, so the dialer uses the proxy of the environment and applies the TLS policy)
+	dialer := *websocket.DefaultDialer
+	dialer.TLSClientConfig = tlspolicy.Apply(nil)
+	conn, _, err := dialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to dashboard server at %s: %w", url, err)
+	}
+	s.conn = conn
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				slog.Debug("replay session closed", "session", s.result.SessionId, "error", err)
+				return
+			}
+			s.result.addReplayed(messageAction(msg))
+		}
+	}()
+	return nil
+}
+
+func (s *replaySession) close() {
+	if s.conn == nil {
+		return
+	}
+	_ = s.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	_ = s.conn.Close()
+	<-s.done
+	s.conn = nil
+}
+
+func messageAction(msg []byte) string {
+	var message struct {
+		Action string `json:"action"`
+	}
+	if err := json.Unmarshal(msg, &message); err != nil || message.Action == "" {
+		return "unknown"
+	}
+	return message.Action
+}
@@ -3,6 +3,7 @@ package dashboardserver
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
 	"log/slog"
@@ -17,9 +18,13 @@ import (
 	"github.com/turbot/pipe-fittings/error_helpers"
 	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/powerpipe/internal/badge"
 	"github.com/turbot/powerpipe/internal/dashboardevents"
 	"github.com/turbot/powerpipe/internal/dashboardexecute"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
 	"github.com/turbot/powerpipe/internal/dashboardworkspace"
+	"github.com/turbot/powerpipe/internal/rbac"
 	"gopkg.in/olahol/melody.v1"
 )
 
@@ -28,9 +33,27 @@ type Server struct {
 	dashboardClients map[string]*DashboardClientInfo
 	webSocket        *melody.Melody
 	workspace        *dashboardworkspace.WorkspaceEvents
+	// sessions created for runs triggered through the API or by the server (rather than by a websocket client),
+	// keyed by session id
+	triggeredSessions map[string]*triggeredSession
+	// restricts the dashboards and benchmarks available to each user (nil if auth is not enabled)
+	authorizer *rbac.Authorizer
+	// the badge for the latest run of each benchmark, keyed by benchmark name
+	badges map[string]*badge.Badge
+	// records the websocket message exchange of all sessions (nil if recording is not enabled)
+	recorder *SessionRecorder
+	// the inputs last used by each user for each dashboard (nil if auth is not enabled)
+	userDefaults *UserDefaults
+	// the dashboards and benchmarks currently being warmed up
+	warmingUp map[string]struct{}
 }
 
-func NewServer(ctx context.Context, w *dashboardworkspace.WorkspaceEvents, webSocket *melody.Melody) (*Server, error) {
+// MaxMessageSize is the maximum size of a message received from a client - messages are small, other than dataset
+// uploads, whose content (which is JSON encoded, so may be larger than the dataset) is limited to
+// dashboardexecute.MaxDatasetSize
+const MaxMessageSize = 2*dashboardexecute.MaxDatasetSize + 64*1024
+
+func NewServer(ctx context.Context, w *dashboardworkspace.WorkspaceEvents, webSocket *melody.Melody, authorizer *rbac.Authorizer) (*Server, error) {
 	OutputWait(ctx, "Starting WorkspaceEvents Server")
 
 	var dashboardClients = make(map[string]*DashboardClientInfo)
@@ -38,10 +61,15 @@ func NewServer(ctx context.Context, w *dashboardworkspace.WorkspaceEvents, webSo
 	var mutex = &sync.Mutex{}
 
 	server := &Server{
-		mutex:            mutex,
-		dashboardClients: dashboardClients,
-		webSocket:        webSocket,
-		workspace:        w,
+		mutex:             mutex,
+		dashboardClients:  dashboardClients,
+		webSocket:         webSocket,
+		workspace:         w,
+		triggeredSessions: make(map[string]*triggeredSession),
+		warmingUp:         make(map[string]struct{}),
+		authorizer:        authorizer,
+		badges:            make(map[string]*badge.Badge),
+		userDefaults:      loadUserDefaults(authorizer),
 	}
 
 	w.RegisterDashboardEventHandler(ctx, server.HandleDashboardEvent)
@@ -99,7 +127,7 @@ func (s *Server) HandleDashboardEvent(ctx context.Context, event dashboardevents
 
 	case *dashboardevents.ExecutionStarted:
 		slog.Debug("ExecutionStarted event", "session ", e.Session, "dashboard", e.Root.GetName())
-		payload, payloadError = buildExecutionStartedPayload(e)
+		payload, payloadError = buildExecutionStartedPayload(e, s.setSessionWithheldPanels(e.Session, e.Root))
 		if payloadError != nil {
 			return
 		}
@@ -115,16 +143,19 @@ func (s *Server) HandleDashboardEvent(ctx context.Context, event dashboardevents
 
 		s.writePayloadToSession(e.Session, payload)
 		OutputError(ctx, e.Error)
+		s.completeTriggeredSession(ctx, e.Session, nil, e.Error)
 
 	case *dashboardevents.ExecutionComplete:
 		slog.Debug("execution complete event")
-		payload, payloadError = buildExecutionCompletePayload(e)
+		payload, payloadError = buildExecutionCompletePayload(e, s.setSessionWithheldPanels(e.Session, e.Root))
 		if payloadError != nil {
 			return
 		}
 		dashboardName := e.Root.GetName()
 		s.writePayloadToSession(e.Session, payload)
+		s.recordBadge(e)
 		OutputReady(ctx, fmt.Sprintf("Execution complete: %s", dashboardName))
+		s.completeTriggeredSession(ctx, e.Session, dashboardexecute.ExecutionCompleteToSnapshot(e), nil)
 
 	case *dashboardevents.ControlComplete:
 		slog.Debug("ControlComplete event", "session", e.Session, "control", e.Control.GetControlId())
@@ -143,6 +174,14 @@ func (s *Server) HandleDashboardEvent(ctx context.Context, event dashboardevents
 		s.writePayloadToSession(e.Session, payload)
 
 	case *dashboardevents.LeafNodeUpdated:
+		if panelName, _ := e.LeafNode["name"].(string); s.isWithheldFromSession(e.Session, panelName) {
+			e = &dashboardevents.LeafNodeUpdated{
+				LeafNode:    newWithheldPanel(e.LeafNode),
+				Session:     e.Session,
+				ExecutionId: e.ExecutionId,
+				Timestamp:   e.Timestamp,
+			}
+		}
 		payload, payloadError = buildLeafNodeUpdatedPayload(e)
 		if payloadError != nil {
 			return
@@ -171,6 +210,9 @@ func (s *Server) HandleDashboardEvent(ctx context.Context, event dashboardevents
 		changedTables := e.ChangedTables
 		changedTexts := e.ChangedTexts
 
+		// warm up any warm-up targets which have changed, so that their caches are populated with the new queries
+		s.warmUpChanged(ctx, e)
+
 		// If nothing has changed, ignore
 		if len(deletedDashboards) == 0 &&
 			len(newDashboards) == 0 &&
@@ -209,11 +251,10 @@ func (s *Server) HandleDashboardEvent(ctx context.Context, event dashboardevents
 			_ = s.webSocket.Broadcast(payload)
 
 			// Emit available dashboards event
-			payload, payloadError = buildAvailableDashboardsPayload(s.workspace.GetResourceMaps())
+			payloadError = s.broadcastAvailableDashboards()
 			if payloadError != nil {
 				return
 			}
-			_ = s.webSocket.Broadcast(payload)
 		}
 
 		var dashboardsBeingWatched []string
@@ -290,6 +331,35 @@ func (s *Server) HandleDashboardEvent(ctx context.Context, event dashboardevents
 			}
 		}
 
+	case *dashboardevents.DetectionComplete:
+		slog.Debug("DetectionComplete event", "detection", e.Detection.Name, "findings", len(e.Findings))
+		payload, payloadError = buildDetectionCompletePayload(e)
+		if payloadError != nil {
+			return
+		}
+		_ = s.webSocket.Broadcast(payload)
+		if e.Error != nil {
+			OutputError(ctx, sperr.WrapWithMessage(e.Error, "detection %s failed", e.Detection.Name))
+		} else if len(e.Findings) > 0 {
+			OutputWarning(ctx, fmt.Sprintf("Detection %s: %d %s", e.Detection.Name, len(e.Findings), utils.Pluralize("finding", len(e.Findings))))
+		}
+
+	case *dashboardevents.ModInstallEvent:
+		slog.Debug("ModInstallEvent event", "type", e.Event.Type, "mod", e.Event.Mod)
+		payload, payloadError = buildModInstallEventPayload(e)
+		if payloadError != nil {
+			return
+		}
+		_ = s.webSocket.Broadcast(payload)
+
+	case *dashboardevents.ApprovalChanged:
+		slog.Debug("ApprovalChanged event", "id", e.Request.Id, "status", e.Request.Status)
+		payload, payloadError = buildApprovalChangedPayload(e)
+		if payloadError != nil {
+			return
+		}
+		_ = s.webSocket.Broadcast(payload)
+
 	case *dashboardevents.InputValuesCleared:
 		payload, payloadError = buildInputValuesClearedPayload(e)
 		if payloadError != nil {
@@ -311,15 +381,18 @@ func (s *Server) InitAsync(ctx context.Context) {
 		// Return list of dashboards on connect
 		s.webSocket.HandleConnect(func(session *melody.Session) {
 			slog.Debug("client connected")
+			s.recorder.Record(s.getSessionId(session), RecordedConnect, nil)
 			s.addSession(session)
 		})
 
 		s.webSocket.HandleDisconnect(func(session *melody.Session) {
 			slog.Debug("client disconnected")
+			s.recorder.Record(s.getSessionId(session), RecordedDisconnect, nil)
 			s.clearSession(ctx, session)
 		})
 
 		s.webSocket.HandleMessage(s.handleMessageFunc(ctx))
+		s.registerRecordingHandlers()
 		OutputMessage(ctx, "Initialization complete")
 	}()
 }
@@ -328,6 +401,9 @@ func (s *Server) handleMessageFunc(ctx context.Context) func(session *melody.Ses
 	return func(session *melody.Session, msg []byte) {
 
 		sessionId := s.getSessionId(session)
+		s.recorder.Record(sessionId, RecordedInbound, msg)
+		// record the client address as the initiator of any executions started by this message
+		execCtx := dashboardexecute.WithRunInitiator(ctx, getSessionInitiator(session))
 
 		var request ClientRequest
 		// if we could not decode message - ignore
@@ -350,7 +426,7 @@ func (s *Server) handleMessageFunc(ctx context.Context) func(session *melody.Ses
 			_ = session.Write(payload)
 		case "get_dashboard_metadata":
 			dashboard := s.getResource(request.Payload.Dashboard.FullName)
-			if dashboard == nil {
+			if dashboard == nil || !s.canAccess(session, dashboard) {
 				return
 			}
 			payload, err := buildDashboardMetadataPayload(ctx, dashboard, s.workspace)
@@ -359,7 +435,7 @@ func (s *Server) handleMessageFunc(ctx context.Context) func(session *melody.Ses
 			}
 			_ = session.Write(payload)
 		case "get_available_dashboards":
-			payload, err := buildAvailableDashboardsPayload(s.workspace.GetResourceMaps())
+			payload, err := buildAvailableDashboardsPayload(s.workspace.GetResourceMaps(), s.accessFilter(session))
 			if err != nil {
 				OutputError(ctx, sperr.WrapWithMessage(err, "error building payload for get_available_dashboards"))
 			}
@@ -369,6 +445,16 @@ func (s *Server) handleMessageFunc(ctx context.Context) func(session *melody.Ses
 			if dashboard == nil {
 				return
 			}
+			if !s.canAccess(session, dashboard) {
+				s.writeAccessDenied(ctx, session, request.Payload.Dashboard.FullName)
+				return
+			}
+			// restore the inputs the user last used for the dashboard, or save the inputs they selected it with
+			if len(request.Payload.InputValues) == 0 {
+				s.restoreUserDefaults(session, dashboard, &request.Payload)
+			} else {
+				s.saveUserDefaults(session, dashboard.Name(), request.Payload.InputValues, request.Payload.SearchPath, request.Payload.SearchPathPrefix)
+			}
 			s.setDashboardForSession(sessionId, request.Payload.Dashboard.FullName, request.Payload.InputValues)
 
 			// was a search path passed into the execute command?
@@ -379,24 +465,63 @@ func (s *Server) handleMessageFunc(ctx context.Context) func(session *melody.Ses
 					SearchPathPrefix: request.Payload.SearchPathPrefix,
 				}))
 			}
-			_ = dashboardexecute.Executor.ExecuteDashboard(ctx, sessionId, dashboard, request.Payload.InputValues, s.workspace, opts...)
+			_ = dashboardexecute.Executor.ExecuteDashboard(execCtx, sessionId, dashboard, request.Payload.InputValues, s.workspace, opts...)
 
 		case "select_snapshot":
 			snapshotName := request.Payload.Dashboard.FullName
 			s.setDashboardForSession(sessionId, snapshotName, request.Payload.InputValues)
 			snap, err := dashboardexecute.Executor.LoadSnapshot(ctx, sessionId, snapshotName, s.workspace)
-			// TACTICAL- handle with error message
-			error_helpers.FailOnError(err)
-			// error handling???
+			if err != nil {
+				s.writeExecutionError(ctx, session, sperr.WrapWithMessage(err, "error loading snapshot %s", snapshotName))
+				return
+			}
+			if accessible, err := s.withholdSnapshot(session, snap); err != nil {
+				s.writeExecutionError(ctx, session, sperr.WrapWithMessage(err, "error loading snapshot %s", snapshotName))
+				return
+			} else if !accessible {
+				s.writeAccessDenied(ctx, session, snapshotName)
+				return
+			}
 			payload, err := buildDisplaySnapshotPayload(snap)
-			// TACTICAL- handle with error message
-			error_helpers.FailOnError(err)
+			if err != nil {
+				OutputError(ctx, sperr.WrapWithMessage(err, "error building payload for select_snapshot"))
+				return
+			}
 
 			s.writePayloadToSession(sessionId, payload)
 			OutputReady(ctx, fmt.Sprintf("Show snapshot complete: %s", snapshotName))
 		case "input_changed":
 			s.setDashboardInputsForSession(sessionId, request.Payload.InputValues)
-			_ = dashboardexecute.Executor.OnInputChanged(ctx, sessionId, request.Payload.InputValues, request.Payload.ChangedInput)
+			if dashboardName := s.getSessionDashboard(sessionId); dashboardName != "" {
+				s.saveUserDefaults(session, dashboardName, request.Payload.InputValues, nil, nil)
+			}
+			_ = dashboardexecute.Executor.OnInputChanged(execCtx, sessionId, request.Payload.InputValues, request.Payload.ChangedInput)
+		case "get_table_page":
+			if request.Payload.TablePage == nil {
+				return
+			}
+			var data *dashboardtypes.LeafData
+			var pageErr error
+			if s.isWithheldFromSession(sessionId, request.Payload.Panel) {
+				pageErr = errors.New(withheldPanelError)
+			} else {
+				data, pageErr = dashboardexecute.Executor.GetTablePage(ctx, sessionId, request.Payload.Panel, request.Payload.TablePage)
+			}
+			payload, err := buildTablePagePayload(request.Payload.Panel, data, pageErr)
+			if err != nil {
+				OutputError(ctx, sperr.WrapWithMessage(err, "error building payload for get_table_page"))
+				return
+			}
+			_ = session.Write(payload)
+		case "retry_panel":
+			if err := dashboardexecute.Executor.RetryPanel(execCtx, sessionId, request.Payload.Panel); err != nil {
+				OutputError(ctx, sperr.WrapWithMessage(err, "error retrying panel"))
+			}
+		case "upload_dataset":
+			if request.Payload.Dataset == nil {
+				return
+			}
+			s.uploadDataset(execCtx, session, request.Payload.Dataset)
 		case "clear_dashboard":
 			s.setDashboardInputsForSession(sessionId, nil)
 			dashboardexecute.Executor.CancelExecutionForSession(ctx, sessionId)
@@ -412,6 +537,7 @@ func (s *Server) clearSession(ctx context.Context, session *melody.Session) {
 	sessionId := s.getSessionId(session)
 
 	dashboardexecute.Executor.CancelExecutionForSession(ctx, sessionId)
+	dashboardexecute.Executor.ClearDatasets(sessionId)
 
 	s.deleteDashboardClient(sessionId)
 }
@@ -437,6 +563,13 @@ func (s *Server) getSessionId(session *melody.Session) string {
 	return fmt.Sprintf("%p", session)
 }
 
+func getSessionInitiator(session *melody.Session) string {
+	if session.Request == nil {
+		return ""
+	}
+	return session.Request.RemoteAddr
+}
+
 // functions providing locked access to member properties
 
 func (s *Server) setDashboardForSession(sessionId string, dashboardName string, inputs map[string]interface{}) *DashboardClientInfo {
@@ -450,6 +583,17 @@ func (s *Server) setDashboardForSession(sessionId string, dashboardName string,
 	return dashboardClientInfo
 }
 
+// getSessionDashboard returns the name of the dashboard selected by the session, or "" if there is none
+func (s *Server) getSessionDashboard(sessionId string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if dashboardClientInfo, ok := s.dashboardClients[sessionId]; ok && dashboardClientInfo.Dashboard != nil {
+		return *dashboardClientInfo.Dashboard
+	}
+	return ""
+}
+
 func (s *Server) writePayloadToSession(sessionId string, payload []byte) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -0,0 +1,163 @@
+package dashboardserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/pipe-fittings/steampipeconfig"
+	"github.com/turbot/powerpipe/internal/dashboardexecute"
+)
+
+// triggeredSession is a session created for a run triggered through the API or by the server
+type triggeredSession struct {
+	// closed when the session is cleared
+	done chan struct{}
+	// the snapshot of the completed run, or the error of the failed run - set before done is closed
+	snapshot *steampipeconfig.SteampipeSnapshot
+	err      error
+}
+
+// TriggerRun executes the named benchmark or dashboard in a new headless session
+// (i.e. a session with no websocket client) and returns details of the run
+// this is used to execute runs from inbound webhooks
+func (s *Server) TriggerRun(ctx context.Context, target string, inputs map[string]any) (*dashboardexecute.RunInfo, error) {
+	resource, err := s.getRunnableResource(target)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionId, err := newTriggeredSessionId()
+	if err != nil {
+		return nil, err
+	}
+	s.addTriggeredSession(sessionId)
+
+	// runs execute in the background - use a context which is not cancelled when the request completes
+	execCtx := dashboardexecute.WithRunInitiator(context.WithoutCancel(ctx), dashboardexecute.RunInitiatorFromContext(ctx))
+	err = dashboardexecute.Executor.ExecuteDashboard(execCtx, sessionId, resource, normaliseInputNames(inputs), s.workspace)
+	if err != nil {
+		s.clearTriggeredSession(ctx, sessionId)
+		return nil, err
+	}
+
+	runInfo, ok := dashboardexecute.Executor.RunForSession(sessionId)
+	if !ok {
+		// the run may already have completed
+		return &dashboardexecute.RunInfo{Session: sessionId, Target: target}, nil
+	}
+	return &runInfo, nil
+}
+
+// RunTarget executes the named benchmark or dashboard in a new headless session, waits for the run to complete, and
+// returns the snapshot of the run - this is used to execute scheduled runs
+func (s *Server) RunTarget(ctx context.Context, target string, inputs map[string]any) (*steampipeconfig.SteampipeSnapshot, error) {
+	resource, err := s.getRunnableResource(target)
+	if err != nil {
+		return nil, err
+	}
+	sessionId, err := newTriggeredSessionId()
+	if err != nil {
+		return nil, err
+	}
+	session := s.addTriggeredSession(sessionId)
+
+	if err := dashboardexecute.Executor.ExecuteDashboard(ctx, sessionId, resource, normaliseInputNames(inputs), s.workspace); err != nil {
+		s.clearTriggeredSession(ctx, sessionId)
+		return nil, err
+	}
+	select {
+	case <-session.done:
+	case <-ctx.Done():
+		s.clearTriggeredSession(ctx, sessionId)
+		return nil, ctx.Err()
+	}
+	if session.err != nil {
+		return nil, session.err
+	}
+	if session.snapshot == nil {
+		return nil, fmt.Errorf("the run of %s was cancelled", target)
+	}
+	session.snapshot.FileNameRoot = resource.Name()
+	return session.snapshot, nil
+}
+
+// ValidateRunTarget returns an error if the target is not a benchmark or dashboard of the workspace
+func (s *Server) ValidateRunTarget(target string) error {
+	_, err := s.getRunnableResource(target)
+	return err
+}
+
+// getRunnableResource returns the benchmark or dashboard with the given name
+func (s *Server) getRunnableResource(target string) (modconfig.ModTreeItem, error) {
+	resource := s.getResource(target)
+	if resource == nil {
+		return nil, perr.NotFoundWithMessage(fmt.Sprintf("%s not found", target))
+	}
+	switch resource.(type) {
+	case *modconfig.Dashboard, *modconfig.Benchmark:
+		return resource, nil
+	default:
+		return nil, perr.BadRequestWithMessage(fmt.Sprintf("%s cannot be run - only benchmarks and dashboards may be triggered", target))
+	}
+}
+
+// completeTriggeredSession records the result of the run of the session, then clears it - this is a no-op if the
+// session is not a triggered session
+func (s *Server) completeTriggeredSession(ctx context.Context, sessionId string, snapshot *steampipeconfig.SteampipeSnapshot, err error) {
+	s.mutex.Lock()
+	if session, isTriggered := s.triggeredSessions[sessionId]; isTriggered {
+		session.snapshot = snapshot
+		session.err = err
+	}
+	s.mutex.Unlock()
+	s.clearTriggeredSession(ctx, sessionId)
+}
+
+// if the session is a triggered session, remove the execution and the session, and close its done channel
+// (for websocket sessions, this is done when the client disconnects)
+func (s *Server) clearTriggeredSession(ctx context.Context, sessionId string) {
+	s.mutex.Lock()
+	session, isTriggered := s.triggeredSessions[sessionId]
+	delete(s.triggeredSessions, sessionId)
+	s.mutex.Unlock()
+
+	if isTriggered {
+		dashboardexecute.Executor.CancelExecutionForSession(ctx, sessionId)
+		close(session.done)
+	}
+}
+
+// addTriggeredSession adds a triggered session - its done channel is closed when the session is cleared
+func (s *Server) addTriggeredSession(sessionId string) *triggeredSession {
+	session := &triggeredSession{done: make(chan struct{})}
+	s.mutex.Lock()
+	s.triggeredSessions[sessionId] = session
+	s.mutex.Unlock()
+	return session
+}
+
+func newTriggeredSessionId() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "triggered_" + hex.EncodeToString(b), nil
+}
+
+// add the 'input.' prefix to any input names which do not have it
+func normaliseInputNames(inputs map[string]any) map[string]any {
+	res := make(map[string]any, len(inputs))
+	for name, value := range inputs {
+		if !strings.HasPrefix(name, "input.") {
+			name = modconfig.BuildModResourceName(schema.BlockTypeInput, name)
+		}
+		res[name] = value
+	}
+	return res
+}
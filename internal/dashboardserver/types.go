@@ -5,7 +5,11 @@ import (
 	"time"
 
 	"github.com/turbot/pipe-fittings/steampipeconfig"
+	"github.com/turbot/powerpipe/internal/approval"
 	"github.com/turbot/powerpipe/internal/controlstatus"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+	"github.com/turbot/powerpipe/internal/detection"
+	"github.com/turbot/powerpipe/internal/modsource"
 	"gopkg.in/olahol/melody.v1"
 )
 
@@ -50,8 +54,11 @@ type ExecutionStartedPayload struct {
 	Panels        map[string]any                    `json:"panels"`
 	Layout        *steampipeconfig.SnapshotTreeNode `json:"layout"`
 	Inputs        map[string]interface{}            `json:"inputs,omitempty"`
-	Variables     map[string]string                 `json:"variables,omitempty"`
-	StartTime     time.Time                         `json:"start_time"`
+	// map of input name to the names of the inputs it depends on
+	InputDependencies map[string][]string `json:"input_dependencies,omitempty"`
+	Variables         map[string]string   `json:"variables,omitempty"`
+	Warnings          []string            `json:"warnings,omitempty"`
+	StartTime         time.Time           `json:"start_time"`
 }
 
 var LeafNodeUpdatedSchemaVersion int64 = 20240607
@@ -103,10 +110,47 @@ type InputValuesClearedPayload struct {
 	ExecutionId   string   `json:"execution_id"`
 }
 
+type DetectionCompletePayload struct {
+	Action    string               `json:"action"`
+	Detection string               `json:"detection"`
+	Severity  string               `json:"severity"`
+	Findings  []*detection.Finding `json:"findings"`
+	Error     string               `json:"error,omitempty"`
+	Timestamp time.Time            `json:"timestamp"`
+}
+
+type ModInstallEventPayload struct {
+	Action string                 `json:"action"`
+	Event  modsource.InstallEvent `json:"event"`
+}
+
+type ApprovalChangedPayload struct {
+	Action  string            `json:"action"`
+	Request *approval.Request `json:"request"`
+}
+
 type DashboardClientInfo struct {
 	Session         *melody.Session
 	Dashboard       *string
 	DashboardInputs map[string]interface{}
+	// the panels of the current execution whose data the user may not view
+	WithheldPanels map[string]struct{}
+}
+
+type TablePagePayload struct {
+	Action string                   `json:"action"`
+	Panel  string                   `json:"panel"`
+	Data   *dashboardtypes.LeafData `json:"data,omitempty"`
+	Error  string                   `json:"error,omitempty"`
+}
+
+type DatasetUploadedPayload struct {
+	Action   string   `json:"action"`
+	Name     string   `json:"name"`
+	Table    string   `json:"table,omitempty"`
+	Columns  []string `json:"columns,omitempty"`
+	RowCount int      `json:"row_count"`
+	Error    string   `json:"error,omitempty"`
 }
 
 type ClientRequestDashboardPayload struct {
@@ -119,6 +163,18 @@ type ClientRequestPayload struct {
 	ChangedInput     string                        `json:"changed_input"`
 	SearchPath       []string                      `json:"search_path"`
 	SearchPathPrefix []string                      `json:"search_path_prefix"`
+	// for get_table_page and retry_panel requests
+	Panel     string                           `json:"panel"`
+	TablePage *dashboardtypes.TablePageRequest `json:"table_page"`
+	// for upload_dataset requests
+	Dataset *ClientRequestDatasetPayload `json:"dataset"`
+}
+
+type ClientRequestDatasetPayload struct {
+	Name string `json:"name"`
+	// csv or json
+	Format  string `json:"format"`
+	Content string `json:"content"`
 }
 
 type ClientRequest struct {
@@ -166,6 +222,16 @@ type SearchPathMetadata struct {
 	SearchPathPrefix     []string `json:"short_name"`
 }
 
+// LocaleMetadata is the timezone, date and number formatting used to display values in the UI
+type LocaleMetadata struct {
+	Timezone string `json:"timezone,omitempty"`
+	// the dayjs format used to display timestamps
+	DateFormat         string `json:"date_format"`
+	ThousandsSeparator string `json:"thousands_separator,omitempty"`
+	DecimalSeparator   string `json:"decimal_separator,omitempty"`
+	Currency           string `json:"currency,omitempty"`
+}
+
 type DashboardMetadata struct {
 	Database   string              `json:"database"`
 	SearchPath *SearchPathMetadata `json:"search_path"`
@@ -182,6 +248,7 @@ type ServerMetadata struct {
 	Cloud         *steampipeconfig.CloudMetadata `json:"cloud,omitempty"`
 	Telemetry     string                         `json:"telemetry"`
 	SearchPath    *SearchPathMetadata            `json:"search_path"`
+	Locale        *LocaleMetadata                `json:"locale"`
 }
 
 type ServerMetadataPayload struct {
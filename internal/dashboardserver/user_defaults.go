@@ -0,0 +1,164 @@
+package dashboardserver
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/turbot/pipe-fittings/filepaths"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/powerpipe/internal/rbac"
+	"gopkg.in/olahol/melody.v1"
+)
+
+// when auth is enabled, the input values and search path overrides last used by each user for each dashboard are
+// saved, and restored when the user next selects the dashboard without input values - so that users do not have to
+// re-enter their inputs every session
+//
+// (variables are resolved when the workspace is loaded, so are the same for all sessions of a server - the search
+// path is the per session override of the dashboard configuration)
+
+// DashboardDefaults are the input values and search path overrides last used by a user for a dashboard
+type DashboardDefaults struct {
+	Inputs           map[string]any `json:"inputs,omitempty"`
+	SearchPath       []string       `json:"search_path,omitempty"`
+	SearchPathPrefix []string       `json:"search_path_prefix,omitempty"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// UserDefaults are the dashboard defaults of each user, saved to a file
+type UserDefaults struct {
+	// map of user name to dashboard full name to defaults
+	Users map[string]map[string]*DashboardDefaults `json:"users"`
+	path  string
+	mut   sync.Mutex
+}
+
+// UserDefaultsPath returns the path of the user defaults file in the internal directory
+func UserDefaultsPath() string {
+	return filepath.Join(filepaths.EnsureInternalDir(), "user_defaults.json")
+}
+
+// LoadUserDefaults loads the user defaults from the given path - if the file does not exist, there are no defaults
+func LoadUserDefaults(path string) (*UserDefaults, error) {
+	res := &UserDefaults{Users: make(map[string]map[string]*DashboardDefaults), path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return res, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, err
+	}
+	if res.Users == nil {
+		res.Users = make(map[string]map[string]*DashboardDefaults)
+	}
+	return res, nil
+}
+
+// Get returns the defaults of the user for the dashboard, if any
+func (u *UserDefaults) Get(user, dashboard string) (*DashboardDefaults, bool) {
+	u.mut.Lock()
+	defer u.mut.Unlock()
+	defaults, ok := u.Users[user][dashboard]
+	return defaults, ok
+}
+
+// Set saves the defaults of the user for the dashboard
+func (u *UserDefaults) Set(user, dashboard string, defaults *DashboardDefaults) error {
+	u.mut.Lock()
+	defer u.mut.Unlock()
+	if u.Users[user] == nil {
+		u.Users[user] = make(map[string]*DashboardDefaults)
+	}
+	defaults.UpdatedAt = time.Now()
+	u.Users[user][dashboard] = defaults
+
+	data, err := json.MarshalIndent(u, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(u.path, data, 0600)
+}
+
+// loadUserDefaults loads the user defaults if auth is enabled (defaults are saved per user, so require an identity)
+func loadUserDefaults(authorizer *rbac.Authorizer) *UserDefaults {
+	if !authorizer.Enabled() {
+		return nil
+	}
+	res, err := LoadUserDefaults(UserDefaultsPath())
+	if err != nil {
+		slog.Warn("could not load user defaults - the inputs of each user will not be restored", "error", err)
+		return nil
+	}
+	return res
+}
+
+// getSessionUser returns the name of the user of the session, or "" if the session has no identity
+func (s *Server) getSessionUser(session *melody.Session) string {
+	if s.userDefaults == nil {
+		return ""
+	}
+	identity := s.authorizer.GetIdentity(session.Request)
+	if identity == nil {
+		return ""
+	}
+	return identity.Name
+}
+
+// restoreUserDefaults sets the inputs and search path of the request to those last used by the user of the session
+func (s *Server) restoreUserDefaults(session *melody.Session, dashboard modconfig.ModTreeItem, payload *ClientRequestPayload) {
+	user := s.getSessionUser(session)
+	if user == "" {
+		return
+	}
+	defaults, ok := s.userDefaults.Get(user, dashboard.Name())
+	if !ok {
+		return
+	}
+	payload.InputValues = dashboardInputValues(dashboard, defaults.Inputs)
+	if payload.SearchPath == nil && payload.SearchPathPrefix == nil {
+		payload.SearchPath = defaults.SearchPath
+		payload.SearchPathPrefix = defaults.SearchPathPrefix
+	}
+	slog.Debug("restored user defaults", "user", user, "dashboard", dashboard.Name(), "inputs", len(payload.InputValues))
+}
+
+// saveUserDefaults saves the inputs and search path of the session as the defaults of its user for the dashboard
+func (s *Server) saveUserDefaults(session *melody.Session, dashboard string, inputs map[string]any, searchPath, searchPathPrefix []string) {
+	user := s.getSessionUser(session)
+	if user == "" {
+		return
+	}
+	defaults := &DashboardDefaults{Inputs: inputs, SearchPath: searchPath, SearchPathPrefix: searchPathPrefix}
+	if existing, ok := s.userDefaults.Get(user, dashboard); ok && searchPath == nil && searchPathPrefix == nil {
+		// input changes do not include the search path - keep the search path the dashboard was selected with
+		defaults.SearchPath = existing.SearchPath
+		defaults.SearchPathPrefix = existing.SearchPathPrefix
+	}
+	if err := s.userDefaults.Set(user, dashboard, defaults); err != nil {
+		slog.Warn("could not save user defaults", "user", user, "dashboard", dashboard, "error", err)
+	}
+}
+
+// dashboardInputValues returns the values of the inputs which the dashboard still declares
+// (inputs may have been removed since the values were saved)
+func dashboardInputValues(dashboard modconfig.ModTreeItem, values map[string]any) map[string]any {
+	d, ok := dashboard.(*modconfig.Dashboard)
+	if !ok {
+		// benchmarks have no inputs
+		return nil
+	}
+	res := make(map[string]any)
+	for name, value := range values {
+		if _, ok := d.GetInput(name); ok {
+			res[name] = value
+		}
+	}
+	return res
+}
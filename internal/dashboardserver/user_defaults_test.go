@@ -0,0 +1,68 @@
+package dashboardserver
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type userDefaultsTest struct {
+	user      string
+	dashboard string
+	expected  *DashboardDefaults
+}
+
+var testCasesUserDefaults = map[string]userDefaultsTest{
+	"saved": {
+		user:      "alice@example.com",
+		dashboard: "aws_insights.dashboard.s3_bucket_detail",
+		expected: &DashboardDefaults{
+			Inputs:     map[string]any{"input.bucket_arn": "arn:aws:s3:::logs"},
+			SearchPath: []string{"aws_prod"},
+		},
+	},
+	"other dashboard": {
+		user:      "alice@example.com",
+		dashboard: "aws_insights.dashboard.ec2_instance_detail",
+	},
+	"other user": {
+		user:      "bob@example.com",
+		dashboard: "aws_insights.dashboard.s3_bucket_detail",
+	},
+}
+
+func TestUserDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "user_defaults.json")
+	defaults, err := LoadUserDefaults(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := defaults.Set("alice@example.com", "aws_insights.dashboard.s3_bucket_detail", &DashboardDefaults{
+		Inputs:     map[string]any{"input.bucket_arn": "arn:aws:s3:::logs"},
+		SearchPath: []string{"aws_prod"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the defaults must be restored by a new server
+	loaded, err := LoadUserDefaults(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, test := range testCasesUserDefaults {
+		actual, ok := loaded.Get(test.user, test.dashboard)
+		if test.expected == nil {
+			if ok {
+				t.Errorf("Test: '%s' FAILED : expected no defaults, got %+v", name, actual)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("Test: '%s' FAILED : expected %+v, got no defaults", name, test.expected)
+			continue
+		}
+		if !reflect.DeepEqual(actual.Inputs, test.expected.Inputs) || !reflect.DeepEqual(actual.SearchPath, test.expected.SearchPath) || actual.UpdatedAt.IsZero() {
+			t.Errorf("Test: '%s' FAILED : expected %+v, got %+v", name, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package dashboardserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/utils"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/dashboardevents"
+	"github.com/turbot/powerpipe/internal/dashboardexecute"
+)
+
+// dashboards and benchmarks may be warmed up - executed in a headless session when the server starts, and again
+// when they change - so that database and plugin caches (and any materializations they read) are populated before
+// the first visitor opens them
+//
+// targets are warmed up if they are listed in the --warm-up arg (e.g. dashboards of dependency mods), or tagged, e.g.
+//
+//	dashboard "account_report" {
+//	  tags = {
+//	    warm_up = "true"
+//	  }
+//	}
+//
+// targets are warmed up one at a time, so that warm-up does not compete with visitors for database connections
+const (
+	TagWarmUp = "warm_up"
+
+	warmUpInitiator = "warm-up"
+)
+
+// StartWarmUp warms up the warm-up targets of the workspace in the background
+func (s *Server) StartWarmUp(ctx context.Context) {
+	targets := s.warmUpTargets()
+	if len(targets) == 0 {
+		return
+	}
+	OutputMessage(ctx, fmt.Sprintf("Warming up %d %s", len(targets), utils.Pluralize("target", len(targets))))
+	go s.warmUp(ctx, targets)
+}
+
+// warmUpTargets returns the names of the dashboards and benchmarks to warm up, sorted by name
+func (s *Server) warmUpTargets() []string {
+	var res []string
+	for _, name := range viper.GetStringSlice(localconstants.ArgWarmUp) {
+		resource := s.getResource(name)
+		if resource == nil {
+			slog.Warn("warm-up target not found", "target", name)
+			continue
+		}
+		if !isWarmUpResource(resource) {
+			slog.Warn("warm-up target is not a dashboard or benchmark", "target", name)
+			continue
+		}
+		res = append(res, resource.Name())
+	}
+
+	resourceMaps := s.workspace.GetResourceMaps()
+	for name, dashboard := range resourceMaps.Dashboards {
+		if dashboard.Tags[TagWarmUp] == "true" {
+			res = append(res, name)
+		}
+	}
+	for name, benchmark := range resourceMaps.Benchmarks {
+		if benchmark.Tags[TagWarmUp] == "true" {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return slices.Compact(res)
+}
+
+// warmUp executes each target in turn, skipping any which are already being warmed up
+func (s *Server) warmUp(ctx context.Context, targets []string) {
+	for _, target := range targets {
+		if ctx.Err() != nil {
+			return
+		}
+		if !s.startWarmingUp(target) {
+			continue
+		}
+		startTime := time.Now()
+		err := s.warmUpTarget(ctx, target)
+		s.stopWarmingUp(target)
+		if err != nil {
+			slog.Warn("warm-up failed", "target", target, "error", err)
+			continue
+		}
+		slog.Info("warmed up", "target", target, "duration", time.Since(startTime).Round(time.Millisecond).String())
+	}
+}
+
+// warmUpTarget executes the target in a headless session, waiting for the execution to complete
+func (s *Server) warmUpTarget(ctx context.Context, target string) error {
+	resource := s.getResource(target)
+	if resource == nil {
+		return fmt.Errorf("%s not found", target)
+	}
+	sessionId, err := newTriggeredSessionId()
+	if err != nil {
+		return err
+	}
+	session := s.addTriggeredSession(sessionId)
+
+	execCtx := dashboardexecute.WithRunInitiator(ctx, warmUpInitiator)
+	if err := dashboardexecute.Executor.ExecuteDashboard(execCtx, sessionId, resource, nil, s.workspace); err != nil {
+		s.clearTriggeredSession(ctx, sessionId)
+		return err
+	}
+	select {
+	case <-session.done:
+	case <-ctx.Done():
+	}
+	return ctx.Err()
+}
+
+// warmUpChanged warms up the warm-up targets which are new or have changed
+func (s *Server) warmUpChanged(ctx context.Context, e *dashboardevents.DashboardChanged) {
+	targets := changedWarmUpTargets(s.warmUpTargets(), e)
+	if len(targets) == 0 {
+		return
+	}
+	OutputMessage(ctx, fmt.Sprintf("Warming up %d changed %s", len(targets), utils.Pluralize("target", len(targets))))
+	go s.warmUp(ctx, targets)
+}
+
+// changedWarmUpTargets returns the warm-up targets which are new, have changed, or contain a changed resource
+func changedWarmUpTargets(targets []string, e *dashboardevents.DashboardChanged) []string {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	var changedItems []*modconfig.DashboardTreeItemDiffs
+	for _, items := range [][]*modconfig.DashboardTreeItemDiffs{
+		e.ChangedDashboards, e.ChangedBenchmarks, e.ChangedCategories, e.ChangedContainers, e.ChangedControls,
+		e.ChangedCards, e.ChangedCharts, e.ChangedEdges, e.ChangedFlows, e.ChangedGraphs, e.ChangedHierarchies,
+		e.ChangedImages, e.ChangedInputs, e.ChangedNodes, e.ChangedTables, e.ChangedTexts,
+	} {
+		changedItems = append(changedItems, items...)
+	}
+	changed := getDashboardsInterestedInResourceChanges(targets, nil, changedItems)
+	for _, d := range e.ChangedDashboards {
+		changed = append(changed, d.Name)
+	}
+	for _, b := range e.ChangedBenchmarks {
+		changed = append(changed, b.Name)
+	}
+	for _, d := range e.NewDashboards {
+		changed = append(changed, d.Name())
+	}
+	for _, b := range e.NewBenchmarks {
+		changed = append(changed, b.Name())
+	}
+
+	var res []string
+	for _, target := range targets {
+		if slices.Contains(changed, target) {
+			res = append(res, target)
+		}
+	}
+	return res
+}
+
+// startWarmingUp records that the target is being warmed up, returning false if it already is
+func (s *Server) startWarmingUp(target string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.warmingUp[target]; ok {
+		return false
+	}
+	s.warmingUp[target] = struct{}{}
+	return true
+}
+
+func (s *Server) stopWarmingUp(target string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.warmingUp, target)
+}
+
+func isWarmUpResource(resource modconfig.ModTreeItem) bool {
+	switch resource.(type) {
+	case *modconfig.Dashboard, *modconfig.Benchmark:
+		return true
+	}
+	return false
+}
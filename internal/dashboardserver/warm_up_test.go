@@ -0,0 +1,74 @@
+package dashboardserver
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/powerpipe/internal/dashboardevents"
+)
+
+type changedWarmUpTargetsTest struct {
+	targets  []string
+	event    *dashboardevents.DashboardChanged
+	expected []string
+}
+
+func testWarmUpDashboard(name string, paths ...modconfig.NodePath) *modconfig.Dashboard {
+	return &modconfig.Dashboard{
+		ModTreeItemImpl: modconfig.ModTreeItemImpl{
+			HclResourceImpl: modconfig.HclResourceImpl{FullName: name},
+			Paths:           paths,
+		},
+	}
+}
+
+var testCasesChangedWarmUpTargets = map[string]changedWarmUpTargetsTest{
+	"no targets": {
+		event: &dashboardevents.DashboardChanged{
+			NewDashboards: []*modconfig.Dashboard{testWarmUpDashboard("m.dashboard.d1")},
+		},
+	},
+	"new target": {
+		targets: []string{"m.dashboard.d1", "m.dashboard.d2"},
+		event: &dashboardevents.DashboardChanged{
+			NewDashboards: []*modconfig.Dashboard{testWarmUpDashboard("m.dashboard.d2"), testWarmUpDashboard("m.dashboard.d3")},
+		},
+		expected: []string{"m.dashboard.d2"},
+	},
+	"changed target": {
+		targets: []string{"m.dashboard.d1", "m.dashboard.d2"},
+		event: &dashboardevents.DashboardChanged{
+			ChangedDashboards: []*modconfig.DashboardTreeItemDiffs{
+				{Name: "m.dashboard.d1", Item: testWarmUpDashboard("m.dashboard.d1", modconfig.NodePath{"m.dashboard.d1"})},
+			},
+		},
+		expected: []string{"m.dashboard.d1"},
+	},
+	"changed child of target": {
+		targets: []string{"m.dashboard.d1", "m.dashboard.d2"},
+		event: &dashboardevents.DashboardChanged{
+			ChangedDashboards: []*modconfig.DashboardTreeItemDiffs{
+				{Name: "m.dashboard.child", Item: testWarmUpDashboard("m.dashboard.child", modconfig.NodePath{"m.dashboard.d2", "m.dashboard.child"})},
+			},
+		},
+		expected: []string{"m.dashboard.d2"},
+	},
+	"unrelated change": {
+		targets: []string{"m.dashboard.d1"},
+		event: &dashboardevents.DashboardChanged{
+			ChangedDashboards: []*modconfig.DashboardTreeItemDiffs{
+				{Name: "m.dashboard.d3", Item: testWarmUpDashboard("m.dashboard.d3", modconfig.NodePath{"m.dashboard.d3"})},
+			},
+		},
+	},
+}
+
+func TestChangedWarmUpTargets(t *testing.T) {
+	for name, test := range testCasesChangedWarmUpTargets {
+		res := changedWarmUpTargets(test.targets, test.event)
+		if !slices.Equal(res, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, res)
+		}
+	}
+}
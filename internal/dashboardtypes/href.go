@@ -0,0 +1,56 @@
+package dashboardtypes
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+
+	typehelpers "github.com/turbot/go-kit/types"
+)
+
+// href templates reference columns of the current row, optionally uri-encoded, e.g. a link to another
+// dashboard with its inputs populated from the clicked row
+//
+//	href = "/aws_insights.dashboard.vpc_detail?input.vpc_id={{ .vpc_id | @uri }}"
+//
+// templates are resolved server-side for each row, so links also work in snapshots and exports
+// - templates using any other expressions are left for the UI to resolve
+var hrefTemplateRegex = regexp.MustCompile(`{{\s*\.(?:([A-Za-z_][A-Za-z0-9_]*)|"([^"]+)")\s*(\|\s*@uri\s*)?}}`)
+
+// ResolveHrefTemplate resolves the column references in a template for the given row
+// returns false if the template contains expressions which are not column references
+func ResolveHrefTemplate(template string, row map[string]any) (string, bool) {
+	// if there are any other template expressions we cannot resolve this template
+	if strings.Contains(hrefTemplateRegex.ReplaceAllString(template, ""), "{{") {
+		return "", false
+	}
+	return hrefTemplateRegex.ReplaceAllStringFunc(template, func(match string) string {
+		groups := hrefTemplateRegex.FindStringSubmatch(match)
+		column := groups[1]
+		if column == "" {
+			column = groups[2]
+		}
+		value := HrefValueString(row[column])
+		if groups[3] != "" {
+			// match the UI (jq) @uri encoding, which encodes spaces as %20
+			value = strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+		}
+		return value
+	}), true
+}
+
+// HrefValueString returns the string used for a column value in an href
+func HrefValueString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case map[string]any, []any:
+		jsonBytes, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(jsonBytes)
+	}
+	return typehelpers.ToString(value)
+}
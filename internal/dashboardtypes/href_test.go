@@ -0,0 +1,58 @@
+package dashboardtypes
+
+import (
+	"testing"
+)
+
+type resolveHrefTemplateTest struct {
+	template string
+	row      map[string]any
+	expected string
+	resolved bool
+}
+
+var testCasesResolveHrefTemplate = map[string]resolveHrefTemplateTest{
+	"no template": {
+		template: "https://example.com",
+		expected: "https://example.com",
+		resolved: true,
+	},
+	"column": {
+		template: "/aws.dashboard.vpc_detail?input.vpc_id={{ .vpc_id }}",
+		row:      map[string]any{"vpc_id": "vpc-123"},
+		expected: "/aws.dashboard.vpc_detail?input.vpc_id=vpc-123",
+		resolved: true,
+	},
+	"uri encoded": {
+		template: "/aws.dashboard.bucket_detail?input.name={{.name | @uri}}",
+		row:      map[string]any{"name": "my bucket&co"},
+		expected: "/aws.dashboard.bucket_detail?input.name=my%20bucket%26co",
+		resolved: true,
+	},
+	"quoted column": {
+		template: `/detail?input.id={{ ."Account ID" }}`,
+		row:      map[string]any{"Account ID": 123456},
+		expected: "/detail?input.id=123456",
+		resolved: true,
+	},
+	"missing column": {
+		template: "/detail?input.id={{ .id }}",
+		row:      map[string]any{},
+		expected: "/detail?input.id=",
+		resolved: true,
+	},
+	"unsupported expression": {
+		template: "/detail?input.id={{ .id | ascii_downcase }}",
+		row:      map[string]any{"id": "ABC"},
+		resolved: false,
+	},
+}
+
+func TestResolveHrefTemplate(t *testing.T) {
+	for name, test := range testCasesResolveHrefTemplate {
+		actual, resolved := ResolveHrefTemplate(test.template, test.row)
+		if resolved != test.resolved || actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s' (%v), got '%s' (%v)", name, test.expected, test.resolved, actual, resolved)
+		}
+	}
+}
@@ -11,6 +11,10 @@ import (
 type LeafData struct {
 	Columns []*queryresult.ColumnDef `json:"columns"`
 	Rows    []map[string]interface{} `json:"rows"`
+	// the resolved href templates for each row (if the resource has any)
+	Hrefs []map[string]string `json:"hrefs,omitempty"`
+	// for paged tables, the page of data returned
+	Pagination *TablePagination `json:"pagination,omitempty"`
 }
 
 func NewLeafData(result *localqueryresult.SyncQueryResult) (*LeafData, error) {
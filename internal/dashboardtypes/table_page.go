@@ -0,0 +1,24 @@
+package dashboardtypes
+
+const (
+	SortAscending  = "asc"
+	SortDescending = "desc"
+)
+
+// TablePageRequest describes a page of table data to retrieve, with optional sorting and filtering
+// which are pushed down to the database
+type TablePageRequest struct {
+	Page          int    `json:"page"`
+	PageSize      int    `json:"page_size"`
+	SortColumn    string `json:"sort_column,omitempty"`
+	SortDirection string `json:"sort_direction,omitempty"`
+	// rows are included if the text value of the filter column contains the filter value (case-insensitive)
+	FilterColumn string `json:"filter_column,omitempty"`
+	FilterValue  string `json:"filter_value,omitempty"`
+}
+
+// TablePagination describes the page of data contained in a LeafData
+type TablePagination struct {
+	TablePageRequest
+	HasMore bool `json:"has_more"`
+}
@@ -6,7 +6,10 @@ import (
 
 	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/workspace"
+	"github.com/turbot/powerpipe/internal/assemble"
+	"github.com/turbot/powerpipe/internal/conditional"
 	"github.com/turbot/powerpipe/internal/dashboardevents"
+	"github.com/turbot/powerpipe/internal/rewrite"
 )
 
 // WorkspaceEvents is a wrapper around workspace.WorkspaceEvents that adds dashboard specific event handling
@@ -27,6 +30,20 @@ func NewWorkspaceEvents(workspace *workspace.Workspace) *WorkspaceEvents {
 		w.PublishDashboardEvent(ctx, &dashboardevents.WorkspaceError{Error: err})
 	}
 	w.OnFileWatcherEvent = func(ctx context.Context, resourceMaps, prevResourceMaps *modconfig.ResourceMaps) {
+		// remove disabled resources, add the selected controls to any assembled benchmarks and apply any query
+		// rewrite rules in the reloaded resources
+		if err := conditional.RemoveDisabled(resourceMaps); err != nil {
+			w.PublishDashboardEvent(ctx, &dashboardevents.WorkspaceError{Error: err})
+			return
+		}
+		if err := assemble.Benchmarks(resourceMaps); err != nil {
+			w.PublishDashboardEvent(ctx, &dashboardevents.WorkspaceError{Error: err})
+			return
+		}
+		if err := rewrite.RewriteQueries(resourceMaps); err != nil {
+			w.PublishDashboardEvent(ctx, &dashboardevents.WorkspaceError{Error: err})
+			return
+		}
 		w.raiseDashboardChangedEvents(ctx, resourceMaps, prevResourceMaps)
 	}
 	return w
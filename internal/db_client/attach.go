@@ -0,0 +1,86 @@
+package db_client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/constants"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// additional database files may be attached to a DuckDB or SQLite database using --database-attach, so that
+// queries can join across multiple local database files, e.g.
+//
+//	--database-attach "duckdb:///data/main.duckdb=billing:/data/billing.duckdb"
+//
+// the tables of the attached file are then available as 'billing.<table>'
+// if the alias is omitted, the file name (without extension) is used - aliases must be at least 2 characters, so they
+// cannot be mistaken for a windows drive
+
+// Attachment is a database file attached to a database connection
+type Attachment struct {
+	Alias string
+	Path  string
+}
+
+var attachmentAliasRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// AttachmentsForDatabase returns the database files to attach to the given database
+func AttachmentsForDatabase(database string) ([]Attachment, error) {
+	attachments, err := databaseAttachments(viper.GetStringSlice(localconstants.ArgDatabaseAttach))
+	if err != nil {
+		return nil, err
+	}
+	return attachments[database], nil
+}
+
+// databaseAttachments parses args of the form 'database=alias:path' (or 'database=path') into a map of attachments keyed by database
+func databaseAttachments(args []string) (map[string][]Attachment, error) {
+	res := make(map[string][]Attachment)
+	for _, arg := range args {
+		database, value, ok := parseDatabaseAssignment(arg)
+		if !ok || value == "" {
+			return nil, sperr.New("invalid %s '%s' - expected 'database=alias:path'", localconstants.ArgDatabaseAttach, arg)
+		}
+
+		var attachment Attachment
+		// a single letter before the colon is a windows drive, not an alias
+		if alias, path, ok := strings.Cut(value, ":"); ok && len(alias) > 1 && attachmentAliasRegex.MatchString(alias) {
+			attachment = Attachment{Alias: alias, Path: path}
+		} else {
+			// the path may be a windows path, whatever the current OS
+			name := value[strings.LastIndexAny(value, `/\`)+1:]
+			attachment = Attachment{Path: value, Alias: strings.TrimSuffix(name, filepath.Ext(name))}
+			if !attachmentAliasRegex.MatchString(attachment.Alias) {
+				return nil, sperr.New("invalid %s '%s' - the file name is not a valid alias, so an alias must be given, e.g. 'database=alias:path'", localconstants.ArgDatabaseAttach, arg)
+			}
+		}
+		res[database] = append(res[database], attachment)
+	}
+	return res, nil
+}
+
+// attachStatement returns the statement which attaches the file for the given backend - this is run on each new connection
+// attached DuckDB files are read only - attached SQLite files are read only in practice, as only queries are executed
+func attachStatement(backendName string, attachment Attachment) (string, error) {
+	var format string
+	switch backendName {
+	case constants.DuckDBBackendName:
+		// DuckDB attaches files to the database, which is shared by all connections in the pool
+		format = `ATTACH IF NOT EXISTS '%s' AS %s (READ_ONLY)`
+	case constants.SQLiteBackendName:
+		format = `ATTACH DATABASE '%s' AS %s`
+	default:
+		return "", sperr.New("%s is only supported for DuckDB and SQLite databases", localconstants.ArgDatabaseAttach)
+	}
+	// attaching a file which does not exist would create an empty database
+	if _, err := os.Stat(attachment.Path); err != nil {
+		return "", sperr.New("could not attach database file '%s': %s", attachment.Path, err.Error())
+	}
+	return fmt.Sprintf(format, strings.ReplaceAll(attachment.Path, "'", "''"), PgEscapeName(attachment.Alias)), nil
+}
@@ -0,0 +1,76 @@
+package db_client
+
+import (
+	"reflect"
+	"testing"
+)
+
+type databaseAttachmentsTest struct {
+	args        []string
+	expected    map[string][]Attachment
+	expectError bool
+}
+
+var testCasesDatabaseAttachments = map[string]databaseAttachmentsTest{
+	"alias": {
+		args: []string{"duckdb:///data/main.duckdb=billing:/data/billing.duckdb"},
+		expected: map[string][]Attachment{
+			"duckdb:///data/main.duckdb": {{Alias: "billing", Path: "/data/billing.duckdb"}},
+		},
+	},
+	"alias from file name": {
+		args: []string{"sqlite:///data/main.db=/data/usage.db"},
+		expected: map[string][]Attachment{
+			"sqlite:///data/main.db": {{Alias: "usage", Path: "/data/usage.db"}},
+		},
+	},
+	"windows path": {
+		args: []string{`sqlite:main.db=C:\data\usage.db`},
+		expected: map[string][]Attachment{
+			"sqlite:main.db": {{Alias: "usage", Path: `C:\data\usage.db`}},
+		},
+	},
+	"path containing '='": {
+		args: []string{"duckdb:///data/main.duckdb=billing:/data/year=2024/billing.duckdb"},
+		expected: map[string][]Attachment{
+			"duckdb:///data/main.duckdb": {{Alias: "billing", Path: "/data/year=2024/billing.duckdb"}},
+		},
+	},
+	"multiple files": {
+		args: []string{"sqlite:main.db=one:a.db", "sqlite:main.db=two:b.db"},
+		expected: map[string][]Attachment{
+			"sqlite:main.db": {{Alias: "one", Path: "a.db"}, {Alias: "two", Path: "b.db"}},
+		},
+	},
+	"missing database": {
+		args:        []string{"=billing:/data/billing.duckdb"},
+		expectError: true,
+	},
+	"missing path": {
+		args:        []string{"sqlite:main.db="},
+		expectError: true,
+	},
+	"invalid file name alias": {
+		args:        []string{"sqlite:main.db=/data/usage-2024.db"},
+		expectError: true,
+	},
+}
+
+func TestDatabaseAttachments(t *testing.T) {
+	for name, test := range testCasesDatabaseAttachments {
+		attachments, err := databaseAttachments(test.args)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error, got none", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(attachments, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, attachments)
+		}
+	}
+}
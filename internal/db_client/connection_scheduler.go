@@ -0,0 +1,164 @@
+package db_client
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+// when the server is shared by many dashboard sessions and scheduled runs, the connections of a database client
+// are shared between them - so that one origin (e.g. a session running a large benchmark) cannot starve the
+// others of connections, the connections are scheduled fairly between origins:
+//   - an origin may use at most --max-connections-per-origin connections at once (0 for no quota)
+//   - when connections are contended, queued queries are granted connections round robin between origins,
+//     and in the order they were queued within an origin
+//
+// the origin of a query is set in its context using WithOrigin - queries with no origin share the default origin
+
+type originContextKey struct{}
+
+// WithOrigin returns a context whose queries are scheduled as the given origin,
+// e.g. the dashboard session or scheduled job which executes them
+func WithOrigin(ctx context.Context, origin string) context.Context {
+	return context.WithValue(ctx, originContextKey{}, origin)
+}
+
+// OriginFromContext returns the origin of the queries executed with the context
+func OriginFromContext(ctx context.Context) string {
+	origin, _ := ctx.Value(originContextKey{}).(string)
+	return origin
+}
+
+type connectionWaiter struct {
+	granted chan struct{}
+}
+
+// connectionScheduler grants database connections to origins, enforcing the per origin quota
+type connectionScheduler struct {
+	// the number of connections of the client
+	capacity int
+	// the maximum number of connections of an origin (0 for no quota)
+	quota int
+
+	active         int
+	activeByOrigin map[string]int
+	waiters        map[string][]*connectionWaiter
+	// the origins with waiters, in the order they will be granted connections
+	waitingOrigins []string
+	mut            sync.Mutex
+}
+
+func newConnectionScheduler(capacity, quota int) *connectionScheduler {
+	return &connectionScheduler{
+		capacity:       capacity,
+		quota:          quota,
+		activeByOrigin: make(map[string]int),
+		waiters:        make(map[string][]*connectionWaiter),
+	}
+}
+
+// newClientConnectionScheduler returns a scheduler for the connections of a client, using the configured quota
+func newClientConnectionScheduler() *connectionScheduler {
+	return newConnectionScheduler(MaxDbConnections(), viper.GetInt(localconstants.ArgMaxConnectionsPerOrigin))
+}
+
+// acquire waits until the origin of the context is granted a connection (or the context is cancelled)
+// it returns a function which must be called once the connection is closed
+func (s *connectionScheduler) acquire(ctx context.Context) (func(), error) {
+	origin := OriginFromContext(ctx)
+	release := func() { s.release(origin) }
+
+	s.mut.Lock()
+	// grant immediately if possible - unless earlier queries of the origin are queued
+	if len(s.waiters[origin]) == 0 && s.canGrant(origin) {
+		s.grant(origin)
+		s.mut.Unlock()
+		return release, nil
+	}
+	waiter := &connectionWaiter{granted: make(chan struct{})}
+	s.waiters[origin] = append(s.waiters[origin], waiter)
+	if !slices.Contains(s.waitingOrigins, origin) {
+		s.waitingOrigins = append(s.waitingOrigins, origin)
+	}
+	slog.Debug("query waiting for database connection", "origin", origin, "active", s.active, "origin active", s.activeByOrigin[origin])
+	s.mut.Unlock()
+
+	select {
+	case <-waiter.granted:
+		return release, nil
+	case <-ctx.Done():
+		s.mut.Lock()
+		defer s.mut.Unlock()
+		select {
+		case <-waiter.granted:
+			// the connection was granted as the context was cancelled - give it back
+			s.releaseLocked(origin)
+		default:
+			s.removeWaiter(origin, waiter)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (s *connectionScheduler) release(origin string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.releaseLocked(origin)
+}
+
+func (s *connectionScheduler) releaseLocked(origin string) {
+	s.active--
+	s.activeByOrigin[origin]--
+	if s.activeByOrigin[origin] <= 0 {
+		delete(s.activeByOrigin, origin)
+	}
+	s.dispatch()
+}
+
+// dispatch grants free connections to the waiting origins, round robin
+func (s *connectionScheduler) dispatch() {
+	for s.active < s.capacity {
+		idx := slices.IndexFunc(s.waitingOrigins, s.canGrant)
+		if idx == -1 {
+			return
+		}
+		origin := s.waitingOrigins[idx]
+		waiter := s.waiters[origin][0]
+		s.waiters[origin] = s.waiters[origin][1:]
+
+		// move the origin to the back of the queue, or remove it if it has no more waiters
+		s.waitingOrigins = slices.Delete(s.waitingOrigins, idx, idx+1)
+		if len(s.waiters[origin]) > 0 {
+			s.waitingOrigins = append(s.waitingOrigins, origin)
+		} else {
+			delete(s.waiters, origin)
+		}
+
+		s.grant(origin)
+		close(waiter.granted)
+	}
+}
+
+func (s *connectionScheduler) canGrant(origin string) bool {
+	if s.active >= s.capacity {
+		return false
+	}
+	return s.quota <= 0 || s.activeByOrigin[origin] < s.quota
+}
+
+func (s *connectionScheduler) grant(origin string) {
+	s.active++
+	s.activeByOrigin[origin]++
+}
+
+func (s *connectionScheduler) removeWaiter(origin string, waiter *connectionWaiter) {
+	s.waiters[origin] = slices.DeleteFunc(s.waiters[origin], func(w *connectionWaiter) bool { return w == waiter })
+	if len(s.waiters[origin]) == 0 {
+		delete(s.waiters, origin)
+		s.waitingOrigins = slices.DeleteFunc(s.waitingOrigins, func(o string) bool { return o == origin })
+	}
+}
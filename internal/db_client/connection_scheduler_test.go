@@ -0,0 +1,140 @@
+package db_client
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type connectionSchedulerTest struct {
+	capacity int
+	quota    int
+	// origins which hold a connection when the queued origins acquire
+	held []string
+	// origins which acquire in order once the held connections are granted
+	queued []string
+	// the order in which the queued origins are granted connections
+	expected []string
+}
+
+var testCasesConnectionScheduler = map[string]connectionSchedulerTest{
+	"round robin": {
+		capacity: 1,
+		held:     []string{"benchmark"},
+		queued:   []string{"benchmark", "benchmark", "benchmark", "dashboard"},
+		expected: []string{"benchmark", "dashboard", "benchmark", "benchmark"},
+	},
+	"quota": {
+		capacity: 2,
+		quota:    1,
+		held:     []string{"benchmark"},
+		queued:   []string{"benchmark", "dashboard"},
+		expected: []string{"dashboard", "benchmark"},
+	},
+	"fifo within origin": {
+		capacity: 1,
+		held:     []string{"a"},
+		queued:   []string{"a", "b", "b", "a"},
+		expected: []string{"a", "b", "a", "b"},
+	},
+}
+
+// waitFor polls until the condition is true, failing the test after a second
+func waitFor(t *testing.T, condition func() bool) {
+	for start := time.Now(); !condition(); time.Sleep(time.Millisecond) {
+		if time.Since(start) > time.Second {
+			t.Fatal("timed out waiting for the connection scheduler")
+		}
+	}
+}
+
+func (s *connectionScheduler) waiterCount() int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	count := 0
+	for _, waiters := range s.waiters {
+		count += len(waiters)
+	}
+	return count
+}
+
+func TestConnectionScheduler(t *testing.T) {
+	for name, test := range testCasesConnectionScheduler {
+		s := newConnectionScheduler(test.capacity, test.quota)
+		var releases []func()
+		for _, origin := range test.held {
+			release, err := s.acquire(WithOrigin(context.Background(), origin))
+			if err != nil {
+				t.Fatal(err)
+			}
+			releases = append(releases, release)
+		}
+
+		var granted []string
+		var mut sync.Mutex
+		grantedCount := func() int {
+			mut.Lock()
+			defer mut.Unlock()
+			return len(granted)
+		}
+		var wg sync.WaitGroup
+		for idx, origin := range test.queued {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				release, err := s.acquire(WithOrigin(context.Background(), origin))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mut.Lock()
+				granted = append(granted, origin)
+				mut.Unlock()
+				release()
+			}()
+			// wait for the origin to be granted or queued, so that the origins are queued in order
+			waitFor(t, func() bool { return grantedCount()+s.waiterCount() == idx+1 })
+		}
+		for _, release := range releases {
+			release()
+		}
+		wg.Wait()
+
+		if !reflect.DeepEqual(granted, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, granted)
+		}
+	}
+}
+
+func TestConnectionSchedulerCancel(t *testing.T) {
+	s := newConnectionScheduler(1, 0)
+	release, err := s.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(WithOrigin(context.Background(), "cancelled"))
+	errChan := make(chan error)
+	go func() {
+		_, err := s.acquire(ctx)
+		errChan <- err
+	}()
+	waitFor(t, func() bool { return s.waiterCount() == 1 })
+	cancel()
+	if err := <-errChan; err != context.Canceled {
+		t.Errorf("Test: 'cancel' FAILED : expected %v, got %v", context.Canceled, err)
+	}
+
+	// the connection of the cancelled waiter must not be leaked
+	release()
+	release, err = s.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if s.active != 0 || len(s.waitingOrigins) != 0 {
+		t.Errorf("Test: 'cancel' FAILED : expected no active connections or waiting origins, got %d active, %v waiting", s.active, s.waitingOrigins)
+	}
+}
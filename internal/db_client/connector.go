@@ -0,0 +1,148 @@
+package db_client
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/marcboeker/go-duckdb"
+	"github.com/mattn/go-sqlite3"
+	"github.com/turbot/pipe-fittings/backend"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// connectWithSetup connects to the database of the backend, running the setup statements (attaching files and
+// on connect SQL) on each new connection in the pool
+// the pipe-fittings backends do not support per connection setup, so the connection pool is created here
+func connectWithSetup(ctx context.Context, b backend.Backend, statements []string, opts ...backend.ConnectOption) (*sql.DB, error) {
+	connector, err := newSetupConnector(ctx, b, statements, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return openDB(ctx, connector, opts...)
+}
+
+// newSetupConnector returns a connector for the database of the backend, which runs the setup statements on each new
+// connection
+func newSetupConnector(ctx context.Context, b backend.Backend, statements []string, opts ...backend.ConnectOption) (driver.Connector, error) {
+	var connector driver.Connector
+	var err error
+	switch b.Name() {
+	case constants.PostgresBackendName, constants.SteampipeBackendName:
+		connector, err = postgresSetupConnector(ctx, b, statements, opts...)
+	case constants.MySQLBackendName:
+		var cfg *mysql.Config
+		if cfg, err = mysql.ParseDSN(b.ConnectionString()); err == nil {
+			connector, err = mysql.NewConnector(cfg)
+		}
+	case constants.DuckDBBackendName:
+		// the json extension is loaded by the DuckDB backend - loading it is a no-op for subsequent connections
+		statements = append([]string{"INSTALL 'json'", "LOAD 'json'"}, statements...)
+		connector, err = duckdb.NewConnector(b.ConnectionString(), nil)
+	case constants.SQLiteBackendName:
+		connector = &sqliteConnector{dsn: b.ConnectionString(), driver: &sqlite3.SQLiteDriver{}}
+	default:
+		return nil, sperr.New("connection setup is not supported for the %s backend", b.Name())
+	}
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "unable to parse connection string")
+	}
+
+	// the postgres connector runs the statements itself, after setting the search path
+	if _, ok := connector.(*PgxConnector); !ok {
+		connector = &setupConnector{
+			Connector: connector,
+			AfterConnectFunc: func(ctx context.Context, conn driver.Conn) error {
+				return execStatements(ctx, conn, statements)
+			},
+		}
+	}
+	return connector, nil
+}
+
+// openDB opens a connection pool using the connector
+func openDB(ctx context.Context, connector driver.Connector, opts ...backend.ConnectOption) (*sql.DB, error) {
+	config := backend.NewConnectConfig(opts)
+	db := sql.OpenDB(connector)
+	db.SetConnMaxIdleTime(config.MaxConnIdleTime)
+	db.SetConnMaxLifetime(config.MaxConnLifeTime)
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	// connect now, so any setup error is returned here rather than from the first query
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// postgresSetupConnector returns a connector which sets the search path required by the search path config
+// (as the backend does) then runs the statements
+func postgresSetupConnector(ctx context.Context, b backend.Backend, statements []string, opts ...backend.ConnectOption) (driver.Connector, error) {
+	// connecting the backend resolves its required search path - this does not open any connections
+	db, err := b.Connect(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	db.Close()
+
+	var searchPath []string
+	if provider, ok := b.(backend.SearchPathProvider); ok {
+		searchPath = provider.RequiredSearchPath()
+	}
+	if len(searchPath) > 0 {
+		statements = append([]string{"SET search_path TO " + strings.Join(searchPath, ",")}, statements...)
+	}
+	return NewPgxConnector(b.ConnectionString(), func(ctx context.Context, conn driver.Conn) error {
+		return execStatements(ctx, conn, statements)
+	})
+}
+
+// execStatements runs the statements on a new connection
+func execStatements(ctx context.Context, conn driver.Conn, statements []string) error {
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		return fmt.Errorf("%T does not implement ExecerContext", conn)
+	}
+	for _, statement := range statements {
+		if _, err := execer.ExecContext(ctx, statement, nil); err != nil {
+			return sperr.WrapWithMessage(err, "connection setup failed running '%s'", statement)
+		}
+	}
+	return nil
+}
+
+// setupConnector runs a function on each new connection
+type setupConnector struct {
+	driver.Connector
+	AfterConnectFunc func(context.Context, driver.Conn) error
+}
+
+func (c *setupConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.AfterConnectFunc(ctx, conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// sqliteConnector opens SQLite connections - the SQLite driver does not implement driver.DriverContext
+type sqliteConnector struct {
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+}
+
+func (c *sqliteConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *sqliteConnector) Driver() driver.Driver {
+	return c.driver
+}
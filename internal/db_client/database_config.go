@@ -1,10 +1,14 @@
 package db_client
 
 import (
+	"log/slog"
+	"strings"
+
 	"github.com/spf13/viper"
 	"github.com/turbot/pipe-fittings/backend"
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/modconfig"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
 	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
 )
 
@@ -23,19 +27,16 @@ func GetDatabaseConfigForResource(resource modconfig.ModTreeItem, workspaceMod *
 			return database, searchPathConfig, sperr.New("could not find mod requirement for '%s' in workspace mod %s", depName, workspaceMod.ShortName)
 		}
 
-		// if the mod requirement has a search path, prefix or database, set it in viper,
-		// overriding whatever value sth, use it
-		// TODO should we only respect overriden search path and search path prefix if the db is overriden?
-		//if modRequirement.OriginalSearchPath != nil {
-		//	searchPathConfig.OriginalSearchPath = modRequirement.OriginalSearchPath
-		//}
-		//if modRequirement.SearchPathPrefix != nil {
-		//	searchPathConfig.SearchPathPrefix = modRequirement.SearchPathPrefix
-		//}
+		// if the mod requirement overrides the database, use the search path configured for that database
 		if modRequirement.Database != nil {
-			// if database is overriden, also use overriden search path and search path prefix (even if empty)
 			database = *modRequirement.Database
+			searchPathConfig = SearchPathConfigForDatabase(database)
+		}
+		// a search path or prefix set for the mod requirement takes precedence
+		if len(modRequirement.SearchPath) > 0 {
 			searchPathConfig.SearchPath = modRequirement.SearchPath
+		}
+		if len(modRequirement.SearchPathPrefix) > 0 {
 			searchPathConfig.SearchPathPrefix = modRequirement.SearchPathPrefix
 		}
 	}
@@ -53,14 +54,62 @@ func GetDefaultDatabaseConfig(opts ...backend.ConnectOption) (string, backend.Se
 	}
 
 	// resolve the active database and search search path config for the dashboard
-	defaultSearchPathConfig := backend.SearchPathConfig{
-		SearchPath:       viper.GetStringSlice(constants.ArgSearchPath),
-		SearchPathPrefix: viper.GetStringSlice(constants.ArgSearchPathPrefix),
-	}
+	defaultDatabase := viper.GetString(constants.ArgDatabase)
+	defaultSearchPathConfig := SearchPathConfigForDatabase(defaultDatabase)
 	// has the search path been overridden?
 	if !cfg.SearchPathConfig.Empty() {
 		defaultSearchPathConfig = cfg.SearchPathConfig
 	}
-	defaultDatabase := viper.GetString(constants.ArgDatabase)
 	return defaultDatabase, defaultSearchPathConfig
 }
+
+// SearchPathConfigForDatabase returns the search path config for a database connection
+// the global search path and prefix are overridden by any set for the database using
+// --database-search-path and --database-search-path-prefix, e.g.
+//
+//	--database-search-path "postgres://localhost:9193/steampipe=aws,net"
+func SearchPathConfigForDatabase(database string) backend.SearchPathConfig {
+	res := backend.SearchPathConfig{
+		SearchPath:       viper.GetStringSlice(constants.ArgSearchPath),
+		SearchPathPrefix: viper.GetStringSlice(constants.ArgSearchPathPrefix),
+	}
+	if searchPath, ok := databaseSearchPaths(viper.GetStringSlice(localconstants.ArgDatabaseSearchPath))[database]; ok {
+		res.SearchPath = searchPath
+	}
+	if searchPathPrefix, ok := databaseSearchPaths(viper.GetStringSlice(localconstants.ArgDatabaseSearchPathPrefix))[database]; ok {
+		res.SearchPathPrefix = searchPathPrefix
+	}
+	return res
+}
+
+// databaseSearchPaths parses args of the form 'database=schema1,schema2' into a map of search paths keyed by database
+func databaseSearchPaths(args []string) map[string][]string {
+	res := make(map[string][]string)
+	for _, arg := range args {
+		database, value, ok := parseDatabaseAssignment(arg)
+		if !ok {
+			slog.Warn("ignoring invalid database search path - expected 'database=schema1,schema2'", "value", arg)
+			continue
+		}
+		var searchPath []string
+		for _, schema := range strings.Split(value, ",") {
+			if schema = strings.TrimSpace(schema); schema != "" {
+				searchPath = append(searchPath, schema)
+			}
+		}
+		res[database] = searchPath
+	}
+	return res
+}
+
+// parseDatabaseAssignment splits an arg of the form 'database=value' at the first '=', returning the trimmed database
+// and value - it returns false if there is no '=' or the database is empty
+// the value may itself contain '=' (e.g. a command with arguments) but the database may not
+func parseDatabaseAssignment(arg string) (database, value string, ok bool) {
+	database, value, ok = strings.Cut(arg, "=")
+	database, value = strings.TrimSpace(database), strings.TrimSpace(value)
+	if !ok || database == "" {
+		return "", "", false
+	}
+	return database, value, true
+}
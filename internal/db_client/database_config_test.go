@@ -0,0 +1,47 @@
+package db_client
+
+import (
+	"testing"
+)
+
+type parseDatabaseAssignmentTest struct {
+	arg              string
+	expectedDatabase string
+	expectedValue    string
+	expectedOk       bool
+}
+
+var testCasesParseDatabaseAssignment = map[string]parseDatabaseAssignmentTest{
+	"assignment": {
+		arg:              " postgres://localhost:5432/reporting = setup.sql ",
+		expectedDatabase: "postgres://localhost:5432/reporting",
+		expectedValue:    "setup.sql",
+		expectedOk:       true,
+	},
+	"value containing '='": {
+		arg:              "sqlite:main.db=get-credentials --profile=reporting",
+		expectedDatabase: "sqlite:main.db",
+		expectedValue:    "get-credentials --profile=reporting",
+		expectedOk:       true,
+	},
+	"empty value": {
+		arg:              "sqlite:main.db=",
+		expectedDatabase: "sqlite:main.db",
+		expectedOk:       true,
+	},
+	"missing database": {
+		arg: " =setup.sql",
+	},
+	"missing '='": {
+		arg: "sqlite:main.db",
+	},
+}
+
+func TestParseDatabaseAssignment(t *testing.T) {
+	for name, test := range testCasesParseDatabaseAssignment {
+		database, value, ok := parseDatabaseAssignment(test.arg)
+		if database != test.expectedDatabase || value != test.expectedValue || ok != test.expectedOk {
+			t.Errorf("Test: '%s' FAILED : expected '%s', '%s', %v, got '%s', '%s', %v", name, test.expectedDatabase, test.expectedValue, test.expectedOk, database, value, ok)
+		}
+	}
+}
@@ -3,8 +3,6 @@ package db_client
 import (
 	"context"
 	"database/sql"
-	"github.com/spf13/viper"
-	"github.com/turbot/pipe-fittings/constants"
 
 	"github.com/turbot/pipe-fittings/backend"
 	"github.com/turbot/pipe-fittings/utils"
@@ -16,6 +14,10 @@ type DbClient struct {
 
 	// db handle
 	db *sql.DB
+	// schedules the connections of the db handle between the origins of queries
+	scheduler *connectionScheduler
+	// if the database has a credential command, the source of its current connection string
+	credentials *credentialSource
 
 	// the Backend
 	Backend backend.Backend
@@ -25,7 +27,21 @@ func NewDbClient(ctx context.Context, connectionString string, opts ...backend.C
 	utils.LogTime("db_client.NewDbClient start")
 	defer utils.LogTime("db_client.NewDbClient end")
 
-	b, err := backend.FromConnectionString(ctx, connectionString)
+	backendConnectionString := connectionString
+	if IsMetadataDatabase(connectionString) {
+		backendConnectionString = metadataBackendConnectionString
+	}
+	// if the database has a credential command, connect using the connection string it returns
+	credentials, err := credentialSourceForDatabase(connectionString)
+	if err != nil {
+		return nil, err
+	}
+	if credentials != nil {
+		if backendConnectionString, err = credentials.get(ctx, false); err != nil {
+			return nil, err
+		}
+	}
+	b, err := backend.FromConnectionString(ctx, backendConnectionString)
 	if err != nil {
 		return nil, err
 	}
@@ -33,6 +49,8 @@ func NewDbClient(ctx context.Context, connectionString string, opts ...backend.C
 	client := &DbClient{
 		connectionString: connectionString,
 		Backend:          b,
+		scheduler:        newClientConnectionScheduler(),
+		credentials:      credentials,
 	}
 
 	defer func() {
@@ -47,10 +65,7 @@ func NewDbClient(ctx context.Context, connectionString string, opts ...backend.C
 	config.MaxOpenConns = MaxDbConnections()
 	// if no search path override passed in as an option, use the viper config
 	if config.SearchPathConfig.Empty() {
-		config.SearchPathConfig = backend.SearchPathConfig{
-			SearchPath:       viper.GetStringSlice(constants.ArgSearchPath),
-			SearchPathPrefix: viper.GetStringSlice(constants.ArgSearchPathPrefix),
-		}
+		config.SearchPathConfig = SearchPathConfigForDatabase(connectionString)
 	}
 
 	if err := client.connect(ctx, backend.WithConfig(config)); err != nil {
@@ -2,6 +2,8 @@ package db_client
 
 import (
 	"context"
+	"database/sql"
+
 	"github.com/turbot/pipe-fittings/backend"
 	"github.com/turbot/pipe-fittings/utils"
 	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
@@ -11,7 +13,21 @@ func (c *DbClient) connect(ctx context.Context, opts ...backend.ConnectOption) e
 	utils.LogTime("db_client.establishConnectionPool start")
 	defer utils.LogTime("db_client.establishConnectionPool end")
 
-	db, err := c.Backend.Connect(ctx, opts...)
+	statements, err := c.setupStatements(ctx)
+	if err != nil {
+		return err
+	}
+
+	var db *sql.DB
+	if IsMetadataDatabase(c.connectionString) {
+		db, err = connectMetadata(ctx, c.Backend, statements, opts...)
+	} else if c.credentials != nil {
+		db, err = connectWithCredentials(ctx, c.credentials, statements, opts...)
+	} else if len(statements) > 0 {
+		db, err = connectWithSetup(ctx, c.Backend, statements, opts...)
+	} else {
+		db, err = c.Backend.Connect(ctx, opts...)
+	}
 	if err != nil {
 		return sperr.WrapWithMessage(err, "unable to connect to Backend")
 	}
@@ -19,3 +35,30 @@ func (c *DbClient) connect(ctx context.Context, opts ...backend.ConnectOption) e
 	c.db = db
 	return nil
 }
+
+// setupStatements returns the statements to run on each new connection - attaching any database files set with
+// --database-attach and any terraform inputs set with --terraform-input, then any SQL set with --database-on-connect
+func (c *DbClient) setupStatements(ctx context.Context) ([]string, error) {
+	attachments, err := AttachmentsForDatabase(c.connectionString)
+	if err != nil {
+		return nil, err
+	}
+	terraformInputs, err := terraformAttachments(ctx, c.Backend.Name())
+	if err != nil {
+		return nil, err
+	}
+	attachments = append(attachments, terraformInputs...)
+	var res []string
+	for _, attachment := range attachments {
+		statement, err := attachStatement(c.Backend.Name(), attachment)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, statement)
+	}
+	onConnect, err := OnConnectForDatabase(c.connectionString)
+	if err != nil {
+		return nil, err
+	}
+	return append(res, onConnect...), nil
+}
@@ -21,31 +21,75 @@ import (
 // NOTE: The returned Result MUST be fully read - otherwise the connection will block and will prevent further communication
 func (c *DbClient) Execute(ctx context.Context, query string, args ...any) (*localqueryresult.Result, error) {
 	// acquire a connection
-	databaseConnection, err := c.db.Conn(ctx)
+	databaseConnection, releaseConnection, err := c.acquireConnection(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// define callback to close session when the async execution is complete
-	closeSessionCallback := func() { _ = databaseConnection.Close() }
+	closeSessionCallback := func() {
+		_ = databaseConnection.Close()
+		releaseConnection()
+	}
 	return c.executeOnConnection(ctx, databaseConnection, closeSessionCallback, query, args...)
 }
 
 // ExecuteSync executes a query against this client and wait for the result
 func (c *DbClient) ExecuteSync(ctx context.Context, query string, args ...any) (*localqueryresult.SyncQueryResult, error) {
 	// acquire a connection
-	dbConn, err := c.db.Conn(ctx)
+	dbConn, releaseConnection, err := c.acquireConnection(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	defer func() {
 		dbConn.Close()
-
+		releaseConnection()
 	}()
 	return c.executeSyncOnConnection(ctx, dbConn, query, args...)
 }
 
+// ExecuteInTransaction executes the statements in a single transaction on one connection - if any statement fails,
+// the transaction is rolled back, so none of the statements take effect
+func (c *DbClient) ExecuteInTransaction(ctx context.Context, statements ...string) error {
+	dbConn, releaseConnection, err := c.acquireConnection(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		dbConn.Close()
+		releaseConnection()
+	}()
+
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return error_helpers.WrapError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after commit
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return error_helpers.WrapError(err)
+		}
+	}
+	return error_helpers.WrapError(tx.Commit())
+}
+
+// acquireConnection waits for the connection scheduler to grant the origin of the context a connection, then
+// acquires the connection - the returned function must be called once the connection is closed
+func (c *DbClient) acquireConnection(ctx context.Context) (*sql.Conn, func(), error) {
+	release, err := c.scheduler.acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	dbConn, err := c.db.Conn(ctx)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	return dbConn, release, nil
+}
+
 // execute a query against this client and wait for the result
 func (c *DbClient) executeSyncOnConnection(ctx context.Context, dbConn *sql.Conn, query string, args ...any) (*localqueryresult.SyncQueryResult, error) {
 	if query == "" {
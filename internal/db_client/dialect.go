@@ -0,0 +1,55 @@
+package db_client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/constants"
+)
+
+// the SQL generated by powerpipe (e.g. to page tables or load datasets) must be valid for the backend of the
+// query - these functions return the syntax which differs between backends
+
+// EscapeName quotes an identifier for the backend - MySQL quotes identifiers with backticks (double quotes are
+// string literals unless ANSI_QUOTES is set), the other backends use standard double quotes
+func EscapeName(backendName, name string) string {
+	if backendName == constants.MySQLBackendName {
+		return fmt.Sprintf("`%s`", strings.ReplaceAll(name, "`", "``"))
+	}
+	return PgEscapeName(name)
+}
+
+// Placeholder returns the bind parameter of the given (1-based) position - MySQL only supports positional '?'
+// parameters, so the parameters of a MySQL query must be in the order they appear in the query
+func Placeholder(backendName string, position int) string {
+	switch backendName {
+	case constants.MySQLBackendName:
+		return "?"
+	case constants.SQLiteBackendName:
+		return fmt.Sprintf("?%d", position)
+	default:
+		return fmt.Sprintf("$%d", position)
+	}
+}
+
+// TextType returns the type values are cast to, to compare them as text
+func TextType(backendName string) string {
+	if backendName == constants.MySQLBackendName {
+		return "char"
+	}
+	return "text"
+}
+
+// likeEscape is the escape character of the patterns returned by ContainsPattern (it is not a backslash, as
+// MySQL also treats a backslash as an escape in string literals)
+const likeEscape = "!"
+
+// ContainsPattern returns a LIKE pattern matching values containing the text, with the wildcards of the text escaped
+// - the pattern must be used with LikeEscapeClause
+func ContainsPattern(text string) string {
+	replacer := strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_")
+	return "%" + replacer.Replace(text) + "%"
+}
+
+// LikeEscapeClause is the ESCAPE clause of a LIKE comparison with a pattern returned by ContainsPattern
+const LikeEscapeClause = "escape '" + likeEscape + "'"
@@ -0,0 +1,43 @@
+package db_client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Listen listens for postgres notifications on the given channels, calling onNotify for each notification received
+// a dedicated connection is taken from the pool for the duration of the call, which blocks until
+// the context is cancelled or the connection fails
+func (c *DbClient) Listen(ctx context.Context, channels []string, onNotify func(channel, payload string)) error {
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// stop listening before returning the connection to the pool (this fails harmlessly if the connection is closed)
+		_, _ = conn.ExecContext(context.Background(), "unlisten *")
+		_ = conn.Close()
+	}()
+
+	for _, channel := range channels {
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("listen %s", PgEscapeName(channel))); err != nil {
+			return err
+		}
+	}
+
+	return conn.Raw(func(driverConn any) error {
+		pgxConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("notifications are only supported for postgres databases")
+		}
+		for {
+			notification, err := pgxConn.Conn().WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+			onNotify(notification.Channel, notification.Payload)
+		}
+	})
+}
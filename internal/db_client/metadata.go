@@ -0,0 +1,210 @@
+package db_client
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/turbot/pipe-fittings/backend"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// the metadata database exposes the state of powerpipe itself (the mods and resources of the workspace, the last
+// control runs and the snapshots) as tables, so that it can be queried like any other database, e.g.
+//
+//	powerpipe query run "select name, last_alarm from powerpipe_control_run" --database powerpipe://
+//
+// or used by the queries of a dashboard about the compliance program itself, by setting the database of the query
+// or dashboard to 'powerpipe://'
+//
+// the metadata database is an in-memory SQLite database - the tables are populated on each new connection
+// reading the tables is slow, so they are cached until the version of the metadata changes, and connections are kept
+// until then - a connection populated with an earlier version is discarded rather than reused, so each query sees the
+// current state
+const MetadataDatabase = "powerpipe://"
+
+// the SQLite connection string of the metadata database
+const metadataBackendConnectionString = "sqlite::memory:"
+
+// MetadataColumn is a column of a metadata table
+type MetadataColumn struct {
+	Name string
+	// the SQLite type of the column, e.g. TEXT, INTEGER, REAL or BOOLEAN
+	Type string
+}
+
+// MetadataTable is a table of the metadata database
+type MetadataTable struct {
+	Name    string
+	Columns []MetadataColumn
+	Rows    [][]any
+}
+
+// MetadataProvider provides the tables of the metadata database
+type MetadataProvider interface {
+	// Version returns a key identifying the current state of the metadata - it must be cheap, as it is checked before
+	// each query, and the tables are only read again when it changes
+	Version() (string, error)
+	// Tables returns the tables of the metadata database
+	Tables() ([]*MetadataTable, error)
+}
+
+var (
+	metadataProvider    MetadataProvider
+	metadataProviderMut sync.RWMutex
+
+	// the tables read from the provider, and their version
+	metadataTables  []*MetadataTable
+	metadataVersion string
+)
+
+// SetMetadataProvider sets the provider of the tables of the metadata database
+// this is set when the workspace is loaded - until then, the metadata database has no tables
+func SetMetadataProvider(provider MetadataProvider) {
+	metadataProviderMut.Lock()
+	defer metadataProviderMut.Unlock()
+	metadataProvider = provider
+	metadataTables, metadataVersion = nil, ""
+}
+
+// getMetadataTables returns the current metadata tables and their version, reading the tables from the provider
+// only if the version has changed since they were last read
+func getMetadataTables() ([]*MetadataTable, string, error) {
+	metadataProviderMut.Lock()
+	defer metadataProviderMut.Unlock()
+	if metadataProvider == nil {
+		return nil, "", nil
+	}
+
+	version, err := metadataProvider.Version()
+	if err != nil {
+		return nil, "", err
+	}
+	if metadataTables != nil && version == metadataVersion {
+		return metadataTables, metadataVersion, nil
+	}
+	tables, err := metadataProvider.Tables()
+	if err != nil {
+		return nil, "", err
+	}
+	metadataTables, metadataVersion = tables, version
+	return tables, version, nil
+}
+
+// getMetadataVersion returns the current version of the metadata
+func getMetadataVersion() (string, error) {
+	metadataProviderMut.RLock()
+	defer metadataProviderMut.RUnlock()
+	if metadataProvider == nil {
+		return "", nil
+	}
+	return metadataProvider.Version()
+}
+
+// IsMetadataDatabase returns whether the database is the metadata database
+func IsMetadataDatabase(database string) bool {
+	return strings.HasPrefix(database, MetadataDatabase)
+}
+
+// connectMetadata connects to the metadata database, creating the metadata tables (then running the setup
+// statements) on each new connection
+func connectMetadata(ctx context.Context, b backend.Backend, statements []string, opts ...backend.ConnectOption) (*sql.DB, error) {
+	connector := &setupConnector{
+		Connector: &metadataConnector{sqliteConnector{dsn: b.ConnectionString(), driver: &sqlite3.SQLiteDriver{}}},
+		AfterConnectFunc: func(ctx context.Context, conn driver.Conn) error {
+			return execStatements(ctx, conn, statements)
+		},
+	}
+	return openDB(ctx, connector, opts...)
+}
+
+// metadataConnector opens connections to the metadata database, populated with the current metadata tables
+type metadataConnector struct {
+	sqliteConnector
+}
+
+func (c *metadataConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	tables, version, err := getMetadataTables()
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "failed to read the powerpipe metadata")
+	}
+	conn, err := c.sqliteConnector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := createMetadataTables(ctx, conn, tables); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &metadataConn{SQLiteConn: conn.(*sqlite3.SQLiteConn), version: version}, nil
+}
+
+// metadataConn is a connection to the metadata database, populated with the given version of the metadata
+type metadataConn struct {
+	*sqlite3.SQLiteConn
+	version string
+}
+
+// ResetSession implements driver.SessionResetter - it is called before the connection is reused, so that a
+// connection populated with an earlier version of the metadata is discarded
+func (c *metadataConn) ResetSession(context.Context) error {
+	if version, err := getMetadataVersion(); err != nil || version != c.version {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+// createMetadataTables creates and populates the metadata tables on a new connection
+func createMetadataTables(ctx context.Context, conn driver.Conn, tables []*MetadataTable) error {
+	if len(tables) == 0 {
+		return nil
+	}
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		return fmt.Errorf("%T does not implement ExecerContext", conn)
+	}
+	// populate the tables in a single transaction - committing each insert is slow
+	if _, err := execer.ExecContext(ctx, "BEGIN", nil); err != nil {
+		return err
+	}
+	for _, table := range tables {
+		if err := createMetadataTable(ctx, execer, table); err != nil {
+			_, _ = execer.ExecContext(ctx, "ROLLBACK", nil)
+			return sperr.WrapWithMessage(err, "failed to create metadata table %s", table.Name)
+		}
+	}
+	_, err := execer.ExecContext(ctx, "COMMIT", nil)
+	return err
+}
+
+func createMetadataTable(ctx context.Context, execer driver.ExecerContext, table *MetadataTable) error {
+	columns := make([]string, len(table.Columns))
+	placeholders := make([]string, len(table.Columns))
+	for i, c := range table.Columns {
+		columns[i] = fmt.Sprintf("%s %s", PgEscapeName(c.Name), c.Type)
+		placeholders[i] = "?"
+	}
+	if _, err := execer.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (%s)", PgEscapeName(table.Name), strings.Join(columns, ", ")), nil); err != nil {
+		return err
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s VALUES (%s)", PgEscapeName(table.Name), strings.Join(placeholders, ", "))
+	for _, row := range table.Rows {
+		args := make([]driver.NamedValue, len(row))
+		for i, value := range row {
+			v, err := driver.DefaultParameterConverter.ConvertValue(value)
+			if err != nil {
+				return err
+			}
+			args[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+		}
+		if _, err := execer.ExecContext(ctx, insert, args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package db_client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/turbot/powerpipe/internal/queryresult"
+)
+
+type metadataDatabaseTest struct {
+	query    string
+	expected string
+}
+
+var testCasesMetadataDatabase = map[string]metadataDatabaseTest{
+	"all rows": {
+		query:    "select name, runs from powerpipe_test order by name",
+		expected: "[[a 1] [b <nil>] [it's 3]]",
+	},
+	"aggregate": {
+		query:    "select sum(runs) from powerpipe_test",
+		expected: "[[4]]",
+	},
+	"boolean": {
+		query:    "select name from powerpipe_test where enabled",
+		expected: "[[a]]",
+	},
+}
+
+// testMetadataProvider provides a test table, counting the reads of the table
+type testMetadataProvider struct {
+	version string
+	rows    [][]any
+	reads   int
+}
+
+func (p *testMetadataProvider) Version() (string, error) {
+	return p.version, nil
+}
+
+func (p *testMetadataProvider) Tables() ([]*MetadataTable, error) {
+	p.reads++
+	return []*MetadataTable{{
+		Name: "powerpipe_test",
+		Columns: []MetadataColumn{
+			{Name: "name", Type: "TEXT"},
+			{Name: "runs", Type: "INTEGER"},
+			{Name: "enabled", Type: "BOOLEAN"},
+		},
+		Rows: p.rows,
+	}}, nil
+}
+
+func TestMetadataDatabase(t *testing.T) {
+	provider := &testMetadataProvider{version: "1", rows: [][]any{{"a", 1, true}, {"b", nil, false}, {"it's", 3, false}}}
+	SetMetadataProvider(provider)
+	defer SetMetadataProvider(nil)
+
+	ctx := context.Background()
+	client, err := NewDbClient(ctx, MetadataDatabase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close(ctx)
+
+	for name, test := range testCasesMetadataDatabase {
+		res, err := client.ExecuteSync(ctx, test.query)
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		var rows [][]any
+		for _, row := range res.Rows {
+			rows = append(rows, row.(*queryresult.RowResult).Data)
+		}
+		if actual := fmt.Sprint(rows); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, actual)
+		}
+	}
+	// the tables are only read once while the version is unchanged
+	if provider.reads != 1 {
+		t.Errorf("Test: 'cached' FAILED : expected the tables to be read once, got %d reads for %d queries", provider.reads, len(testCasesMetadataDatabase))
+	}
+
+	// when the version changes, the tables are read again and queries see the current state
+	provider.version, provider.rows = "2", [][]any{{"c", 5, true}}
+	for i := 0; i < 2; i++ {
+		res, err := client.ExecuteSync(ctx, "select sum(runs) from powerpipe_test")
+		if err != nil {
+			t.Fatalf("Test: 'changed' FAILED : unexpected error %v", err)
+		}
+		if actual := fmt.Sprint(res.Rows[0].(*queryresult.RowResult).Data); actual != "[5]" {
+			t.Errorf("Test: 'changed' FAILED : expected [5], got %s", actual)
+		}
+	}
+	if provider.reads != 2 {
+		t.Errorf("Test: 'changed' FAILED : expected the tables to be read again once, got %d reads", provider.reads)
+	}
+}
@@ -0,0 +1,50 @@
+package db_client
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// session setup SQL may be run on each new connection to a database using --database-on-connect, e.g. to set a role
+// for row level security, set session parameters required by a warehouse, or create temporary functions
+//
+//	--database-on-connect "postgres://localhost:5432/reporting=setup.sql"
+//
+// the SQL of each file is run as a single statement, after the search path has been set - so it may override it
+// NOTE: MySQL only runs multiple statements in one file if the connection string sets 'multiStatements=true'
+
+// OnConnectForDatabase returns the SQL to run on each new connection to the given database
+func OnConnectForDatabase(database string) ([]string, error) {
+	paths, err := databaseOnConnectPaths(viper.GetStringSlice(localconstants.ArgDatabaseOnConnect))
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, path := range paths[database] {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, sperr.New("could not read %s file '%s': %s", localconstants.ArgDatabaseOnConnect, path, err.Error())
+		}
+		if sql := strings.TrimSpace(string(data)); sql != "" {
+			res = append(res, sql)
+		}
+	}
+	return res, nil
+}
+
+// databaseOnConnectPaths parses args of the form 'database=path' into a map of SQL file paths keyed by database
+func databaseOnConnectPaths(args []string) (map[string][]string, error) {
+	res := make(map[string][]string)
+	for _, arg := range args {
+		database, path, ok := parseDatabaseAssignment(arg)
+		if !ok || path == "" {
+			return nil, sperr.New("invalid %s '%s' - expected 'database=path'", localconstants.ArgDatabaseOnConnect, arg)
+		}
+		res[database] = append(res[database], path)
+	}
+	return res, nil
+}
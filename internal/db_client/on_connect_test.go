@@ -0,0 +1,54 @@
+package db_client
+
+import (
+	"reflect"
+	"testing"
+)
+
+type databaseOnConnectPathsTest struct {
+	args        []string
+	expected    map[string][]string
+	expectError bool
+}
+
+var testCasesDatabaseOnConnectPaths = map[string]databaseOnConnectPathsTest{
+	"single file": {
+		args:     []string{"postgres://localhost:5432/reporting=setup.sql"},
+		expected: map[string][]string{"postgres://localhost:5432/reporting": {"setup.sql"}},
+	},
+	"path containing '='": {
+		args:     []string{"postgres://localhost:5432/reporting=sql/env=prod/setup.sql"},
+		expected: map[string][]string{"postgres://localhost:5432/reporting": {"sql/env=prod/setup.sql"}},
+	},
+	"multiple files": {
+		args:     []string{"sqlite:main.db=role.sql", "sqlite:main.db=functions.sql"},
+		expected: map[string][]string{"sqlite:main.db": {"role.sql", "functions.sql"}},
+	},
+	"missing database": {
+		args:        []string{"=setup.sql"},
+		expectError: true,
+	},
+	"missing path": {
+		args:        []string{"sqlite:main.db="},
+		expectError: true,
+	},
+}
+
+func TestDatabaseOnConnectPaths(t *testing.T) {
+	for name, test := range testCasesDatabaseOnConnectPaths {
+		paths, err := databaseOnConnectPaths(test.args)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error, got none", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(paths, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, paths)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package db_client
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/filepaths"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/terraform"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// Terraform plan and state files set with --terraform-input are loaded into a DuckDB database file (see the terraform
+// package), which is attached to each DuckDB database of the run - other databases are not affected
+const defaultTerraformAlias = "terraform"
+
+// terraformAttachments returns the databases of the terraform inputs to attach to a database of the given backend
+func terraformAttachments(ctx context.Context, backendName string) ([]Attachment, error) {
+	args := viper.GetStringSlice(localconstants.ArgTerraformInput)
+	if len(args) == 0 || backendName != constants.DuckDBBackendName {
+		return nil, nil
+	}
+	inputs, err := terraformInputs(args)
+	if err != nil {
+		return nil, err
+	}
+	cacheDir := filepath.Join(filepaths.EnsureInternalDir(), "terraform")
+	for i, input := range inputs {
+		dbPath, err := terraform.Database(ctx, input.Path, cacheDir)
+		if err != nil {
+			return nil, err
+		}
+		inputs[i].Path = dbPath
+	}
+	return inputs, nil
+}
+
+// terraformInputs parses args of the form 'alias:path' (or 'path') into the inputs to load
+func terraformInputs(args []string) ([]Attachment, error) {
+	var res []Attachment
+	aliases := make(map[string]struct{})
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		input := Attachment{Alias: defaultTerraformAlias, Path: arg}
+		// a single letter before the colon is a windows drive, not an alias
+		if alias, path, ok := strings.Cut(arg, ":"); ok && len(alias) > 1 && attachmentAliasRegex.MatchString(alias) {
+			input = Attachment{Alias: alias, Path: path}
+		}
+		if input.Path == "" {
+			return nil, sperr.New("invalid %s '%s' - expected 'alias:path' or 'path'", localconstants.ArgTerraformInput, arg)
+		}
+		if _, ok := aliases[input.Alias]; ok {
+			return nil, sperr.New("invalid %s '%s' - the alias '%s' is already used, so an alias must be given, e.g. 'plan:%s'", localconstants.ArgTerraformInput, arg, input.Alias, input.Path)
+		}
+		aliases[input.Alias] = struct{}{}
+		res = append(res, input)
+	}
+	return res, nil
+}
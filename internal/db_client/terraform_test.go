@@ -0,0 +1,54 @@
+package db_client
+
+import (
+	"reflect"
+	"testing"
+)
+
+type terraformInputsTest struct {
+	args        []string
+	expected    []Attachment
+	expectError bool
+}
+
+var testCasesTerraformInputs = map[string]terraformInputsTest{
+	"default alias": {
+		args:     []string{"plan.json"},
+		expected: []Attachment{{Alias: "terraform", Path: "plan.json"}},
+	},
+	"alias": {
+		args:     []string{"plan:/infra/plan.json", "state:/infra/terraform.tfstate"},
+		expected: []Attachment{{Alias: "plan", Path: "/infra/plan.json"}, {Alias: "state", Path: "/infra/terraform.tfstate"}},
+	},
+	"windows path": {
+		args:     []string{`C:\infra\plan.json`},
+		expected: []Attachment{{Alias: "terraform", Path: `C:\infra\plan.json`}},
+	},
+	"duplicate alias": {
+		args:        []string{"plan.json", "terraform.tfstate"},
+		expectError: true,
+	},
+	"missing path": {
+		args:        []string{"plan:"},
+		expectError: true,
+	},
+}
+
+func TestTerraformInputs(t *testing.T) {
+	for name, test := range testCasesTerraformInputs {
+		inputs, err := terraformInputs(test.args)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error, got none", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(inputs, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, inputs)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package deprecation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+// resources may be marked as deprecated using a 'deprecated' tag, whose value describes the migration path, e.g.
+//
+//	benchmark "cis_v140" {
+//	  children = [...]
+//	  tags = {
+//	    deprecated = "use benchmark.cis_v200 instead"
+//	  }
+//	}
+//
+// running a deprecated resource, or a resource which references a deprecated resource (as a descendant,
+// its query or its base), emits a warning in the CLI output and the dashboard UI
+const TagDeprecated = "deprecated"
+
+// Message returns the deprecation message of the resource, and whether it is deprecated
+func Message(resource modconfig.HclResource) (string, bool) {
+	message, ok := resource.GetTags()[TagDeprecated]
+	return strings.TrimSpace(message), ok
+}
+
+// Warnings returns a warning for each of the targets which is deprecated, and for each deprecated resource
+// the targets reference - each deprecated resource is only reported once
+func Warnings(targets ...modconfig.ModTreeItem) []string {
+	w := &walker{visited: make(map[string]struct{})}
+	for _, target := range targets {
+		w.visit(target, nil)
+	}
+	return w.warnings
+}
+
+type walker struct {
+	visited  map[string]struct{}
+	warnings []string
+}
+
+func (w *walker) visit(resource modconfig.HclResource, referencedBy modconfig.HclResource) {
+	if helpers.IsNil(resource) {
+		return
+	}
+	if _, ok := w.visited[resource.Name()]; ok {
+		return
+	}
+	w.visited[resource.Name()] = struct{}{}
+
+	if message, ok := Message(resource); ok {
+		w.warnings = append(w.warnings, warning(resource, referencedBy, message))
+	}
+
+	if queryProvider, ok := resource.(modconfig.QueryProvider); ok {
+		if query := queryProvider.GetQuery(); query != nil {
+			w.visit(query, resource)
+		}
+	}
+	w.visit(resource.GetBase(), resource)
+	if treeItem, ok := resource.(modconfig.ModTreeItem); ok {
+		for _, child := range treeItem.GetChildren() {
+			w.visit(child, resource)
+		}
+	}
+}
+
+func warning(resource modconfig.HclResource, referencedBy modconfig.HclResource, message string) string {
+	var res string
+	if referencedBy == nil {
+		res = fmt.Sprintf("%s is deprecated", resource.Name())
+	} else {
+		res = fmt.Sprintf("%s references deprecated %s", referencedBy.Name(), resource.Name())
+	}
+	if message != "" {
+		res = fmt.Sprintf("%s: %s", res, message)
+	}
+	return res
+}
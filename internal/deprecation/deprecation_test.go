@@ -0,0 +1,70 @@
+package deprecation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+func newTestControl(name string, tags map[string]string) *modconfig.Control {
+	c := &modconfig.Control{}
+	c.FullName = name
+	c.Tags = tags
+	return c
+}
+
+func newTestBenchmark(name string, tags map[string]string, children ...modconfig.ModTreeItem) *modconfig.Benchmark {
+	b := &modconfig.Benchmark{}
+	b.FullName = name
+	b.Tags = tags
+	b.SetChildren(children)
+	return b
+}
+
+type warningsTest struct {
+	targets  []modconfig.ModTreeItem
+	expected []string
+}
+
+func testCasesWarnings() map[string]warningsTest {
+	query := &modconfig.Query{}
+	query.FullName = "m.query.q"
+	query.Tags = map[string]string{TagDeprecated: "use query.q2 instead"}
+
+	withQuery := newTestControl("m.control.with_query", nil)
+	withQuery.Query = query
+
+	deprecatedControl := newTestControl("m.control.old", map[string]string{TagDeprecated: ""})
+	current := newTestBenchmark("m.benchmark.current", nil, deprecatedControl, newTestControl("m.control.ok", nil), withQuery)
+
+	return map[string]warningsTest{
+		"deprecated target": {
+			targets: []modconfig.ModTreeItem{newTestBenchmark("m.benchmark.old", map[string]string{TagDeprecated: " use benchmark.current instead "}, current)},
+			expected: []string{
+				"m.benchmark.old is deprecated: use benchmark.current instead",
+				"m.benchmark.current references deprecated m.control.old",
+				"m.control.with_query references deprecated m.query.q: use query.q2 instead",
+			},
+		},
+		"reported once": {
+			targets: []modconfig.ModTreeItem{current, deprecatedControl},
+			expected: []string{
+				"m.benchmark.current references deprecated m.control.old",
+				"m.control.with_query references deprecated m.query.q: use query.q2 instead",
+			},
+		},
+		"not deprecated": {
+			targets: []modconfig.ModTreeItem{newTestBenchmark("m.benchmark.b", nil, newTestControl("m.control.ok", nil))},
+		},
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	for name, test := range testCasesWarnings() {
+		actual := Warnings(test.targets...)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package detection
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	typehelpers "github.com/turbot/go-kit/types"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+// detections are queries which are tagged with a detection schedule - each run writes a snapshot of its findings (as
+// scheduled runs do), and its findings are sent to the notifiers of the routing config (if set), e.g.
+//
+//	query "failed_logins" {
+//	  sql = "select ..."
+//	  tags = {
+//	    detection_schedule = "5m"
+//	    detection_severity = "high"
+//	  }
+//	}
+const (
+	TagSchedule = "detection_schedule"
+	TagSeverity = "detection_severity"
+
+	DefaultSeverity = "info"
+	// the minimum schedule interval - this avoids overloading the database
+	MinSchedule = 30 * time.Second
+)
+
+// Detection is a query which is executed on a schedule in server mode - every row returned is a Finding
+type Detection struct {
+	Name     string
+	Title    string
+	Schedule time.Duration
+	Severity string
+	Query    *modconfig.Query
+}
+
+// GetDetections returns a detection for each query in the resource maps with a detection schedule tag
+func GetDetections(resourceMaps *modconfig.ResourceMaps) ([]*Detection, error) {
+	var res []*Detection
+	for _, query := range resourceMaps.Queries {
+		schedule, ok := query.Tags[TagSchedule]
+		if !ok {
+			continue
+		}
+		d, err := newDetection(query, schedule)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+	// sort for consistent ordering
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
+
+func newDetection(query *modconfig.Query, schedule string) (*Detection, error) {
+	interval, err := time.ParseDuration(schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s tag '%s' for %s: %s", TagSchedule, schedule, query.Name(), err.Error())
+	}
+	if interval < MinSchedule {
+		return nil, fmt.Errorf("invalid %s tag '%s' for %s: must be at least %s", TagSchedule, schedule, query.Name(), MinSchedule)
+	}
+	if typehelpers.SafeString(query.SQL) == "" {
+		return nil, fmt.Errorf("detection %s has no sql", query.Name())
+	}
+
+	severity := query.Tags[TagSeverity]
+	if severity == "" {
+		severity = DefaultSeverity
+	}
+
+	return &Detection{
+		Name:     query.Name(),
+		Title:    query.GetTitle(),
+		Schedule: interval,
+		Severity: severity,
+		Query:    query,
+	}, nil
+}
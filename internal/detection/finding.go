@@ -0,0 +1,68 @@
+package detection
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/turbot/pipe-fittings/queryresult"
+)
+
+// columns which, if returned by a detection query, override the finding properties
+const (
+	columnTimestamp = "timestamp"
+	columnSeverity  = "severity"
+)
+
+// Finding is a single row returned by a detection query
+type Finding struct {
+	Detection string         `json:"detection"`
+	Title     string         `json:"title,omitempty"`
+	Severity  string         `json:"severity"`
+	Timestamp time.Time      `json:"timestamp"`
+	RunTime   time.Time      `json:"run_time"`
+	Data      map[string]any `json:"data"`
+	// the path or url of the snapshot of the detection run (if snapshots are written)
+	Snapshot string `json:"snapshot,omitempty"`
+}
+
+// GetDataValue returns the value of the column of the finding as a string, or "" if there is no such column
+func (f *Finding) GetDataValue(column string) string {
+	value, ok := f.Data[column]
+	if !ok || value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func newFinding(d *Detection, cols []*queryresult.ColumnDef, row []any, runTime time.Time) *Finding {
+	f := &Finding{
+		Detection: d.Name,
+		Title:     d.Title,
+		Severity:  d.Severity,
+		Timestamp: runTime,
+		RunTime:   runTime,
+		Data:      make(map[string]any, len(cols)),
+	}
+	for i, col := range cols {
+		if i >= len(row) {
+			break
+		}
+		value := row[i]
+		f.Data[col.Name] = value
+
+		switch col.Name {
+		case columnTimestamp:
+			if t, ok := value.(time.Time); ok {
+				f.Timestamp = t
+			}
+		case columnSeverity:
+			if s, ok := value.(string); ok && s != "" {
+				f.Severity = s
+			}
+		}
+	}
+	return f
+}
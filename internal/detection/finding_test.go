@@ -0,0 +1,67 @@
+package detection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turbot/pipe-fittings/queryresult"
+)
+
+type newFindingTest struct {
+	cols              []string
+	row               []any
+	expectedSeverity  string
+	expectedTimestamp time.Time
+}
+
+func testCasesNewFinding(runTime, eventTime time.Time) map[string]newFindingTest {
+	return map[string]newFindingTest{
+		"defaults": {
+			cols:              []string{"user_name"},
+			row:               []any{"alice"},
+			expectedSeverity:  "high",
+			expectedTimestamp: runTime,
+		},
+		"severity column": {
+			cols:              []string{"user_name", "severity"},
+			row:               []any{"alice", "critical"},
+			expectedSeverity:  "critical",
+			expectedTimestamp: runTime,
+		},
+		"timestamp column": {
+			cols:              []string{"timestamp", "user_name"},
+			row:               []any{eventTime, "alice"},
+			expectedSeverity:  "high",
+			expectedTimestamp: eventTime,
+		},
+		"non time timestamp column": {
+			cols:              []string{"timestamp"},
+			row:               []any{"yesterday"},
+			expectedSeverity:  "high",
+			expectedTimestamp: runTime,
+		},
+	}
+}
+
+func TestNewFinding(t *testing.T) {
+	runTime := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	eventTime := runTime.Add(-time.Hour)
+	d := &Detection{Name: "mod.query.failed_logins", Severity: "high"}
+
+	for name, test := range testCasesNewFinding(runTime, eventTime) {
+		var cols []*queryresult.ColumnDef
+		for _, c := range test.cols {
+			cols = append(cols, &queryresult.ColumnDef{Name: c})
+		}
+		f := newFinding(d, cols, test.row, runTime)
+		if f.Severity != test.expectedSeverity {
+			t.Errorf("Test: '%s' FAILED : expected severity %s, got %s", name, test.expectedSeverity, f.Severity)
+		}
+		if !f.Timestamp.Equal(test.expectedTimestamp) {
+			t.Errorf("Test: '%s' FAILED : expected timestamp %v, got %v", name, test.expectedTimestamp, f.Timestamp)
+		}
+		if len(f.Data) != len(test.cols) {
+			t.Errorf("Test: '%s' FAILED : expected %d data values, got %d", name, len(test.cols), len(f.Data))
+		}
+	}
+}
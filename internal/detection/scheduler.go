@@ -0,0 +1,167 @@
+package detection
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+	"github.com/turbot/powerpipe/internal/db_client"
+	localqueryresult "github.com/turbot/powerpipe/internal/queryresult"
+	"github.com/turbot/powerpipe/internal/snapshotdest"
+)
+
+// the number of findings retained in memory (across all detections)
+const maxRetainedFindings = 1000
+
+// FindingsHandler is called each time a detection run completes
+type FindingsHandler func(ctx context.Context, d *Detection, findings []*Finding, err error)
+
+// Scheduler executes detections on their schedule and retains the most recent findings
+// if a snapshot location is set, a snapshot of each detection run with findings is written to it
+type Scheduler struct {
+	detections       []*Detection
+	workspaceMod     *modconfig.Mod
+	clientMap        *db_client.ClientMap
+	snapshotLocation string
+	onFindings       FindingsHandler
+
+	findingsLock sync.RWMutex
+	findings     []*Finding
+}
+
+func NewScheduler(detections []*Detection, workspaceMod *modconfig.Mod, clientMap *db_client.ClientMap, snapshotLocation string, onFindings FindingsHandler) *Scheduler {
+	return &Scheduler{
+		detections:       detections,
+		workspaceMod:     workspaceMod,
+		clientMap:        clientMap,
+		snapshotLocation: snapshotLocation,
+		onFindings:       onFindings,
+	}
+}
+
+// Start starts a goroutine for each detection - these run until the context is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, d := range s.detections {
+		go s.runDetectionLoop(ctx, d)
+	}
+}
+
+// Detections returns the scheduled detections
+func (s *Scheduler) Detections() []*Detection {
+	return s.detections
+}
+
+// Findings returns the retained findings, most recent first
+// if detectionName is set, only findings for that detection are returned
+func (s *Scheduler) Findings(detectionName string) []*Finding {
+	s.findingsLock.RLock()
+	defer s.findingsLock.RUnlock()
+
+	res := make([]*Finding, 0, len(s.findings))
+	for i := len(s.findings) - 1; i >= 0; i-- {
+		if f := s.findings[i]; detectionName == "" || f.Detection == detectionName {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+func (s *Scheduler) runDetectionLoop(ctx context.Context, d *Detection) {
+	// schedule the database connections of the detection fairly with those of dashboard sessions
+	ctx = db_client.WithOrigin(ctx, "detection:"+d.Name)
+	ticker := time.NewTicker(d.Schedule)
+	defer ticker.Stop()
+
+	// run immediately, then on the schedule
+	for {
+		s.runDetection(ctx, d)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) runDetection(ctx context.Context, d *Detection) {
+	slog.Debug("running detection", "detection", d.Name)
+	runTime := time.Now()
+
+	findings, data, err := s.executeDetection(ctx, d, runTime)
+	if err != nil {
+		slog.Warn("detection failed", "detection", d.Name, "error", err)
+	} else {
+		if len(findings) > 0 && s.snapshotLocation != "" {
+			s.writeSnapshot(ctx, d, findings, data, runTime)
+		}
+		s.addFindings(findings)
+	}
+
+	if s.onFindings != nil {
+		s.onFindings(ctx, d, findings, err)
+	}
+}
+
+// executeDetection executes the query of the detection, returning its findings and the data of the query
+func (s *Scheduler) executeDetection(ctx context.Context, d *Detection, runTime time.Time) ([]*Finding, *dashboardtypes.LeafData, error) {
+	defaultDatabase, defaultSearchPathConfig := db_client.GetDefaultDatabaseConfig()
+	database, searchPathConfig, err := db_client.GetDatabaseConfigForResource(d.Query, s.workspaceMod, defaultDatabase, defaultSearchPathConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := s.clientMap.GetOrCreate(ctx, database, searchPathConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resolvedQuery, err := d.Query.GetResolvedQuery(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := client.ExecuteSync(ctx, resolvedQuery.ExecuteSQL, resolvedQuery.Args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	findings := make([]*Finding, 0, len(result.Rows))
+	for _, r := range result.Rows {
+		row, ok := r.(*localqueryresult.RowResult)
+		if !ok || row.Error != nil {
+			continue
+		}
+		findings = append(findings, newFinding(d, result.Cols, row.Data, runTime))
+	}
+	data, err := dashboardtypes.NewLeafData(result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return findings, data, nil
+}
+
+// writeSnapshot writes the snapshot of the detection run to the snapshot location, and sets the snapshot of the
+// findings - a failure to write the snapshot does not fail the run
+func (s *Scheduler) writeSnapshot(ctx context.Context, d *Detection, findings []*Finding, data *dashboardtypes.LeafData, runTime time.Time) {
+	snapshotPath, err := snapshotdest.WriteSnapshot(ctx, s.snapshotLocation, newSnapshot(d, data, runTime, time.Now()))
+	if err != nil {
+		slog.Warn("failed to write detection snapshot", "detection", d.Name, "error", err)
+		return
+	}
+	for _, f := range findings {
+		f.Snapshot = snapshotPath
+	}
+}
+
+func (s *Scheduler) addFindings(findings []*Finding) {
+	s.findingsLock.Lock()
+	defer s.findingsLock.Unlock()
+
+	s.findings = append(s.findings, findings...)
+	if excess := len(s.findings) - maxRetainedFindings; excess > 0 {
+		s.findings = s.findings[excess:]
+	}
+}
@@ -0,0 +1,57 @@
+package detection
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/pipe-fittings/steampipeconfig"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+// snapshotPanel is a panel of the snapshot of a detection run
+type snapshotPanel struct {
+	Name      string                   `json:"name"`
+	PanelType string                   `json:"panel_type"`
+	Dashboard string                   `json:"dashboard"`
+	Title     string                   `json:"title,omitempty"`
+	Status    dashboardtypes.RunStatus `json:"status"`
+	Data      *dashboardtypes.LeafData `json:"data,omitempty"`
+}
+
+// IsSnapshotPanel implements SnapshotPanel
+func (*snapshotPanel) IsSnapshotPanel() {}
+
+// newSnapshot returns the snapshot of the findings of a detection run - as the snapshot of a query, the snapshot is a
+// dashboard containing a table of the rows returned by the detection query
+func newSnapshot(d *Detection, data *dashboardtypes.LeafData, startTime, endTime time.Time) *steampipeconfig.SteampipeSnapshot {
+	return &steampipeconfig.SteampipeSnapshot{
+		SchemaVersion: fmt.Sprintf("%d", steampipeconfig.SteampipeSnapshotSchemaVersion),
+		Panels: map[string]steampipeconfig.SnapshotPanel{
+			d.Name: &snapshotPanel{
+				Name:      d.Name,
+				PanelType: schema.BlockTypeDashboard,
+				Dashboard: d.Name,
+				Title:     d.Title,
+				Status:    dashboardtypes.RunComplete,
+			},
+			modconfig.SnapshotQueryTableName: &snapshotPanel{
+				Name:      modconfig.SnapshotQueryTableName,
+				PanelType: schema.BlockTypeTable,
+				Dashboard: d.Name,
+				Status:    dashboardtypes.RunComplete,
+				Data:      data,
+			},
+		},
+		Layout: &steampipeconfig.SnapshotTreeNode{
+			Name:     d.Name,
+			NodeType: schema.BlockTypeDashboard,
+			Children: []*steampipeconfig.SnapshotTreeNode{{Name: modconfig.SnapshotQueryTableName, NodeType: schema.BlockTypeTable}},
+		},
+		StartTime:    startTime,
+		EndTime:      endTime,
+		Title:        d.Title,
+		FileNameRoot: d.Name,
+	}
+}
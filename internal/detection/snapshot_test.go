@@ -0,0 +1,49 @@
+package detection
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/queryresult"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+func TestWriteSnapshot(t *testing.T) {
+	location := t.TempDir()
+	s := NewScheduler(nil, nil, nil, location, nil)
+	d := &Detection{Name: "mod.query.failed_logins", Title: "Failed logins"}
+	findings := []*Finding{{Detection: d.Name}, {Detection: d.Name}}
+	data := &dashboardtypes.LeafData{
+		Columns: []*queryresult.ColumnDef{{Name: "user_name"}},
+		Rows:    []map[string]any{{"user_name": "alice"}, {"user_name": "bob"}},
+	}
+	s.writeSnapshot(context.Background(), d, findings, data, time.Now())
+
+	for _, f := range findings {
+		if filepath.Dir(f.Snapshot) != location {
+			t.Fatalf("Test: 'snapshot' FAILED : expected the snapshot to be written to %s, got '%s'", location, f.Snapshot)
+		}
+	}
+	snapshotData, err := os.ReadFile(findings[0].Snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snapshot struct {
+		Panels map[string]struct {
+			Data *dashboardtypes.LeafData `json:"data"`
+		} `json:"panels"`
+	}
+	if err := json.Unmarshal(snapshotData, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	// the snapshot has the layout of a query snapshot
+	table, ok := snapshot.Panels[modconfig.SnapshotQueryTableName]
+	if !ok || table.Data == nil || len(table.Data.Rows) != 2 {
+		t.Errorf("Test: 'snapshot' FAILED : expected a table of 2 rows, got %s", snapshotData)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	typeHelpers "github.com/turbot/go-kit/types"
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/queryresult"
+	"github.com/turbot/powerpipe/internal/locale"
 )
 
 // columnNames builds a list of name from a slice of column defs - respecting the original name if present
@@ -25,7 +26,11 @@ func columnNames(columns []*queryresult.ColumnDef) []string {
 	return colNames
 }
 
-type columnValueSettings struct{ nullString string }
+type columnValueSettings struct {
+	nullString string
+	// whether to format numbers using the locale settings (numbers are left unformatted in machine readable output)
+	formatNumbers bool
+}
 
 type ColumnValueOption func(opt *columnValueSettings)
 
@@ -35,6 +40,12 @@ func WithNullString(nullString string) ColumnValueOption {
 	}
 }
 
+func WithFormattedNumbers() ColumnValueOption {
+	return func(opt *columnValueSettings) {
+		opt.formatNumbers = true
+	}
+}
+
 // ColumnValuesAsString converts a slice of columns into strings
 func ColumnValuesAsString(values []interface{}, columns []*queryresult.ColumnDef, opts ...ColumnValueOption) ([]string, error) {
 	rowAsString := make([]string, len(columns))
@@ -74,10 +85,15 @@ func ColumnValueAsString(val interface{}, col *queryresult.ColumnDef, opts ...Co
 			return "", err
 		}
 		return string(bytes), nil
+	case "TIMESTAMPTZ":
+		if t, ok := val.(time.Time); ok {
+			return locale.Current().FormatTime(t), nil
+		}
+		return typeHelpers.ToString(val), nil
 	case "TIMESTAMP", "DATE", "TIME", "INTERVAL":
 		t, ok := val.(time.Time)
 		if ok {
-			return t.Format("2006-01-02 15:04:05"), nil
+			return locale.Current().FormatLocalTime(t), nil
 		}
 		fallthrough
 	case "NAME":
@@ -85,6 +101,11 @@ func ColumnValueAsString(val interface{}, col *queryresult.ColumnDef, opts ...Co
 		return result, nil
 
 	default:
+		if opt.formatNumbers {
+			if res, ok := locale.Current().FormatColumnNumber(col.Name, val); ok {
+				return res, nil
+			}
+		}
 		return typeHelpers.ToString(val), nil
 	}
 }
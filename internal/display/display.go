@@ -174,7 +174,7 @@ func displayLine(ctx context.Context, result *queryresult.Result) int {
 
 	// define a function to display each row
 	rowFunc := func(row []interface{}, result *queryresult.Result) {
-		recordAsString, _ := ColumnValuesAsString(row, result.Cols)
+		recordAsString, _ := ColumnValuesAsString(row, result.Cols, WithFormattedNumbers())
 		requiredTerminalColumnsForValuesOfRecord := 0
 		for _, colValue := range recordAsString {
 			colRequired := getTerminalColumnsRequiredForString(colValue)
@@ -354,7 +354,7 @@ func displayTable(ctx context.Context, result *queryresult.Result) int {
 
 	// define a function to execute for each row
 	rowFunc := func(row []interface{}, result *queryresult.Result) {
-		rowAsString, _ := ColumnValuesAsString(row, result.Cols)
+		rowAsString, _ := ColumnValuesAsString(row, result.Cols, WithFormattedNumbers())
 		rowObj := table.Row{}
 		for _, col := range rowAsString {
 			// trim out non-displayable code-points in string
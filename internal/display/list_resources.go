@@ -13,7 +13,12 @@ import (
 	"github.com/turbot/pipe-fittings/printers"
 	"github.com/turbot/pipe-fittings/schema"
 	"github.com/turbot/pipe-fittings/workspace"
+	"github.com/turbot/powerpipe/internal/assemble"
 	localcmdconfig "github.com/turbot/powerpipe/internal/cmdconfig"
+	"github.com/turbot/powerpipe/internal/conditional"
+	"github.com/turbot/powerpipe/internal/eventbus"
+	"github.com/turbot/powerpipe/internal/modsource"
+	"github.com/turbot/powerpipe/internal/modvars"
 )
 
 func ListResources[T modconfig.ModTreeItem](cmd *cobra.Command) {
@@ -22,11 +27,23 @@ func ListResources[T modconfig.ModTreeItem](cmd *cobra.Command) {
 	modLocation := viper.GetString(constants.ArgModLocation)
 	// build options to specify which blocks we need to load (based on type T
 	opts := getListLoadWorkspaceOpts[T]()
+	_, err := modsource.RestoreVendoredMods(modLocation)
+	error_helpers.FailOnError(err)
 	w, errAndWarnings := workspace.LoadWorkspacePromptingForVariables(ctx, modLocation, opts...)
 	error_helpers.FailOnError(errAndWarnings.GetError())
+	eventbus.Publish(eventbus.NewEvent(eventbus.EventWorkspaceLoaded, w.Mod.Name(), map[string]any{"path": w.Path}))
+	// remove disabled resources and add the selected controls to any assembled benchmarks
+	error_helpers.FailOnError(conditional.RemoveDisabled(w.GetResourceMaps()))
+	error_helpers.FailOnError(assemble.Benchmarks(w.GetResourceMaps()))
+	// as variables are not validated when listing resources, warn of invalid dependency mod args - rather than
+	// failing, so the variables of the dependency mods may be listed
+	if err := modvars.ValidateRequireArgs(w.Mod); err != nil {
+		error_helpers.ShowWarning(err.Error())
+	}
 
 	// get resource filter depending on resource type and output type
-	resourceFilter := getListResourceFilter[T](w)
+	resourceFilter, err := getListResourceFilter[T](w)
+	error_helpers.FailOnError(err)
 	resources, err := workspace.FilterWorkspaceResourcesOfType[T](w, resourceFilter)
 	if err != nil {
 		error_helpers.ShowErrorWithMessage(ctx, err, "failed to filter resources")
@@ -51,7 +68,7 @@ func ListResources[T modconfig.ModTreeItem](cmd *cobra.Command) {
 	}
 }
 
-func getListResourceFilter[T modconfig.ModTreeItem](w *workspace.Workspace) workspace.ResourceFilter {
+func getListResourceFilter[T modconfig.ModTreeItem](w *workspace.Workspace) (workspace.ResourceFilter, error) {
 	var res = workspace.ResourceFilter{}
 
 	var empty T
@@ -82,7 +99,21 @@ func getListResourceFilter[T modconfig.ModTreeItem](w *workspace.Workspace) work
 		}
 	}
 
-	return res
+	// if T is variable, and a dependency mod is specified, only show the variables of that mod
+	if _, ok := any(empty).(*modconfig.Variable); ok {
+		if depModName := viper.GetString(localconstants.ArgDependencyMod); depModName != "" {
+			depMod, err := modvars.FindDependencyMod(w.Mod, depModName)
+			if err != nil {
+				return res, err
+			}
+			res.WherePredicate = func(item modconfig.HclResource) bool {
+				variable, ok := item.(*modconfig.Variable)
+				return ok && variable.ModName == depMod.ShortName
+			}
+		}
+	}
+
+	return res, nil
 }
 
 // build LoadWorkspaceOptions to specify which blocks we need to load (based on type T)
@@ -104,8 +135,18 @@ func ShowResource[T modconfig.ModTreeItem](cmd *cobra.Command, args []string) {
 	modLocation := viper.GetString(constants.ArgModLocation)
 	// build options to specify which blocks we need to load (based on type T
 	opts := getListLoadWorkspaceOpts[T]()
+	_, err := modsource.RestoreVendoredMods(modLocation)
+	error_helpers.FailOnError(err)
 	w, errAndWarnings := workspace.LoadWorkspacePromptingForVariables(ctx, modLocation, opts...)
 	error_helpers.FailOnError(errAndWarnings.GetError())
+	eventbus.Publish(eventbus.NewEvent(eventbus.EventWorkspaceLoaded, w.Mod.Name(), map[string]any{"path": w.Path}))
+	// remove disabled resources and add the selected controls to any assembled benchmarks
+	error_helpers.FailOnError(conditional.RemoveDisabled(w.GetResourceMaps()))
+	error_helpers.FailOnError(assemble.Benchmarks(w.GetResourceMaps()))
+	// warn of invalid dependency mod args
+	if err := modvars.ValidateRequireArgs(w.Mod); err != nil {
+		error_helpers.ShowWarning(err.Error())
+	}
 	if !w.ModfileExists() {
 		error_helpers.FailOnError(localconstants.ErrorNoModDefinition{})
 	}
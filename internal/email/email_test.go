@@ -0,0 +1,167 @@
+package email
+
+import (
+	"encoding/base64"
+	"github.com/turbot/powerpipe/internal/integration"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type loadIntegrationFileTest struct {
+	config   string
+	expected []*Integration
+	err      string
+}
+
+var testCasesLoadIntegrationFile = map[string]loadIntegrationFileTest{
+	"defaults": {
+		config: `
+integration "email" "weekly" {
+  smtp_host = "smtp.acme.com"
+  from      = "Powerpipe <powerpipe@acme.com>"
+  to        = ["security@acme.com"]
+}
+integration "jira" "security" {
+  url = "https://acme.atlassian.net"
+}`,
+		expected: []*Integration{{
+			Name:     "weekly",
+			SmtpHost: "smtp.acme.com",
+			SmtpPort: 587,
+			From:     "Powerpipe <powerpipe@acme.com>",
+			To:       []string{"security@acme.com"},
+		}},
+	},
+	"no recipients": {
+		config: `
+integration "email" "weekly" {
+  smtp_host = "smtp.acme.com"
+  from      = "powerpipe@acme.com"
+  to        = []
+}`,
+		err: "email integration 'weekly' has no recipients: 'to' must contain at least one address",
+	},
+	"invalid address": {
+		config: `
+integration "email" "weekly" {
+  smtp_host = "smtp.acme.com"
+  from      = "powerpipe@acme.com"
+  to        = ["security@acme.com"]
+  cc        = ["ciso"]
+}`,
+		err: "email integration 'weekly' has invalid address 'ciso': mail: missing '@' or angle-addr",
+	},
+	"duplicate attachment": {
+		config: `
+integration "email" "weekly" {
+  smtp_host   = "smtp.acme.com"
+  from        = "powerpipe@acme.com"
+  to          = ["security@acme.com"]
+  attachments = ["csv", "csv"]
+}`,
+		err: "email integration 'weekly' has duplicate attachment 'csv'",
+	},
+}
+
+func TestLoadIntegrationFile(t *testing.T) {
+	dir := t.TempDir()
+	for name, test := range testCasesLoadIntegrationFile {
+		filePath := filepath.Join(dir, strings.ReplaceAll(name, " ", "_")+".ppc")
+		if err := os.WriteFile(filePath, []byte(test.config), 0600); err != nil {
+			t.Fatal(err)
+		}
+		var actual []*Integration
+		blocks, err := integration.FileBlocks(filePath, IntegrationTypeEmail)
+		for _, block := range blocks {
+			var i *Integration
+			if i, err = decodeIntegration(block); err != nil {
+				break
+			}
+			actual = append(actual, i)
+		}
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("Test: '%s' FAILED : expected error '%s', got %v", name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %+v, got %+v", name, test.expected, actual)
+		}
+	}
+}
+
+func TestMessageBytes(t *testing.T) {
+	msg := &message{
+		from:    "Powerpipe <powerpipe@acme.com>",
+		to:      []string{"security@acme.com", "ops@acme.com"},
+		subject: "Weekly report – CIS",
+		date:    time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC),
+		html:    []byte("<h1>Report</h1>"),
+		attachments: []attachment{
+			{filename: "benchmark.cis.csv", data: []byte(strings.Repeat("a,b\n", 30))},
+		},
+	}
+	data, err := msg.bytes("test-boundary")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	headers := map[string]string{
+		"To":      parsed.Header.Get("To"),
+		"Cc":      parsed.Header.Get("Cc"),
+		"Subject": subject,
+		"Date":    parsed.Header.Get("Date"),
+	}
+	expectedHeaders := map[string]string{
+		"To":      "security@acme.com, ops@acme.com",
+		"Cc":      "",
+		"Subject": "Weekly report – CIS",
+		"Date":    "Mon, 04 Mar 2024 09:00:00 +0000",
+	}
+	if !reflect.DeepEqual(headers, expectedHeaders) {
+		t.Errorf("Test: 'headers' FAILED : expected %v, got %v", expectedHeaders, headers)
+	}
+
+	var parts []string
+	reader := multipart.NewReader(parsed.Body, "test-boundary")
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the reader decodes quoted-printable parts - base64 parts must be decoded by the caller
+		content, _ := io.ReadAll(part)
+		if part.Header.Get("Content-Transfer-Encoding") == "base64" {
+			content, _ = base64.StdEncoding.DecodeString(strings.ReplaceAll(string(content), "\r\n", ""))
+		}
+		parts = append(parts, part.Header.Get("Content-Type")+" "+part.FileName()+" "+string(content))
+	}
+	expectedParts := []string{
+		"text/html; charset=utf-8  <h1>Report</h1>",
+		"text/csv; name=benchmark.cis.csv benchmark.cis.csv " + strings.Repeat("a,b\n", 30),
+	}
+	if !reflect.DeepEqual(parts, expectedParts) {
+		t.Errorf("Test: 'parts' FAILED : expected %q, got %q", expectedParts, parts)
+	}
+}
@@ -0,0 +1,179 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/export"
+)
+
+const (
+	ExportPrefix = "email:"
+	// the body of the email is the export of this format
+	bodyFormat = "html"
+)
+
+// Exporter sends the HTML report by email, with the exports of any other formats attached
+// a copy of the sent message is written to the destination path
+type Exporter struct {
+	export.ExporterBase
+	integration *Integration
+	body        export.Exporter
+	attachments []export.Exporter
+}
+
+// GetExporters returns an exporter for each email integration in the export args
+// the body and attachments of the email are rendered using the given exporters, i.e. the export formats of the command
+// an error is returned if an integration is not defined in the workspace config, or has an unsupported attachment
+func GetExporters(exportArgs []string, exporters []export.Exporter) ([]export.Exporter, error) {
+	var names []string
+	for _, arg := range exportArgs {
+		if name, ok := ParseExportArg(arg); ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	configPaths, err := cmdconfig.GetConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	integrations, err := LoadIntegrations(configPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []export.Exporter
+	seen := make(map[string]struct{})
+	for _, name := range names {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+
+		integration, ok := integrations[name]
+		if !ok {
+			return nil, fmt.Errorf("email integration '%s' not found - integrations must be defined in the workspace config", name)
+		}
+		exporter, err := newExporter(integration, exporters)
+		if err != nil {
+			return nil, err
+		}
+		slog.Debug("registering email exporter", "integration", name, "attachments", integration.Attachments)
+		res = append(res, exporter)
+	}
+	return res, nil
+}
+
+// ParseExportArg returns the integration name of an email export arg, e.g. email:weekly
+func ParseExportArg(arg string) (string, bool) {
+	arg = strings.TrimSpace(arg)
+	if !strings.HasPrefix(arg, ExportPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(arg, ExportPrefix), true
+}
+
+func newExporter(integration *Integration, exporters []export.Exporter) (*Exporter, error) {
+	body := findExporter(exporters, bodyFormat)
+	if body == nil {
+		return nil, fmt.Errorf("email export is not supported by this command: it has no %s export format", bodyFormat)
+	}
+	res := &Exporter{integration: integration, body: body}
+	for _, format := range integration.Attachments {
+		exporter := findExporter(exporters, format)
+		if exporter == nil {
+			return nil, fmt.Errorf("email integration '%s' has attachment '%s', which is not an export format of this command", integration.Name, format)
+		}
+		res.attachments = append(res.attachments, exporter)
+	}
+	return res, nil
+}
+
+// findExporter returns the exporter with the given name or alias
+func findExporter(exporters []export.Exporter, format string) export.Exporter {
+	for _, exporter := range exporters {
+		if exporter.Name() == format || exporter.Alias() == format {
+			return exporter
+		}
+	}
+	return nil
+}
+
+func (e *Exporter) Export(ctx context.Context, input export.ExportSourceData, destPath string) error {
+	// the exports are named after the destination, i.e. <execution name>.<timestamp>.<extension>
+	baseName := strings.TrimSuffix(filepath.Base(destPath), e.FileExtension())
+
+	tmpDir, err := os.MkdirTemp("", "powerpipe-email")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	html, err := render(ctx, e.body, input, filepath.Join(tmpDir, baseName+e.body.FileExtension()))
+	if err != nil {
+		return err
+	}
+	msg := &message{
+		from:    e.integration.From,
+		to:      e.integration.To,
+		cc:      e.integration.Cc,
+		subject: e.subject(baseName),
+		date:    time.Now(),
+		html:    html,
+	}
+	for _, exporter := range e.attachments {
+		filename := baseName + exporter.FileExtension()
+		data, err := render(ctx, exporter, input, filepath.Join(tmpDir, filename))
+		if err != nil {
+			return err
+		}
+		msg.attachments = append(msg.attachments, attachment{filename: filename, data: data})
+	}
+
+	data, err := msg.bytes("")
+	if err != nil {
+		return err
+	}
+	if err := send(ctx, e.integration, data); err != nil {
+		return fmt.Errorf("failed to send email using integration '%s': %w", e.integration.Name, err)
+	}
+	return export.Write(destPath, bytes.NewReader(data))
+}
+
+// subject returns the subject of the integration, defaulting to the execution name
+func (e *Exporter) subject(baseName string) string {
+	if e.integration.Subject != "" {
+		return e.integration.Subject
+	}
+	// strip the timestamp from the base name
+	if idx := strings.LastIndex(baseName, "."); idx > 0 {
+		baseName = baseName[:idx]
+	}
+	return fmt.Sprintf("Powerpipe report: %s", baseName)
+}
+
+// render exports the input using the exporter, returning the exported data
+func render(ctx context.Context, exporter export.Exporter, input export.ExportSourceData, path string) ([]byte, error) {
+	if err := exporter.Export(ctx, input, path); err != nil {
+		return nil, fmt.Errorf("failed to export %s for email: %w", exporter.Name(), err)
+	}
+	return os.ReadFile(path)
+}
+
+func (e *Exporter) FileExtension() string {
+	return ".eml"
+}
+
+func (e *Exporter) Name() string {
+	return ExportPrefix + e.integration.Name
+}
@@ -0,0 +1,121 @@
+package email
+
+import (
+	"fmt"
+	"net/mail"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/turbot/powerpipe/internal/integration"
+)
+
+// email integrations are defined in the workspace config (.ppc) files, e.g.
+//
+//	integration "email" "weekly" {
+//	  smtp_host     = "smtp.acme.com"
+//	  smtp_port     = 587
+//	  smtp_username = "powerpipe@acme.com"
+//	  smtp_password = "..."
+//	  from          = "Powerpipe <powerpipe@acme.com>"
+//	  to            = ["security@acme.com"]
+//	  cc            = ["ciso@acme.com"]
+//	  subject       = "Weekly compliance report"
+//	  attachments   = ["csv"]
+//	}
+//
+// and are used by a run with 'powerpipe benchmark run --export email:weekly'
+// the HTML report is sent as the body of the email, and each attachment is an export format of the command,
+// e.g. csv for benchmarks, or pdf for dashboards
+const (
+	IntegrationTypeEmail = "email"
+
+	defaultSmtpPort = 587
+	// the port for SMTP over implicit TLS - other ports use STARTTLS, if the server supports it
+	implicitTlsSmtpPort = 465
+)
+
+// Integration is an SMTP server and the recipients a report is sent to
+type Integration struct {
+	Name         string
+	SmtpHost     string   `hcl:"smtp_host"`
+	SmtpPort     int      `hcl:"smtp_port,optional"`
+	SmtpUsername string   `hcl:"smtp_username,optional"`
+	SmtpPassword string   `hcl:"smtp_password,optional"`
+	From         string   `hcl:"from"`
+	To           []string `hcl:"to"`
+	Cc           []string `hcl:"cc,optional"`
+	Subject      string   `hcl:"subject,optional"`
+	Attachments  []string `hcl:"attachments,optional"`
+}
+
+// LoadIntegrations loads the email integrations from the config files in the given paths
+func LoadIntegrations(configPaths []string) (map[string]*Integration, error) {
+	blocks, err := integration.Blocks(configPaths, IntegrationTypeEmail)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]*Integration, len(blocks))
+	for name, block := range blocks {
+		i, err := decodeIntegration(block)
+		if err != nil {
+			return nil, err
+		}
+		res[name] = i
+	}
+	return res, nil
+}
+
+func decodeIntegration(block *hcl.Block) (*Integration, error) {
+	res := &Integration{Name: block.Labels[1]}
+	if diags := gohcl.DecodeBody(block.Body, nil, res); diags.HasErrors() {
+		return nil, fmt.Errorf("failed to decode email integration '%s': %s", res.Name, diags.Error())
+	}
+	if err := res.validate(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (i *Integration) validate() error {
+	if i.SmtpHost == "" {
+		return fmt.Errorf("email integration '%s' has no smtp_host", i.Name)
+	}
+	if i.SmtpPort == 0 {
+		i.SmtpPort = defaultSmtpPort
+	}
+	if i.SmtpPort < 0 || i.SmtpPort > 65535 {
+		return fmt.Errorf("email integration '%s' has invalid smtp_port %d", i.Name, i.SmtpPort)
+	}
+	if len(i.To) == 0 {
+		return fmt.Errorf("email integration '%s' has no recipients: 'to' must contain at least one address", i.Name)
+	}
+	for _, address := range append(append([]string{i.From}, i.To...), i.Cc...) {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("email integration '%s' has invalid address '%s': %s", i.Name, address, err.Error())
+		}
+	}
+	for idx, format := range i.Attachments {
+		if slices.Contains(i.Attachments[:idx], format) {
+			return fmt.Errorf("email integration '%s' has duplicate attachment '%s'", i.Name, format)
+		}
+	}
+	return nil
+}
+
+// recipients returns the addresses of the to and cc recipients
+func (i *Integration) recipients() []string {
+	var res []string
+	for _, address := range append(slices.Clone(i.To), i.Cc...) {
+		// addresses are validated when the integration is loaded
+		parsed, _ := mail.ParseAddress(address)
+		res = append(res, parsed.Address)
+	}
+	return res
+}
+
+// sender returns the address of the sender
+func (i *Integration) sender() string {
+	parsed, _ := mail.ParseAddress(i.From)
+	return parsed.Address
+}
@@ -0,0 +1,121 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// the maximum line length of base64 encoded attachments (RFC 2045)
+const base64LineLength = 76
+
+// attachment is a file attached to an email
+type attachment struct {
+	filename string
+	data     []byte
+}
+
+// message is an email with an HTML body, and any attachments
+type message struct {
+	from        string
+	to          []string
+	cc          []string
+	subject     string
+	date        time.Time
+	html        []byte
+	attachments []attachment
+}
+
+// bytes returns the message in MIME format
+// boundary sets the multipart boundary - if empty, a random boundary is used
+func (m *message) bytes(boundary string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	if boundary != "" {
+		if err := writer.SetBoundary(boundary); err != nil {
+			return nil, err
+		}
+	}
+
+	headers := [][2]string{
+		{"From", m.from},
+		{"To", strings.Join(m.to, ", ")},
+		{"Cc", strings.Join(m.cc, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", m.subject)},
+		{"Date", m.date.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/mixed; boundary=%q", writer.Boundary())},
+	}
+	for _, header := range headers {
+		if header[1] == "" {
+			continue
+		}
+		fmt.Fprintf(&buf, "%s: %s\r\n", header[0], header[1])
+	}
+	buf.WriteString("\r\n")
+
+	body, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qp := quotedprintable.NewWriter(body)
+	if _, err := qp.Write(m.html); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+
+	for _, a := range m.attachments {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(contentType(a.filename), map[string]string{"name": a.filename})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(a.data)
+		for len(encoded) > base64LineLength {
+			fmt.Fprintf(part, "%s\r\n", encoded[:base64LineLength])
+			encoded = encoded[base64LineLength:]
+		}
+		fmt.Fprintf(part, "%s\r\n", encoded)
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// the media types of the common export formats, which are not known to all systems
+var contentTypes = map[string]string{
+	".csv":  "text/csv",
+	".html": "text/html",
+	".json": "application/json",
+	".md":   "text/markdown",
+	".pdf":  "application/pdf",
+	".xml":  "application/xml",
+}
+
+// contentType returns the media type of an attachment, based on its extension
+func contentType(filename string) string {
+	if mediaType, ok := contentTypes[filepath.Ext(filename)]; ok {
+		return mediaType
+	}
+	if mediaType := mime.TypeByExtension(filepath.Ext(filename)); mediaType != "" {
+		return strings.Split(mediaType, ";")[0]
+	}
+	return "application/octet-stream"
+}
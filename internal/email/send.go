@@ -0,0 +1,95 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+)
+
+// the timeout for sending an email, if the context has no deadline
+const sendTimeout = time.Minute
+
+// send sends the message to the recipients of the integration
+func send(ctx context.Context, integration *Integration, data []byte) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, sendTimeout)
+		defer cancel()
+	}
+
+	client, err := dial(ctx, integration)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if integration.SmtpUsername != "" {
+		auth := smtp.PlainAuth("", integration.SmtpUsername, integration.SmtpPassword, integration.SmtpHost)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(integration.sender()); err != nil {
+		return err
+	}
+	for _, recipient := range integration.recipients() {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("recipient %s was rejected: %w", recipient, err)
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// dial connects to the SMTP server of the integration, using TLS if the server supports it
+func dial(ctx context.Context, integration *Integration) (*smtp.Client, error) {
+	address := net.JoinHostPort(integration.SmtpHost, strconv.Itoa(integration.SmtpPort))
+	tlsConfig := tlspolicy.Apply(&tls.Config{ServerName: integration.SmtpHost, MinVersion: tls.VersionTLS12})
+
+	var conn net.Conn
+	var err error
+	if integration.SmtpPort == implicitTlsSmtpPort {
+		dialer := &tls.Dialer{Config: tlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	// the smtp client does not take a context - bound the whole exchange by the context deadline
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, integration.SmtpHost)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	if integration.SmtpPort != implicitTlsSmtpPort {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+				return nil, fmt.Errorf("failed to start TLS with %s: %w", address, err)
+			}
+		}
+	}
+	return client, nil
+}
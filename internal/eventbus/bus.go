@@ -0,0 +1,214 @@
+package eventbus
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// lifecycle events of the workspace are published to the event bus, which delivers them to each configured sink, so
+// integrators may react to them without polling - sinks are configured using POWERPIPE_EVENT_SINKS, a comma separated
+// list of sinks (see ParseSink), e.g.
+//
+//	POWERPIPE_EVENT_SINKS=log,file:///var/log/powerpipe/events.jsonl,https://hooks.acme.com/powerpipe,nats://nats:4222/powerpipe
+//
+// events are delivered to each sink asynchronously and in order, so a slow or failing sink never delays a run - if the
+// queue of a sink is full, events are dropped (and a warning logged), and delivery errors are logged
+// when the command completes, the events queued for delivery are flushed (waiting at most flushTimeout)
+const (
+	sinkQueueSize = 1024
+	flushTimeout  = 5 * time.Second
+)
+
+// EventType is the type of an Event
+type EventType string
+
+const (
+	// EventWorkspaceLoaded is published when the workspace is loaded
+	EventWorkspaceLoaded EventType = "workspace_loaded"
+	// EventRunStarted is published when a benchmark or dashboard run starts
+	EventRunStarted EventType = "run_started"
+	// EventRunFinished is published when a benchmark or dashboard run finishes
+	EventRunFinished EventType = "run_finished"
+	// EventControlCompleted is published when a control run completes (successfully or with an error)
+	EventControlCompleted EventType = "control_completed"
+	// EventSnapshotWritten is published when a snapshot file is written
+	EventSnapshotWritten EventType = "snapshot_written"
+	// EventModInstalled is published when the dependencies of the workspace are installed or updated
+	EventModInstalled EventType = "mod_installed"
+)
+
+// EventTypes are the types of event published
+var EventTypes = []EventType{EventWorkspaceLoaded, EventRunStarted, EventRunFinished, EventControlCompleted, EventSnapshotWritten, EventModInstalled}
+
+// Event is a lifecycle event of the workspace
+type Event struct {
+	Type      EventType `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	// the path of the workspace
+	Workspace string `json:"workspace,omitempty"`
+	// the name of the resource the event relates to, e.g. the benchmark of a run or the control of a control run,
+	// or the path of a snapshot
+	Subject string `json:"subject,omitempty"`
+	// the details of the event, which depend on its type
+	Data map[string]any `json:"data,omitempty"`
+}
+
+// NewEvent returns an event of the given type, with the current time
+func NewEvent(eventType EventType, subject string, data map[string]any) Event {
+	return Event{Type: eventType, Timestamp: time.Now(), Subject: subject, Data: data}
+}
+
+// Sink delivers events - sinks which hold resources (e.g. a connection) may also implement io.Closer
+type Sink interface {
+	// Name returns a description of the sink, used when logging delivery errors
+	Name() string
+	Send(ctx context.Context, event Event) error
+}
+
+// Bus delivers the events published to it to its sinks
+type Bus struct {
+	workspace string
+	mut       sync.RWMutex
+	workers   []*sinkWorker
+}
+
+// NewBus returns a bus for the workspace at the given path, delivering events to the given sinks
+func NewBus(workspace string, sinks ...Sink) *Bus {
+	b := &Bus{workspace: workspace}
+	for _, sink := range sinks {
+		b.Register(sink)
+	}
+	return b
+}
+
+// Register adds a sink to the bus - it receives the events published after it is registered
+func (b *Bus) Register(sink Sink) {
+	w := &sinkWorker{sink: sink, events: make(chan Event, sinkQueueSize), done: make(chan struct{})}
+	go w.run()
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	b.workers = append(b.workers, w)
+}
+
+// Enabled returns whether the bus has any sinks
+func (b *Bus) Enabled() bool {
+	b.mut.RLock()
+	defer b.mut.RUnlock()
+	return len(b.workers) > 0
+}
+
+// Publish queues the event for delivery to each sink
+func (b *Bus) Publish(event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if event.Workspace == "" {
+		event.Workspace = b.workspace
+	}
+	b.mut.RLock()
+	defer b.mut.RUnlock()
+	for _, w := range b.workers {
+		select {
+		case w.events <- event:
+		default:
+			slog.Warn("event sink queue is full - dropping event", "sink", w.sink.Name(), "event", event.Type)
+		}
+	}
+}
+
+// Close delivers the queued events and closes the sinks, waiting until the context is done at most
+// (events which have not been delivered by then are dropped)
+func (b *Bus) Close(ctx context.Context) {
+	b.mut.Lock()
+	workers := b.workers
+	b.workers = nil
+	b.mut.Unlock()
+
+	for _, w := range workers {
+		close(w.events)
+	}
+	for _, w := range workers {
+		select {
+		case <-w.done:
+		case <-ctx.Done():
+			slog.Warn("timed out delivering events", "sink", w.sink.Name())
+		}
+		if closer, ok := w.sink.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				slog.Debug("failed to close event sink", "sink", w.sink.Name(), "error", err)
+			}
+		}
+	}
+}
+
+// sinkWorker delivers the events of a sink in order
+type sinkWorker struct {
+	sink   Sink
+	events chan Event
+	done   chan struct{}
+}
+
+func (w *sinkWorker) run() {
+	defer close(w.done)
+	for event := range w.events {
+		if err := w.sink.Send(context.Background(), event); err != nil {
+			slog.Warn("failed to deliver event", "sink", w.sink.Name(), "event", event.Type, "error", err)
+		}
+	}
+}
+
+var (
+	defaultBusLock sync.RWMutex
+	defaultBus     = NewBus("")
+)
+
+// Configure replaces the default bus with a bus for the workspace delivering events to the sinks of the given
+// (comma separated) sink configuration, closing the existing bus
+func Configure(workspace, sinksConfig string) error {
+	sinks, err := ParseSinks(sinksConfig)
+	if err != nil {
+		return err
+	}
+	bus := NewBus(workspace, sinks...)
+
+	defaultBusLock.Lock()
+	previous := defaultBus
+	defaultBus = bus
+	defaultBusLock.Unlock()
+
+	previous.Close(context.Background())
+	return nil
+}
+
+func getDefaultBus() *Bus {
+	defaultBusLock.RLock()
+	defer defaultBusLock.RUnlock()
+	return defaultBus
+}
+
+// Register adds a sink to the default bus
+func Register(sink Sink) {
+	getDefaultBus().Register(sink)
+}
+
+// Enabled returns whether the default bus has any sinks - callers may use this to avoid building events which would
+// not be delivered
+func Enabled() bool {
+	return getDefaultBus().Enabled()
+}
+
+// Publish publishes the event to the default bus
+func Publish(event Event) {
+	getDefaultBus().Publish(event)
+}
+
+// Flush delivers the events queued by the default bus and closes its sinks, waiting at most flushTimeout
+// - it is called when the command completes
+func Flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	getDefaultBus().Close(ctx)
+}
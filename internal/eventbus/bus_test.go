@@ -0,0 +1,114 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testSink records the subjects of the events it receives
+type testSink struct {
+	mut      sync.Mutex
+	subjects []string
+	closed   bool
+	// if set, Send blocks until the channel is closed
+	block chan struct{}
+	err   error
+}
+
+func (*testSink) Name() string {
+	return "test"
+}
+
+func (s *testSink) Send(_ context.Context, event Event) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.subjects = append(s.subjects, event.Subject)
+	return s.err
+}
+
+func (s *testSink) Close() error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.closed = true
+	return nil
+}
+
+func TestBus(t *testing.T) {
+	sink := &testSink{}
+	// a failing sink does not affect delivery to the other sinks
+	failing := &testSink{err: errors.New("unavailable")}
+	bus := NewBus("/workspace", sink, failing)
+	if !bus.Enabled() {
+		t.Errorf("Test: 'enabled' FAILED : expected the bus to be enabled")
+	}
+
+	var expected []string
+	for _, subject := range []string{"a", "b", "c", "d"} {
+		bus.Publish(NewEvent(EventControlCompleted, subject, nil))
+		expected = append(expected, subject)
+	}
+	bus.Close(context.Background())
+
+	for name, s := range map[string]*testSink{"sink": sink, "failing sink": failing} {
+		if !reflect.DeepEqual(s.subjects, expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, expected, s.subjects)
+		}
+		if !s.closed {
+			t.Errorf("Test: '%s' FAILED : expected the sink to be closed", name)
+		}
+	}
+	if bus.Enabled() {
+		t.Errorf("Test: 'closed' FAILED : expected the bus to have no sinks")
+	}
+}
+
+func TestBusCloseTimeout(t *testing.T) {
+	sink := &testSink{block: make(chan struct{})}
+	defer close(sink.block)
+	bus := NewBus("", sink)
+	bus.Publish(NewEvent(EventRunStarted, "a", nil))
+
+	// a sink which does not deliver its events does not block the command from completing
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	bus.Close(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Test: 'close timeout' FAILED : expected close to time out, took %s", elapsed)
+	}
+}
+
+func TestBusWorkspace(t *testing.T) {
+	sink := &recordingSink{}
+	bus := NewBus("/workspace", sink)
+	bus.Publish(Event{Type: EventWorkspaceLoaded})
+	bus.Close(context.Background())
+
+	if len(sink.events) != 1 {
+		t.Fatalf("Test: 'workspace' FAILED : expected 1 event, got %d", len(sink.events))
+	}
+	event := sink.events[0]
+	if event.Workspace != "/workspace" || event.Timestamp.IsZero() {
+		t.Errorf("Test: 'workspace' FAILED : expected the workspace and timestamp to be set, got %+v", event)
+	}
+}
+
+type recordingSink struct {
+	events []Event
+}
+
+func (*recordingSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingSink) Send(_ context.Context, event Event) error {
+	s.events = append(s.events, event)
+	return nil
+}
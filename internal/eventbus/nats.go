@@ -0,0 +1,196 @@
+package eventbus
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+)
+
+// events are published to NATS using the client protocol directly (a publish-only client needs only the CONNECT, PUB
+// and PING/PONG messages) - each publish is followed by a PING, so the server has processed the publish (or reported
+// an error) once its PONG is received
+// the connection is opened when the first event is published, and reopened if it fails
+const (
+	natsDefaultPort    = "4222"
+	natsDefaultSubject = "powerpipe.events"
+)
+
+type natsSink struct {
+	addr    string
+	host    string
+	useTLS  bool
+	subject string
+	user    string
+	pass    string
+	token   string
+
+	mut    sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func newNatsSink(spec string) (*natsSink, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event sink '%s': %s", spec, err.Error())
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid event sink '%s' - the NATS server host is missing", spec)
+	}
+	port := u.Port()
+	if port == "" {
+		port = natsDefaultPort
+	}
+	s := &natsSink{
+		addr:    net.JoinHostPort(u.Hostname(), port),
+		host:    u.Hostname(),
+		useTLS:  u.Scheme == "tls",
+		subject: natsDefaultSubject,
+	}
+	if subject := strings.Trim(u.Path, "/"); subject != "" {
+		s.subject = strings.ReplaceAll(subject, "/", ".")
+	}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			s.user, s.pass = u.User.Username(), pass
+		} else {
+			s.token = u.User.Username()
+		}
+	}
+	return s, nil
+}
+
+func (s *natsSink) Name() string {
+	return "nats://" + s.addr + "/" + s.subject
+}
+
+func (s *natsSink) Send(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.conn == nil {
+		if err := s.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if err := s.publish(s.subject+"."+string(event.Type), payload); err != nil {
+		// the connection is reopened for the next event
+		s.closeConn()
+		return err
+	}
+	return nil
+}
+
+func (s *natsSink) Close() error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.closeConn()
+	return nil
+}
+
+func (s *natsSink) closeConn() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+		s.reader = nil
+	}
+}
+
+// natsInfo is the INFO message sent by the server when a client connects
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+}
+
+// natsConnect is the CONNECT message sent by the client
+type natsConnect struct {
+	Verbose   bool   `json:"verbose"`
+	Pedantic  bool   `json:"pedantic"`
+	Name      string `json:"name"`
+	Lang      string `json:"lang"`
+	User      string `json:"user,omitempty"`
+	Pass      string `json:"pass,omitempty"`
+	AuthToken string `json:"auth_token,omitempty"`
+}
+
+func (s *natsSink) connect(ctx context.Context) error {
+	dialer := &net.Dialer{Timeout: sendTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(sendTimeout))
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to read the NATS server info: %w", err)
+	}
+	var info natsInfo
+	if infoJson, ok := strings.CutPrefix(strings.TrimSpace(line), "INFO "); ok {
+		_ = json.Unmarshal([]byte(infoJson), &info)
+	} else {
+		conn.Close()
+		return fmt.Errorf("unexpected NATS server message: %s", strings.TrimSpace(line))
+	}
+	if s.useTLS || info.TLSRequired {
+		tlsConn := tls.Client(conn, tlspolicy.Apply(&tls.Config{ServerName: s.host, MinVersion: tls.VersionTLS12}))
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return err
+		}
+		conn = tlsConn
+		reader = bufio.NewReader(conn)
+	}
+
+	connect, err := json.Marshal(natsConnect{Name: "powerpipe", Lang: "go", User: s.user, Pass: s.pass, AuthToken: s.token})
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\n", connect); err != nil {
+		conn.Close()
+		return err
+	}
+	s.conn = conn
+	s.reader = reader
+	return nil
+}
+
+// publish publishes the payload, then waits for the server to respond to a PING
+func (s *natsSink) publish(subject string, payload []byte) error {
+	_ = s.conn.SetDeadline(time.Now().Add(sendTimeout))
+	if _, err := fmt.Fprintf(s.conn, "PUB %s %d\r\n%s\r\nPING\r\n", subject, len(payload), payload); err != nil {
+		return err
+	}
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := s.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS server error: %s", strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")), "'"))
+		}
+		// (+OK and INFO messages are ignored)
+	}
+}
@@ -0,0 +1,126 @@
+package eventbus
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeNatsServer implements enough of the NATS server protocol to receive publishes
+type fakeNatsServer struct {
+	listener net.Listener
+	mut      sync.Mutex
+	connects []natsConnect
+	subjects []string
+	// if set, publishes are rejected with this error
+	reject string
+}
+
+func newFakeNatsServer(t *testing.T) *fakeNatsServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeNatsServer{listener: listener}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *fakeNatsServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeNatsServer) handle(conn net.Conn) {
+	defer conn.Close()
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"test\"}\r\n")
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		op, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch op {
+		case "CONNECT":
+			var connect natsConnect
+			_ = json.Unmarshal([]byte(args), &connect)
+			s.mut.Lock()
+			s.connects = append(s.connects, connect)
+			s.mut.Unlock()
+		case "PUB":
+			subject, _, _ := strings.Cut(args, " ")
+			// (read the payload)
+			if _, err := reader.ReadString('\n'); err != nil {
+				return
+			}
+			s.mut.Lock()
+			reject := s.reject
+			if reject == "" {
+				s.subjects = append(s.subjects, subject)
+			}
+			s.mut.Unlock()
+			if reject != "" {
+				fmt.Fprintf(conn, "-ERR '%s'\r\n", reject)
+			}
+		case "PING":
+			fmt.Fprintf(conn, "PONG\r\n")
+		}
+	}
+}
+
+func TestNatsSink(t *testing.T) {
+	server := newFakeNatsServer(t)
+	sink, err := ParseSink(fmt.Sprintf("nats://acme:secret@%s/acme/powerpipe", server.listener.Addr()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.(*natsSink).Close()
+
+	for _, eventType := range []EventType{EventRunStarted, EventRunFinished} {
+		if err := sink.Send(context.Background(), NewEvent(eventType, "acme.benchmark.cis", nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server.mut.Lock()
+	expected := []string{"acme.powerpipe.run_started", "acme.powerpipe.run_finished"}
+	if strings.Join(server.subjects, ",") != strings.Join(expected, ",") {
+		t.Errorf("Test: 'nats publish' FAILED : expected %v, got %v", expected, server.subjects)
+	}
+	// the connection is reused
+	if len(server.connects) != 1 || server.connects[0].User != "acme" || server.connects[0].Pass != "secret" {
+		t.Errorf("Test: 'nats connect' FAILED : expected a single connection authenticated as acme, got %+v", server.connects)
+	}
+	server.reject = "Permissions Violation for Publish"
+	server.mut.Unlock()
+
+	err = sink.Send(context.Background(), NewEvent(EventRunStarted, "acme.benchmark.cis", nil))
+	if err == nil || !strings.Contains(err.Error(), "Permissions Violation") {
+		t.Errorf("Test: 'nats error' FAILED : expected the server error, got %v", err)
+	}
+
+	// after an error, the sink reconnects
+	server.mut.Lock()
+	server.reject = ""
+	server.mut.Unlock()
+	if err := sink.Send(context.Background(), NewEvent(EventRunStarted, "acme.benchmark.cis", nil)); err != nil {
+		t.Fatal(err)
+	}
+	server.mut.Lock()
+	defer server.mut.Unlock()
+	if len(server.connects) != 2 {
+		t.Errorf("Test: 'nats reconnect' FAILED : expected 2 connections, got %d", len(server.connects))
+	}
+}
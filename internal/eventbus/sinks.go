@@ -0,0 +1,160 @@
+package eventbus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+)
+
+const (
+	// SinkLog logs each event
+	SinkLog = "log"
+
+	sendTimeout = 10 * time.Second
+)
+
+// ParseSinks returns the sinks of a comma separated sink configuration (see ParseSink)
+func ParseSinks(config string) ([]Sink, error) {
+	var res []Sink
+	for _, spec := range strings.Split(config, ",") {
+		if spec = strings.TrimSpace(spec); spec == "" {
+			continue
+		}
+		sink, err := ParseSink(spec)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, sink)
+	}
+	return res, nil
+}
+
+// ParseSink returns the sink of a sink configuration, which is one of:
+//   - log: each event is logged (at info level)
+//   - file:<path> (or file://<absolute path>): each event is appended to the file as a line of json
+//   - http(s)://<url>: each event is posted as json to the url (a webhook)
+//   - nats://[<user>:<password>@|<token>@]<host>[:<port>][/<subject>]: each event is published to the NATS server,
+//     with the subject <subject>.<event type> (the subject defaults to powerpipe.events) - use tls:// for servers
+//     requiring TLS
+func ParseSink(spec string) (Sink, error) {
+	if spec == SinkLog {
+		return &logSink{}, nil
+	}
+	scheme, rest, ok := strings.Cut(spec, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid event sink '%s' - must be one of: log, file:<path>, http(s)://<url>, nats://<host>[/<subject>]", spec)
+	}
+	switch strings.ToLower(scheme) {
+	case "file":
+		path := strings.TrimPrefix(rest, "//")
+		if path == "" {
+			return nil, fmt.Errorf("invalid event sink '%s' - the file path is missing", spec)
+		}
+		return &fileSink{path: path}, nil
+	case "http", "https":
+		if _, err := url.ParseRequestURI(spec); err != nil {
+			return nil, fmt.Errorf("invalid event sink '%s': %s", spec, err.Error())
+		}
+		return &webhookSink{url: spec, client: tlspolicy.HTTPClient()}, nil
+	case "nats", "tls":
+		sink, err := newNatsSink(spec)
+		if err != nil {
+			return nil, err
+		}
+		return sink, nil
+	default:
+		return nil, fmt.Errorf("invalid event sink '%s' - unsupported scheme '%s'", spec, scheme)
+	}
+}
+
+// logSink logs each event
+type logSink struct{}
+
+func (*logSink) Name() string {
+	return SinkLog
+}
+
+func (*logSink) Send(_ context.Context, event Event) error {
+	slog.Info("event", "type", event.Type, "subject", event.Subject, "workspace", event.Workspace, "data", event.Data)
+	return nil
+}
+
+// fileSink appends each event to a file as a line of json
+type fileSink struct {
+	path string
+	mut  sync.Mutex
+}
+
+func (s *fileSink) Name() string {
+	return "file:" + s.path
+}
+
+func (s *fileSink) Send(_ context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// webhookSink posts each event as json to a url
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *webhookSink) Name() string {
+	// (the url may contain credentials, so only the host is used)
+	if u, err := url.Parse(s.url); err == nil {
+		return u.Scheme + "://" + u.Host
+	}
+	return "webhook"
+}
+
+func (s *webhookSink) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Powerpipe-Event", string(event.Type))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned status %s", s.Name(), resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package exitcodes
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/workspace"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+// exit codes of failure classes which are specific to powerpipe - the others are defined by pipe-fittings
+const (
+	ExitCodeModResolutionFailed      = 63 // mod - resolving or installing the workspace dependencies failed
+	ExitCodeWorkspaceParseFailed     = 64 // mod - parsing the workspace files failed
+	ExitCodeModVerifyFailed          = 65 // mod - the installed dependencies do not match the lockfile
+	ExitCodeExportFailed             = 66 // check - the run or its exports failed (with --exit-after-export)
+	ExitCodeDatabaseConnectionFailed = 71 // database - connecting to the database failed
+	ExitCodeServerConnectionFailed   = 72 // server - connecting to the powerpipe server failed (ps and cancel)
+)
+
+// ExitCode is a documented exit code - exit codes and their classes are stable, so wrapper scripts can
+// branch on them
+type ExitCode struct {
+	Code        int    `json:"exit_code"`
+	Class       string `json:"class"`
+	Description string `json:"description"`
+}
+
+// Registry is the exit codes used by powerpipe, in order
+var Registry = []ExitCode{
+	{constants.ExitCodeSuccessful, "success", "The command succeeded"},
+	{constants.ExitCodeControlsAlarm, "alarm", "One or more controls are in alarm, and no controls are in error"},
+	{constants.ExitCodeControlsError, "control_error", "One or more controls are in error"},
+	{constants.ExitCodeSnapshotCreationFailed, "snapshot_creation", "Creating a snapshot failed"},
+	{constants.ExitCodeSnapshotUploadFailed, "snapshot_upload", "Uploading a snapshot failed"},
+	{constants.ExitCodeServiceSetupFailure, "service", "Setting up the service failed"},
+	{constants.ExitCodeServiceStartupFailure, "service", "Starting the service failed"},
+	{constants.ExitCodeServiceStopFailure, "service", "Stopping the service failed"},
+	{constants.ExitCodeQueryExecutionFailed, "query_execution", "One or more queries failed"},
+	{constants.ExitCodeLoginCloudConnectionFailed, "login", "Connecting to Turbot Pipes to log in failed"},
+	{constants.ExitCodeModInitFailed, "mod_init", "Initialising the mod failed"},
+	{constants.ExitCodeModInstallFailed, "mod_install", "Installing mods failed"},
+	{ExitCodeModResolutionFailed, "mod_resolution", "Resolving or installing the workspace dependencies failed"},
+	{ExitCodeWorkspaceParseFailed, "parse", "Parsing the workspace files failed, e.g. an invalid HCL block"},
+	{ExitCodeModVerifyFailed, "mod_verify", "The installed mod dependencies are missing, drifted or tampered with"},
+	{ExitCodeExportFailed, "export", "With --exit-after-export, running the benchmarks or writing their exports failed"},
+	{ExitCodeDatabaseConnectionFailed, "database_connection", "Connecting to the database failed"},
+	{ExitCodeServerConnectionFailed, "server_connection", "Connecting to the powerpipe server failed"},
+	{constants.ExitCodeInvalidExecutionEnvironment, "execution_environment", "Powerpipe is running in an unsupported environment"},
+	{constants.ExitCodeInitializationFailed, "initialization", "Initialisation failed"},
+	{constants.ExitCodeBindPortUnavailable, "port_unavailable", "The port of the dashboard server is not available"},
+	{constants.ExitCodeNoModFile, "no_mod_file", "The command requires a mod file, and none was found"},
+	{constants.ExitCodeFileSystemAccessFailure, "file_system", "Accessing the file system failed"},
+	{constants.ExitCodeInsufficientOrWrongInputs, "invalid_input", "The command line arguments or inputs are missing or invalid"},
+	{constants.ExitCodeUnknownErrorPanic, "unknown", "An unexpected error occurred"},
+}
+
+// Lookup returns the registered exit code - codes which are not registered have the class 'unknown'
+func Lookup(code int) ExitCode {
+	for _, e := range Registry {
+		if e.Code == code {
+			return e
+		}
+	}
+	return ExitCode{Code: code, Class: "unknown", Description: "An unexpected error occurred"}
+}
+
+// Error is an error which determines the exit code of the command
+type Error struct {
+	ExitCode int
+	Err      error
+}
+
+// WithExitCode returns the error with the exit code of its failure class
+func WithExitCode(err error, exitCode int) error {
+	if err == nil {
+		return nil
+	}
+	return Error{ExitCode: exitCode, Err: err}
+}
+
+func (e Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e Error) Unwrap() error {
+	return e.Err
+}
+
+// FromError returns the exit code of the failure class of an error, or defaultCode if it has none
+func FromError(err error, defaultCode int) int {
+	var exitCodeError Error
+	switch {
+	case errors.As(err, &exitCodeError):
+		return exitCodeError.ExitCode
+	case errors.Is(err, workspace.ErrorNoModDefinition), errors.As(err, &localconstants.ErrorNoModDefinition{}):
+		return constants.ExitCodeNoModFile
+	}
+	return defaultCode
+}
+
+// WorkspaceLoadError returns an error loading the workspace with its exit code - either the dependencies
+// of the workspace could not be resolved, or its files could not be parsed
+func WorkspaceLoadError(err error) error {
+	var errorModel perr.ErrorModel
+	if errors.As(err, &errorModel) && errorModel.Type == perr.ErrorCodeDependencyFailure {
+		return WithExitCode(err, ExitCodeModResolutionFailed)
+	}
+	// errors for dependencies which are not installed are untyped, but tell the user to install them
+	if strings.Contains(err.Error(), app_specific.AppName+" mod install") {
+		return WithExitCode(err, ExitCodeModResolutionFailed)
+	}
+	return WithExitCode(err, ExitCodeWorkspaceParseFailed)
+}
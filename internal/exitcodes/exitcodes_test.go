@@ -0,0 +1,138 @@
+package exitcodes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/workspace"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+type fromErrorTest struct {
+	err      error
+	expected int
+}
+
+var testCasesFromError = map[string]fromErrorTest{
+	"no exit code": {
+		err:      fmt.Errorf("failed"),
+		expected: constants.ExitCodeUnknownErrorPanic,
+	},
+	"database connection": {
+		err:      WithExitCode(fmt.Errorf("connection refused"), ExitCodeDatabaseConnectionFailed),
+		expected: ExitCodeDatabaseConnectionFailed,
+	},
+	"wrapped parse error": {
+		err:      fmt.Errorf("failed to load workspace: %w", WorkspaceLoadError(fmt.Errorf("Invalid expression"))),
+		expected: ExitCodeWorkspaceParseFailed,
+	},
+	"dependency failure": {
+		err:      WorkspaceLoadError(perr.BadRequestWithTypeAndMessage(perr.ErrorCodeDependencyFailure, "not all dependencies are installed")),
+		expected: ExitCodeModResolutionFailed,
+	},
+	"dependency not installed": {
+		err:      WorkspaceLoadError(fmt.Errorf("dependency mod 'github.com/turbot/steampipe-mod-aws-insights' is not installed - run 'powerpipe mod install'")),
+		expected: ExitCodeModResolutionFailed,
+	},
+	"no mod definition": {
+		err:      workspace.ErrorNoModDefinition,
+		expected: constants.ExitCodeNoModFile,
+	},
+	"no mod definition type": {
+		err:      localconstants.ErrorNoModDefinition{},
+		expected: constants.ExitCodeNoModFile,
+	},
+}
+
+func TestFromError(t *testing.T) {
+	for name, test := range testCasesFromError {
+		if actual := FromError(test.err, constants.ExitCodeUnknownErrorPanic); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %d, got %d", name, test.expected, actual)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	codes := make(map[int]bool)
+	for _, e := range Registry {
+		if codes[e.Code] {
+			t.Errorf("Test: 'registry' FAILED : exit code %d is registered more than once", e.Code)
+		}
+		codes[e.Code] = true
+		if e.Class == "" || e.Description == "" {
+			t.Errorf("Test: 'registry' FAILED : exit code %d has no class or description", e.Code)
+		}
+	}
+	if actual := Lookup(99).Class; actual != "unknown" {
+		t.Errorf("Test: 'unregistered' FAILED : expected class 'unknown', got '%s'", actual)
+	}
+}
+
+type errorReporterTest struct {
+	format   string
+	exitCode int
+	// the expected report, or nil if the output is expected as text
+	expected *Report
+}
+
+var testCasesErrorReporter = map[string]errorReporterTest{
+	"text": {
+		format:   ErrorFormatText,
+		exitCode: constants.ExitCodeInsufficientOrWrongInputs,
+	},
+	"json": {
+		format:   ErrorFormatJSON,
+		exitCode: ExitCodeWorkspaceParseFailed,
+		expected: &Report{
+			ExitCode: Lookup(ExitCodeWorkspaceParseFailed),
+			Errors:   []string{"failed to load workspace: Invalid expression"},
+			Warnings: []string{"no controls or benchmarks found in current workspace"},
+			Messages: []string{"Powerpipe crashed"},
+		},
+	},
+}
+
+func TestErrorReporter(t *testing.T) {
+	defer viper.Reset()
+	stderr := color.Error
+	defer func() { color.Error = stderr }()
+
+	for name, test := range testCasesErrorReporter {
+		viper.Set(localconstants.ArgErrorFormat, test.format)
+		var buf bytes.Buffer
+		color.Error = &buf
+		reporter := CaptureErrors()
+
+		fmt.Fprintf(color.Error, "%s: %v\n", color.RedString("Error"), "failed to load workspace: Invalid expression")
+		fmt.Fprintf(color.Error, "%s: %v\n", color.YellowString("Warning"), "no controls or benchmarks found in current workspace")
+		fmt.Fprintf(color.Error, "Powerpipe crashed\n")
+		if err := reporter.Flush(test.exitCode); err != nil {
+			t.Fatal(err)
+		}
+		if color.Error != &buf {
+			t.Errorf("Test: '%s' FAILED : expected color.Error to be restored", name)
+		}
+
+		if test.expected == nil {
+			if !bytes.HasPrefix(buf.Bytes(), []byte("Error: failed to load workspace")) {
+				t.Errorf("Test: '%s' FAILED : expected text output, got '%s'", name, buf.String())
+			}
+			continue
+		}
+		var actual Report
+		if err := json.Unmarshal(buf.Bytes(), &actual); err != nil {
+			t.Errorf("Test: '%s' FAILED : expected a json report, got '%s'", name, buf.String())
+			continue
+		}
+		if !reflect.DeepEqual(&actual, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %+v, got %+v", name, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package exitcodes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+const (
+	ErrorFormatText = "text"
+	ErrorFormatJSON = "json"
+)
+
+// ErrorFormats are the valid values of --error-format
+var ErrorFormats = []string{ErrorFormatText, ErrorFormatJSON}
+
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// Report is the json object written to stderr once a command completes, if --error-format is json
+type Report struct {
+	ExitCode
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+	// any other messages written to stderr, e.g. the location of a crash report
+	Messages []string `json:"messages,omitempty"`
+}
+
+// ErrorReporter replaces the writer which errors and warnings are displayed using (color.Error)
+//
+// if --error-format is json, the errors and warnings are captured rather than written, and are written to stderr
+// as a single Report once the command completes, so wrapper scripts can branch on the class of failure
+// otherwise they are written to stderr as usual
+type ErrorReporter struct {
+	stderr io.Writer
+	mut    sync.Mutex
+	report Report
+}
+
+// CaptureErrors replaces color.Error with an ErrorReporter, which Flush restores
+func CaptureErrors() *ErrorReporter {
+	r := &ErrorReporter{stderr: color.Error}
+	color.Error = r
+	return r
+}
+
+func jsonErrorFormat() bool {
+	return viper.GetString(localconstants.ArgErrorFormat) == ErrorFormatJSON
+}
+
+func (r *ErrorReporter) Write(p []byte) (int, error) {
+	if !jsonErrorFormat() {
+		return r.stderr.Write(p)
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	// each message is displayed using a single write, prefixed with 'Error: ' or 'Warning: '
+	message := strings.TrimSpace(ansiPattern.ReplaceAllString(string(p), ""))
+	switch {
+	case message == "":
+	case strings.HasPrefix(message, "Error: "):
+		r.report.Errors = append(r.report.Errors, strings.TrimPrefix(message, "Error: "))
+	case strings.HasPrefix(message, "Warning: "):
+		r.report.Warnings = append(r.report.Warnings, strings.TrimPrefix(message, "Warning: "))
+	default:
+		r.report.Messages = append(r.report.Messages, message)
+	}
+	return len(p), nil
+}
+
+// Flush restores color.Error and, if --error-format is json, writes the report of the command to stderr
+// - unless the command succeeded without errors or warnings
+func (r *ErrorReporter) Flush(exitCode int) error {
+	color.Error = r.stderr
+	if !jsonErrorFormat() {
+		return nil
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if exitCode == 0 && len(r.report.Errors)+len(r.report.Warnings) == 0 {
+		return nil
+	}
+	r.report.ExitCode = Lookup(exitCode)
+	data, err := json.Marshal(r.report)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(r.stderr, string(data))
+	return err
+}
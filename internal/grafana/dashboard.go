@@ -0,0 +1,133 @@
+package grafana
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Dashboard is the subset of the Grafana dashboard JSON model used to convert dashboards
+type Dashboard struct {
+	Uid         string     `json:"uid,omitempty"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Panels      []*Panel   `json:"panels"`
+	Templating  Templating `json:"templating"`
+	// the version of the dashboard schema
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+	// the datasources chosen when a dashboard exported for sharing is imported
+	Inputs []*ImportInput `json:"__inputs,omitempty"`
+}
+
+// ImportInput is a datasource chosen when a dashboard is imported into Grafana, referenced as ${<name>}
+type ImportInput struct {
+	Name     string `json:"name"`
+	Label    string `json:"label"`
+	Type     string `json:"type"`
+	PluginId string `json:"pluginId"`
+}
+
+type Templating struct {
+	List []*Variable `json:"list"`
+}
+
+// Variable is a dashboard variable (template variable)
+type Variable struct {
+	Name       string      `json:"name"`
+	Label      string      `json:"label,omitempty"`
+	Type       string      `json:"type"`
+	Query      any         `json:"query,omitempty"`
+	Datasource *Datasource `json:"datasource,omitempty"`
+	Definition string      `json:"definition,omitempty"`
+	Multi      bool        `json:"multi,omitempty"`
+	// when the options of a query variable are refreshed - 1 is when the dashboard loads
+	Refresh int `json:"refresh,omitempty"`
+}
+
+type Panel struct {
+	Id          int         `json:"id,omitempty"`
+	Type        string      `json:"type"`
+	Title       string      `json:"title,omitempty"`
+	Description string      `json:"description,omitempty"`
+	GridPos     GridPos     `json:"gridPos"`
+	Datasource  *Datasource `json:"datasource,omitempty"`
+	Targets     []*Target   `json:"targets,omitempty"`
+	Options     *Options    `json:"options,omitempty"`
+	// the content of a text panel, in dashboards using older schema versions
+	Content string `json:"content,omitempty"`
+	Mode    string `json:"mode,omitempty"`
+	// the panels of a collapsed row
+	Panels    []*Panel `json:"panels,omitempty"`
+	Collapsed bool     `json:"collapsed,omitempty"`
+}
+
+// GridPos is the position of a panel in the 24 column grid of the dashboard
+type GridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type Options struct {
+	// text panels
+	Content string `json:"content,omitempty"`
+	Mode    string `json:"mode,omitempty"`
+}
+
+// Target is a query of a panel
+type Target struct {
+	RefId      string      `json:"refId,omitempty"`
+	Datasource *Datasource `json:"datasource,omitempty"`
+	Format     string      `json:"format,omitempty"`
+	RawQuery   bool        `json:"rawQuery,omitempty"`
+	EditorMode string      `json:"editorMode,omitempty"`
+	// the SQL of the postgres, mysql and mssql datasources
+	RawSql string `json:"rawSql,omitempty"`
+	// the SQL of the SQLite datasource
+	QueryText    string `json:"queryText,omitempty"`
+	RawQueryText string `json:"rawQueryText,omitempty"`
+	// the query of non SQL datasources, e.g. prometheus
+	Expr string `json:"expr,omitempty"`
+}
+
+// Sql returns the SQL of the target, or an empty string if it is not a SQL query
+func (t *Target) Sql() string {
+	for _, sql := range []string{t.RawSql, t.QueryText, t.RawQueryText} {
+		if strings.TrimSpace(sql) != "" {
+			return sql
+		}
+	}
+	return ""
+}
+
+// Datasource is a reference to a datasource - older dashboards reference datasources by name
+type Datasource struct {
+	Type string `json:"type,omitempty"`
+	Uid  string `json:"uid,omitempty"`
+	Name string `json:"-"`
+}
+
+func (d *Datasource) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		d.Name = name
+		return nil
+	}
+	type datasource Datasource
+	return json.Unmarshal(data, (*datasource)(d))
+}
+
+// String returns the name, uid or type of the datasource
+func (d *Datasource) String() string {
+	switch {
+	case d == nil:
+		return ""
+	case d.Name != "":
+		return d.Name
+	case d.Uid != "":
+		return d.Uid
+	default:
+		return d.Type
+	}
+}
@@ -0,0 +1,383 @@
+package grafana
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/schema"
+)
+
+// Powerpipe dashboards are exported to Grafana dashboards by mapping each panel to the equivalent Grafana panel, querying
+// a SQL datasource (e.g. the PostgreSQL datasource, connected to the Powerpipe database):
+//   - card: stat
+//   - table: table
+//   - line and area charts: timeseries
+//   - bar and column charts: barchart
+//   - pie and donut charts: piechart
+//   - text: text
+//   - container: row, if it has a title (Grafana rows cannot be nested, so the panels of nested containers are added
+//     to the enclosing row)
+//
+// inputs are exported as dashboard variables - query params passed input values are replaced with the variable,
+// formatted as a quoted SQL string (${<input>:sqlstring}), and params passed static values with the value
+// panels of other types (e.g. graphs, flows and images) are not exported
+// the width of each panel is converted from the 12 column Powerpipe grid to the 24 column Grafana grid
+
+const (
+	// the datasource type used if none is given
+	DefaultDatasourceType = "grafana-postgresql-datasource"
+	// the name of the datasource chosen when the dashboard is imported, if no datasource is given
+	datasourceInputName = "DS_POWERPIPE"
+	// the version of the dashboard schema the exported dashboards conform to
+	exportSchemaVersion = 39
+	// grafana dashboard uids have a maximum length
+	maxUidLength = 40
+)
+
+var exportChartTypes = map[string]string{
+	"line":   "timeseries",
+	"area":   "timeseries",
+	"bar":    "barchart",
+	"column": "barchart",
+	"pie":    "piechart",
+	"donut":  "piechart",
+}
+
+// the height of each type of panel, in grid units
+var panelHeights = map[string]int{
+	"stat": 4,
+	"text": 4,
+}
+
+const defaultPanelHeight = 8
+
+// e.g. $1
+var placeholderRegex = regexp.MustCompile(`\$(\d+)`)
+
+// ExportOptions are the options for exporting a dashboard
+type ExportOptions struct {
+	// the uid of the datasource queried by the panels - if not set, the datasource is chosen when the dashboard is
+	// imported into Grafana
+	DatasourceUid string
+	// the type of the datasource, e.g. grafana-postgresql-datasource
+	DatasourceType string
+}
+
+// ExportResult is a Powerpipe dashboard converted to a Grafana dashboard
+type ExportResult struct {
+	Dashboard *Dashboard
+	// the number of panels exported
+	Exported int
+	// the panels (and queries) which could not be exported
+	Warnings []string
+}
+
+// Export converts the Powerpipe dashboard to a Grafana dashboard
+func Export(dashboard *modconfig.Dashboard, opts ExportOptions) *ExportResult {
+	if opts.DatasourceType == "" {
+		opts.DatasourceType = DefaultDatasourceType
+	}
+	datasource := &Datasource{Type: opts.DatasourceType, Uid: opts.DatasourceUid}
+
+	res := &Dashboard{
+		Uid:           dashboard.GetShortName(),
+		Title:         dashboard.GetTitle(),
+		Description:   dashboard.GetDescription(),
+		SchemaVersion: exportSchemaVersion,
+	}
+	if len(res.Uid) > maxUidLength {
+		res.Uid = res.Uid[:maxUidLength]
+	}
+	if res.Title == "" {
+		res.Title = dashboard.GetShortName()
+	}
+	if opts.DatasourceUid == "" {
+		datasource.Uid = "${" + datasourceInputName + "}"
+		res.Inputs = []*ImportInput{{Name: datasourceInputName, Label: "Powerpipe", Type: "datasource", PluginId: opts.DatasourceType}}
+	}
+
+	e := &exporter{datasource: datasource, dashboard: res}
+	for _, input := range dashboard.Inputs {
+		e.variable(input)
+	}
+	e.children(dashboard.GetChildren(), false)
+
+	return &ExportResult{Dashboard: res, Exported: e.exported, Warnings: e.warnings}
+}
+
+type exporter struct {
+	datasource *Datasource
+	dashboard  *Dashboard
+	exported   int
+	warnings   []string
+	// the layout position of the next panel
+	x, y       int
+	lineHeight int
+}
+
+func (e *exporter) children(children []modconfig.ModTreeItem, inRow bool) {
+	for _, child := range children {
+		switch item := child.(type) {
+		case *modconfig.DashboardContainer:
+			if !inRow && item.GetTitle() != "" {
+				e.row(item.GetTitle())
+				e.children(item.GetChildren(), true)
+				continue
+			}
+			e.newLine()
+			e.children(item.GetChildren(), inRow)
+		case *modconfig.DashboardCard:
+			e.queryPanel(item, "stat", item.Width)
+		case *modconfig.DashboardTable:
+			e.queryPanel(item, "table", item.Width)
+		case *modconfig.DashboardChart:
+			chartType := "column"
+			if item.Type != nil {
+				chartType = *item.Type
+			}
+			panelType, ok := exportChartTypes[chartType]
+			if !ok {
+				e.warn(item, fmt.Sprintf("charts of type '%s' are not supported", chartType))
+				continue
+			}
+			e.queryPanel(item, panelType, item.Width)
+		case *modconfig.DashboardText:
+			e.textPanel(item)
+		case *modconfig.DashboardInput:
+			// inputs are exported as dashboard variables
+		default:
+			e.warn(child, fmt.Sprintf("%s panels are not supported", child.BlockType()))
+		}
+	}
+}
+
+func (e *exporter) row(title string) {
+	e.newLine()
+	e.dashboard.Panels = append(e.dashboard.Panels, &Panel{
+		Id:      len(e.dashboard.Panels) + 1,
+		Type:    "row",
+		Title:   title,
+		GridPos: GridPos{H: 1, W: 24, X: 0, Y: e.y},
+		Panels:  []*Panel{},
+	})
+	e.y++
+}
+
+func (e *exporter) textPanel(text *modconfig.DashboardText) {
+	mode := "markdown"
+	if text.Type != nil && *text.Type == "html" {
+		mode = "html"
+	}
+	content := ""
+	if text.Value != nil {
+		content = *text.Value
+	}
+	e.add(&Panel{
+		Type:    "text",
+		Title:   text.GetTitle(),
+		Options: &Options{Content: content, Mode: mode},
+	}, text.Width)
+}
+
+func (e *exporter) queryPanel(item modconfig.QueryProvider, panelType string, width *int) {
+	sql, err := querySql(item)
+	if err != nil {
+		e.warn(item, err.Error())
+		return
+	}
+	e.add(&Panel{
+		Type:        panelType,
+		Title:       item.GetTitle(),
+		Description: item.GetDescription(),
+		Datasource:  e.datasource,
+		Targets: []*Target{{
+			RefId:      "A",
+			Datasource: e.datasource,
+			Format:     "table",
+			RawQuery:   true,
+			EditorMode: "code",
+			RawSql:     sql,
+		}},
+	}, width)
+}
+
+// add adds the panel at the next position of the layout, wrapping onto a new line if it does not fit
+func (e *exporter) add(panel *Panel, width *int) {
+	w := 24
+	if width != nil && *width > 0 && *width < 12 {
+		w = *width * 2
+	}
+	if e.x+w > 24 {
+		e.newLine()
+	}
+	h := defaultPanelHeight
+	if height, ok := panelHeights[panel.Type]; ok {
+		h = height
+	}
+	panel.Id = len(e.dashboard.Panels) + 1
+	panel.GridPos = GridPos{H: h, W: w, X: e.x, Y: e.y}
+	e.dashboard.Panels = append(e.dashboard.Panels, panel)
+	e.exported++
+
+	e.x += w
+	e.lineHeight = max(e.lineHeight, h)
+}
+
+func (e *exporter) newLine() {
+	e.y += e.lineHeight
+	e.x, e.lineHeight = 0, 0
+}
+
+// variable adds a dashboard variable for the input - inputs with a query are query variables, inputs with options
+// are custom variables, and other inputs text boxes
+func (e *exporter) variable(input *modconfig.DashboardInput) {
+	variable := &Variable{Name: input.GetShortName(), Label: input.GetTitle()}
+	if input.Label != nil {
+		variable.Label = *input.Label
+	}
+	if input.Type != nil && *input.Type == "multiselect" {
+		variable.Multi = true
+	}
+
+	switch {
+	case input.GetSQL() != nil || input.GetQuery() != nil:
+		sql, err := querySql(input)
+		if err != nil {
+			e.warn(input, err.Error())
+			return
+		}
+		// input queries return label and value columns - grafana reads the option text and value from the __text and
+		// __value columns
+		sql = fmt.Sprintf("select label as __text, value as __value from (\n%s\n) as options", sql)
+		variable.Type = "query"
+		variable.Datasource = e.datasource
+		variable.Query = sql
+		variable.Definition = sql
+		variable.Refresh = 1
+	case len(input.Options) > 0:
+		var options []string
+		for _, option := range input.Options {
+			options = append(options, option.Name)
+		}
+		variable.Type = "custom"
+		variable.Query = strings.Join(options, ",")
+	default:
+		variable.Type = "textbox"
+	}
+	e.dashboard.Templating.List = append(e.dashboard.Templating.List, variable)
+}
+
+func (e *exporter) warn(item modconfig.ModTreeItem, reason string) {
+	name := item.GetTitle()
+	if name == "" {
+		name = item.GetUnqualifiedName()
+	}
+	e.warnings = append(e.warnings, fmt.Sprintf("%s '%s' was not exported - %s", item.BlockType(), name, reason))
+}
+
+// querySql returns the SQL of the query provider, with any params replaced with their values - an error is returned
+// if the SQL has a param which cannot be converted
+func querySql(item modconfig.QueryProvider) (string, error) {
+	var sql *string
+	params := item.GetParams()
+	if sql = item.GetSQL(); sql == nil && item.GetQuery() != nil {
+		sql = item.GetQuery().GetSQL()
+		if len(params) == 0 {
+			params = item.GetQuery().GetParams()
+		}
+	}
+	if sql == nil || strings.TrimSpace(*sql) == "" {
+		return "", fmt.Errorf("it has no query")
+	}
+
+	values, err := paramValues(item, params)
+	if err != nil {
+		return "", err
+	}
+	var missing []string
+	res := placeholderRegex.ReplaceAllStringFunc(strings.TrimSpace(*sql), func(placeholder string) string {
+		idx, _ := strconv.Atoi(placeholder[1:])
+		if idx < 1 || idx > len(values) || values[idx-1] == "" {
+			missing = append(missing, placeholder)
+			return placeholder
+		}
+		return values[idx-1]
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("no value is passed to the query params %s", strings.Join(distinct(missing), ", "))
+	}
+	return res, nil
+}
+
+// paramValues returns the SQL of the value of each query param - either a variable reference, for params passed an
+// input value, or a literal
+// (values which cannot be converted are empty)
+func paramValues(item modconfig.QueryProvider, params []*modconfig.ParamDef) ([]string, error) {
+	args := item.GetArgs()
+	if args == nil {
+		args = &modconfig.QueryArgs{}
+	}
+
+	// the inputs passed to each named or positional arg
+	inputs := make(map[string]string)
+	argCount := len(args.ArgList)
+	for _, dep := range item.GetRuntimeDependencies() {
+		if dep.PropertyPath == nil {
+			continue
+		}
+		if dep.PropertyPath.ItemType != schema.BlockTypeInput {
+			return nil, fmt.Errorf("args referencing %s blocks are not supported", dep.PropertyPath.ItemType)
+		}
+		variable := fmt.Sprintf("${%s:sqlstring}", dep.PropertyPath.Name)
+		switch {
+		case dep.TargetPropertyName != nil:
+			inputs[*dep.TargetPropertyName] = variable
+		case dep.TargetPropertyIndex != nil:
+			inputs[strconv.Itoa(*dep.TargetPropertyIndex)] = variable
+			argCount = max(argCount, *dep.TargetPropertyIndex+1)
+		}
+	}
+
+	var values []string
+	if len(params) > 0 {
+		for _, param := range params {
+			value := inputs[param.ShortName]
+			if value == "" {
+				if arg, ok, err := args.GetNamedArg(param.ShortName); err == nil && ok {
+					value = literal(arg)
+				} else if def, err := param.GetDefault(); err == nil && param.Default != nil {
+					value = literal(def)
+				}
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+	for i := 0; i < argCount; i++ {
+		value := inputs[strconv.Itoa(i)]
+		if value == "" {
+			if arg, ok, err := args.GetPositionalArg(i); err == nil && ok {
+				value = literal(arg)
+			}
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// literal converts an arg value to a SQL literal - lists and objects are not converted
+func literal(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case float64, int, bool:
+		return fmt.Sprintf("%v", v)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,193 @@
+package grafana
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/schema"
+)
+
+type exportTest struct {
+	children func(mod *modconfig.Mod) []modconfig.ModTreeItem
+	// each expected panel, in the form '<type> <x>,<y> <width>x<height>: <sql>'
+	expected       []string
+	expectWarnings int
+}
+
+var testCasesExport = map[string]exportTest{
+	"layout": {
+		children: func(mod *modconfig.Mod) []modconfig.ModTreeItem {
+			return []modconfig.ModTreeItem{
+				testCard(mod, "a", 3, "select 1"),
+				testCard(mod, "b", 6, "select 2"),
+				testChart(mod, "c", 6, "pie", "select 'a', 1"),
+				testTable(mod, "d", 0, "select 3"),
+			}
+		},
+		expected: []string{
+			"stat 0,0 6x4: select 1",
+			"stat 6,0 12x4: select 2",
+			"piechart 0,4 12x8: select 'a', 1",
+			"table 0,12 24x8: select 3",
+		},
+	},
+	"containers": {
+		children: func(mod *modconfig.Mod) []modconfig.ModTreeItem {
+			return []modconfig.ModTreeItem{
+				testCard(mod, "a", 6, "select 1"),
+				testContainer(mod, "details", "Details",
+					testChart(mod, "b", 6, "line", "select now(), 1"),
+					testContainer(mod, "nested", "Nested", testTable(mod, "c", 6, "select 2")),
+				),
+				testContainer(mod, "untitled", "", testTable(mod, "d", 6, "select 3")),
+			}
+		},
+		expected: []string{
+			"stat 0,0 12x4: select 1",
+			"row 0,4 24x1: ",
+			"timeseries 0,5 12x8: select now(), 1",
+			"table 0,13 12x8: select 2",
+			"table 0,21 12x8: select 3",
+		},
+	},
+	"params": {
+		children: func(mod *modconfig.Mod) []modconfig.ModTreeItem {
+			query := modconfig.NewQuery(&hcl.Block{Type: schema.BlockTypeQuery}, mod, "by_region").(*modconfig.Query)
+			query.SQL = ptr("select count(*) from orders where region = $1 and qty > $2")
+			minQty := &modconfig.ParamDef{ShortName: "min_qty"}
+			if err := minQty.SetDefault(2); err != nil {
+				panic(err)
+			}
+			query.Params = []*modconfig.ParamDef{{ShortName: "region"}, minQty}
+
+			card := testCard(mod, "a", 0, "")
+			card.SQL = nil
+			card.Query = query
+			card.AddRuntimeDependencies([]*modconfig.RuntimeDependency{{
+				PropertyPath:       &modconfig.ParsedPropertyPath{ItemType: schema.BlockTypeInput, Name: "region"},
+				TargetPropertyName: ptr("region"),
+				ParentPropertyName: "args",
+			}})
+
+			table := testTable(mod, "b", 0, "select * from orders where name = $1")
+			table.Args = modconfig.NewQueryArgs()
+			if err := table.Args.AddPositionalArgVal("it's"); err != nil {
+				panic(err)
+			}
+			return []modconfig.ModTreeItem{card, table}
+		},
+		expected: []string{
+			"stat 0,0 24x4: select count(*) from orders where region = ${region:sqlstring} and qty > 2",
+			"table 0,4 24x8: select * from orders where name = 'it''s'",
+		},
+	},
+	"not exported": {
+		children: func(mod *modconfig.Mod) []modconfig.ModTreeItem {
+			graph := modconfig.NewDashboardGraph(&hcl.Block{Type: schema.BlockTypeGraph}, mod, "g").(*modconfig.DashboardGraph)
+			return []modconfig.ModTreeItem{
+				graph,
+				testChart(mod, "a", 6, "heatmap", "select 1"),
+				testCard(mod, "b", 6, "select $1"),
+				testCard(mod, "c", 6, ""),
+				testTable(mod, "d", 6, "select 1"),
+			}
+		},
+		expected:       []string{"table 0,0 12x8: select 1"},
+		expectWarnings: 4,
+	},
+}
+
+func TestExport(t *testing.T) {
+	for name, test := range testCasesExport {
+		mod := modconfig.NewMod("test", t.TempDir(), hcl.Range{})
+		dashboard := modconfig.NewDashboard(&hcl.Block{Type: schema.BlockTypeDashboard, Labels: []string{"d"}}, mod, "d").(*modconfig.Dashboard)
+		dashboard.SetChildren(test.children(mod))
+
+		res := Export(dashboard, ExportOptions{})
+		var actual []string
+		for _, panel := range res.Dashboard.Panels {
+			sql := ""
+			if len(panel.Targets) > 0 {
+				sql = panel.Targets[0].RawSql
+			}
+			actual = append(actual, fmt.Sprintf("%s %d,%d %dx%d: %s", panel.Type, panel.GridPos.X, panel.GridPos.Y, panel.GridPos.W, panel.GridPos.H, sql))
+		}
+		if strings.Join(actual, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("Test: '%s' FAILED : expected\n%s\ngot\n%s", name, strings.Join(test.expected, "\n"), strings.Join(actual, "\n"))
+		}
+		if len(res.Warnings) != test.expectWarnings {
+			t.Errorf("Test: '%s' FAILED : expected %d warnings, got %v", name, test.expectWarnings, res.Warnings)
+		}
+	}
+}
+
+func TestExportInputs(t *testing.T) {
+	mod := modconfig.NewMod("test", t.TempDir(), hcl.Range{})
+	dashboard := modconfig.NewDashboard(&hcl.Block{Type: schema.BlockTypeDashboard, Labels: []string{"d"}}, mod, "d").(*modconfig.Dashboard)
+
+	region := modconfig.NewDashboardInput(&hcl.Block{Type: schema.BlockTypeInput}, mod, "region").(*modconfig.DashboardInput)
+	region.SQL = ptr("select region as label, region as value from regions")
+	kind := modconfig.NewDashboardInput(&hcl.Block{Type: schema.BlockTypeInput}, mod, "kind").(*modconfig.DashboardInput)
+	kind.Type = ptr("multiselect")
+	kind.Options = []*modconfig.DashboardInputOption{{Name: "a"}, {Name: "b"}}
+	search := modconfig.NewDashboardInput(&hcl.Block{Type: schema.BlockTypeInput}, mod, "search").(*modconfig.DashboardInput)
+	dashboard.Inputs = []*modconfig.DashboardInput{region, kind, search}
+
+	res := Export(dashboard, ExportOptions{DatasourceUid: "steampipe"})
+	var actual []string
+	for _, v := range res.Dashboard.Templating.List {
+		actual = append(actual, fmt.Sprintf("%s %s %t %v", v.Name, v.Type, v.Multi, v.Query))
+	}
+	expected := []string{
+		"region query false select label as __text, value as __value from (\nselect region as label, region as value from regions\n) as options",
+		"kind custom true a,b",
+		"search textbox false <nil>",
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Test: 'inputs' FAILED : expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+	if res.Dashboard.Inputs != nil || res.Dashboard.Templating.List[0].Datasource.Uid != "steampipe" {
+		t.Errorf("Test: 'inputs' FAILED : expected the datasource 'steampipe' to be used")
+	}
+}
+
+func testCard(mod *modconfig.Mod, name string, width int, sql string) *modconfig.DashboardCard {
+	card := modconfig.NewDashboardCard(&hcl.Block{Type: schema.BlockTypeCard}, mod, name).(*modconfig.DashboardCard)
+	card.Width, card.SQL = widthOf(width), ptr(sql)
+	return card
+}
+
+func testTable(mod *modconfig.Mod, name string, width int, sql string) *modconfig.DashboardTable {
+	table := modconfig.NewDashboardTable(&hcl.Block{Type: schema.BlockTypeTable}, mod, name).(*modconfig.DashboardTable)
+	table.Width, table.SQL = widthOf(width), ptr(sql)
+	return table
+}
+
+func testChart(mod *modconfig.Mod, name string, width int, chartType, sql string) *modconfig.DashboardChart {
+	chart := modconfig.NewDashboardChart(&hcl.Block{Type: schema.BlockTypeChart}, mod, name).(*modconfig.DashboardChart)
+	chart.Width, chart.Type, chart.SQL = widthOf(width), ptr(chartType), ptr(sql)
+	return chart
+}
+
+func testContainer(mod *modconfig.Mod, name, title string, children ...modconfig.ModTreeItem) *modconfig.DashboardContainer {
+	container := modconfig.NewDashboardContainer(&hcl.Block{Type: schema.BlockTypeContainer}, mod, name).(*modconfig.DashboardContainer)
+	if title != "" {
+		container.Title = ptr(title)
+	}
+	container.SetChildren(children)
+	return container
+}
+
+func widthOf(width int) *int {
+	if width == 0 {
+		return nil
+	}
+	return &width
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,357 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Grafana dashboards backed by SQL datasources are converted to Powerpipe dashboards by mapping each panel to the
+// equivalent Powerpipe panel:
+//   - table: table
+//   - stat, gauge: card
+//   - timeseries, graph: line chart
+//   - barchart, bargauge: bar chart
+//   - piechart: pie chart
+//   - text: text
+//   - row: container, holding the panels of the row
+//
+// the SQL of the first query of each panel is used - panels of other types or datasources, queries using Grafana
+// macros or dashboard variables, and additional queries are marked with TODO comments to be completed by hand
+// the width of each panel is converted from the 24 column Grafana grid to the 12 column Powerpipe grid
+
+var chartTypes = map[string]string{
+	"timeseries": "line",
+	"graph":      "line",
+	"barchart":   "bar",
+	"bargauge":   "bar",
+	"piechart":   "pie",
+}
+
+var (
+	// e.g. $__timeFilter(time)
+	macroRegex = regexp.MustCompile(`\$__\w+`)
+	// e.g. $region, ${region} or [[region]]
+	variableRegex = regexp.MustCompile(`\$\{?(\w+)|\[\[(\w+)`)
+	// characters which are not valid in a resource name
+	invalidNameCharsRegex = regexp.MustCompile(`[^a-z0-9_]+`)
+)
+
+// ImportResult is a Grafana dashboard converted to a Powerpipe dashboard
+type ImportResult struct {
+	// the name of the dashboard
+	Name string
+	// the dashboard HCL
+	Hcl []byte
+	// the number of panels converted, and the number which could not be fully converted (and are marked with TODO)
+	Converted int
+	Todo      int
+}
+
+// Import converts the Grafana dashboard JSON to a Powerpipe dashboard with the given name - if no name is given,
+// the name is derived from the title of the dashboard
+func Import(data []byte, name string) (*ImportResult, error) {
+	var dashboard Dashboard
+	if err := json.Unmarshal(data, &dashboard); err != nil {
+		return nil, fmt.Errorf("invalid Grafana dashboard: %s", err.Error())
+	}
+	// dashboards exported for sharing wrap the dashboard in a 'dashboard' property
+	if dashboard.Title == "" && dashboard.Panels == nil {
+		var wrapped struct {
+			Dashboard *Dashboard `json:"dashboard"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err == nil && wrapped.Dashboard != nil {
+			dashboard = *wrapped.Dashboard
+		}
+	}
+	if dashboard.Title == "" && dashboard.Panels == nil {
+		return nil, fmt.Errorf("invalid Grafana dashboard: no title or panels")
+	}
+	if name == "" {
+		name = ResourceName(dashboard.Title)
+	} else if name != ResourceName(name) {
+		return nil, fmt.Errorf("invalid dashboard name '%s' - must contain only lower case letters, numbers and underscores", name)
+	}
+
+	i := &importer{variables: make(map[string]struct{})}
+	for _, v := range dashboard.Templating.List {
+		i.variables[v.Name] = struct{}{}
+	}
+	hcl := i.dashboardHcl(&dashboard, name)
+
+	formatted := hclwrite.Format([]byte(hcl))
+	return &ImportResult{Name: name, Hcl: formatted, Converted: i.converted, Todo: i.todo}, nil
+}
+
+type importer struct {
+	variables map[string]struct{}
+	converted int
+	todo      int
+	b         strings.Builder
+	indent    int
+}
+
+func (i *importer) dashboardHcl(dashboard *Dashboard, name string) string {
+	i.line("dashboard %q {", name)
+	i.indent++
+	i.attribute("title", dashboard.Title)
+	if dashboard.Description != "" {
+		i.attribute("description", dashboard.Description)
+	}
+	if len(dashboard.Templating.List) > 0 {
+		var names []string
+		for _, v := range dashboard.Templating.List {
+			names = append(names, v.Name)
+		}
+		i.line("")
+		i.todoComment("the dashboard variables %s must be added as inputs, and passed as args to the queries which use them", strings.Join(names, ", "))
+	}
+
+	for _, panel := range groupRows(dashboard.Panels) {
+		i.line("")
+		i.panel(panel)
+	}
+	i.indent--
+	i.line("}")
+	return i.b.String()
+}
+
+// groupRows returns the top level panels in layout order, with the panels following each row moved into the row
+// (the panels of collapsed rows are already within the row)
+func groupRows(panels []*Panel) []*Panel {
+	sorted := sortPanels(panels)
+	var res []*Panel
+	var row *Panel
+	for _, panel := range sorted {
+		switch {
+		case panel.Type == "row":
+			row = &Panel{Type: "row", Title: panel.Title, Panels: sortPanels(panel.Panels)}
+			res = append(res, row)
+		case row != nil:
+			row.Panels = append(row.Panels, panel)
+		default:
+			res = append(res, panel)
+		}
+	}
+	return res
+}
+
+// sortPanels returns the panels in layout order - top to bottom, then left to right
+func sortPanels(panels []*Panel) []*Panel {
+	res := append([]*Panel(nil), panels...)
+	sort.SliceStable(res, func(a, b int) bool {
+		if res[a].GridPos.Y != res[b].GridPos.Y {
+			return res[a].GridPos.Y < res[b].GridPos.Y
+		}
+		return res[a].GridPos.X < res[b].GridPos.X
+	})
+	return res
+}
+
+func (i *importer) panel(panel *Panel) {
+	switch panel.Type {
+	case "row":
+		i.line("container {")
+		i.indent++
+		if panel.Title != "" {
+			i.attribute("title", panel.Title)
+		}
+		for _, child := range panel.Panels {
+			i.line("")
+			i.panel(child)
+		}
+		i.indent--
+		i.line("}")
+	case "text":
+		i.textPanel(panel)
+	case "table":
+		i.queryPanel(panel, "table", "")
+	case "stat", "gauge", "singlestat":
+		i.queryPanel(panel, "card", "")
+	default:
+		if chartType, ok := chartTypes[panel.Type]; ok {
+			i.queryPanel(panel, "chart", chartType)
+			return
+		}
+		i.unsupportedPanel(panel, fmt.Sprintf("panels of type '%s' are not supported", panel.Type))
+	}
+}
+
+func (i *importer) textPanel(panel *Panel) {
+	content, mode := panel.Content, panel.Mode
+	if panel.Options != nil {
+		if panel.Options.Content != "" {
+			content = panel.Options.Content
+		}
+		if panel.Options.Mode != "" {
+			mode = panel.Options.Mode
+		}
+	}
+	i.converted++
+	i.line("text {")
+	i.indent++
+	i.width(panel)
+	if mode == "html" {
+		i.attribute("type", "html")
+	}
+	if panel.Title != "" && mode != "html" {
+		content = "## " + panel.Title + "\n\n" + content
+	}
+	i.attribute("value", content)
+	i.indent--
+	i.line("}")
+}
+
+func (i *importer) queryPanel(panel *Panel, blockType, chartType string) {
+	var sql string
+	if len(panel.Targets) > 0 {
+		sql = panel.Targets[0].Sql()
+	}
+	if sql == "" {
+		reason := "the panel has no SQL query"
+		if len(panel.Targets) > 0 && panel.Targets[0].Expr != "" {
+			reason = fmt.Sprintf("the panel queries the non SQL datasource '%s'", datasourceName(panel))
+		}
+		i.unsupportedPanel(panel, reason)
+		return
+	}
+
+	var todos []string
+	if len(panel.Targets) > 1 {
+		todos = append(todos, fmt.Sprintf("only the first of the %d queries of the panel has been converted", len(panel.Targets)))
+	}
+	if macros := distinct(macroRegex.FindAllString(sql, -1)); len(macros) > 0 {
+		todos = append(todos, fmt.Sprintf("replace the Grafana macros %s", strings.Join(macros, ", ")))
+	}
+	if variables := i.variablesOf(sql); len(variables) > 0 {
+		todos = append(todos, fmt.Sprintf("replace the dashboard variables %s with params", strings.Join(variables, ", ")))
+	}
+
+	i.converted++
+	if len(todos) > 0 {
+		i.todo++
+		for _, todo := range todos {
+			i.todoComment("%s", todo)
+		}
+	}
+	i.line("%s {", blockType)
+	i.indent++
+	if panel.Title != "" {
+		i.attribute("title", panel.Title)
+	}
+	if chartType != "" {
+		i.attribute("type", chartType)
+	}
+	i.width(panel)
+	i.heredoc("sql", sql)
+	i.indent--
+	i.line("}")
+}
+
+func (i *importer) unsupportedPanel(panel *Panel, reason string) {
+	i.todo++
+	title := panel.Title
+	if title == "" {
+		title = fmt.Sprintf("%d", panel.Id)
+	}
+	i.todoComment("the Grafana panel '%s' could not be converted - %s", title, reason)
+	for _, target := range panel.Targets {
+		if query := target.Sql(); query != "" || target.Expr != "" {
+			if query == "" {
+				query = target.Expr
+			}
+			for _, line := range strings.Split(strings.TrimSpace(query), "\n") {
+				i.line("#   %s", line)
+			}
+		}
+	}
+}
+
+// variablesOf returns the dashboard variables used by the SQL
+func (i *importer) variablesOf(sql string) []string {
+	var res []string
+	for _, match := range variableRegex.FindAllStringSubmatch(sql, -1) {
+		name := match[1] + match[2]
+		if _, ok := i.variables[name]; ok {
+			res = append(res, "$"+name)
+		}
+	}
+	return distinct(res)
+}
+
+func (i *importer) width(panel *Panel) {
+	// the Grafana grid has 24 columns - a full width panel needs no width
+	if panel.GridPos.W <= 0 || panel.GridPos.W >= 24 {
+		return
+	}
+	width := (panel.GridPos.W + 1) / 2
+	i.line("width = %d", width)
+}
+
+func (i *importer) attribute(name, value string) {
+	i.line("%s = %s", name, hclwrite.TokensForValue(cty.StringVal(value)).Bytes())
+}
+
+// heredoc writes a heredoc attribute, escaping template sequences in the value (e.g. ${var})
+func (i *importer) heredoc(name, value string) {
+	value = strings.NewReplacer("${", "$${", "%{", "%%{").Replace(strings.TrimSpace(value))
+	i.line("%s = <<-EOQ", name)
+	i.indent++
+	for _, line := range strings.Split(value, "\n") {
+		i.line("%s", strings.TrimRight(line, " \t\r"))
+	}
+	i.indent--
+	i.line("EOQ")
+}
+
+func (i *importer) todoComment(format string, args ...any) {
+	i.line("# TODO: "+format, args...)
+}
+
+func (i *importer) line(format string, args ...any) {
+	text := fmt.Sprintf(format, args...)
+	if text != "" {
+		i.b.WriteString(strings.Repeat("  ", i.indent))
+		i.b.WriteString(text)
+	}
+	i.b.WriteString("\n")
+}
+
+func datasourceName(panel *Panel) string {
+	if name := panel.Datasource.String(); name != "" {
+		return name
+	}
+	if len(panel.Targets) > 0 {
+		return panel.Targets[0].Datasource.String()
+	}
+	return ""
+}
+
+// ResourceName converts a title to a resource name, e.g. 'AWS Overview' to 'aws_overview'
+func ResourceName(title string) string {
+	name := strings.Trim(invalidNameCharsRegex.ReplaceAllString(strings.ToLower(title), "_"), "_")
+	if name == "" {
+		return "grafana"
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "grafana_" + name
+	}
+	return name
+}
+
+func distinct(values []string) []string {
+	var res []string
+	seen := make(map[string]struct{})
+	for _, v := range values {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			res = append(res, v)
+		}
+	}
+	return res
+}
@@ -0,0 +1,106 @@
+package grafana
+
+import (
+	"strings"
+	"testing"
+)
+
+type importTest struct {
+	dashboard       string
+	name            string
+	expectName      string
+	expectContains  []string
+	expectConverted int
+	expectTodo      int
+	expectError     bool
+}
+
+var testCasesImport = map[string]importTest{
+	"table": {
+		dashboard:       `{"title": "AWS Overview", "panels": [{"type": "table", "title": "Buckets", "gridPos": {"w": 12}, "targets": [{"rawSql": "select name from aws_s3_bucket"}]}]}`,
+		expectName:      "aws_overview",
+		expectContains:  []string{`dashboard "aws_overview" {`, "table {", `title = "Buckets"`, "width = 6", "select name from aws_s3_bucket"},
+		expectConverted: 1,
+	},
+	"stat and charts": {
+		dashboard: `{"title": "Charts", "panels": [
+			{"type": "stat", "targets": [{"rawSql": "select 1"}]},
+			{"type": "piechart", "targets": [{"queryText": "select 'a', 1"}]},
+			{"type": "barchart", "targets": [{"rawSql": "select 'a', 1"}]}]}`,
+		expectContains:  []string{"card {", `type = "pie"`, `type = "bar"`, "select 'a', 1"},
+		expectConverted: 3,
+	},
+	"rows": {
+		dashboard: `{"title": "Rows", "panels": [
+			{"type": "table", "gridPos": {"y": 10}, "targets": [{"rawSql": "select 'in row'"}]},
+			{"type": "row", "title": "Details", "gridPos": {"y": 5}},
+			{"type": "table", "gridPos": {"y": 0}, "targets": [{"rawSql": "select 'before row'"}]},
+			{"type": "row", "title": "Collapsed", "gridPos": {"y": 20}, "collapsed": true, "panels": [{"type": "table", "targets": [{"rawSql": "select 'collapsed'"}]}]}]}`,
+		expectContains:  []string{"select 'before row'", `title = "Details"`, "select 'in row'", `title = "Collapsed"`, "select 'collapsed'"},
+		expectConverted: 3,
+	},
+	"text": {
+		dashboard:       `{"title": "Text", "panels": [{"type": "text", "title": "About", "options": {"mode": "markdown", "content": "Some *notes*"}}]}`,
+		expectContains:  []string{"text {", `value = "## About\n\nSome *notes*"`},
+		expectConverted: 1,
+	},
+	"macros and variables": {
+		dashboard:       `{"title": "Macros", "templating": {"list": [{"name": "region"}]}, "panels": [{"type": "timeseries", "targets": [{"rawSql": "select $__time(t), v from m where $__timeFilter(t) and r = '${region}'"}]}]}`,
+		expectContains:  []string{"# TODO: replace the Grafana macros $__time, $__timeFilter", "# TODO: replace the dashboard variables $region with params", "r = '$${region}'"},
+		expectConverted: 1,
+		expectTodo:      1,
+	},
+	"unsupported": {
+		dashboard: `{"title": "Unsupported", "panels": [
+			{"type": "heatmap", "title": "Latency"},
+			{"type": "timeseries", "title": "CPU", "datasource": {"type": "prometheus", "uid": "prom"}, "targets": [{"expr": "rate(cpu[5m])"}]}]}`,
+		expectContains: []string{"# TODO: the Grafana panel 'Latency' could not be converted - panels of type 'heatmap' are not supported", "the non SQL datasource 'prom'", "#   rate(cpu[5m])"},
+		expectTodo:     2,
+	},
+	"wrapped": {
+		dashboard:       `{"meta": {}, "dashboard": {"title": "Wrapped", "panels": [{"type": "table", "targets": [{"rawSql": "select 1"}]}]}}`,
+		expectName:      "wrapped",
+		expectConverted: 1,
+	},
+	"named": {
+		dashboard:  `{"title": "Named", "panels": []}`,
+		name:       "my_dashboard",
+		expectName: "my_dashboard",
+	},
+	"invalid name": {
+		dashboard:   `{"title": "Named", "panels": []}`,
+		name:        "My Dashboard",
+		expectError: true,
+	},
+	"not a dashboard": {
+		dashboard:   `{"foo": "bar"}`,
+		expectError: true,
+	},
+}
+
+func TestImport(t *testing.T) {
+	for name, test := range testCasesImport {
+		res, err := Import([]byte(test.dashboard), test.name)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error, got none", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %v", name, err)
+			continue
+		}
+		if test.expectName != "" && res.Name != test.expectName {
+			t.Errorf("Test: '%s' FAILED : expected name %s, got %s", name, test.expectName, res.Name)
+		}
+		for _, expected := range test.expectContains {
+			if !strings.Contains(string(res.Hcl), expected) {
+				t.Errorf("Test: '%s' FAILED : expected HCL to contain %s, got:\n%s", name, expected, res.Hcl)
+			}
+		}
+		if res.Converted != test.expectConverted || res.Todo != test.expectTodo {
+			t.Errorf("Test: '%s' FAILED : expected %d converted and %d todo, got %d and %d", name, test.expectConverted, test.expectTodo, res.Converted, res.Todo)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"golang.org/x/text/language"
+)
+
+// the language of the text in CLI summaries and the chrome of report exports (headings, statuses, footers) may be
+// set using the --language flag (or the POWERPIPE_LANGUAGE env var), e.g.
+//
+//	powerpipe benchmark run cis_v300 --language de --export report.html
+//
+// the value is either a built-in language (a language tag such as 'de' or 'fr-CA' uses its base language),
+// or the path of a json file mapping message keys to translations - keys which are not translated use English
+//
+// the titles, descriptions and results of mod resources are not translated
+const DefaultLanguage = "en"
+
+//go:embed catalogs/*.json
+var catalogFS embed.FS
+
+// Catalog is a set of translated messages, keyed by message key
+type Catalog struct {
+	// the language tag of the catalog, e.g. 'de'
+	Language string
+	messages map[string]string
+}
+
+var (
+	// the built-in catalogs, keyed by language
+	catalogs = loadCatalogs()
+
+	current     = catalogs[DefaultLanguage]
+	currentLock sync.RWMutex
+)
+
+func loadCatalogs() map[string]*Catalog {
+	entries, err := catalogFS.ReadDir("catalogs")
+	if err != nil {
+		panic(err)
+	}
+	res := make(map[string]*Catalog, len(entries))
+	for _, e := range entries {
+		data, err := catalogFS.ReadFile(path.Join("catalogs", e.Name()))
+		if err != nil {
+			panic(err)
+		}
+		lang := strings.TrimSuffix(e.Name(), ".json")
+		c, err := parseCatalog(lang, data)
+		if err != nil {
+			panic(fmt.Sprintf("invalid built-in catalog %s: %s", e.Name(), err.Error()))
+		}
+		res[lang] = c
+	}
+	return res
+}
+
+func parseCatalog(lang string, data []byte) (*Catalog, error) {
+	var messages map[string]string
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, err
+	}
+	return &Catalog{Language: lang, messages: messages}, nil
+}
+
+// Languages returns the built-in languages
+func Languages() []string {
+	res := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		res = append(res, lang)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// NewCatalog returns the catalog for the given language, which is either a built-in language or the path
+// of a json catalog file - an empty language returns the English catalog
+func NewCatalog(lang string) (*Catalog, error) {
+	if lang == "" {
+		return catalogs[DefaultLanguage], nil
+	}
+	if strings.HasSuffix(lang, ".json") {
+		data, err := os.ReadFile(lang)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s file '%s': %s", localconstants.ArgLanguage, lang, err.Error())
+		}
+		c, err := parseCatalog(DefaultLanguage, data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s file '%s' - must be a json object mapping message keys to translations: %s", localconstants.ArgLanguage, lang, err.Error())
+		}
+		// a file named for its language, e.g. 'pt.json', sets the language of the documents it is used in
+		if tag, err := language.Parse(strings.TrimSuffix(filepath.Base(lang), ".json")); err == nil {
+			c.Language = tag.String()
+		}
+		return c, nil
+	}
+
+	invalidErr := fmt.Errorf("invalid %s '%s' - must be one of: %s, or the path of a json catalog file", localconstants.ArgLanguage, lang, strings.Join(Languages(), ", "))
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return nil, invalidErr
+	}
+	base, _ := tag.Base()
+	c, ok := catalogs[base.String()]
+	if !ok {
+		return nil, invalidErr
+	}
+	return c, nil
+}
+
+// Init validates the language set in viper, and sets its catalog as the current catalog
+func Init() error {
+	c, err := NewCatalog(viper.GetString(localconstants.ArgLanguage))
+	if err != nil {
+		return err
+	}
+	currentLock.Lock()
+	defer currentLock.Unlock()
+	current = c
+	return nil
+}
+
+// Current returns the current catalog
+func Current() *Catalog {
+	currentLock.RLock()
+	defer currentLock.RUnlock()
+	return current
+}
+
+// T returns the message with the given key in the current language
+// if args are given, the message is used as a format string
+func T(key string, args ...any) string {
+	return Current().T(key, args...)
+}
+
+// Status returns the name of a control status, e.g. 'alarm', in the current language
+// unknown statuses are returned as is
+func Status(status string) string {
+	if message, ok := Current().lookup("status." + status); ok {
+		return message
+	}
+	return status
+}
+
+// Severity returns the name of a control severity, e.g. 'critical', in the current language
+// unknown severities (mods may use their own) are returned as is
+func Severity(severity string) string {
+	if message, ok := Current().lookup("severity." + severity); ok {
+		return message
+	}
+	return severity
+}
+
+// T returns the message with the given key - unknown keys are returned as is
+func (c *Catalog) T(key string, args ...any) string {
+	message, ok := c.lookup(key)
+	if !ok {
+		message = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// lookup returns the message with the given key - keys which are not in the catalog use the English message
+func (c *Catalog) lookup(key string) (string, bool) {
+	if message, ok := c.messages[key]; ok {
+		return message, true
+	}
+	message, ok := catalogs[DefaultLanguage].messages[key]
+	return message, ok
+}
@@ -0,0 +1,116 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type newCatalogTest struct {
+	language         string
+	key              string
+	args             []any
+	expectedLanguage string
+	expected         string
+	expectError      bool
+}
+
+func TestNewCatalog(t *testing.T) {
+	dir := t.TempDir()
+	customPath := filepath.Join(dir, "pt.json")
+	if err := os.WriteFile(customPath, []byte(`{"report.summary": "Resumo"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	invalidPath := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalidPath, []byte(`["Resumo"]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]newCatalogTest{
+		"default": {
+			key:              "report.summary",
+			expectedLanguage: "en",
+			expected:         "Summary",
+		},
+		"built in": {
+			language:         "de",
+			key:              "report.summary",
+			expectedLanguage: "de",
+			expected:         "Zusammenfassung",
+		},
+		"regional tag": {
+			language:         "fr-CA",
+			key:              "status.alarm",
+			expectedLanguage: "fr",
+			expected:         "Alarme",
+		},
+		"format args": {
+			language:         "es",
+			key:              "report.generated_from",
+			args:             []any{"2024-01-02", 3},
+			expectedLanguage: "es",
+			expected:         "Generado el 2024-01-02 a partir de 3 entornos",
+		},
+		"unknown key": {
+			language:         "de",
+			key:              "report.unknown",
+			expectedLanguage: "de",
+			expected:         "report.unknown",
+		},
+		"custom file": {
+			language:         customPath,
+			key:              "report.summary",
+			expectedLanguage: "pt",
+			expected:         "Resumo",
+		},
+		"custom file fallback": {
+			language:         customPath,
+			key:              "report.contents",
+			expectedLanguage: "pt",
+			expected:         "Contents",
+		},
+		"unsupported language": {
+			language:    "xx",
+			expectError: true,
+		},
+		"missing file": {
+			language:    filepath.Join(dir, "missing.json"),
+			expectError: true,
+		},
+		"invalid file": {
+			language:    invalidPath,
+			expectError: true,
+		},
+	}
+
+	for name, test := range testCases {
+		c, err := NewCatalog(test.language)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error, got none", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %v", name, err)
+			continue
+		}
+		if c.Language != test.expectedLanguage {
+			t.Errorf("Test: '%s' FAILED : expected language %s, got %s", name, test.expectedLanguage, c.Language)
+		}
+		if actual := c.T(test.key, test.args...); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, actual)
+		}
+	}
+}
+
+// every built-in catalog must translate every English message
+func TestCatalogsComplete(t *testing.T) {
+	for lang, c := range catalogs {
+		for key := range catalogs[DefaultLanguage].messages {
+			if _, ok := c.messages[key]; !ok {
+				t.Errorf("Test: '%s' FAILED : expected a translation of %s, got none", lang, key)
+			}
+		}
+	}
+}
@@ -16,11 +16,23 @@ import (
 	"github.com/turbot/pipe-fittings/statushooks"
 	"github.com/turbot/pipe-fittings/utils"
 	"github.com/turbot/pipe-fittings/workspace"
+	"github.com/turbot/powerpipe/internal/assemble"
+	"github.com/turbot/powerpipe/internal/batch"
 	"github.com/turbot/powerpipe/internal/cmdconfig"
+	"github.com/turbot/powerpipe/internal/conditional"
 	localconstants "github.com/turbot/powerpipe/internal/constants"
 	"github.com/turbot/powerpipe/internal/dashboardexecute"
 	"github.com/turbot/powerpipe/internal/dashboardworkspace"
 	"github.com/turbot/powerpipe/internal/db_client"
+	"github.com/turbot/powerpipe/internal/deprecation"
+	"github.com/turbot/powerpipe/internal/eventbus"
+	"github.com/turbot/powerpipe/internal/exitcodes"
+	"github.com/turbot/powerpipe/internal/metadata"
+	"github.com/turbot/powerpipe/internal/modsource"
+	"github.com/turbot/powerpipe/internal/publish"
+	"github.com/turbot/powerpipe/internal/rewrite"
+	"github.com/turbot/powerpipe/internal/snapshot"
+	"github.com/turbot/powerpipe/internal/verbosity"
 	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
 	"github.com/turbot/steampipe-plugin-sdk/v5/telemetry"
 	"log/slog"
@@ -33,8 +45,13 @@ type InitData[T modconfig.ModTreeItem] struct {
 
 	ShutdownTelemetry func()
 	ExportManager     *export.Manager
-	Targets           []modconfig.ModTreeItem
-	DefaultClient     *db_client.DbClient
+	// records the files written by the registered exporters, which may be published with --publish
+	ExportRecorder *publish.Recorder
+	Targets        []modconfig.ModTreeItem
+	DefaultClient  *db_client.DbClient
+
+	// warnings about the workspace, which are errors in strict mode
+	workspaceWarnings []string
 }
 
 func NewErrorInitData[T modconfig.ModTreeItem](err error) *InitData[T] {
@@ -48,21 +65,46 @@ func NewErrorInitData[T modconfig.ModTreeItem](err error) *InitData[T] {
 func NewInitData[T modconfig.ModTreeItem](ctx context.Context, cmd *cobra.Command, cmdArgs ...string) *InitData[T] {
 	modLocation := viper.GetString(constants.ArgModLocation)
 
+	// install any vendored dependencies which are not installed, so they are loaded without being fetched
+	if _, err := modsource.RestoreVendoredMods(modLocation); err != nil {
+		return NewErrorInitData[T](err)
+	}
 	w, errAndWarnings := workspace.LoadWorkspacePromptingForVariables(ctx, modLocation)
 	if errAndWarnings.GetError() != nil {
-		return NewErrorInitData[T](fmt.Errorf("failed to load workspace: %s", error_helpers.HandleCancelError(errAndWarnings.GetError()).Error()))
+		err := error_helpers.HandleCancelError(errAndWarnings.GetError())
+		if !error_helpers.IsCancelledError(err) {
+			err = exitcodes.WorkspaceLoadError(err)
+		}
+		return NewErrorInitData[T](fmt.Errorf("failed to load workspace: %w", err))
+	}
+	verbosity.Detailf("Loaded workspace %s", w.Path)
+	eventbus.Publish(eventbus.NewEvent(eventbus.EventWorkspaceLoaded, w.Mod.Name(), map[string]any{"path": w.Path}))
+	// remove disabled resources
+	if err := conditional.RemoveDisabled(w.GetResourceMaps()); err != nil {
+		return NewErrorInitData[T](err)
+	}
+	// add the selected controls to any assembled benchmarks
+	if err := assemble.Benchmarks(w.GetResourceMaps()); err != nil {
+		return NewErrorInitData[T](err)
+	}
+	// apply any query rewrite rules
+	if err := rewrite.RewriteQueries(w.GetResourceMaps()); err != nil {
+		return NewErrorInitData[T](err)
 	}
 
 	if !w.ModfileExists() && commandRequiresModfile[T](cmd, cmdArgs) {
 		return NewErrorInitData[T](localconstants.ErrorNoModDefinition{})
 	}
 	i := &InitData[T]{
-		Result:        &InitResult{},
-		ExportManager: export.NewManager(),
+		Result:         &InitResult{},
+		ExportManager:  export.NewManager(),
+		ExportRecorder: publish.NewRecorder(),
 	}
 
 	i.Workspace = w
-	i.Result.Warnings = errAndWarnings.Warnings
+	// expose the state of the workspace through the metadata database
+	db_client.SetMetadataProvider(metadata.Provider(w))
+	i.addWorkspaceWarnings(errAndWarnings.Warnings...)
 
 	// now do the actual initialisation
 	i.Init(ctx, cmdArgs...)
@@ -83,6 +125,11 @@ func commandRequiresModfile[T modconfig.ModTreeItem](cmd *cobra.Command, args []
 
 func (i *InitData[T]) RegisterExporters(exporters ...export.Exporter) error {
 	for _, e := range exporters {
+		e = i.ExportRecorder.Wrap(e)
+		// in batch mode, exports are written to deterministic paths
+		if batch.Enabled() {
+			e = batch.WrapExporter(e)
+		}
 		if err := i.ExportManager.Register(e); err != nil {
 			return err
 		}
@@ -115,6 +162,8 @@ func (i *InitData[T]) Init(ctx context.Context, args ...string) {
 	if i.Result.Error != nil {
 		return
 	}
+	// warn if the targets are or reference deprecated resources
+	i.addWorkspaceWarnings(deprecation.Warnings(i.Targets...)...)
 
 	statushooks.SetStatus(ctx, "Initializing")
 	i.WorkspaceEvents = dashboardworkspace.NewWorkspaceEvents(i.Workspace)
@@ -138,11 +187,12 @@ func (i *InitData[T]) Init(ctx context.Context, args ...string) {
 		// use force install so that errors are ignored during installation
 		// (we are validating prereqs later)
 		opts.Force = true
-		_, err := modinstaller.InstallWorkspaceDependencies(ctx, opts)
+		installData, err := modinstaller.InstallWorkspaceDependencies(ctx, opts)
 		if err != nil {
-			i.Result.Error = err
+			i.Result.Error = exitcodes.WithExitCode(err, exitcodes.ExitCodeModResolutionFailed)
 			return
 		}
+		modsource.PublishModInstalled(opts, installData)
 	}
 
 	// retrieve cloud metadata
@@ -166,14 +216,21 @@ func (i *InitData[T]) Init(ctx context.Context, args ...string) {
 	}
 	client, err := db_client.NewDbClient(ctx, database, opts...)
 	if err != nil {
-		i.Result.Error = err
+		i.Result.Error = exitcodes.WithExitCode(err, exitcodes.ExitCodeDatabaseConnectionFailed)
 		return
 	}
 	i.DefaultClient = client
+	verbosity.Detailf("Connected to %s database %s", client.Backend.Name(), snapshot.RedactValue(constants.ArgDatabase, client.Backend.ConnectionString()))
 
 	// validate mod requirements
 	validationWarnings := validateModRequirementsRecursively(i.Workspace.Mod, client)
-	i.Result.AddWarnings(validationWarnings...)
+	i.addWorkspaceWarnings(validationWarnings...)
+
+	// in strict mode, fail if there are workspace warnings or hygiene failures
+	if err := i.strictModeError(); err != nil {
+		i.Result.Error = err
+		return
+	}
 
 	// create the dashboard executor, passing the default client inside a client map
 	clientMap := db_client.NewClientMap().Add(client, searchPathConfig)
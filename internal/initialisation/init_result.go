@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/viper"
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/powerpipe/internal/verbosity"
 )
 
 type InitResult struct {
@@ -44,9 +45,9 @@ func (r *InitResult) DisplayMessages() {
 	for _, w := range r.Warnings {
 		r.DisplayWarning(context.Background(), w)
 	}
-	// do not display message in json or csv output mode
+	// do not display message in json or csv output mode, or if --quiet is set
 	output := viper.Get(constants.ArgOutput)
-	if output == constants.OutputFormatJSON || output == constants.OutputFormatCSV {
+	if output == constants.OutputFormatJSON || output == constants.OutputFormatCSV || verbosity.Quiet() {
 		return
 	}
 	for _, m := range r.Messages {
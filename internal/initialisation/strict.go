@@ -0,0 +1,71 @@
+package initialisation
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/utils"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/exitcodes"
+)
+
+// in strict mode (--strict), workspace warnings are errors, and resources of the workspace mod are also
+// required to have a description - this allows mod repos to enforce hygiene in CI, while remaining lenient
+// for end users
+
+// the resource types which are required to have a description in strict mode
+var strictDescribedBlockTypes = []string{"benchmark", "control", "dashboard", "detection", "query"}
+
+// addWorkspaceWarnings adds warnings which are errors in strict mode
+func (i *InitData[T]) addWorkspaceWarnings(warnings ...string) {
+	i.workspaceWarnings = append(i.workspaceWarnings, warnings...)
+	i.Result.AddWarnings(warnings...)
+}
+
+// strictModeError returns an error listing the workspace warnings and hygiene failures, if strict mode is enabled
+func (i *InitData[T]) strictModeError() error {
+	if !viper.GetBool(localconstants.ArgStrict) {
+		return nil
+	}
+	problems := append(slices.Clone(i.workspaceWarnings), missingDescriptions(i.Workspace.Mod, i.Workspace.Path)...)
+	if len(problems) == 0 {
+		return nil
+	}
+	count := len(problems)
+	err := fmt.Errorf("strict mode: %d workspace %s:\n  %s", count, utils.Pluralize("problem", count), strings.Join(problems, "\n  "))
+	return exitcodes.WithExitCode(err, exitcodes.ExitCodeWorkspaceParseFailed)
+}
+
+// missingDescriptions returns a message for each top level resource of the mod which has no description
+// (resources of dependency mods are not checked, as the workspace mod does not control them)
+func missingDescriptions(mod *modconfig.Mod, workspacePath string) []string {
+	var res []string
+	_ = mod.ResourceMaps.WalkResources(func(resource modconfig.HclResource) (bool, error) {
+		modItem, ok := resource.(modconfig.ModItem)
+		if !ok || modItem.GetMod() != mod {
+			return true, nil
+		}
+		if !slices.Contains(strictDescribedBlockTypes, resource.BlockType()) || !resource.IsTopLevel() {
+			return true, nil
+		}
+		if strings.TrimSpace(resource.GetDescription()) != "" {
+			return true, nil
+		}
+		message := fmt.Sprintf("%s has no description", resource.Name())
+		if declRange := resource.GetDeclRange(); declRange != nil && declRange.Filename != "" {
+			filename := declRange.Filename
+			if rel, err := filepath.Rel(workspacePath, filename); err == nil {
+				filename = rel
+			}
+			message = fmt.Sprintf("%s (%s:%d)", message, filename, declRange.Start.Line)
+		}
+		res = append(res, message)
+		return true, nil
+	})
+	slices.Sort(res)
+	return res
+}
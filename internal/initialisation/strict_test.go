@@ -0,0 +1,78 @@
+package initialisation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+type missingDescriptionsTest struct {
+	resources func(mod, dependencyMod *modconfig.Mod) []modconfig.HclResource
+	expected  []string
+}
+
+func newTestResource(mod *modconfig.Mod, blockType, name string, line int, description string, topLevel bool) modconfig.HclResource {
+	block := &hcl.Block{
+		Type:     blockType,
+		Labels:   []string{name},
+		DefRange: hcl.Range{Filename: "/workspace/controls/aws.pp", Start: hcl.Pos{Line: line}},
+	}
+	var resource modconfig.HclResource
+	switch blockType {
+	case "control":
+		resource = modconfig.NewControl(block, mod, name)
+		resource.(*modconfig.Control).Description = &description
+	case "query":
+		resource = modconfig.NewQuery(block, mod, name)
+		resource.(*modconfig.Query).Description = &description
+	}
+	resource.(interface{ SetTopLevel(bool) }).SetTopLevel(topLevel)
+	return resource
+}
+
+var testCasesMissingDescriptions = map[string]missingDescriptionsTest{
+	"missing": {
+		resources: func(mod, _ *modconfig.Mod) []modconfig.HclResource {
+			return []modconfig.HclResource{
+				newTestResource(mod, "query", "q", 12, "", true),
+				newTestResource(mod, "control", "c", 3, " ", true),
+			}
+		},
+		expected: []string{
+			"m.control.c has no description (controls/aws.pp:3)",
+			"m.query.q has no description (controls/aws.pp:12)",
+		},
+	},
+	"described": {
+		resources: func(mod, _ *modconfig.Mod) []modconfig.HclResource {
+			return []modconfig.HclResource{newTestResource(mod, "control", "c", 3, "Checks things", true)}
+		},
+	},
+	"nested": {
+		resources: func(mod, _ *modconfig.Mod) []modconfig.HclResource {
+			return []modconfig.HclResource{newTestResource(mod, "control", "anonymous", 3, "", false)}
+		},
+	},
+	"dependency mod": {
+		resources: func(_, dependencyMod *modconfig.Mod) []modconfig.HclResource {
+			return []modconfig.HclResource{newTestResource(dependencyMod, "control", "c", 3, "", true)}
+		},
+	},
+}
+
+func TestMissingDescriptions(t *testing.T) {
+	for name, test := range testCasesMissingDescriptions {
+		mod := modconfig.NewMod("m", "/workspace", hcl.Range{})
+		dependencyMod := modconfig.NewMod("dep", "/workspace/.powerpipe/mods/dep", hcl.Range{})
+		for _, resource := range test.resources(mod, dependencyMod) {
+			mod.ResourceMaps.AddResource(resource)
+		}
+
+		actual := missingDescriptions(mod, "/workspace")
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/schema"
+)
+
+// integrations are defined in the workspace config (.ppc) files, e.g.
+//
+//	integration "<type>" "<name>" {
+//	  ...
+//	}
+//
+// the attributes of an integration block depend on its type - each package which uses an integration type
+// loads the blocks of that type, and decodes them
+
+var integrationFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       schema.BlockTypeIntegration,
+			LabelNames: []string{schema.LabelType, schema.LabelName},
+		},
+	},
+}
+
+// Blocks returns the integration blocks of the given types in the config files of the given paths, keyed by name
+// paths are in order of decreasing precedence - if an integration is defined in more than one path, the first is used
+func Blocks(configPaths []string, integrationTypes ...string) (map[string]*hcl.Block, error) {
+	res := make(map[string]*hcl.Block)
+	for _, configPath := range configPaths {
+		filePaths, err := filepath.Glob(filepath.Join(configPath, "*"+app_specific.ConfigExtension))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(filePaths)
+
+		pathBlocks := make(map[string]*hcl.Block)
+		for _, filePath := range filePaths {
+			blocks, err := FileBlocks(filePath, integrationTypes...)
+			if err != nil {
+				return nil, err
+			}
+			for _, block := range blocks {
+				name := block.Labels[1]
+				if _, ok := pathBlocks[name]; ok {
+					return nil, fmt.Errorf("duplicate %s integration '%s' in %s", block.Labels[0], name, configPath)
+				}
+				pathBlocks[name] = block
+			}
+		}
+		for name, block := range pathBlocks {
+			if _, ok := res[name]; !ok {
+				res[name] = block
+			}
+		}
+	}
+	return res, nil
+}
+
+// FileBlocks returns the integration blocks of the given types in a config file
+func FileBlocks(filePath string, integrationTypes ...string) ([]*hcl.Block, error) {
+	fileData, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	file, diags := hclparse.NewParser().ParseHCL(fileData, filePath)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %s: %s", filePath, diags.Error())
+	}
+	// the file may contain other blocks, which are loaded elsewhere
+	content, _, diags := file.Body.PartialContent(integrationFileSchema)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %s: %s", filePath, diags.Error())
+	}
+
+	var res []*hcl.Block
+	for _, block := range content.Blocks {
+		if slices.Contains(integrationTypes, block.Labels[0]) {
+			res = append(res, block)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,159 @@
+package introspect
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/pipe-fittings/steampipeconfig"
+	"github.com/turbot/pipe-fittings/versionmap"
+	"github.com/turbot/powerpipe/internal/completion"
+	"github.com/turbot/powerpipe/internal/dashboardserver"
+	"github.com/turbot/powerpipe/internal/exitcodes"
+	"github.com/turbot/powerpipe/internal/service/api/common"
+)
+
+// the capabilities of powerpipe are described by a machine-readable document (powerpipe introspect, and the
+// /introspect API endpoint), so wrapper tooling and UIs may detect the available features rather than parsing the
+// version - the commands and their flags, the output and export formats of each command, the resource types which
+// may be defined in mods, the schema versions of the files and payloads powerpipe writes, and the exit codes
+//
+// the commands are described from the command tree, and the values of each flag from its usage (as for shell
+// completion), so the document always matches the CLI
+
+// SchemaVersion is the version of the introspection document - it is incremented if fields are removed or their
+// meaning changes (fields may be added without a change of version)
+const SchemaVersion = 1
+
+// Capabilities is the introspection document
+type Capabilities struct {
+	SchemaVersion int    `json:"schema_version" yaml:"schema_version"`
+	Version       string `json:"version" yaml:"version"`
+	// the runnable commands, e.g. 'benchmark run', ordered by name
+	Commands      []Command `json:"commands" yaml:"commands"`
+	ResourceTypes []string  `json:"resource_types" yaml:"resource_types"`
+	// the schema versions of the files and payloads written by powerpipe, keyed by name
+	SchemaVersions map[string]string    `json:"schema_versions" yaml:"schema_versions"`
+	ExitCodes      []exitcodes.ExitCode `json:"exit_codes" yaml:"exit_codes"`
+}
+
+// Command is a runnable command
+type Command struct {
+	// the command path, without the application name, e.g. 'benchmark run'
+	Name          string   `json:"name" yaml:"name"`
+	Description   string   `json:"description" yaml:"description"`
+	OutputFormats []string `json:"output_formats,omitempty" yaml:"output_formats,omitempty"`
+	// export formats ending in ':' are a prefix for a user provided value, e.g. 'custom:<format>'
+	ExportFormats []string `json:"export_formats,omitempty" yaml:"export_formats,omitempty"`
+	Flags         []Flag   `json:"flags,omitempty" yaml:"flags,omitempty"`
+}
+
+// Flag is a flag of a command
+type Flag struct {
+	Name        string `json:"name" yaml:"name"`
+	Shorthand   string `json:"shorthand,omitempty" yaml:"shorthand,omitempty"`
+	Type        string `json:"type" yaml:"type"`
+	Default     string `json:"default,omitempty" yaml:"default,omitempty"`
+	Description string `json:"description" yaml:"description"`
+	// the documented values of the flag, if it has a fixed set of values
+	Values []string `json:"values,omitempty" yaml:"values,omitempty"`
+}
+
+// the resource types which may be defined in mods
+var resourceTypes = []string{
+	schema.BlockTypeBenchmark,
+	schema.BlockTypeCard,
+	schema.BlockTypeCategory,
+	schema.BlockTypeChart,
+	schema.BlockTypeContainer,
+	schema.BlockTypeControl,
+	schema.BlockTypeDashboard,
+	schema.BlockTypeEdge,
+	schema.BlockTypeFlow,
+	schema.BlockTypeGraph,
+	schema.BlockTypeHierarchy,
+	schema.BlockTypeImage,
+	schema.BlockTypeInput,
+	schema.BlockTypeLocals,
+	schema.BlockTypeMod,
+	schema.BlockTypeNode,
+	schema.BlockTypeQuery,
+	schema.BlockTypeTable,
+	schema.BlockTypeText,
+	schema.BlockTypeVariable,
+	schema.BlockTypeWith,
+}
+
+// Describe returns the capabilities of the application of the given root command
+func Describe(root *cobra.Command, version string) *Capabilities {
+	commands := describeCommands(root, root)
+	if commands == nil {
+		commands = []Command{}
+	}
+	return &Capabilities{
+		SchemaVersion: SchemaVersion,
+		Version:       version,
+		Commands:      commands,
+		ResourceTypes: resourceTypes,
+		SchemaVersions: map[string]string{
+			"introspection":    fmt.Sprintf("%d", SchemaVersion),
+			"api":              common.APIVersion0,
+			"snapshot":         fmt.Sprintf("%d", steampipeconfig.SteampipeSnapshotSchemaVersion),
+			"dashboard_events": fmt.Sprintf("%d", dashboardserver.ExecutionCompletePayloadSchemaVersion),
+			"mod_lock":         fmt.Sprintf("%d", versionmap.WorkspaceLockStructVersion),
+		},
+		ExitCodes: exitcodes.Registry,
+	}
+}
+
+// describeCommands returns the runnable commands of the command and its subcommands, depth first in name order
+// (hidden commands, help topics and the help command are excluded)
+func describeCommands(root, cmd *cobra.Command) []Command {
+	var res []Command
+	if cmd != root && cmd.Runnable() {
+		res = append(res, describeCommand(root, cmd))
+	}
+	for _, child := range cmd.Commands() {
+		if child.Hidden || child.Name() == "help" || !child.IsAvailableCommand() && !child.Runnable() {
+			continue
+		}
+		res = append(res, describeCommands(root, child)...)
+	}
+	return res
+}
+
+func describeCommand(root, cmd *cobra.Command) Command {
+	res := Command{
+		Name:        strings.TrimSpace(strings.TrimPrefix(cmd.CommandPath(), root.Name())),
+		Description: cmd.Short,
+	}
+	// (getting the inherited flags merges the persistent flags of the parents into the flags of the command)
+	cmd.InheritedFlags()
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden || flag.Name == constants.ArgHelp {
+			return
+		}
+		f := Flag{
+			Name:        flag.Name,
+			Shorthand:   flag.Shorthand,
+			Type:        flag.Value.Type(),
+			Default:     flag.DefValue,
+			Description: flag.Usage,
+			Values:      completion.UsageValues(flag.Usage),
+		}
+		// (the values of enum flags are documented in map order, so are sorted for a stable document)
+		sort.Strings(f.Values)
+		switch flag.Name {
+		case constants.ArgOutput:
+			res.OutputFormats = f.Values
+		case constants.ArgExport:
+			res.ExportFormats = f.Values
+		}
+		res.Flags = append(res.Flags, f)
+	})
+	return res
+}
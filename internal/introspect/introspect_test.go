@@ -0,0 +1,43 @@
+package introspect
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestDescribe(t *testing.T) {
+	root := &cobra.Command{Use: "powerpipe [--version] [--help] COMMAND [args]"}
+	root.PersistentFlags().String("install-dir", "~/.powerpipe", "Path to the installation directory")
+	benchmark := &cobra.Command{Use: "benchmark [command]", Short: "Benchmark commands"}
+	run := &cobra.Command{Use: "run [flags] [benchmark]", Short: "Run one or more benchmarks", Run: func(*cobra.Command, []string) {}}
+	run.Flags().String("output", "text", "Output format; one of: text, json, csv")
+	run.Flags().StringSlice("export", nil, "Export output to file, supported formats: csv, pps (snapshot), custom:<format> (custom exporter)")
+	run.Flags().Bool("help", false, "Help for run")
+	run.Flags().Bool("internal", false, "")
+	_ = run.Flags().MarkHidden("internal")
+	hidden := &cobra.Command{Use: "hidden", Hidden: true, Run: func(*cobra.Command, []string) {}}
+	topic := &cobra.Command{Use: "exit-codes", Short: "Exit codes and their classes", Long: "..."}
+	benchmark.AddCommand(run)
+	root.AddCommand(benchmark, hidden, topic)
+
+	capabilities := Describe(root, "1.2.3")
+	if capabilities.Version != "1.2.3" || capabilities.SchemaVersion != SchemaVersion || len(capabilities.ResourceTypes) == 0 || len(capabilities.ExitCodes) == 0 {
+		t.Errorf("Test: 'describe' FAILED : expected the version, schema version, resource types and exit codes, got %v", capabilities)
+	}
+	expected := []Command{{
+		Name:          "benchmark run",
+		Description:   "Run one or more benchmarks",
+		OutputFormats: []string{"csv", "json", "text"},
+		ExportFormats: []string{"csv", "custom:", "pps"},
+		Flags: []Flag{
+			{Name: "export", Type: "stringSlice", Default: "[]", Description: "Export output to file, supported formats: csv, pps (snapshot), custom:<format> (custom exporter)", Values: []string{"csv", "custom:", "pps"}},
+			{Name: "install-dir", Type: "string", Default: "~/.powerpipe", Description: "Path to the installation directory"},
+			{Name: "output", Type: "string", Default: "text", Description: "Output format; one of: text, json, csv", Values: []string{"csv", "json", "text"}},
+		},
+	}}
+	if !reflect.DeepEqual(capabilities.Commands, expected) {
+		t.Errorf("Test: 'describe' FAILED : expected %v, got %v", expected, capabilities.Commands)
+	}
+}
@@ -0,0 +1,125 @@
+package k8sevents
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+)
+
+// when running in a Kubernetes cluster (e.g. as a CronJob), the results of a run may be emitted as Kubernetes
+// events, using the service account of the pod - the service account requires permission to create events in the
+// namespace of the pod, e.g.
+//
+//	rules:
+//	  - apiGroups: [""]
+//	    resources: ["events"]
+//	    verbs: ["create"]
+//
+// events are reported against the pod, whose name is taken from POD_NAME (e.g. set using the downward API) or the
+// hostname, so they are shown by 'kubectl describe pod' and 'kubectl get events'
+const (
+	EnvPodName = "POD_NAME"
+
+	// the environment variables set by Kubernetes in every pod
+	envServiceHost = "KUBERNETES_SERVICE_HOST"
+	envServicePort = "KUBERNETES_SERVICE_PORT"
+
+	requestTimeout = 30 * time.Second
+)
+
+// the directory of the service account credentials mounted in every pod - a variable so tests may replace it
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// Client creates events in the namespace of the pod using the API server of the cluster
+type Client struct {
+	baseUrl    string
+	token      string
+	namespace  string
+	podName    string
+	httpClient *http.Client
+}
+
+// InClusterClient returns a client using the service account of the pod - it returns an error if powerpipe is not
+// running in a Kubernetes cluster
+func InClusterClient() (*Client, error) {
+	host, port := os.Getenv(envServiceHost), os.Getenv(envServicePort)
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("kubernetes events can only be emitted when running in a Kubernetes cluster: %s and %s are not set", envServiceHost, envServicePort)
+	}
+	token, err := os.ReadFile(filepath.Join(serviceAccountDir, "token"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the service account token: %s", err.Error())
+	}
+	namespace, err := os.ReadFile(filepath.Join(serviceAccountDir, "namespace"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the service account namespace: %s", err.Error())
+	}
+	caCert, err := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the cluster CA certificate: %s", err.Error())
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("failed to parse the cluster CA certificate")
+	}
+
+	podName := os.Getenv(EnvPodName)
+	if podName == "" {
+		podName, _ = os.Hostname()
+	}
+	return &Client{
+		baseUrl:    "https://" + net.JoinHostPort(host, port),
+		token:      strings.TrimSpace(string(token)),
+		namespace:  strings.TrimSpace(string(namespace)),
+		podName:    podName,
+		httpClient: &http.Client{Transport: tlspolicy.NewTransport(&tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12})},
+	}, nil
+}
+
+// Namespace returns the namespace the events are created in
+func (c *Client) Namespace() string {
+	return c.namespace
+}
+
+// Create creates the event, reported against the pod
+func (c *Client) Create(ctx context.Context, event Event) error {
+	body, err := json.Marshal(c.resource(event, time.Now()))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	path := fmt.Sprintf("/api/v1/namespaces/%s/events", url.PathEscape(c.namespace))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseUrl+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("POST %s returned status %s: %s", path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package k8sevents
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreate(t *testing.T) {
+	var actualPath, actualAuth string
+	var actual eventResource
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actualPath, actualAuth = r.URL.Path, r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &actual); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	writeServiceAccount(t, server)
+	t.Setenv(EnvPodName, "powerpipe-cis-28401234-abcde")
+	client, err := InClusterClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := Event{Type: TypeWarning, Reason: "ControlAlarm", Message: "S3 buckets should block public access: 2 alarm", Annotations: map[string]string{"powerpipe.io/control": "aws_compliance.control.s3_public"}}
+	if err := client.Create(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	if actualPath != "/api/v1/namespaces/compliance/events" || actualAuth != "Bearer test-token" {
+		t.Errorf("Test: 'create' FAILED : expected a request to the events of the namespace, got %s %s", actualPath, actualAuth)
+	}
+	if actual.InvolvedObject.Kind != "Pod" || actual.InvolvedObject.Name != "powerpipe-cis-28401234-abcde" || actual.InvolvedObject.Namespace != "compliance" {
+		t.Errorf("Test: 'create' FAILED : expected the event to be reported against the pod, got %v", actual.InvolvedObject)
+	}
+	if actual.Type != TypeWarning || actual.Reason != event.Reason || actual.Message != event.Message || actual.Metadata.Annotations["powerpipe.io/control"] != "aws_compliance.control.s3_public" {
+		t.Errorf("Test: 'create' FAILED : expected %v, got %v", event, actual)
+	}
+}
+
+func TestCreateError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `events is forbidden: User "system:serviceaccount:compliance:default" cannot create resource "events"`, http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	writeServiceAccount(t, server)
+	client, err := InClusterClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Create(context.Background(), Event{Type: TypeNormal}); err == nil || !strings.Contains(err.Error(), "forbidden") {
+		t.Errorf("Test: 'forbidden' FAILED : expected forbidden error, got %v", err)
+	}
+}
+
+func TestInClusterClientNotInCluster(t *testing.T) {
+	t.Setenv(envServiceHost, "")
+	if _, err := InClusterClient(); err == nil {
+		t.Errorf("Test: 'not in cluster' FAILED : expected error, got none")
+	}
+}
+
+type truncateTest struct {
+	message  string
+	maxBytes int
+	expected string
+}
+
+var testCasesTruncate = map[string]truncateTest{
+	"short": {
+		message:  "ok",
+		maxBytes: 10,
+		expected: "ok",
+	},
+	"long": {
+		message:  "abcdefghijkl",
+		maxBytes: 10,
+		expected: "abcdefg...",
+	},
+	"multibyte": {
+		message:  "abcdef€€€€",
+		maxBytes: 10,
+		expected: "abcdef...",
+	},
+}
+
+func TestTruncate(t *testing.T) {
+	for name, test := range testCasesTruncate {
+		actual := truncate(test.message, test.maxBytes)
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, actual)
+		}
+	}
+}
+
+// writeServiceAccount writes the service account files for the test server, and sets the environment of a pod
+func writeServiceAccount(t *testing.T, server *httptest.Server) {
+	dir := t.TempDir()
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	for name, content := range map[string]string{"token": "test-token\n", "namespace": "compliance", "ca.crt": string(caCert)} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	previous := serviceAccountDir
+	serviceAccountDir = dir
+	t.Cleanup(func() { serviceAccountDir = previous })
+
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(server.URL, "https://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envServiceHost, host)
+	t.Setenv(envServicePort, port)
+}
@@ -0,0 +1,90 @@
+package k8sevents
+
+import (
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	TypeNormal  = "Normal"
+	TypeWarning = "Warning"
+
+	component = "powerpipe"
+	// the API server rejects longer messages
+	maxMessageLength = 1024
+)
+
+// Event is an event of a run, e.g. a benchmark completing, or a control in alarm
+type Event struct {
+	Type    string
+	Reason  string
+	Message string
+	// annotations identifying the benchmark or control of the event, e.g. powerpipe.io/control
+	Annotations map[string]string
+}
+
+// the v1 Event resource
+type eventResource struct {
+	ApiVersion         string            `json:"apiVersion"`
+	Kind               string            `json:"kind"`
+	Metadata           eventMetadata     `json:"metadata"`
+	InvolvedObject     objectReference   `json:"involvedObject"`
+	Reason             string            `json:"reason"`
+	Message            string            `json:"message"`
+	Type               string            `json:"type"`
+	Source             map[string]string `json:"source"`
+	FirstTimestamp     string            `json:"firstTimestamp"`
+	LastTimestamp      string            `json:"lastTimestamp"`
+	Count              int               `json:"count"`
+	ReportingComponent string            `json:"reportingComponent"`
+	ReportingInstance  string            `json:"reportingInstance,omitempty"`
+}
+
+type eventMetadata struct {
+	GenerateName string            `json:"generateName"`
+	Namespace    string            `json:"namespace"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+type objectReference struct {
+	ApiVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+}
+
+func (c *Client) resource(event Event, now time.Time) eventResource {
+	timestamp := now.UTC().Format(time.RFC3339)
+	return eventResource{
+		ApiVersion: "v1",
+		Kind:       "Event",
+		Metadata: eventMetadata{
+			GenerateName: component + "-",
+			Namespace:    c.namespace,
+			Annotations:  event.Annotations,
+		},
+		InvolvedObject:     objectReference{ApiVersion: "v1", Kind: "Pod", Name: c.podName, Namespace: c.namespace},
+		Reason:             event.Reason,
+		Message:            truncate(event.Message, maxMessageLength),
+		Type:               event.Type,
+		Source:             map[string]string{"component": component},
+		FirstTimestamp:     timestamp,
+		LastTimestamp:      timestamp,
+		Count:              1,
+		ReportingComponent: component,
+		ReportingInstance:  c.podName,
+	}
+}
+
+// truncate truncates the message to at most maxBytes bytes, without splitting a character
+func truncate(message string, maxBytes int) string {
+	if len(message) <= maxBytes {
+		return message
+	}
+	const ellipsis = "..."
+	cut := maxBytes - len(ellipsis)
+	for cut > 0 && !utf8.RuneStart(message[cut]) {
+		cut--
+	}
+	return message[:cut] + ellipsis
+}
@@ -0,0 +1,235 @@
+package locale
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+// the formatting of timestamps and numbers in table output, dashboards and exports may be set using the
+// --timezone, --date-format, --number-format and --currency flags (or the POWERPIPE_TIMEZONE, POWERPIPE_DATE_FORMAT,
+// POWERPIPE_NUMBER_FORMAT and POWERPIPE_CURRENCY env vars), e.g.
+//
+//	powerpipe query run "select ..." --timezone Europe/Berlin --date-format eu --number-format 1.234,5 --currency €
+//
+// by default, timestamps are displayed in the timezone they are returned in and numbers are not formatted
+// the currency symbol is applied to numeric columns named for monetary values, e.g. 'cost' or 'unblended_cost'
+const (
+	DateFormatISO     = "iso"
+	DateFormatUS      = "us"
+	DateFormatEU      = "eu"
+	DateFormatRFC3339 = "rfc3339"
+
+	NumberFormatDefault = "1,234.5"
+	NumberFormatPeriod  = "1.234,5"
+	NumberFormatSpace   = "1 234,5"
+	NumberFormatNone    = "1234.5"
+)
+
+// DateFormat is a date format, with its layout for Go and for the dashboard UI (dayjs)
+type DateFormat struct {
+	Layout   string
+	UiLayout string
+}
+
+var DateFormats = map[string]DateFormat{
+	DateFormatISO:     {Layout: "2006-01-02 15:04:05", UiLayout: "YYYY-MM-DD HH:mm:ss"},
+	DateFormatUS:      {Layout: "01/02/2006 03:04:05 PM", UiLayout: "MM/DD/YYYY hh:mm:ss A"},
+	DateFormatEU:      {Layout: "02/01/2006 15:04:05", UiLayout: "DD/MM/YYYY HH:mm:ss"},
+	DateFormatRFC3339: {Layout: time.RFC3339, UiLayout: "YYYY-MM-DDTHH:mm:ssZ"},
+}
+
+// the thousands and decimal separators of each number format
+var numberFormats = map[string][2]string{
+	NumberFormatDefault: {",", "."},
+	NumberFormatPeriod:  {".", ","},
+	NumberFormatSpace:   {" ", ","},
+	NumberFormatNone:    {"", "."},
+}
+
+// numeric columns with these names (or name suffixes) are formatted as currency
+var currencyColumnRegex = regexp.MustCompile(`(?i)(^|_)(cost|price|amount|spend)$`)
+
+// Settings are the timezone, date and number formatting to use for output
+type Settings struct {
+	// the IANA timezone name, or empty to leave timestamps in the timezone they were returned in
+	Timezone   string
+	DateFormat string
+	// the number format, or empty if numbers are not formatted
+	NumberFormat       string
+	ThousandsSeparator string
+	DecimalSeparator   string
+	Currency           string
+	location           *time.Location
+}
+
+var (
+	current     = defaultSettings()
+	currentLock sync.RWMutex
+)
+
+func defaultSettings() *Settings {
+	return &Settings{
+		DateFormat:       DateFormatISO,
+		DecimalSeparator: ".",
+	}
+}
+
+// NewSettings validates and builds the settings for the given options - empty options use the defaults
+func NewSettings(timezone, dateFormat, numberFormat, currency string) (*Settings, error) {
+	s := defaultSettings()
+	s.Currency = currency
+
+	if timezone != "" {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s '%s' - must be an IANA timezone name, e.g. Europe/London", localconstants.ArgTimezone, timezone)
+		}
+		s.Timezone = timezone
+		s.location = location
+	}
+	if dateFormat != "" {
+		if _, ok := DateFormats[dateFormat]; !ok {
+			return nil, fmt.Errorf("invalid %s '%s' - must be one of: %s, %s, %s, %s", localconstants.ArgDateFormat, dateFormat, DateFormatISO, DateFormatUS, DateFormatEU, DateFormatRFC3339)
+		}
+		s.DateFormat = dateFormat
+	}
+	if numberFormat != "" {
+		separators, ok := numberFormats[numberFormat]
+		if !ok {
+			return nil, fmt.Errorf("invalid %s '%s' - must be one of: '%s', '%s', '%s', '%s'", localconstants.ArgNumberFormat, numberFormat, NumberFormatDefault, NumberFormatPeriod, NumberFormatSpace, NumberFormatNone)
+		}
+		s.NumberFormat = numberFormat
+		s.ThousandsSeparator, s.DecimalSeparator = separators[0], separators[1]
+	}
+	return s, nil
+}
+
+// Init validates the formatting options set in viper, and sets them as the current settings
+func Init() error {
+	s, err := NewSettings(
+		viper.GetString(localconstants.ArgTimezone),
+		viper.GetString(localconstants.ArgDateFormat),
+		viper.GetString(localconstants.ArgNumberFormat),
+		viper.GetString(localconstants.ArgCurrency),
+	)
+	if err != nil {
+		return err
+	}
+	currentLock.Lock()
+	defer currentLock.Unlock()
+	current = s
+	return nil
+}
+
+// Current returns the current settings
+func Current() *Settings {
+	currentLock.RLock()
+	defer currentLock.RUnlock()
+	return current
+}
+
+// FormatTime formats a timestamp in the timezone (if set) and date format of the settings
+func (s *Settings) FormatTime(t time.Time) string {
+	if s.location != nil {
+		t = t.In(s.location)
+	}
+	return s.FormatLocalTime(t)
+}
+
+// FormatTimeWithZone formats a timestamp as FormatTime, followed by the timezone abbreviation
+// (unless the date format includes the timezone offset)
+func (s *Settings) FormatTimeWithZone(t time.Time) string {
+	if s.location != nil {
+		t = t.In(s.location)
+	}
+	if s.DateFormat == DateFormatRFC3339 {
+		return s.FormatLocalTime(t)
+	}
+	return fmt.Sprintf("%s %s", s.FormatLocalTime(t), t.Format("MST"))
+}
+
+// FormatLocalTime formats a timestamp which has no timezone (e.g. a postgres timestamp or date) in the date format
+// of the settings - the timestamp is not converted to the timezone of the settings
+func (s *Settings) FormatLocalTime(t time.Time) string {
+	return t.Format(DateFormats[s.DateFormat].Layout)
+}
+
+// FormatNumber formats a number with the separators of the settings
+// false is returned if the value is not numeric, or no number format is set
+func (s *Settings) FormatNumber(value any) (string, bool) {
+	if s.NumberFormat == "" {
+		return "", false
+	}
+	var str string
+	switch v := value.(type) {
+	case int:
+		str = strconv.FormatInt(int64(v), 10)
+	case int16:
+		str = strconv.FormatInt(int64(v), 10)
+	case int32:
+		str = strconv.FormatInt(int64(v), 10)
+	case int64:
+		str = strconv.FormatInt(v, 10)
+	case float32:
+		str = strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", false
+		}
+		str = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return "", false
+	}
+	return s.separate(str), true
+}
+
+// FormatColumnNumber formats a numeric column value, adding the currency symbol if the column is a monetary value
+func (s *Settings) FormatColumnNumber(columnName string, value any) (string, bool) {
+	res, ok := s.FormatNumber(value)
+	if !ok {
+		return "", false
+	}
+	if s.Currency != "" && IsCurrencyColumn(columnName) {
+		if strings.HasPrefix(res, "-") {
+			return "-" + s.Currency + res[1:], true
+		}
+		return s.Currency + res, true
+	}
+	return res, true
+}
+
+// separate adds the thousands separator to a formatted number, and replaces the decimal point
+func (s *Settings) separate(str string) string {
+	sign := ""
+	if strings.HasPrefix(str, "-") {
+		sign, str = "-", str[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(str, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, c := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(s.ThousandsSeparator)
+		}
+		b.WriteRune(c)
+	}
+	if hasFraction {
+		b.WriteString(s.DecimalSeparator)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// IsCurrencyColumn returns whether numeric values of the named column are formatted as currency
+func IsCurrencyColumn(columnName string) bool {
+	return currencyColumnRegex.MatchString(columnName)
+}
@@ -0,0 +1,142 @@
+package locale
+
+import (
+	"testing"
+	"time"
+)
+
+type formatColumnNumberTest struct {
+	numberFormat string
+	currency     string
+	column       string
+	value        any
+	expected     string
+}
+
+var testCasesFormatColumnNumber = map[string]formatColumnNumberTest{
+	"no format": {
+		column:   "count",
+		value:    1234567,
+		expected: "",
+	},
+	"default int": {
+		numberFormat: NumberFormatDefault,
+		column:       "count",
+		value:        int64(1234567),
+		expected:     "1,234,567",
+	},
+	"default small": {
+		numberFormat: NumberFormatDefault,
+		column:       "count",
+		value:        int32(123),
+		expected:     "123",
+	},
+	"period float": {
+		numberFormat: NumberFormatPeriod,
+		column:       "ratio",
+		value:        1234.5,
+		expected:     "1.234,5",
+	},
+	"space negative": {
+		numberFormat: NumberFormatSpace,
+		column:       "delta",
+		value:        -1234567.25,
+		expected:     "-1 234 567,25",
+	},
+	"none": {
+		numberFormat: NumberFormatNone,
+		column:       "count",
+		value:        1234567,
+		expected:     "1234567",
+	},
+	"currency": {
+		numberFormat: NumberFormatDefault,
+		currency:     "€",
+		column:       "unblended_cost",
+		value:        -1234.5,
+		expected:     "-€1,234.5",
+	},
+	"currency not monetary column": {
+		numberFormat: NumberFormatDefault,
+		currency:     "€",
+		column:       "cost_center",
+		value:        1234,
+		expected:     "1,234",
+	},
+	"not numeric": {
+		numberFormat: NumberFormatDefault,
+		column:       "name",
+		value:        "1234",
+		expected:     "",
+	},
+}
+
+func TestFormatColumnNumber(t *testing.T) {
+	for name, test := range testCasesFormatColumnNumber {
+		settings, err := NewSettings("", "", test.numberFormat, test.currency)
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %v", name, err)
+			continue
+		}
+		actual, _ := settings.FormatColumnNumber(test.column, test.value)
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, actual)
+		}
+	}
+}
+
+type formatTimeTest struct {
+	timezone   string
+	dateFormat string
+	expected   string
+	err        bool
+}
+
+var testCasesFormatTime = map[string]formatTimeTest{
+	"default": {
+		expected: "2024-03-01 14:30:00",
+	},
+	"us in new york": {
+		timezone:   "America/New_York",
+		dateFormat: DateFormatUS,
+		expected:   "03/01/2024 09:30:00 AM",
+	},
+	"eu in berlin": {
+		timezone:   "Europe/Berlin",
+		dateFormat: DateFormatEU,
+		expected:   "01/03/2024 15:30:00",
+	},
+	"rfc3339 in tokyo": {
+		timezone:   "Asia/Tokyo",
+		dateFormat: DateFormatRFC3339,
+		expected:   "2024-03-01T23:30:00+09:00",
+	},
+	"invalid timezone": {
+		timezone: "Mars/Olympus",
+		err:      true,
+	},
+	"invalid date format": {
+		dateFormat: "yyyy-mm-dd",
+		err:        true,
+	},
+}
+
+func TestFormatTime(t *testing.T) {
+	timestamp := time.Date(2024, 3, 1, 14, 30, 0, 0, time.UTC)
+	for name, test := range testCasesFormatTime {
+		settings, err := NewSettings(test.timezone, test.dateFormat, "", "")
+		if test.err {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error, got none", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %v", name, err)
+			continue
+		}
+		if actual := settings.FormatTime(timestamp); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, actual)
+		}
+	}
+}
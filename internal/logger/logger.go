@@ -3,7 +3,6 @@ package logger
 import (
 	"fmt"
 	"github.com/turbot/pipe-fittings/sanitize"
-	"io"
 	"log/slog"
 	"os"
 	"strings"
@@ -31,25 +30,28 @@ func Initialize() {
 }
 
 func PowerpipeLogger() *slog.Logger {
+	replaceAttr := func(groups []string, a slog.Attr) slog.Attr {
+		sanitized := sanitize.Instance.SanitizeKeyValue(a.Key, a.Value.Any())
+
+		return slog.Attr{
+			Key:   a.Key,
+			Value: slog.AnyValue(sanitized),
+		}
+	}
+	// the most recent logs are always retained, so they can be included in a crash report
+	recentHandler := slog.NewJSONHandler(recentLogs, &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: replaceAttr})
+
 	level := getLogLevel()
 	if level == constants.LogLevelOff {
-		return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{}))
+		return slog.New(recentHandler)
 	}
 
 	handlerOptions := &slog.HandlerOptions{
-		Level: level,
-
-		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
-			sanitized := sanitize.Instance.SanitizeKeyValue(a.Key, a.Value.Any())
-
-			return slog.Attr{
-				Key:   a.Key,
-				Value: slog.AnyValue(sanitized),
-			}
-		},
+		Level:       level,
+		ReplaceAttr: replaceAttr,
 	}
 
-	return slog.New(slog.NewJSONHandler(os.Stderr, handlerOptions))
+	return slog.New(multiHandler{slog.NewJSONHandler(os.Stderr, handlerOptions), recentHandler})
 }
 
 func getLogLevel() slog.Leveler {
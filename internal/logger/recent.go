@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// maxRecentLogs is the number of log lines retained for crash reports
+const maxRecentLogs = 500
+
+// recentLogs retains the most recent log lines, at debug level whatever the configured log level,
+// so they can be included in a crash report
+var recentLogs = &recentLogWriter{}
+
+// RecentLogs returns the most recent log lines, oldest first
+func RecentLogs() []string {
+	return recentLogs.lines()
+}
+
+// recentLogWriter is a ring buffer of log lines
+type recentLogWriter struct {
+	mut  sync.Mutex
+	ring [maxRecentLogs]string
+	// the index of the next line to write
+	next int
+	full bool
+}
+
+func (w *recentLogWriter) Write(p []byte) (int, error) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	w.ring[w.next] = strings.TrimSuffix(string(p), "\n")
+	w.next = (w.next + 1) % maxRecentLogs
+	if w.next == 0 {
+		w.full = true
+	}
+	return len(p), nil
+}
+
+func (w *recentLogWriter) lines() []string {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	if !w.full {
+		return append([]string{}, w.ring[:w.next]...)
+	}
+	return append(append([]string{}, w.ring[w.next:]...), w.ring[:w.next]...)
+}
+
+// multiHandler passes log records to each of its handlers which is enabled for the record level
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range m {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	res := make(multiHandler, len(m))
+	for i, h := range m {
+		res[i] = h.WithAttrs(attrs)
+	}
+	return res
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	res := make(multiHandler, len(m))
+	for i, h := range m {
+		res[i] = h.WithGroup(name)
+	}
+	return res
+}
@@ -0,0 +1,158 @@
+package materialize
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	typehelpers "github.com/turbot/go-kit/types"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+// materializations are queries which are tagged with a materialized table name, e.g.
+//
+//	query "bucket_counts_by_region" {
+//	  sql = "select region, count(*) from aws_s3_bucket group by region"
+//	  tags = {
+//	    materialized_table   = "powerpipe_cache_bucket_counts_by_region"
+//	    materialized_refresh = "1h"
+//	  }
+//	}
+//
+// in server mode, the results of the query are maintained as a table in the target database,
+// so dashboard panels may select from the table rather than recomputing the aggregation on every view
+//
+// the tables created by materializations are recorded in the ownership table (powerpipe_materialized_tables) of the
+// database, and a refresh only replaces a table recorded there - a materialization naming an existing table which
+// was not created by powerpipe fails rather than dropping it
+const (
+	TagTable   = "materialized_table"
+	TagRefresh = "materialized_refresh"
+
+	DefaultRefresh = time.Hour
+	MinRefresh     = time.Minute
+
+	// suffix used for the table which is populated before being swapped in
+	stagingSuffix = "__pp_staging"
+	// suffix used for the table which is swapped out, before it is dropped (for backends swapping tables by renaming)
+	oldSuffix = "__pp_old"
+
+	// the table recording the tables created by materializations
+	ownershipTable = "powerpipe_materialized_tables"
+)
+
+// a table name, optionally schema qualified
+var tableNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+// Materialization is a query whose results are maintained as a table in the target database
+type Materialization struct {
+	Name    string
+	Table   string
+	Refresh time.Duration
+	Query   *modconfig.Query
+}
+
+// GetMaterializations returns a materialization for each query in the resource maps with a materialized table tag
+func GetMaterializations(resourceMaps *modconfig.ResourceMaps) ([]*Materialization, error) {
+	var res []*Materialization
+	tables := make(map[string]string)
+	for _, query := range resourceMaps.Queries {
+		table, ok := query.Tags[TagTable]
+		if !ok {
+			continue
+		}
+		m, err := newMaterialization(query, table)
+		if err != nil {
+			return nil, err
+		}
+		if existing, ok := tables[m.Table]; ok {
+			return nil, fmt.Errorf("%s and %s both materialize the table '%s'", existing, m.Name, m.Table)
+		}
+		tables[m.Table] = m.Name
+		res = append(res, m)
+	}
+	// sort for consistent ordering
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
+
+func newMaterialization(query *modconfig.Query, table string) (*Materialization, error) {
+	if !tableNameRegex.MatchString(table) {
+		return nil, fmt.Errorf("invalid %s tag '%s' for %s: must be a table name, optionally qualified with a schema", TagTable, table, query.Name())
+	}
+	if typehelpers.SafeString(query.SQL) == "" {
+		return nil, fmt.Errorf("materialization %s has no sql", query.Name())
+	}
+
+	refresh := DefaultRefresh
+	if refreshTag, ok := query.Tags[TagRefresh]; ok {
+		var err error
+		refresh, err = time.ParseDuration(refreshTag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s tag '%s' for %s: %s", TagRefresh, refreshTag, query.Name(), err.Error())
+		}
+		if refresh < MinRefresh {
+			return nil, fmt.Errorf("invalid %s tag '%s' for %s: must be at least %s", TagRefresh, refreshTag, query.Name(), MinRefresh)
+		}
+	}
+
+	return &Materialization{
+		Name:    query.Name(),
+		Table:   table,
+		Refresh: refresh,
+		Query:   query,
+	}, nil
+}
+
+// refreshPlan is the statements which rebuild the table of a materialization
+// the results are written to a staging table (build) which then replaces the existing table (swap), so the table is
+// never unavailable or partially populated - the swap statements are executed in a single transaction, or are a single
+// atomic rename where the backend does not support transactional DDL, after which any replaced table is dropped (cleanup)
+type refreshPlan struct {
+	build   []string
+	swap    []string
+	cleanup []string
+}
+
+// ownershipTableStatement creates the ownership table, if it does not exist
+var ownershipTableStatement = fmt.Sprintf("create table if not exists %s (table_name varchar(255) not null primary key)", ownershipTable)
+
+func (m *Materialization) refreshPlan(backendName, sql string) refreshPlan {
+	stagingTable := m.Table + stagingSuffix
+	// the new name in a rename must not be schema qualified
+	unqualifiedTable := m.Table
+	if idx := strings.LastIndex(m.Table, "."); idx != -1 {
+		unqualifiedTable = m.Table[idx+1:]
+	}
+	build := []string{
+		// record the table as created by powerpipe (the ownership of an existing table is checked before the refresh)
+		fmt.Sprintf("delete from %s where table_name = '%s'", ownershipTable, m.Table),
+		fmt.Sprintf("insert into %s (table_name) values ('%s')", ownershipTable, m.Table),
+		fmt.Sprintf("drop table if exists %s", stagingTable),
+		fmt.Sprintf("create table %s as %s", stagingTable, strings.TrimRight(strings.TrimSpace(sql), ";")),
+	}
+
+	// MySQL implicitly commits DDL statements, so cannot swap the tables in a transaction - instead, swap them with a
+	// single rename statement, which is atomic (the table is created first if it does not exist, as both tables must)
+	if backendName == constants.MySQLBackendName {
+		oldTable := m.Table + oldSuffix
+		return refreshPlan{
+			build: append([]string{fmt.Sprintf("drop table if exists %s", oldTable)}, build...),
+			swap: []string{
+				fmt.Sprintf("create table if not exists %s like %s", m.Table, stagingTable),
+				fmt.Sprintf("rename table %s to %s, %s to %s", m.Table, oldTable, stagingTable, m.Table),
+			},
+			cleanup: []string{fmt.Sprintf("drop table if exists %s", oldTable)},
+		}
+	}
+	return refreshPlan{
+		build: build,
+		swap: []string{
+			fmt.Sprintf("drop table if exists %s", m.Table),
+			fmt.Sprintf("alter table %s rename to %s", stagingTable, unqualifiedTable),
+		},
+	}
+}
@@ -0,0 +1,195 @@
+package materialize
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/turbot/pipe-fittings/constants"
+	localqueryresult "github.com/turbot/powerpipe/internal/queryresult"
+)
+
+type refreshPlanTest struct {
+	backend  string
+	table    string
+	expected refreshPlan
+}
+
+var testCasesRefreshPlan = map[string]refreshPlanTest{
+	"postgres": {
+		backend: constants.PostgresBackendName,
+		table:   "cache.bucket_counts",
+		expected: refreshPlan{
+			build: []string{
+				"delete from powerpipe_materialized_tables where table_name = 'cache.bucket_counts'",
+				"insert into powerpipe_materialized_tables (table_name) values ('cache.bucket_counts')",
+				"drop table if exists cache.bucket_counts__pp_staging",
+				"create table cache.bucket_counts__pp_staging as select region, count(*) from buckets group by region",
+			},
+			swap: []string{
+				"drop table if exists cache.bucket_counts",
+				"alter table cache.bucket_counts__pp_staging rename to bucket_counts",
+			},
+		},
+	},
+	"mysql": {
+		backend: constants.MySQLBackendName,
+		table:   "bucket_counts",
+		expected: refreshPlan{
+			build: []string{
+				"drop table if exists bucket_counts__pp_old",
+				"delete from powerpipe_materialized_tables where table_name = 'bucket_counts'",
+				"insert into powerpipe_materialized_tables (table_name) values ('bucket_counts')",
+				"drop table if exists bucket_counts__pp_staging",
+				"create table bucket_counts__pp_staging as select region, count(*) from buckets group by region",
+			},
+			swap: []string{
+				"create table if not exists bucket_counts like bucket_counts__pp_staging",
+				"rename table bucket_counts to bucket_counts__pp_old, bucket_counts__pp_staging to bucket_counts",
+			},
+			cleanup: []string{
+				"drop table if exists bucket_counts__pp_old",
+			},
+		},
+	},
+}
+
+func TestRefreshPlan(t *testing.T) {
+	for name, test := range testCasesRefreshPlan {
+		m := &Materialization{Name: "query.bucket_counts", Table: test.table}
+		actual := m.refreshPlan(test.backend, " select region, count(*) from buckets group by region; ")
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %+v, got %+v", name, test.expected, actual)
+		}
+	}
+}
+
+// sqlExecutor executes the statements of a refresh against a database
+type sqlExecutor struct {
+	db *sql.DB
+	// if set, ExecuteInTransaction executes this statement after the others, to test rolling back the transaction
+	failStatement string
+}
+
+func (e *sqlExecutor) ExecuteSync(ctx context.Context, query string, args ...any) (*localqueryresult.SyncQueryResult, error) {
+	rows, err := e.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	// (only the number of rows is used by a refresh)
+	res := &localqueryresult.SyncQueryResult{}
+	for rows.Next() {
+		res.Rows = append(res.Rows, struct{}{})
+	}
+	return res, rows.Err()
+}
+
+func (e *sqlExecutor) ExecuteInTransaction(ctx context.Context, statements ...string) error {
+	tx, err := e.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after commit
+	if e.failStatement != "" {
+		statements = append(statements, e.failStatement)
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (e *sqlExecutor) values(t *testing.T, table string) []string {
+	rows, err := e.db.Query("select name from " + table + " order by name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var res []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, name)
+	}
+	return res
+}
+
+func TestRebuild(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// (each connection to an in-memory database is a separate database)
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+	if _, err := db.Exec("create table buckets(name text); insert into buckets values ('a'), ('b')"); err != nil {
+		t.Fatal(err)
+	}
+	executor := &sqlExecutor{db: db}
+	m := &Materialization{Name: "query.bucket_names", Table: "bucket_names"}
+
+	// the table is created
+	if err := m.rebuild(ctx, constants.SQLiteBackendName, executor, "select name from buckets"); err != nil {
+		t.Fatalf("Test: 'create' FAILED : unexpected error %v", err)
+	}
+	if actual := executor.values(t, "bucket_names"); !reflect.DeepEqual(actual, []string{"a", "b"}) {
+		t.Errorf("Test: 'create' FAILED : expected [a b], got %v", actual)
+	}
+
+	// the table is replaced
+	if err := m.rebuild(ctx, constants.SQLiteBackendName, executor, "select name from buckets where name = 'b'"); err != nil {
+		t.Fatalf("Test: 'replace' FAILED : unexpected error %v", err)
+	}
+	if actual := executor.values(t, "bucket_names"); !reflect.DeepEqual(actual, []string{"b"}) {
+		t.Errorf("Test: 'replace' FAILED : expected [b], got %v", actual)
+	}
+
+	// if the query fails, the table is unchanged
+	if err := m.rebuild(ctx, constants.SQLiteBackendName, executor, "select name from missing_table"); err == nil {
+		t.Errorf("Test: 'query failed' FAILED : expected error")
+	}
+	if actual := executor.values(t, "bucket_names"); !reflect.DeepEqual(actual, []string{"b"}) {
+		t.Errorf("Test: 'query failed' FAILED : expected [b], got %v", actual)
+	}
+
+	// if the swap fails, it is rolled back, so the table is not dropped
+	executor.failStatement = "select * from missing_table"
+	if err := m.rebuild(ctx, constants.SQLiteBackendName, executor, "select name from buckets"); err == nil {
+		t.Errorf("Test: 'swap failed' FAILED : expected error")
+	}
+	if actual := executor.values(t, "bucket_names"); !reflect.DeepEqual(actual, []string{"b"}) {
+		t.Errorf("Test: 'swap failed' FAILED : expected [b], got %v", actual)
+	}
+}
+
+func TestRebuildExistingTable(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+	// a table which was not created by powerpipe
+	if _, err := db.Exec("create table buckets(name text); insert into buckets values ('a'), ('b'); create table accounts(name text); insert into accounts values ('prod')"); err != nil {
+		t.Fatal(err)
+	}
+	executor := &sqlExecutor{db: db}
+	m := &Materialization{Name: "query.bucket_names", Table: "accounts"}
+
+	expected := "table 'accounts' already exists and was not created by powerpipe - it is not replaced by query.bucket_names"
+	if err := m.rebuild(ctx, constants.SQLiteBackendName, executor, "select name from buckets"); err == nil || err.Error() != expected {
+		t.Errorf("Test: 'existing table' FAILED : expected error '%s', got %v", expected, err)
+	}
+	if actual := executor.values(t, "accounts"); !reflect.DeepEqual(actual, []string{"prod"}) {
+		t.Errorf("Test: 'existing table' FAILED : expected [prod], got %v", actual)
+	}
+}
@@ -0,0 +1,182 @@
+package materialize
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/powerpipe/internal/db_client"
+	localqueryresult "github.com/turbot/powerpipe/internal/queryresult"
+)
+
+// Status is the refresh status of a materialization
+type Status struct {
+	Name        string     `json:"name"`
+	Table       string     `json:"table"`
+	Refresh     string     `json:"refresh"`
+	LastRefresh *time.Time `json:"last_refresh,omitempty"`
+	Duration    string     `json:"duration,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// Refresher maintains the materialized tables, rebuilding each on its refresh interval
+type Refresher struct {
+	materializations []*Materialization
+	workspaceMod     *modconfig.Mod
+	clientMap        *db_client.ClientMap
+
+	statusLock sync.RWMutex
+	status     map[string]*Status
+}
+
+func NewRefresher(materializations []*Materialization, workspaceMod *modconfig.Mod, clientMap *db_client.ClientMap) *Refresher {
+	status := make(map[string]*Status, len(materializations))
+	for _, m := range materializations {
+		status[m.Name] = &Status{Name: m.Name, Table: m.Table, Refresh: m.Refresh.String()}
+	}
+	return &Refresher{
+		materializations: materializations,
+		workspaceMod:     workspaceMod,
+		clientMap:        clientMap,
+		status:           status,
+	}
+}
+
+// Start starts a goroutine for each materialization - these run until the context is cancelled
+func (r *Refresher) Start(ctx context.Context) {
+	for _, m := range r.materializations {
+		go r.refreshLoop(ctx, m)
+	}
+}
+
+// Status returns the refresh status of all materializations
+func (r *Refresher) Status() []Status {
+	r.statusLock.RLock()
+	defer r.statusLock.RUnlock()
+
+	res := make([]Status, 0, len(r.materializations))
+	for _, m := range r.materializations {
+		res = append(res, *r.status[m.Name])
+	}
+	return res
+}
+
+func (r *Refresher) refreshLoop(ctx context.Context, m *Materialization) {
+	// schedule the database connections of the refresh fairly with those of dashboard sessions
+	ctx = db_client.WithOrigin(ctx, "materialization:"+m.Name)
+	ticker := time.NewTicker(m.Refresh)
+	defer ticker.Stop()
+
+	// build immediately, then on the refresh interval
+	for {
+		r.refresh(ctx, m)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Refresher) refresh(ctx context.Context, m *Materialization) {
+	slog.Debug("refreshing materialization", "materialization", m.Name, "table", m.Table)
+	startTime := time.Now()
+
+	err := r.rebuildTable(ctx, m)
+	if err != nil {
+		slog.Warn("materialization refresh failed", "materialization", m.Name, "error", err)
+	}
+
+	r.statusLock.Lock()
+	defer r.statusLock.Unlock()
+	status := r.status[m.Name]
+	status.LastRefresh = &startTime
+	status.Duration = time.Since(startTime).Round(time.Millisecond).String()
+	status.Error = ""
+	if err != nil {
+		status.Error = err.Error()
+	}
+}
+
+func (r *Refresher) rebuildTable(ctx context.Context, m *Materialization) error {
+	defaultDatabase, defaultSearchPathConfig := db_client.GetDefaultDatabaseConfig()
+	database, searchPathConfig, err := db_client.GetDatabaseConfigForResource(m.Query, r.workspaceMod, defaultDatabase, defaultSearchPathConfig)
+	if err != nil {
+		return err
+	}
+	client, err := r.clientMap.GetOrCreate(ctx, database, searchPathConfig)
+	if err != nil {
+		return err
+	}
+
+	resolvedQuery, err := m.Query.GetResolvedQuery(nil)
+	if err != nil {
+		return err
+	}
+	// the sql is embedded in a 'create table as' statement so cannot use query args
+	if len(resolvedQuery.Args) > 0 {
+		return fmt.Errorf("materialized queries cannot have params")
+	}
+
+	return m.rebuild(ctx, client.Backend.Name(), client, resolvedQuery.ExecuteSQL)
+}
+
+// statementExecutor executes the statements of a refresh - this is implemented by DbClient
+type statementExecutor interface {
+	ExecuteSync(ctx context.Context, query string, args ...any) (*localqueryresult.SyncQueryResult, error)
+	ExecuteInTransaction(ctx context.Context, statements ...string) error
+}
+
+// rebuild rebuilds the table from the results of the sql
+func (m *Materialization) rebuild(ctx context.Context, backendName string, executor statementExecutor, sql string) error {
+	if err := m.checkOwnership(ctx, executor); err != nil {
+		return err
+	}
+	plan := m.refreshPlan(backendName, sql)
+	for _, statement := range plan.build {
+		if _, err := executor.ExecuteSync(ctx, statement); err != nil {
+			return err
+		}
+	}
+	if backendName == constants.MySQLBackendName {
+		// (each statement is atomic - the transaction would be implicitly committed by each statement)
+		for _, statement := range plan.swap {
+			if _, err := executor.ExecuteSync(ctx, statement); err != nil {
+				return err
+			}
+		}
+	} else if err := executor.ExecuteInTransaction(ctx, plan.swap...); err != nil {
+		return err
+	}
+	for _, statement := range plan.cleanup {
+		if _, err := executor.ExecuteSync(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkOwnership returns an error if the table exists but was not created by powerpipe, so must not be replaced
+func (m *Materialization) checkOwnership(ctx context.Context, executor statementExecutor) error {
+	if _, err := executor.ExecuteSync(ctx, ownershipTableStatement); err != nil {
+		return err
+	}
+	// (the table name is validated, so may be used in the statements - query args are not portable across backends)
+	if _, err := executor.ExecuteSync(ctx, fmt.Sprintf("select 1 from %s where 1 = 0", m.Table)); err != nil {
+		// the table does not exist
+		return nil
+	}
+	res, err := executor.ExecuteSync(ctx, fmt.Sprintf("select table_name from %s where table_name = '%s'", ownershipTable, m.Table))
+	if err != nil {
+		return err
+	}
+	if len(res.Rows) == 0 {
+		return fmt.Errorf("table '%s' already exists and was not created by powerpipe - it is not replaced by %s", m.Table, m.Name)
+	}
+	return nil
+}
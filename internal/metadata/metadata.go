@@ -0,0 +1,365 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/pipe-fittings/workspace"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/db_client"
+	"golang.org/x/exp/maps"
+)
+
+// the tables of the metadata database (see db_client.MetadataDatabase), which expose the state of powerpipe itself:
+//   - powerpipe_mod: the workspace mod and its installed dependency mods
+//   - powerpipe_resource: the resources of the workspace, including those of dependency mods
+//   - powerpipe_control_run: the run history of each control which has been run, with the results of its last run
+//   - powerpipe_snapshot: the snapshots of the workspace
+const (
+	TableMod        = "powerpipe_mod"
+	TableResource   = "powerpipe_resource"
+	TableControlRun = "powerpipe_control_run"
+	TableSnapshot   = "powerpipe_snapshot"
+)
+
+// provider provides the metadata tables of a workspace
+type provider struct {
+	w *workspace.Workspace
+}
+
+// Provider returns a provider of the metadata tables of the workspace
+func Provider(w *workspace.Workspace) db_client.MetadataProvider {
+	return &provider{w: w}
+}
+
+// Version returns the version of the metadata - the loaded workspace mod (which is replaced when the workspace is
+// reloaded), and the modification time and size of the control history and of each snapshot file
+func (p *provider) Version() (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%p", p.w.Mod)
+
+	snapshots, err := snapshotPaths(p.w.Path, p.w.GetResourceMaps().Snapshots)
+	if err != nil {
+		return "", err
+	}
+	paths := append([]string{controlexecute.ControlHistoryPath()}, maps.Values(snapshots)...)
+	sort.Strings(paths[1:])
+	for _, path := range paths {
+		// (a file which does not exist is versioned as such)
+		if stat, err := os.Stat(path); err == nil {
+			fmt.Fprintf(&b, "\n%s %d %d", path, stat.ModTime().UnixNano(), stat.Size())
+		} else {
+			fmt.Fprintf(&b, "\n%s", path)
+		}
+	}
+	return b.String(), nil
+}
+
+// Tables returns the metadata tables of the workspace
+func (p *provider) Tables() ([]*db_client.MetadataTable, error) {
+	history, err := controlexecute.LoadControlHistory(controlexecute.ControlHistoryPath())
+	if err != nil {
+		return nil, err
+	}
+	resourceMaps := p.w.GetResourceMaps()
+	snapshots, err := snapshotTable(p.w.Path, resourceMaps.Snapshots)
+	if err != nil {
+		return nil, err
+	}
+	return []*db_client.MetadataTable{
+		modTable(p.w.Mod, resourceMaps.Mods),
+		resourceTable(resourceMaps),
+		controlRunTable(history),
+		snapshots,
+	}, nil
+}
+
+func modTable(workspaceMod *modconfig.Mod, mods map[string]*modconfig.Mod) *db_client.MetadataTable {
+	res := &db_client.MetadataTable{
+		Name: TableMod,
+		Columns: []db_client.MetadataColumn{
+			{Name: "name", Type: "TEXT"},
+			{Name: "title", Type: "TEXT"},
+			{Name: "description", Type: "TEXT"},
+			{Name: "version", Type: "TEXT"},
+			{Name: "dependency_name", Type: "TEXT"},
+			{Name: "dependency_path", Type: "TEXT"},
+			{Name: "location", Type: "TEXT"},
+			{Name: "is_workspace_mod", Type: "BOOLEAN"},
+		},
+	}
+
+	// the mods of the resource maps may include the workspace mod
+	added := make(map[*modconfig.Mod]struct{})
+	all := []*modconfig.Mod{workspaceMod}
+	for _, mod := range mods {
+		all = append(all, mod)
+	}
+	for _, mod := range all {
+		if _, ok := added[mod]; ok || mod == nil {
+			continue
+		}
+		added[mod] = struct{}{}
+		res.Rows = append(res.Rows, []any{
+			mod.ShortName,
+			nullIfEmpty(mod.GetTitle()),
+			nullIfEmpty(mod.GetDescription()),
+			modVersion(mod),
+			nullIfEmpty(mod.DependencyName),
+			nullIfNil(mod.DependencyPath),
+			nullIfEmpty(mod.ModPath),
+			mod == workspaceMod,
+		})
+	}
+	sortRows(res.Rows)
+	return res
+}
+
+// modVersion returns the installed version of a dependency mod - its version, or the branch, tag or path it was
+// installed from
+func modVersion(mod *modconfig.Mod) any {
+	v := mod.Version
+	switch {
+	case v == nil:
+		return nil
+	case v.Version != nil:
+		return v.Version.String()
+	case v.Branch != "":
+		return v.Branch
+	case v.Tag != "":
+		return v.Tag
+	default:
+		return nullIfEmpty(v.FilePath)
+	}
+}
+
+func resourceTable(resourceMaps *modconfig.ResourceMaps) *db_client.MetadataTable {
+	res := &db_client.MetadataTable{
+		Name: TableResource,
+		Columns: []db_client.MetadataColumn{
+			{Name: "name", Type: "TEXT"},
+			{Name: "resource_type", Type: "TEXT"},
+			{Name: "mod_name", Type: "TEXT"},
+			{Name: "title", Type: "TEXT"},
+			{Name: "description", Type: "TEXT"},
+			{Name: "tags", Type: "TEXT"},
+			{Name: "file_name", Type: "TEXT"},
+			{Name: "start_line_number", Type: "INTEGER"},
+			{Name: "end_line_number", Type: "INTEGER"},
+		},
+	}
+
+	_ = resourceMaps.WalkResources(func(resource modconfig.HclResource) (bool, error) {
+		// mods are in their own table
+		if resource.BlockType() == schema.BlockTypeMod {
+			return true, nil
+		}
+		var tags any
+		if resourceTags := resource.GetTags(); len(resourceTags) > 0 {
+			tagsJson, _ := json.Marshal(resourceTags)
+			tags = string(tagsJson)
+		}
+		declRange := resource.GetDeclRange()
+		modName, _, _ := strings.Cut(resource.Name(), ".")
+		res.Rows = append(res.Rows, []any{
+			resource.Name(),
+			resource.BlockType(),
+			modName,
+			nullIfEmpty(resource.GetTitle()),
+			nullIfEmpty(resource.GetDescription()),
+			tags,
+			nullIfEmpty(declRange.Filename),
+			declRange.Start.Line,
+			declRange.End.Line,
+		})
+		return true, nil
+	})
+	sortRows(res.Rows)
+	return res
+}
+
+func controlRunTable(history *controlexecute.ControlHistory) *db_client.MetadataTable {
+	res := &db_client.MetadataTable{
+		Name: TableControlRun,
+		Columns: []db_client.MetadataColumn{
+			{Name: "control_name", Type: "TEXT"},
+			{Name: "runs", Type: "INTEGER"},
+			{Name: "average_duration_ms", Type: "INTEGER"},
+			{Name: "last_run_time", Type: "TEXT"},
+			{Name: "last_ok", Type: "INTEGER"},
+			{Name: "last_alarm", Type: "INTEGER"},
+			{Name: "last_info", Type: "INTEGER"},
+			{Name: "last_skip", Type: "INTEGER"},
+			{Name: "last_error", Type: "INTEGER"},
+		},
+	}
+
+	for name, d := range history.Controls {
+		// the results of the last run are not known for runs recorded before they were added to the history
+		last := []any{nil, nil, nil, nil, nil}
+		if s := d.LastSummary; s != nil {
+			last = []any{s.Ok, s.Alarm, s.Info, s.Skip, s.Error}
+		}
+		res.Rows = append(res.Rows, append([]any{
+			name,
+			d.Runs,
+			d.Average.Milliseconds(),
+			formatTime(d.LastRun),
+		}, last...))
+	}
+	sortRows(res.Rows)
+	return res
+}
+
+// snapshotInfo is the metadata of a snapshot file, read from the snapshot
+type snapshotInfo struct {
+	modTime time.Time
+	size    int64
+
+	title     any
+	startTime any
+	endTime   any
+}
+
+// reading snapshots is slow, so the metadata of each snapshot file is cached until the file changes
+var (
+	snapshotInfoCache    = make(map[string]*snapshotInfo)
+	snapshotInfoCacheMut sync.Mutex
+)
+
+// snapshotPaths returns the paths of the snapshots of the workspace, keyed by name - the snapshot resources of the
+// workspace, and the snapshot files in the root of the workspace (named as snapshot resources are, e.g.
+// snapshot.weekly for weekly.pps)
+func snapshotPaths(workspacePath string, resourceSnapshotPaths map[string]string) (map[string]string, error) {
+	res := make(map[string]string, len(resourceSnapshotPaths))
+	for name, path := range resourceSnapshotPaths {
+		res[name] = path
+	}
+	if workspacePath != "" {
+		files, err := filepath.Glob(filepath.Join(workspacePath, "*"+localconstants.SnapshotExtension))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range files {
+			name := schema.ResourceTypeSnapshot + "." + strings.TrimSuffix(filepath.Base(path), localconstants.SnapshotExtension)
+			if _, ok := res[name]; !ok {
+				res[name] = path
+			}
+		}
+	}
+	return res, nil
+}
+
+// snapshotTable returns the snapshots of the workspace (see snapshotPaths)
+func snapshotTable(workspacePath string, resourceSnapshotPaths map[string]string) (*db_client.MetadataTable, error) {
+	res := &db_client.MetadataTable{
+		Name: TableSnapshot,
+		Columns: []db_client.MetadataColumn{
+			{Name: "name", Type: "TEXT"},
+			{Name: "file_name", Type: "TEXT"},
+			{Name: "title", Type: "TEXT"},
+			{Name: "start_time", Type: "TEXT"},
+			{Name: "end_time", Type: "TEXT"},
+			{Name: "size_bytes", Type: "INTEGER"},
+			{Name: "modified_time", Type: "TEXT"},
+		},
+	}
+
+	snapshots, err := snapshotPaths(workspacePath, resourceSnapshotPaths)
+	if err != nil {
+		return nil, err
+	}
+	for name, path := range snapshots {
+		info, err := readSnapshotInfo(path)
+		if err != nil {
+			// the snapshot may have been removed
+			continue
+		}
+		res.Rows = append(res.Rows, []any{
+			name,
+			path,
+			info.title,
+			info.startTime,
+			info.endTime,
+			info.size,
+			formatTime(info.modTime),
+		})
+	}
+	sortRows(res.Rows)
+	return res, nil
+}
+
+func readSnapshotInfo(path string) (*snapshotInfo, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotInfoCacheMut.Lock()
+	defer snapshotInfoCacheMut.Unlock()
+	if info, ok := snapshotInfoCache[path]; ok && info.modTime.Equal(stat.ModTime()) && info.size == stat.Size() {
+		return info, nil
+	}
+
+	info := &snapshotInfo{modTime: stat.ModTime(), size: stat.Size()}
+	// a file which cannot be parsed is still listed, without the details read from the snapshot
+	if data, err := os.ReadFile(path); err == nil {
+		var snap struct {
+			StartTime time.Time `json:"start_time"`
+			EndTime   time.Time `json:"end_time"`
+			Layout    *struct {
+				Name string `json:"name"`
+			} `json:"layout"`
+			Panels map[string]struct {
+				Title string `json:"title"`
+			} `json:"panels"`
+		}
+		if json.Unmarshal(data, &snap) == nil {
+			info.startTime = formatTime(snap.StartTime)
+			info.endTime = formatTime(snap.EndTime)
+			if snap.Layout != nil {
+				info.title = nullIfEmpty(snap.Panels[snap.Layout.Name].Title)
+			}
+		}
+	}
+	snapshotInfoCache[path] = info
+	return info, nil
+}
+
+// formatTime formats a time as an RFC3339 string (SQLite has no timestamp type) - the zero time is null
+func formatTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func nullIfNil(s *string) any {
+	if s == nil {
+		return nil
+	}
+	return nullIfEmpty(*s)
+}
+
+// sortRows sorts rows by their first column (the name), so the tables have a stable order
+func sortRows(rows [][]any) {
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i][0].(string) < rows[j][0].(string)
+	})
+}
@@ -0,0 +1,214 @@
+package modcache
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/filepaths"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// the mods installed into a workspace are cloned into the mod installation directory (.powerpipe/mods), with a
+// directory per mod version - versions which are no longer required by the lock file are left behind by installs
+// which do not prune, and interrupted installs leave behind their shadow directories (.powerpipe/.mods.<id>)
+//
+// 'cache info' reports the disk usage of each of these, and 'cache gc' removes unused mod versions and shadow directories
+// according to an age and size policy
+const (
+	KindMod    = "mod"
+	KindShadow = "shadow"
+
+	// shadow directories newer than this may belong to an install in progress, so are never removed
+	shadowMinAge = time.Hour
+)
+
+// Entry is a directory of the mod cache
+type Entry struct {
+	// the path relative to the workspace data directory
+	Path    string    `json:"path"`
+	Kind    string    `json:"kind"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	// whether the mod version is required by the workspace lock file
+	InUse bool `json:"in_use"`
+}
+
+// Policy is the policy applied by Collect
+type Policy struct {
+	// unused mod versions older than this are removed
+	MaxAge time.Duration
+	// if the cache is larger than this after removing old versions, unused mod versions are removed, oldest first,
+	// until it is not (zero for no limit)
+	MaxSize int64
+}
+
+// Scan returns the entries of the mod cache of the workspace, sorted by path
+func Scan(workspacePath string) ([]*Entry, error) {
+	referenced, err := referencedPaths(workspacePath)
+	if err != nil {
+		return nil, err
+	}
+	dataDir := filepath.Join(workspacePath, app_specific.WorkspaceDataDir)
+
+	var res []*Entry
+	dirEntries, err := os.ReadDir(dataDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for _, d := range dirEntries {
+		if d.IsDir() && filepaths.IsModInstallShadowPath(d.Name()) {
+			entry, err := newEntry(dataDir, d.Name(), KindShadow)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, entry)
+		}
+	}
+
+	modDir := filepaths.WorkspaceModPath(workspacePath)
+	err = filepath.WalkDir(modDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		// mod version directories are named <mod>@<version> or <mod>#<branch>
+		if !d.IsDir() || !strings.ContainsAny(d.Name(), "@#") {
+			return nil
+		}
+		rel, err := filepath.Rel(dataDir, path)
+		if err != nil {
+			return err
+		}
+		entry, err := newEntry(dataDir, rel, KindMod)
+		if err != nil {
+			return err
+		}
+		depPath, _ := filepath.Rel(modDir, path)
+		_, entry.InUse = referenced[filepath.ToSlash(depPath)]
+		res = append(res, entry)
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Path < res[j].Path })
+	return res, nil
+}
+
+func newEntry(dataDir, rel, kind string) (*Entry, error) {
+	path := filepath.Join(dataDir, rel)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	size, err := dirSize(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{Path: filepath.ToSlash(rel), Kind: kind, Size: size, ModTime: info.ModTime()}, nil
+}
+
+func dirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// referencedPaths returns the dependency paths of the mod versions in the workspace lock file
+func referencedPaths(workspacePath string) (map[string]struct{}, error) {
+	lock, err := versionmap.LoadWorkspaceLock(workspacePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the workspace lock file: %s", err.Error())
+	}
+	res := make(map[string]struct{})
+	for _, dep := range lock.InstallCache.FlatMap() {
+		res[dep.DependencyPath()] = struct{}{}
+	}
+	return res, nil
+}
+
+// Collect returns the entries which the policy removes - shadow directories of interrupted installs,
+// and unused mod versions older than the max age or which exceed the max size
+func Collect(entries []*Entry, policy Policy, now time.Time) []*Entry {
+	var res, unused []*Entry
+	var remainingSize int64
+	for _, e := range entries {
+		age := now.Sub(e.ModTime)
+		switch {
+		case e.Kind == KindShadow && age >= shadowMinAge:
+			res = append(res, e)
+		case e.Kind == KindMod && !e.InUse && age >= policy.MaxAge:
+			res = append(res, e)
+		default:
+			remainingSize += e.Size
+			if e.Kind == KindMod && !e.InUse {
+				unused = append(unused, e)
+			}
+		}
+	}
+	if policy.MaxSize <= 0 {
+		return res
+	}
+	// remove the oldest unused mod versions until the cache fits
+	sort.SliceStable(unused, func(i, j int) bool { return unused[i].ModTime.Before(unused[j].ModTime) })
+	for _, e := range unused {
+		if remainingSize <= policy.MaxSize {
+			break
+		}
+		res = append(res, e)
+		remainingSize -= e.Size
+	}
+	return res
+}
+
+// Remove removes the entries from the mod cache of the workspace
+func Remove(workspacePath string, entries []*Entry) error {
+	dataDir := filepath.Join(workspacePath, app_specific.WorkspaceDataDir)
+	for _, e := range entries {
+		if err := os.RemoveAll(filepath.Join(dataDir, filepath.FromSlash(e.Path))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseAge parses a max age, which is a duration, or a number of days, e.g. '30d'
+func ParseAge(age string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(age, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age '%s'", age)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(age)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age '%s'", age)
+	}
+	return d, nil
+}
@@ -0,0 +1,115 @@
+package modcache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turbot/pipe-fittings/app_specific"
+)
+
+var testNow = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func testEntry(path, kind string, size int64, age time.Duration, inUse bool) *Entry {
+	return &Entry{Path: path, Kind: kind, Size: size, ModTime: testNow.Add(-age), InUse: inUse}
+}
+
+var testEntries = []*Entry{
+	testEntry(".mods.abc", KindShadow, 100, 2*time.Hour, false),
+	testEntry(".mods.def", KindShadow, 100, time.Minute, false),
+	testEntry("mods/github.com/turbot/a@v1.0.0", KindMod, 1000, 60*24*time.Hour, true),
+	testEntry("mods/github.com/turbot/b@v1.0.0", KindMod, 500, 40*24*time.Hour, false),
+	testEntry("mods/github.com/turbot/b@v2.0.0", KindMod, 500, 10*24*time.Hour, false),
+	testEntry("mods/github.com/turbot/c@v1.0.0", KindMod, 500, 1*24*time.Hour, false),
+}
+
+type collectTest struct {
+	policy   Policy
+	expected []string
+}
+
+var testCasesCollect = map[string]collectTest{
+	"all unused": {
+		expected: []string{".mods.abc", "mods/github.com/turbot/b@v1.0.0", "mods/github.com/turbot/b@v2.0.0", "mods/github.com/turbot/c@v1.0.0"},
+	},
+	"max age": {
+		policy:   Policy{MaxAge: 30 * 24 * time.Hour},
+		expected: []string{".mods.abc", "mods/github.com/turbot/b@v1.0.0"},
+	},
+	"max age and size": {
+		policy:   Policy{MaxAge: 30 * 24 * time.Hour, MaxSize: 1700},
+		expected: []string{".mods.abc", "mods/github.com/turbot/b@v1.0.0", "mods/github.com/turbot/b@v2.0.0"},
+	},
+	"size below in use": {
+		policy:   Policy{MaxAge: 365 * 24 * time.Hour, MaxSize: 10},
+		expected: []string{".mods.abc", "mods/github.com/turbot/b@v1.0.0", "mods/github.com/turbot/b@v2.0.0", "mods/github.com/turbot/c@v1.0.0"},
+	},
+}
+
+func TestCollect(t *testing.T) {
+	for name, test := range testCasesCollect {
+		var actual []string
+		for _, e := range Collect(testEntries, test.policy, testNow) {
+			actual = append(actual, e.Path)
+		}
+		if strings.Join(actual, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	app_specific.WorkspaceDataDir = ".powerpipe"
+	workspace := t.TempDir()
+	lock := `{"local": {"github.com/turbot/a": {"name": "github.com/turbot/a", "version": "1.0.0", "commit": "abc", "alias": "a"}}}`
+	files := map[string]string{
+		".mod.cache.json": lock,
+		".powerpipe/mods/github.com/turbot/a@v1.0.0/mod.pp":       "mod \"a\" {}",
+		".powerpipe/mods/github.com/turbot/b@v1.0.0/mod.pp":       "mod \"b\" {}",
+		".powerpipe/mods/github.com/turbot/b@v1.0.0/queries.pp":   "1234567890",
+		".powerpipe/.mods.1234/github.com/turbot/c@v1.0.0/mod.pp": "mod \"c\" {}",
+	}
+	for path, content := range files {
+		path = filepath.Join(workspace, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := Scan(workspace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual []string
+	for _, e := range entries {
+		actual = append(actual, fmt.Sprintf("%s %s %d %t", e.Path, e.Kind, e.Size, e.InUse))
+	}
+	expected := []string{
+		".mods.1234 shadow 10 false",
+		"mods/github.com/turbot/a@v1.0.0 mod 10 true",
+		"mods/github.com/turbot/b@v1.0.0 mod 20 false",
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Test: 'scan' FAILED : expected %v, got %v", expected, actual)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	for age, expected := range map[string]time.Duration{"30d": 30 * 24 * time.Hour, "72h": 72 * time.Hour, "0s": 0} {
+		actual, err := ParseAge(age)
+		if err != nil || actual != expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s (%v)", age, expected, actual, err)
+		}
+	}
+	for _, age := range []string{"xd", "-1h", "soon"} {
+		if _, err := ParseAge(age); err == nil {
+			t.Errorf("Test: '%s' FAILED : expected error, got none", age)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package modsource
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/app_specific"
+)
+
+// mods may be installed from a local directory or archive, so they can be installed without network access, e.g.
+//
+//	powerpipe mod install ./vendor/mods/aws-compliance
+//	powerpipe mod install ./vendor/mods/aws-compliance.tar.gz
+//
+// directories are installed in place, as a path dependency - archives (.tar.gz, .tgz or .tar) are extracted to
+// .powerpipe/local_mods/<archive name> in the workspace and installed from there, and installing an archive again
+// replaces the extracted mod
+// an archive may contain the mod files at the top level, or in a single top level directory (as in the source archives
+// of git hosts)
+// (local mods are kept out of .powerpipe/mods, which holds only the mods installed by the mod installer)
+const localModsDir = "local_mods"
+
+var modArchiveExtensions = []string{".tar.gz", ".tgz", ".tar"}
+
+// LocalModArgs converts the local paths of the mod arguments of a mod install into absolute paths (local paths are
+// otherwise resolved relative to the workspace) - archives are extracted into the workspace, and the path of the
+// extracted mod returned
+func LocalModArgs(args []string, workspacePath string) ([]string, error) {
+	res := make([]string, len(args))
+	for i, arg := range args {
+		res[i] = arg
+		info, err := os.Stat(arg)
+		if err != nil {
+			continue
+		}
+		absPath, err := filepath.Abs(arg)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = absPath
+		if info.IsDir() {
+			continue
+		}
+		if archiveName(absPath) == "" {
+			return nil, fmt.Errorf("'%s' is not a mod directory or archive - mod archives must be %s files", arg, strings.Join(modArchiveExtensions, ", "))
+		}
+		if res[i], err = extractModArchive(absPath, workspacePath); err != nil {
+			return nil, fmt.Errorf("failed to extract mod archive '%s': %s", arg, err.Error())
+		}
+	}
+	return res, nil
+}
+
+// archiveName returns the name of the archive, without its extension, or an empty string if the path
+// is not a mod archive
+func archiveName(archivePath string) string {
+	base := filepath.Base(archivePath)
+	for _, ext := range modArchiveExtensions {
+		if strings.HasSuffix(strings.ToLower(base), ext) {
+			return base[:len(base)-len(ext)]
+		}
+	}
+	return ""
+}
+
+// extractModArchive extracts the archive into the local mods directory of the workspace, returning the path of the mod
+func extractModArchive(archivePath, workspacePath string) (string, error) {
+	parent := filepath.Join(workspacePath, app_specific.WorkspaceDataDir, localModsDir)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return "", err
+	}
+	// extract to a temporary directory, so a failed extract leaves any previously extracted mod in place
+	tmpDir, err := os.MkdirTemp(parent, ".extract-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := extractArchive(archivePath, tmpDir); err != nil {
+		return "", err
+	}
+	modDir, err := archiveModDir(tmpDir)
+	if err != nil {
+		return "", err
+	}
+
+	dest := filepath.Join(parent, archiveName(archivePath))
+	if err := os.RemoveAll(dest); err != nil {
+		return "", err
+	}
+	if err := os.Rename(modDir, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// archiveModDir returns the directory of the extracted archive which contains the mod definition
+func archiveModDir(dir string) (string, error) {
+	if hasModFile(dir) {
+		return dir, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() && hasModFile(filepath.Join(dir, entries[0].Name())) {
+		return filepath.Join(dir, entries[0].Name()), nil
+	}
+	return "", errors.New("the archive does not contain a mod definition")
+}
+
+func hasModFile(dir string) bool {
+	for _, modFilePath := range app_specific.ModFilePaths(dir) {
+		if _, err := os.Stat(modFilePath); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// extractArchive extracts the regular files and directories of the (optionally gzipped) tar archive into dest
+func extractArchive(archivePath, dest string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if !strings.HasSuffix(strings.ToLower(archivePath), ".tar") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// entries must be within the archive
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("invalid archive entry '%s'", header.Name)
+		}
+		target := filepath.Join(dest, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := extractFile(tr, target, header.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		}
+		// links and other entry types are skipped
+	}
+}
+
+func extractFile(r io.Reader, target string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm|0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil { //nolint:gosec // mod archives are provided by the user
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,116 @@
+package modsource
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/app_specific"
+)
+
+type localModArgsTest struct {
+	// the archive to create, mapping each entry name to its content
+	archive     map[string]string
+	archiveName string
+	// the expected path of the extracted mod, relative to the workspace
+	expected    string
+	expectError bool
+}
+
+var testCasesLocalModArgs = map[string]localModArgsTest{
+	"top level mod": {
+		archive:     map[string]string{"mod.pp": `mod "compliance" {}`, "controls/c.pp": ""},
+		archiveName: "compliance.tar.gz",
+		expected:    ".powerpipe/local_mods/compliance",
+	},
+	"mod in top level directory": {
+		archive:     map[string]string{"compliance-1.0/mod.pp": `mod "compliance" {}`},
+		archiveName: "compliance.tgz",
+		expected:    ".powerpipe/local_mods/compliance",
+	},
+	"uncompressed": {
+		archive:     map[string]string{"mod.pp": `mod "compliance" {}`},
+		archiveName: "compliance-1.0.tar",
+		expected:    ".powerpipe/local_mods/compliance-1.0",
+	},
+	"no mod definition": {
+		archive:     map[string]string{"a/mod.pp": "", "b/c.pp": ""},
+		archiveName: "compliance.tar.gz",
+		expectError: true,
+	},
+	"entry outside archive": {
+		archive:     map[string]string{"mod.pp": `mod "compliance" {}`, "../evil.pp": ""},
+		archiveName: "compliance.tar.gz",
+		expectError: true,
+	},
+	"not an archive": {
+		archive:     map[string]string{"mod.pp": `mod "compliance" {}`},
+		archiveName: "compliance.zip",
+		expectError: true,
+	},
+}
+
+func TestLocalModArgs(t *testing.T) {
+	// (app specific values are set when the CLI starts)
+	app_specific.ModDataExtensions = []string{".pp", ".sp"}
+	app_specific.WorkspaceDataDir = ".powerpipe"
+	for name, test := range testCasesLocalModArgs {
+		dir, workspace := t.TempDir(), t.TempDir()
+		archivePath := filepath.Join(dir, test.archiveName)
+		writeTestArchive(t, archivePath, test.archive)
+
+		res, err := LocalModArgs([]string{archivePath, dir, "github.com/turbot/steampipe-mod-aws-compliance@^1"}, workspace)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error, got none", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %v", name, err)
+			continue
+		}
+		expected := []string{filepath.Join(workspace, test.expected), dir, "github.com/turbot/steampipe-mod-aws-compliance@^1"}
+		for i := range expected {
+			if res[i] != expected[i] {
+				t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, expected[i], res[i])
+			}
+		}
+		if !hasModFile(res[0]) {
+			t.Errorf("Test: '%s' FAILED : expected the mod to be extracted to %s", name, res[0])
+		}
+	}
+}
+
+func writeTestArchive(t *testing.T, archivePath string, entries map[string]string) {
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var gz *gzip.Writer
+	tw := tar.NewWriter(f)
+	if filepath.Ext(archivePath) != ".tar" {
+		gz = gzip.NewWriter(f)
+		tw = tar.NewWriter(gz)
+	}
+	for name, content := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
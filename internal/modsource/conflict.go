@@ -0,0 +1,278 @@
+package modsource
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/parse"
+	"github.com/turbot/pipe-fittings/versionhelpers"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// the installer fails with a bare 'no version of <mod> found satisfying version constraint' error when a version
+// constraint cannot be satisfied - ExplainInstallError adds an explanation of which mods require which versions of the mod,
+// the versions which are available, and suggested constraint changes
+var conflictPattern = regexp.MustCompile(`no version of (\S+) found satisfying version constraint: (\S+)`)
+
+// the maximum number of available versions listed in a conflict explanation
+const maxListedVersions = 10
+
+// ModRequirement is a version constraint of a mod on a dependency
+type ModRequirement struct {
+	// the mod declaring the requirement
+	Parent     string
+	Constraint string
+	// whether the requirement is declared by the workspace mod (so may be changed by the user)
+	Workspace bool
+}
+
+// Conflict is a version constraint of a mod which cannot be satisfied
+type Conflict struct {
+	Name       string
+	Constraint string
+	// all requirements of the mod (which may be satisfiable)
+	Requirements []ModRequirement
+	// the available versions of the mod, highest first
+	Available []*semver.Version
+}
+
+// listModVersions lists the available versions of a mod - a var so it may be replaced in tests
+var listModVersions = listModVersionsFromGit
+
+// ParseConflicts returns a conflict for each unsatisfiable version constraint in the install error
+func ParseConflicts(err error) []*Conflict {
+	if err == nil {
+		return nil
+	}
+	var res []*Conflict
+	seen := make(map[string]struct{})
+	for _, match := range conflictPattern.FindAllStringSubmatch(err.Error(), -1) {
+		key := match[1] + "@" + match[2]
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		res = append(res, &Conflict{Name: match[1], Constraint: match[2]})
+	}
+	return res
+}
+
+// ExplainInstallError returns the install error with an explanation of each unsatisfiable version constraint
+// - if the error is not due to unsatisfiable constraints, it is returned unchanged
+func ExplainInstallError(err error, workspaceMod *modconfig.Mod) error {
+	conflicts := ParseConflicts(err)
+	if len(conflicts) == 0 {
+		return err
+	}
+	requirements := collectRequirements(workspaceMod)
+
+	explanations := []string{err.Error()}
+	for _, c := range conflicts {
+		c.Requirements = requirements[c.Name]
+		if !c.hasRequirement(c.Constraint) {
+			// the constraint is declared by a dependency which is being installed
+			c.Requirements = append(c.Requirements, ModRequirement{Parent: "a dependency being installed", Constraint: c.Constraint})
+		}
+		available, listErr := listModVersions(c.Name)
+		if listErr != nil {
+			slog.Debug("could not list mod versions", "mod", c.Name, "error", listErr)
+		}
+		c.Available = available
+		explanations = append(explanations, c.Explain())
+	}
+	return fmt.Errorf("%s", strings.Join(explanations, "\n\n"))
+}
+
+func (c *Conflict) hasRequirement(constraint string) bool {
+	for _, r := range c.Requirements {
+		if r.Constraint == constraint {
+			return true
+		}
+	}
+	return false
+}
+
+// Explain returns a description of the requirements of the mod, the available versions and suggested constraint changes
+func (c *Conflict) Explain() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("no version of %s satisfies %s\n", c.Name, c.Constraint))
+
+	b.WriteString("requirements:\n")
+	for _, r := range c.Requirements {
+		status := "no available version"
+		if v := c.highestSatisfying(r.Constraint); v != nil {
+			status = "satisfied by v" + v.String()
+		}
+		b.WriteString(fmt.Sprintf("  %s requires %s (%s)\n", r.Parent, r.Constraint, status))
+	}
+
+	if len(c.Available) == 0 {
+		b.WriteString("no versions are available\n")
+	} else {
+		var versions []string
+		for i, v := range c.Available {
+			if i == maxListedVersions {
+				versions = append(versions, fmt.Sprintf("and %d more", len(c.Available)-maxListedVersions))
+				break
+			}
+			versions = append(versions, "v"+v.String())
+		}
+		b.WriteString(fmt.Sprintf("available versions: %s\n", strings.Join(versions, ", ")))
+	}
+
+	b.WriteString("suggestions:")
+	for _, s := range c.Suggestions() {
+		b.WriteString("\n  " + s)
+	}
+	return b.String()
+}
+
+// Suggestions returns the constraint changes which would allow the mod to be installed
+func (c *Conflict) Suggestions() []string {
+	if len(c.Available) == 0 {
+		return []string{fmt.Sprintf("check the name of %s, and that the repository is accessible (set %s to access private repositories)", c.Name, app_specific.EnvGitToken)}
+	}
+
+	// target the highest version satisfying all of the satisfiable requirements, or failing that the latest version
+	target := c.Available[0]
+	for _, v := range c.Available {
+		if c.satisfiesAllSatisfiable(v) {
+			target = v
+			break
+		}
+	}
+	suggested := fmt.Sprintf("^%d.%d", target.Major(), target.Minor())
+
+	var res []string
+	for _, r := range c.Requirements {
+		if c.highestSatisfying(r.Constraint) != nil {
+			continue
+		}
+		if r.Workspace {
+			res = append(res, fmt.Sprintf("change the version constraint of %s in %s from %s to %s (allowing v%s)", c.Name, r.Parent, r.Constraint, suggested, target))
+		} else {
+			res = append(res, fmt.Sprintf("%s requires %s %s - update it to a version compatible with %s v%s, or ask its maintainers to relax the constraint", r.Parent, c.Name, r.Constraint, c.Name, target))
+		}
+	}
+	if len(res) == 0 {
+		res = append(res, fmt.Sprintf("require %s %s", c.Name, suggested))
+	}
+	return res
+}
+
+// highestSatisfying returns the highest available version satisfying the constraint, or nil if there is none
+// (pre-release versions only satisfy pre-release constraints, as when installing)
+func (c *Conflict) highestSatisfying(constraint string) *semver.Version {
+	parsed, err := versionhelpers.NewConstraint(constraint)
+	if err != nil {
+		return nil
+	}
+	for _, v := range c.Available {
+		if v.Prerelease() != "" && !parsed.IsPrerelease() {
+			continue
+		}
+		if parsed.Check(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+// satisfiesAllSatisfiable returns whether the version satisfies every requirement which can be satisfied
+func (c *Conflict) satisfiesAllSatisfiable(v *semver.Version) bool {
+	if v.Prerelease() != "" {
+		return false
+	}
+	for _, r := range c.Requirements {
+		if c.highestSatisfying(r.Constraint) == nil {
+			continue
+		}
+		parsed, err := versionhelpers.NewConstraint(r.Constraint)
+		if err != nil || !parsed.Check(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// collectRequirements returns the version constraints of the workspace mod and its installed dependencies, keyed by mod name
+func collectRequirements(workspaceMod *modconfig.Mod) map[string][]ModRequirement {
+	res := make(map[string][]ModRequirement)
+	addRequirements := func(mod *modconfig.Mod, parent string, workspace bool) {
+		if mod == nil || mod.Require == nil {
+			return
+		}
+		for _, m := range mod.Require.Mods {
+			if m.VersionString == "" {
+				continue
+			}
+			res[m.Name] = append(res[m.Name], ModRequirement{Parent: parent, Constraint: m.VersionString, Workspace: workspace})
+		}
+	}
+	modFileName := app_specific.DefaultModFileName()
+	if workspaceMod.FilePath() != "" {
+		modFileName = filepath.Base(workspaceMod.FilePath())
+	}
+	addRequirements(workspaceMod, modFileName, true)
+
+	lock, err := versionmap.LoadWorkspaceLock(workspaceMod.ModPath)
+	if err != nil {
+		slog.Debug("could not load the workspace lock", "error", err)
+		return res
+	}
+	for _, dep := range lock.InstallCache.FlatMap() {
+		depPath := dep.DependencyPath()
+		mod, err := parse.LoadModfile(filepath.Join(lock.ModInstallationPath, depPath))
+		if err != nil {
+			slog.Debug("could not load dependency mod", "mod", depPath, "error", err)
+			continue
+		}
+		addRequirements(mod, depPath, false)
+	}
+	for _, requirements := range res {
+		sort.SliceStable(requirements, func(i, j int) bool {
+			return requirements[i].Workspace && !requirements[j].Workspace || requirements[i].Workspace == requirements[j].Workspace && requirements[i].Parent < requirements[j].Parent
+		})
+	}
+	return res
+}
+
+// listModVersionsFromGit returns the versions of the mod tagged in its git repository, highest first
+func listModVersionsFromGit(modName string) ([]*semver.Version, error) {
+	InstallGitAuth()
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{"https://" + modName},
+	})
+	var opts git.ListOptions
+	if token := os.Getenv(app_specific.EnvGitToken); token != "" {
+		opts.Auth = &githttp.BasicAuth{Username: token}
+	}
+	refs, err := remote.List(&opts)
+	if err != nil {
+		return nil, err
+	}
+	var res []*semver.Version
+	for _, ref := range refs {
+		if !ref.Name().IsTag() {
+			continue
+		}
+		if v, err := semver.NewVersion(ref.Name().Short()); err == nil {
+			res = append(res, v)
+		}
+	}
+	sort.Sort(sort.Reverse(semver.Collection(res)))
+	return res, nil
+}
@@ -0,0 +1,89 @@
+package modsource
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/turbot/pipe-fittings/app_specific"
+)
+
+type conflictSuggestionsTest struct {
+	requirements []ModRequirement
+	available    []string
+	expected     []string
+}
+
+var testCasesConflictSuggestions = map[string]conflictSuggestionsTest{
+	"workspace constraint too high": {
+		requirements: []ModRequirement{
+			{Parent: "mod.pp", Constraint: "^3.0", Workspace: true},
+		},
+		available: []string{"2.1.0", "2.0.0", "1.4.2"},
+		expected: []string{
+			"change the version constraint of github.com/turbot/x in mod.pp from ^3.0 to ^2.1 (allowing v2.1.0)",
+		},
+	},
+	"compatible with other requirement": {
+		requirements: []ModRequirement{
+			{Parent: "mod.pp", Constraint: "^3.0", Workspace: true},
+			{Parent: "github.com/turbot/y@v1.0.0", Constraint: "^1.2"},
+		},
+		available: []string{"2.1.0", "1.4.2", "1.2.0"},
+		expected: []string{
+			"change the version constraint of github.com/turbot/x in mod.pp from ^3.0 to ^1.4 (allowing v1.4.2)",
+		},
+	},
+	"dependency constraint": {
+		requirements: []ModRequirement{
+			{Parent: "mod.pp", Constraint: "^2.0", Workspace: true},
+			{Parent: "github.com/turbot/y@v1.0.0", Constraint: "^4.0"},
+		},
+		available: []string{"2.1.0", "3.0.0-rc.1"},
+		expected: []string{
+			"github.com/turbot/y@v1.0.0 requires github.com/turbot/x ^4.0 - update it to a version compatible with github.com/turbot/x v2.1.0, or ask its maintainers to relax the constraint",
+		},
+	},
+	"no versions": {
+		requirements: []ModRequirement{
+			{Parent: "mod.pp", Constraint: "^1.0", Workspace: true},
+		},
+		expected: []string{
+			"check the name of github.com/turbot/x, and that the repository is accessible (set POWERPIPE_GIT_TOKEN to access private repositories)",
+		},
+	},
+}
+
+func TestConflictSuggestions(t *testing.T) {
+	// (app specific values are set when the CLI starts)
+	app_specific.EnvGitToken = "POWERPIPE_GIT_TOKEN"
+	for name, test := range testCasesConflictSuggestions {
+		c := &Conflict{Name: "github.com/turbot/x", Requirements: test.requirements}
+		for _, v := range test.available {
+			c.Available = append(c.Available, semver.MustParse(v))
+		}
+		actual := c.Suggestions()
+		if strings.Join(actual, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
+
+func TestParseConflicts(t *testing.T) {
+	err := errors.New(`2 dependencies failed to install
+	no version of github.com/turbot/x found satisfying version constraint: ^3.0
+	no version of github.com/turbot/x found satisfying version constraint: ^3.0
+	no version of github.com/turbot/z found satisfying version constraint: >=1.0`)
+	var actual []string
+	for _, c := range ParseConflicts(err) {
+		actual = append(actual, c.Name+" "+c.Constraint)
+	}
+	expected := "github.com/turbot/x ^3.0,github.com/turbot/z >=1.0"
+	if strings.Join(actual, ",") != expected {
+		t.Errorf("Test: 'parse' FAILED : expected '%s', got '%s'", expected, strings.Join(actual, ","))
+	}
+	if conflicts := ParseConflicts(errors.New("tag v1 not found for mod github.com/turbot/x")); len(conflicts) != 0 {
+		t.Errorf("Test: 'not a conflict' FAILED : expected no conflicts, got %d", len(conflicts))
+	}
+}
@@ -0,0 +1,278 @@
+package modsource
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+// mods may be installed from private repositories using the credentials configured for their host, or for the
+// repository, in a credentials file set with POWERPIPE_GIT_CREDENTIALS - each line is of the form
+// '<host>[/<repository path prefix>] <method> [<args>]', e.g.
+//
+//	# private mods of the acme organisation
+//	github.com/acme                  token ${ACME_GITHUB_TOKEN}
+//	# a repository with a deploy key
+//	github.com/acme/powerpipe-mod-x  ssh-key ~/.ssh/powerpipe_mod_x
+//	gitlab.acme.internal             basic deploy-bot ${GITLAB_DEPLOY_PASSWORD}
+//	git.acme.internal                ssh-agent
+//
+// the most specific matching line is used, and environment variables are expanded, so secrets need not be written
+// to the file - the methods are:
+//
+//	token <token>                         - a personal access token, sent over https
+//	basic <username> <password>           - a username and password, sent over https
+//	ssh-key <private key path> [<user>]   - clone over ssh using the key (e.g. a deploy key) - keys protected by a
+//	                                        passphrase must instead be added to the ssh agent
+//	ssh-agent [<user>]                    - clone over ssh using the keys of the ssh agent
+//
+// repositories using ssh are cloned as git@<host>/<path> (unless a user is given), and the host key is verified using
+// the known_hosts file (or the files listed in SSH_KNOWN_HOSTS)
+// POWERPIPE_GIT_TOKEN is used for hosts with no credentials, as before
+const (
+	CredentialToken    = "token"
+	CredentialBasic    = "basic"
+	CredentialSSHKey   = "ssh-key"
+	CredentialSSHAgent = "ssh-agent"
+)
+
+// the username sent with a token for hosts which require a specific username, for any other host the token is sent
+// as the password of the oauth2 user (as GitLab requires)
+var tokenUsernames = map[string]string{
+	"github.com":    "x-access-token",
+	HostBitbucket:   "x-token-auth",
+	HostAzureDevOps: "pat",
+}
+
+// GitCredential is the credential used for the repositories of a host, or of a path prefix of a host
+type GitCredential struct {
+	Host     string
+	Path     string
+	Method   string
+	Username string
+	Password string
+	KeyPath  string
+}
+
+func (c *GitCredential) isSSH() bool {
+	return c.Method == CredentialSSHKey || c.Method == CredentialSSHAgent
+}
+
+// matches returns whether the credential is for the repository (or a url within the repository)
+func (c *GitCredential) matches(host, repoPath string) bool {
+	if !strings.EqualFold(c.Host, host) {
+		return false
+	}
+	if c.Path == "" {
+		return true
+	}
+	repoPath = strings.TrimPrefix(repoPath, "/")
+	for _, prefix := range []string{c.Path, c.Path + ".git"} {
+		if repoPath == prefix || strings.HasPrefix(repoPath, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sshAuth returns the ssh auth method of an ssh credential
+func (c *GitCredential) sshAuth() (transport.AuthMethod, error) {
+	if c.Method == CredentialSSHAgent {
+		return gitssh.NewSSHAgentAuth(c.Username)
+	}
+	auth, err := gitssh.NewPublicKeysFromFile(c.Username, c.KeyPath, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the ssh key for %s from %s: %s", c.Host, c.KeyPath, err.Error())
+	}
+	return auth, nil
+}
+
+// GitCredentials is the credentials of a credentials file, most specific first
+type GitCredentials []*GitCredential
+
+// LoadGitCredentials loads the credentials file at the given path - if the path is empty, nil is returned
+func LoadGitCredentials(path string) (GitCredentials, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load git credentials: %s", err.Error())
+	}
+	creds, err := ParseGitCredentials(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load git credentials from %s: %s", path, err.Error())
+	}
+	return creds, nil
+}
+
+// ParseGitCredentials parses the contents of a credentials file
+func ParseGitCredentials(data []byte) (GitCredentials, error) {
+	var res GitCredentials
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		for i := range fields {
+			fields[i] = os.ExpandEnv(fields[i])
+		}
+		cred, err := parseGitCredential(fields)
+		if err != nil {
+			return nil, fmt.Errorf("invalid credential on line %d: %s", lineNumber, err.Error())
+		}
+		res = append(res, cred)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// the most specific credential is used, so sort longer paths first
+	sort.SliceStable(res, func(i, j int) bool { return len(res[i].Path) > len(res[j].Path) })
+	return res, nil
+}
+
+func parseGitCredential(fields []string) (*GitCredential, error) {
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("expected '<host>[/<path>] <method> [<args>]'")
+	}
+	// (the host may be given as a url)
+	prefix := fields[0]
+	if _, rest, ok := strings.Cut(prefix, "://"); ok {
+		prefix = rest
+	}
+	host, path, _ := strings.Cut(strings.TrimSuffix(strings.TrimSuffix(prefix, "/"), ".git"), "/")
+	if host == "" {
+		return nil, fmt.Errorf("expected '<host>[/<path>] <method> [<args>]'")
+	}
+	cred := &GitCredential{Host: host, Path: path, Method: fields[1]}
+	args := fields[2:]
+
+	switch cred.Method {
+	case CredentialToken:
+		if len(args) != 1 || args[0] == "" {
+			return nil, fmt.Errorf("expected '%s <token>'", CredentialToken)
+		}
+		cred.Username, cred.Password = tokenUsername(host), args[0]
+	case CredentialBasic:
+		if len(args) != 2 || args[0] == "" {
+			return nil, fmt.Errorf("expected '%s <username> <password>'", CredentialBasic)
+		}
+		cred.Username, cred.Password = args[0], args[1]
+	case CredentialSSHKey:
+		if len(args) < 1 || len(args) > 2 {
+			return nil, fmt.Errorf("expected '%s <private key path> [<user>]'", CredentialSSHKey)
+		}
+		keyPath, err := expandHome(args[0])
+		if err != nil {
+			return nil, err
+		}
+		cred.KeyPath = keyPath
+		cred.Username = sshUser(args[1:])
+	case CredentialSSHAgent:
+		if len(args) > 1 {
+			return nil, fmt.Errorf("expected '%s [<user>]'", CredentialSSHAgent)
+		}
+		cred.Username = sshUser(args)
+	default:
+		return nil, fmt.Errorf("unknown method '%s' - expected one of %s", cred.Method, strings.Join([]string{CredentialToken, CredentialBasic, CredentialSSHKey, CredentialSSHAgent}, ", "))
+	}
+	return cred, nil
+}
+
+func tokenUsername(host string) string {
+	if username, ok := tokenUsernames[strings.ToLower(host)]; ok {
+		return username
+	}
+	return "oauth2"
+}
+
+func sshUser(args []string) string {
+	if len(args) > 0 && args[0] != "" {
+		return args[0]
+	}
+	return gitssh.DefaultUsername
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// lookup returns the most specific credential for the repository, or nil if there is none
+func (c GitCredentials) lookup(host, repoPath string) *GitCredential {
+	for _, cred := range c {
+		if cred.matches(host, repoPath) {
+			return cred
+		}
+	}
+	return nil
+}
+
+// gitCredentialsTransport clones repositories with ssh credentials over ssh, and removes any https auth passed
+// to ssh sessions (the installer passes POWERPIPE_GIT_TOKEN to ssh sessions as well as https sessions)
+type gitCredentialsTransport struct {
+	creds GitCredentials
+	// the error loading the credentials, returned by each session so the install fails
+	err  error
+	base transport.Transport
+	ssh  transport.Transport
+}
+
+func (t *gitCredentialsTransport) NewUploadPackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.UploadPackSession, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	if cred := t.creds.lookup(ep.Host, ep.Path); cred != nil && cred.isSSH() {
+		sshAuth, err := cred.sshAuth()
+		if err != nil {
+			return nil, err
+		}
+		sshEp := &transport.Endpoint{Protocol: "ssh", User: cred.Username, Host: ep.Host, Path: ep.Path}
+		if ep.Protocol == "ssh" {
+			sshEp.Port = ep.Port
+		}
+		return t.ssh.NewUploadPackSession(sshEp, sshAuth)
+	}
+	if _, ok := auth.(gitssh.AuthMethod); ep.Protocol == "ssh" && !ok {
+		// (with no auth, the keys of the ssh agent are used)
+		auth = nil
+	}
+	return t.base.NewUploadPackSession(ep, auth)
+}
+
+func (t *gitCredentialsTransport) NewReceivePackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.ReceivePackSession, error) {
+	return t.base.NewReceivePackSession(ep, auth)
+}
+
+// gitCredentialsAuthTransport adds the token or basic auth credentials of the host (or repository) to https requests -
+// these replace any other credentials, including GITLAB_TOKEN and POWERPIPE_GIT_TOKEN
+type gitCredentialsAuthTransport struct {
+	creds GitCredentials
+	base  http.RoundTripper
+}
+
+func (t *gitCredentialsAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if cred := t.creds.lookup(req.URL.Hostname(), req.URL.Path); cred != nil && !cred.isSSH() {
+		req = req.Clone(req.Context())
+		req.SetBasicAuth(cred.Username, cred.Password)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,272 @@
+package modsource
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+type parseGitCredentialsTest struct {
+	data        string
+	expected    GitCredentials
+	expectError bool
+}
+
+var testCasesParseGitCredentials = map[string]parseGitCredentialsTest{
+	"token": {
+		data: "# comment\n\ngithub.com/acme token ${TEST_GIT_TOKEN}\ngitlab.acme.internal token glpat-1",
+		expected: GitCredentials{
+			{Host: "github.com", Path: "acme", Method: CredentialToken, Username: "x-access-token", Password: "secret"},
+			{Host: "gitlab.acme.internal", Method: CredentialToken, Username: "oauth2", Password: "glpat-1"},
+		},
+	},
+	"basic": {
+		data:     "https://bitbucket.org/acme/repo.git basic deploy-bot pass",
+		expected: GitCredentials{{Host: "bitbucket.org", Path: "acme/repo", Method: CredentialBasic, Username: "deploy-bot", Password: "pass"}},
+	},
+	"ssh": {
+		data: "git.acme.internal ssh-agent\ngithub.com/acme/mod ssh-key /keys/deploy\ngithub.com/acme ssh-key /keys/acme acme-bot",
+		expected: GitCredentials{
+			{Host: "github.com", Path: "acme/mod", Method: CredentialSSHKey, Username: "git", KeyPath: "/keys/deploy"},
+			{Host: "github.com", Path: "acme", Method: CredentialSSHKey, Username: "acme-bot", KeyPath: "/keys/acme"},
+			{Host: "git.acme.internal", Method: CredentialSSHAgent, Username: "git"},
+		},
+	},
+	"unknown method": {
+		data:        "github.com password secret",
+		expectError: true,
+	},
+	"missing token": {
+		data:        "github.com token",
+		expectError: true,
+	},
+	"missing password": {
+		data:        "github.com basic user",
+		expectError: true,
+	},
+	"missing method": {
+		data:        "github.com",
+		expectError: true,
+	},
+}
+
+func TestParseGitCredentials(t *testing.T) {
+	t.Setenv("TEST_GIT_TOKEN", "secret")
+	for name, test := range testCasesParseGitCredentials {
+		actual, err := ParseGitCredentials([]byte(test.data))
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error, got none", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
+
+type gitCredentialsAuthTest struct {
+	url      string
+	auth     string
+	expected string
+}
+
+var testCasesGitCredentialsAuth = map[string]gitCredentialsAuthTest{
+	"host": {
+		url:      "https://github.com/other/controls/info/refs",
+		expected: "x-access-token:host-token",
+	},
+	"repository": {
+		url:      "https://github.com/acme/controls/info/refs",
+		auth:     "git-token",
+		expected: "x-access-token:acme-token",
+	},
+	"repository with git suffix": {
+		url:      "https://github.com/acme/controls.git/git-upload-pack",
+		expected: "x-access-token:acme-token",
+	},
+	"repository prefix": {
+		url:      "https://github.com/acme/controls-2/info/refs",
+		expected: "x-access-token:host-token",
+	},
+	"ssh credentials": {
+		url:      "https://gitlab.com/acme/controls/info/refs",
+		auth:     "git-token",
+		expected: "git-token:",
+	},
+	"no credentials": {
+		url:      "https://bitbucket.org/acme/controls/info/refs",
+		expected: "",
+	},
+}
+
+func TestGitCredentialsAuthTransport(t *testing.T) {
+	creds, err := ParseGitCredentials([]byte("github.com token host-token\ngithub.com/acme/controls token acme-token\ngitlab.com ssh-agent"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := &recordAuthTransport{}
+	transport := &gitCredentialsAuthTransport{creds: creds, base: base}
+	for name, test := range testCasesGitCredentialsAuth {
+		req, err := http.NewRequest(http.MethodGet, test.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.auth != "" {
+			req.SetBasicAuth(test.auth, "")
+		}
+		if _, err := transport.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+		if base.auth != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, base.auth)
+		}
+	}
+}
+
+type recordSessionTransport struct {
+	ep   *transport.Endpoint
+	auth transport.AuthMethod
+}
+
+func (t *recordSessionTransport) NewUploadPackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.UploadPackSession, error) {
+	t.ep, t.auth = ep, auth
+	return nil, nil
+}
+
+func (t *recordSessionTransport) NewReceivePackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.ReceivePackSession, error) {
+	return nil, nil
+}
+
+type gitCredentialsSessionTest struct {
+	url  string
+	auth transport.AuthMethod
+	// the expected endpoint of the session, and whether it is an ssh session
+	expected    string
+	expectSSH   bool
+	expectAuth  string
+	expectError bool
+}
+
+var testCasesGitCredentialsSession = map[string]gitCredentialsSessionTest{
+	"deploy key": {
+		url:        "https://github.com/acme/private-mod",
+		auth:       &githttp.BasicAuth{Username: "git-token"},
+		expected:   "ssh://git@github.com/acme/private-mod",
+		expectSSH:  true,
+		expectAuth: gitssh.PublicKeysName,
+	},
+	"ssh url": {
+		url:        "ssh://git@github.com:2222/acme/private-mod.git",
+		expected:   "ssh://git@github.com:2222/acme/private-mod.git",
+		expectSSH:  true,
+		expectAuth: gitssh.PublicKeysName,
+	},
+	"https": {
+		url:        "https://github.com/acme/public-mod",
+		auth:       &githttp.BasicAuth{Username: "git-token"},
+		expected:   "https://github.com/acme/public-mod",
+		expectAuth: "http-basic-auth",
+	},
+	"git token removed from ssh": {
+		url:       "ssh://git@github.com/acme/public-mod.git",
+		auth:      &githttp.BasicAuth{Username: "git-token"},
+		expected:  "ssh://git@github.com/acme/public-mod.git",
+		expectSSH: true,
+	},
+	"missing key": {
+		url:         "https://github.com/acme/missing-key-mod",
+		expectError: true,
+	},
+}
+
+func TestGitCredentialsTransport(t *testing.T) {
+	keyPath := writeTestSSHKey(t)
+	creds, err := ParseGitCredentials([]byte("github.com/acme/private-mod ssh-key " + keyPath + "\ngithub.com/acme/missing-key-mod ssh-key /missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, test := range testCasesGitCredentialsSession {
+		base, ssh := &recordSessionTransport{}, &recordSessionTransport{}
+		credsTransport := &gitCredentialsTransport{creds: creds, base: base, ssh: ssh}
+		if test.expectSSH {
+			// ssh urls are served by the ssh transport
+			credsTransport.base = ssh
+		}
+		ep, err := transport.NewEndpoint(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = credsTransport.NewUploadPackSession(ep, test.auth)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error, got none", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %v", name, err)
+			continue
+		}
+		actual := base
+		if test.expectSSH {
+			actual = ssh
+		}
+		if actual.ep == nil || actual.ep.String() != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected session for %s, got %v", name, test.expected, actual.ep)
+			continue
+		}
+		authName := ""
+		if actual.auth != nil {
+			authName = actual.auth.Name()
+		}
+		if authName != test.expectAuth {
+			t.Errorf("Test: '%s' FAILED : expected auth '%s', got '%s'", name, test.expectAuth, authName)
+		}
+	}
+}
+
+func TestLoadGitCredentialsError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	writeTestFile(t, path, "github.com token")
+	if _, err := LoadGitCredentials(path); err == nil {
+		t.Errorf("Test: 'invalid file' FAILED : expected error, got none")
+	}
+	if creds, err := LoadGitCredentials(""); creds != nil || err != nil {
+		t.Errorf("Test: 'no file' FAILED : expected no credentials, got %v %v", creds, err)
+	}
+}
+
+// writeTestSSHKey writes an unencrypted private key, returning its path
+func writeTestSSHKey(t *testing.T) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "deploy_key")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,91 @@
+package modsource
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	gitclient "github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+)
+
+// mods hosted in GitLab (SaaS or self-hosted) are installed using the project path, including any group subpaths, e.g.
+//
+//	powerpipe mod install gitlab.com/acme/security/compliance/powerpipe-mod-controls@^1
+//	powerpipe mod install gitlab.acme.internal/security/powerpipe-mod-controls
+//
+// the host is part of the mod name, so is recorded in the mod require block and the lock file
+// if GITLAB_TOKEN is set, it is used to authenticate with GitLab hosts - these are gitlab.com and the hosts listed in
+// POWERPIPE_GITLAB_HOSTS (a comma separated list, for self-hosted GitLab) - the token is not sent to other hosts, even
+// if their name looks like a GitLab host (e.g. gitlab.example.com)
+const HostGitLab = "gitlab.com"
+
+var installGitAuthOnce sync.Once
+
+// InstallGitAuth configures the git client used to install mods to authenticate requests using the credentials
+// of POWERPIPE_GIT_CREDENTIALS and (for GitLab hosts) GITLAB_TOKEN, to report the progress of installs started
+// with InstallWithEvents, and to serve mods from the local mirror (if POWERPIPE_MOD_MIRROR is set) and the repositories
+// fetched by PrefetchDependencies
+func InstallGitAuth() {
+	installGitAuthOnce.Do(func() {
+		creds, credsErr := LoadGitCredentials(os.Getenv(localconstants.EnvGitCredentials))
+
+		roundTripper := tlspolicy.HTTPClient().Transport
+		if len(creds) > 0 {
+			// (the credentials of the host replace GITLAB_TOKEN, so are applied after it)
+			roundTripper = &gitCredentialsAuthTransport{creds: creds, base: roundTripper}
+		}
+		if token := os.Getenv(localconstants.EnvGitLabToken); token != "" {
+			roundTripper = &gitLabAuthTransport{
+				token: token,
+				hosts: gitLabHosts(os.Getenv(localconstants.EnvGitLabHosts)),
+				base:  roundTripper,
+			}
+		}
+		roundTripper = &installProgressTransport{base: roundTripper}
+		client := githttp.NewClient(&http.Client{Transport: roundTripper})
+		client = &gitCredentialsTransport{creds: creds, err: credsErr, base: client, ssh: gitssh.DefaultClient}
+		if mirror := os.Getenv(localconstants.EnvModMirror); mirror != "" {
+			client = newMirrorTransport(mirror, client)
+		}
+		client = &prefetchTransport{base: client}
+		gitclient.InstallProtocol("https", client)
+		gitclient.InstallProtocol("ssh", &gitCredentialsTransport{creds: creds, err: credsErr, base: gitssh.DefaultClient, ssh: gitssh.DefaultClient})
+	})
+}
+
+func gitLabHosts(hostsEnv string) map[string]struct{} {
+	hosts := map[string]struct{}{HostGitLab: {}}
+	for _, host := range strings.Split(hostsEnv, ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			hosts[host] = struct{}{}
+		}
+	}
+	return hosts
+}
+
+// isGitLabHost returns whether the host is one of the GitLab hosts
+func isGitLabHost(host string, hosts map[string]struct{}) bool {
+	_, ok := hosts[strings.ToLower(host)]
+	return ok
+}
+
+// gitLabAuthTransport adds GitLab token authentication to requests to GitLab hosts
+type gitLabAuthTransport struct {
+	token string
+	hosts map[string]struct{}
+	base  http.RoundTripper
+}
+
+func (t *gitLabAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isGitLabHost(req.URL.Hostname(), t.hosts) {
+		// (any git token is replaced - GitLab requires the token as the password of the oauth2 user)
+		req = req.Clone(req.Context())
+		req.SetBasicAuth("oauth2", t.token)
+	}
+	return t.base.RoundTrip(req)
+}
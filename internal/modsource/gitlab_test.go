@@ -0,0 +1,81 @@
+package modsource
+
+import (
+	"net/http"
+	"testing"
+)
+
+type gitLabAuthTest struct {
+	url      string
+	auth     string
+	expected string
+}
+
+var testCasesGitLabAuth = map[string]gitLabAuthTest{
+	"gitlab.com": {
+		url:      "https://gitlab.com/acme/controls/info/refs",
+		expected: "oauth2:secret",
+	},
+	"listed host": {
+		url:      "https://gitlab.acme.internal/acme/controls/info/refs",
+		expected: "oauth2:secret",
+	},
+	"listed host case": {
+		url:      "https://GitLab.Acme.Internal/acme/controls/info/refs",
+		expected: "oauth2:secret",
+	},
+	"custom domain": {
+		url:      "https://code.acme.io/acme/controls/info/refs",
+		expected: "oauth2:secret",
+	},
+	"unlisted gitlab host": {
+		url:      "https://gitlab.attacker.example/acme/controls/info/refs",
+		expected: "",
+	},
+	"git token replaced": {
+		url:      "https://gitlab.com/acme/controls/info/refs",
+		auth:     "github-token",
+		expected: "oauth2:secret",
+	},
+	"github unchanged": {
+		url:      "https://github.com/turbot/controls/info/refs",
+		auth:     "github-token",
+		expected: "github-token:",
+	},
+	"other host unchanged": {
+		url:      "https://bitbucket.org/acme/controls/info/refs",
+		expected: "",
+	},
+}
+
+type recordAuthTransport struct {
+	auth string
+}
+
+func (t *recordAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.auth = ""
+	if user, password, ok := req.BasicAuth(); ok {
+		t.auth = user + ":" + password
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+}
+
+func TestGitLabAuthTransport(t *testing.T) {
+	base := &recordAuthTransport{}
+	transport := &gitLabAuthTransport{token: "secret", hosts: gitLabHosts("code.acme.io, gitlab.acme.internal, "), base: base}
+	for name, test := range testCasesGitLabAuth {
+		req, err := http.NewRequest(http.MethodGet, test.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.auth != "" {
+			req.SetBasicAuth(test.auth, "")
+		}
+		if _, err := transport.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+		if base.auth != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, base.auth)
+		}
+	}
+}
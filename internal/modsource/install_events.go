@@ -0,0 +1,202 @@
+package modsource
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turbot/pipe-fittings/modinstaller"
+	"github.com/turbot/powerpipe/internal/eventbus"
+)
+
+// InstallEventType is the type of an InstallEvent
+type InstallEventType string
+
+const (
+	// InstallEventResolving is sent when the install starts, and when the versions of each mod are first resolved
+	InstallEventResolving InstallEventType = "resolving"
+	// InstallEventDownloading is sent when a mod is downloaded
+	InstallEventDownloading InstallEventType = "downloading"
+	// InstallEventConflict is sent when the install fails as no version of a mod satisfies its constraints
+	InstallEventConflict InstallEventType = "conflict"
+	// InstallEventError is sent when the install fails for any other reason
+	InstallEventError InstallEventType = "error"
+	// InstallEventDone is sent when the install completes
+	InstallEventDone InstallEventType = "done"
+)
+
+// ErrInstallInProgress is returned by InstallWithEvents if another install is in progress
+var ErrInstallInProgress = errors.New("a mod install is already in progress")
+
+// InstallEvent is a progress event of a mod install
+type InstallEvent struct {
+	Type      InstallEventType `json:"type"`
+	Mod       string           `json:"mod,omitempty"`
+	Message   string           `json:"message,omitempty"`
+	Timestamp time.Time        `json:"timestamp"`
+}
+
+// InstallEventHandler is called with each event of an install
+type InstallEventHandler func(InstallEvent)
+
+var (
+	// only one install may run at a time - installs modify the workspace mod and lock file
+	installLock sync.Mutex
+
+	progressLock    sync.RWMutex
+	progressTracker *installTracker
+)
+
+// InstallWithEvents installs the workspace dependencies, calling the handler with the progress of the install
+// - ErrInstallInProgress is returned if another install is in progress
+func InstallWithEvents(ctx context.Context, opts *modinstaller.InstallOpts, handler InstallEventHandler) (*modinstaller.InstallData, error) {
+	if !installLock.TryLock() {
+		return nil, ErrInstallInProgress
+	}
+	defer installLock.Unlock()
+
+	InstallGitAuth()
+	tracker := newInstallTracker(handler)
+	setProgressTracker(tracker)
+	defer setProgressTracker(nil)
+
+	tracker.send(InstallEvent{Type: InstallEventResolving, Message: "resolving dependencies"})
+	cleanup, err := PrefetchDependencies(ctx, opts, DefaultPrefetchParallelism)
+	if err != nil {
+		tracker.send(InstallEvent{Type: InstallEventError, Message: err.Error()})
+		return nil, err
+	}
+	defer cleanup()
+	installData, err := modinstaller.InstallWorkspaceDependencies(ctx, opts)
+	if err != nil {
+		eventType := InstallEventError
+		if len(ParseConflicts(err)) > 0 {
+			eventType = InstallEventConflict
+			err = ExplainInstallError(err, opts.WorkspaceMod)
+		}
+		tracker.send(InstallEvent{Type: eventType, Message: err.Error()})
+		return nil, err
+	}
+	tracker.send(InstallEvent{Type: InstallEventDone, Message: strings.TrimRight(modinstaller.BuildInstallSummary(installData), "\n")})
+	PublishModInstalled(opts, installData)
+	return installData, nil
+}
+
+// PublishModInstalled publishes the mod_installed event of an install which changed the installed mods
+// (nothing is published for a dry run)
+func PublishModInstalled(opts *modinstaller.InstallOpts, installData *modinstaller.InstallData) {
+	if opts.DryRun || installData == nil || !eventbus.Enabled() {
+		return
+	}
+	data := make(map[string]any)
+	for key, items := range map[string][][]string{
+		"installed":   installData.Installed,
+		"upgraded":    installData.Upgraded,
+		"downgraded":  installData.Downgraded,
+		"uninstalled": installData.Uninstalled,
+	} {
+		// each item is the dependency path of the mod version, ending with the mod version
+		var mods []string
+		for _, item := range items {
+			if len(item) > 0 {
+				mods = append(mods, item[len(item)-1])
+			}
+		}
+		if len(mods) > 0 {
+			data[key] = mods
+		}
+	}
+	if len(data) == 0 {
+		return
+	}
+	subject := ""
+	if opts.WorkspaceMod != nil {
+		subject = opts.WorkspaceMod.Name()
+	}
+	eventbus.Publish(eventbus.NewEvent(eventbus.EventModInstalled, subject, data))
+}
+
+func setProgressTracker(tracker *installTracker) {
+	progressLock.Lock()
+	defer progressLock.Unlock()
+	progressTracker = tracker
+}
+
+func getProgressTracker() *installTracker {
+	progressLock.RLock()
+	defer progressLock.RUnlock()
+	return progressTracker
+}
+
+// installTracker converts the git requests of an install into install events
+type installTracker struct {
+	handler InstallEventHandler
+	lock    sync.Mutex
+	// the mods which have been resolved or downloaded
+	seen map[InstallEventType]map[string]struct{}
+}
+
+func newInstallTracker(handler InstallEventHandler) *installTracker {
+	return &installTracker{
+		handler: handler,
+		seen: map[InstallEventType]map[string]struct{}{
+			InstallEventResolving:   {},
+			InstallEventDownloading: {},
+		},
+	}
+}
+
+func (t *installTracker) send(event InstallEvent) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	t.handler(event)
+}
+
+// onRequest sends an event for the first ref listing (resolving) and pack fetch (downloading) of each mod repo
+func (t *installTracker) onRequest(req *http.Request) {
+	eventType, mod := gitRequestEvent(req)
+	if eventType == "" {
+		return
+	}
+	t.lock.Lock()
+	_, seen := t.seen[eventType][mod]
+	t.seen[eventType][mod] = struct{}{}
+	t.lock.Unlock()
+	if !seen {
+		t.send(InstallEvent{Type: eventType, Mod: mod, Message: string(eventType) + " " + mod})
+	}
+}
+
+// gitRequestEvent returns the install event type and mod name of a git smart http request
+// - listing the refs of a repo resolves the mod versions, and fetching the pack downloads the mod
+func gitRequestEvent(req *http.Request) (InstallEventType, string) {
+	path := req.URL.Path
+	var eventType InstallEventType
+	switch {
+	case req.Method == http.MethodGet && strings.HasSuffix(path, "/info/refs"):
+		eventType = InstallEventResolving
+		path = strings.TrimSuffix(path, "/info/refs")
+	case req.Method == http.MethodPost && strings.HasSuffix(path, "/git-upload-pack"):
+		eventType = InstallEventDownloading
+		path = strings.TrimSuffix(path, "/git-upload-pack")
+	default:
+		return "", ""
+	}
+	return eventType, req.URL.Hostname() + strings.TrimSuffix(path, ".git")
+}
+
+// installProgressTransport reports git requests to the tracker of the install in progress (if any)
+type installProgressTransport struct {
+	base http.RoundTripper
+}
+
+func (t *installProgressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if tracker := getProgressTracker(); tracker != nil {
+		tracker.onRequest(req)
+	}
+	return t.base.RoundTrip(req)
+}
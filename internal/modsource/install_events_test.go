@@ -0,0 +1,62 @@
+package modsource
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type installTrackerTest struct {
+	requests []string
+	expected []string
+}
+
+var testCasesInstallTracker = map[string]installTrackerTest{
+	"clone": {
+		requests: []string{
+			"GET https://github.com/turbot/steampipe-mod-aws-compliance/info/refs?service=git-upload-pack",
+			"GET https://github.com/turbot/steampipe-mod-aws-compliance/info/refs?service=git-upload-pack",
+			"POST https://github.com/turbot/steampipe-mod-aws-compliance/git-upload-pack",
+		},
+		expected: []string{
+			"resolving github.com/turbot/steampipe-mod-aws-compliance",
+			"downloading github.com/turbot/steampipe-mod-aws-compliance",
+		},
+	},
+	"git suffix and group path": {
+		requests: []string{
+			"GET https://gitlab.com/acme/security/controls.git/info/refs?service=git-upload-pack",
+			"POST https://gitlab.com/acme/security/controls.git/git-upload-pack",
+		},
+		expected: []string{
+			"resolving gitlab.com/acme/security/controls",
+			"downloading gitlab.com/acme/security/controls",
+		},
+	},
+	"other requests": {
+		requests: []string{
+			"GET https://github.com/turbot/steampipe-mod-aws-compliance",
+			"POST https://github.com/turbot/steampipe-mod-aws-compliance/info/refs",
+		},
+	},
+}
+
+func TestInstallTracker(t *testing.T) {
+	for name, test := range testCasesInstallTracker {
+		var actual []string
+		tracker := newInstallTracker(func(event InstallEvent) {
+			actual = append(actual, event.Message)
+		})
+		for _, r := range test.requests {
+			method, url, _ := strings.Cut(r, " ")
+			req, err := http.NewRequest(method, url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tracker.onRequest(req)
+		}
+		if strings.Join(actual, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package modsource
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/modinstaller"
+	"github.com/turbot/pipe-fittings/parse"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// the installed dependencies of the workspace are listed as a tree, from the lockfile - each with the version it was
+// resolved to, the constraint of the mod which requires it, and its install path
+// for dependencies with a version constraint, the versions tagged in the git repository are listed to find whether a
+// newer version satisfying the constraint exists (i.e. whether 'powerpipe mod update' would update it) - branch, tag
+// and file path dependencies have no newer version
+
+// InstalledMod is an installed dependency of the workspace
+type InstalledMod struct {
+	Name        string `json:"name" yaml:"name"`
+	Alias       string `json:"alias,omitempty" yaml:"alias,omitempty"`
+	Version     string `json:"version,omitempty" yaml:"version,omitempty"`
+	Constraint  string `json:"constraint,omitempty" yaml:"constraint,omitempty"`
+	Commit      string `json:"commit,omitempty" yaml:"commit,omitempty"`
+	InstallPath string `json:"install_path" yaml:"install_path"`
+	// the newest version satisfying the constraint, if newer than the installed version
+	NewerVersion string `json:"newer_version,omitempty" yaml:"newer_version,omitempty"`
+	// the error listing the versions of the mod, if they could not be listed
+	NewerVersionError string          `json:"newer_version_error,omitempty" yaml:"newer_version_error,omitempty"`
+	Dependencies      []*InstalledMod `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+
+	installed *versionmap.InstalledModVersion
+	required  *modconfig.ModVersionConstraint
+}
+
+// Key returns the name and version of the dependency (file path dependencies have no version)
+func (m *InstalledMod) Key() string {
+	if m.Version == "" {
+		return m.Name
+	}
+	return m.Name + "@" + m.Version
+}
+
+// Flatten returns the dependency and its dependencies, depth first
+func (m *InstalledMod) Flatten() []*InstalledMod {
+	res := []*InstalledMod{m}
+	for _, dep := range m.Dependencies {
+		res = append(res, dep.Flatten()...)
+	}
+	return res
+}
+
+// ListInstalledMods returns the dependency tree of the workspace mod from the lock, ordered by name - the constraint of
+// each dependency is read from the mod file of the mod requiring it
+func ListInstalledMods(workspaceMod *modconfig.Mod, lock *versionmap.WorkspaceLock) ([]*InstalledMod, error) {
+	return listInstalledMods(workspaceMod, workspaceMod.GetInstallCacheKey(), lock, map[string]struct{}{})
+}
+
+func listInstalledMods(parent *modconfig.Mod, parentKey string, lock *versionmap.WorkspaceLock, ancestors map[string]struct{}) ([]*InstalledMod, error) {
+	deps := lock.InstallCache[parentKey]
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var res []*InstalledMod
+	for _, name := range names {
+		dep := deps[name]
+		if dep == nil || dep.ResolvedVersionConstraint == nil {
+			continue
+		}
+		mod := &InstalledMod{
+			Name:        dep.Name,
+			Alias:       dep.Alias,
+			Version:     lockedVersion(dep),
+			Commit:      dep.Commit,
+			InstallPath: filepath.Join(lock.ModInstallationPath, dep.DependencyPath()),
+			installed:   dep,
+		}
+		if dep.FilePath != "" {
+			mod.InstallPath = dep.FilePath
+		}
+		if required := requiredMod(parent, dep.Name); required != nil {
+			mod.required = required
+			mod.Constraint = fmt.Sprintf("%v", required.OriginalConstraint())
+		}
+
+		key := dep.DependencyPath()
+		if _, ok := ancestors[key]; !ok && len(lock.InstallCache[key]) > 0 {
+			depMod, err := loadInstalledModfile(mod.InstallPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load the mod definition of %s: %s", mod.Key(), err.Error())
+			}
+			ancestors[key] = struct{}{}
+			mod.Dependencies, err = listInstalledMods(depMod, key, lock, ancestors)
+			delete(ancestors, key)
+			if err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, mod)
+	}
+	return res, nil
+}
+
+// requiredMod returns the constraint of the mod file for the dependency, if any
+func requiredMod(parent *modconfig.Mod, name string) *modconfig.ModVersionConstraint {
+	if parent == nil || parent.Require == nil {
+		return nil
+	}
+	for _, required := range parent.Require.Mods {
+		if required.Name == name {
+			return required
+		}
+	}
+	return nil
+}
+
+// loadInstalledModfile loads the mod file of an installed dependency - if the dependency is not installed, nil is
+// returned (so the constraints of its dependencies are unknown)
+func loadInstalledModfile(installPath string) (*modconfig.Mod, error) {
+	if _, err := os.Stat(installPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return parse.LoadModfile(installPath)
+}
+
+// listRemoteTags returns the tags of the git repository of the mod - a variable so tests may replace it
+var listRemoteTags = remoteTags
+
+// SetNewerVersions sets the newest version satisfying the constraint of each dependency (and their dependencies), if
+// it is newer than the installed version - the versions of each mod are listed once, concurrently
+func SetNewerVersions(ctx context.Context, mods []*InstalledMod) {
+	var all []*InstalledMod
+	for _, mod := range mods {
+		all = append(all, mod.Flatten()...)
+	}
+
+	type tagsResult struct {
+		tags []string
+		err  error
+	}
+	tags := make(map[string]*tagsResult)
+	for _, mod := range all {
+		if mod.hasVersionConstraint() {
+			tags[mod.Name] = nil
+		}
+	}
+	var wg sync.WaitGroup
+	var mut sync.Mutex
+	for name := range tags {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			res, err := listRemoteTags(ctx, name)
+			mut.Lock()
+			tags[name] = &tagsResult{tags: res, err: err}
+			mut.Unlock()
+		}(name)
+	}
+	wg.Wait()
+
+	for _, mod := range all {
+		if !mod.hasVersionConstraint() {
+			continue
+		}
+		result := tags[mod.Name]
+		if result.err != nil {
+			mod.NewerVersionError = result.err.Error()
+			continue
+		}
+		if newer := newerVersion(result.tags, mod.required, mod.installed.Version); newer != "" {
+			mod.NewerVersion = newer
+		}
+	}
+}
+
+func (m *InstalledMod) hasVersionConstraint() bool {
+	return m.required != nil && m.required.VersionConstraint() != nil && m.installed.Version != nil
+}
+
+// newerVersion returns the newest of the tags satisfying the constraint, if it is newer than the installed version
+func newerVersion(tags []string, required *modconfig.ModVersionConstraint, installed *semver.Version) string {
+	newest := newestVersion(tags, required)
+	if newest == nil || !newest.GreaterThan(installed) {
+		return ""
+	}
+	return newest.Original()
+}
+
+// newestVersion returns the newest of the tags satisfying the version constraint, or nil if none do -
+// prerelease versions only satisfy constraints which include a prerelease, e.g. ^1.1.0-rc.0
+func newestVersion(tags []string, required *modconfig.ModVersionConstraint) *semver.Version {
+	var newest *semver.Version
+	for _, tag := range tags {
+		v, err := semver.NewVersion(tag)
+		if err != nil || !required.VersionConstraint().Check(v) {
+			continue
+		}
+		if newest == nil || v.GreaterThan(newest) {
+			newest = v
+		}
+	}
+	return newest
+}
+
+// remoteTags lists the tags of the git repository of the mod over https, using the git client configured by
+// InstallGitAuth (so the credentials and the local mirror are used, as for installs)
+func remoteTags(ctx context.Context, modName string) ([]string, error) {
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{"https://" + modName},
+	})
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: gitTokenAuth()})
+	if err != nil {
+		return nil, fmt.Errorf("could not list the versions of %s: %s", modName, err.Error())
+	}
+	var res []string
+	for _, ref := range refs {
+		if ref.Name().IsTag() {
+			res = append(res, ref.Name().Short())
+		}
+	}
+	return res, nil
+}
+
+// gitTokenAuth returns the authentication for POWERPIPE_GIT_TOKEN, which is used for hosts with no credentials,
+// as for installs - nil is returned if it is not set
+func gitTokenAuth() transport.AuthMethod {
+	token := os.Getenv(app_specific.EnvGitToken)
+	switch {
+	case token == "":
+		return nil
+	case strings.HasPrefix(token, modinstaller.GitHubAppInstallationAccessTokenPrefix):
+		return &http.BasicAuth{Username: "x-access-token", Password: token}
+	default:
+		return &http.BasicAuth{Username: token}
+	}
+}
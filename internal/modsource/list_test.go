@@ -0,0 +1,141 @@
+package modsource
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+func TestListInstalledMods(t *testing.T) {
+	// (app specific values are set when the CLI starts)
+	app_specific.ModDataExtensions = []string{".pp", ".sp"}
+	modsPath := t.TempDir()
+	workspaceMod := modconfig.NewMod("local", t.TempDir(), hcl.Range{})
+	workspaceMod.Require = modconfig.NewRequire()
+	required, err := modconfig.NewModVersionConstraint("github.com/acme/a@^1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	workspaceMod.Require.Mods = []*modconfig.ModVersionConstraint{required}
+
+	lock := &versionmap.WorkspaceLock{ModInstallationPath: modsPath, InstallCache: make(versionmap.InstalledDependencyVersionsMap)}
+	a := testInstalledModVersion("github.com/acme/a", "v1.0.0")
+	b := testInstalledModVersion("github.com/acme/b", "v2.1.0")
+	lock.InstallCache.AddDependency("local", a)
+	lock.InstallCache.AddDependency(a.DependencyPath(), b)
+	writeTestFile(t, filepath.Join(modsPath, a.DependencyPath(), "mod.pp"), `
+mod "a" {
+  require {
+    mod "github.com/acme/b" {
+      version = "^2.0"
+    }
+  }
+}`)
+
+	mods, err := ListInstalledMods(workspaceMod, lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := listRemoteTags
+	listRemoteTags = func(_ context.Context, modName string) ([]string, error) {
+		if modName == "github.com/acme/a" {
+			return []string{"v1.0.0", "v1.2.0", "v1.3.0-rc.1", "v2.0.0", "latest"}, nil
+		}
+		return nil, fmt.Errorf("could not list the versions of %s", modName)
+	}
+	t.Cleanup(func() { listRemoteTags = previous })
+	SetNewerVersions(context.Background(), mods)
+
+	var actual []string
+	for _, mod := range mods {
+		for _, m := range mod.Flatten() {
+			actual = append(actual, fmt.Sprintf("%s %s %s %s %s", m.Key(), m.Constraint, m.InstallPath, m.NewerVersion, m.NewerVersionError))
+		}
+	}
+	expected := []string{
+		fmt.Sprintf("github.com/acme/a@v1.0.0 ^1 %s v1.2.0 ", filepath.Join(modsPath, "github.com/acme/a@v1.0.0")),
+		fmt.Sprintf("github.com/acme/b@v2.1.0 ^2.0 %s  could not list the versions of github.com/acme/b", filepath.Join(modsPath, "github.com/acme/b@v2.1.0")),
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Test: 'list' FAILED : expected %v, got %v", expected, actual)
+	}
+}
+
+type newerVersionTest struct {
+	constraint string
+	installed  string
+	tags       []string
+	expected   string
+}
+
+var testCasesNewerVersion = map[string]newerVersionTest{
+	"newer": {
+		constraint: "^1.0",
+		installed:  "v1.0.0",
+		tags:       []string{"v1.0.0", "v1.1.0", "v1.0.1"},
+		expected:   "v1.1.0",
+	},
+	"latest": {
+		constraint: "^1.0",
+		installed:  "v1.1.0",
+		tags:       []string{"v1.0.0", "v1.1.0"},
+		expected:   "",
+	},
+	"outside constraint": {
+		constraint: "~1.1",
+		installed:  "v1.1.0",
+		tags:       []string{"v1.1.0", "v1.2.0", "v2.0.0"},
+		expected:   "",
+	},
+	"prerelease": {
+		constraint: "^1.0",
+		installed:  "v1.0.0",
+		tags:       []string{"v1.0.0", "v1.1.0-rc.1"},
+		expected:   "",
+	},
+	"prerelease constraint": {
+		constraint: "^1.1.0-rc.0",
+		installed:  "v1.1.0-rc.1",
+		tags:       []string{"v1.1.0-rc.1", "v1.1.0-rc.2"},
+		expected:   "v1.1.0-rc.2",
+	},
+	"not a version": {
+		constraint: "*",
+		installed:  "v1.0.0",
+		tags:       []string{"v1.0.0", "release-2024"},
+		expected:   "",
+	},
+}
+
+func TestNewerVersion(t *testing.T) {
+	for name, test := range testCasesNewerVersion {
+		required, err := modconfig.NewModVersionConstraint("github.com/acme/a@" + test.constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := newerVersion(test.tags, required, semver.MustParse(test.installed))
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, actual)
+		}
+	}
+}
+
+func testInstalledModVersion(name, version string) *versionmap.InstalledModVersion {
+	return &versionmap.InstalledModVersion{
+		ResolvedVersionConstraint: &versionmap.ResolvedVersionConstraint{
+			DependencyVersion: modconfig.DependencyVersion{Version: semver.MustParse(version)},
+			Name:              name,
+			Commit:            "0f7c4ab7e9e2d0a3c5b1e4b1a0f1c2d3e4f5a6b7",
+			GitRefStr:         "refs/tags/" + version,
+		},
+	}
+}
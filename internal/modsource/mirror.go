@@ -0,0 +1,112 @@
+package modsource
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp/capability"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// mods may be installed without network access from a local mirror - a directory of git repositories laid out by
+// mod name, set with POWERPIPE_MOD_MIRROR, e.g. with POWERPIPE_MOD_MIRROR=/opt/mods
+//
+//	/opt/mods/github.com/turbot/steampipe-mod-aws-compliance
+//	/opt/mods/github.com/turbot/steampipe-mod-aws-insights.git
+//
+// each repository may be a working copy or a bare repository (e.g. created with 'git clone --mirror')
+// the versions of mods in the mirror are listed and installed from the mirror, so dependencies (including the
+// dependencies of mods installed from a local path or archive) are resolved against it - mods which are not in the
+// mirror are installed from their git host as usual
+
+// mirrorTransport serves the repositories found in the mirror, passing requests for any other repository
+// to the base transport
+type mirrorTransport struct {
+	loader *mirrorLoader
+	mirror transport.Transport
+	base   transport.Transport
+}
+
+func newMirrorTransport(dir string, base transport.Transport) transport.Transport {
+	loader := &mirrorLoader{dir: dir}
+	return &mirrorTransport{
+		loader: loader,
+		mirror: server.NewClient(loader),
+		base:   base,
+	}
+}
+
+func (t *mirrorTransport) NewUploadPackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.UploadPackSession, error) {
+	if t.loader.repoPath(ep) == "" {
+		return t.base.NewUploadPackSession(ep, auth)
+	}
+	session, err := t.mirror.NewUploadPackSession(ep, auth)
+	if err != nil {
+		return nil, err
+	}
+	return &mirrorSession{UploadPackSession: session}, nil
+}
+
+func (t *mirrorTransport) NewReceivePackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.ReceivePackSession, error) {
+	return t.base.NewReceivePackSession(ep, auth)
+}
+
+// mirrorSession clones the full history of mirrored repositories, as shallow clones are not supported when serving
+// repositories from the file system
+type mirrorSession struct {
+	transport.UploadPackSession
+}
+
+func (s *mirrorSession) UploadPack(ctx context.Context, req *packp.UploadPackRequest) (*packp.UploadPackResponse, error) {
+	req.Depth = packp.DepthCommits(0)
+	req.Capabilities.Delete(capability.Shallow)
+	return s.UploadPackSession.UploadPack(ctx, req)
+}
+
+// mirrorLoader loads the repositories of the mirror
+type mirrorLoader struct {
+	dir string
+}
+
+func (l *mirrorLoader) Load(ep *transport.Endpoint) (storer.Storer, error) {
+	repoPath := l.repoPath(ep)
+	if repoPath == "" {
+		return nil, transport.ErrRepositoryNotFound
+	}
+	return filesystem.NewStorage(osfs.New(repoPath), cache.NewObjectLRUDefault()), nil
+}
+
+// repoPath returns the path of the git directory of the repository of the endpoint in the mirror,
+// or an empty string if the repository is not in the mirror
+func (l *mirrorLoader) repoPath(ep *transport.Endpoint) string {
+	name := path.Clean(path.Join(ep.Host, strings.TrimSuffix(ep.Path, ".git")))
+	if name == "." || strings.HasPrefix(name, "..") {
+		return ""
+	}
+	base := filepath.Join(l.dir, filepath.FromSlash(name))
+	for _, candidate := range []string{filepath.Join(base, ".git"), base, base + ".git"} {
+		if isGitDir(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// isGitDir returns whether the path is a git directory, i.e. the .git directory of a working copy or a bare repository
+func isGitDir(dir string) bool {
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
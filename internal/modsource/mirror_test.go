@@ -0,0 +1,128 @@
+package modsource
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gitclient "github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+type mirrorRepoPathTest struct {
+	url      string
+	expected string
+}
+
+var testCasesMirrorRepoPath = map[string]mirrorRepoPathTest{
+	"working copy": {
+		url:      "https://github.com/acme/working",
+		expected: "github.com/acme/working/.git",
+	},
+	"bare": {
+		url:      "https://github.com/acme/bare",
+		expected: "github.com/acme/bare",
+	},
+	"bare with git suffix": {
+		url:      "https://github.com/acme/suffixed",
+		expected: "github.com/acme/suffixed.git",
+	},
+	"url with git suffix": {
+		url:      "https://github.com/acme/bare.git",
+		expected: "github.com/acme/bare",
+	},
+	"not mirrored": {
+		url: "https://github.com/acme/other",
+	},
+	"outside mirror": {
+		url: "https://github.com/../../acme/bare",
+	},
+}
+
+func TestMirrorRepoPath(t *testing.T) {
+	mirror := t.TempDir()
+	initTestRepo(t, filepath.Join(mirror, "github.com/acme/working"), false)
+	initTestRepo(t, filepath.Join(mirror, "github.com/acme/bare"), true)
+	initTestRepo(t, filepath.Join(mirror, "github.com/acme/suffixed.git"), true)
+
+	loader := &mirrorLoader{dir: mirror}
+	for name, test := range testCasesMirrorRepoPath {
+		ep, err := transport.NewEndpoint(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := ""
+		if test.expected != "" {
+			expected = filepath.Join(mirror, test.expected)
+		}
+		if actual := loader.repoPath(ep); actual != expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, expected, actual)
+		}
+	}
+}
+
+func TestMirrorClone(t *testing.T) {
+	mirror := t.TempDir()
+	commit := initTestRepo(t, filepath.Join(mirror, "github.com/acme/mod"), false)
+
+	gitclient.InstallProtocol("https", newMirrorTransport(mirror, githttp.DefaultClient))
+	defer gitclient.InstallProtocol("https", githttp.DefaultClient)
+
+	// clone as the mod installer does
+	repo, err := git.PlainClone(t.TempDir(), false, &git.CloneOptions{
+		URL:           "https://github.com/acme/mod",
+		ReferenceName: plumbing.NewTagReferenceName("v1.0.0"),
+		Depth:         1,
+		SingleBranch:  true,
+	})
+	if err != nil {
+		t.Fatalf("Test: 'clone' FAILED : unexpected error: %v", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Hash() != commit {
+		t.Errorf("Test: 'clone' FAILED : expected %s, got %s", commit, head.Hash())
+	}
+}
+
+// initTestRepo creates a repository with a single commit, tagged v1.0.0
+func initTestRepo(t *testing.T, dir string, bare bool) plumbing.Hash {
+	src := dir
+	if bare {
+		src = t.TempDir()
+	}
+	repo, err := git.PlainInit(src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "mod.pp"), []byte(`mod "test" {}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Add("mod.pp"); err != nil {
+		t.Fatal(err)
+	}
+	commit, err := wt.Commit("init", &git.CommitOptions{Author: &object.Signature{Name: "test", When: time.Now()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateTag("v1.0.0", commit, nil); err != nil {
+		t.Fatal(err)
+	}
+	if bare {
+		if _, err := git.PlainClone(dir, true, &git.CloneOptions{URL: src, Tags: git.AllTags}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return commit
+}
@@ -0,0 +1,101 @@
+package modsource
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// mods may be installed from any git host - the mod name is the repository path, without the scheme, e.g.
+//
+//	github.com/turbot/steampipe-mod-aws-compliance
+//	dev.azure.com/acme/security/_git/powerpipe-mod-controls
+//	bitbucket.org/acme/powerpipe-mod-controls
+//
+// the clone and browse URLs shown by Azure DevOps and Bitbucket are converted to this form, so they may be
+// passed directly to 'powerpipe mod install', e.g.
+//
+//	https://acme@dev.azure.com/acme/security/_git/powerpipe-mod-controls
+//	git@ssh.dev.azure.com:v3/acme/security/powerpipe-mod-controls
+//	https://acme.visualstudio.com/security/_git/powerpipe-mod-controls
+//	git@bitbucket.org:acme/powerpipe-mod-controls.git
+//	https://bitbucket.org/acme/powerpipe-mod-controls/src/main/
+//
+// versions and tags are listed using the git protocol, so no host specific API is required
+const (
+	HostAzureDevOps = "dev.azure.com"
+	HostBitbucket   = "bitbucket.org"
+
+	azureDevOpsSshHost = "ssh.dev.azure.com"
+)
+
+var (
+	// e.g. git@bitbucket.org:acme/repo.git
+	scpUrlRegex = regexp.MustCompile(`^[\w.-]+@([\w.-]+):(.+)$`)
+	// e.g. acme.visualstudio.com/security/_git/repo, optionally with the legacy DefaultCollection path
+	visualStudioRegex = regexp.MustCompile(`^([\w-]+)\.visualstudio\.com/(?:DefaultCollection/)?(.+)$`)
+)
+
+// NormaliseModArgs converts the mod source URLs of the mod arguments of a mod command into mod names
+func NormaliseModArgs(args []string) []string {
+	res := make([]string, len(args))
+	for i, arg := range args {
+		res[i] = NormaliseModArg(arg)
+	}
+	return res
+}
+
+// NormaliseModArg converts a mod source URL into a mod name, preserving any version (@) or branch (#) suffix
+// file paths are returned unchanged
+func NormaliseModArg(arg string) string {
+	if _, err := os.Stat(arg); err == nil || strings.HasPrefix(arg, ".") || strings.HasPrefix(arg, "/") {
+		return arg
+	}
+
+	name := arg
+	// remove the scheme, or convert an scp style ssh url
+	if scheme, rest, ok := strings.Cut(name, "://"); ok && scheme != "" {
+		name = rest
+	} else if groups := scpUrlRegex.FindStringSubmatch(name); groups != nil && !strings.Contains(groups[1], "/") {
+		name = groups[1] + "/" + groups[2]
+	}
+
+	// remove any user info (e.g. the organisation name in an Azure DevOps clone url)
+	if at, slash := strings.Index(name, "@"), strings.Index(name, "/"); at >= 0 && slash > at {
+		name = name[at+1:]
+	}
+
+	// split off the version or branch suffix
+	suffix := ""
+	if idx := strings.IndexAny(name, "@#"); idx >= 0 {
+		name, suffix = name[:idx], name[idx:]
+	}
+	// remove any query string (e.g. the path or version of a browse url)
+	name, _, _ = strings.Cut(name, "?")
+	// remove any GitLab browse path, e.g. gitlab.com/group/subgroup/project/-/tree/main
+	name, _, _ = strings.Cut(name, "/-/")
+	name = strings.TrimSuffix(strings.TrimSuffix(name, "/"), ".git")
+
+	host, path, _ := strings.Cut(name, "/")
+	switch {
+	case host == azureDevOpsSshHost:
+		// ssh.dev.azure.com/v3/org/project/repo
+		if parts := strings.Split(strings.TrimPrefix(path, "v3/"), "/"); len(parts) == 3 {
+			name = strings.Join([]string{HostAzureDevOps, parts[0], parts[1], "_git", parts[2]}, "/")
+		}
+	case visualStudioRegex.MatchString(name):
+		groups := visualStudioRegex.FindStringSubmatch(name)
+		name = strings.Join([]string{HostAzureDevOps, groups[1], groups[2]}, "/")
+	case host == HostAzureDevOps:
+		// remove any path within the repository, e.g. dev.azure.com/org/project/_git/repo/...
+		if parts := strings.Split(path, "/"); len(parts) > 4 && parts[2] == "_git" {
+			name = strings.Join(append([]string{host}, parts[:4]...), "/")
+		}
+	case host == HostBitbucket:
+		// remove any browse path, e.g. bitbucket.org/workspace/repo/src/main/...
+		if parts := strings.Split(path, "/"); len(parts) > 2 {
+			name = strings.Join(append([]string{host}, parts[:2]...), "/")
+		}
+	}
+	return name + suffix
+}
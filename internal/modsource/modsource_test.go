@@ -0,0 +1,35 @@
+package modsource
+
+import "testing"
+
+type normaliseModArgTest struct {
+	arg      string
+	expected string
+}
+
+var testCasesNormaliseModArg = map[string]normaliseModArgTest{
+	"github":                {arg: "github.com/turbot/steampipe-mod-aws-compliance@^1", expected: "github.com/turbot/steampipe-mod-aws-compliance@^1"},
+	"github https":          {arg: "https://github.com/turbot/steampipe-mod-aws-compliance.git", expected: "github.com/turbot/steampipe-mod-aws-compliance"},
+	"azure https clone":     {arg: "https://acme@dev.azure.com/acme/security/_git/controls", expected: "dev.azure.com/acme/security/_git/controls"},
+	"azure with version":    {arg: "https://acme@dev.azure.com/acme/security/_git/controls@v1.2.0", expected: "dev.azure.com/acme/security/_git/controls@v1.2.0"},
+	"azure ssh":             {arg: "git@ssh.dev.azure.com:v3/acme/security/controls", expected: "dev.azure.com/acme/security/_git/controls"},
+	"azure browse":          {arg: "https://dev.azure.com/acme/security/_git/controls?path=/mod.pp&version=GBmain", expected: "dev.azure.com/acme/security/_git/controls"},
+	"azure visualstudio":    {arg: "https://acme.visualstudio.com/DefaultCollection/security/_git/controls#main", expected: "dev.azure.com/acme/security/_git/controls#main"},
+	"bitbucket ssh":         {arg: "git@bitbucket.org:acme/controls.git", expected: "bitbucket.org/acme/controls"},
+	"bitbucket https clone": {arg: "https://jsmith@bitbucket.org/acme/controls.git@^2", expected: "bitbucket.org/acme/controls@^2"},
+	"bitbucket browse":      {arg: "https://bitbucket.org/acme/controls/src/main/", expected: "bitbucket.org/acme/controls"},
+	"bitbucket branch":      {arg: "bitbucket.org/acme/controls#develop", expected: "bitbucket.org/acme/controls#develop"},
+	"gitlab subgroup":       {arg: "https://gitlab.com/acme/security/compliance/controls.git@^1", expected: "gitlab.com/acme/security/compliance/controls@^1"},
+	"gitlab browse":         {arg: "https://gitlab.acme.internal/security/controls/-/tree/main", expected: "gitlab.acme.internal/security/controls"},
+	"gitlab ssh":            {arg: "git@gitlab.acme.internal:security/controls.git", expected: "gitlab.acme.internal/security/controls"},
+	"relative file path":    {arg: "../controls", expected: "../controls"},
+	"absolute file path":    {arg: "/opt/mods/controls", expected: "/opt/mods/controls"},
+}
+
+func TestNormaliseModArg(t *testing.T) {
+	for name, test := range testCasesNormaliseModArg {
+		if actual := NormaliseModArg(test.arg); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, actual)
+		}
+	}
+}
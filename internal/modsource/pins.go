@@ -0,0 +1,151 @@
+package modsource
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// the exact version and commit of each installed dependency is recorded in the pins file in the workspace mod directory,
+// separately from the version constraints of the mod file (the constraints express intent, the pins what is installed):
+//
+//	# pinned dependency versions - generated by powerpipe mod install
+//	github.com/turbot/steampipe-mod-aws-compliance v1.2.3 0f7c4ab7e9e2d0a3c5b1e4b1a0f1c2d3e4f5a6b7
+//
+// the pins file is updated by each mod install, update and uninstall - 'mod install --frozen' instead installs the pinned
+// dependencies and fails if the dependencies which would be installed deviate from the pins in any way
+// (branch and file path dependencies have no fixed version, so are not pinned)
+const PinsFileName = "powerpipe.pins"
+
+const pinsFileHeader = "# pinned dependency versions - generated by powerpipe mod install\n"
+
+// Pins is the pinned versions of the workspace dependencies, keyed by mod name and version
+type Pins map[string]ModSum
+
+// NewPins returns the pins for the given mod versions
+func NewPins(sums []ModSum) Pins {
+	pins := make(Pins, len(sums))
+	for _, sum := range sums {
+		pins[sum.key()] = sum
+	}
+	return pins
+}
+
+// PinsPath returns the path of the pins file of the workspace mod in the given directory
+func PinsPath(modPath string) string {
+	return filepath.Join(modPath, PinsFileName)
+}
+
+// LoadPins loads the pins file of the workspace mod in the given directory - if the file does not exist, nil is returned
+func LoadPins(modPath string) (Pins, error) {
+	data, err := os.ReadFile(PinsPath(modPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return ParsePins(data)
+}
+
+// ParsePins parses the contents of a pins file
+func ParsePins(data []byte) (Pins, error) {
+	pins := make(Pins)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid pin on line %d of %s: expected '<mod> <version> <commit>'", lineNumber, PinsFileName)
+		}
+		sum := ModSum{Name: fields[0], Version: fields[1], Commit: fields[2]}
+		pins[sum.key()] = sum
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return pins, nil
+}
+
+// Save writes the pins file of the workspace mod in the given directory - if there are no pins, any existing pins file is removed
+func (p Pins) Save(modPath string) error {
+	path := PinsPath(modPath)
+	if len(p) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(path, p.bytes(), 0644)
+}
+
+func (p Pins) bytes() []byte {
+	var b bytes.Buffer
+	b.WriteString(pinsFileHeader)
+	for _, sum := range p.sorted() {
+		b.WriteString(fmt.Sprintf("%s %s %s\n", sum.Name, sum.Version, sum.Commit))
+	}
+	return b.Bytes()
+}
+
+func (p Pins) sorted() []ModSum {
+	res := make([]ModSum, 0, len(p))
+	for _, sum := range p {
+		res = append(res, sum)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].key() < res[j].key() })
+	return res
+}
+
+// Deviations returns a description of each way in which the given mod versions deviate from the pins
+func (p Pins) Deviations(sums []ModSum) []string {
+	var res []string
+	resolved := NewPins(sums)
+	for _, sum := range resolved.sorted() {
+		pin, ok := p[sum.key()]
+		switch {
+		case !ok:
+			res = append(res, fmt.Sprintf("%s@%s is not pinned", sum.Name, sum.Version))
+		case pin.Commit != sum.Commit:
+			res = append(res, fmt.Sprintf("%s@%s resolves to commit %s, pinned commit is %s", sum.Name, sum.Version, sum.Commit, pin.Commit))
+		}
+	}
+	for _, pin := range p.sorted() {
+		if _, ok := resolved[pin.key()]; !ok {
+			res = append(res, fmt.Sprintf("%s@%s is pinned but would not be installed", pin.Name, pin.Version))
+		}
+	}
+	return res
+}
+
+// UpdatePins writes the pins file of the workspace mod in the given directory from the dependencies in the lock
+func UpdatePins(modPath string, lock *versionmap.WorkspaceLock) error {
+	return NewPins(LockedModSums(lock)).Save(modPath)
+}
+
+// VerifyPins returns an error if the dependencies in the lock deviate from the pins file of the workspace mod
+// in the given directory - an error is also returned if there is no pins file
+func VerifyPins(modPath string, lock *versionmap.WorkspaceLock) error {
+	pins, err := LoadPins(modPath)
+	if err != nil {
+		return err
+	}
+	if pins == nil {
+		return fmt.Errorf("%s not found - run 'powerpipe mod install' without --frozen to create it", PinsFileName)
+	}
+	if deviations := pins.Deviations(LockedModSums(lock)); len(deviations) > 0 {
+		return fmt.Errorf("dependencies deviate from %s:\n\t%s", PinsFileName, strings.Join(deviations, "\n\t"))
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package modsource
+
+import (
+	"strings"
+	"testing"
+)
+
+const testPins = `# pinned dependency versions - generated by powerpipe mod install
+github.com/turbot/steampipe-mod-aws-compliance v1.2.3 abc123
+
+github.com/turbot/steampipe-mod-aws-insights v0.9.0 def456
+`
+
+type pinsDeviationsTest struct {
+	sums     []ModSum
+	expected []string
+}
+
+var testCasesPinsDeviations = map[string]pinsDeviationsTest{
+	"matching": {
+		sums: []ModSum{
+			{Name: "github.com/turbot/steampipe-mod-aws-insights", Version: "v0.9.0", Commit: "def456"},
+			{Name: "github.com/turbot/steampipe-mod-aws-compliance", Version: "v1.2.3", Commit: "abc123"},
+		},
+	},
+	"upgraded": {
+		sums: []ModSum{
+			{Name: "github.com/turbot/steampipe-mod-aws-compliance", Version: "v1.3.0", Commit: "fed987"},
+			{Name: "github.com/turbot/steampipe-mod-aws-insights", Version: "v0.9.0", Commit: "def456"},
+		},
+		expected: []string{
+			"github.com/turbot/steampipe-mod-aws-compliance@v1.3.0 is not pinned",
+			"github.com/turbot/steampipe-mod-aws-compliance@v1.2.3 is pinned but would not be installed",
+		},
+	},
+	"tag moved": {
+		sums: []ModSum{
+			{Name: "github.com/turbot/steampipe-mod-aws-compliance", Version: "v1.2.3", Commit: "evil99"},
+			{Name: "github.com/turbot/steampipe-mod-aws-insights", Version: "v0.9.0", Commit: "def456"},
+		},
+		expected: []string{
+			"github.com/turbot/steampipe-mod-aws-compliance@v1.2.3 resolves to commit evil99, pinned commit is abc123",
+		},
+	},
+	"removed": {
+		sums: []ModSum{
+			{Name: "github.com/turbot/steampipe-mod-aws-compliance", Version: "v1.2.3", Commit: "abc123"},
+		},
+		expected: []string{
+			"github.com/turbot/steampipe-mod-aws-insights@v0.9.0 is pinned but would not be installed",
+		},
+	},
+}
+
+func TestPinsDeviations(t *testing.T) {
+	pins, err := ParsePins([]byte(testPins))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, test := range testCasesPinsDeviations {
+		actual := pins.Deviations(test.sums)
+		if strings.Join(actual, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
+
+func TestPinsRoundTrip(t *testing.T) {
+	pins, err := ParsePins([]byte(testPins))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := pins.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPins(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := string(loaded.bytes()); actual != string(pins.bytes()) {
+		t.Errorf("Test: 'round trip' FAILED : expected '%s', got '%s'", pins.bytes(), actual)
+	}
+
+	// saving empty pins removes the file
+	if err := NewPins(nil).Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err := LoadPins(dir); err != nil || loaded != nil {
+		t.Errorf("Test: 'remove' FAILED : expected no pins, got %v (%v)", loaded, err)
+	}
+}
+
+func TestParsePinsInvalid(t *testing.T) {
+	if _, err := ParsePins([]byte("github.com/turbot/steampipe-mod-aws-compliance v1.2.3\n")); err == nil {
+		t.Errorf("Test: 'invalid' FAILED : expected error, got none")
+	}
+}
@@ -0,0 +1,410 @@
+package modsource
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/modinstaller"
+	"github.com/turbot/pipe-fittings/parse"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// the mod installer resolves and clones the dependencies of the workspace one at a time, so installing a mod with a
+// deep dependency graph is dominated by git round trips - before installing, the dependency graph is walked and the
+// repository of each dependency is fetched concurrently (up to --max-parallel at a time) into a temporary directory,
+// which then serves the repositories to the installer, as the local mirror does
+//
+// the installer resolves the versions in its usual order, from the refs of the fetched repositories, so the
+// resolution does not depend on the order in which they were fetched - and as the refs of each repository are listed
+// once, every constraint on a mod is resolved against the same versions
+// the graph is walked using the newest version satisfying each constraint (or the locked version, for the minimal and
+// development update strategies), so any repository which is not fetched (or fails to fetch) is fetched by the
+// installer as usual, and any errors are reported by it
+
+// DefaultPrefetchParallelism is the default maximum number of repositories fetched concurrently
+const DefaultPrefetchParallelism = 8
+
+var (
+	prefetchLock sync.RWMutex
+	prefetchDir  string
+)
+
+// prefetchURL returns the git url of the mod - a variable so tests may replace it
+var prefetchURL = func(modName string) string {
+	return "https://" + modName
+}
+
+// PrefetchDependencies fetches the repositories of the dependencies of the install, at most maxParallel at a time,
+// and serves them to the installer until the returned function is called (which removes them)
+func PrefetchDependencies(ctx context.Context, opts *modinstaller.InstallOpts, maxParallel int) (func(), error) {
+	InstallGitAuth()
+	dir, err := os.MkdirTemp("", "powerpipe-mods-")
+	if err != nil {
+		return nil, err
+	}
+
+	p := newPrefetcher(dir, maxParallel)
+	if opts.UpdateStrategy == constants.ModUpdateMinimal || opts.UpdateStrategy == constants.ModUpdateDevelopment {
+		// (a missing or invalid lock file is reported by the installer)
+		if lock, err := versionmap.LoadWorkspaceLock(opts.WorkspaceMod.ModPath); err == nil {
+			p.lock = lock
+		}
+	}
+	p.run(ctx, prefetchRequiredMods(opts))
+	slog.Debug("prefetched mod dependencies", "mods", p.fetchedMods(), "max_parallel", maxParallel)
+
+	setPrefetchDir(dir)
+	return func() {
+		setPrefetchDir("")
+		_ = os.RemoveAll(dir)
+	}, nil
+}
+
+// prefetchRequiredMods returns the mods required by the workspace mod and the mod args of the install
+// (mod args are installed using the latest version satisfying them, so are never resolved from the lock)
+func prefetchRequiredMods(opts *modinstaller.InstallOpts) []*prefetchItem {
+	var res []*prefetchItem
+	for _, arg := range opts.ModArgs {
+		if required, err := modconfig.NewModVersionConstraint(arg); err == nil {
+			res = append(res, &prefetchItem{required: required, latest: true})
+		}
+	}
+	if opts.WorkspaceMod.Require != nil {
+		for _, required := range opts.WorkspaceMod.Require.Mods {
+			res = append(res, &prefetchItem{required: required})
+		}
+	}
+	return res
+}
+
+func setPrefetchDir(dir string) {
+	prefetchLock.Lock()
+	defer prefetchLock.Unlock()
+	prefetchDir = dir
+}
+
+func getPrefetchDir() string {
+	prefetchLock.RLock()
+	defer prefetchLock.RUnlock()
+	return prefetchDir
+}
+
+// prefetchItem is a required mod of the dependency graph
+type prefetchItem struct {
+	required *modconfig.ModVersionConstraint
+	// whether the latest version satisfying the constraint is installed, regardless of the lock
+	latest bool
+}
+
+// prefetchRepo is a fetched repository
+type prefetchRepo struct {
+	once sync.Once
+	repo *git.Repository
+	err  error
+}
+
+// prefetcher walks the dependency graph, fetching the repository of each mod once
+type prefetcher struct {
+	dir  string
+	lock *versionmap.WorkspaceLock
+	// limits the number of concurrent fetches
+	sem chan struct{}
+	wg  sync.WaitGroup
+
+	mut   sync.Mutex
+	repos map[string]*prefetchRepo
+	// the mod versions whose dependencies have been walked
+	walked map[string]struct{}
+}
+
+func newPrefetcher(dir string, maxParallel int) *prefetcher {
+	if maxParallel < 1 {
+		maxParallel = 1
+	}
+	return &prefetcher{
+		dir:    dir,
+		sem:    make(chan struct{}, maxParallel),
+		repos:  make(map[string]*prefetchRepo),
+		walked: make(map[string]struct{}),
+	}
+}
+
+// run walks the dependency graph from the given mods, returning when all repositories have been fetched
+func (p *prefetcher) run(ctx context.Context, items []*prefetchItem) {
+	p.add(ctx, items)
+	p.wg.Wait()
+}
+
+func (p *prefetcher) add(ctx context.Context, items []*prefetchItem) {
+	for _, item := range items {
+		// (file path dependencies are not fetched)
+		if item.required.FilePath != "" {
+			continue
+		}
+		p.wg.Add(1)
+		go func(item *prefetchItem) {
+			defer p.wg.Done()
+			p.visit(ctx, item)
+		}(item)
+	}
+}
+
+// visit fetches the repository of the mod (unless the mod is resolved from the lock), and walks the dependencies of
+// the version which would be installed
+func (p *prefetcher) visit(ctx context.Context, item *prefetchItem) {
+	name := item.required.Name
+	if installed := p.lockedModPath(item); installed != "" {
+		if p.markWalked(name + "@" + installed) {
+			p.add(ctx, requiredModItems(loadPrefetchModfile(installed)))
+		}
+		return
+	}
+
+	repo, err := p.fetch(ctx, name)
+	if err != nil {
+		slog.Debug("failed to prefetch mod", "mod", name, "error", err)
+		return
+	}
+	ref := prefetchRef(repo, item.required)
+	if ref == "" || !p.markWalked(name+"@"+ref.String()) {
+		return
+	}
+	mod, err := readModfileAtRef(repo, ref)
+	if err != nil {
+		slog.Debug("failed to read the mod definition of prefetched mod", "mod", name, "ref", ref, "error", err)
+		return
+	}
+	p.add(ctx, requiredModItems(mod))
+}
+
+// lockedModPath returns the install path of the locked version of the mod if it satisfies the constraint and is
+// installed (so the installer does not fetch it), otherwise an empty string
+func (p *prefetcher) lockedModPath(item *prefetchItem) string {
+	if p.lock == nil || item.latest || item.required.BranchName != "" {
+		return ""
+	}
+	locked, err := p.lock.FindLockedModVersion(item.required)
+	if err != nil || locked == nil {
+		return ""
+	}
+	installPath := filepath.Join(p.lock.ModInstallationPath, locked.DependencyPath())
+	if _, err := os.Stat(installPath); err != nil {
+		return ""
+	}
+	return installPath
+}
+
+// markWalked returns whether the key was not already walked, marking it as walked
+func (p *prefetcher) markWalked(key string) bool {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	if _, ok := p.walked[key]; ok {
+		return false
+	}
+	p.walked[key] = struct{}{}
+	return true
+}
+
+// fetch fetches the repository of the mod, once
+func (p *prefetcher) fetch(ctx context.Context, name string) (*git.Repository, error) {
+	p.mut.Lock()
+	r, ok := p.repos[name]
+	if !ok {
+		r = &prefetchRepo{}
+		p.repos[name] = r
+	}
+	p.mut.Unlock()
+
+	r.once.Do(func() {
+		select {
+		case p.sem <- struct{}{}:
+		case <-ctx.Done():
+			r.err = ctx.Err()
+			return
+		}
+		defer func() { <-p.sem }()
+		r.repo, r.err = fetchMirror(ctx, name, filepath.Join(p.dir, filepath.FromSlash(name)))
+	})
+	return r.repo, r.err
+}
+
+// fetchedMods returns the names of the fetched repositories, sorted
+func (p *prefetcher) fetchedMods() []string {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	var res []string
+	for name, r := range p.repos {
+		if r.err == nil {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// fetchMirror fetches the branches and tags of the repository of the mod into a bare repository (as
+// 'git clone --mirror' does), which is removed if the fetch fails
+func fetchMirror(ctx context.Context, name, dir string) (*git.Repository, error) {
+	repo, err := git.PlainInit(dir, true)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := repo.CreateRemote(&config.RemoteConfig{
+		Name:  "origin",
+		URLs:  []string{prefetchURL(name)},
+		Fetch: []config.RefSpec{"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"},
+	})
+	if err == nil {
+		err = remote.FetchContext(ctx, &git.FetchOptions{Auth: gitTokenAuth(), Tags: git.NoTags})
+	}
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	// the head of the repository must resolve for the repository to be served, so it is set to the first branch if
+	// there is no master branch
+	if _, err := repo.Reference(plumbing.Master, false); err != nil {
+		if branch := firstBranch(repo); branch != "" {
+			_ = repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, branch))
+		}
+	}
+	return repo, nil
+}
+
+func firstBranch(repo *git.Repository) plumbing.ReferenceName {
+	branches, err := repo.Branches()
+	if err != nil {
+		return ""
+	}
+	var names []string
+	_ = branches.ForEach(func(ref *plumbing.Reference) error {
+		names = append(names, ref.Name().String())
+		return nil
+	})
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return plumbing.ReferenceName(names[0])
+}
+
+// prefetchRef returns the ref of the version of the mod which would be installed for the constraint - the branch or
+// tag, or the newest version satisfying the version constraint - or an empty string if there is none
+func prefetchRef(repo *git.Repository, required *modconfig.ModVersionConstraint) plumbing.ReferenceName {
+	switch {
+	case required.BranchName != "":
+		return plumbing.NewBranchReferenceName(required.BranchName)
+	case required.Tag != "":
+		return plumbing.NewTagReferenceName(required.Tag)
+	case required.VersionConstraint() == nil:
+		return ""
+	}
+	tags, err := repo.Tags()
+	if err != nil {
+		return ""
+	}
+	var names []string
+	_ = tags.ForEach(func(ref *plumbing.Reference) error {
+		names = append(names, ref.Name().Short())
+		return nil
+	})
+	newest := newestVersion(names, required)
+	if newest == nil {
+		return ""
+	}
+	return plumbing.NewTagReferenceName(newest.Original())
+}
+
+// readModfileAtRef parses the mod file of the commit of the ref, returning nil if it has no mod file
+func readModfileAtRef(repo *git.Repository, ref plumbing.ReferenceName) (*modconfig.Mod, error) {
+	resolved, err := repo.Reference(ref, true)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(resolved.Hash())
+	if err != nil {
+		// annotated tags refer to a tag object
+		tag, tagErr := repo.TagObject(resolved.Hash())
+		if tagErr != nil {
+			return nil, err
+		}
+		if commit, err = tag.Commit(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, fileName := range app_specific.ModFileNames() {
+		file, err := commit.File(fileName)
+		if err == object.ErrFileNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		contents, err := file.Contents()
+		if err != nil {
+			return nil, err
+		}
+		// (the mod file is parsed from a directory, as for installed mods)
+		modDir, err := os.MkdirTemp("", "powerpipe-modfile-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(modDir)
+		if err := os.WriteFile(filepath.Join(modDir, fileName), []byte(contents), 0600); err != nil {
+			return nil, err
+		}
+		return parse.LoadModfile(modDir)
+	}
+	return nil, nil
+}
+
+// loadPrefetchModfile loads the mod file of an installed mod, returning nil if it cannot be loaded
+func loadPrefetchModfile(installPath string) *modconfig.Mod {
+	mod, err := parse.LoadModfile(installPath)
+	if err != nil {
+		slog.Debug("failed to load the mod definition of installed mod", "path", installPath, "error", err)
+		return nil
+	}
+	return mod
+}
+
+func requiredModItems(mod *modconfig.Mod) []*prefetchItem {
+	if mod == nil || mod.Require == nil {
+		return nil
+	}
+	res := make([]*prefetchItem, len(mod.Require.Mods))
+	for i, required := range mod.Require.Mods {
+		res[i] = &prefetchItem{required: required}
+	}
+	return res
+}
+
+// prefetchTransport serves the repositories fetched by PrefetchDependencies to the installer, passing requests for
+// any other repository (and all requests when no dependencies are prefetched) to the base transport
+type prefetchTransport struct {
+	base transport.Transport
+}
+
+func (t *prefetchTransport) NewUploadPackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.UploadPackSession, error) {
+	if dir := getPrefetchDir(); dir != "" {
+		return newMirrorTransport(dir, t.base).NewUploadPackSession(ep, auth)
+	}
+	return t.base.NewUploadPackSession(ep, auth)
+}
+
+func (t *prefetchTransport) NewReceivePackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.ReceivePackSession, error) {
+	return t.base.NewReceivePackSession(ep, auth)
+}
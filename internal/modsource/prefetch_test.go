@@ -0,0 +1,170 @@
+package modsource
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	gitclient "github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+// the mod files of each tagged version of the test repositories
+var testPrefetchRepos = map[string]map[string]string{
+	"github.com/acme/a": {
+		// (v1.0.0 is not the newest version satisfying ^1, so its dependencies are not fetched)
+		"v1.0.0": `mod "a" {
+  require {
+    mod "github.com/acme/unused" {
+      version = "*"
+    }
+  }
+}`,
+		"v1.1.0": `mod "a" {
+  require {
+    mod "github.com/acme/b" {
+      version = "^2"
+    }
+    mod "github.com/acme/c" {
+      version = "*"
+    }
+  }
+}`,
+	},
+	"github.com/acme/b": {
+		"v2.0.0": `mod "b" {
+  require {
+    mod "github.com/acme/d" {
+      version = "^1"
+    }
+  }
+}`,
+	},
+	"github.com/acme/c": {
+		"v0.1.0": `mod "c" {
+  require {
+    mod "github.com/acme/d" {
+      version = "~1.0"
+    }
+  }
+}`,
+	},
+	"github.com/acme/d": {
+		"v1.0.0": `mod "d" {}`,
+		"v1.2.0": `mod "d" {}`,
+	},
+	"github.com/acme/unused": {
+		"v1.0.0": `mod "unused" {}`,
+	},
+}
+
+func TestPrefetch(t *testing.T) {
+	// (app specific values are set when the CLI starts)
+	app_specific.ModDataExtensions = []string{".pp", ".sp"}
+	remote := t.TempDir()
+	for name, versions := range testPrefetchRepos {
+		initTestModRepo(t, filepath.Join(remote, name), versions)
+	}
+	gitclient.InstallProtocol("https", newMirrorTransport(remote, githttp.DefaultClient))
+	defer gitclient.InstallProtocol("https", githttp.DefaultClient)
+
+	required, err := modconfig.NewModVersionConstraint("github.com/acme/a@^1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same repositories and versions are fetched however many are fetched concurrently
+	expectedMods := []string{"github.com/acme/a", "github.com/acme/b", "github.com/acme/c", "github.com/acme/d"}
+	expectedWalked := []string{
+		"github.com/acme/a@refs/tags/v1.1.0",
+		"github.com/acme/b@refs/tags/v2.0.0",
+		"github.com/acme/c@refs/tags/v0.1.0",
+		"github.com/acme/d@refs/tags/v1.0.0",
+		"github.com/acme/d@refs/tags/v1.2.0",
+	}
+	for _, maxParallel := range []int{1, 4} {
+		p := newPrefetcher(t.TempDir(), maxParallel)
+		p.run(context.Background(), []*prefetchItem{{required: required}})
+
+		if actual := p.fetchedMods(); !reflect.DeepEqual(actual, expectedMods) {
+			t.Errorf("Test: 'max parallel %d' FAILED : expected %v, got %v", maxParallel, expectedMods, actual)
+		}
+		var walked []string
+		for key := range p.walked {
+			walked = append(walked, key)
+		}
+		sort.Strings(walked)
+		if !reflect.DeepEqual(walked, expectedWalked) {
+			t.Errorf("Test: 'max parallel %d' FAILED : expected %v, got %v", maxParallel, expectedWalked, walked)
+		}
+	}
+}
+
+func TestPrefetchTransport(t *testing.T) {
+	dir := t.TempDir()
+	commit := initTestRepo(t, filepath.Join(dir, "github.com/acme/mod"), true)
+
+	// the prefetched repositories are only served while the prefetch directory is set
+	gitclient.InstallProtocol("https", &prefetchTransport{base: githttp.DefaultClient})
+	defer gitclient.InstallProtocol("https", githttp.DefaultClient)
+	setPrefetchDir(dir)
+	defer setPrefetchDir("")
+
+	repo, err := git.PlainClone(t.TempDir(), false, &git.CloneOptions{
+		URL:           "https://github.com/acme/mod",
+		ReferenceName: plumbing.NewTagReferenceName("v1.0.0"),
+		Depth:         1,
+		SingleBranch:  true,
+	})
+	if err != nil {
+		t.Fatalf("Test: 'clone' FAILED : unexpected error: %v", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Hash() != commit {
+		t.Errorf("Test: 'clone' FAILED : expected %s, got %s", commit, head.Hash())
+	}
+}
+
+// initTestModRepo creates a repository with a commit of the mod file of each version, tagged with the version
+func initTestModRepo(t *testing.T, dir string, versions map[string]string) {
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := make([]string, 0, len(versions))
+	for tag := range versions {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		if err := os.WriteFile(filepath.Join(dir, "mod.pp"), []byte(versions[tag]), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Add("mod.pp"); err != nil {
+			t.Fatal(err)
+		}
+		commit, err := wt.Commit(tag, &git.CommitOptions{Author: &object.Signature{Name: "test", When: time.Now()}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.CreateTag(tag, commit, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package modsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+)
+
+// mods published to the public hub are found using the registry API - the search endpoint returns the mods whose
+// name, title or description match the search term, e.g.
+//
+//	GET https://hub.powerpipe.io/api/mods?search=aws
+//
+//	{"items": [{"name": "github.com/turbot/steampipe-mod-aws-compliance", "title": "AWS Compliance", ...}]}
+//
+// the registry url may be set using POWERPIPE_MOD_REGISTRY, e.g. for a private mirror of the hub
+const (
+	DefaultRegistryUrl = "https://hub.powerpipe.io/api"
+
+	registryTimeout = 30 * time.Second
+)
+
+// RegistryMod is a mod published to the registry
+type RegistryMod struct {
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// the latest published version
+	Version     string   `json:"version,omitempty"`
+	Maintainers []string `json:"maintainers,omitempty"`
+}
+
+// Registry searches the mods published to the registry
+type Registry struct {
+	url    string
+	client *http.Client
+}
+
+// NewRegistry returns a Registry configured from the environment
+func NewRegistry() *Registry {
+	registryUrl := os.Getenv(localconstants.EnvModRegistry)
+	if registryUrl == "" {
+		registryUrl = DefaultRegistryUrl
+	}
+	return &Registry{
+		url:    strings.TrimSuffix(registryUrl, "/"),
+		client: &http.Client{Transport: tlspolicy.HTTPClient().Transport, Timeout: registryTimeout},
+	}
+}
+
+// Search returns the mods matching the search term, in the order returned by the registry
+func (r *Registry) Search(ctx context.Context, term string) ([]*RegistryMod, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/mods?search=%s", r.url, url.QueryEscape(term)), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search the mod registry: %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to search the mod registry: %s returned %s", r.url, resp.Status)
+	}
+	var res struct {
+		Items []*RegistryMod `json:"items"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10*1024*1024)).Decode(&res); err != nil {
+		return nil, fmt.Errorf("invalid mod registry response: %s", err.Error())
+	}
+	return res.Items, nil
+}
@@ -0,0 +1,82 @@
+package modsource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type registrySearchTest struct {
+	term        string
+	expectNames []string
+	expectError bool
+}
+
+var testCasesRegistrySearch = map[string]registrySearchTest{
+	"found": {
+		term:        "aws",
+		expectNames: []string{"github.com/turbot/steampipe-mod-aws-compliance", "github.com/turbot/steampipe-mod-aws-insights"},
+	},
+	"none found": {
+		term: "nothing",
+	},
+	"escaped term": {
+		term:        "aws compliance",
+		expectNames: []string{"github.com/turbot/steampipe-mod-aws-compliance"},
+	},
+	"registry error": {
+		term:        "broken",
+		expectError: true,
+	},
+	"invalid response": {
+		term:        "invalid",
+		expectError: true,
+	},
+}
+
+func TestRegistrySearch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/mods" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Query().Get("search") {
+		case "aws":
+			_, _ = w.Write([]byte(`{"items": [{"name": "github.com/turbot/steampipe-mod-aws-compliance", "version": "v1.0.0", "maintainers": ["turbot"]}, {"name": "github.com/turbot/steampipe-mod-aws-insights"}]}`))
+		case "aws compliance":
+			_, _ = w.Write([]byte(`{"items": [{"name": "github.com/turbot/steampipe-mod-aws-compliance"}]}`))
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "invalid":
+			_, _ = w.Write([]byte(`not json`))
+		default:
+			_, _ = w.Write([]byte(`{"items": []}`))
+		}
+	}))
+	defer server.Close()
+
+	registry := &Registry{url: server.URL + "/api", client: server.Client()}
+	for name, test := range testCasesRegistrySearch {
+		mods, err := registry.Search(context.Background(), test.term)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected an error, got %d mods", name, len(mods))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %s", name, err.Error())
+			continue
+		}
+		if len(mods) != len(test.expectNames) {
+			t.Errorf("Test: '%s' FAILED : expected %d mods, got %d", name, len(test.expectNames), len(mods))
+			continue
+		}
+		for i, mod := range mods {
+			if mod.Name != test.expectNames[i] {
+				t.Errorf("Test: '%s' FAILED : expected mod %s, got %s", name, test.expectNames[i], mod.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package modsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/filepaths"
+	"github.com/turbot/pipe-fittings/versionmap"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+	"golang.org/x/mod/sumdb/note"
+	"golang.org/x/mod/sumdb/tlog"
+)
+
+// the commit of each mod version installed from the public hub (i.e. hosted on github.com) is verified against the
+// mod checksum database, a transparency log of the commit each published version tag pointed to when it was first seen
+// - this protects against a version tag being rewritten to point at a different commit
+//
+// the log is a Merkle tree of records of the form '<name> <version> <commit>' (using the tlog format of the Go checksum
+// database) - a lookup returns the index of the record, its inclusion proof, and the tree head signed by the database:
+//
+//	<index>
+//	<name> <version> <commit>
+//	<proof hash>...
+//
+//	<signed tree note>
+//
+// so a response is only trusted if it is signed with the key of the database, and the record is included in the tree
+//
+// the commits of verified versions are also recorded locally, and a version which is later installed with a different
+// commit fails verification even if the checksum database cannot be reached
+//
+// verification is configured using:
+//
+//	POWERPIPE_MODSUMDB     - the url of the checksum database, or 'off' to disable verification
+//	POWERPIPE_MODSUMDB_KEY - the verifier key of the checksum database, required if POWERPIPE_MODSUMDB is set
+//	POWERPIPE_MODNOSUMDB   - a comma separated list of mod name prefixes which are not verified (e.g. private mods)
+//
+// if POWERPIPE_MODSUMDB is not set and the build has no key for the default database (i.e. it is not a release build),
+// verification is off - a warning is shown the first time mods are installed without verification
+const (
+	DefaultSumDBUrl = "https://hub.powerpipe.io/sumdb"
+	SumDBOff        = "off"
+
+	sumDBHost          = "github.com"
+	sumDBLookupTimeout = 10 * time.Second
+)
+
+// SumDBKey is the verifier key (in the note format, '<name>+<hash>+<key>') of the default checksum database
+// it is set at build time - a build without it cannot verify mods using the default database
+var SumDBKey string
+
+var noSumDBKeyWarningOnce sync.Once
+
+// ModSum is the commit of an installed mod version
+type ModSum struct {
+	Name    string
+	Version string
+	Commit  string
+}
+
+func (s ModSum) key() string {
+	return fmt.Sprintf("%s@%s", s.Name, s.Version)
+}
+
+// SumMismatchError is returned when the commit of an installed mod version does not match the commit recorded for it
+type SumMismatchError struct {
+	ModSum
+	Expected string
+	Source   string
+}
+
+func (e *SumMismatchError) Error() string {
+	return fmt.Sprintf("verifying %s@%s: checksum mismatch\n\tdownloaded: %s\n\t%s: %s\nthe version tag may have been rewritten - the mod has not been trusted",
+		e.Name, e.Version, e.Commit, e.Source, e.Expected)
+}
+
+// UnverifiedError is returned when a mod version cannot be verified, e.g. as the checksum database cannot be reached
+// or its response is not signed - the mod is not installed unless verification is disabled for it
+type UnverifiedError struct {
+	ModSum
+	Err error
+}
+
+func (e *UnverifiedError) Error() string {
+	return fmt.Sprintf("verifying %s@%s: %s\nthe mod has not been trusted - to install it without verification, add it to %s, or set %s=%s",
+		e.Name, e.Version, e.Err.Error(), localconstants.EnvModNoSumDB, localconstants.EnvModSumDB, SumDBOff)
+}
+
+func (e *UnverifiedError) Unwrap() error {
+	return e.Err
+}
+
+// SumDB verifies installed mod versions against the checksum database
+type SumDB struct {
+	url string
+	// the verifier of the signature of the database (nil if the database has no key)
+	verifier  note.Verifier
+	noSumDB   []string
+	client    *http.Client
+	knownPath string
+}
+
+// NewSumDB returns a SumDB configured from the environment, or nil if verification is disabled (or the default
+// database is used and there is no key for it)
+func NewSumDB() (*SumDB, error) {
+	url := os.Getenv(localconstants.EnvModSumDB)
+	key := os.Getenv(localconstants.EnvModSumDBKey)
+	if url == "" {
+		url = DefaultSumDBUrl
+		if key == "" {
+			key = SumDBKey
+		}
+		if key == "" {
+			noSumDBKeyWarningOnce.Do(func() {
+				error_helpers.ShowWarning(fmt.Sprintf("mods are not verified against the checksum database, as this build has no verifier key - set %s to verify them", localconstants.EnvModSumDBKey))
+			})
+			return nil, nil
+		}
+	}
+	if strings.EqualFold(url, SumDBOff) {
+		return nil, nil
+	}
+	var verifier note.Verifier
+	if key != "" {
+		var err error
+		if verifier, err = note.NewVerifier(key); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", localconstants.EnvModSumDBKey, err)
+		}
+	}
+	var noSumDB []string
+	for _, prefix := range strings.Split(os.Getenv(localconstants.EnvModNoSumDB), ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			noSumDB = append(noSumDB, prefix)
+		}
+	}
+	return &SumDB{
+		url:       strings.TrimSuffix(url, "/"),
+		verifier:  verifier,
+		noSumDB:   noSumDB,
+		client:    &http.Client{Transport: tlspolicy.HTTPClient().Transport, Timeout: sumDBLookupTimeout},
+		knownPath: filepath.Join(filepaths.EnsureInternalDir(), "mod_sums.json"),
+	}, nil
+}
+
+// Verify verifies the commit of each mod version in the lock which was installed from the public hub - an error is
+// returned if any commit does not match or cannot be verified, and a warning for each version which is not in the
+// checksum database (i.e. has not been published)
+func (s *SumDB) Verify(ctx context.Context, lock *versionmap.WorkspaceLock) (warnings []string, err error) {
+	if lock == nil {
+		return nil, nil
+	}
+	return s.verify(ctx, LockedModSums(lock))
+}
+
+func (s *SumDB) verify(ctx context.Context, sums []ModSum) (warnings []string, err error) {
+	known, err := loadKnownSums(s.knownPath)
+	if err != nil {
+		return nil, err
+	}
+	updated := false
+	for _, sum := range sums {
+		if !s.shouldVerify(sum.Name) {
+			continue
+		}
+		if expected, ok := known[sum.key()]; ok {
+			if expected != sum.Commit {
+				return warnings, &SumMismatchError{ModSum: sum, Expected: expected, Source: "previously installed"}
+			}
+			continue
+		}
+
+		expected, found, err := s.lookup(ctx, sum)
+		if err != nil {
+			return warnings, &UnverifiedError{ModSum: sum, Err: err}
+		}
+		if !found {
+			warnings = append(warnings, fmt.Sprintf("%s is not in the checksum database, so has not been verified", sum.key()))
+			continue
+		}
+		if expected != sum.Commit {
+			return warnings, &SumMismatchError{ModSum: sum, Expected: expected, Source: "sumdb"}
+		}
+		known[sum.key()] = sum.Commit
+		updated = true
+	}
+	if updated {
+		if err := saveKnownSums(s.knownPath, known); err != nil {
+			return warnings, err
+		}
+	}
+	return warnings, nil
+}
+
+// shouldVerify returns whether the mod is installed from the public hub and is not excluded by POWERPIPE_MODNOSUMDB
+func (s *SumDB) shouldVerify(modName string) bool {
+	if !strings.HasPrefix(modName, sumDBHost+"/") {
+		return false
+	}
+	for _, prefix := range s.noSumDB {
+		if modName == prefix || strings.HasPrefix(modName, strings.TrimSuffix(prefix, "/")+"/") {
+			return false
+		}
+	}
+	return true
+}
+
+// lookup returns the commit recorded in the checksum database for the mod version, verifying the signature of the
+// response and the inclusion of the record in the signed tree
+func (s *SumDB) lookup(ctx context.Context, sum ModSum) (commit string, found bool, err error) {
+	if s.verifier == nil {
+		return "", false, fmt.Errorf("the checksum database has no verifier key - set %s", localconstants.EnvModSumDBKey)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/lookup/%s", s.url, sum.key()), nil)
+	if err != nil {
+		return "", false, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusGone:
+		return "", false, nil
+	default:
+		return "", false, fmt.Errorf("checksum database returned %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", false, err
+	}
+	record, err := s.verifyLookup(body)
+	if err != nil {
+		return "", false, err
+	}
+	fields := strings.Fields(record)
+	if len(fields) != 3 || fields[0] != sum.Name || fields[1] != sum.Version {
+		return "", false, fmt.Errorf("checksum database returned the record '%s'", record)
+	}
+	return fields[2], true, nil
+}
+
+// verifyLookup verifies the signature of the tree of a lookup response, and the proof the record is included in the
+// tree, returning the record
+func (s *SumDB) verifyLookup(body []byte) (string, error) {
+	head, signedTree, ok := strings.Cut(string(body), "\n\n")
+	if !ok {
+		return "", fmt.Errorf("invalid checksum database response")
+	}
+	treeNote, err := note.Open([]byte(signedTree), note.VerifierList(s.verifier))
+	if err != nil {
+		return "", fmt.Errorf("invalid checksum database signature: %w", err)
+	}
+	tree, err := tlog.ParseTree([]byte(treeNote.Text))
+	if err != nil {
+		return "", fmt.Errorf("invalid checksum database tree: %w", err)
+	}
+
+	lines := strings.Split(head, "\n")
+	if len(lines) < 2 {
+		return "", fmt.Errorf("invalid checksum database response")
+	}
+	index, err := strconv.ParseInt(lines[0], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid checksum database record index '%s'", lines[0])
+	}
+	record := lines[1]
+	var proof tlog.RecordProof
+	for _, line := range lines[2:] {
+		hash, err := tlog.ParseHash(line)
+		if err != nil {
+			return "", fmt.Errorf("invalid checksum database proof: %w", err)
+		}
+		proof = append(proof, hash)
+	}
+	if err := tlog.CheckRecord(proof, tree.N, tree.Hash, index, tlog.RecordHash([]byte(record+"\n"))); err != nil {
+		return "", fmt.Errorf("the checksum database record is not in its signed tree: %w", err)
+	}
+	return record, nil
+}
+
+// LockedModSums returns the sum of each version tagged mod in the lock, sorted by name and version
+// (branch and file path dependencies have no fixed commit, so are not included)
+func LockedModSums(lock *versionmap.WorkspaceLock) []ModSum {
+	var res []ModSum
+	seen := make(map[string]struct{})
+	for _, deps := range lock.InstallCache {
+		for _, dep := range deps {
+			if dep == nil || dep.ResolvedVersionConstraint == nil || dep.Commit == "" || dep.FilePath != "" || dep.Branch != "" {
+				continue
+			}
+			version := lockedVersion(dep)
+			if version == "" {
+				continue
+			}
+			sum := ModSum{Name: dep.Name, Version: version, Commit: dep.Commit}
+			if _, ok := seen[sum.key()]; ok {
+				continue
+			}
+			seen[sum.key()] = struct{}{}
+			res = append(res, sum)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].key() < res[j].key() })
+	return res
+}
+
+// loadKnownSums loads the map of mod version to commit of previously verified versions
+func loadKnownSums(path string) (map[string]string, error) {
+	known := make(map[string]string)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return known, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &known); err != nil {
+		return nil, fmt.Errorf("failed to load verified mod checksums from %s: %s", path, err.Error())
+	}
+	return known, nil
+}
+
+func saveKnownSums(path string, known map[string]string) error {
+	data, err := json.MarshalIndent(known, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,271 @@
+package modsource
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"golang.org/x/mod/sumdb/note"
+	"golang.org/x/mod/sumdb/tlog"
+)
+
+// testSumDB is a checksum database serving signed lookups of its records
+type testSumDB struct {
+	records []string
+	hashes  []tlog.Hash
+	signer  note.Signer
+	// the verifier key of the database
+	vkey string
+}
+
+func newTestSumDB(t *testing.T, records ...string) *testSumDB {
+	skey, vkey, err := note.GenerateKey(rand.Reader, "sumdb.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := &testSumDB{signer: signer, vkey: vkey}
+	for _, record := range records {
+		hashes, err := tlog.StoredHashes(int64(len(db.records)), []byte(record+"\n"), db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.records = append(db.records, record)
+		db.hashes = append(db.hashes, hashes...)
+	}
+	return db
+}
+
+func (db *testSumDB) ReadHashes(indexes []int64) ([]tlog.Hash, error) {
+	res := make([]tlog.Hash, len(indexes))
+	for i, index := range indexes {
+		res[i] = db.hashes[index]
+	}
+	return res, nil
+}
+
+// lookup returns the signed lookup response of the record with the index, returning the record as the given text
+// (to test forged records)
+func (db *testSumDB) lookup(index int, record string) (string, error) {
+	n := int64(len(db.records))
+	treeHash, err := tlog.TreeHash(n, db)
+	if err != nil {
+		return "", err
+	}
+	signedTree, err := note.Sign(&note.Note{Text: string(tlog.FormatTree(tlog.Tree{N: n, Hash: treeHash}))}, db.signer)
+	if err != nil {
+		return "", err
+	}
+	proof, err := tlog.ProveRecord(n, int64(index), db)
+	if err != nil {
+		return "", err
+	}
+	lines := []string{fmt.Sprintf("%d", index), record}
+	for _, hash := range proof {
+		lines = append(lines, hash.String())
+	}
+	return strings.Join(lines, "\n") + "\n\n" + string(signedTree), nil
+}
+
+func (db *testSumDB) verifier(t *testing.T) note.Verifier {
+	verifier, err := note.NewVerifier(db.vkey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verifier
+}
+
+type sumDBVerifyTest struct {
+	sums     []ModSum
+	known    map[string]string
+	noSumDB  []string
+	warnings int
+	// the commit expected by the mismatch error, if any
+	mismatch string
+	// whether an unverified error is expected
+	unverified bool
+}
+
+var testCasesSumDBVerify = map[string]sumDBVerifyTest{
+	"verified": {
+		sums: []ModSum{{Name: "github.com/turbot/steampipe-mod-aws-compliance", Version: "v1.0.0", Commit: "abc123"}},
+	},
+	"tag rewritten": {
+		sums:     []ModSum{{Name: "github.com/turbot/steampipe-mod-aws-compliance", Version: "v1.0.0", Commit: "evil99"}},
+		mismatch: "abc123",
+	},
+	"not in sumdb": {
+		sums:     []ModSum{{Name: "github.com/turbot/steampipe-mod-aws-compliance", Version: "v2.0.0", Commit: "def456"}},
+		warnings: 1,
+	},
+	"sumdb error": {
+		sums:       []ModSum{{Name: "github.com/turbot/broken", Version: "v1.0.0", Commit: "def456"}},
+		unverified: true,
+	},
+	"unsigned": {
+		sums:       []ModSum{{Name: "github.com/turbot/unsigned", Version: "v1.0.0", Commit: "evil99"}},
+		unverified: true,
+	},
+	"forged record": {
+		sums:       []ModSum{{Name: "github.com/turbot/forged", Version: "v1.0.0", Commit: "evil99"}},
+		unverified: true,
+	},
+	"other record": {
+		sums:       []ModSum{{Name: "github.com/turbot/other", Version: "v1.0.0", Commit: "abc123"}},
+		unverified: true,
+	},
+	"previously installed, sumdb unavailable": {
+		sums:  []ModSum{{Name: "github.com/turbot/broken", Version: "v1.0.0", Commit: "def456"}},
+		known: map[string]string{"github.com/turbot/broken@v1.0.0": "def456"},
+	},
+	"previously installed mismatch": {
+		sums:     []ModSum{{Name: "github.com/turbot/steampipe-mod-aws-compliance", Version: "v2.0.0", Commit: "def456"}},
+		known:    map[string]string{"github.com/turbot/steampipe-mod-aws-compliance@v2.0.0": "fed654"},
+		mismatch: "fed654",
+	},
+	"previously installed": {
+		sums:  []ModSum{{Name: "github.com/turbot/steampipe-mod-aws-compliance", Version: "v2.0.0", Commit: "def456"}},
+		known: map[string]string{"github.com/turbot/steampipe-mod-aws-compliance@v2.0.0": "def456"},
+	},
+	"not hub": {
+		sums: []ModSum{{Name: "gitlab.com/acme/controls", Version: "v1.0.0", Commit: "evil99"}},
+	},
+	"excluded": {
+		sums:    []ModSum{{Name: "github.com/acme/private-controls", Version: "v1.0.0", Commit: "evil99"}},
+		noSumDB: []string{"github.com/acme"},
+	},
+}
+
+func TestSumDBVerify(t *testing.T) {
+	db := newTestSumDB(t,
+		"github.com/turbot/steampipe-mod-aws-compliance v1.0.0 abc123",
+		"github.com/turbot/controls v1.0.0 abc123",
+		"github.com/turbot/forged v1.0.0 abc123",
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body string
+		var err error
+		switch r.URL.Path {
+		case "/lookup/github.com/turbot/steampipe-mod-aws-compliance@v1.0.0":
+			body, err = db.lookup(0, db.records[0])
+		case "/lookup/github.com/turbot/other@v1.0.0":
+			// a valid record of a different mod
+			body, err = db.lookup(1, db.records[1])
+		case "/lookup/github.com/turbot/forged@v1.0.0":
+			// a record which is not in the tree
+			body, err = db.lookup(2, "github.com/turbot/forged v1.0.0 evil99")
+		case "/lookup/github.com/turbot/unsigned@v1.0.0":
+			body = "github.com/turbot/unsigned v1.0.0 evil99\n"
+		case "/lookup/github.com/turbot/broken@v1.0.0":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	for name, test := range testCasesSumDBVerify {
+		knownPath := filepath.Join(t.TempDir(), "mod_sums.json")
+		if test.known != nil {
+			if err := saveKnownSums(knownPath, test.known); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s := &SumDB{url: server.URL, verifier: db.verifier(t), noSumDB: test.noSumDB, client: server.Client(), knownPath: knownPath}
+
+		warnings, err := s.verify(context.Background(), test.sums)
+
+		var mismatchErr *SumMismatchError
+		var unverifiedErr *UnverifiedError
+		if errors.As(err, &unverifiedErr) {
+			if !test.unverified {
+				t.Errorf("Test: '%s' FAILED : unexpected error %s", name, err.Error())
+			}
+		} else if test.unverified {
+			t.Errorf("Test: '%s' FAILED : expected unverified error, got %v", name, err)
+		} else if errors.As(err, &mismatchErr) {
+			if mismatchErr.Expected != test.mismatch {
+				t.Errorf("Test: '%s' FAILED : expected mismatch with '%s', got '%s'", name, test.mismatch, mismatchErr.Expected)
+			}
+		} else if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %s", name, err.Error())
+		} else if test.mismatch != "" {
+			t.Errorf("Test: '%s' FAILED : expected mismatch with '%s', got none", name, test.mismatch)
+		}
+		if len(warnings) != test.warnings {
+			t.Errorf("Test: '%s' FAILED : expected %d warnings, got %d: %s", name, test.warnings, len(warnings), strings.Join(warnings, ", "))
+		}
+	}
+}
+
+func TestSumDBRecordsVerifiedSums(t *testing.T) {
+	db := newTestSumDB(t, "github.com/turbot/controls v1.0.0 abc123")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := db.lookup(0, db.records[0])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	knownPath := filepath.Join(t.TempDir(), "mod_sums.json")
+	s := &SumDB{url: server.URL, verifier: db.verifier(t), client: server.Client(), knownPath: knownPath}
+
+	if _, err := s.verify(context.Background(), []ModSum{{Name: "github.com/turbot/controls", Version: "v1.0.0", Commit: "abc123"}}); err != nil {
+		t.Fatal(err)
+	}
+	// once recorded, a rewritten tag is detected even when the checksum database cannot be reached
+	server.Close()
+	_, err := s.verify(context.Background(), []ModSum{{Name: "github.com/turbot/controls", Version: "v1.0.0", Commit: "evil99"}})
+	var mismatchErr *SumMismatchError
+	if !errors.As(err, &mismatchErr) {
+		t.Errorf("Test: 'recorded' FAILED : expected mismatch error, got %v", err)
+	}
+	if _, statErr := os.Stat(knownPath); statErr != nil {
+		t.Errorf("Test: 'recorded' FAILED : expected known sums file, got %s", statErr.Error())
+	}
+}
+
+func TestSumDBNoKey(t *testing.T) {
+	s := &SumDB{url: "https://sumdb.test", client: http.DefaultClient, knownPath: filepath.Join(t.TempDir(), "mod_sums.json")}
+	_, err := s.verify(context.Background(), []ModSum{{Name: "github.com/turbot/controls", Version: "v1.0.0", Commit: "abc123"}})
+	var unverifiedErr *UnverifiedError
+	if !errors.As(err, &unverifiedErr) {
+		t.Errorf("Test: 'no key' FAILED : expected unverified error, got %v", err)
+	}
+}
+
+func TestNewSumDBNoDefaultKey(t *testing.T) {
+	t.Setenv(localconstants.EnvModSumDB, "")
+	t.Setenv(localconstants.EnvModSumDBKey, "")
+	defaultKey := SumDBKey
+	SumDBKey = ""
+	defer func() { SumDBKey = defaultKey }()
+
+	// a build without a key for the default database does not verify mods (rather than failing every install)
+	s, err := NewSumDB()
+	if err != nil {
+		t.Errorf("Test: 'no default key' FAILED : expected no error, got %s", err.Error())
+	}
+	if s != nil {
+		t.Errorf("Test: 'no default key' FAILED : expected verification to be off, got %v", s)
+	}
+}
@@ -0,0 +1,184 @@
+package modsource
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/filepaths"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// the installed dependencies of a workspace may be vendored - copied into .powerpipe/vendor, with the same layout as
+// .powerpipe/mods - so the full mod graph can be committed with the workspace and the workspace built without access
+// to the git hosts of its dependencies:
+//
+//	powerpipe mod vendor
+//
+// the mod installer and workspace loading resolve dependencies from .powerpipe/mods, so before installing and loading,
+// any dependency of the lock file which is not installed is restored from the vendor directory (and the lock file
+// itself, if it does not exist) - dependencies are only fetched from git if they are not vendored, or the lock file
+// no longer satisfies the constraints of the mod file (e.g. after changing a version constraint, run 'mod install' and
+// 'mod vendor' again)
+// the lock file is copied into the vendor directory, so the vendored versions are known without reading each mod
+// (file path dependencies are not vendored)
+const VendorDirName = "vendor"
+
+// VendorPath returns the vendor directory of the workspace
+func VendorPath(workspacePath string) string {
+	return filepath.Join(workspacePath, app_specific.WorkspaceDataDir, VendorDirName)
+}
+
+// VendorResult is the result of Vendor
+type VendorResult struct {
+	// the vendored mod versions, sorted
+	Vendored []string
+	// the file path dependencies, which are not vendored, sorted
+	Skipped []string
+}
+
+// Vendor replaces the vendor directory of the workspace with the installed dependencies of the lock file - an error is
+// returned if any dependency is not installed
+func Vendor(workspacePath string) (*VendorResult, error) {
+	lock, err := versionmap.LoadWorkspaceLock(workspacePath)
+	if err != nil {
+		return nil, err
+	}
+	if lock.Incomplete() {
+		return nil, fmt.Errorf("the dependencies of the workspace are not all installed - run '%s mod install' before vendoring them", app_specific.AppName)
+	}
+	if lock.Empty() {
+		return nil, fmt.Errorf("the workspace has no installed dependencies to vendor")
+	}
+
+	// the vendor directory is built alongside the existing one, then swapped in
+	vendorPath := VendorPath(workspacePath)
+	newPath := vendorPath + ".new"
+	if err := os.RemoveAll(newPath); err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(newPath)
+
+	res := &VendorResult{}
+	// (a mod version may be the dependency of more than one mod)
+	vendored := make(map[string]struct{})
+	for _, deps := range lock.InstallCache {
+		for _, dep := range deps {
+			depPath := dep.DependencyPath()
+			if _, ok := vendored[depPath]; ok {
+				continue
+			}
+			vendored[depPath] = struct{}{}
+			if dep.FilePath != "" {
+				res.Skipped = append(res.Skipped, dep.Name)
+				continue
+			}
+			if err := copyModDir(filepath.Join(lock.ModInstallationPath, depPath), filepath.Join(newPath, depPath)); err != nil {
+				return nil, fmt.Errorf("failed to vendor %s: %s", depPath, err.Error())
+			}
+			res.Vendored = append(res.Vendored, depPath)
+		}
+	}
+	if err := copyFile(filepaths.WorkspaceLockPath(workspacePath), filepath.Join(newPath, filepaths.WorkspaceLockFileName)); err != nil {
+		return nil, err
+	}
+
+	if err := os.RemoveAll(vendorPath); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(newPath, vendorPath); err != nil {
+		return nil, err
+	}
+	sort.Strings(res.Vendored)
+	sort.Strings(res.Skipped)
+	return res, nil
+}
+
+// RestoreVendoredMods installs the dependencies of the lock file which are not installed from the vendor directory of
+// the workspace (restoring the lock file from the vendor directory if it does not exist), returning the restored
+// mod versions - nothing is restored if the workspace has no vendor directory
+func RestoreVendoredMods(workspacePath string) ([]string, error) {
+	vendorPath := VendorPath(workspacePath)
+	if _, err := os.Stat(vendorPath); err != nil {
+		return nil, nil
+	}
+
+	lockPath := filepaths.WorkspaceLockPath(workspacePath)
+	if _, err := os.Stat(lockPath); os.IsNotExist(err) {
+		if err := copyFile(filepath.Join(vendorPath, filepaths.WorkspaceLockFileName), lockPath); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	lock, err := versionmap.LoadWorkspaceLock(workspacePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for _, deps := range lock.MissingVersions {
+		for _, dep := range deps {
+			depPath := dep.DependencyPath()
+			source := filepath.Join(vendorPath, depPath)
+			if dep.FilePath != "" || !dirExists(source) {
+				continue
+			}
+			target := filepath.Join(lock.ModInstallationPath, depPath)
+			if dirExists(target) {
+				// (the dependency is missing for more than one parent)
+				continue
+			}
+			if err := copyModDir(source, target); err != nil {
+				_ = os.RemoveAll(target)
+				return nil, fmt.Errorf("failed to restore vendored mod %s: %s", depPath, err.Error())
+			}
+			res = append(res, depPath)
+		}
+	}
+	sort.Strings(res)
+	if len(res) > 0 {
+		slog.Info("restored vendored mods", "mods", res)
+	}
+	return res, nil
+}
+
+// copyModDir copies the regular files of the mod directory (excluding any git directory) into the target directory
+func copyModDir(source, target string) error {
+	return filepath.WalkDir(source, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(source, filePath)
+		if err != nil {
+			return err
+		}
+		return copyFile(filePath, filepath.Join(target, rel))
+	})
+}
+
+func copyFile(source, target string) error {
+	f, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return extractFile(f, target, info.Mode().Perm())
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,88 @@
+package modsource
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/filepaths"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+func TestVendor(t *testing.T) {
+	// (app specific values are set when the CLI starts)
+	app_specific.WorkspaceDataDir = ".powerpipe"
+	app_specific.ModDataExtensions = []string{".pp", ".sp"}
+	workspacePath := t.TempDir()
+	modsPath := filepaths.WorkspaceModPath(workspacePath)
+
+	lock := &versionmap.WorkspaceLock{WorkspacePath: workspacePath, InstallCache: make(versionmap.InstalledDependencyVersionsMap)}
+	a := testInstalledModVersion("github.com/acme/a", "v1.0.0")
+	b := testInstalledModVersion("github.com/acme/b", "v2.1.0")
+	lock.InstallCache.AddDependency("local", a)
+	lock.InstallCache.AddDependency(a.DependencyPath(), b)
+	if err := lock.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// vendoring fails until the dependencies are installed
+	if _, err := Vendor(workspacePath); err == nil {
+		t.Errorf("Test: 'not installed' FAILED : expected an error")
+	}
+
+	writeTestFile(t, filepath.Join(modsPath, a.DependencyPath(), "mod.pp"), `mod "a" {}`)
+	writeTestFile(t, filepath.Join(modsPath, a.DependencyPath(), "controls", "s3.pp"), `control "s3" {}`)
+	writeTestFile(t, filepath.Join(modsPath, a.DependencyPath(), ".git", "HEAD"), "ref: refs/heads/main")
+	writeTestFile(t, filepath.Join(modsPath, b.DependencyPath(), "mod.pp"), `mod "b" {}`)
+	// a stale mod version, which is removed from the vendor directory
+	writeTestFile(t, filepath.Join(VendorPath(workspacePath), "github.com/acme/b@v2.0.0", "mod.pp"), `mod "b" {}`)
+
+	res, err := Vendor(workspacePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"github.com/acme/a@v1.0.0", "github.com/acme/b@v2.1.0"}
+	if !reflect.DeepEqual(res.Vendored, expected) {
+		t.Errorf("Test: 'vendor' FAILED : expected %v, got %v", expected, res.Vendored)
+	}
+	for name, exists := range map[string]bool{
+		"github.com/acme/a@v1.0.0/controls/s3.pp": true,
+		"github.com/acme/a@v1.0.0/.git":           false,
+		"github.com/acme/b@v2.0.0":                false,
+		filepaths.WorkspaceLockFileName:           true,
+	} {
+		if _, err := os.Stat(filepath.Join(VendorPath(workspacePath), name)); (err == nil) != exists {
+			t.Errorf("Test: 'vendor %s' FAILED : expected exists %v, got %v", name, exists, err == nil)
+		}
+	}
+
+	// the mods (and lock file) are restored from the vendor directory
+	if err := os.RemoveAll(modsPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepaths.WorkspaceLockPath(workspacePath)); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := RestoreVendoredMods(workspacePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored, expected) {
+		t.Errorf("Test: 'restore' FAILED : expected %v, got %v", expected, restored)
+	}
+	restoredLock, err := versionmap.LoadWorkspaceLock(workspacePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restoredLock.Incomplete() || restoredLock.Empty() {
+		t.Errorf("Test: 'restore' FAILED : expected all locked dependencies to be installed, missing %v", restoredLock.MissingVersions)
+	}
+
+	// nothing is restored if the mods are installed
+	restored, err = RestoreVendoredMods(workspacePath)
+	if err != nil || len(restored) != 0 {
+		t.Errorf("Test: 'restore installed' FAILED : expected nothing to be restored, got %v (%v)", restored, err)
+	}
+}
@@ -0,0 +1,284 @@
+package modsource
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// the installed dependencies of the workspace are verified against the lockfile:
+//   - missing  - a locked dependency is not installed, or a dependency required by the mod file is not locked
+//   - drifted  - the installed commit of a dependency is not the locked commit, or the locked version deviates from
+//     the pins file (if there is one)
+//   - tampered - the installed files of a dependency do not match the files of the locked commit - the checksum of each
+//     file is recomputed and compared with the checksum recorded in the locked commit, so files which have been
+//     modified, added or removed since the mod was installed are detected
+//
+// (file path dependencies are not installed by the mod installer, so are not verified)
+const (
+	VerifyOK       = "ok"
+	VerifyMissing  = "missing"
+	VerifyDrifted  = "drifted"
+	VerifyTampered = "tampered"
+	VerifySkipped  = "skipped"
+)
+
+// the maximum number of changed files reported for a tampered dependency
+const maxVerifyDetails = 10
+
+// Verification is the result of verifying an installed dependency against the lockfile
+type Verification struct {
+	Name    string
+	Version string
+	Status  string
+	Details []string
+}
+
+func (v Verification) key() string {
+	return fmt.Sprintf("%s@%s", v.Name, v.Version)
+}
+
+// Failed returns whether the dependency failed verification
+func (v Verification) Failed() bool {
+	return v.Status != VerifyOK && v.Status != VerifySkipped
+}
+
+// VerifyDependencies verifies each dependency in the lock against the installed files - if pins is not nil, the locked
+// versions are also verified against the pins
+func VerifyDependencies(workspaceMod *modconfig.Mod, lock *versionmap.WorkspaceLock, pins Pins) []Verification {
+	var res []Verification
+	seen := make(map[string]struct{})
+	add := func(dep *versionmap.InstalledModVersion, verify func(*versionmap.InstalledModVersion) Verification) {
+		if dep == nil || dep.ResolvedVersionConstraint == nil {
+			return
+		}
+		if _, ok := seen[dep.DependencyPath()]; ok {
+			return
+		}
+		seen[dep.DependencyPath()] = struct{}{}
+		res = append(res, verify(dep))
+	}
+
+	for _, deps := range lock.InstallCache {
+		for _, dep := range deps {
+			add(dep, func(dep *versionmap.InstalledModVersion) Verification {
+				return verifyInstalled(dep, filepath.Join(lock.ModInstallationPath, dep.DependencyPath()))
+			})
+		}
+	}
+	for _, deps := range lock.MissingVersions {
+		for _, dep := range deps {
+			add(dep, func(dep *versionmap.InstalledModVersion) Verification {
+				return Verification{Name: dep.Name, Version: lockedVersion(dep), Status: VerifyMissing, Details: []string{"not installed"}}
+			})
+		}
+	}
+
+	// dependencies of the mod file which are not in the lock
+	if workspaceMod != nil && workspaceMod.Require != nil {
+		key := workspaceMod.GetInstallCacheKey()
+		for _, required := range workspaceMod.Require.Mods {
+			if lock.InstallCache[key][required.Name] == nil && lock.MissingVersions[key][required.Name] == nil {
+				res = append(res, Verification{Name: required.Name, Version: required.VersionString, Status: VerifyMissing, Details: []string{"required by the mod file, but not in the lockfile"}})
+			}
+		}
+	}
+
+	if pins != nil {
+		res = verifyPins(res, lock, pins)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].key() < res[j].key() })
+	return res
+}
+
+// verifyInstalled verifies the dependency installed in the given directory against its locked commit
+func verifyInstalled(dep *versionmap.InstalledModVersion, installPath string) Verification {
+	res := Verification{Name: dep.Name, Version: lockedVersion(dep), Status: VerifyOK}
+	if dep.FilePath != "" {
+		res.Status = VerifySkipped
+		res.Details = []string{"file path dependencies are not verified"}
+		return res
+	}
+	if dep.Commit == "" {
+		res.Status = VerifyTampered
+		res.Details = []string{"the lockfile has no commit for the dependency"}
+		return res
+	}
+
+	if _, err := os.Stat(installPath); os.IsNotExist(err) {
+		res.Status = VerifyMissing
+		res.Details = []string{"not installed"}
+		return res
+	}
+	repo, err := git.PlainOpen(installPath)
+	if err != nil {
+		res.Status = VerifyTampered
+		res.Details = []string{fmt.Sprintf("the installed mod is not a git checkout: %s", err.Error())}
+		return res
+	}
+	commit, err := lockedCommit(repo, plumbing.NewHash(dep.Commit))
+	if err != nil {
+		res.Status = VerifyDrifted
+		res.Details = []string{fmt.Sprintf("the locked commit %s is not installed", dep.Commit)}
+		return res
+	}
+	if head, err := repo.Head(); err != nil || head.Hash() != commit.Hash {
+		res.Status = VerifyDrifted
+		installed := "unknown"
+		if err == nil {
+			installed = head.Hash().String()
+		}
+		res.Details = []string{fmt.Sprintf("installed commit %s, locked commit %s", installed, commit.Hash)}
+		return res
+	}
+
+	changes, err := changedFiles(commit, installPath)
+	if err != nil {
+		res.Status = VerifyTampered
+		res.Details = []string{fmt.Sprintf("failed to verify the installed files: %s", err.Error())}
+		return res
+	}
+	if len(changes) > 0 {
+		res.Status = VerifyTampered
+		if len(changes) > maxVerifyDetails {
+			changes = append(changes[:maxVerifyDetails], fmt.Sprintf("and %d more files", len(changes)-maxVerifyDetails))
+		}
+		res.Details = changes
+	}
+	return res
+}
+
+// lockedCommit returns the locked commit - the lock records the hash of the tag reference, which for an annotated tag
+// is the tag object rather than the commit
+func lockedCommit(repo *git.Repository, hash plumbing.Hash) (*object.Commit, error) {
+	if tag, err := repo.TagObject(hash); err == nil {
+		return tag.Commit()
+	}
+	return repo.CommitObject(hash)
+}
+
+// changedFiles returns a description of each file of the installed mod which does not match the file of the commit
+func changedFiles(commit *object.Commit, installPath string) ([]string, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	expected := make(map[string]struct{})
+	err = tree.Files().ForEach(func(f *object.File) error {
+		expected[f.Name] = struct{}{}
+		hash, err := fileHash(filepath.Join(installPath, filepath.FromSlash(f.Name)), f.Mode)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			res = append(res, fmt.Sprintf("removed %s", f.Name))
+		case err != nil:
+			return err
+		case hash != f.Hash:
+			res = append(res, fmt.Sprintf("modified %s", f.Name))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.WalkDir(installPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(installPath, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel == git.GitDirName {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, ok := expected[rel]; !ok {
+			res = append(res, fmt.Sprintf("added %s", rel))
+		}
+		return nil
+	})
+	sort.Strings(res)
+	return res, err
+}
+
+// fileHash returns the git blob hash of the installed file - for symlinks, this is the hash of the link target
+func fileHash(path string, mode filemode.FileMode) (plumbing.Hash, error) {
+	var data []byte
+	var err error
+	if mode == filemode.Symlink {
+		var target string
+		target, err = os.Readlink(path)
+		data = []byte(target)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return plumbing.ComputeHash(plumbing.BlobObject, data), nil
+}
+
+// verifyPins marks the dependencies whose locked versions deviate from the pins as drifted, and adds the pins which
+// are not in the lock
+func verifyPins(res []Verification, lock *versionmap.WorkspaceLock, pins Pins) []Verification {
+	// (missing dependencies are still locked)
+	locked := NewPins(append(LockedModSums(lock), LockedModSums(&versionmap.WorkspaceLock{InstallCache: lock.MissingVersions})...))
+	for i, v := range res {
+		sum, ok := locked[v.key()]
+		if !ok {
+			continue
+		}
+		pin, ok := pins[v.key()]
+		switch {
+		case !ok:
+			res[i].Details = append(res[i].Details, fmt.Sprintf("not pinned in %s", PinsFileName))
+		case pin.Commit != sum.Commit:
+			res[i].Details = append(res[i].Details, fmt.Sprintf("locked commit %s, pinned commit %s", sum.Commit, pin.Commit))
+		default:
+			continue
+		}
+		// tampered and missing dependencies keep their status
+		if res[i].Status == VerifyOK {
+			res[i].Status = VerifyDrifted
+		}
+	}
+	for _, pin := range pins.sorted() {
+		if _, ok := locked[pin.key()]; !ok {
+			res = append(res, Verification{Name: pin.Name, Version: pin.Version, Status: VerifyDrifted, Details: []string{fmt.Sprintf("pinned in %s, but not in the lockfile", PinsFileName)}})
+		}
+	}
+	return res
+}
+
+// lockedVersion returns the version of the locked dependency - for version constraints this is the name of the
+// resolved tag (the version loaded from the lockfile does not keep the original form of the tag, e.g. a 'v' prefix)
+func lockedVersion(dep *versionmap.InstalledModVersion) string {
+	switch {
+	case dep.Tag != "":
+		return dep.Tag
+	case dep.Version != nil:
+		if ref := plumbing.ReferenceName(dep.GitRefStr); ref.IsTag() {
+			return ref.Short()
+		}
+		return dep.Version.Original()
+	default:
+		return dep.Branch
+	}
+}
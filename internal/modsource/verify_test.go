@@ -0,0 +1,193 @@
+package modsource
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+const verifyTestMod = "github.com/acme/mod"
+
+type verifyDependenciesTest struct {
+	// modifies the installed mod
+	modify func(t *testing.T, installPath string)
+	// returns the pins for the locked commit - if nil, there is no pins file
+	pins            func(commit string) Pins
+	expected        string
+	expectedDetails []string
+}
+
+var testCasesVerifyDependencies = map[string]verifyDependenciesTest{
+	"ok": {
+		expected: VerifyOK,
+	},
+	"pinned": {
+		pins: func(commit string) Pins {
+			return NewPins([]ModSum{{Name: verifyTestMod, Version: "v1.0.0", Commit: commit}})
+		},
+		expected: VerifyOK,
+	},
+	"modified": {
+		modify: func(t *testing.T, installPath string) {
+			writeTestFile(t, filepath.Join(installPath, "mod.pp"), `mod "evil" {}`)
+		},
+		expected:        VerifyTampered,
+		expectedDetails: []string{"modified mod.pp"},
+	},
+	"added and removed": {
+		modify: func(t *testing.T, installPath string) {
+			writeTestFile(t, filepath.Join(installPath, "controls", "evil.pp"), "")
+			if err := os.Remove(filepath.Join(installPath, "mod.pp")); err != nil {
+				t.Fatal(err)
+			}
+		},
+		expected:        VerifyTampered,
+		expectedDetails: []string{"added controls/evil.pp", "removed mod.pp"},
+	},
+	"missing": {
+		modify: func(t *testing.T, installPath string) {
+			if err := os.RemoveAll(installPath); err != nil {
+				t.Fatal(err)
+			}
+		},
+		expected:        VerifyMissing,
+		expectedDetails: []string{"not installed"},
+	},
+	"not a git checkout": {
+		modify: func(t *testing.T, installPath string) {
+			if err := os.RemoveAll(filepath.Join(installPath, git.GitDirName)); err != nil {
+				t.Fatal(err)
+			}
+		},
+		expected: VerifyTampered,
+	},
+	"drifted commit": {
+		modify: func(t *testing.T, installPath string) {
+			repo, err := git.PlainOpen(installPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wt, err := repo.Worktree()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := wt.Commit("update", &git.CommitOptions{AllowEmptyCommits: true, Author: &object.Signature{Name: "test", When: time.Now()}}); err != nil {
+				t.Fatal(err)
+			}
+		},
+		expected: VerifyDrifted,
+	},
+	"drifted pin": {
+		pins: func(string) Pins {
+			return NewPins([]ModSum{{Name: verifyTestMod, Version: "v1.0.0", Commit: "0f7c4ab7e9e2d0a3c5b1e4b1a0f1c2d3e4f5a6b7"}})
+		},
+		expected: VerifyDrifted,
+	},
+	"not pinned": {
+		pins: func(string) Pins {
+			return NewPins([]ModSum{{Name: "github.com/acme/other", Version: "v1.0.0", Commit: "0f7c4ab7e9e2d0a3c5b1e4b1a0f1c2d3e4f5a6b7"}})
+		},
+		expected:        VerifyDrifted,
+		expectedDetails: []string{"not pinned in powerpipe.pins"},
+	},
+}
+
+func TestVerifyDependencies(t *testing.T) {
+	for name, test := range testCasesVerifyDependencies {
+		modsPath := t.TempDir()
+		installPath := filepath.Join(modsPath, verifyTestMod+"@v1.0.0")
+		commit := initTestRepo(t, installPath, false)
+		if test.modify != nil {
+			test.modify(t, installPath)
+		}
+		var pins Pins
+		if test.pins != nil {
+			pins = test.pins(commit.String())
+		}
+
+		lock := testLock(modsPath, commit.String())
+		res := VerifyDependencies(nil, lock, pins)
+		var actual *Verification
+		for i := range res {
+			if res[i].Name == verifyTestMod {
+				actual = &res[i]
+			}
+		}
+		if actual == nil {
+			t.Errorf("Test: '%s' FAILED : expected %s to be verified", name, verifyTestMod)
+			continue
+		}
+		if actual.Status != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s %v", name, test.expected, actual.Status, actual.Details)
+		}
+		if test.expectedDetails != nil && !reflect.DeepEqual(actual.Details, test.expectedDetails) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expectedDetails, actual.Details)
+		}
+		if actual.Failed() != (test.expected != VerifyOK) {
+			t.Errorf("Test: '%s' FAILED : expected failed to be %v", name, test.expected != VerifyOK)
+		}
+	}
+}
+
+func TestVerifyDependenciesNotLocked(t *testing.T) {
+	workspaceMod := modconfig.NewMod("local", t.TempDir(), hcl.Range{})
+	workspaceMod.Require = modconfig.NewRequire()
+	workspaceMod.Require.Mods = []*modconfig.ModVersionConstraint{{Name: "github.com/acme/required", VersionString: "^1"}}
+	pins := NewPins([]ModSum{{Name: "github.com/acme/pinned", Version: "v2.0.0", Commit: "0f7c4ab7e9e2d0a3c5b1e4b1a0f1c2d3e4f5a6b7"}})
+
+	res := VerifyDependencies(workspaceMod, testLock(t.TempDir(), ""), pins)
+	expected := map[string]string{
+		"github.com/acme/pinned@v2.0.0": VerifyDrifted,
+		"github.com/acme/required@^1":   VerifyMissing,
+		verifyTestMod + "@v1.0.0":       VerifyMissing,
+	}
+	actual := make(map[string]string)
+	for _, v := range res {
+		actual[v.key()] = v.Status
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Test: 'not locked' FAILED : expected %v, got %v", expected, actual)
+	}
+}
+
+// testLock returns a lock with verifyTestMod@v1.0.0 installed in the given mods directory at the given commit - if
+// the commit is empty, the dependency is locked but not installed
+func testLock(modsPath, commit string) *versionmap.WorkspaceLock {
+	dep := &versionmap.InstalledModVersion{
+		ResolvedVersionConstraint: &versionmap.ResolvedVersionConstraint{
+			DependencyVersion: modconfig.DependencyVersion{Version: semver.MustParse("v1.0.0")},
+			Name:              verifyTestMod,
+			Commit:            commit,
+			GitRefStr:         "refs/tags/v1.0.0",
+		},
+	}
+	lock := &versionmap.WorkspaceLock{
+		ModInstallationPath: modsPath,
+		InstallCache:        make(versionmap.InstalledDependencyVersionsMap),
+		MissingVersions:     make(versionmap.InstalledDependencyVersionsMap),
+	}
+	if commit == "" {
+		lock.MissingVersions.AddDependency("local", dep)
+	} else {
+		lock.InstallCache.AddDependency("local", dep)
+	}
+	return lock
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,153 @@
+package modvars
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/zclconf/go-cty/cty/convert"
+	"golang.org/x/exp/maps"
+)
+
+// the variables of a dependency mod may be set in the require block of the parent mod, e.g.
+//
+//	require {
+//	  mod "github.com/turbot/steampipe-mod-aws-compliance" {
+//	    version = "^1"
+//	    args = {
+//	      regions = ["us-east-1", "eu-west-1"]
+//	    }
+//	  }
+//	}
+//
+// variables are not validated when the workspace is loaded to list or show resources, so args which are not
+// declared by the dependency mod, or have the wrong type, would be silently ignored - ValidateRequireArgs reports
+// them, listing the variables the dependency mod declares
+//
+// NOTE: all the values of an args map must have the same type - set values of different types using
+// the variable files of the workspace, e.g. aws_compliance.regions = ["us-east-1"]
+
+// ValidateRequireArgs validates the args set in the require blocks of the mod and its dependency mods
+func ValidateRequireArgs(mod *modconfig.Mod) error {
+	problems := requireArgsProblems(mod, make(map[*modconfig.Mod]struct{}))
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid dependency mod args:\n  %s", strings.Join(problems, "\n  "))
+}
+
+func requireArgsProblems(mod *modconfig.Mod, visited map[*modconfig.Mod]struct{}) []string {
+	if _, ok := visited[mod]; ok {
+		return nil
+	}
+	visited[mod] = struct{}{}
+
+	var res []string
+	if mod.Require != nil {
+		for _, constraint := range mod.Require.Mods {
+			if len(constraint.Args) == 0 {
+				continue
+			}
+			depMod := dependencyModByName(mod, constraint.Name)
+			if depMod == nil {
+				// the dependency is not installed - this is reported by the workspace load
+				continue
+			}
+			res = append(res, argsProblems(constraint, depMod)...)
+		}
+	}
+	for _, depMod := range dependencyMods(mod) {
+		res = append(res, requireArgsProblems(depMod, visited)...)
+	}
+	return res
+}
+
+func argsProblems(constraint *modconfig.ModVersionConstraint, depMod *modconfig.Mod) []string {
+	variables := Variables(depMod)
+	location := fmt.Sprintf("%s:%d", filepath.Base(constraint.DefRange.Filename), constraint.DefRange.Start.Line)
+
+	var res []string
+	for _, name := range sortedKeys(constraint.Args) {
+		value := constraint.Args[name]
+		variable, ok := variables[name]
+		if !ok {
+			res = append(res, fmt.Sprintf("%s: dependency mod %s does not declare variable '%s'%s", location, constraint.Name, name, declaredSuffix(variables)))
+			continue
+		}
+		if !value.IsWhollyKnown() {
+			continue
+		}
+		if _, err := convert.Convert(value, variable.Type); err != nil {
+			res = append(res, fmt.Sprintf("%s: invalid value for variable '%s' of dependency mod %s: %s", location, name, constraint.Name, err.Error()))
+		}
+	}
+	return res
+}
+
+func declaredSuffix[T any](variables map[string]T) string {
+	if len(variables) == 0 {
+		return " - it declares no variables"
+	}
+	return fmt.Sprintf(" - declared variables: %s", strings.Join(sortedKeys(variables), ", "))
+}
+
+// FindDependencyMod returns the dependency mod of the workspace mod with the given alias or dependency name,
+// e.g. aws_compliance or github.com/turbot/steampipe-mod-aws-compliance
+func FindDependencyMod(mod *modconfig.Mod, name string) (*modconfig.Mod, error) {
+	depMods := dependencyMods(mod)
+	for _, depMod := range depMods {
+		if depMod.ShortName == name || depMod.DependencyName == name {
+			return depMod, nil
+		}
+	}
+	if len(depMods) == 0 {
+		return nil, fmt.Errorf("mod %s has no dependency mod '%s' - it has no dependency mods", mod.ShortName, name)
+	}
+	var names []string
+	for _, depMod := range depMods {
+		names = append(names, fmt.Sprintf("%s (%s)", depMod.ShortName, depMod.DependencyName))
+	}
+	slices.Sort(names)
+	return nil, fmt.Errorf("mod %s has no dependency mod '%s' - its dependency mods are: %s", mod.ShortName, name, strings.Join(names, ", "))
+}
+
+// Variables returns the variables declared by the mod, keyed by short name
+func Variables(mod *modconfig.Mod) map[string]*modconfig.Variable {
+	res := make(map[string]*modconfig.Variable)
+	for _, variable := range mod.ResourceMaps.Variables {
+		if variable.ModName == mod.ShortName {
+			res[variable.ShortName] = variable
+		}
+	}
+	return res
+}
+
+// dependencyMods returns the direct dependency mods of the mod
+func dependencyMods(mod *modconfig.Mod) []*modconfig.Mod {
+	var res []*modconfig.Mod
+	for _, depMod := range mod.ResourceMaps.Mods {
+		// the resource maps include the mod itself
+		if depMod == mod || depMod.DependencyName == "" || depMod.DependencyName == mod.DependencyName {
+			continue
+		}
+		res = append(res, depMod)
+	}
+	return res
+}
+
+func dependencyModByName(mod *modconfig.Mod, dependencyName string) *modconfig.Mod {
+	for _, depMod := range dependencyMods(mod) {
+		if depMod.DependencyName == dependencyName {
+			return depMod
+		}
+	}
+	return nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := maps.Keys(m)
+	slices.Sort(keys)
+	return keys
+}
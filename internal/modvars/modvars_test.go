@@ -0,0 +1,112 @@
+package modvars
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type validateRequireArgsTest struct {
+	args     map[string]cty.Value
+	expected string
+}
+
+func newTestWorkspaceMod(args map[string]cty.Value) *modconfig.Mod {
+	mod := modconfig.NewMod("main", "/workspace", hcl.Range{})
+	mod.Require.Mods = []*modconfig.ModVersionConstraint{{
+		Name:     "github.com/acme/dep",
+		Args:     args,
+		DefRange: hcl.Range{Filename: "/workspace/mod.pp", Start: hcl.Pos{Line: 4}},
+	}}
+
+	depMod := modconfig.NewMod("dep", "/workspace/.powerpipe/mods/github.com/acme/dep@v1.0.0", hcl.Range{})
+	depMod.DependencyName = "github.com/acme/dep"
+	for name, varType := range map[string]cty.Type{"regions": cty.List(cty.String), "threshold": cty.Number} {
+		variable := &modconfig.Variable{Type: varType, ModName: "dep"}
+		variable.ShortName = name
+		depMod.ResourceMaps.Variables["dep.var."+name] = variable
+	}
+	mod.ResourceMaps.Mods[depMod.Name()] = depMod
+	return mod
+}
+
+var testCasesValidateRequireArgs = map[string]validateRequireArgsTest{
+	"valid": {
+		args: map[string]cty.Value{"threshold": cty.NumberIntVal(5)},
+	},
+	"converted": {
+		args: map[string]cty.Value{"threshold": cty.StringVal("5")},
+	},
+	"no args": {},
+	"undeclared": {
+		args: map[string]cty.Value{"threshhold": cty.NumberIntVal(5)},
+		expected: "invalid dependency mod args:\n" +
+			"  mod.pp:4: dependency mod github.com/acme/dep does not declare variable 'threshhold' - declared variables: regions, threshold",
+	},
+	"wrong type": {
+		args: map[string]cty.Value{"regions": cty.StringVal("us-east-1"), "threshold": cty.StringVal("high")},
+		expected: "invalid dependency mod args:\n" +
+			"  mod.pp:4: invalid value for variable 'regions' of dependency mod github.com/acme/dep: list of string required\n" +
+			"  mod.pp:4: invalid value for variable 'threshold' of dependency mod github.com/acme/dep: a number is required",
+	},
+}
+
+func TestValidateRequireArgs(t *testing.T) {
+	for name, test := range testCasesValidateRequireArgs {
+		err := ValidateRequireArgs(newTestWorkspaceMod(test.args))
+		actual := ""
+		if err != nil {
+			actual = err.Error()
+		}
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, actual)
+		}
+	}
+}
+
+type findDependencyModTest struct {
+	name     string
+	expected string
+	err      string
+}
+
+var testCasesFindDependencyMod = map[string]findDependencyModTest{
+	"alias": {
+		name:     "dep",
+		expected: "mod.dep",
+	},
+	"dependency name": {
+		name:     "github.com/acme/dep",
+		expected: "mod.dep",
+	},
+	"workspace mod": {
+		name: "main",
+		err:  "mod main has no dependency mod 'main' - its dependency mods are: dep (github.com/acme/dep)",
+	},
+	"unknown": {
+		name: "other",
+		err:  "mod main has no dependency mod 'other' - its dependency mods are: dep (github.com/acme/dep)",
+	},
+}
+
+func TestFindDependencyMod(t *testing.T) {
+	mod := newTestWorkspaceMod(nil)
+	for name, test := range testCasesFindDependencyMod {
+		depMod, err := FindDependencyMod(mod, test.name)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("Test: '%s' FAILED : expected error '%s', got %v", name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		if depMod.Name() != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, depMod.Name())
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package publish
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/turbot/powerpipe/internal/integration"
+)
+
+// s3 integrations are defined in the workspace config (.ppc) files, e.g.
+//
+//	integration "s3" "artifacts" {
+//	  bucket         = "acme-compliance"
+//	  region         = "us-east-2"
+//	  key            = "{benchmark}/{date}/{run_id}.{format}"
+//	  retention_days = 90
+//	  tags = {
+//	    team = "security"
+//	  }
+//	}
+//
+// and are used by a run with 'powerpipe benchmark run --export csv --export pps --publish artifacts'
+// each file exported by the run is uploaded to the bucket, with a key generated from the key template
+//
+// any S3 compatible service (e.g. MinIO or Cloudflare R2) may be used by setting its endpoint - path style
+// addressing is used for custom endpoints unless path_style is false
+//
+// credentials are read from the access_key and secret_key attributes if set, otherwise from the AWS profile,
+// if set, or the default AWS credential chain
+//
+// the objects are tagged with the tags of the integration, and with a retention-days tag if retention_days is set,
+// so that they may be expired by the lifecycle rules of the bucket, e.g. a rule with a tag filter of
+// retention-days=90 and an expiration of 90 days
+const (
+	IntegrationTypeS3 = "s3"
+
+	defaultRegion = "us-east-1"
+	defaultKey    = "{target}/{date}/{run_id}.{format}"
+	// the tag set for retention_days
+	TagRetentionDays = "retention-days"
+	// the maximum number of tags of an S3 object
+	maxObjectTags = 10
+)
+
+// Integration is an S3 compatible bucket which the exports of a run are published to
+type Integration struct {
+	Name          string
+	Bucket        string            `hcl:"bucket"`
+	Region        string            `hcl:"region,optional"`
+	Endpoint      string            `hcl:"endpoint,optional"`
+	PathStyle     *bool             `hcl:"path_style,optional"`
+	Profile       string            `hcl:"profile,optional"`
+	AccessKey     string            `hcl:"access_key,optional"`
+	SecretKey     string            `hcl:"secret_key,optional"`
+	Key           string            `hcl:"key,optional"`
+	RetentionDays int               `hcl:"retention_days,optional"`
+	Tags          map[string]string `hcl:"tags,optional"`
+}
+
+// LoadIntegrations loads the s3 integrations from the config files in the given paths
+func LoadIntegrations(configPaths []string) (map[string]*Integration, error) {
+	blocks, err := integration.Blocks(configPaths, IntegrationTypeS3)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]*Integration, len(blocks))
+	for name, block := range blocks {
+		i, err := decodeIntegration(block)
+		if err != nil {
+			return nil, err
+		}
+		res[name] = i
+	}
+	return res, nil
+}
+
+func decodeIntegration(block *hcl.Block) (*Integration, error) {
+	res := &Integration{Name: block.Labels[1]}
+	if diags := gohcl.DecodeBody(block.Body, nil, res); diags.HasErrors() {
+		return nil, fmt.Errorf("failed to decode s3 integration '%s': %s", res.Name, diags.Error())
+	}
+	if err := res.validate(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (i *Integration) validate() error {
+	if i.Bucket == "" {
+		return fmt.Errorf("s3 integration '%s' has no bucket", i.Name)
+	}
+	if i.Region == "" {
+		i.Region = defaultRegion
+	}
+	if i.Endpoint != "" {
+		if u, err := url.Parse(i.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("s3 integration '%s' has invalid endpoint '%s'", i.Name, i.Endpoint)
+		}
+	}
+	if (i.AccessKey == "") != (i.SecretKey == "") {
+		return fmt.Errorf("s3 integration '%s' must set both access_key and secret_key, or neither", i.Name)
+	}
+	if i.Key == "" {
+		i.Key = defaultKey
+	}
+	if err := validateKeyTemplate(i.Key); err != nil {
+		return fmt.Errorf("s3 integration '%s' has invalid key: %s", i.Name, err.Error())
+	}
+	if i.RetentionDays < 0 {
+		return fmt.Errorf("s3 integration '%s' has invalid retention_days %d", i.Name, i.RetentionDays)
+	}
+	if _, ok := i.Tags[TagRetentionDays]; ok && i.RetentionDays > 0 {
+		return fmt.Errorf("s3 integration '%s' sets the %s tag, which is set by retention_days", i.Name, TagRetentionDays)
+	}
+	if len(i.objectTags()) > maxObjectTags {
+		return fmt.Errorf("s3 integration '%s' has too many tags: objects may have at most %d tags", i.Name, maxObjectTags)
+	}
+	return nil
+}
+
+// objectTags returns the tags set on the published objects
+func (i *Integration) objectTags() map[string]string {
+	res := make(map[string]string, len(i.Tags)+1)
+	for k, v := range i.Tags {
+		res[k] = v
+	}
+	if i.RetentionDays > 0 {
+		res[TagRetentionDays] = fmt.Sprintf("%d", i.RetentionDays)
+	}
+	return res
+}
+
+// usePathStyle returns whether the bucket is addressed in the path, rather than the host, of the object urls
+func (i *Integration) usePathStyle() bool {
+	if i.PathStyle != nil {
+		return *i.PathStyle
+	}
+	return i.Endpoint != ""
+}
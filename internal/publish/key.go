@@ -0,0 +1,63 @@
+package publish
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/maps"
+)
+
+var keyPlaceholderRegex = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// keyVars are the values of the placeholders of a key template
+type keyVars struct {
+	// the full name of the run target, e.g. aws_compliance.benchmark.cis_v300
+	target string
+	runId  string
+	time   time.Time
+	// the base name of the exported file
+	file string
+	// the export format, i.e. the extension of the file without the leading '.', e.g. csv or remediation.md
+	format string
+}
+
+// the placeholders which may be used in a key template
+func (v keyVars) placeholders() map[string]string {
+	return map[string]string{
+		"target":    v.target,
+		"benchmark": v.target,
+		"dashboard": v.target,
+		"run_id":    v.runId,
+		"date":      v.time.Format("2006-01-02"),
+		"time":      v.time.Format("150405"),
+		"timestamp": v.time.Format("20060102T150405"),
+		"file":      v.file,
+		"format":    v.format,
+	}
+}
+
+func validateKeyTemplate(key string) error {
+	placeholders := keyVars{}.placeholders()
+	for _, match := range keyPlaceholderRegex.FindAllStringSubmatch(key, -1) {
+		if _, ok := placeholders[match[1]]; !ok {
+			names := maps.Keys(placeholders)
+			slices.Sort(names)
+			return fmt.Errorf("unknown placeholder '%s' - valid placeholders are: {%s}", match[0], strings.Join(names, "}, {"))
+		}
+	}
+	if strings.HasPrefix(key, "/") {
+		return fmt.Errorf("key must not start with '/'")
+	}
+	return nil
+}
+
+// objectKey returns the key for the key template, replacing each placeholder with its value
+func objectKey(key string, vars keyVars) string {
+	placeholders := vars.placeholders()
+	return keyPlaceholderRegex.ReplaceAllStringFunc(key, func(placeholder string) string {
+		return placeholders[strings.Trim(placeholder, "{}")]
+	})
+}
@@ -0,0 +1,141 @@
+package publish
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/powerpipe/internal/upload"
+)
+
+// Publisher uploads the exports of a run to s3 integrations
+type Publisher struct {
+	integrations []*Integration
+}
+
+// NewPublisher returns a publisher for the s3 integrations with the given names, which must be defined in the
+// workspace config - nil is returned if no names are given
+func NewPublisher(names []string) (*Publisher, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	configPaths, err := cmdconfig.GetConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	integrations, err := LoadIntegrations(configPaths)
+	if err != nil {
+		return nil, err
+	}
+	res := &Publisher{}
+	for _, name := range names {
+		integration, ok := integrations[name]
+		if !ok {
+			return nil, fmt.Errorf("s3 integration '%s' not found - integrations must be defined in the workspace config", name)
+		}
+		res.integrations = append(res.integrations, integration)
+	}
+	return res, nil
+}
+
+// Publish uploads the artifacts of a run of the given target to each integration,
+// returning a message for each uploaded object
+// the artifacts are uploaded concurrently, with the default options of an upload pipeline
+func (p *Publisher) Publish(ctx context.Context, target string, artifacts []Artifact) ([]string, error) {
+	if len(artifacts) == 0 {
+		return nil, nil
+	}
+	pipeline := upload.NewPipeline(ctx, upload.Options{})
+	submitErr := p.Submit(ctx, pipeline, target, artifacts)
+
+	var messages []string
+	errors := []error{submitErr}
+	for _, status := range pipeline.Wait() {
+		if status.Error != nil {
+			errors = append(errors, status.Error)
+			continue
+		}
+		messages = append(messages, fmt.Sprintf("File published to %s", status.Location))
+	}
+	return messages, error_helpers.CombineErrors(errors...)
+}
+
+// Submit adds the upload of the artifacts of a run of the given target to each integration to the pipeline - an error
+// is returned for each integration whose uploads could not be submitted, e.g. as its credentials could not be loaded
+func (p *Publisher) Submit(ctx context.Context, pipeline *upload.Pipeline, target string, artifacts []Artifact) error {
+	if len(artifacts) == 0 {
+		return nil
+	}
+	now := time.Now()
+	vars := keyVars{target: target, runId: newRunId(now), time: now}
+
+	var errors []error
+	for _, integration := range p.integrations {
+		if err := integration.submit(ctx, pipeline, vars, artifacts); err != nil {
+			errors = append(errors, fmt.Errorf("failed to publish to s3 integration '%s': %w", integration.Name, err))
+		}
+	}
+	return error_helpers.CombineErrors(errors...)
+}
+
+func (i *Integration) submit(ctx context.Context, pipeline *upload.Pipeline, vars keyVars, artifacts []Artifact) error {
+	keys, err := i.objectKeys(vars, artifacts)
+	if err != nil {
+		return err
+	}
+	creds, err := i.credentials(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load credentials: %w", err)
+	}
+
+	for idx, artifact := range artifacts {
+		data, err := os.ReadFile(artifact.Path)
+		if err != nil {
+			return err
+		}
+		key := keys[idx]
+		pipeline.Submit(upload.Task{
+			Name: fmt.Sprintf("%s (s3 integration '%s')", filepath.Base(artifact.Path), i.Name),
+			Size: len(data),
+			Upload: func(ctx context.Context) (string, error) {
+				if err := i.upload(ctx, creds, key, data); err != nil {
+					return "", fmt.Errorf("failed to publish %s to s3 integration '%s': %w", artifact.Path, i.Name, err)
+				}
+				return fmt.Sprintf("s3://%s/%s", i.Bucket, key), nil
+			},
+		})
+	}
+	return nil
+}
+
+// objectKeys returns the key of each artifact - an error is returned if the key template gives more than one
+// artifact the same key, as the objects would overwrite each other
+func (i *Integration) objectKeys(vars keyVars, artifacts []Artifact) ([]string, error) {
+	keys := make([]string, len(artifacts))
+	paths := make(map[string]string, len(artifacts))
+	for idx, artifact := range artifacts {
+		vars.file = filepath.Base(artifact.Path)
+		vars.format = strings.TrimPrefix(artifact.Extension, ".")
+		key := objectKey(i.Key, vars)
+		if path, ok := paths[key]; ok {
+			return nil, fmt.Errorf("key '%s' gives %s and %s the same key '%s' - include {format} or {file} in the key", i.Key, path, artifact.Path, key)
+		}
+		paths[key] = artifact.Path
+		keys[idx] = key
+	}
+	return keys, nil
+}
+
+// newRunId returns a unique id for a run, starting with its time so that run ids sort in time order
+func newRunId(t time.Time) string {
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s-%s", t.UTC().Format("20060102T150405Z"), hex.EncodeToString(suffix))
+}
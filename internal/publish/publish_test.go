@@ -0,0 +1,203 @@
+package publish
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+type objectKeysTest struct {
+	key       string
+	artifacts []Artifact
+	expected  []string
+	err       string
+}
+
+var testArtifacts = []Artifact{
+	{Path: "out/aws_compliance.benchmark.cis.20240304T090000.csv", Extension: ".csv"},
+	{Path: "aws_compliance.benchmark.cis.20240304T090000.remediation.md", Extension: ".remediation.md"},
+}
+
+var testCasesObjectKeys = map[string]objectKeysTest{
+	"default": {
+		key:       defaultKey,
+		artifacts: testArtifacts,
+		expected: []string{
+			"aws_compliance.benchmark.cis/2024-03-04/20240304T090000Z-0a1b.csv",
+			"aws_compliance.benchmark.cis/2024-03-04/20240304T090000Z-0a1b.remediation.md",
+		},
+	},
+	"file": {
+		key:       "reports/{timestamp}/{file}",
+		artifacts: testArtifacts,
+		expected: []string{
+			"reports/20240304T090000/aws_compliance.benchmark.cis.20240304T090000.csv",
+			"reports/20240304T090000/aws_compliance.benchmark.cis.20240304T090000.remediation.md",
+		},
+	},
+	"fixed extension": {
+		key:       "{benchmark}/{date}/{run_id}.sarif",
+		artifacts: testArtifacts[:1],
+		expected:  []string{"aws_compliance.benchmark.cis/2024-03-04/20240304T090000Z-0a1b.sarif"},
+	},
+	"duplicate": {
+		key:       "{benchmark}/{date}/{run_id}.sarif",
+		artifacts: testArtifacts,
+		err:       "key '{benchmark}/{date}/{run_id}.sarif' gives out/aws_compliance.benchmark.cis.20240304T090000.csv and aws_compliance.benchmark.cis.20240304T090000.remediation.md the same key 'aws_compliance.benchmark.cis/2024-03-04/20240304T090000Z-0a1b.sarif' - include {format} or {file} in the key",
+	},
+}
+
+func TestObjectKeys(t *testing.T) {
+	vars := keyVars{target: "aws_compliance.benchmark.cis", runId: "20240304T090000Z-0a1b", time: time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)}
+	for name, test := range testCasesObjectKeys {
+		i := &Integration{Key: test.key}
+		actual, err := i.objectKeys(vars, test.artifacts)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("Test: '%s' FAILED : expected error '%s', got %v", name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
+
+type validateTest struct {
+	integration Integration
+	err         string
+}
+
+var testCasesValidate = map[string]validateTest{
+	"defaults": {
+		integration: Integration{Name: "artifacts", Bucket: "b"},
+	},
+	"unknown placeholder": {
+		integration: Integration{Name: "artifacts", Bucket: "b", Key: "{benchmark}/{run}.csv"},
+		err:         "s3 integration 'artifacts' has invalid key: unknown placeholder '{run}' - valid placeholders are: {benchmark}, {dashboard}, {date}, {file}, {format}, {run_id}, {target}, {time}, {timestamp}",
+	},
+	"partial credentials": {
+		integration: Integration{Name: "artifacts", Bucket: "b", AccessKey: "AKIA"},
+		err:         "s3 integration 'artifacts' must set both access_key and secret_key, or neither",
+	},
+	"retention tag": {
+		integration: Integration{Name: "artifacts", Bucket: "b", RetentionDays: 30, Tags: map[string]string{TagRetentionDays: "90"}},
+		err:         "s3 integration 'artifacts' sets the retention-days tag, which is set by retention_days",
+	},
+}
+
+func TestValidate(t *testing.T) {
+	for name, test := range testCasesValidate {
+		err := test.integration.validate()
+		actual := ""
+		if err != nil {
+			actual = err.Error()
+		}
+		if actual != test.err {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.err, actual)
+		}
+	}
+}
+
+type objectUrlTest struct {
+	integration Integration
+	expected    string
+}
+
+var testCasesObjectUrl = map[string]objectUrlTest{
+	"aws": {
+		integration: Integration{Bucket: "acme", Region: "us-east-2"},
+		expected:    "https://acme.s3.us-east-2.amazonaws.com/reports/a%20b%2Bc.csv",
+	},
+	"custom endpoint": {
+		integration: Integration{Bucket: "acme", Region: "us-east-1", Endpoint: "http://localhost:9000/"},
+		expected:    "http://localhost:9000/acme/reports/a%20b%2Bc.csv",
+	},
+}
+
+func TestObjectUrl(t *testing.T) {
+	for name, test := range testCasesObjectUrl {
+		actual := test.integration.objectUrl("reports/a b+c.csv").String()
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, actual)
+		}
+	}
+}
+
+func TestUpload(t *testing.T) {
+	var path, tagging, authorization, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		path, tagging, authorization, body = r.URL.EscapedPath(), r.Header.Get("X-Amz-Tagging"), r.Header.Get("Authorization"), string(data)
+	}))
+	defer server.Close()
+
+	i := &Integration{Bucket: "acme", Region: "us-east-1", Endpoint: server.URL, RetentionDays: 90, Tags: map[string]string{"team": "security"}}
+	creds := aws.Credentials{AccessKeyID: "AKIA", SecretAccessKey: "secret"}
+	if err := i.upload(context.Background(), creds, "cis/2024-03-04/run.csv", []byte("a,b\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	actual := []string{path, tagging, body}
+	expected := []string{"/acme/cis/2024-03-04/run.csv", "retention-days=90&team=security", "a,b\n"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Test: 'upload' FAILED : expected %q, got %q", expected, actual)
+	}
+	if !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKIA/") {
+		t.Errorf("Test: 'upload' FAILED : expected a signed request, got authorization '%s'", authorization)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	var mut sync.Mutex
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		requests[r.URL.Path]++
+		mut.Unlock()
+		// uploads of the remediation report are not authorized, so are not retried
+		if strings.HasSuffix(r.URL.Path, ".md") {
+			http.Error(w, "access denied", http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	var artifacts []Artifact
+	for _, name := range []string{"run.csv", "run.json", "run.remediation.md"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		artifacts = append(artifacts, Artifact{Path: path, Extension: filepath.Ext(name)})
+	}
+	i := &Integration{Name: "archive", Bucket: "acme", Region: "us-east-1", Endpoint: server.URL, Key: "{file}", AccessKey: "AKIA", SecretKey: "secret"}
+	publisher := &Publisher{integrations: []*Integration{i}}
+
+	messages, err := publisher.Publish(context.Background(), "test.benchmark.cis", artifacts)
+	expected := []string{"File published to s3://acme/run.csv", "File published to s3://acme/run.json"}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("Test: 'messages' FAILED : expected %q, got %q", expected, messages)
+	}
+	if err == nil || !strings.Contains(err.Error(), "failed to publish "+artifacts[2].Path+" to s3 integration 'archive'") {
+		t.Errorf("Test: 'error' FAILED : expected the upload of the remediation report to fail, got %v", err)
+	}
+	if requests["/acme/run.remediation.md"] != 1 {
+		t.Errorf("Test: 'retries' FAILED : expected 1 request for the remediation report, got %d", requests["/acme/run.remediation.md"])
+	}
+}
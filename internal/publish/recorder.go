@@ -0,0 +1,63 @@
+package publish
+
+import (
+	"context"
+	"sync"
+
+	"github.com/turbot/pipe-fittings/export"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/eventbus"
+)
+
+// Artifact is a file written by an exporter
+type Artifact struct {
+	Path string
+	// the file extension of the exporter, e.g. .csv
+	Extension string
+}
+
+// Recorder records the files written by exporters, so that they may be published
+type Recorder struct {
+	artifacts []Artifact
+	mut       sync.Mutex
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Wrap returns an exporter which records the files written by the given exporter
+func (r *Recorder) Wrap(exporter export.Exporter) export.Exporter {
+	return &recordingExporter{Exporter: exporter, recorder: r}
+}
+
+// Flush returns the files written since the last flush
+func (r *Recorder) Flush() []Artifact {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	res := r.artifacts
+	r.artifacts = nil
+	return res
+}
+
+func (r *Recorder) add(artifact Artifact) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.artifacts = append(r.artifacts, artifact)
+}
+
+type recordingExporter struct {
+	export.Exporter
+	recorder *Recorder
+}
+
+func (e *recordingExporter) Export(ctx context.Context, input export.ExportSourceData, destPath string) error {
+	if err := e.Exporter.Export(ctx, input, destPath); err != nil {
+		return err
+	}
+	e.recorder.add(Artifact{Path: destPath, Extension: e.FileExtension()})
+	if e.FileExtension() == localconstants.SnapshotExtension {
+		eventbus.Publish(eventbus.NewEvent(eventbus.EventSnapshotWritten, destPath, nil))
+	}
+	return nil
+}
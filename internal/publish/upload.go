@@ -0,0 +1,151 @@
+package publish
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+	"github.com/turbot/powerpipe/internal/upload"
+)
+
+const maxErrorBodyBytes = 1024
+
+// objects are uploaded with a signed PUT request, so that any S3 compatible service may be used
+// (the object tags are set by the x-amz-tagging header of the request)
+
+// credentials returns the credentials used to sign requests to the bucket
+func (i *Integration) credentials(ctx context.Context) (aws.Credentials, error) {
+	if i.AccessKey != "" {
+		return credentials.NewStaticCredentialsProvider(i.AccessKey, i.SecretKey, "").Retrieve(ctx)
+	}
+	// the credential providers (e.g. STS and the instance metadata service) apply the TLS policy
+	httpClient := awshttp.NewBuildableClient().WithTransportOptions(func(transport *http.Transport) {
+		transport.TLSClientConfig = tlspolicy.Apply(transport.TLSClientConfig)
+	})
+	opts := []func(*config.LoadOptions) error{config.WithRegion(i.Region), config.WithHTTPClient(httpClient)}
+	if i.Profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(i.Profile))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+	return cfg.Credentials.Retrieve(ctx)
+}
+
+// objectUrl returns the url of the object with the given key
+func (i *Integration) objectUrl(key string) *url.URL {
+	endpoint := i.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", i.Region)
+	}
+	// the endpoint is validated when the integration is loaded
+	res, _ := url.Parse(strings.TrimSuffix(endpoint, "/"))
+
+	path := "/" + key
+	if i.usePathStyle() {
+		path = "/" + i.Bucket + path
+	} else {
+		res.Host = i.Bucket + "." + res.Host
+	}
+	res.Path = res.Path + path
+	res.RawPath = escapePath(res.Path)
+	return res
+}
+
+// Upload uploads the data as the object with the given key, using the credentials of the integration
+func (i *Integration) Upload(ctx context.Context, key string, data []byte) error {
+	creds, err := i.credentials(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load credentials: %w", err)
+	}
+	return i.upload(ctx, creds, key, data)
+}
+
+// upload uploads the data as the object with the given key
+func (i *Integration) upload(ctx context.Context, creds aws.Credentials, key string, data []byte) error {
+	req, err := upload.NewRequest(ctx, http.MethodPut, i.objectUrl(key).String(), data)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(data)
+	payloadHash := hex.EncodeToString(hash[:])
+	req.Header.Set("Content-Type", contentType(key))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if tags := i.objectTags(); len(tags) > 0 {
+		values := url.Values{}
+		for k, v := range tags {
+			values.Set(k, v)
+		}
+		req.Header.Set("X-Amz-Tagging", values.Encode())
+	}
+
+	signer := v4.NewSigner(func(o *v4.SignerOptions) {
+		// the path is already escaped by objectUrl
+		o.DisableURIPathEscaping = true
+	})
+	if err := signer.SignHTTP(ctx, creds, req, payloadHash, "s3", i.Region, time.Now()); err != nil {
+		return err
+	}
+
+	resp, err := tlspolicy.HTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		err := fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+		if upload.PermanentStatus(resp.StatusCode) {
+			return upload.Permanent(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// escapePath escapes each segment of the path as required by S3, i.e. all characters other than
+// letters, digits and '-', '.', '_' and '~'
+func escapePath(path string) string {
+	var res strings.Builder
+	for _, b := range []byte(path) {
+		switch {
+		case b == '/', b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9', b == '-', b == '.', b == '_', b == '~':
+			res.WriteByte(b)
+		default:
+			fmt.Fprintf(&res, "%%%02X", b)
+		}
+	}
+	return res.String()
+}
+
+// the media types of the export formats which differ from, or are not known to, the system media types
+// (e.g. .pps is registered for PowerPoint, but powerpipe snapshots are json)
+var contentTypes = map[string]string{
+	".pps": "application/json",
+	".md":  "text/markdown",
+}
+
+func contentType(key string) string {
+	if mediaType, ok := contentTypes[filepath.Ext(key)]; ok {
+		return mediaType
+	}
+	if mediaType := mime.TypeByExtension(filepath.Ext(key)); mediaType != "" {
+		return mediaType
+	}
+	return "application/octet-stream"
+}
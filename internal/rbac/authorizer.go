@@ -0,0 +1,81 @@
+package rbac
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+// Authorizer applies the current policy - the policy may be replaced at runtime through the API
+// a nil Authorizer allows everything (i.e. auth is not enabled)
+type Authorizer struct {
+	policyLock sync.RWMutex
+	policy     *Policy
+}
+
+func NewAuthorizer(policy *Policy) *Authorizer {
+	return &Authorizer{policy: policy}
+}
+
+// Enabled returns whether access is restricted by a policy
+func (a *Authorizer) Enabled() bool {
+	return a != nil
+}
+
+func (a *Authorizer) GetPolicy() *Policy {
+	a.policyLock.RLock()
+	defer a.policyLock.RUnlock()
+	return a.policy
+}
+
+// SetPolicy validates and replaces the current policy
+func (a *Authorizer) SetPolicy(policy *Policy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	a.policyLock.Lock()
+	defer a.policyLock.Unlock()
+	a.policy = policy
+	return nil
+}
+
+// GetIdentity returns the identity of the user making the request
+func (a *Authorizer) GetIdentity(request *http.Request) *Identity {
+	if !a.Enabled() || request == nil {
+		return nil
+	}
+	return a.GetPolicy().GetIdentity(request.Header)
+}
+
+// CanAccess returns whether the identity may view and execute the resource
+func (a *Authorizer) CanAccess(identity *Identity, resource modconfig.ModTreeItem) bool {
+	if !a.Enabled() {
+		return true
+	}
+	return a.GetPolicy().CanAccess(identity, resource)
+}
+
+// CanViewPanel returns whether the identity may view the named panel of the dashboard or benchmark
+func (a *Authorizer) CanViewPanel(identity *Identity, resource modconfig.ModTreeItem, panelName string) bool {
+	if !a.Enabled() {
+		return true
+	}
+	return a.GetPolicy().CanViewPanel(identity, resource, panelName)
+}
+
+// CanPerform returns whether the identity may perform the operation of the service API
+func (a *Authorizer) CanPerform(identity *Identity, operation Operation) bool {
+	if !a.Enabled() {
+		return true
+	}
+	return a.GetPolicy().CanPerform(identity, operation)
+}
+
+// IsAdmin returns whether the identity may manage the policy
+func (a *Authorizer) IsAdmin(identity *Identity) bool {
+	if !a.Enabled() {
+		return false
+	}
+	return a.GetPolicy().IsAdmin(identity)
+}
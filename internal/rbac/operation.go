@@ -0,0 +1,70 @@
+package rbac
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Operation is an operation of the service API which may be restricted by the policy
+// - if any role of the policy lists operations, an identity may only perform the operations listed by its roles
+// (roles may list "*" to allow all operations, and admin roles may perform all operations) - if no role lists
+// operations, operations are not restricted for identified users, so policies which only restrict dashboards and
+// benchmarks are unchanged for them
+// requests without an identity (i.e. anonymous requests) may not perform any operation once a policy is loaded
+// (webhook runs are authorized by their signature, so are not restricted)
+type Operation string
+
+const (
+	// OperationRunBenchmarks allows listing and cancelling runs
+	OperationRunBenchmarks Operation = "run_benchmarks"
+	// OperationInstallMods allows installing the workspace dependencies and getting the status of the install
+	OperationInstallMods Operation = "install_mods"
+	// OperationManageSchedules allows managing the schedules of benchmark and dashboard runs, listing detections and
+	// materializations, and reviewing the approval requests of scheduled runs
+	OperationManageSchedules Operation = "manage_schedules"
+	// OperationReadSnapshots allows reading the panel data of runs, and the annotations of snapshots
+	OperationReadSnapshots Operation = "read_snapshots"
+
+	// AllOperations may be listed by a role to allow all operations
+	AllOperations = "*"
+)
+
+// Operations are the operations which may be restricted by the policy
+var Operations = []Operation{OperationRunBenchmarks, OperationInstallMods, OperationManageSchedules, OperationReadSnapshots}
+
+func validateOperations(role *Role) error {
+	for _, operation := range role.Operations {
+		if operation != AllOperations && !slices.Contains(Operations, Operation(operation)) {
+			names := make([]string, len(Operations))
+			for i, o := range Operations {
+				names[i] = string(o)
+			}
+			return fmt.Errorf("role '%s' has invalid operation '%s' - must be one of: %s, %s", role.Name, operation, strings.Join(names, ", "), AllOperations)
+		}
+	}
+	return nil
+}
+
+// restrictsOperations returns whether any role of the policy lists operations
+func (p *Policy) restrictsOperations() bool {
+	return slices.ContainsFunc(p.Roles, func(role *Role) bool {
+		return role.Operations != nil
+	})
+}
+
+// CanPerform returns whether the identity may perform the operation (a nil identity may not perform any operation)
+func (p *Policy) CanPerform(identity *Identity, operation Operation) bool {
+	if identity == nil {
+		return false
+	}
+	if !p.restrictsOperations() {
+		return true
+	}
+	for _, role := range p.getRoles(identity) {
+		if role.Admin || slices.Contains(role.Operations, AllOperations) || slices.Contains(role.Operations, string(operation)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+package rbac
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+// a policy restricts the dashboards, benchmarks and API operations available to each user of the server, e.g.
+//
+//	role "security" {
+//	  groups     = ["security"]
+//	  dashboards = ["aws_insights.dashboard.*"]
+//	  benchmarks = ["aws_compliance.benchmark.cis_v300"]
+//	}
+//
+//	role "finance" {
+//	  groups     = ["finance"]
+//	  dashboards = ["aws_insights.dashboard.account_report"]
+//	  panels     = ["aws_insights.chart.*cost*"]
+//	}
+//
+//	role "ci" {
+//	  identities = ["ci@example.com"]
+//	  benchmarks = ["*"]
+//	  operations = ["run_benchmarks", "read_snapshots"]
+//	}
+//
+//	role "admin" {
+//	  identities = ["alice@example.com"]
+//	  dashboards = ["*"]
+//	  benchmarks = ["*"]
+//	  admin      = true
+//	}
+//
+// the server is expected to be behind an authenticating proxy, which passes the identity and groups
+// of the user in request headers - a user may access any dashboard or benchmark allowed by any of their roles, and
+// perform any operation of the service API allowed by any of their roles (see Operation)
+//
+// a role may also restrict the panels of its dashboards and benchmarks the user may view - the data of any other panel
+// is withheld (see CanViewPanel)
+const (
+	DefaultIdentityHeader = "X-Forwarded-User"
+	DefaultGroupsHeader   = "X-Forwarded-Groups"
+)
+
+type Policy struct {
+	IdentityHeader string  `hcl:"identity_header,optional" json:"identity_header,omitempty"`
+	GroupsHeader   string  `hcl:"groups_header,optional" json:"groups_header,omitempty"`
+	Roles          []*Role `hcl:"role,block" json:"roles"`
+}
+
+// Role grants the matching identities and groups access to dashboards, benchmarks and API operations
+// - dashboards and benchmarks are glob patterns matched against the resource full name
+type Role struct {
+	Name       string   `hcl:"name,label" json:"name"`
+	Identities []string `hcl:"identities,optional" json:"identities,omitempty"`
+	Groups     []string `hcl:"groups,optional" json:"groups,omitempty"`
+	Dashboards []string `hcl:"dashboards,optional" json:"dashboards,omitempty"`
+	Benchmarks []string `hcl:"benchmarks,optional" json:"benchmarks,omitempty"`
+	// glob patterns matched against the full name of the panels of the dashboards and benchmarks of the role which
+	// may be viewed - if there are none, all panels may be viewed
+	Panels []string `hcl:"panels,optional" json:"panels,omitempty"`
+	// the operations of the service API the role may perform (see Operation)
+	Operations []string `hcl:"operations,optional" json:"operations,omitempty"`
+	// admins may view and update the policy through the API
+	Admin bool `hcl:"admin,optional" json:"admin,omitempty"`
+}
+
+// Identity is the authenticated user making a request
+type Identity struct {
+	Name   string
+	Groups []string
+}
+
+// LoadPolicy loads a policy from an HCL file
+func LoadPolicy(filePath string) (*Policy, error) {
+	fileData, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth policy: %w", err)
+	}
+	file, diags := hclparse.NewParser().ParseHCL(fileData, filePath)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse auth policy: %s", diags.Error())
+	}
+
+	policy := &Policy{}
+	if diags := gohcl.DecodeBody(file.Body, nil, policy); diags.HasErrors() {
+		return nil, fmt.Errorf("failed to decode auth policy: %s", diags.Error())
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// Validate checks role names are unique and all patterns and operations are valid
+func (p *Policy) Validate() error {
+	names := make(map[string]struct{}, len(p.Roles))
+	for _, role := range p.Roles {
+		if role.Name == "" {
+			return fmt.Errorf("auth policy contains a role with no name")
+		}
+		if _, ok := names[role.Name]; ok {
+			return fmt.Errorf("auth policy contains duplicate role '%s'", role.Name)
+		}
+		names[role.Name] = struct{}{}
+		for _, pattern := range slices.Concat(role.Dashboards, role.Benchmarks, role.Panels) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("role '%s' has invalid pattern '%s'", role.Name, pattern)
+			}
+		}
+		if err := validateOperations(role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetIdentity returns the identity passed in the request headers, or nil if there is none
+func (p *Policy) GetIdentity(header http.Header) *Identity {
+	identityHeader := p.IdentityHeader
+	if identityHeader == "" {
+		identityHeader = DefaultIdentityHeader
+	}
+	groupsHeader := p.GroupsHeader
+	if groupsHeader == "" {
+		groupsHeader = DefaultGroupsHeader
+	}
+
+	name := strings.TrimSpace(header.Get(identityHeader))
+	if name == "" {
+		return nil
+	}
+	identity := &Identity{Name: name}
+	for _, group := range strings.Split(header.Get(groupsHeader), ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			identity.Groups = append(identity.Groups, group)
+		}
+	}
+	return identity
+}
+
+// CanAccess returns whether the identity may view and execute the resource
+// - a benchmark is accessible if it, or any benchmark it is a child of, is allowed
+func (p *Policy) CanAccess(identity *Identity, resource modconfig.ModTreeItem) bool {
+	if !isRestricted(resource) {
+		return true
+	}
+	for _, role := range p.getRoles(identity) {
+		if role.canAccess(resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanViewPanel returns whether the identity may view the named panel of the dashboard or benchmark
+// - a panel may be viewed if any role allowing access to the dashboard or benchmark has no panel patterns, or has a
+// pattern matching the panel
+func (p *Policy) CanViewPanel(identity *Identity, resource modconfig.ModTreeItem, panelName string) bool {
+	for _, role := range p.getRoles(identity) {
+		if !role.canAccess(resource) {
+			continue
+		}
+		if len(role.Panels) == 0 {
+			return true
+		}
+		for _, pattern := range role.Panels {
+			if match, _ := path.Match(pattern, panelName); match {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsAdmin returns whether the identity may manage the policy
+func (p *Policy) IsAdmin(identity *Identity) bool {
+	for _, role := range p.getRoles(identity) {
+		if role.Admin {
+			return true
+		}
+	}
+	return false
+}
+
+// getRoles returns the roles which apply to the identity
+func (p *Policy) getRoles(identity *Identity) []*Role {
+	if identity == nil {
+		return nil
+	}
+	var res []*Role
+	for _, role := range p.Roles {
+		if slices.Contains(role.Identities, identity.Name) || slices.ContainsFunc(role.Groups, func(g string) bool {
+			return slices.Contains(identity.Groups, g)
+		}) {
+			res = append(res, role)
+		}
+	}
+	return res
+}
+
+// canAccess returns whether the role allows access to the resource
+func (r *Role) canAccess(resource modconfig.ModTreeItem) bool {
+	var patterns []string
+	switch resource.(type) {
+	case *modconfig.Dashboard:
+		patterns = r.Dashboards
+	case *modconfig.Benchmark:
+		patterns = r.Benchmarks
+	default:
+		return true
+	}
+	for _, pattern := range patterns {
+		if matches(pattern, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRestricted returns whether access to the resource is restricted by roles - other resources are not restricted
+func isRestricted(resource modconfig.ModTreeItem) bool {
+	switch resource.(type) {
+	case *modconfig.Dashboard, *modconfig.Benchmark:
+		return true
+	}
+	return false
+}
+
+// matches returns whether the resource, or any of its ancestors of the same type, matches the pattern
+func matches(pattern string, resource modconfig.ModTreeItem) bool {
+	if match, _ := path.Match(pattern, resource.Name()); match {
+		return true
+	}
+	for _, parent := range resource.GetParents() {
+		if parent.BlockType() == resource.BlockType() && matches(pattern, parent) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,274 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+func newTestDashboard(name string) *modconfig.Dashboard {
+	d := &modconfig.Dashboard{}
+	d.FullName = name
+	return d
+}
+
+func newTestBenchmark(name string, parent *modconfig.Benchmark) *modconfig.Benchmark {
+	b := &modconfig.Benchmark{}
+	b.FullName = name
+	if parent != nil {
+		_ = b.AddParent(parent)
+	}
+	return b
+}
+
+var testPolicy = &Policy{
+	Roles: []*Role{
+		{
+			Name:       "security",
+			Groups:     []string{"security"},
+			Dashboards: []string{"aws_insights.dashboard.*"},
+			Benchmarks: []string{"aws_compliance.benchmark.cis_v300"},
+		},
+		{
+			Name:       "admin",
+			Identities: []string{"alice@example.com"},
+			Dashboards: []string{"*"},
+			Admin:      true,
+		},
+	},
+}
+
+var testCisBenchmark = newTestBenchmark("aws_compliance.benchmark.cis_v300", nil)
+
+type canAccessTest struct {
+	identity *Identity
+	resource modconfig.ModTreeItem
+	expected bool
+}
+
+var testCasesCanAccess = map[string]canAccessTest{
+	"group allows dashboard": {
+		identity: &Identity{Name: "bob", Groups: []string{"security"}},
+		resource: newTestDashboard("aws_insights.dashboard.s3_bucket_detail"),
+		expected: true,
+	},
+	"group does not allow dashboard": {
+		identity: &Identity{Name: "bob", Groups: []string{"security"}},
+		resource: newTestDashboard("azure_insights.dashboard.storage_account_detail"),
+		expected: false,
+	},
+	"child of allowed benchmark": {
+		identity: &Identity{Name: "bob", Groups: []string{"security"}},
+		resource: newTestBenchmark("aws_compliance.benchmark.cis_v300_1", testCisBenchmark),
+		expected: true,
+	},
+	"benchmark not allowed": {
+		identity: &Identity{Name: "alice@example.com"},
+		resource: testCisBenchmark,
+		expected: false,
+	},
+	"identity allows dashboard": {
+		identity: &Identity{Name: "alice@example.com"},
+		resource: newTestDashboard("azure_insights.dashboard.storage_account_detail"),
+		expected: true,
+	},
+	"no identity": {
+		resource: newTestDashboard("aws_insights.dashboard.s3_bucket_detail"),
+		expected: false,
+	},
+	"other resource": {
+		resource: &modconfig.Query{},
+		expected: true,
+	},
+}
+
+func TestCanAccess(t *testing.T) {
+	for name, test := range testCasesCanAccess {
+		actual := testPolicy.CanAccess(test.identity, test.resource)
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
+
+var testPanelsPolicy = &Policy{
+	Roles: []*Role{
+		{
+			Name:       "finance",
+			Groups:     []string{"finance"},
+			Dashboards: []string{"aws_insights.dashboard.account_report"},
+			Panels:     []string{"aws_insights.chart.*cost*"},
+		},
+		{
+			Name:       "security",
+			Groups:     []string{"security"},
+			Dashboards: []string{"aws_insights.dashboard.*"},
+		},
+		{
+			Name:       "audit",
+			Groups:     []string{"audit"},
+			Dashboards: []string{"aws_insights.dashboard.iam_report"},
+			Panels:     []string{"aws_insights.table.*"},
+		},
+	},
+}
+
+type canViewPanelTest struct {
+	identity *Identity
+	resource modconfig.ModTreeItem
+	panel    string
+	expected bool
+}
+
+var testCasesCanViewPanel = map[string]canViewPanelTest{
+	"panel matches": {
+		identity: &Identity{Name: "bob", Groups: []string{"finance"}},
+		resource: newTestDashboard("aws_insights.dashboard.account_report"),
+		panel:    "aws_insights.chart.monthly_cost",
+		expected: true,
+	},
+	"panel does not match": {
+		identity: &Identity{Name: "bob", Groups: []string{"finance"}},
+		resource: newTestDashboard("aws_insights.dashboard.account_report"),
+		panel:    "aws_insights.table.account_users",
+		expected: false,
+	},
+	"role without panels": {
+		identity: &Identity{Name: "bob", Groups: []string{"finance", "security"}},
+		resource: newTestDashboard("aws_insights.dashboard.account_report"),
+		panel:    "aws_insights.table.account_users",
+		expected: true,
+	},
+	"panels of role without access to dashboard": {
+		identity: &Identity{Name: "bob", Groups: []string{"finance", "audit"}},
+		resource: newTestDashboard("aws_insights.dashboard.account_report"),
+		panel:    "aws_insights.table.account_users",
+		expected: false,
+	},
+	"dashboard not allowed": {
+		identity: &Identity{Name: "bob", Groups: []string{"finance"}},
+		resource: newTestDashboard("aws_insights.dashboard.iam_report"),
+		panel:    "aws_insights.chart.monthly_cost",
+		expected: false,
+	},
+	"no identity": {
+		resource: newTestDashboard("aws_insights.dashboard.account_report"),
+		panel:    "aws_insights.chart.monthly_cost",
+		expected: false,
+	},
+}
+
+func TestCanViewPanel(t *testing.T) {
+	for name, test := range testCasesCanViewPanel {
+		actual := testPanelsPolicy.CanViewPanel(test.identity, test.resource, test.panel)
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
+
+var testOperationsPolicy = &Policy{
+	Roles: []*Role{
+		{
+			Name:       "ci",
+			Identities: []string{"ci@example.com"},
+			Operations: []string{"run_benchmarks", "read_snapshots"},
+		},
+		{
+			Name:       "platform",
+			Groups:     []string{"platform"},
+			Operations: []string{"*"},
+		},
+		{
+			Name:   "security",
+			Groups: []string{"security"},
+		},
+		{
+			Name:       "admin",
+			Identities: []string{"alice@example.com"},
+			Admin:      true,
+		},
+	},
+}
+
+type canPerformTest struct {
+	policy    *Policy
+	identity  *Identity
+	operation Operation
+	expected  bool
+}
+
+var testCasesCanPerform = map[string]canPerformTest{
+	"operation allowed": {
+		policy:    testOperationsPolicy,
+		identity:  &Identity{Name: "ci@example.com"},
+		operation: OperationRunBenchmarks,
+		expected:  true,
+	},
+	"operation not allowed": {
+		policy:    testOperationsPolicy,
+		identity:  &Identity{Name: "ci@example.com"},
+		operation: OperationInstallMods,
+		expected:  false,
+	},
+	"all operations allowed": {
+		policy:    testOperationsPolicy,
+		identity:  &Identity{Name: "bob", Groups: []string{"platform"}},
+		operation: OperationManageSchedules,
+		expected:  true,
+	},
+	"role without operations": {
+		policy:    testOperationsPolicy,
+		identity:  &Identity{Name: "bob", Groups: []string{"security"}},
+		operation: OperationReadSnapshots,
+		expected:  false,
+	},
+	"admin": {
+		policy:    testOperationsPolicy,
+		identity:  &Identity{Name: "alice@example.com"},
+		operation: OperationInstallMods,
+		expected:  true,
+	},
+	"no identity": {
+		policy:    testOperationsPolicy,
+		operation: OperationRunBenchmarks,
+		expected:  false,
+	},
+	"operations not restricted": {
+		policy:    testPolicy,
+		identity:  &Identity{Name: "bob", Groups: []string{"security"}},
+		operation: OperationInstallMods,
+		expected:  true,
+	},
+	"operations not restricted, identity without roles": {
+		policy:    testPolicy,
+		identity:  &Identity{Name: "carol"},
+		operation: OperationInstallMods,
+		expected:  true,
+	},
+	// anonymous requests may not perform operations, even if the policy does not restrict them
+	"operations not restricted, no identity": {
+		policy:    testPolicy,
+		operation: OperationRunBenchmarks,
+		expected:  false,
+	},
+}
+
+func TestCanPerform(t *testing.T) {
+	for name, test := range testCasesCanPerform {
+		actual := test.policy.CanPerform(test.identity, test.operation)
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
+
+func TestValidateOperations(t *testing.T) {
+	if err := testOperationsPolicy.Validate(); err != nil {
+		t.Errorf("Test: 'valid operations' FAILED : unexpected error %v", err)
+	}
+	policy := &Policy{Roles: []*Role{{Name: "ci", Operations: []string{"delete_workspace"}}}}
+	if err := policy.Validate(); err == nil {
+		t.Errorf("Test: 'invalid operation' FAILED : expected an error")
+	}
+}
@@ -0,0 +1,271 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turbot/pipe-fittings/steampipeconfig"
+	"github.com/turbot/powerpipe/internal/badge"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+	"github.com/turbot/powerpipe/internal/i18n"
+)
+
+// an aggregate report is a roll-up of the benchmark and control results of snapshots from multiple environments
+// (e.g. accounts or regions), with a column for each environment, e.g.
+//
+//	powerpipe report aggregate prod=prod.pps staging=staging.pps
+//
+// the environment name defaults to the snapshot file name, without the extension
+
+// Environment is a snapshot to aggregate, with the name of the environment it was run against
+type Environment struct {
+	Name     string
+	Snapshot []byte
+}
+
+// ParseEnvironmentArg parses an aggregate argument, in the form [name=]path
+func ParseEnvironmentArg(arg string) (name, path string) {
+	if name, path, ok := strings.Cut(arg, "="); ok && name != "" {
+		return name, path
+	}
+	name = filepath.Base(arg)
+	for _, ext := range []string{".pps", ".sps", ".json"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name, arg
+}
+
+// Aggregate is the roll-up of the control results of multiple environments
+type Aggregate struct {
+	Title        string          `json:"title"`
+	GeneratedAt  time.Time       `json:"generated_at"`
+	Environments []string        `json:"environments"`
+	Rows         []*AggregateRow `json:"rows"`
+}
+
+// AggregateRow is the results of a benchmark or control in each environment
+type AggregateRow struct {
+	Name      string `json:"name"`
+	Title     string `json:"title"`
+	PanelType string `json:"panel_type"`
+	// the depth of the benchmark or control in the benchmark tree
+	Depth int `json:"depth"`
+	// the result counts for each environment, in environment order - nil if the environment has no results for the row
+	Environments []*controlstatus.StatusSummary `json:"environments"`
+	Total        *controlstatus.StatusSummary   `json:"total"`
+}
+
+// NewAggregate builds the aggregate report of the given environment snapshots - the rows are the union of the
+// benchmarks and controls of all snapshots, in the order they are first found
+func NewAggregate(environments []*Environment) (*Aggregate, error) {
+	res := &Aggregate{GeneratedAt: time.Now()}
+	rowMap := make(map[string]*AggregateRow)
+	seen := make(map[string]struct{})
+
+	for i, env := range environments {
+		if _, ok := seen[env.Name]; ok {
+			return nil, fmt.Errorf("duplicate environment '%s' - use name=path to name each snapshot", env.Name)
+		}
+		seen[env.Name] = struct{}{}
+		res.Environments = append(res.Environments, env.Name)
+
+		var content snapshotContent
+		if err := json.Unmarshal(env.Snapshot, &content); err != nil {
+			return nil, fmt.Errorf("failed to parse snapshot for environment '%s': %w", env.Name, err)
+		}
+		if content.Layout == nil {
+			return nil, fmt.Errorf("snapshot for environment '%s' has no layout", env.Name)
+		}
+
+		title := ""
+		if root, ok := content.Panels[content.Layout.Name]; ok {
+			title = root.Title
+		}
+		switch {
+		case i == 0:
+			res.Title = title
+		case res.Title != title:
+			// the snapshots are of different benchmarks
+			res.Title = ""
+		}
+
+		res.addEnvironmentRows(i, content, rowMap)
+	}
+
+	if res.Title == "" {
+		res.Title = "Aggregate report"
+	}
+	// pad the rows with no results for the later environments
+	for _, row := range res.Rows {
+		for len(row.Environments) < len(res.Environments) {
+			row.Environments = append(row.Environments, nil)
+		}
+	}
+	return res, nil
+}
+
+func (a *Aggregate) addEnvironmentRows(envIdx int, content snapshotContent, rowMap map[string]*AggregateRow) {
+	var walk func(node *steampipeconfig.SnapshotTreeNode, depth int)
+	walk = func(node *steampipeconfig.SnapshotTreeNode, depth int) {
+		panel, ok := content.Panels[node.Name]
+		if !ok {
+			return
+		}
+		if counts := parseStatusSummary(panel.Summary); counts != nil && (panel.PanelType == "benchmark" || panel.PanelType == "control") {
+			row, ok := rowMap[node.Name]
+			if !ok {
+				row = &AggregateRow{Name: node.Name, Title: panel.Title, PanelType: panel.PanelType, Depth: depth, Total: &controlstatus.StatusSummary{}}
+				rowMap[node.Name] = row
+				a.Rows = append(a.Rows, row)
+			}
+			// pad the results of any earlier environments which do not include this row
+			for len(row.Environments) < envIdx {
+				row.Environments = append(row.Environments, nil)
+			}
+			// (a control with multiple parents is only counted once per environment)
+			if len(row.Environments) == envIdx {
+				row.Environments = append(row.Environments, counts)
+				row.Total.Ok += counts.Ok
+				row.Total.Alarm += counts.Alarm
+				row.Total.Error += counts.Error
+				row.Total.Info += counts.Info
+				row.Total.Skip += counts.Skip
+			}
+			depth++
+		}
+		for _, child := range node.Children {
+			walk(child, depth)
+		}
+	}
+	walk(content.Layout, 0)
+}
+
+// parseStatusSummary returns the control result counts from a control or benchmark summary
+func parseStatusSummary(raw json.RawMessage) *controlstatus.StatusSummary {
+	kv := parseSummary(raw)
+	if kv == nil {
+		return nil
+	}
+	res := &controlstatus.StatusSummary{}
+	for _, c := range kv {
+		count, _ := strconv.Atoi(c.Value)
+		switch c.Key {
+		case "ok":
+			res.Ok = count
+		case "alarm":
+			res.Alarm = count
+		case "error":
+			res.Error = count
+		case "info":
+			res.Info = count
+		case "skip":
+			res.Skip = count
+		}
+	}
+	return res
+}
+
+// FormatCell returns the summary of a row's results, e.g. "92% / 14 alarms", or "-" if there are no results
+func FormatCell(counts *controlstatus.StatusSummary) string {
+	if counts == nil {
+		return "-"
+	}
+	return badge.NewBadge("", *counts).Message
+}
+
+// Table returns the headers and rows of the aggregate as a table, with a column for each environment and the total
+func (a *Aggregate) Table() ([]string, [][]string) {
+	headers := append([]string{"NAME"}, a.Environments...)
+	headers = append(headers, "TOTAL")
+	var rows [][]string
+	for _, row := range a.Rows {
+		title := row.Title
+		if title == "" {
+			title = row.Name
+		}
+		values := []string{strings.Repeat("  ", row.Depth) + title}
+		for _, counts := range row.Environments {
+			values = append(values, FormatCell(counts))
+		}
+		values = append(values, FormatCell(row.Total))
+		rows = append(rows, values)
+	}
+	return headers, rows
+}
+
+var aggregateTemplate = template.Must(template.New("aggregate.tmpl").Funcs(template.FuncMap{
+	"cell":       FormatCell,
+	"cellClass":  cellClass,
+	"formatTime": formatTime,
+	"t":          i18n.T,
+	"language":   language,
+	"indent":     func(depth int) int { return 2 + depth*4 },
+}).ParseFS(templateFS, "templates/aggregate.tmpl"))
+
+// RenderHtml renders the aggregate as an HTML table
+func (a *Aggregate) RenderHtml() (io.Reader, error) {
+	var buf bytes.Buffer
+	if err := aggregateTemplate.Execute(&buf, a); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// cellClass returns the class used to color a result cell, based on the pass percentage
+func cellClass(counts *controlstatus.StatusSummary) string {
+	if counts == nil {
+		return "none"
+	}
+	switch badge.NewBadge("", *counts).Color {
+	case badge.ColorGood:
+		return "good"
+	case badge.ColorWarning:
+		return "warning"
+	case badge.ColorBad:
+		return "bad"
+	}
+	return "none"
+}
+
+// RenderCsv renders the aggregate as CSV, with the result counts of each environment in separate columns
+func (a *Aggregate) RenderCsv() (io.Reader, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	statuses := []string{"ok", "alarm", "error", "info", "skip"}
+
+	headers := []string{"name", "title", "panel_type"}
+	for _, env := range append(append([]string{}, a.Environments...), "total") {
+		for _, status := range statuses {
+			headers = append(headers, fmt.Sprintf("%s_%s", env, status))
+		}
+	}
+	if err := w.Write(headers); err != nil {
+		return nil, err
+	}
+
+	for _, row := range a.Rows {
+		values := []string{row.Name, row.Title, row.PanelType}
+		for _, counts := range append(append([]*controlstatus.StatusSummary{}, row.Environments...), row.Total) {
+			if counts == nil {
+				values = append(values, "", "", "", "", "")
+				continue
+			}
+			for _, count := range []int{counts.Ok, counts.Alarm, counts.Error, counts.Info, counts.Skip} {
+				values = append(values, strconv.Itoa(count))
+			}
+		}
+		if err := w.Write(values); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return &buf, w.Error()
+}
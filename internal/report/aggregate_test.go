@@ -0,0 +1,108 @@
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+const testProdSnapshot = `{
+  "layout": {"name": "cis", "children": [{"name": "cis_1", "children": [{"name": "c1"}, {"name": "c2"}]}]},
+  "panels": {
+    "cis": {"name": "cis", "title": "CIS", "panel_type": "benchmark", "summary": {"status": {"ok": 9, "alarm": 1}}},
+    "cis_1": {"name": "cis_1", "title": "Section 1", "panel_type": "benchmark", "summary": {"status": {"ok": 9, "alarm": 1}}},
+    "c1": {"name": "c1", "title": "Control 1", "panel_type": "control", "summary": {"ok": 9}},
+    "c2": {"name": "c2", "title": "Control 2", "panel_type": "control", "summary": {"alarm": 1}}
+  }
+}`
+
+const testStagingSnapshot = `{
+  "layout": {"name": "cis", "children": [{"name": "cis_1", "children": [{"name": "c2"}, {"name": "c3"}]}]},
+  "panels": {
+    "cis": {"name": "cis", "title": "CIS", "panel_type": "benchmark", "summary": {"status": {"ok": 1, "alarm": 3}}},
+    "cis_1": {"name": "cis_1", "title": "Section 1", "panel_type": "benchmark", "summary": {"status": {"ok": 1, "alarm": 3}}},
+    "c2": {"name": "c2", "title": "Control 2", "panel_type": "control", "summary": {"alarm": 2}},
+    "c3": {"name": "c3", "title": "Control 3", "panel_type": "control", "summary": {"ok": 1, "alarm": 1}}
+  }
+}`
+
+type newAggregateTest struct {
+	environments []*Environment
+	// the expected table rows, joined by '|'
+	expected []string
+	err      bool
+}
+
+var testCasesNewAggregate = map[string]newAggregateTest{
+	"single environment": {
+		environments: []*Environment{{Name: "prod", Snapshot: []byte(testProdSnapshot)}},
+		expected: []string{
+			"CIS|90% / 1 alarm|90% / 1 alarm",
+			"  Section 1|90% / 1 alarm|90% / 1 alarm",
+			"    Control 1|100% / 0 alarms|100% / 0 alarms",
+			"    Control 2|0% / 1 alarm|0% / 1 alarm",
+		},
+	},
+	"multiple environments": {
+		environments: []*Environment{{Name: "prod", Snapshot: []byte(testProdSnapshot)}, {Name: "staging", Snapshot: []byte(testStagingSnapshot)}},
+		expected: []string{
+			"CIS|90% / 1 alarm|25% / 3 alarms|71% / 4 alarms",
+			"  Section 1|90% / 1 alarm|25% / 3 alarms|71% / 4 alarms",
+			"    Control 1|100% / 0 alarms|-|100% / 0 alarms",
+			"    Control 2|0% / 1 alarm|0% / 2 alarms|0% / 3 alarms",
+			"    Control 3|-|50% / 1 alarm|50% / 1 alarm",
+		},
+	},
+	"duplicate environment": {
+		environments: []*Environment{{Name: "prod", Snapshot: []byte(testProdSnapshot)}, {Name: "prod", Snapshot: []byte(testStagingSnapshot)}},
+		err:          true,
+	},
+	"invalid snapshot": {
+		environments: []*Environment{{Name: "prod", Snapshot: []byte("not json")}},
+		err:          true,
+	},
+}
+
+func TestNewAggregate(t *testing.T) {
+	for name, test := range testCasesNewAggregate {
+		aggregate, err := NewAggregate(test.environments)
+		if test.err {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error, got none", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %v", name, err)
+			continue
+		}
+		_, rows := aggregate.Table()
+		var actual []string
+		for _, row := range rows {
+			actual = append(actual, strings.Join(row, "|"))
+		}
+		if strings.Join(actual, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("Test: '%s' FAILED : expected\n%s\ngot\n%s", name, strings.Join(test.expected, "\n"), strings.Join(actual, "\n"))
+		}
+	}
+}
+
+type parseEnvironmentArgTest struct {
+	arg  string
+	name string
+	path string
+}
+
+var testCasesParseEnvironmentArg = map[string]parseEnvironmentArgTest{
+	"named":     {arg: "prod=snapshots/prod.pps", name: "prod", path: "snapshots/prod.pps"},
+	"file name": {arg: "snapshots/staging.pps", name: "staging", path: "snapshots/staging.pps"},
+	"no name":   {arg: "=staging.pps", name: "=staging", path: "=staging.pps"},
+}
+
+func TestParseEnvironmentArg(t *testing.T) {
+	for name, test := range testCasesParseEnvironmentArg {
+		envName, path := ParseEnvironmentArg(test.arg)
+		if envName != test.name || path != test.path {
+			t.Errorf("Test: '%s' FAILED : expected %s, %s, got %s, %s", name, test.name, test.path, envName, path)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/export"
+	"github.com/turbot/pipe-fittings/steampipeconfig"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+const (
+	ExportFormatHtml = "html"
+	ExportFormatPdf  = "pdf"
+)
+
+// the browsers which may be used to print a report to PDF, in order of preference
+// (set POWERPIPE_CHROME_PATH to use a different browser executable)
+var pdfBrowsers = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "chrome", "msedge"}
+
+var darwinPdfBrowsers = []string{
+	"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+	"/Applications/Chromium.app/Contents/MacOS/Chromium",
+}
+
+// HtmlExporter exports a dashboard snapshot as a print-oriented HTML report
+type HtmlExporter struct {
+	export.ExporterBase
+}
+
+func (e *HtmlExporter) Export(_ context.Context, input export.ExportSourceData, destPath string) error {
+	res, err := renderSnapshot(input)
+	if err != nil {
+		return err
+	}
+	return export.Write(destPath, res)
+}
+
+func (e *HtmlExporter) FileExtension() string {
+	return "." + ExportFormatHtml
+}
+
+func (e *HtmlExporter) Name() string {
+	return ExportFormatHtml
+}
+
+// PdfExporter exports a dashboard snapshot as a PDF report, by printing the HTML report using a headless browser
+type PdfExporter struct {
+	export.ExporterBase
+}
+
+func (e *PdfExporter) Export(ctx context.Context, input export.ExportSourceData, destPath string) error {
+	browser, err := findPdfBrowser()
+	if err != nil {
+		return err
+	}
+	res, err := renderSnapshot(input)
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "powerpipe-report")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	htmlPath := filepath.Join(tmpDir, "report.html")
+	if err := export.Write(htmlPath, res); err != nil {
+		return err
+	}
+
+	absDestPath, err := filepath.Abs(destPath)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, browser,
+		"--headless",
+		"--disable-gpu",
+		"--no-pdf-header-footer",
+		"--print-to-pdf="+absDestPath,
+		"file://"+filepath.ToSlash(htmlPath),
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to print report to PDF using %s: %w: %s", browser, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (e *PdfExporter) FileExtension() string {
+	return "." + ExportFormatPdf
+}
+
+func (e *PdfExporter) Name() string {
+	return ExportFormatPdf
+}
+
+func renderSnapshot(input export.ExportSourceData) (io.Reader, error) {
+	snapshot, ok := input.(*steampipeconfig.SteampipeSnapshot)
+	if !ok {
+		return nil, fmt.Errorf("report exporter input must be a SteampipeSnapshot")
+	}
+	report, err := NewReport(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return report.RenderHtml()
+}
+
+func findPdfBrowser() (string, error) {
+	if browser, ok := os.LookupEnv(localconstants.EnvChromePath); ok {
+		return browser, nil
+	}
+	for _, browser := range pdfBrowsers {
+		if path, err := exec.LookPath(browser); err == nil {
+			return path, nil
+		}
+	}
+	if runtime.GOOS == "darwin" {
+		for _, browser := range darwinPdfBrowsers {
+			if _, err := os.Stat(browser); err == nil {
+				return browser, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("PDF export requires Chrome or Chromium - install it, or set %s to the browser executable", localconstants.EnvChromePath)
+}
@@ -0,0 +1,109 @@
+package report
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/i18n"
+	"github.com/turbot/powerpipe/internal/locale"
+)
+
+//go:embed templates/*
+var templateFS embed.FS
+
+var reportTemplate = template.Must(template.New("report.tmpl").Funcs(template.FuncMap{
+	"heading":    heading,
+	"markdown":   markdown,
+	"formatTime": formatTime,
+	"t":          i18n.T,
+	"status":     i18n.Status,
+	"lower":      strings.ToLower,
+	"language":   language,
+}).ParseFS(templateFS, "templates/report.tmpl"))
+
+// RenderHtml renders the report as a print-oriented HTML document
+func (r *Report) RenderHtml() (io.Reader, error) {
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, r); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+func formatTime(t time.Time) string {
+	return locale.Current().FormatTimeWithZone(t)
+}
+
+// language returns the language tag of the current language, for the lang attribute of the document
+func language() string {
+	return i18n.Current().Language
+}
+
+func heading(level int, title string) template.HTML {
+	return template.HTML(fmt.Sprintf("<h%d>%s</h%d>", level, html.EscapeString(title), level))
+}
+
+var (
+	markdownHeadingRegex = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	markdownListRegex    = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	markdownBoldRegex    = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+)
+
+// markdown renders the subset of markdown commonly used in text panels - headings, lists, bold text and paragraphs
+// all other content is escaped
+func markdown(text string) template.HTML {
+	var res strings.Builder
+	var paragraph []string
+	inList := false
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			res.WriteString("<p>" + strings.Join(paragraph, " ") + "</p>")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if inList {
+			res.WriteString("</ul>")
+			inList = false
+		}
+	}
+	inline := func(s string) string {
+		return markdownBoldRegex.ReplaceAllString(html.EscapeString(s), "<strong>$1</strong>")
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		switch {
+		case strings.TrimSpace(line) == "":
+			flushParagraph()
+			closeList()
+		case markdownHeadingRegex.MatchString(line):
+			flushParagraph()
+			closeList()
+			groups := markdownHeadingRegex.FindStringSubmatch(line)
+			// text panel headings are nested below the report headings
+			level := min(len(groups[1])+2, 6)
+			res.WriteString(fmt.Sprintf("<h%d>%s</h%d>", level, inline(groups[2]), level))
+		case markdownListRegex.MatchString(line):
+			flushParagraph()
+			if !inList {
+				res.WriteString("<ul>")
+				inList = true
+			}
+			res.WriteString("<li>" + inline(markdownListRegex.FindStringSubmatch(line)[1]) + "</li>")
+		default:
+			closeList()
+			paragraph = append(paragraph, inline(strings.TrimSpace(line)))
+		}
+	}
+	flushParagraph()
+	closeList()
+	return template.HTML(res.String())
+}
@@ -0,0 +1,327 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turbot/pipe-fittings/steampipeconfig"
+	"github.com/turbot/powerpipe/internal/locale"
+)
+
+// a report is a dashboard rendered for print, rather than the interactive UI - each top level child of the
+// dashboard is a section, starting on a new page, e.g.
+//
+//	dashboard "monthly_review" {
+//	  title = "Monthly Security Review"
+//	  tags = {
+//	    report        = "true"
+//	    report_header = "ACME Corp - Confidential"
+//	    report_footer = "Generated by the security team"
+//	  }
+//
+//	  container {
+//	    title = "Summary"
+//	    card { ... }
+//	  }
+//	}
+//
+// reports are exported using 'powerpipe dashboard run --export html' (or pdf), which may be used with any dashboard
+// - the report tags customise the output:
+//   - report_header / report_footer: text repeated at the top / bottom of every page
+//   - report_toc: whether to include a table of contents (default true)
+//   - report_page_size: the page size, e.g. A4 or letter (default A4)
+//   - report_orientation: portrait or landscape (default portrait)
+const (
+	TagReport            = "report"
+	TagHeader            = "report_header"
+	TagFooter            = "report_footer"
+	TagToc               = "report_toc"
+	TagPageSize          = "report_page_size"
+	TagOrientation       = "report_orientation"
+	DefaultPageSize      = "A4"
+	DefaultOrientation   = "portrait"
+	OrientationLandscape = "landscape"
+
+	// limit the rows rendered for each table, so that reports remain printable
+	MaxTableRows = 1000
+)
+
+// Report is the print layout of an executed dashboard
+type Report struct {
+	Title       string
+	Header      string
+	Footer      string
+	Toc         bool
+	PageSize    string
+	Orientation string
+	GeneratedAt time.Time
+	// the input values the dashboard was run with
+	Inputs   []KeyValue
+	Sections []*Node
+}
+
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// Node is a panel of the report, along with its children
+type Node struct {
+	Id          string
+	Title       string
+	PanelType   string
+	DisplayType string
+	Error       string
+	// the heading level of the node title - sections are level 2
+	Level int
+	// card label and value
+	Label string
+	Value string
+	// text value
+	Text     string
+	Markdown bool
+	ImageSrc string
+	// tabular data, for tables, charts and controls
+	Columns   []string
+	Rows      [][]string
+	Truncated int
+	// control result counts, for benchmarks and controls
+	Summary  []KeyValue
+	Children []*Node
+}
+
+// snapshotPanel is the snapshot JSON representation of a panel
+type snapshotPanel struct {
+	Name        string            `json:"name"`
+	Title       string            `json:"title"`
+	PanelType   string            `json:"panel_type"`
+	DisplayType string            `json:"display_type"`
+	Error       string            `json:"error"`
+	Tags        map[string]string `json:"tags"`
+	Properties  map[string]any    `json:"properties"`
+	Data        *struct {
+		Columns []struct {
+			Name string `json:"name"`
+		} `json:"columns"`
+		Rows []map[string]any `json:"rows"`
+	} `json:"data"`
+	Summary json.RawMessage `json:"summary"`
+}
+
+type snapshotContent struct {
+	Panels map[string]*snapshotPanel         `json:"panels"`
+	Inputs map[string]any                    `json:"inputs"`
+	Layout *steampipeconfig.SnapshotTreeNode `json:"layout"`
+	End    time.Time                         `json:"end_time"`
+}
+
+// IsReport returns whether the tags mark a dashboard as a report
+func IsReport(tags map[string]string) bool {
+	res, _ := strconv.ParseBool(tags[TagReport])
+	return res
+}
+
+// NewReport builds the report for an executed dashboard snapshot
+func NewReport(snapshot *steampipeconfig.SteampipeSnapshot) (*Report, error) {
+	// use the snapshot JSON, so all panel types are handled uniformly
+	snapshotJson, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	var content snapshotContent
+	if err := json.Unmarshal(snapshotJson, &content); err != nil {
+		return nil, err
+	}
+	if content.Layout == nil {
+		return nil, fmt.Errorf("snapshot has no layout")
+	}
+	root, ok := content.Panels[content.Layout.Name]
+	if !ok {
+		return nil, fmt.Errorf("snapshot has no panel for '%s'", content.Layout.Name)
+	}
+
+	report := &Report{
+		Title:       root.Title,
+		Header:      root.Tags[TagHeader],
+		Footer:      root.Tags[TagFooter],
+		Toc:         true,
+		PageSize:    DefaultPageSize,
+		Orientation: DefaultOrientation,
+		GeneratedAt: content.End,
+	}
+	if report.Title == "" {
+		report.Title = snapshot.Title
+	}
+	if toc, ok := root.Tags[TagToc]; ok {
+		report.Toc, err = strconv.ParseBool(toc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid '%s' tag '%s' - must be true or false", TagToc, toc)
+		}
+	}
+	if pageSize := root.Tags[TagPageSize]; pageSize != "" {
+		report.PageSize = pageSize
+	}
+	if orientation := root.Tags[TagOrientation]; orientation != "" {
+		if orientation != DefaultOrientation && orientation != OrientationLandscape {
+			return nil, fmt.Errorf("invalid '%s' tag '%s' - must be %s or %s", TagOrientation, orientation, DefaultOrientation, OrientationLandscape)
+		}
+		report.Orientation = orientation
+	}
+
+	for name, value := range content.Inputs {
+		report.Inputs = append(report.Inputs, KeyValue{Key: name, Value: formatValue(value)})
+	}
+	sort.Slice(report.Inputs, func(i, j int) bool { return report.Inputs[i].Key < report.Inputs[j].Key })
+
+	for i, child := range content.Layout.Children {
+		section := newNode(child, content.Panels, 2)
+		if section == nil {
+			continue
+		}
+		if section.Title == "" {
+			section.Title = fmt.Sprintf("Section %d", i+1)
+		}
+		report.Sections = append(report.Sections, section)
+	}
+	return report, nil
+}
+
+func newNode(layout *steampipeconfig.SnapshotTreeNode, panels map[string]*snapshotPanel, level int) *Node {
+	panel, ok := panels[layout.Name]
+	if !ok {
+		return nil
+	}
+	// inputs are interactive - their values are listed at the start of the report
+	if panel.PanelType == "input" || panel.PanelType == "with" {
+		return nil
+	}
+
+	node := &Node{
+		Id:          strings.ReplaceAll(panel.Name, ".", "-"),
+		Title:       panel.Title,
+		PanelType:   panel.PanelType,
+		DisplayType: panel.DisplayType,
+		Error:       panel.Error,
+		Level:       min(level, 6),
+		Summary:     parseSummary(panel.Summary),
+	}
+
+	switch panel.PanelType {
+	case "card":
+		node.setCard(panel)
+	case "text":
+		node.Text = formatValue(panel.Properties["value"])
+		node.Markdown = panel.DisplayType != "html"
+	case "image":
+		node.ImageSrc = formatValue(panel.Properties["src"])
+		if node.ImageSrc == "" {
+			node.ImageSrc = firstValue(panel)
+		}
+	default:
+		node.setTable(panel)
+	}
+
+	for _, child := range layout.Children {
+		if childNode := newNode(child, panels, level+1); childNode != nil {
+			node.Children = append(node.Children, childNode)
+		}
+	}
+	return node
+}
+
+// setCard sets the label and value of a card, from its properties or the first row of its data
+// (which either has label and value columns, or a single column used as the label and value)
+func (n *Node) setCard(panel *snapshotPanel) {
+	n.Label = formatValue(panel.Properties["label"])
+	n.Value = formatValue(panel.Properties["value"])
+	if panel.Data == nil || len(panel.Data.Rows) == 0 || len(panel.Data.Columns) == 0 {
+		return
+	}
+	row := panel.Data.Rows[0]
+	if value, ok := row["value"]; ok {
+		n.Value = formatValue(value)
+		if label, ok := row["label"]; ok {
+			n.Label = formatValue(label)
+		}
+		return
+	}
+	column := panel.Data.Columns[0].Name
+	n.Label = column
+	n.Value = formatValue(row[column])
+}
+
+func (n *Node) setTable(panel *snapshotPanel) {
+	if panel.Data == nil {
+		return
+	}
+	for _, c := range panel.Data.Columns {
+		n.Columns = append(n.Columns, c.Name)
+	}
+	rows := panel.Data.Rows
+	if len(rows) > MaxTableRows {
+		n.Truncated = len(rows) - MaxTableRows
+		rows = rows[:MaxTableRows]
+	}
+	for _, row := range rows {
+		values := make([]string, len(n.Columns))
+		for i, c := range n.Columns {
+			values[i] = formatValue(row[c])
+		}
+		n.Rows = append(n.Rows, values)
+	}
+}
+
+func firstValue(panel *snapshotPanel) string {
+	if panel.Data == nil || len(panel.Data.Rows) == 0 || len(panel.Data.Columns) == 0 {
+		return ""
+	}
+	return formatValue(panel.Data.Rows[0][panel.Data.Columns[0].Name])
+}
+
+// parseSummary returns the control result counts from a control summary, or a benchmark summary
+// (which nests the counts in a status field)
+func parseSummary(raw json.RawMessage) []KeyValue {
+	if len(raw) == 0 {
+		return nil
+	}
+	var summary struct {
+		Status *map[string]int `json:"status"`
+	}
+	counts := map[string]int{}
+	if err := json.Unmarshal(raw, &summary); err == nil && summary.Status != nil {
+		counts = *summary.Status
+	} else if err := json.Unmarshal(raw, &counts); err != nil {
+		return nil
+	}
+
+	var res []KeyValue
+	for _, status := range []string{"ok", "alarm", "error", "info", "skip"} {
+		if count, ok := counts[status]; ok {
+			res = append(res, KeyValue{Key: status, Value: strconv.Itoa(count)})
+		}
+	}
+	return res
+}
+
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		if res, ok := locale.Current().FormatNumber(v); ok {
+			return res
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]any, []any:
+		jsonBytes, _ := json.Marshal(v)
+		return string(jsonBytes)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,118 @@
+package report
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/steampipeconfig"
+)
+
+// testPanel is a snapshot panel, with the snapshot JSON fields of a dashboard run
+type testPanel struct {
+	Name       string            `json:"name"`
+	Title      string            `json:"title,omitempty"`
+	PanelType  string            `json:"panel_type"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	Properties map[string]any    `json:"properties,omitempty"`
+	Data       map[string]any    `json:"data,omitempty"`
+	Summary    map[string]any    `json:"summary,omitempty"`
+}
+
+func (*testPanel) IsSnapshotPanel() {}
+
+func newTestSnapshot(tags map[string]string) *steampipeconfig.SteampipeSnapshot {
+	panels := map[string]steampipeconfig.SnapshotPanel{
+		"d":        &testPanel{Name: "d", Title: "Monthly <Review>", PanelType: "dashboard", Tags: tags},
+		"summary":  &testPanel{Name: "summary", Title: "Summary", PanelType: "container"},
+		"card":     &testPanel{Name: "card", PanelType: "card", Data: map[string]any{"columns": []any{map[string]any{"name": "Buckets"}}, "rows": []any{map[string]any{"Buckets": 12}}}},
+		"text":     &testPanel{Name: "text", PanelType: "text", Properties: map[string]any{"value": "## Notes\n- **one**\n- two"}},
+		"input":    &testPanel{Name: "input", PanelType: "input"},
+		"table":    &testPanel{Name: "table", PanelType: "table", Data: map[string]any{"columns": []any{map[string]any{"name": "name"}}, "rows": []any{map[string]any{"name": "a"}, map[string]any{"name": nil}}}},
+		"controls": &testPanel{Name: "controls", PanelType: "benchmark", Summary: map[string]any{"status": map[string]any{"ok": 3, "alarm": 1}}},
+	}
+	layout := &steampipeconfig.SnapshotTreeNode{Name: "d", Children: []*steampipeconfig.SnapshotTreeNode{
+		{Name: "summary", Children: []*steampipeconfig.SnapshotTreeNode{{Name: "card"}, {Name: "text"}}},
+		{Name: "input"},
+		{Name: "table"},
+		{Name: "controls"},
+	}}
+	return &steampipeconfig.SteampipeSnapshot{Panels: panels, Layout: layout, Inputs: map[string]any{"input.team": "platform"}}
+}
+
+type newReportTest struct {
+	tags     map[string]string
+	contains []string
+	excludes []string
+	err      bool
+}
+
+var testCasesNewReport = map[string]newReportTest{
+	"default": {
+		tags: map[string]string{"report": "true", "report_header": "Confidential"},
+		contains: []string{
+			"<h1>Monthly &lt;Review&gt;</h1>",
+			`<div class="page-header">Confidential</div>`,
+			`<li><a href="#summary">Summary</a></li>`,
+			`<li><a href="#table">Section 3</a></li>`,
+			`<div class="card-label">Buckets</div>`,
+			`<div class="card-value">12</div>`,
+			"<h4>Notes</h4><ul><li><strong>one</strong></li><li>two</li></ul>",
+			"<tr><td>a</td></tr>",
+			`<span class="status-ok">ok: 3</span><span class="status-alarm">alarm: 1</span>`,
+			"<tr><td>input.team</td><td>platform</td></tr>",
+			"size: A4 portrait;",
+		},
+		excludes: []string{`class="page-footer"`},
+	},
+	"no toc, landscape": {
+		tags:     map[string]string{"report_toc": "false", "report_orientation": "landscape", "report_page_size": "letter"},
+		contains: []string{"size: letter landscape;"},
+		excludes: []string{"Contents"},
+	},
+	"invalid toc": {
+		tags: map[string]string{"report_toc": "maybe"},
+		err:  true,
+	},
+	"invalid orientation": {
+		tags: map[string]string{"report_orientation": "sideways"},
+		err:  true,
+	},
+}
+
+func TestNewReport(t *testing.T) {
+	for name, test := range testCasesNewReport {
+		report, err := NewReport(newTestSnapshot(test.tags))
+		if test.err {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		res, err := report.RenderHtml()
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		html, err := io.ReadAll(res)
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		actual := string(html)
+		for _, s := range test.contains {
+			if !strings.Contains(actual, s) {
+				t.Errorf("Test: '%s' FAILED : expected output to contain '%s', got '%s'", name, s, actual)
+			}
+		}
+		for _, s := range test.excludes {
+			if strings.Contains(actual, s) {
+				t.Errorf("Test: '%s' FAILED : expected output not to contain '%s'", name, s)
+			}
+		}
+	}
+}
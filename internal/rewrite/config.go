@@ -0,0 +1,147 @@
+package rewrite
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/modconfig"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+// a query rewrite config remaps the table references of control and dashboard SQL before it is executed,
+// so mods written against standard schema and table names can run against environments which use other names, e.g.
+//
+//	rule "aws_prod" {
+//	  mods           = ["aws_compliance", "aws_insights"]
+//	  schemas        = { aws = "aws_prod" }
+//	  table_prefixes = { "aws_" = "prod_aws_" }
+//	}
+//
+// with this rule, 'from aws.aws_s3_bucket' in the aws_compliance and aws_insights mods is executed as
+// 'from aws_prod.prod_aws_s3_bucket'
+//
+// rules only apply to table references - the tables following 'from' and 'join' (and in comma separated from lists)
+// schemas remap the schema of qualified table references, and table prefixes replace the prefix of table names
+// a rule with no mods applies to all mods - mods are identified by their short name, e.g. 'aws_compliance'
+// the first rule which matches a schema or table name is applied
+type Config struct {
+	Rules []*Rule `hcl:"rule,block"`
+}
+
+// Rule is a set of schema and table name remappings
+type Rule struct {
+	Name          string            `hcl:"name,label"`
+	Mods          []string          `hcl:"mods,optional"`
+	Schemas       map[string]string `hcl:"schemas,optional"`
+	TablePrefixes map[string]string `hcl:"table_prefixes,optional"`
+}
+
+// LoadConfig loads a query rewrite config from an HCL file
+func LoadConfig(filePath string) (*Config, error) {
+	fileData, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query rewrite config: %w", err)
+	}
+	file, diags := hclparse.NewParser().ParseHCL(fileData, filePath)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse query rewrite config: %s", diags.Error())
+	}
+
+	config := &Config{}
+	if diags := gohcl.DecodeBody(file.Body, nil, config); diags.HasErrors() {
+		return nil, fmt.Errorf("failed to decode query rewrite config: %s", diags.Error())
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate checks rule names are unique, and each rule remaps at least one schema or table prefix
+func (c *Config) Validate() error {
+	rules := make(map[string]struct{}, len(c.Rules))
+	for _, r := range c.Rules {
+		if _, ok := rules[r.Name]; ok {
+			return fmt.Errorf("query rewrite config contains duplicate rule '%s'", r.Name)
+		}
+		rules[r.Name] = struct{}{}
+		if len(r.Schemas) == 0 && len(r.TablePrefixes) == 0 {
+			return fmt.Errorf("rule '%s' has no schemas or table_prefixes", r.Name)
+		}
+		for from, to := range r.Schemas {
+			if from == "" || to == "" {
+				return fmt.Errorf("rule '%s' has an empty schema name", r.Name)
+			}
+		}
+		for from := range r.TablePrefixes {
+			if from == "" {
+				return fmt.Errorf("rule '%s' has an empty table prefix", r.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// RewriteQueries rewrites the SQL of the query providers using the query rewrite config set by
+// --query-rewrite-config, if any
+func RewriteQueries(resourceMaps *modconfig.ResourceMaps) error {
+	configPath := viper.GetString(localconstants.ArgQueryRewriteConfig)
+	if configPath == "" {
+		return nil
+	}
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	config.Apply(resourceMaps)
+	return nil
+}
+
+// Apply rewrites the SQL of the query providers
+func (c *Config) Apply(resourceMaps *modconfig.ResourceMaps) {
+	// the SQL of a resource may be shared with its base resource - only rewrite it once
+	rewritten := make(map[*string]struct{})
+	for _, queryProvider := range resourceMaps.QueryProviders() {
+		sql := queryProvider.GetSQL()
+		if sql == nil {
+			continue
+		}
+		if _, ok := rewritten[sql]; ok {
+			continue
+		}
+		rewritten[sql] = struct{}{}
+		if rules := c.rulesForMod(queryProvider.GetMod()); len(rules) > 0 {
+			*sql = Rewrite(*sql, rules)
+		}
+	}
+}
+
+// rulesForMod returns the rules which apply to the given mod
+func (c *Config) rulesForMod(mod *modconfig.Mod) []*Rule {
+	var res []*Rule
+	for _, r := range c.Rules {
+		if r.appliesTo(mod) {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+func (r *Rule) appliesTo(mod *modconfig.Mod) bool {
+	if len(r.Mods) == 0 {
+		return true
+	}
+	if mod == nil {
+		return false
+	}
+	for _, m := range r.Mods {
+		if strings.EqualFold(m, mod.ShortName) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,301 @@
+package rewrite
+
+import (
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenIdentifier tokenKind = iota
+	tokenQuotedIdentifier
+	tokenPunctuation
+	// string literals and any other token
+	tokenOther
+)
+
+type token struct {
+	kind tokenKind
+	// the offsets of the token in the SQL
+	start, end int
+	// the identifier name (without quotes), or the punctuation character
+	value string
+}
+
+// keywords which end the from list of a query - identifiers which follow them are not table references
+var fromListEnd = map[string]struct{}{
+	"where": {}, "group": {}, "having": {}, "order": {}, "limit": {}, "offset": {}, "fetch": {}, "window": {},
+	"qualify": {}, "union": {}, "intersect": {}, "except": {}, "on": {}, "using": {}, "returning": {},
+	"select": {}, "set": {}, "values": {}, "for": {},
+}
+
+// keywords which may precede a table reference without ending it, e.g. 'from only t' or 'left outer join t'
+var tableModifiers = map[string]struct{}{
+	"only": {}, "lateral": {}, "inner": {}, "left": {}, "right": {}, "full": {}, "outer": {}, "cross": {}, "natural": {},
+}
+
+// Rewrite applies the schema and table prefix remappings of the rules to the table references of the SQL
+// the rest of the SQL, including comments and string literals, is unchanged
+func Rewrite(sql string, rules []*Rule) string {
+	tokens := tokenize(sql)
+
+	type replacement struct {
+		token token
+		value string
+	}
+	var replacements []replacement
+	replace := func(t token, value string) {
+		if t.kind == tokenQuotedIdentifier {
+			value = `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+		}
+		replacements = append(replacements, replacement{t, value})
+	}
+
+	// the state of each level of parentheses - whether it is in a from list, and whether it is the args of a function
+	type level struct {
+		inFrom     bool
+		isFunction bool
+	}
+	levels := []*level{{}}
+	expectTable := false
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		current := levels[len(levels)-1]
+
+		if t.kind == tokenIdentifier {
+			keyword := strings.ToLower(t.value)
+			switch keyword {
+			case "from":
+				// from is also used in function args, e.g. extract(epoch from created_at)
+				if !current.isFunction {
+					current.inFrom = true
+					expectTable = true
+				}
+				continue
+			case "join":
+				current.inFrom = true
+				expectTable = true
+				continue
+			}
+			if _, ok := tableModifiers[keyword]; ok && expectTable {
+				continue
+			}
+			if _, ok := fromListEnd[keyword]; ok {
+				current.inFrom = false
+				expectTable = false
+				continue
+			}
+		}
+
+		if t.kind == tokenPunctuation {
+			switch t.value {
+			case "(":
+				isFunction := i > 0 && (tokens[i-1].kind == tokenIdentifier || tokens[i-1].kind == tokenQuotedIdentifier) && !isKeyword(tokens[i-1].value)
+				levels = append(levels, &level{isFunction: isFunction})
+			case ")":
+				if len(levels) > 1 {
+					levels = levels[:len(levels)-1]
+				}
+			case ",":
+				expectTable = current.inFrom
+				continue
+			}
+			expectTable = false
+			continue
+		}
+
+		if !expectTable || (t.kind != tokenIdentifier && t.kind != tokenQuotedIdentifier) {
+			expectTable = false
+			continue
+		}
+		expectTable = false
+
+		// a table reference is a (possibly qualified) name which is not followed by '(' - otherwise it is a function call
+		chain := []token{t}
+		for i+2 < len(tokens) && tokens[i+1].kind == tokenPunctuation && tokens[i+1].value == "." &&
+			(tokens[i+2].kind == tokenIdentifier || tokens[i+2].kind == tokenQuotedIdentifier) {
+			chain = append(chain, tokens[i+2])
+			i += 2
+		}
+		if i+1 < len(tokens) && tokens[i+1].kind == tokenPunctuation && tokens[i+1].value == "(" {
+			continue
+		}
+
+		// NOTE: replacements must be added in order of position
+		if len(chain) > 1 {
+			schema := chain[len(chain)-2]
+			if value, ok := remapSchema(schema, rules); ok {
+				replace(schema, value)
+			}
+		}
+		table := chain[len(chain)-1]
+		if value, ok := remapTable(table, rules); ok {
+			replace(table, value)
+		}
+	}
+
+	if len(replacements) == 0 {
+		return sql
+	}
+	var res strings.Builder
+	pos := 0
+	for _, r := range replacements {
+		res.WriteString(sql[pos:r.token.start])
+		res.WriteString(r.value)
+		pos = r.token.end
+	}
+	res.WriteString(sql[pos:])
+	return res.String()
+}
+
+// remapSchema returns the schema name given by the first rule which remaps the schema
+func remapSchema(t token, rules []*Rule) (string, bool) {
+	for _, r := range rules {
+		for from, to := range r.Schemas {
+			if nameEquals(t, from) {
+				return to, true
+			}
+		}
+	}
+	return "", false
+}
+
+// remapTable returns the table name with its prefix replaced by the first rule which remaps the prefix
+// if a rule has more than one matching prefix, the longest is used
+func remapTable(t token, rules []*Rule) (string, bool) {
+	for _, r := range rules {
+		var match string
+		for from := range r.TablePrefixes {
+			if len(from) > len(match) && hasPrefix(t, from) {
+				match = from
+			}
+		}
+		if match != "" {
+			return r.TablePrefixes[match] + t.value[len(match):], true
+		}
+	}
+	return "", false
+}
+
+// nameEquals returns whether the identifier has the given name - unquoted identifiers are case insensitive
+func nameEquals(t token, name string) bool {
+	if t.kind == tokenQuotedIdentifier {
+		return t.value == name
+	}
+	return strings.EqualFold(t.value, name)
+}
+
+func hasPrefix(t token, prefix string) bool {
+	if len(t.value) < len(prefix) {
+		return false
+	}
+	return nameEquals(token{kind: t.kind, value: t.value[:len(prefix)]}, prefix)
+}
+
+func isKeyword(value string) bool {
+	keyword := strings.ToLower(value)
+	if _, ok := fromListEnd[keyword]; ok {
+		return true
+	}
+	if _, ok := tableModifiers[keyword]; ok {
+		return true
+	}
+	switch keyword {
+	case "from", "join", "as", "in", "exists", "and", "or", "not", "with", "recursive", "materialized":
+		return true
+	}
+	return false
+}
+
+// tokenize splits the SQL into identifiers, punctuation and other tokens - whitespace and comments are skipped
+func tokenize(sql string) []token {
+	var tokens []token
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(sql[i:], "--"):
+			if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(sql)
+			}
+			continue
+		case strings.HasPrefix(sql[i:], "/*"):
+			if end := strings.Index(sql[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				i = len(sql)
+			}
+			continue
+		case c == '\'':
+			i = quotedEnd(sql, i, '\'')
+			tokens = append(tokens, token{kind: tokenOther, start: start, end: i})
+		case c == '"':
+			i = quotedEnd(sql, i, '"')
+			value := strings.ReplaceAll(strings.TrimSuffix(sql[start+1:i], `"`), `""`, `"`)
+			tokens = append(tokens, token{kind: tokenQuotedIdentifier, start: start, end: i, value: value})
+		case c == '$':
+			// a dollar quoted string, e.g. $$text$$ or $tag$text$tag$ - otherwise a parameter, e.g. $1
+			tagEnd := i + 1
+			for tagEnd < len(sql) && (isIdentifierStart(sql[tagEnd]) || (tagEnd > i+1 && isDigit(sql[tagEnd]))) {
+				tagEnd++
+			}
+			if tagEnd < len(sql) && sql[tagEnd] == '$' {
+				tag := sql[i : tagEnd+1]
+				if end := strings.Index(sql[tagEnd+1:], tag); end >= 0 {
+					i = tagEnd + 1 + end + len(tag)
+				} else {
+					i = len(sql)
+				}
+			} else {
+				i = tagEnd
+			}
+			tokens = append(tokens, token{kind: tokenOther, start: start, end: i})
+		case isIdentifierStart(c):
+			for i < len(sql) && isIdentifierChar(sql[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdentifier, start: start, end: i, value: sql[start:i]})
+		case isDigit(c):
+			for i < len(sql) && (isIdentifierChar(sql[i]) || sql[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenOther, start: start, end: i})
+		default:
+			i++
+			tokens = append(tokens, token{kind: tokenPunctuation, start: start, end: i, value: string(c)})
+		}
+	}
+	return tokens
+}
+
+// quotedEnd returns the offset after the closing quote of the quoted text starting at i - a doubled quote is an escaped quote
+func quotedEnd(sql string, i int, quote byte) int {
+	for i++; i < len(sql); i++ {
+		if sql[i] == quote {
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isIdentifierChar(c byte) bool {
+	return isIdentifierStart(c) || isDigit(c) || c == '$'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,82 @@
+package rewrite
+
+import (
+	"testing"
+)
+
+type rewriteTest struct {
+	sql      string
+	expected string
+}
+
+var testRewriteRules = []*Rule{
+	{
+		Name:          "prod",
+		Schemas:       map[string]string{"aws": "aws_prod"},
+		TablePrefixes: map[string]string{"aws_": "prod_aws_", "aws_s3_": "storage_s3_"},
+	},
+	{
+		Name:    "net",
+		Schemas: map[string]string{"net": "network", "aws": "ignored"},
+	},
+}
+
+var testCasesRewrite = map[string]rewriteTest{
+	"unqualified table": {
+		sql:      "select arn from aws_iam_role",
+		expected: "select arn from prod_aws_iam_role",
+	},
+	"qualified table": {
+		sql:      "select * from aws.aws_iam_role r join net.net_dns_record d on d.name = r.name",
+		expected: "select * from aws_prod.prod_aws_iam_role r join network.net_dns_record d on d.name = r.name",
+	},
+	"longest prefix": {
+		sql:      "select * from aws_s3_bucket",
+		expected: "select * from storage_s3_bucket",
+	},
+	"from list": {
+		sql:      "select * from aws_a a, aws_b as b left outer join aws_c c using (id) where a.aws_id = b.aws_id",
+		expected: "select * from prod_aws_a a, prod_aws_b as b left outer join prod_aws_c c using (id) where a.aws_id = b.aws_id",
+	},
+	"quoted identifiers": {
+		sql:      `select * from "aws"."aws_iam_role"`,
+		expected: `select * from "aws_prod"."prod_aws_iam_role"`,
+	},
+	"quoted identifiers are case sensitive": {
+		sql:      `select * from "AWS"."AWS_iam_role"`,
+		expected: `select * from "AWS"."AWS_iam_role"`,
+	},
+	"unquoted identifiers are case insensitive": {
+		sql:      `select * from AWS.AWS_iam_role`,
+		expected: `select * from aws_prod.prod_aws_iam_role`,
+	},
+	"subqueries and ctes": {
+		sql:      "with roles as (select * from aws_iam_role) select * from roles where arn in (select arn from aws.aws_iam_policy)",
+		expected: "with roles as (select * from prod_aws_iam_role) select * from roles where arn in (select arn from aws_prod.prod_aws_iam_policy)",
+	},
+	"functions": {
+		sql:      "select extract(epoch from aws_time) from aws_x, jsonb_array_elements(aws_x.policy) as s, lateral aws_fn(1)",
+		expected: "select extract(epoch from aws_time) from prod_aws_x, jsonb_array_elements(aws_x.policy) as s, lateral aws_fn(1)",
+	},
+	"strings and comments": {
+		sql:      "-- from aws_a\nselect 'from aws_b', $$from aws_c$$, $tag$ from aws_d $tag$ /* from aws_e */ from aws_f where x = $1",
+		expected: "-- from aws_a\nselect 'from aws_b', $$from aws_c$$, $tag$ from aws_d $tag$ /* from aws_e */ from prod_aws_f where x = $1",
+	},
+	"columns": {
+		sql:      "select aws.aws_col from aws_t as aws",
+		expected: "select aws.aws_col from prod_aws_t as aws",
+	},
+	"no match": {
+		sql:      "select * from azure_ad_user",
+		expected: "select * from azure_ad_user",
+	},
+}
+
+func TestRewrite(t *testing.T) {
+	for name, test := range testCasesRewrite {
+		actual := Rewrite(test.sql, testRewriteRules)
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected\n%s\ngot\n%s", name, test.expected, actual)
+		}
+	}
+}
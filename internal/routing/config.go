@@ -0,0 +1,134 @@
+package routing
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+)
+
+// a routing config sends the alarm and error findings of a benchmark run, and the findings of detections in server mode,
+// to the notifiers of the teams which own them, e.g.
+//
+//	notifier "platform_slack" {
+//	  type = "slack"
+//	  url  = "https://hooks.slack.com/services/..."
+//	}
+//
+//	notifier "triage" {
+//	  type    = "webhook"
+//	  url     = "https://triage.internal/api/findings"
+//	  headers = { Authorization = "Bearer ..." }
+//	}
+//
+//	route "platform" {
+//	  tag       = "team"
+//	  values    = ["platform", "infra"]
+//	  notifiers = ["platform_slack"]
+//	}
+//
+//	unrouted_notifiers = ["triage"]
+//
+// a finding matches a route if the control tag, or the result dimension, with the route tag key has one of the
+// route values (or any value, if no values are set) - a finding may match more than one route
+// (for a detection, the column of the finding or the tag of the detection query with the route tag key)
+// findings which match no route are reported as unrouted, and sent to the unrouted notifiers
+const (
+	NotifierTypeWebhook = "webhook"
+	NotifierTypeSlack   = "slack"
+)
+
+type Config struct {
+	Notifiers         []*Notifier `hcl:"notifier,block"`
+	Routes            []*Route    `hcl:"route,block"`
+	UnroutedNotifiers []string    `hcl:"unrouted_notifiers,optional"`
+}
+
+// Notifier is a target which findings are sent to
+type Notifier struct {
+	Name    string            `hcl:"name,label"`
+	Type    string            `hcl:"type"`
+	Url     string            `hcl:"url"`
+	Headers map[string]string `hcl:"headers,optional"`
+}
+
+// Route maps findings with a tag value to notifiers
+type Route struct {
+	Name      string   `hcl:"name,label"`
+	Tag       string   `hcl:"tag"`
+	Values    []string `hcl:"values,optional"`
+	Notifiers []string `hcl:"notifiers"`
+}
+
+// LoadConfig loads a routing config from an HCL file
+func LoadConfig(filePath string) (*Config, error) {
+	fileData, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routing config: %w", err)
+	}
+	file, diags := hclparse.NewParser().ParseHCL(fileData, filePath)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse routing config: %s", diags.Error())
+	}
+
+	config := &Config{}
+	if diags := gohcl.DecodeBody(file.Body, nil, config); diags.HasErrors() {
+		return nil, fmt.Errorf("failed to decode routing config: %s", diags.Error())
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate checks notifier and route names are unique, notifiers are valid, and routes only reference defined notifiers
+func (c *Config) Validate() error {
+	notifiers := make(map[string]struct{}, len(c.Notifiers))
+	for _, n := range c.Notifiers {
+		if _, ok := notifiers[n.Name]; ok {
+			return fmt.Errorf("routing config contains duplicate notifier '%s'", n.Name)
+		}
+		notifiers[n.Name] = struct{}{}
+		if !slices.Contains([]string{NotifierTypeWebhook, NotifierTypeSlack}, n.Type) {
+			return fmt.Errorf("notifier '%s' has invalid type '%s' - must be %s or %s", n.Name, n.Type, NotifierTypeWebhook, NotifierTypeSlack)
+		}
+		if u, err := url.Parse(n.Url); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("notifier '%s' has invalid url '%s'", n.Name, n.Url)
+		}
+	}
+
+	checkNotifiers := func(source string, names []string) error {
+		for _, name := range names {
+			if _, ok := notifiers[name]; !ok {
+				return fmt.Errorf("%s references unknown notifier '%s'", source, name)
+			}
+		}
+		return nil
+	}
+	routes := make(map[string]struct{}, len(c.Routes))
+	for _, r := range c.Routes {
+		if _, ok := routes[r.Name]; ok {
+			return fmt.Errorf("routing config contains duplicate route '%s'", r.Name)
+		}
+		routes[r.Name] = struct{}{}
+		if r.Tag == "" {
+			return fmt.Errorf("route '%s' has no tag", r.Name)
+		}
+		if err := checkNotifiers(fmt.Sprintf("route '%s'", r.Name), r.Notifiers); err != nil {
+			return err
+		}
+	}
+	return checkNotifiers("unrouted_notifiers", c.UnroutedNotifiers)
+}
+
+func (c *Config) getNotifier(name string) *Notifier {
+	for _, n := range c.Notifiers {
+		if n.Name == name {
+			return n
+		}
+	}
+	return nil
+}
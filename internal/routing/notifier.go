@@ -0,0 +1,97 @@
+package routing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/turbot/pipe-fittings/error_helpers"
+)
+
+const (
+	notifyTimeout = 30 * time.Second
+	// limit the findings listed in slack messages - the full list is sent to webhooks
+	maxSlackFindings = 20
+	unroutedName     = "unrouted"
+)
+
+// webhookPayload is the body posted to webhook notifiers
+type webhookPayload struct {
+	Run      string     `json:"run"`
+	Route    string     `json:"route"`
+	Findings []*Finding `json:"findings"`
+}
+
+// Notify sends the findings of each route to its notifiers using the client, and the unrouted findings to the
+// unrouted notifiers
+// routes with no findings are not notified
+func (c *Config) Notify(ctx context.Context, client *http.Client, runName string, result *Result) error {
+	var errors []error
+	send := func(route string, notifierNames []string, findings []*Finding) {
+		if len(findings) == 0 {
+			return
+		}
+		for _, name := range notifierNames {
+			if err := c.getNotifier(name).send(ctx, client, runName, route, findings); err != nil {
+				errors = append(errors, fmt.Errorf("failed to notify '%s' of route '%s' findings: %w", name, route, err))
+			}
+		}
+	}
+	for _, routed := range result.Routes {
+		send(routed.Route.Name, routed.Route.Notifiers, routed.Findings)
+	}
+	send(unroutedName, c.UnroutedNotifiers, result.Unrouted)
+	return error_helpers.CombineErrors(errors...)
+}
+
+func (n *Notifier) send(ctx context.Context, client *http.Client, runName, route string, findings []*Finding) error {
+	var body any = &webhookPayload{Run: runName, Route: route, Findings: findings}
+	if n.Type == NotifierTypeSlack {
+		body = map[string]string{"text": slackMessage(runName, route, findings)}
+	}
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.Url, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range n.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned status %s", n.Url, resp.Status)
+	}
+	return nil
+}
+
+func slackMessage(runName, route string, findings []*Finding) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("*%s*: %d findings for *%s*", runName, len(findings), route))
+	for i, f := range findings {
+		if i == maxSlackFindings {
+			b.WriteString(fmt.Sprintf("\n… and %d more", len(findings)-maxSlackFindings))
+			break
+		}
+		resource := f.Resource
+		if f.Href != "" {
+			resource = fmt.Sprintf("<%s|%s>", f.Href, f.Resource)
+		}
+		b.WriteString(fmt.Sprintf("\n• [%s] %s - %s: %s", f.Status, f.Control, resource, f.Reason))
+	}
+	return b.String()
+}
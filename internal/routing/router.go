@@ -0,0 +1,170 @@
+package routing
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/detection"
+)
+
+// the columns of a detection query which are the resource and reason of its findings
+const (
+	columnResource = "resource"
+	columnReason   = "reason"
+)
+
+// Finding is an alarm or error result of a control, or a finding of a detection
+type Finding struct {
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// the name of the control or detection
+	Control  string `json:"control"`
+	Title    string `json:"title,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Status   string `json:"status"`
+	Resource string `json:"resource"`
+	Reason   string `json:"reason"`
+	Href     string `json:"href,omitempty"`
+	// the row returned by the query of a detection
+	Data map[string]any `json:"data,omitempty"`
+	// the snapshot of the detection run
+	Snapshot string `json:"snapshot,omitempty"`
+}
+
+// RoutedFindings is the findings which matched a route
+type RoutedFindings struct {
+	Route    *Route
+	Findings []*Finding
+}
+
+// Result is the findings of a run, grouped by route
+type Result struct {
+	Routes   []*RoutedFindings
+	Unrouted []*Finding
+}
+
+// Route groups the alarm and error findings of an executed tree by the routes they match
+func (c *Config) Route(tree *controlexecute.ExecutionTree) *Result {
+	res, routed := c.newResult()
+
+	// sort the control runs, so findings are in a consistent order
+	runs := make([]*controlexecute.ControlRun, 0, len(tree.ControlRuns))
+	for _, run := range tree.ControlRuns {
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].FullName < runs[j].FullName })
+
+	for _, run := range runs {
+		for _, row := range run.Rows {
+			if row.Status != constants.ControlAlarm && row.Status != constants.ControlError {
+				continue
+			}
+			finding := &Finding{
+				Fingerprint: row.Fingerprint,
+				Control:     run.FullName,
+				Title:       run.Title,
+				Severity:    run.Severity,
+				Status:      row.Status,
+				Resource:    row.Resource,
+				Reason:      row.Reason,
+				Href:        row.Href,
+			}
+			// result dimensions take precedence over control tags
+			c.addFinding(res, routed, finding, func(tag string) string {
+				if value := row.GetDimensionValue(tag); value != "" {
+					return value
+				}
+				return run.Tags[tag]
+			})
+		}
+	}
+	return res
+}
+
+// RouteDetection groups the findings of a detection run by the routes they match - a finding matches a route if its
+// data, or the tags of the detection query, has a value for the route tag which the route accepts
+// (the data of the finding takes precedence over the query tags)
+//
+// the resource and reason of a finding are the values of its 'resource' and 'reason' columns, if the detection query
+// returns them
+func (c *Config) RouteDetection(d *detection.Detection, findings []*detection.Finding) *Result {
+	res, routed := c.newResult()
+	for _, f := range findings {
+		finding := &Finding{
+			Control:  d.Name,
+			Title:    d.Title,
+			Severity: f.Severity,
+			Status:   constants.ControlAlarm,
+			Resource: f.GetDataValue(columnResource),
+			Reason:   f.GetDataValue(columnReason),
+			Data:     f.Data,
+			Snapshot: f.Snapshot,
+		}
+		c.addFinding(res, routed, finding, func(tag string) string {
+			if value := f.GetDataValue(tag); value != "" {
+				return value
+			}
+			return d.Query.Tags[tag]
+		})
+	}
+	return res
+}
+
+// newResult returns an empty result, and the findings of its routes keyed by route name
+func (c *Config) newResult() (*Result, map[string]*RoutedFindings) {
+	res := &Result{}
+	routed := make(map[string]*RoutedFindings, len(c.Routes))
+	for _, route := range c.Routes {
+		routed[route.Name] = &RoutedFindings{Route: route}
+		res.Routes = append(res.Routes, routed[route.Name])
+	}
+	return res, routed
+}
+
+// addFinding adds the finding to each route it matches, given the value of each tag for the finding - or to the
+// unrouted findings if it matches none
+func (c *Config) addFinding(res *Result, routed map[string]*RoutedFindings, finding *Finding, tagValue func(string) string) {
+	matched := false
+	for _, route := range c.Routes {
+		if route.accepts(tagValue(route.Tag)) {
+			routed[route.Name].Findings = append(routed[route.Name].Findings, finding)
+			matched = true
+		}
+	}
+	if !matched {
+		res.Unrouted = append(res.Unrouted, finding)
+	}
+}
+
+// accepts returns whether the route accepts the value of its tag for a finding
+func (r *Route) accepts(value string) bool {
+	if value == "" {
+		return false
+	}
+	return len(r.Values) == 0 || slices.Contains(r.Values, value)
+}
+
+// UnroutedReport returns a summary of the findings which matched no route, listing the number of findings for each control
+func (r *Result) UnroutedReport() string {
+	if len(r.Unrouted) == 0 {
+		return ""
+	}
+	var controls []string
+	counts := make(map[string]int)
+	for _, f := range r.Unrouted {
+		if counts[f.Control] == 0 {
+			controls = append(controls, f.Control)
+		}
+		counts[f.Control]++
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%d findings matched no route:", len(r.Unrouted)))
+	for _, control := range controls {
+		b.WriteString(fmt.Sprintf("\n  %s: %d", control, counts[control]))
+	}
+	return b.String()
+}
@@ -0,0 +1,180 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/detection"
+)
+
+func newTestTree() *controlexecute.ExecutionTree {
+	tree := &controlexecute.ExecutionTree{ControlRuns: make(map[string]*controlexecute.ControlRun)}
+	for _, c := range []struct {
+		name string
+		tags map[string]string
+		rows []*controlexecute.ResultRow
+	}{
+		{"c1", map[string]string{"team": "platform"}, []*controlexecute.ResultRow{
+			{Resource: "r1", Status: "alarm"},
+			{Resource: "r2", Status: "ok"},
+			{Resource: "r3", Status: "alarm", Dimensions: []controlexecute.Dimension{{Key: "team", Value: "security"}}},
+		}},
+		{"c2", map[string]string{"team": "data"}, []*controlexecute.ResultRow{
+			{Resource: "r4", Status: "error"},
+		}},
+		{"c3", nil, []*controlexecute.ResultRow{
+			{Resource: "r5", Status: "alarm"},
+			{Resource: "r6", Status: "info"},
+		}},
+	} {
+		tree.ControlRuns[c.name] = &controlexecute.ControlRun{FullName: c.name, Tags: c.tags, Rows: c.rows}
+	}
+	return tree
+}
+
+type routeTest struct {
+	routes   []*Route
+	expected string
+}
+
+var testCasesRoute = map[string]routeTest{
+	"no routes": {
+		expected: "unrouted[c1:r1 c1:r3 c2:r4 c3:r5]",
+	},
+	"route by value": {
+		routes: []*Route{
+			{Name: "platform", Tag: "team", Values: []string{"platform"}},
+			{Name: "security", Tag: "team", Values: []string{"security", "data"}},
+		},
+		expected: "platform[c1:r1] security[c1:r3 c2:r4] unrouted[c3:r5]",
+	},
+	"route any value": {
+		routes:   []*Route{{Name: "teams", Tag: "team"}},
+		expected: "teams[c1:r1 c1:r3 c2:r4] unrouted[c3:r5]",
+	},
+	"multiple matching routes": {
+		routes: []*Route{
+			{Name: "all", Tag: "team"},
+			{Name: "platform", Tag: "team", Values: []string{"platform"}},
+		},
+		expected: "all[c1:r1 c1:r3 c2:r4] platform[c1:r1] unrouted[c3:r5]",
+	},
+}
+
+func formatFindings(name string, findings []*Finding) string {
+	var res []string
+	for _, f := range findings {
+		res = append(res, f.Control+":"+f.Resource)
+	}
+	return fmt.Sprintf("%s[%s]", name, strings.Join(res, " "))
+}
+
+func TestRoute(t *testing.T) {
+	for name, test := range testCasesRoute {
+		config := &Config{Routes: test.routes}
+		result := config.Route(newTestTree())
+
+		var actual []string
+		for _, routed := range result.Routes {
+			actual = append(actual, formatFindings(routed.Route.Name, routed.Findings))
+		}
+		if len(result.Unrouted) > 0 {
+			actual = append(actual, formatFindings("unrouted", result.Unrouted))
+		}
+		if strings.Join(actual, " ") != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, strings.Join(actual, " "))
+		}
+	}
+}
+
+func TestRouteDetection(t *testing.T) {
+	query := &modconfig.Query{}
+	query.Tags = map[string]string{"team": "security"}
+	d := &detection.Detection{Name: "d1", Query: query}
+	findings := []*detection.Finding{
+		{Data: map[string]any{"resource": "r1", "reason": "failed login"}},
+		{Data: map[string]any{"resource": "r2", "team": "platform"}},
+		{Data: map[string]any{"resource": 3}},
+	}
+	config := &Config{Routes: []*Route{
+		{Name: "platform", Tag: "team", Values: []string{"platform"}},
+		{Name: "data", Tag: "team", Values: []string{"data"}},
+	}}
+	result := config.RouteDetection(d, findings)
+
+	var actual []string
+	for _, routed := range result.Routes {
+		actual = append(actual, formatFindings(routed.Route.Name, routed.Findings))
+	}
+	actual = append(actual, formatFindings("unrouted", result.Unrouted))
+	// the column of the finding takes precedence over the query tag
+	if expected := "platform[d1:r2] data[] unrouted[d1:r1 d1:3]"; strings.Join(actual, " ") != expected {
+		t.Errorf("Test: 'route detection' FAILED : expected '%s', got '%s'", expected, strings.Join(actual, " "))
+	}
+	if f := result.Unrouted[0]; f.Reason != "failed login" || f.Status != "alarm" {
+		t.Errorf("Test: 'detection finding' FAILED : expected an alarm with reason 'failed login', got %+v", f)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	var lock sync.Mutex
+	received := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		if text, ok := payload["text"]; ok {
+			received[r.URL.Path] = fmt.Sprintf("%v", text)
+		} else {
+			received[r.URL.Path] = fmt.Sprintf("%v:%d", payload["route"], len(payload["findings"].([]any)))
+		}
+	}))
+	defer server.Close()
+
+	config := &Config{
+		Notifiers: []*Notifier{
+			{Name: "slack", Type: NotifierTypeSlack, Url: server.URL + "/slack"},
+			{Name: "webhook", Type: NotifierTypeWebhook, Url: server.URL + "/webhook"},
+		},
+		Routes:            []*Route{{Name: "platform", Tag: "team", Values: []string{"platform"}, Notifiers: []string{"slack"}}},
+		UnroutedNotifiers: []string{"webhook"},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Notify(context.Background(), server.Client(), "nightly", config.Route(newTestTree())); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "*nightly*: 1 findings for *platform*\n• [alarm] c1 - r1: "; received["/slack"] != expected {
+		t.Errorf("Test: 'slack' FAILED : expected '%s', got '%s'", expected, received["/slack"])
+	}
+	if expected := "unrouted:3"; received["/webhook"] != expected {
+		t.Errorf("Test: 'webhook' FAILED : expected '%s', got '%s'", expected, received["/webhook"])
+	}
+}
+
+func TestValidate(t *testing.T) {
+	notifiers := []*Notifier{{Name: "n", Type: NotifierTypeWebhook, Url: "https://example.com"}}
+	for name, config := range map[string]*Config{
+		"invalid notifier type": {Notifiers: []*Notifier{{Name: "n", Type: "email", Url: "https://example.com"}}},
+		"invalid notifier url":  {Notifiers: []*Notifier{{Name: "n", Type: NotifierTypeSlack, Url: "example.com"}}},
+		"unknown notifier":      {Notifiers: notifiers, Routes: []*Route{{Name: "r", Tag: "team", Notifiers: []string{"x"}}}},
+		"duplicate route":       {Notifiers: notifiers, Routes: []*Route{{Name: "r", Tag: "team"}, {Name: "r", Tag: "owner"}}},
+		"no tag":                {Notifiers: notifiers, Routes: []*Route{{Name: "r", Notifiers: []string{"n"}}}},
+		"unknown unrouted":      {Notifiers: notifiers, UnroutedNotifiers: []string{"x"}},
+	} {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Test: '%s' FAILED : expected error", name)
+		}
+	}
+}
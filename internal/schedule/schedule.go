@@ -0,0 +1,135 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+// benchmarks and dashboards may be run on a schedule in server mode - a snapshot of each run is stored, and the
+// status of the last run of each schedule is available through the API
+// schedules are defined by tagging a benchmark or dashboard with a cron expression, e.g.
+//
+//	benchmark "cis_v300" {
+//	  tags = {
+//	    schedule = "0 6 * * *"
+//	  }
+//	}
+//
+// by the --schedule arg, in the form 'target=cron' (e.g. for the benchmarks of dependency mods), or through the API
+// a schedule set by the --schedule arg replaces a tagged schedule of the same target, and a schedule created through
+// the API replaces both
+//
+// cron expressions have 5 fields (minute, hour, day of month, month and day of week), or are a descriptor, e.g.
+// '@daily' or '@every 6h' - they are evaluated in the local timezone of the server, unless prefixed with
+// CRON_TZ=<zone>
+const (
+	TagSchedule = "schedule"
+	// the minimum interval of '@every' schedules - this avoids overloading the database
+	MinInterval = time.Minute
+)
+
+// Source is where a schedule is defined
+type Source string
+
+const (
+	SourceTag Source = "tag"
+	SourceArg Source = "arg"
+	SourceAPI Source = "api"
+)
+
+// the precedence of each source - a schedule replaces a schedule of the same target from a source of lower precedence
+var sourcePrecedence = map[Source]int{SourceTag: 0, SourceArg: 1, SourceAPI: 2}
+
+// Schedule is a benchmark or dashboard which is run on a cron schedule
+type Schedule struct {
+	// the full name of the benchmark or dashboard
+	Target string         `json:"target"`
+	Cron   string         `json:"cron"`
+	Inputs map[string]any `json:"inputs,omitempty"`
+	// the s3 integrations the snapshot of each run is published to
+	Publish []string `json:"publish,omitempty"`
+	// whether the snapshot of each run is only published once its approval request is approved
+	RequireApproval bool   `json:"require_approval,omitempty"`
+	Source          Source `json:"source"`
+
+	schedule cron.Schedule
+}
+
+// NewSchedule returns the schedule of the target with the given cron expression
+func NewSchedule(target, cronExpr string, source Source) (*Schedule, error) {
+	s := &Schedule{Target: target, Cron: strings.TrimSpace(cronExpr), Source: source}
+	if err := s.parse(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// parse parses the cron expression of the schedule
+func (s *Schedule) parse() error {
+	schedule, err := cron.ParseStandard(s.Cron)
+	if err != nil {
+		return fmt.Errorf("invalid schedule '%s' for %s: %s", s.Cron, s.Target, err.Error())
+	}
+	if every, ok := schedule.(cron.ConstantDelaySchedule); ok && every.Delay < MinInterval {
+		return fmt.Errorf("invalid schedule '%s' for %s: must be at least %s", s.Cron, s.Target, MinInterval)
+	}
+	s.schedule = schedule
+	return nil
+}
+
+// Next returns the time of the first run of the schedule after the given time
+func (s *Schedule) Next(t time.Time) time.Time {
+	return s.schedule.Next(t)
+}
+
+// GetTagSchedules returns a schedule for each benchmark and dashboard in the resource maps with a schedule tag,
+// sorted by target
+func GetTagSchedules(resourceMaps *modconfig.ResourceMaps) ([]*Schedule, error) {
+	var res []*Schedule
+	add := func(name string, tags map[string]string) error {
+		cronExpr, ok := tags[TagSchedule]
+		if !ok {
+			return nil
+		}
+		s, err := NewSchedule(name, cronExpr, SourceTag)
+		if err != nil {
+			return err
+		}
+		res = append(res, s)
+		return nil
+	}
+	for name, benchmark := range resourceMaps.Benchmarks {
+		if err := add(name, benchmark.Tags); err != nil {
+			return nil, err
+		}
+	}
+	for name, dashboard := range resourceMaps.Dashboards {
+		if err := add(name, dashboard.Tags); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Target < res[j].Target })
+	return res, nil
+}
+
+// ParseArgSchedules returns the schedules of the --schedule args, in the form 'target=cron'
+func ParseArgSchedules(args []string) ([]*Schedule, error) {
+	var res []*Schedule
+	for _, arg := range args {
+		target, cronExpr, ok := strings.Cut(arg, "=")
+		if !ok || strings.TrimSpace(target) == "" {
+			return nil, fmt.Errorf("invalid schedule '%s' - must be in the form 'target=cron', e.g. 'aws_compliance.benchmark.cis_v300=0 6 * * *'", arg)
+		}
+		s, err := NewSchedule(strings.TrimSpace(target), cronExpr, SourceArg)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
@@ -0,0 +1,135 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+type newScheduleTest struct {
+	cron     string
+	from     time.Time
+	expected interface{}
+}
+
+var testCasesNewSchedule = map[string]newScheduleTest{
+	"daily at 6": {
+		cron:     "0 6 * * *",
+		from:     time.Date(2026, 10, 16, 7, 0, 0, 0, time.UTC),
+		expected: time.Date(2026, 10, 17, 6, 0, 0, 0, time.UTC),
+	},
+	"weekdays": {
+		cron:     "30 9 * * 1-5",
+		from:     time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), // a friday
+		expected: time.Date(2026, 10, 19, 9, 30, 0, 0, time.UTC),
+	},
+	"descriptor": {
+		cron:     "@daily",
+		from:     time.Date(2026, 10, 16, 7, 0, 0, 0, time.UTC),
+		expected: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC),
+	},
+	"every": {
+		cron:     "@every 6h",
+		from:     time.Date(2026, 10, 16, 7, 0, 0, 0, time.UTC),
+		expected: time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC),
+	},
+	"timezone": {
+		cron:     "CRON_TZ=Europe/London 0 6 * * *",
+		from:     time.Date(2026, 10, 16, 7, 0, 0, 0, time.UTC),
+		expected: time.Date(2026, 10, 17, 5, 0, 0, 0, time.UTC),
+	},
+	"every too short": {
+		cron:     "@every 10s",
+		expected: "ERROR",
+	},
+	"six fields": {
+		cron:     "0 0 6 * * *",
+		expected: "ERROR",
+	},
+	"invalid": {
+		cron:     "daily",
+		expected: "ERROR",
+	},
+}
+
+func TestNewSchedule(t *testing.T) {
+	for name, test := range testCasesNewSchedule {
+		s, err := NewSchedule("mod.benchmark.cis", test.cron, SourceAPI)
+		if err != nil {
+			if test.expected != "ERROR" {
+				t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			}
+			continue
+		}
+		if test.expected == "ERROR" {
+			t.Errorf("Test: '%s' FAILED : expected an error", name)
+			continue
+		}
+		if actual := s.Next(test.from).UTC(); !actual.Equal(test.expected.(time.Time)) {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, actual)
+		}
+	}
+}
+
+type parseArgSchedulesTest struct {
+	args     []string
+	expected interface{}
+}
+
+var testCasesParseArgSchedules = map[string]parseArgSchedulesTest{
+	"single": {
+		args:     []string{"aws_compliance.benchmark.cis_v300=0 6 * * *"},
+		expected: map[string]string{"aws_compliance.benchmark.cis_v300": "0 6 * * *"},
+	},
+	"multiple": {
+		args: []string{"aws_compliance.benchmark.cis_v300 = @daily", "mod.dashboard.costs=CRON_TZ=UTC 0 * * * *"},
+		expected: map[string]string{
+			"aws_compliance.benchmark.cis_v300": "@daily",
+			"mod.dashboard.costs":               "CRON_TZ=UTC 0 * * * *",
+		},
+	},
+	"no cron": {
+		args:     []string{"aws_compliance.benchmark.cis_v300"},
+		expected: "ERROR",
+	},
+	"no target": {
+		args:     []string{"=@daily"},
+		expected: "ERROR",
+	},
+	"invalid cron": {
+		args:     []string{"aws_compliance.benchmark.cis_v300=@fortnightly"},
+		expected: "ERROR",
+	},
+}
+
+func TestParseArgSchedules(t *testing.T) {
+	for name, test := range testCasesParseArgSchedules {
+		schedules, err := ParseArgSchedules(test.args)
+		if err != nil {
+			if test.expected != "ERROR" {
+				t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			}
+			continue
+		}
+		if test.expected == "ERROR" {
+			t.Errorf("Test: '%s' FAILED : expected an error", name)
+			continue
+		}
+		expected := test.expected.(map[string]string)
+		actual := make(map[string]string)
+		for _, s := range schedules {
+			if s.Source != SourceArg {
+				t.Errorf("Test: '%s' FAILED : expected source %s, got %s", name, SourceArg, s.Source)
+			}
+			actual[s.Target] = s.Cron
+		}
+		if len(actual) != len(expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, expected, actual)
+			continue
+		}
+		for target, cron := range expected {
+			if actual[target] != cron {
+				t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, expected, actual)
+			}
+		}
+	}
+}
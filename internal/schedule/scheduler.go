@@ -0,0 +1,433 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/steampipeconfig"
+	"github.com/turbot/powerpipe/internal/approval"
+	"github.com/turbot/powerpipe/internal/dashboardexecute"
+	"github.com/turbot/powerpipe/internal/db_client"
+	"github.com/turbot/powerpipe/internal/publish"
+	"github.com/turbot/powerpipe/internal/snapshotdest"
+)
+
+const (
+	runInitiator = "schedule"
+	// the interval at which the scheduler checks for due schedules if no schedule is due sooner
+	maxSleep = time.Hour
+)
+
+var (
+	ErrNotFound     = errors.New("schedule not found")
+	ErrNotRemovable = errors.New("only schedules created through the API may be removed")
+	ErrRunning      = errors.New("the schedule is already running")
+)
+
+// RunStatus is the status of a run of a schedule
+type RunStatus string
+
+const (
+	RunStatusRunning          RunStatus = "running"
+	RunStatusAwaitingApproval RunStatus = "awaiting_approval"
+	RunStatusComplete         RunStatus = "complete"
+	RunStatusError            RunStatus = "error"
+)
+
+// Run is a run of a schedule
+type Run struct {
+	Status    RunStatus  `json:"status"`
+	StartTime time.Time  `json:"start_time"`
+	EndTime   *time.Time `json:"end_time,omitempty"`
+	// the path or url of the snapshot of the run
+	Snapshot string `json:"snapshot,omitempty"`
+	// the approval request for publishing the snapshot (if the schedule requires approval)
+	Approval       string          `json:"approval,omitempty"`
+	ApprovalStatus approval.Status `json:"approval_status,omitempty"`
+	// the objects published to the s3 integrations of the schedule
+	Published []string `json:"published,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// Status is a schedule, with the time of its next run and its last run (if any)
+type Status struct {
+	Schedule
+	NextRun time.Time `json:"next_run"`
+	LastRun *Run      `json:"last_run,omitempty"`
+}
+
+// Runner executes the runs of schedules
+type Runner interface {
+	// ValidateRunTarget returns an error if the target is not a benchmark or dashboard of the workspace
+	ValidateRunTarget(target string) error
+	// RunTarget executes the target, waiting for the run to complete, and returns the snapshot of the run
+	RunTarget(ctx context.Context, target string, inputs map[string]any) (*steampipeconfig.SteampipeSnapshot, error)
+}
+
+type entry struct {
+	schedule  *Schedule
+	publisher *publish.Publisher
+	nextRun   time.Time
+	lastRun   *Run
+	running   bool
+}
+
+// Scheduler runs the schedules, storing a snapshot of each run in the snapshot location
+type Scheduler struct {
+	runner Runner
+	// a local directory or the url of a snapshot destination
+	snapshotLocation string
+	// the gate holding the publishing of snapshots for approval
+	approvalGate *approval.Gate
+	// the file the schedules created through the API are saved to (not saved if empty)
+	storePath string
+
+	lock    sync.Mutex
+	entries map[string]*entry
+	// signalled when the schedules change
+	wake chan struct{}
+}
+
+func NewScheduler(runner Runner, snapshotLocation string, approvalGate *approval.Gate, storePath string) *Scheduler {
+	return &Scheduler{
+		runner:           runner,
+		snapshotLocation: snapshotLocation,
+		approvalGate:     approvalGate,
+		storePath:        storePath,
+		entries:          make(map[string]*entry),
+		wake:             make(chan struct{}, 1),
+	}
+}
+
+// Start restores the schedules saved by a previous server, then starts a goroutine running the schedules - this runs
+// until the context is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	saved, err := loadSchedules(s.storePath)
+	if err != nil {
+		slog.Warn("failed to load saved schedules", "error", err)
+	}
+	for _, schedule := range saved {
+		// (do not save again while restoring)
+		if err := s.add(schedule, false); err != nil {
+			slog.Warn("failed to restore schedule", "target", schedule.Target, "error", err)
+		}
+	}
+	go s.loop(ctx)
+}
+
+// Add adds the schedule, replacing any schedule of the same target from a source of the same or lower precedence
+func (s *Scheduler) Add(schedule *Schedule) error {
+	return s.add(schedule, true)
+}
+
+func (s *Scheduler) add(schedule *Schedule, save bool) error {
+	if schedule.schedule == nil {
+		if err := schedule.parse(); err != nil {
+			return err
+		}
+	}
+	if err := s.runner.ValidateRunTarget(schedule.Target); err != nil {
+		return err
+	}
+	e := &entry{schedule: schedule, nextRun: schedule.Next(time.Now())}
+	if len(schedule.Publish) > 0 {
+		if snapshotdest.IsDestination(s.snapshotLocation) {
+			return fmt.Errorf("the schedule of %s cannot publish snapshots - snapshots may only be published when the schedule snapshot location is a local directory", schedule.Target)
+		}
+		publisher, err := publish.NewPublisher(schedule.Publish)
+		if err != nil {
+			return err
+		}
+		e.publisher = publisher
+	}
+	if schedule.RequireApproval && len(schedule.Publish) == 0 {
+		return fmt.Errorf("the schedule of %s requires approval, but does not publish its snapshots", schedule.Target)
+	}
+	if schedule.RequireApproval && s.approvalGate == nil {
+		return fmt.Errorf("the schedule of %s requires approval, but approval is not enabled", schedule.Target)
+	}
+
+	s.lock.Lock()
+	if existing, ok := s.entries[schedule.Target]; ok {
+		if sourcePrecedence[existing.schedule.Source] > sourcePrecedence[schedule.Source] {
+			s.lock.Unlock()
+			return fmt.Errorf("%s already has a schedule defined by %s", schedule.Target, existing.schedule.Source)
+		}
+		e.lastRun = existing.lastRun
+		e.running = existing.running
+	}
+	s.entries[schedule.Target] = e
+	s.lock.Unlock()
+
+	s.changed(save && schedule.Source == SourceAPI)
+	return nil
+}
+
+// Remove removes the schedule of the target - only schedules created through the API may be removed
+func (s *Scheduler) Remove(target string) error {
+	s.lock.Lock()
+	e, ok := s.entries[target]
+	if !ok {
+		s.lock.Unlock()
+		return ErrNotFound
+	}
+	if e.schedule.Source != SourceAPI {
+		s.lock.Unlock()
+		return ErrNotRemovable
+	}
+	delete(s.entries, target)
+	s.lock.Unlock()
+
+	s.changed(true)
+	return nil
+}
+
+// SetTagSchedules replaces the schedules defined by tags, e.g. when the workspace changes - schedules defined by args
+// or through the API are unchanged
+func (s *Scheduler) SetTagSchedules(schedules []*Schedule) {
+	s.lock.Lock()
+	tagged := make(map[string]*Schedule, len(schedules))
+	for _, schedule := range schedules {
+		tagged[schedule.Target] = schedule
+	}
+	for target, e := range s.entries {
+		if _, ok := tagged[target]; !ok && e.schedule.Source == SourceTag {
+			delete(s.entries, target)
+		}
+	}
+	s.lock.Unlock()
+
+	for _, schedule := range schedules {
+		if err := s.add(schedule, false); err != nil {
+			slog.Debug("tagged schedule not added", "target", schedule.Target, "error", err)
+		}
+	}
+}
+
+// Get returns the status of the schedule of the target
+func (s *Scheduler) Get(target string) (*Status, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	e, ok := s.entries[target]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return e.status(), nil
+}
+
+// List returns the status of all schedules, sorted by target
+func (s *Scheduler) List() []*Status {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res := make([]*Status, 0, len(s.entries))
+	for _, e := range s.entries {
+		res = append(res, e.status())
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Target < res[j].Target })
+	return res
+}
+
+// RunNow starts a run of the schedule of the target, returning its status
+func (s *Scheduler) RunNow(ctx context.Context, target string) (*Status, error) {
+	s.lock.Lock()
+	e, ok := s.entries[target]
+	if !ok {
+		s.lock.Unlock()
+		return nil, ErrNotFound
+	}
+	if e.running {
+		s.lock.Unlock()
+		return nil, ErrRunning
+	}
+	schedule, publisher := s.startRun(e)
+	res := e.status()
+	s.lock.Unlock()
+
+	go s.run(ctx, schedule, publisher)
+	return res, nil
+}
+
+func (s *Scheduler) loop(ctx context.Context) {
+	timer := time.NewTimer(s.untilNextRun(time.Now()))
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+		for _, due := range s.startDueRuns(time.Now()) {
+			go s.run(ctx, due.schedule, due.publisher)
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(s.untilNextRun(time.Now()))
+	}
+}
+
+// untilNextRun returns the duration until the next run of any schedule
+func (s *Scheduler) untilNextRun(now time.Time) time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res := maxSleep
+	for _, e := range s.entries {
+		if d := e.nextRun.Sub(now); d < res {
+			res = max(d, 0)
+		}
+	}
+	return res
+}
+
+type dueRun struct {
+	schedule  *Schedule
+	publisher *publish.Publisher
+}
+
+// startDueRuns starts the runs of the schedules which are due, and sets the time of their next run - if the previous
+// run of a schedule is still in progress, the run is skipped
+func (s *Scheduler) startDueRuns(now time.Time) []dueRun {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var res []dueRun
+	for target, e := range s.entries {
+		if e.nextRun.After(now) {
+			continue
+		}
+		if e.running {
+			slog.Warn("skipping scheduled run - the previous run is still in progress", "target", target)
+			e.nextRun = e.schedule.Next(now)
+			continue
+		}
+		schedule, publisher := s.startRun(e)
+		res = append(res, dueRun{schedule: schedule, publisher: publisher})
+		e.nextRun = e.schedule.Next(now)
+	}
+	return res
+}
+
+// startRun marks the entry as running - the lock must be held
+func (s *Scheduler) startRun(e *entry) (*Schedule, *publish.Publisher) {
+	e.running = true
+	e.lastRun = &Run{Status: RunStatusRunning, StartTime: time.Now()}
+	return e.schedule, e.publisher
+}
+
+// run executes the target of the schedule, stores the snapshot of the run, and publishes it (once approved, if the
+// schedule requires approval)
+func (s *Scheduler) run(ctx context.Context, schedule *Schedule, publisher *publish.Publisher) {
+	target := schedule.Target
+	slog.Debug("running schedule", "target", target)
+	// schedule the database connections of the run fairly with those of dashboard sessions
+	ctx = db_client.WithOrigin(ctx, "schedule:"+target)
+	ctx = dashboardexecute.WithRunInitiator(ctx, runInitiator)
+
+	err := s.execute(ctx, schedule, publisher)
+	if err != nil {
+		slog.Warn("scheduled run failed", "target", target, "error", err)
+	}
+	s.updateRun(target, func(r *Run) {
+		now := time.Now()
+		r.EndTime = &now
+		r.Status = RunStatusComplete
+		if err != nil {
+			r.Status = RunStatusError
+			r.Error = err.Error()
+		}
+	})
+
+	s.lock.Lock()
+	if e, ok := s.entries[target]; ok {
+		e.running = false
+	}
+	s.lock.Unlock()
+}
+
+func (s *Scheduler) execute(ctx context.Context, schedule *Schedule, publisher *publish.Publisher) error {
+	target := schedule.Target
+	snapshot, err := s.runner.RunTarget(ctx, target, schedule.Inputs)
+	if err != nil {
+		return err
+	}
+	snapshotPath, err := snapshotdest.WriteSnapshot(ctx, s.snapshotLocation, snapshot)
+	if err != nil {
+		return err
+	}
+	s.updateRun(target, func(r *Run) { r.Snapshot = snapshotPath })
+	if publisher == nil {
+		return nil
+	}
+
+	if schedule.RequireApproval {
+		s.updateRun(target, func(r *Run) { r.Status = RunStatusAwaitingApproval })
+		summary := fmt.Sprintf("Scheduled run of %s completed at %s - snapshot %s", target, snapshot.EndTime.Format(time.RFC3339), snapshotPath)
+		request, err := s.approvalGate.Await(ctx, target, summary, schedule.Publish)
+		if err != nil {
+			return err
+		}
+		s.updateRun(target, func(r *Run) {
+			r.Approval = request.Id
+			r.ApprovalStatus = request.Status
+		})
+		if request.Status != approval.StatusApproved {
+			slog.Info("scheduled run not published", "target", target, "approval", request.Status)
+			return nil
+		}
+	}
+
+	published, err := publisher.Publish(ctx, target, []publish.Artifact{{Path: snapshotPath, Extension: constants.SnapshotExtension}})
+	s.updateRun(target, func(r *Run) { r.Published = published })
+	return err
+}
+
+// updateRun updates the last run of the schedule of the target (if the schedule has not been removed)
+func (s *Scheduler) updateRun(target string, update func(r *Run)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if e, ok := s.entries[target]; ok && e.lastRun != nil {
+		update(e.lastRun)
+	}
+}
+
+// changed wakes the loop to reschedule, and saves the schedules created through the API if required
+func (s *Scheduler) changed(save bool) {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	if !save || s.storePath == "" {
+		return
+	}
+	s.lock.Lock()
+	var schedules []*Schedule
+	for _, e := range s.entries {
+		if e.schedule.Source == SourceAPI {
+			schedules = append(schedules, e.schedule)
+		}
+	}
+	s.lock.Unlock()
+	if err := saveSchedules(s.storePath, schedules); err != nil {
+		slog.Warn("failed to save schedules", "error", err)
+	}
+}
+
+// status returns a copy of the status of the entry - the lock must be held
+func (e *entry) status() *Status {
+	res := &Status{Schedule: *e.schedule, NextRun: e.nextRun}
+	if e.lastRun != nil {
+		lastRun := *e.lastRun
+		res.LastRun = &lastRun
+	}
+	return res
+}
@@ -0,0 +1,274 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/steampipeconfig"
+	"github.com/turbot/powerpipe/internal/approval"
+)
+
+// testRunner runs the targets in its set of targets, returning an empty snapshot
+type testRunner struct {
+	targets map[string]bool
+	// if set, runs wait until it is closed
+	release chan struct{}
+
+	lock sync.Mutex
+	runs []string
+}
+
+func (r *testRunner) ValidateRunTarget(target string) error {
+	if !r.targets[target] {
+		return perr.NotFoundWithMessage(target + " not found")
+	}
+	return nil
+}
+
+func (r *testRunner) RunTarget(_ context.Context, target string, _ map[string]any) (*steampipeconfig.SteampipeSnapshot, error) {
+	if r.release != nil {
+		<-r.release
+	}
+	r.lock.Lock()
+	r.runs = append(r.runs, target)
+	r.lock.Unlock()
+	if target == "mod.dashboard.failing" {
+		return nil, errors.New("query failed")
+	}
+	now := time.Now()
+	return &steampipeconfig.SteampipeSnapshot{SchemaVersion: "20240607", FileNameRoot: target, StartTime: now, EndTime: now}, nil
+}
+
+func newTestRunner() *testRunner {
+	return &testRunner{targets: map[string]bool{
+		"mod.benchmark.cis":     true,
+		"mod.dashboard.costs":   true,
+		"mod.dashboard.failing": true,
+	}}
+}
+
+func mustSchedule(t *testing.T, target, cron string, source Source) *Schedule {
+	s, err := NewSchedule(target, cron, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// waitForRun waits until the last run of the schedule of the target is no longer running
+func waitForRun(t *testing.T, scheduler *Scheduler, target string) *Run {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		status, err := scheduler.Get(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.LastRun != nil && status.LastRun.Status != RunStatusRunning {
+			return status.LastRun
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("the run of %s did not complete", target)
+	return nil
+}
+
+func TestSchedulerAdd(t *testing.T) {
+	scheduler := NewScheduler(newTestRunner(), t.TempDir(), nil, "")
+
+	if err := scheduler.Add(mustSchedule(t, "mod.benchmark.missing", "@daily", SourceAPI)); err == nil {
+		t.Errorf("Test: 'missing target' FAILED : expected an error")
+	}
+	if err := scheduler.Add(mustSchedule(t, "mod.benchmark.cis", "@daily", SourceArg)); err != nil {
+		t.Fatal(err)
+	}
+	// a tagged schedule does not replace a schedule set by an arg
+	if err := scheduler.Add(mustSchedule(t, "mod.benchmark.cis", "@hourly", SourceTag)); err == nil {
+		t.Errorf("Test: 'precedence' FAILED : expected an error adding a tagged schedule over an arg schedule")
+	}
+	// a schedule created through the API does
+	if err := scheduler.Add(mustSchedule(t, "mod.benchmark.cis", "@hourly", SourceAPI)); err != nil {
+		t.Errorf("Test: 'precedence' FAILED : unexpected error %v", err)
+	}
+	status, err := scheduler.Get("mod.benchmark.cis")
+	if err != nil || status.Cron != "@hourly" || status.Source != SourceAPI {
+		t.Errorf("Test: 'replace' FAILED : expected the api schedule, got %v (%v)", status, err)
+	}
+	if status != nil && (status.NextRun.Before(time.Now()) || status.NextRun.After(time.Now().Add(time.Hour))) {
+		t.Errorf("Test: 'next run' FAILED : expected the next run within the hour, got %s", status.NextRun)
+	}
+
+	approvalSchedule := mustSchedule(t, "mod.dashboard.costs", "@daily", SourceAPI)
+	approvalSchedule.RequireApproval = true
+	if err := scheduler.Add(approvalSchedule); err == nil {
+		t.Errorf("Test: 'approval without publish' FAILED : expected an error")
+	}
+}
+
+func TestSchedulerRemove(t *testing.T) {
+	scheduler := NewScheduler(newTestRunner(), t.TempDir(), nil, "")
+	for _, s := range []*Schedule{
+		mustSchedule(t, "mod.benchmark.cis", "@daily", SourceTag),
+		mustSchedule(t, "mod.dashboard.costs", "@daily", SourceAPI),
+	} {
+		if err := scheduler.Add(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := scheduler.Remove("mod.benchmark.cis"); !errors.Is(err, ErrNotRemovable) {
+		t.Errorf("Test: 'remove tagged' FAILED : expected %v, got %v", ErrNotRemovable, err)
+	}
+	if err := scheduler.Remove("mod.dashboard.costs"); err != nil {
+		t.Errorf("Test: 'remove api' FAILED : unexpected error %v", err)
+	}
+	if err := scheduler.Remove("mod.dashboard.costs"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Test: 'remove removed' FAILED : expected %v, got %v", ErrNotFound, err)
+	}
+	if actual := len(scheduler.List()); actual != 1 {
+		t.Errorf("Test: 'list' FAILED : expected 1 schedule, got %d", actual)
+	}
+}
+
+func TestSchedulerSetTagSchedules(t *testing.T) {
+	scheduler := NewScheduler(newTestRunner(), t.TempDir(), nil, "")
+	scheduler.SetTagSchedules([]*Schedule{
+		mustSchedule(t, "mod.benchmark.cis", "@daily", SourceTag),
+		mustSchedule(t, "mod.dashboard.costs", "@daily", SourceTag),
+	})
+	if err := scheduler.Add(mustSchedule(t, "mod.dashboard.failing", "@daily", SourceArg)); err != nil {
+		t.Fatal(err)
+	}
+	// the tag is removed from the costs dashboard and changed on the benchmark
+	scheduler.SetTagSchedules([]*Schedule{
+		mustSchedule(t, "mod.benchmark.cis", "@hourly", SourceTag),
+	})
+
+	var actual []string
+	for _, status := range scheduler.List() {
+		actual = append(actual, status.Target+"="+status.Cron)
+	}
+	expected := "mod.benchmark.cis=@hourly,mod.dashboard.failing=@daily"
+	if strings.Join(actual, ",") != expected {
+		t.Errorf("Test: 'set tag schedules' FAILED : expected %s, got %s", expected, strings.Join(actual, ","))
+	}
+}
+
+func TestSchedulerRunNow(t *testing.T) {
+	runner := newTestRunner()
+	runner.release = make(chan struct{})
+	snapshotDir := t.TempDir()
+	scheduler := NewScheduler(runner, snapshotDir, nil, "")
+	for _, target := range []string{"mod.benchmark.cis", "mod.dashboard.failing"} {
+		if err := scheduler.Add(mustSchedule(t, target, "@daily", SourceAPI)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	status, err := scheduler.RunNow(context.Background(), "mod.benchmark.cis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.LastRun == nil || status.LastRun.Status != RunStatusRunning {
+		t.Errorf("Test: 'run now' FAILED : expected a running run, got %v", status.LastRun)
+	}
+	if _, err := scheduler.RunNow(context.Background(), "mod.benchmark.cis"); !errors.Is(err, ErrRunning) {
+		t.Errorf("Test: 'run now while running' FAILED : expected %v, got %v", ErrRunning, err)
+	}
+	close(runner.release)
+
+	run := waitForRun(t, scheduler, "mod.benchmark.cis")
+	if run.Status != RunStatusComplete || run.EndTime == nil || filepath.Dir(run.Snapshot) != snapshotDir {
+		t.Errorf("Test: 'complete run' FAILED : expected a complete run with a snapshot in %s, got %+v", snapshotDir, run)
+	}
+	if _, err := os.Stat(run.Snapshot); err != nil {
+		t.Errorf("Test: 'snapshot' FAILED : expected the snapshot to be written: %v", err)
+	}
+
+	if _, err := scheduler.RunNow(context.Background(), "mod.dashboard.failing"); err != nil {
+		t.Fatal(err)
+	}
+	run = waitForRun(t, scheduler, "mod.dashboard.failing")
+	if run.Status != RunStatusError || run.Error != "query failed" || run.Snapshot != "" {
+		t.Errorf("Test: 'failed run' FAILED : expected a failed run, got %+v", run)
+	}
+}
+
+func TestSchedulerStartDueRuns(t *testing.T) {
+	scheduler := NewScheduler(newTestRunner(), t.TempDir(), nil, "")
+	for _, target := range []string{"mod.benchmark.cis", "mod.dashboard.costs"} {
+		if err := scheduler.Add(mustSchedule(t, target, "@hourly", SourceAPI)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	if due := scheduler.startDueRuns(now); len(due) != 0 {
+		t.Errorf("Test: 'not due' FAILED : expected no due runs, got %d", len(due))
+	}
+	later := now.Add(time.Hour)
+	due := scheduler.startDueRuns(later)
+	if len(due) != 2 {
+		t.Fatalf("Test: 'due' FAILED : expected 2 due runs, got %d", len(due))
+	}
+	// the runs are still in progress, so the next runs are skipped
+	if due := scheduler.startDueRuns(later.Add(time.Hour)); len(due) != 0 {
+		t.Errorf("Test: 'running' FAILED : expected the runs in progress to be skipped, got %d", len(due))
+	}
+	status, _ := scheduler.Get("mod.benchmark.cis")
+	if !status.NextRun.After(later.Add(time.Hour)) {
+		t.Errorf("Test: 'next run' FAILED : expected the next run after %s, got %s", later.Add(time.Hour), status.NextRun)
+	}
+}
+
+func TestSchedulerApproval(t *testing.T) {
+	gate := approval.NewGate("", time.Minute, http.DefaultClient, nil)
+	scheduler := NewScheduler(newTestRunner(), t.TempDir(), gate, "")
+	s := mustSchedule(t, "mod.benchmark.cis", "@daily", SourceAPI)
+	s.Publish = []string{"artifacts"}
+	s.RequireApproval = true
+	// (publishing requires the integration to be defined in the workspace config)
+	if err := scheduler.Add(s); err == nil {
+		t.Errorf("Test: 'undefined integration' FAILED : expected an error")
+	}
+
+	scheduler = NewScheduler(newTestRunner(), "s3://acme/snapshots", gate, "")
+	if err := scheduler.Add(s); err == nil {
+		t.Errorf("Test: 'publish from destination' FAILED : expected an error")
+	}
+}
+
+func TestSchedulerSave(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "schedules.json")
+	scheduler := NewScheduler(newTestRunner(), t.TempDir(), nil, storePath)
+	s := mustSchedule(t, "mod.dashboard.costs", "0 6 * * *", SourceAPI)
+	s.Inputs = map[string]any{"input.region": "us-east-1"}
+	for _, s := range []*Schedule{s, mustSchedule(t, "mod.benchmark.cis", "@daily", SourceArg)} {
+		if err := scheduler.Add(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// only the schedules created through the API are restored
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	restored := NewScheduler(newTestRunner(), t.TempDir(), nil, storePath)
+	restored.Start(ctx)
+	statuses := restored.List()
+	if len(statuses) != 1 || statuses[0].Target != "mod.dashboard.costs" || statuses[0].Cron != "0 6 * * *" || statuses[0].Inputs["input.region"] != "us-east-1" {
+		t.Fatalf("Test: 'restore' FAILED : expected the api schedule, got %+v", statuses)
+	}
+
+	if err := restored.Remove("mod.dashboard.costs"); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := loadSchedules(storePath)
+	if err != nil || len(saved) != 0 {
+		t.Errorf("Test: 'remove' FAILED : expected no saved schedules, got %v (%v)", saved, err)
+	}
+}
@@ -0,0 +1,53 @@
+package schedule
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/turbot/pipe-fittings/filepaths"
+)
+
+// the schedules created through the API are saved, so that they are restored when the server restarts
+// (the status of their runs is not saved)
+
+// SchedulesPath returns the path of the schedules file in the internal directory
+func SchedulesPath() string {
+	return filepath.Join(filepaths.EnsureInternalDir(), "schedules.json")
+}
+
+// loadSchedules loads the saved schedules - no schedules are returned if the file does not exist
+func loadSchedules(path string) ([]*Schedule, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var res []*Schedule
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	for _, schedule := range res {
+		schedule.Source = SourceAPI
+	}
+	return res, nil
+}
+
+// saveSchedules saves the schedules, sorted by target
+func saveSchedules(path string, schedules []*Schedule) error {
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].Target < schedules[j].Target })
+	if schedules == nil {
+		schedules = []*Schedule{}
+	}
+	data, err := json.MarshalIndent(schedules, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
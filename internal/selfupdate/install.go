@@ -0,0 +1,157 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"time"
+)
+
+const (
+	binaryName = "powerpipe"
+	// the suffixes of the staged new binary, and of the previous binary which is kept for rollback
+	stagedSuffix   = ".new"
+	previousSuffix = ".old"
+
+	checkTimeout = 30 * time.Second
+)
+
+// checkFunc checks that the binary at the given path runs
+type checkFunc func(ctx context.Context, binaryPath string) error
+
+// Install downloads (using the client) and verifies the release, then installs it in place of the executable at exePath
+func Install(ctx context.Context, client *http.Client, release *Release, exePath string) error {
+	downloadCtx, cancel := context.WithTimeout(ctx, downloadTimeout)
+	defer cancel()
+
+	files := make(map[string][]byte)
+	for _, name := range []string{checksumsFileName, signatureFileName, ArchiveName()} {
+		asset, err := release.asset(name)
+		if err != nil {
+			return err
+		}
+		data, err := download(downloadCtx, client, asset.URL)
+		if err != nil {
+			return fmt.Errorf("failed to download '%s': %w", name, err)
+		}
+		files[name] = data
+	}
+
+	// the checksums are only trusted once their signature is verified for the version of the release
+	if err := verifySignature(files[checksumsFileName], files[signatureFileName], release.Version.String(), ReleasePublicKey); err != nil {
+		return err
+	}
+	expected, err := checksumFor(files[checksumsFileName], ArchiveName())
+	if err != nil {
+		return err
+	}
+	if err := verifyChecksum(files[ArchiveName()], ArchiveName(), expected); err != nil {
+		return err
+	}
+
+	binary, err := extractBinary(files[ArchiveName()])
+	if err != nil {
+		return err
+	}
+	return installBinary(ctx, exePath, binary, CheckBinary)
+}
+
+// extractBinary returns the powerpipe binary from a release archive
+func extractBinary(archive []byte) ([]byte, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release archive: %w", err)
+	}
+	defer gzipReader.Close()
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("release archive does not contain '%s'", binaryName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read release archive: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg && path.Base(header.Name) == binaryName {
+			return io.ReadAll(io.LimitReader(tarReader, maxDownloadSize))
+		}
+	}
+}
+
+// installBinary stages the binary alongside the executable and checks it runs, then swaps it in, keeping the
+// current executable for rollback - if the swap or the check of the installed binary fails, the current executable
+// is restored
+func installBinary(ctx context.Context, exePath string, binary []byte, check checkFunc) error {
+	staged := exePath + stagedSuffix
+	if err := os.WriteFile(staged, binary, 0755); err != nil {
+		return fmt.Errorf("failed to stage the new binary: %w", err)
+	}
+	defer os.Remove(staged)
+	if err := check(ctx, staged); err != nil {
+		return fmt.Errorf("the new binary failed to run, so was not installed: %w", err)
+	}
+
+	previous := exePath + previousSuffix
+	if err := os.Remove(previous); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove the previous binary: %w", err)
+	}
+	if err := os.Rename(exePath, previous); err != nil {
+		return fmt.Errorf("failed to replace the current binary: %w", err)
+	}
+	if err := os.Rename(staged, exePath); err != nil {
+		return restore(previous, exePath, fmt.Errorf("failed to install the new binary: %w", err))
+	}
+	if err := check(ctx, exePath); err != nil {
+		return restore(previous, exePath, fmt.Errorf("the installed binary failed to run: %w", err))
+	}
+	return nil
+}
+
+// restore restores the previous binary after a failed install, returning the install error
+func restore(previous, exePath string, installErr error) error {
+	if err := os.Rename(previous, exePath); err != nil {
+		return fmt.Errorf("%w - and failed to restore the previous binary from '%s': %s", installErr, previous, err.Error())
+	}
+	return fmt.Errorf("%w - the previous binary has been restored", installErr)
+}
+
+// PreviousVersionPath returns the path of the binary kept by the last update, if any
+func PreviousVersionPath(exePath string) (string, bool) {
+	previous := exePath + previousSuffix
+	if _, err := os.Stat(previous); err != nil {
+		return "", false
+	}
+	return previous, true
+}
+
+// Rollback reinstates the binary kept by the last update - as with an update, the current binary is kept in its
+// place, so a rollback can itself be rolled back
+func Rollback(ctx context.Context, exePath string) error {
+	previous, ok := PreviousVersionPath(exePath)
+	if !ok {
+		return fmt.Errorf("there is no previous version to roll back to")
+	}
+	binary, err := os.ReadFile(previous)
+	if err != nil {
+		return err
+	}
+	return installBinary(ctx, exePath, binary, CheckBinary)
+}
+
+// CheckBinary checks the binary runs, by running 'powerpipe --version'
+func CheckBinary(ctx context.Context, binaryPath string) error {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, binaryPath, "--version").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(output))
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package selfupdate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// the CLI can update itself from the GitHub releases of powerpipe, for users who installed it using the install
+// script rather than a package manager. An update:
+//   - finds the latest release of the channel - 'stable' releases only, or 'beta' which also includes prereleases
+//   - downloads the archive for the current platform and the checksums file of the release
+//   - verifies the signature of the checksums file using the release signing key built into the CLI, then verifies
+//     the checksum of the archive
+//   - stages the new binary alongside the current one and checks it runs before swapping it in - if the swap, or the
+//     check of the installed binary, fails then the current binary is restored
+//
+// the previous binary is kept alongside the new one, so an update can be rolled back using 'update-cli --rollback'
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
+
+	releasesURL       = "https://api.github.com/repos/turbot/powerpipe/releases?per_page=50"
+	checksumsFileName = "checksums.txt"
+	signatureFileName = "checksums.txt.sig"
+
+	requestTimeout  = 10 * time.Second
+	downloadTimeout = 5 * time.Minute
+	// the maximum size of a downloaded file
+	maxDownloadSize = 512 * 1024 * 1024
+)
+
+// Channels are the release channels which may be updated from
+var Channels = []string{ChannelStable, ChannelBeta}
+
+// Release is a GitHub release of powerpipe
+type Release struct {
+	TagName    string  `json:"tag_name"`
+	Prerelease bool    `json:"prerelease"`
+	Draft      bool    `json:"draft"`
+	Assets     []Asset `json:"assets"`
+	Version    *semver.Version
+}
+
+// Asset is a file of a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// LatestRelease returns the latest release of the channel
+func LatestRelease(ctx context.Context, client *http.Client, channel string) (*Release, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	data, err := download(ctx, client, releasesURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list releases: %w", err)
+	}
+	var releases []*Release
+	if err := json.Unmarshal(data, &releases); err != nil {
+		return nil, fmt.Errorf("failed to list releases: %w", err)
+	}
+	return selectRelease(releases, channel)
+}
+
+// selectRelease returns the release of the channel with the highest version
+// drafts are never selected, and prereleases are only selected for the beta channel
+func selectRelease(releases []*Release, channel string) (*Release, error) {
+	if channel != ChannelStable && channel != ChannelBeta {
+		return nil, fmt.Errorf("invalid channel '%s' - must be one of: %s, %s", channel, ChannelStable, ChannelBeta)
+	}
+	var res *Release
+	for _, r := range releases {
+		if r.Draft || (r.Prerelease && channel != ChannelBeta) {
+			continue
+		}
+		version, err := semver.NewVersion(r.TagName)
+		if err != nil {
+			continue
+		}
+		// a release is only marked as a prerelease if its version is one, so check both
+		if version.Prerelease() != "" && channel != ChannelBeta {
+			continue
+		}
+		if res == nil || version.GreaterThan(res.Version) {
+			r.Version = version
+			res = r
+		}
+	}
+	if res == nil {
+		return nil, fmt.Errorf("no %s release found", channel)
+	}
+	return res, nil
+}
+
+// asset returns the asset of the release with the given name
+func (r *Release) asset(name string) (*Asset, error) {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("release %s has no file '%s'", r.TagName, name)
+}
+
+// ArchiveName returns the name of the release archive for the current platform
+func ArchiveName() string {
+	return fmt.Sprintf("powerpipe.%s.%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+}
+
+func download(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDownloadSize {
+		return nil, fmt.Errorf("%s is larger than the maximum download size", url)
+	}
+	return data, nil
+}
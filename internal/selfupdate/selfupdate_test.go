@@ -0,0 +1,141 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type selectReleaseTest struct {
+	channel  string
+	expected string
+}
+
+var testReleases = []*Release{
+	{TagName: "v0.3.0"},
+	{TagName: "v0.4.0-rc.1", Prerelease: true},
+	{TagName: "v0.3.1"},
+	{TagName: "v0.5.0", Draft: true},
+	{TagName: "v0.3.2-beta.1"},
+	{TagName: "not-a-version"},
+}
+
+var testCasesSelectRelease = map[string]selectReleaseTest{
+	"stable":          {channel: ChannelStable, expected: "v0.3.1"},
+	"beta":            {channel: ChannelBeta, expected: "v0.4.0-rc.1"},
+	"invalid channel": {channel: "nightly", expected: "ERROR"},
+}
+
+func TestSelectRelease(t *testing.T) {
+	for name, test := range testCasesSelectRelease {
+		release, err := selectRelease(testReleases, test.channel)
+		if err != nil {
+			if test.expected != "ERROR" {
+				t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			}
+			continue
+		}
+		if release.TagName != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, release.TagName)
+		}
+	}
+}
+
+func TestVerifyRelease(t *testing.T) {
+	archive := []byte("archive")
+	sum := sha256.Sum256(archive)
+	checksums := []byte(fmt.Sprintf("%s  powerpipe.linux.amd64.tar.gz\n%s *powerpipe.darwin.arm64.tar.gz\n", hex.EncodeToString(sum[:]), strings.Repeat("0", 64)))
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encodedKey := base64.StdEncoding.EncodeToString(publicKey)
+	signature := ed25519.Sign(privateKey, append([]byte("powerpipe 1.2.0\n"), checksums...))
+
+	if err := verifySignature(checksums, signature, "1.2.0", encodedKey); err != nil {
+		t.Errorf("Test: 'raw signature' FAILED : unexpected error %v", err)
+	}
+	if err := verifySignature(checksums, []byte(base64.StdEncoding.EncodeToString(signature)+"\n"), "1.2.0", encodedKey); err != nil {
+		t.Errorf("Test: 'base64 signature' FAILED : unexpected error %v", err)
+	}
+	if err := verifySignature(append(checksums, '\n'), signature, "1.2.0", encodedKey); err == nil {
+		t.Errorf("Test: 'tampered checksums' FAILED : expected error")
+	}
+	if err := verifySignature(checksums, signature, "1.3.0", encodedKey); err == nil {
+		t.Errorf("Test: 'other version' FAILED : expected error")
+	}
+	if err := verifySignature(checksums, ed25519.Sign(privateKey, checksums), "1.2.0", encodedKey); err == nil {
+		t.Errorf("Test: 'unversioned signature' FAILED : expected error")
+	}
+	if err := verifySignature(checksums, signature, "1.2.0", ""); err == nil {
+		t.Errorf("Test: 'no signing key' FAILED : expected error")
+	}
+
+	expected, err := checksumFor(checksums, "powerpipe.linux.amd64.tar.gz")
+	if err != nil {
+		t.Fatalf("Test: 'checksum' FAILED : unexpected error %v", err)
+	}
+	if err := verifyChecksum(archive, "powerpipe.linux.amd64.tar.gz", expected); err != nil {
+		t.Errorf("Test: 'checksum' FAILED : unexpected error %v", err)
+	}
+	if expected, err := checksumFor(checksums, "powerpipe.darwin.arm64.tar.gz"); err != nil || verifyChecksum(archive, "powerpipe.darwin.arm64.tar.gz", expected) == nil {
+		t.Errorf("Test: 'checksum mismatch' FAILED : expected mismatch, got %v", err)
+	}
+	if _, err := checksumFor(checksums, "powerpipe.windows.amd64.tar.gz"); err == nil {
+		t.Errorf("Test: 'missing checksum' FAILED : expected error")
+	}
+}
+
+type installBinaryTest struct {
+	// the checks which fail - 'staged' or 'installed'
+	failCheck string
+	// the expected content of the executable after the install
+	expected string
+	err      bool
+}
+
+var testCasesInstallBinary = map[string]installBinaryTest{
+	"success":               {expected: "new"},
+	"staged check fails":    {failCheck: "staged", expected: "current", err: true},
+	"installed check fails": {failCheck: "installed", expected: "current", err: true},
+}
+
+func TestInstallBinary(t *testing.T) {
+	for name, test := range testCasesInstallBinary {
+		exePath := filepath.Join(t.TempDir(), "powerpipe")
+		if err := os.WriteFile(exePath, []byte("current"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		check := func(_ context.Context, binaryPath string) error {
+			if (test.failCheck == "staged" && binaryPath != exePath) || (test.failCheck == "installed" && binaryPath == exePath) {
+				return fmt.Errorf("check failed")
+			}
+			return nil
+		}
+
+		err := installBinary(context.Background(), exePath, []byte("new"), check)
+		if (err != nil) != test.err {
+			t.Errorf("Test: '%s' FAILED : expected error %v, got %v", name, test.err, err)
+		}
+		if data, _ := os.ReadFile(exePath); string(data) != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected executable '%s', got '%s'", name, test.expected, data)
+		}
+		if _, err := os.Stat(exePath + stagedSuffix); err == nil {
+			t.Errorf("Test: '%s' FAILED : expected the staged binary to be removed", name)
+		}
+		if test.err {
+			continue
+		}
+		if data, _ := os.ReadFile(exePath + previousSuffix); string(data) != "current" {
+			t.Errorf("Test: '%s' FAILED : expected the previous binary to be kept, got '%s'", name, data)
+		}
+	}
+}
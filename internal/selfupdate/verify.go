@@ -0,0 +1,69 @@
+package selfupdate
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ReleasePublicKey is the base64 encoded ed25519 public key which signs the checksums file of each release
+// it is set at build time - a build without it cannot verify releases, so cannot update itself
+var ReleasePublicKey string
+
+// signedPayload returns the data signed by the signature of a release - its version, then its checksums file
+// the version is signed so that the checksums and signature of one release cannot be served as those of another
+// (see the signs of .goreleaser.yml)
+func signedPayload(version string, checksums []byte) []byte {
+	return append([]byte(fmt.Sprintf("powerpipe %s\n", version)), checksums...)
+}
+
+// verifySignature verifies the ed25519 signature of the checksums file of the release with the given version - the
+// signature may be raw or base64 encoded
+func verifySignature(checksums, signature []byte, version, publicKey string) error {
+	if publicKey == "" {
+		return fmt.Errorf("this build of powerpipe has no release signing key, so cannot verify releases")
+	}
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("the release signing key of this build is invalid")
+	}
+	if len(signature) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+		if err != nil {
+			return fmt.Errorf("invalid signature: %w", err)
+		}
+		signature = decoded
+	}
+	if !ed25519.Verify(key, signedPayload(version, checksums), signature) {
+		return fmt.Errorf("the signature of %s is not valid for version %s and the release signing key", checksumsFileName, version)
+	}
+	return nil
+}
+
+// checksumFor returns the sha256 checksum of the named file from a checksums file, which has a line per file of
+// the form '<checksum>  <name>'
+func checksumFor(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// sha256sum prefixes the names of files read in binary mode with '*'
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no checksum for '%s'", checksumsFileName, name)
+}
+
+// verifyChecksum verifies the sha256 checksum of the data
+func verifyChecksum(data []byte, name, expected string) error {
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return fmt.Errorf("checksum mismatch for '%s' - expected %s, got %s", name, expected, actual)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package serverclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/powerpipe/internal/dashboardexecute"
+	"github.com/turbot/powerpipe/internal/exitcodes"
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+)
+
+const defaultRequestTimeout = 30 * time.Second
+
+// Client is a minimal client for the API exposed by 'powerpipe server'
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	// the headers sent with each request, e.g. the identity of the user if the server has an auth policy
+	headers http.Header
+	// set if the server is connected to using https
+	tls bool
+	// the CAs trusted to verify the certificate of the server (nil to use those of the TLS policy)
+	rootCAs *x509.CertPool
+}
+
+type ClientOption func(*Client)
+
+// WithTLS connects to the server using https, verifying its certificate using the given CAs (or, if nil, the system
+// roots and the CA bundles of the TLS policy)
+func WithTLS(rootCAs *x509.CertPool) ClientOption {
+	return func(c *Client) {
+		c.tls = true
+		c.rootCAs = rootCAs
+	}
+}
+
+// WithHeader sets a header sent with each request, e.g. the identity header read by the auth policy of the server
+// (headers with an empty value are not sent)
+func WithHeader(name, value string) ClientOption {
+	return func(c *Client) {
+		if value != "" {
+			c.headers.Set(name, value)
+		}
+	}
+}
+
+func NewClient(host string, port int, opts ...ClientOption) *Client {
+	c := &Client{headers: make(http.Header)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	scheme := "http"
+	if c.tls {
+		scheme = "https"
+	}
+	c.baseURL = fmt.Sprintf("%s://%s/api/v0", scheme, net.JoinHostPort(host, strconv.Itoa(port)))
+	c.httpClient = &http.Client{
+		Transport: tlspolicy.NewTransport(&tls.Config{RootCAs: c.rootCAs}), //nolint:gosec // the minimum version is set by the TLS policy
+		Timeout:   defaultRequestTimeout,
+	}
+	return c
+}
+
+// ListRuns returns the in-flight runs on the server
+func (c *Client) ListRuns(ctx context.Context) ([]dashboardexecute.RunInfo, error) {
+	var res struct {
+		Items []dashboardexecute.RunInfo `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/run", &res); err != nil {
+		return nil, err
+	}
+	return res.Items, nil
+}
+
+// CancelRun cancels the run with the given id
+func (c *Client) CancelRun(ctx context.Context, runId string) (*dashboardexecute.RunInfo, error) {
+	var res dashboardexecute.RunInfo
+	if err := c.do(ctx, http.MethodDelete, "/run/"+url.PathEscape(runId), &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return exitcodes.WithExitCode(fmt.Errorf("could not connect to powerpipe server at %s - is 'powerpipe server' running? (%s)", c.baseURL, errors.Unwrap(err)), exitcodes.ExitCodeServerConnectionFailed)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		// try to extract the error detail
+		var errorModel perr.ErrorModel
+		if json.Unmarshal(body, &errorModel) == nil && errorModel.Detail != "" {
+			err = fmt.Errorf("%s", errorModel.Detail)
+		} else {
+			err = fmt.Errorf("server returned %s", resp.Status)
+		}
+		// a client error is an invalid request, e.g. the id of a run which does not exist
+		if resp.StatusCode < http.StatusInternalServerError {
+			return exitcodes.WithExitCode(err, constants.ExitCodeInsufficientOrWrongInputs)
+		}
+		return err
+	}
+
+	return json.Unmarshal(body, target)
+}
@@ -0,0 +1,147 @@
+package serverclient
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/powerpipe/internal/exitcodes"
+)
+
+// newTestClient returns a client of the server
+func newTestClient(t *testing.T, server *httptest.Server, opts ...ClientOption) *Client {
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewClient(host, portNumber, opts...)
+}
+
+type clientTest struct {
+	status int
+	body   string
+	// the expected id of the cancelled run, or the expected error and exit code
+	expected         string
+	expectedExitCode int
+}
+
+var testCasesCancelRun = map[string]clientTest{
+	"cancelled": {
+		status:   http.StatusOK,
+		body:     `{"run_id": "r1", "run_type": "benchmark", "target": "mod.benchmark.cis", "status": "cancelled"}`,
+		expected: "r1",
+	},
+	"not found": {
+		status:           http.StatusNotFound,
+		body:             `{"status": 404, "detail": "run r1 not found"}`,
+		expected:         "run r1 not found",
+		expectedExitCode: constants.ExitCodeInsufficientOrWrongInputs,
+	},
+	"server error": {
+		status:           http.StatusInternalServerError,
+		body:             "failed",
+		expected:         "server returned 500 Internal Server Error",
+		expectedExitCode: constants.ExitCodeUnknownErrorPanic,
+	},
+}
+
+func TestCancelRun(t *testing.T) {
+	for name, test := range testCasesCancelRun {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodDelete || r.URL.Path != "/api/v0/run/r1" {
+				t.Errorf("Test: '%s' FAILED : unexpected request %s %s", name, r.Method, r.URL.Path)
+			}
+			w.WriteHeader(test.status)
+			_, _ = w.Write([]byte(test.body))
+		}))
+		run, err := newTestClient(t, server).CancelRun(context.Background(), "r1")
+		server.Close()
+
+		if test.expectedExitCode != 0 {
+			if err == nil || err.Error() != test.expected {
+				t.Errorf("Test: '%s' FAILED : expected error '%s', got %v", name, test.expected, err)
+			} else if actual := exitcodes.FromError(err, constants.ExitCodeUnknownErrorPanic); actual != test.expectedExitCode {
+				t.Errorf("Test: '%s' FAILED : expected exit code %d, got %d", name, test.expectedExitCode, actual)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		if run.RunId != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected run %s, got %s", name, test.expected, run.RunId)
+		}
+	}
+}
+
+func TestListRuns(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/v0/run" {
+			t.Errorf("Test: 'list' FAILED : unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"items": [{"run_id": "r1", "run_type": "dashboard", "target": "mod.dashboard.d", "status": "running"}]}`))
+	}))
+	client := newTestClient(t, server)
+
+	runs, err := client.ListRuns(context.Background())
+	if err != nil {
+		t.Fatalf("Test: 'list' FAILED : unexpected error %v", err)
+	}
+	if len(runs) != 1 || runs[0].RunId != "r1" || runs[0].Target != "mod.dashboard.d" {
+		t.Errorf("Test: 'list' FAILED : expected run r1, got %+v", runs)
+	}
+
+	// once the server has stopped, the error is a connection failure
+	server.Close()
+	_, err = client.ListRuns(context.Background())
+	if actual := exitcodes.FromError(err, constants.ExitCodeUnknownErrorPanic); actual != exitcodes.ExitCodeServerConnectionFailed {
+		t.Errorf("Test: 'not running' FAILED : expected exit code %d, got %d (%v)", exitcodes.ExitCodeServerConnectionFailed, actual, err)
+	}
+}
+
+func TestListRunsTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the server has an auth policy, so requires the identity of the user
+		if r.Header.Get("X-Forwarded-User") != "alice@example.com" || r.Header.Get("X-Forwarded-Groups") != "security,ops" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"status": 403, "detail": "the auth policy does not allow the run_benchmarks operation"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"items": [{"run_id": "r1", "run_type": "dashboard", "target": "mod.dashboard.d", "status": "running"}]}`))
+	}))
+	defer server.Close()
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(server.Certificate())
+
+	client := newTestClient(t, server, WithTLS(rootCAs), WithHeader("X-Forwarded-User", "alice@example.com"), WithHeader("X-Forwarded-Groups", "security,ops"))
+	runs, err := client.ListRuns(context.Background())
+	if err != nil {
+		t.Fatalf("Test: 'tls' FAILED : unexpected error %v", err)
+	}
+	if len(runs) != 1 || runs[0].RunId != "r1" {
+		t.Errorf("Test: 'tls' FAILED : expected run r1, got %+v", runs)
+	}
+
+	// without the identity, the request is forbidden
+	_, err = newTestClient(t, server, WithTLS(rootCAs), WithHeader("X-Forwarded-User", "")).ListRuns(context.Background())
+	expected := "the auth policy does not allow the run_benchmarks operation"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Test: 'no identity' FAILED : expected error '%s', got %v", expected, err)
+	}
+
+	// the certificate of the server is not trusted unless its CA is
+	_, err = newTestClient(t, server, WithTLS(nil)).ListRuns(context.Background())
+	if actual := exitcodes.FromError(err, constants.ExitCodeUnknownErrorPanic); actual != exitcodes.ExitCodeServerConnectionFailed {
+		t.Errorf("Test: 'untrusted' FAILED : expected exit code %d, got %d (%v)", exitcodes.ExitCodeServerConnectionFailed, actual, err)
+	}
+}
@@ -2,10 +2,10 @@ package api
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net/http"
-	"path"
 	"reflect"
 	"strings"
 	"time"
@@ -22,8 +22,15 @@ import (
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/filepaths"
 	"github.com/turbot/pipe-fittings/workspace"
+	"github.com/turbot/powerpipe/internal/approval"
 	"github.com/turbot/powerpipe/internal/dashboardserver"
+	"github.com/turbot/powerpipe/internal/detection"
+	"github.com/turbot/powerpipe/internal/introspect"
+	"github.com/turbot/powerpipe/internal/materialize"
+	"github.com/turbot/powerpipe/internal/rbac"
+	"github.com/turbot/powerpipe/internal/schedule"
 	"github.com/turbot/powerpipe/internal/service/api/common"
+	"github.com/turbot/powerpipe/internal/tlspolicy"
 	"gopkg.in/olahol/melody.v1"
 )
 
@@ -73,6 +80,28 @@ type APIService struct {
 
 	// the loaded workspace
 	workspace *workspace.Workspace
+	// the dashboard server - used to execute runs triggered through the API
+	dashboardServer *dashboardserver.Server
+	// the detection scheduler (if the workspace contains detections)
+	detectionScheduler *detection.Scheduler
+	// the materialization refresher (if the workspace contains materializations)
+	materializationRefresher *materialize.Refresher
+	// the authorizer applying the auth policy (nil if auth is not enabled)
+	authorizer *rbac.Authorizer
+	// the status of the mod install started through the API
+	modInstall modInstallState
+	// the gate holding the results of scheduled runs for approval
+	approvalGate *approval.Gate
+	// the scheduler of benchmark and dashboard runs
+	scheduler *schedule.Scheduler
+	// the capabilities of the server, served by the introspection endpoint
+	capabilities *introspect.Capabilities
+	// the certificate of the server - if set, the server is served over HTTPS
+	tlsCertificate *tls.Certificate
+	// the Content-Security-Policy header of the dashboard - empty for the default policy, or ContentSecurityPolicyOff
+	contentSecurityPolicy string
+	// the signatures of recent webhook requests, used to reject replayed requests
+	webhookReplays *webhookReplayCache
 }
 
 // APIServiceOption defines a type of function to configures the APIService.
@@ -92,6 +121,67 @@ func WithWorkspace(workspace *workspace.Workspace) APIServiceOption {
 	}
 }
 
+func WithDashboardServer(dashboardServer *dashboardserver.Server) APIServiceOption {
+	return func(api *APIService) error {
+		api.dashboardServer = dashboardServer
+		return nil
+	}
+}
+
+func WithDetectionScheduler(scheduler *detection.Scheduler) APIServiceOption {
+	return func(api *APIService) error {
+		api.detectionScheduler = scheduler
+		return nil
+	}
+}
+
+func WithMaterializationRefresher(refresher *materialize.Refresher) APIServiceOption {
+	return func(api *APIService) error {
+		api.materializationRefresher = refresher
+		return nil
+	}
+}
+
+func WithApprovalGate(gate *approval.Gate) APIServiceOption {
+	return func(api *APIService) error {
+		api.approvalGate = gate
+		return nil
+	}
+}
+
+func WithScheduler(scheduler *schedule.Scheduler) APIServiceOption {
+	return func(api *APIService) error {
+		api.scheduler = scheduler
+		return nil
+	}
+}
+
+func WithAuthorizer(authorizer *rbac.Authorizer) APIServiceOption {
+	return func(api *APIService) error {
+		api.authorizer = authorizer
+		return nil
+	}
+}
+
+func WithCapabilities(capabilities *introspect.Capabilities) APIServiceOption {
+	return func(api *APIService) error {
+		api.capabilities = capabilities
+		return nil
+	}
+}
+
+// WithTLSCertificate serves the API over HTTPS, using the PEM encoded certificate and key files and the TLS policy
+func WithTLSCertificate(certFile, keyFile string) APIServiceOption {
+	return func(api *APIService) error {
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load the TLS certificate: %w", err)
+		}
+		api.tlsCertificate = &certificate
+		return nil
+	}
+}
+
 func WithHttpPort(port dashboardserver.ListenPort) APIServiceOption {
 	return func(api *APIService) error {
 		api.HTTPPort = fmt.Sprintf("%d", port)
@@ -103,8 +193,9 @@ func WithHttpPort(port dashboardserver.ListenPort) APIServiceOption {
 func NewAPIService(ctx context.Context, opts ...APIServiceOption) (*APIService, error) {
 	// Defaults
 	api := &APIService{
-		ctx:    ctx,
-		Status: "initialized",
+		ctx:            ctx,
+		Status:         "initialized",
+		webhookReplays: newWebhookReplayCache(),
 	}
 
 	// Set options
@@ -155,11 +246,25 @@ func (api *APIService) Start() error {
 	apiLimiter.SetBurst(viper.GetInt("web.rate.burst"))
 
 	RegisterPublicAPI(apiPrefixGroup)
+	api.registerRunAPI(apiPrefixGroup)
+	api.registerWebhookAPI(apiPrefixGroup)
+	api.registerDetectionAPI(apiPrefixGroup)
+	api.registerMaterializationAPI(apiPrefixGroup)
+	api.registerBadgeAPI(apiPrefixGroup)
+	api.registerPanelDataAPI(apiPrefixGroup)
+	api.registerModAPI(apiPrefixGroup)
+	api.registerApprovalAPI(apiPrefixGroup)
+	api.registerScheduleAPI(apiPrefixGroup)
+	api.registerAuthAPI(apiPrefixGroup)
+	api.registerSnapshotAPI(apiPrefixGroup)
+	api.registerIntrospectAPI(apiPrefixGroup)
 
 	// put in handing for the dashboard for the mod
 	assetsDirectory := filepaths.EnsureDashboardAssetsDir()
-	// respond with the static dashboard assets for / (root)
-	router.Use(static.Serve("/", static.LocalFile(assetsDirectory, true)))
+	indexPage := loadIndexPage()
+	router.Use(api.securityHeaders(indexPage))
+	// respond with the static dashboard assets for / (root) - the index page is served by the fall through handler
+	router.Use(static.Serve("/", assetsFileSystem{static.LocalFile(assetsDirectory, true)}))
 	if api.webSocket != nil {
 		router.GET("/ws", func(c *gin.Context) {
 			if err := api.webSocket.HandleRequest(c.Writer, c.Request); err != nil {
@@ -169,13 +274,7 @@ func (api *APIService) Start() error {
 	}
 
 	// fall through
-	router.NoRoute(func(c *gin.Context) {
-		// https://stackoverflow.com/questions/49547/how-do-we-control-web-page-caching-across-all-browsers
-		c.Header("Cache-Control", "no-cache, no-store, must-revalidate") // HTTP 1.1.
-		c.Header("Pragma", "no-cache")                                   // HTTP 1.0.
-		c.Header("Expires", "0")                                         // Proxies.
-		c.File(path.Join(assetsDirectory, "index.html"))
-	})
+	router.NoRoute(serveIndexPage(indexPage, assetsDirectory))
 
 	api.apiPrefixGroup = apiPrefixGroup
 	api.router = router
@@ -231,7 +330,14 @@ func (api *APIService) Start() error {
 	// Initializing the server in a goroutine so that
 	// it won't block the graceful shutdown handling below
 	go func() {
-		if err := api.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if api.tlsCertificate != nil {
+			api.httpServer.TLSConfig = tlspolicy.Apply(&tls.Config{Certificates: []tls.Certificate{*api.tlsCertificate}})
+			err = api.httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = api.httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("listen: %s\n", err)
 		}
 	}()
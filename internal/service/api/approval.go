@@ -0,0 +1,162 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/powerpipe/internal/approval"
+	"github.com/turbot/powerpipe/internal/rbac"
+	"github.com/turbot/powerpipe/internal/service/api/common"
+)
+
+type ApprovalRequestURI struct {
+	Id string `uri:"id" binding:"required"`
+}
+
+type ApprovalDecisionRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+type ListApprovalResponse struct {
+	Items []*approval.Request `json:"items"`
+}
+
+func (api *APIService) registerApprovalAPI(router *gin.RouterGroup) {
+	authorize := api.authorizeOperation(rbac.OperationManageSchedules)
+	router.GET("/approval", authorize, api.approvalList)
+	router.GET("/approval/:id", authorize, api.approvalGet)
+	router.POST("/approval/:id/approve", authorize, api.approvalApprove)
+	router.POST("/approval/:id/reject", authorize, api.approvalReject)
+}
+
+// @Summary List approval requests
+// @Description List the requests for approval to push the results of scheduled runs to external systems, newest first
+// @ID   approval_list
+// @Tags Approval
+// @Produce json
+// @Param status query string false "Only return requests with this status; one of: pending, approved, rejected, expired"
+// @Success 200 {object} ListApprovalResponse
+// @Failure 403 {object} perr.ErrorModel
+// @Router /approval [get]
+func (api *APIService) approvalList(c *gin.Context) {
+	res := ListApprovalResponse{Items: []*approval.Request{}}
+	if api.approvalGate != nil {
+		res.Items = api.approvalGate.List(approval.Status(c.Query("status")))
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Get approval request
+// @Description Get a request for approval to push the results of a scheduled run to external systems
+// @ID   approval_get
+// @Tags Approval
+// @Produce json
+// @Param id path string true "The id of the approval request"
+// @Success 200 {object} approval.Request
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Router /approval/{id} [get]
+func (api *APIService) approvalGet(c *gin.Context) {
+	var uri ApprovalRequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+	if api.approvalGate == nil {
+		common.AbortWithError(c, perr.NotFoundWithMessage(approval.ErrNotFound.Error()))
+		return
+	}
+	request, err := api.approvalGate.Get(uri.Id)
+	if err != nil {
+		common.AbortWithError(c, approvalError(err))
+		return
+	}
+	c.JSON(http.StatusOK, request)
+}
+
+// @Summary Approve approval request
+// @Description Approve a pending request, allowing the results of the run to be pushed to external systems. Requires an admin role if auth is enabled.
+// @ID   approval_approve
+// @Tags Approval
+// @Accept json
+// @Produce json
+// @Param id path string true "The id of the approval request"
+// @Param request body ApprovalDecisionRequest false "The reason for the decision"
+// @Success 200 {object} approval.Request
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Failure 409 {object} perr.ErrorModel
+// @Router /approval/{id}/approve [post]
+func (api *APIService) approvalApprove(c *gin.Context) {
+	api.approvalDecide(c, true)
+}
+
+// @Summary Reject approval request
+// @Description Reject a pending request - the results of the run are not pushed to external systems. Requires an admin role if auth is enabled.
+// @ID   approval_reject
+// @Tags Approval
+// @Accept json
+// @Produce json
+// @Param id path string true "The id of the approval request"
+// @Param request body ApprovalDecisionRequest false "The reason for the decision"
+// @Success 200 {object} approval.Request
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Failure 409 {object} perr.ErrorModel
+// @Router /approval/{id}/reject [post]
+func (api *APIService) approvalReject(c *gin.Context) {
+	api.approvalDecide(c, false)
+}
+
+func (api *APIService) approvalDecide(c *gin.Context, approve bool) {
+	identity := api.authorizer.GetIdentity(c.Request)
+	if api.authorizer.Enabled() && !api.authorizer.IsAdmin(identity) {
+		common.AbortWithError(c, perr.ForbiddenWithMessage("an admin role is required to decide approval requests"))
+		return
+	}
+	var uri ApprovalRequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+	var req ApprovalDecisionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			common.AbortWithError(c, err)
+			return
+		}
+	}
+	if api.approvalGate == nil {
+		common.AbortWithError(c, perr.NotFoundWithMessage(approval.ErrNotFound.Error()))
+		return
+	}
+
+	// record who decided the request, if known
+	decidedBy := c.ClientIP()
+	if identity != nil && identity.Name != "" {
+		decidedBy = identity.Name
+	}
+	decide := api.approvalGate.Reject
+	if approve {
+		decide = api.approvalGate.Approve
+	}
+	request, err := decide(c.Request.Context(), uri.Id, decidedBy, req.Reason)
+	if err != nil {
+		common.AbortWithError(c, approvalError(err))
+		return
+	}
+	c.JSON(http.StatusOK, request)
+}
+
+func approvalError(err error) error {
+	switch {
+	case errors.Is(err, approval.ErrNotFound):
+		return perr.NotFoundWithMessage(err.Error())
+	case errors.Is(err, approval.ErrAlreadyDecided):
+		return perr.ConflictWithMessage(err.Error())
+	default:
+		return err
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/powerpipe/internal/rbac"
+	"github.com/turbot/powerpipe/internal/service/api/common"
+)
+
+func (api *APIService) registerAuthAPI(router *gin.RouterGroup) {
+	router.GET("/auth/policy", api.authPolicyGet)
+	router.PUT("/auth/policy", api.authPolicyUpdate)
+}
+
+// @Summary Get auth policy
+// @Description Get the auth policy restricting the dashboards, benchmarks and API operations available to each user. Requires an admin role.
+// @ID   auth_policy_get
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} rbac.Policy
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Router /auth/policy [get]
+func (api *APIService) authPolicyGet(c *gin.Context) {
+	if !api.authorizeAdmin(c) {
+		return
+	}
+	c.JSON(http.StatusOK, api.authorizer.GetPolicy())
+}
+
+// @Summary Update auth policy
+// @Description Replace the auth policy. The policy applies until the server is restarted. Requires an admin role.
+// @ID   auth_policy_update
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body rbac.Policy true "The new policy"
+// @Success 200 {object} rbac.Policy
+// @Failure 400 {object} perr.ErrorModel
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Router /auth/policy [put]
+func (api *APIService) authPolicyUpdate(c *gin.Context) {
+	if !api.authorizeAdmin(c) {
+		return
+	}
+	var policy rbac.Policy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+	if err := api.authorizer.SetPolicy(&policy); err != nil {
+		common.AbortWithError(c, perr.BadRequestWithMessage(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, &policy)
+}
+
+// authorizeOperation returns middleware which aborts the request unless the user may perform the operation
+// (if auth is not enabled, all operations are allowed)
+func (api *APIService) authorizeOperation(operation rbac.Operation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !api.authorizer.CanPerform(api.authorizer.GetIdentity(c.Request), operation) {
+			common.AbortWithError(c, perr.ForbiddenWithMessage(fmt.Sprintf("the auth policy does not allow the %s operation", operation)))
+			return
+		}
+		c.Next()
+	}
+}
+
+// authorizeAdmin aborts the request unless auth is enabled and the user has an admin role
+func (api *APIService) authorizeAdmin(c *gin.Context) bool {
+	if !api.authorizer.Enabled() {
+		common.AbortWithError(c, perr.NotFoundWithMessage("auth is not enabled - set an auth policy to enable"))
+		return false
+	}
+	if !api.authorizer.IsAdmin(api.authorizer.GetIdentity(c.Request)) {
+		common.AbortWithError(c, perr.ForbiddenWithMessage("an admin role is required to manage the auth policy"))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/powerpipe/internal/badge"
+	"github.com/turbot/powerpipe/internal/service/api/common"
+	"github.com/turbot/powerpipe/internal/types"
+)
+
+func (api *APIService) registerBadgeAPI(router *gin.RouterGroup) {
+	router.GET("/benchmark/:benchmark_name/badge.svg", api.badgeGetSVG)
+	router.GET("/benchmark/:benchmark_name/badge.json", api.badgeGetJSON)
+}
+
+// @Summary Get benchmark badge
+// @Description Get an SVG badge summarizing the latest run of a benchmark, for embedding in READMEs and wikis
+// @ID   badge_get_svg
+// @Tags Badge
+// @Produce image/svg+xml
+// @Param benchmark_name path string true "The full name of the benchmark"
+// @Success 200 {string} string
+// @Failure 404 {object} perr.ErrorModel
+// @Router /benchmark/{benchmark_name}/badge.svg [get]
+func (api *APIService) badgeGetSVG(c *gin.Context) {
+	b, ok := api.getLatestBadge(c)
+	if !ok {
+		return
+	}
+	// badges must reflect the latest run, so must not be cached by image proxies
+	c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+	c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", []byte(b.SVG()))
+}
+
+// @Summary Get benchmark badge JSON
+// @Description Get a summary of the latest run of a benchmark, in the shields.io endpoint badge format
+// @ID   badge_get_json
+// @Tags Badge
+// @Produce json
+// @Param benchmark_name path string true "The full name of the benchmark"
+// @Success 200 {object} badge.Badge
+// @Failure 404 {object} perr.ErrorModel
+// @Router /benchmark/{benchmark_name}/badge.json [get]
+func (api *APIService) badgeGetJSON(c *gin.Context) {
+	b, ok := api.getLatestBadge(c)
+	if !ok {
+		return
+	}
+	c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+	c.JSON(http.StatusOK, b)
+}
+
+// getLatestBadge returns the badge for the latest run of the requested benchmark,
+// aborting the request if there is none
+func (api *APIService) getLatestBadge(c *gin.Context) (*badge.Badge, bool) {
+	if api.dashboardServer == nil {
+		common.AbortWithError(c, perr.NotFoundWithMessage("badges are not available"))
+		return nil, false
+	}
+	var uri types.BenchmarkBadgeRequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		common.AbortWithError(c, err)
+		return nil, false
+	}
+	b, err := api.dashboardServer.LatestBadge(c.Request, uri.BenchmarkName)
+	if err != nil {
+		common.AbortWithError(c, err)
+		return nil, false
+	}
+	return b, true
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-contrib/static"
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/powerpipe/internal/dashboardassets"
+)
+
+// ContentSecurityPolicyOff disables the Content-Security-Policy header of the dashboard
+const ContentSecurityPolicyOff = "off"
+
+// the default content security policy of the dashboard only allows scripts served by the server (or the inline scripts
+// of the index page), and connections to the server and its websocket
+// images may be loaded from anywhere, as dashboards may include external images
+// the default-src, script-src and connect-src directives are added for each response (see contentSecurityPolicy)
+var defaultContentSecurityPolicy = []string{
+	"style-src 'self' 'unsafe-inline'",
+	"img-src 'self' data: blob: https:",
+	"font-src 'self' data:",
+	"object-src 'none'",
+	"base-uri 'self'",
+	"form-action 'self'",
+	"frame-ancestors 'self'",
+}
+
+func WithContentSecurityPolicy(policy string) APIServiceOption {
+	return func(api *APIService) error {
+		api.contentSecurityPolicy = policy
+		return nil
+	}
+}
+
+// loadIndexPage loads the index page of the dashboard, with the integrity of the assets of the extraction manifest -
+// nil is returned if the page cannot be loaded, in which case the index file is served as is
+func loadIndexPage() *dashboardassets.IndexPage {
+	manifest, err := dashboardassets.LoadManifest()
+	if err != nil {
+		slog.Warn("dashboard assets will be served without integrity checks", "error", err)
+	}
+	page, err := dashboardassets.LoadIndexPage(manifest)
+	if err != nil {
+		slog.Warn("failed to load the dashboard index page", "error", err)
+		return nil
+	}
+	return page
+}
+
+// securityHeaders returns a middleware adding the content security policy (unless disabled) and nosniff headers
+func (api *APIService) securityHeaders(page *dashboardassets.IndexPage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("X-Content-Type-Options", "nosniff")
+		if policy := api.contentSecurityPolicyFor(c.Request, page); policy != "" {
+			c.Header("Content-Security-Policy", policy)
+		}
+		c.Next()
+	}
+}
+
+// contentSecurityPolicyFor returns the content security policy of the response to the request - the policy set by
+// --content-security-policy, or the default policy, allowing the inline scripts of the index page and websocket
+// connections to the host of the request
+func (api *APIService) contentSecurityPolicyFor(r *http.Request, page *dashboardassets.IndexPage) string {
+	switch api.contentSecurityPolicy {
+	case ContentSecurityPolicyOff:
+		return ""
+	case "":
+		return contentSecurityPolicy(page, r.Host)
+	default:
+		return api.contentSecurityPolicy
+	}
+}
+
+func contentSecurityPolicy(page *dashboardassets.IndexPage, host string) string {
+	scriptSrc := []string{"script-src", "'self'"}
+	if page != nil {
+		scriptSrc = append(scriptSrc, page.ScriptHashes...)
+	}
+	// ('self' does not match websocket urls in all browsers)
+	connectSrc := []string{"connect-src", "'self'"}
+	if host != "" {
+		connectSrc = append(connectSrc, fmt.Sprintf("ws://%s", host), fmt.Sprintf("wss://%s", host))
+	}
+	directives := append([]string{"default-src 'self'", strings.Join(scriptSrc, " "), strings.Join(connectSrc, " ")}, defaultContentSecurityPolicy...)
+	return strings.Join(directives, "; ")
+}
+
+// serveIndexPage serves the index page of the dashboard
+func serveIndexPage(page *dashboardassets.IndexPage, assetsDirectory string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// https://stackoverflow.com/questions/49547/how-do-we-control-web-page-caching-across-all-browsers
+		c.Header("Cache-Control", "no-cache, no-store, must-revalidate") // HTTP 1.1.
+		c.Header("Pragma", "no-cache")                                   // HTTP 1.0.
+		c.Header("Expires", "0")                                         // Proxies.
+		if page == nil {
+			c.File(path.Join(assetsDirectory, "index.html"))
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page.HTML)
+	}
+}
+
+// assetsFileSystem serves the dashboard assets, except for the index page, which is served by serveIndexPage
+type assetsFileSystem struct {
+	static.ServeFileSystem
+}
+
+func (f assetsFileSystem) Exists(prefix string, filePath string) bool {
+	if p := path.Clean("/" + strings.TrimPrefix(filePath, prefix)); p == "/" || p == "/index.html" {
+		return false
+	}
+	return f.ServeFileSystem.Exists(prefix, filePath)
+}
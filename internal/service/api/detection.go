@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/powerpipe/internal/detection"
+	"github.com/turbot/powerpipe/internal/rbac"
+)
+
+type DetectionResponse struct {
+	Name     string `json:"name"`
+	Title    string `json:"title,omitempty"`
+	Schedule string `json:"schedule"`
+	Severity string `json:"severity"`
+}
+
+type ListDetectionResponse struct {
+	Items []DetectionResponse `json:"items"`
+}
+
+type ListDetectionFindingResponse struct {
+	Items []*detection.Finding `json:"items"`
+}
+
+func (api *APIService) registerDetectionAPI(router *gin.RouterGroup) {
+	authorize := api.authorizeOperation(rbac.OperationManageSchedules)
+	router.GET("/detection", authorize, api.detectionList)
+	router.GET("/detection/finding", authorize, api.detectionFindingList)
+}
+
+// @Summary List detections
+// @Description List the detections scheduled by the server
+// @ID   detection_list
+// @Tags Detection
+// @Produce json
+// @Success 200 {object} ListDetectionResponse
+// @Failure 403 {object} perr.ErrorModel
+// @Router /detection [get]
+func (api *APIService) detectionList(c *gin.Context) {
+	res := ListDetectionResponse{Items: []DetectionResponse{}}
+	if api.detectionScheduler != nil {
+		for _, d := range api.detectionScheduler.Detections() {
+			res.Items = append(res.Items, DetectionResponse{
+				Name:     d.Name,
+				Title:    d.Title,
+				Schedule: d.Schedule.String(),
+				Severity: d.Severity,
+			})
+		}
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary List detection findings
+// @Description List the most recent detection findings, newest first
+// @ID   detection_finding_list
+// @Tags Detection
+// @Produce json
+// @Param detection query string false "Only return findings for this detection"
+// @Success 200 {object} ListDetectionFindingResponse
+// @Failure 403 {object} perr.ErrorModel
+// @Router /detection/finding [get]
+func (api *APIService) detectionFindingList(c *gin.Context) {
+	res := ListDetectionFindingResponse{Items: []*detection.Finding{}}
+	if api.detectionScheduler != nil {
+		res.Items = api.detectionScheduler.Findings(c.Query("detection"))
+	}
+	c.JSON(http.StatusOK, res)
+}
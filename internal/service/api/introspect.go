@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/powerpipe/internal/service/api/common"
+)
+
+func (api *APIService) registerIntrospectAPI(router *gin.RouterGroup) {
+	router.GET("/introspect", api.introspectGet)
+}
+
+// @Summary Get capabilities
+// @Description Get a machine-readable description of the capabilities of the server - the commands and their flags, the output and export formats, the resource types, the schema versions and the exit codes - so clients may detect the available features rather than parsing the version
+// @ID   introspect_get
+// @Tags Introspect
+// @Produce json
+// @Success 200 {object} introspect.Capabilities
+// @Failure 404 {object} perr.ErrorModel
+// @Router /introspect [get]
+func (api *APIService) introspectGet(c *gin.Context) {
+	if api.capabilities == nil {
+		common.AbortWithError(c, perr.NotFoundWithMessage("introspection is not available"))
+		return
+	}
+	c.JSON(http.StatusOK, api.capabilities)
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/powerpipe/internal/materialize"
+	"github.com/turbot/powerpipe/internal/rbac"
+)
+
+type ListMaterializationResponse struct {
+	Items []materialize.Status `json:"items"`
+}
+
+func (api *APIService) registerMaterializationAPI(router *gin.RouterGroup) {
+	router.GET("/materialization", api.authorizeOperation(rbac.OperationManageSchedules), api.materializationList)
+}
+
+// @Summary List materializations
+// @Description List the materialized tables maintained by the server, with their refresh status
+// @ID   materialization_list
+// @Tags Materialization
+// @Produce json
+// @Success 200 {object} ListMaterializationResponse
+// @Failure 403 {object} perr.ErrorModel
+// @Router /materialization [get]
+func (api *APIService) materializationList(c *gin.Context) {
+	res := ListMaterializationResponse{Items: []materialize.Status{}}
+	if api.materializationRefresher != nil {
+		res.Items = api.materializationRefresher.Status()
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/modinstaller"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/powerpipe/internal/modsource"
+	"github.com/turbot/powerpipe/internal/rbac"
+	"github.com/turbot/powerpipe/internal/service/api/common"
+)
+
+type ModInstallRequest struct {
+	// the mods to install - if empty, the dependencies of the workspace mod are installed
+	Mods []string `json:"mods,omitempty"`
+}
+
+// ModInstallStatus is the status of the current (or most recent) mod install started through the API
+type ModInstallStatus struct {
+	Running bool                     `json:"running"`
+	Mods    []string                 `json:"mods"`
+	Events  []modsource.InstallEvent `json:"events"`
+}
+
+// modInstallState records the events of the current (or most recent) mod install
+type modInstallState struct {
+	lock   sync.Mutex
+	status ModInstallStatus
+}
+
+func (s *modInstallState) get() ModInstallStatus {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res := s.status
+	res.Events = append([]modsource.InstallEvent{}, s.status.Events...)
+	return res
+}
+
+func (api *APIService) registerModAPI(router *gin.RouterGroup) {
+	authorize := api.authorizeOperation(rbac.OperationInstallMods)
+	router.GET("/mod/install", authorize, api.modInstallGet)
+	router.POST("/mod/install", authorize, api.modInstallStart)
+}
+
+// @Summary Get mod install status
+// @Description Get the status and events of the current (or most recent) mod install started through the API
+// @ID   mod_install_get
+// @Tags Mod
+// @Produce json
+// @Success 200 {object} ModInstallStatus
+// @Failure 403 {object} perr.ErrorModel
+// @Router /mod/install [get]
+func (api *APIService) modInstallGet(c *gin.Context) {
+	if !api.authorizeModInstall(c) {
+		return
+	}
+	c.JSON(http.StatusOK, api.modInstall.get())
+}
+
+// @Summary Install mods
+// @Description Start installing mods into the workspace. The install runs in the background - its progress events
+// @Description (resolving, downloading, conflict, error, done) are sent to connected dashboard clients as
+// @Description mod_install_event messages, and are returned by the mod install status. Requires an admin role if auth is enabled.
+// @ID   mod_install
+// @Tags Mod
+// @Accept json
+// @Produce json
+// @Param request body ModInstallRequest false "The mods to install"
+// @Success 202 {object} ModInstallStatus
+// @Failure 400 {object} perr.ErrorModel
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 409 {object} perr.ErrorModel
+// @Router /mod/install [post]
+func (api *APIService) modInstallStart(c *gin.Context) {
+	if !api.authorizeModInstall(c) {
+		return
+	}
+	var req ModInstallRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			common.AbortWithError(c, err)
+			return
+		}
+	}
+	if api.workspace == nil || api.workspace.Mod == nil {
+		common.AbortWithError(c, perr.BadRequestWithMessage("no workspace mod is loaded"))
+		return
+	}
+
+	mods := modsource.NormaliseModArgs(req.Mods)
+	updateStrategy := constants.ModUpdateMinimal
+	if len(mods) > 0 {
+		updateStrategy = constants.ModUpdateLatest
+	}
+	opts := &modinstaller.InstallOpts{
+		WorkspaceMod:   api.workspace.Mod,
+		Command:        "install",
+		ModArgs:        mods,
+		UpdateStrategy: updateStrategy,
+	}
+
+	state := &api.modInstall
+	state.lock.Lock()
+	if state.status.Running {
+		state.lock.Unlock()
+		common.AbortWithError(c, perr.ConflictWithMessage(modsource.ErrInstallInProgress.Error()))
+		return
+	}
+	state.status = ModInstallStatus{Running: true, Mods: mods, Events: []modsource.InstallEvent{}}
+	state.lock.Unlock()
+
+	// the install outlives the request
+	ctx := context.Background()
+	go func() {
+		installData, err := modsource.InstallWithEvents(ctx, opts, func(event modsource.InstallEvent) {
+			state.lock.Lock()
+			state.status.Events = append(state.status.Events, event)
+			state.lock.Unlock()
+			if api.dashboardServer != nil {
+				api.dashboardServer.OnModInstallEvent(ctx, event)
+			}
+		})
+		if err == nil {
+			err = modsource.UpdatePins(opts.WorkspaceMod.ModPath, installData.Lock)
+		}
+		if err != nil {
+			slog.Warn("mod install failed", "error", err)
+		}
+		state.lock.Lock()
+		state.status.Running = false
+		state.lock.Unlock()
+	}()
+
+	c.JSON(http.StatusAccepted, state.get())
+}
+
+// authorizeModInstall aborts the request if auth is enabled and the user does not have an admin role
+func (api *APIService) authorizeModInstall(c *gin.Context) bool {
+	if api.authorizer.Enabled() && !api.authorizer.IsAdmin(api.authorizer.GetIdentity(c.Request)) {
+		common.AbortWithError(c, perr.ForbiddenWithMessage("an admin role is required to install mods"))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/powerpipe/internal/dashboardexecute"
+	"github.com/turbot/powerpipe/internal/dashboardserver"
+	"github.com/turbot/powerpipe/internal/rbac"
+	"github.com/turbot/powerpipe/internal/service/api/common"
+	"github.com/turbot/powerpipe/internal/types"
+)
+
+func (api *APIService) registerPanelDataAPI(router *gin.RouterGroup) {
+	authorize := api.authorizeOperation(rbac.OperationReadSnapshots)
+	router.GET("/run/:run_id/panel/:panel_name/data.csv", authorize, api.panelDataGetCSV)
+	router.GET("/run/:run_id/panel/:panel_name/data.json", authorize, api.panelDataGetJSON)
+}
+
+// @Summary Get panel data CSV
+// @Description Download the latest data of a panel of a dashboard run as CSV. The run id is the execution id of the dashboard.
+// @ID   panel_data_get_csv
+// @Tags Run
+// @Produce text/csv
+// @Param run_id path string true "The id of the run"
+// @Param panel_name path string true "The full name of the panel"
+// @Success 200 {string} string
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Failure 409 {object} perr.ErrorModel
+// @Router /run/{run_id}/panel/{panel_name}/data.csv [get]
+func (api *APIService) panelDataGetCSV(c *gin.Context) {
+	panelData, ok := api.getPanelData(c)
+	if !ok {
+		return
+	}
+	data, err := dashboardserver.PanelDataCSV(panelData)
+	if err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+	setPanelDataAttachment(c, panelData, "csv")
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
+
+// @Summary Get panel data JSON
+// @Description Download the latest data of a panel of a dashboard run as JSON. The run id is the execution id of the dashboard.
+// @ID   panel_data_get_json
+// @Tags Run
+// @Produce json
+// @Param run_id path string true "The id of the run"
+// @Param panel_name path string true "The full name of the panel"
+// @Success 200 {object} dashboardserver.PanelDataResponse
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Failure 409 {object} perr.ErrorModel
+// @Router /run/{run_id}/panel/{panel_name}/data.json [get]
+func (api *APIService) panelDataGetJSON(c *gin.Context) {
+	panelData, ok := api.getPanelData(c)
+	if !ok {
+		return
+	}
+	data, err := dashboardserver.PanelDataJSON(panelData)
+	if err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+	setPanelDataAttachment(c, panelData, "json")
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// getPanelData returns the latest data of the requested panel, aborting the request if it is not available
+func (api *APIService) getPanelData(c *gin.Context) (*dashboardexecute.PanelData, bool) {
+	if api.dashboardServer == nil {
+		common.AbortWithError(c, perr.NotFoundWithMessage("panel data is not available"))
+		return nil, false
+	}
+	var uri types.PanelDataRequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		common.AbortWithError(c, err)
+		return nil, false
+	}
+	panelData, err := api.dashboardServer.PanelData(c.Request.Context(), c.Request, uri.RunId, uri.PanelName)
+	if err != nil {
+		common.AbortWithError(c, err)
+		return nil, false
+	}
+	return panelData, true
+}
+
+// the data is downloaded as a file named after the panel, e.g. aws_insights.table.buckets.csv
+func setPanelDataAttachment(c *gin.Context, panelData *dashboardexecute.PanelData, extension string) {
+	fileName := strings.NewReplacer("/", "_", `"`, "_").Replace(panelData.Panel)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, fileName, extension))
+}
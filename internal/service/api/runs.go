@@ -0,0 +1,86 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/powerpipe/internal/dashboardexecute"
+	"github.com/turbot/powerpipe/internal/rbac"
+	"github.com/turbot/powerpipe/internal/service/api/common"
+	"github.com/turbot/powerpipe/internal/types"
+)
+
+func (api *APIService) registerRunAPI(router *gin.RouterGroup) {
+	authorize := api.authorizeOperation(rbac.OperationRunBenchmarks)
+	router.GET("/run", authorize, api.runList)
+	router.DELETE("/run/:run_id", authorize, api.runCancel)
+}
+
+type ListRunResponse struct {
+	Items []dashboardexecute.RunInfo `json:"items"`
+}
+
+// @Summary List runs
+// @Description List the in-flight benchmark, dashboard and query runs, of the targets the user may access
+// @ID   run_list
+// @Tags Run
+// @Produce json
+// @Success 200 {object} ListRunResponse
+// @Failure 403 {object} perr.ErrorModel
+// @Router /run [get]
+func (api *APIService) runList(c *gin.Context) {
+	if dashboardexecute.Executor == nil {
+		c.JSON(http.StatusOK, ListRunResponse{Items: []dashboardexecute.RunInfo{}})
+		return
+	}
+	runs := []dashboardexecute.RunInfo{}
+	for _, run := range dashboardexecute.Executor.ListRuns() {
+		if api.canAccessRun(c, run) {
+			runs = append(runs, run)
+		}
+	}
+	c.JSON(http.StatusOK, ListRunResponse{Items: runs})
+}
+
+// @Summary Cancel run
+// @Description Cancel an in-flight run (runs of targets the user may not access are not found)
+// @ID   run_cancel
+// @Tags Run
+// @Produce json
+// @Param run_id path string true "The id of the run to cancel"
+// @Success 200 {object} dashboardexecute.RunInfo
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Router /run/{run_id} [delete]
+func (api *APIService) runCancel(c *gin.Context) {
+	var uri types.RunRequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	if dashboardexecute.Executor == nil {
+		common.AbortWithError(c, perr.NotFoundWithMessage(fmt.Sprintf("run %s not found", uri.RunId)))
+		return
+	}
+	// (the run is not found, rather than forbidden, so its existence is not disclosed)
+	if run, found := dashboardexecute.Executor.GetRun(uri.RunId); !found || !api.canAccessRun(c, run) {
+		common.AbortWithError(c, perr.NotFoundWithMessage(fmt.Sprintf("run %s not found", uri.RunId)))
+		return
+	}
+
+	runInfo, found := dashboardexecute.Executor.CancelRun(c.Request.Context(), uri.RunId)
+	if !found {
+		common.AbortWithError(c, perr.NotFoundWithMessage(fmt.Sprintf("run %s not found", uri.RunId)))
+		return
+	}
+	c.JSON(http.StatusOK, runInfo)
+}
+
+// canAccessRun returns whether the user making the request may access the target of the run
+// (if auth is not enabled, all runs may be accessed, including those of ad hoc queries)
+func (api *APIService) canAccessRun(c *gin.Context, run dashboardexecute.RunInfo) bool {
+	return !api.authorizer.Enabled() || api.canAccessTarget(c, run.Target)
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/powerpipe/internal/rbac"
+	"github.com/turbot/powerpipe/internal/schedule"
+	"github.com/turbot/powerpipe/internal/service/api/common"
+)
+
+type ScheduleRequestURI struct {
+	Target string `uri:"target" binding:"required"`
+}
+
+type ScheduleRequest struct {
+	// the cron expression of the schedule, e.g. '0 6 * * *' or '@every 6h'
+	Cron   string         `json:"cron" binding:"required"`
+	Inputs map[string]any `json:"inputs,omitempty"`
+	// the s3 integrations the snapshot of each run is published to
+	Publish []string `json:"publish,omitempty"`
+	// whether the snapshot of each run is only published once its approval request is approved
+	RequireApproval bool `json:"require_approval,omitempty"`
+}
+
+type ListScheduleResponse struct {
+	Items []*schedule.Status `json:"items"`
+}
+
+func (api *APIService) registerScheduleAPI(router *gin.RouterGroup) {
+	authorize := api.authorizeOperation(rbac.OperationManageSchedules)
+	router.GET("/schedule", authorize, api.scheduleList)
+	router.GET("/schedule/:target", authorize, api.scheduleGet)
+	router.PUT("/schedule/:target", authorize, api.schedulePut)
+	router.DELETE("/schedule/:target", authorize, api.scheduleDelete)
+	router.POST("/schedule/:target/run", authorize, api.scheduleRun)
+}
+
+// @Summary List schedules
+// @Description List the scheduled benchmark and dashboard runs, with the status of the last run of each
+// @ID   schedule_list
+// @Tags Schedule
+// @Produce json
+// @Success 200 {object} ListScheduleResponse
+// @Failure 403 {object} perr.ErrorModel
+// @Router /schedule [get]
+func (api *APIService) scheduleList(c *gin.Context) {
+	res := ListScheduleResponse{Items: []*schedule.Status{}}
+	if api.scheduler != nil {
+		for _, status := range api.scheduler.List() {
+			if api.canAccessTarget(c, status.Target) {
+				res.Items = append(res.Items, status)
+			}
+		}
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Get schedule
+// @Description Get the schedule of a benchmark or dashboard, with the status of its last run
+// @ID   schedule_get
+// @Tags Schedule
+// @Produce json
+// @Param target path string true "The full name of the benchmark or dashboard"
+// @Success 200 {object} schedule.Status
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Router /schedule/{target} [get]
+func (api *APIService) scheduleGet(c *gin.Context) {
+	target, ok := api.getScheduleTarget(c)
+	if !ok {
+		return
+	}
+	status, err := api.scheduler.Get(target)
+	if err != nil {
+		common.AbortWithError(c, scheduleError(err, target))
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// @Summary Create or replace schedule
+// @Description Schedule runs of a benchmark or dashboard, replacing any existing schedule created through the API. A snapshot of each run is stored. The schedule is saved, and restored when the server restarts.
+// @ID   schedule_put
+// @Tags Schedule
+// @Accept json
+// @Produce json
+// @Param target path string true "The full name of the benchmark or dashboard"
+// @Param request body ScheduleRequest true "The schedule"
+// @Success 200 {object} schedule.Status
+// @Failure 400 {object} perr.ErrorModel
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Router /schedule/{target} [put]
+func (api *APIService) schedulePut(c *gin.Context) {
+	target, ok := api.getScheduleTarget(c)
+	if !ok {
+		return
+	}
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+	s, err := schedule.NewSchedule(target, req.Cron, schedule.SourceAPI)
+	if err != nil {
+		common.AbortWithError(c, perr.BadRequestWithMessage(err.Error()))
+		return
+	}
+	s.Inputs = req.Inputs
+	s.Publish = req.Publish
+	s.RequireApproval = req.RequireApproval
+	if err := api.scheduler.Add(s); err != nil {
+		common.AbortWithError(c, scheduleError(err, target))
+		return
+	}
+	status, err := api.scheduler.Get(target)
+	if err != nil {
+		common.AbortWithError(c, scheduleError(err, target))
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// @Summary Delete schedule
+// @Description Delete the schedule of a benchmark or dashboard. Only schedules created through the API may be deleted.
+// @ID   schedule_delete
+// @Tags Schedule
+// @Produce json
+// @Param target path string true "The full name of the benchmark or dashboard"
+// @Success 204
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Failure 409 {object} perr.ErrorModel
+// @Router /schedule/{target} [delete]
+func (api *APIService) scheduleDelete(c *gin.Context) {
+	target, ok := api.getScheduleTarget(c)
+	if !ok {
+		return
+	}
+	if err := api.scheduler.Remove(target); err != nil {
+		common.AbortWithError(c, scheduleError(err, target))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Run schedule
+// @Description Start a run of a scheduled benchmark or dashboard now, in addition to its scheduled runs
+// @ID   schedule_run
+// @Tags Schedule
+// @Produce json
+// @Param target path string true "The full name of the benchmark or dashboard"
+// @Success 202 {object} schedule.Status
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Failure 409 {object} perr.ErrorModel
+// @Router /schedule/{target}/run [post]
+func (api *APIService) scheduleRun(c *gin.Context) {
+	target, ok := api.getScheduleTarget(c)
+	if !ok {
+		return
+	}
+	status, err := api.scheduler.RunNow(api.ctx, target)
+	if err != nil {
+		common.AbortWithError(c, scheduleError(err, target))
+		return
+	}
+	c.JSON(http.StatusAccepted, status)
+}
+
+// getScheduleTarget returns the target of the request - a not found error is returned if the scheduler is not
+// running, or the user making the request may not access the target
+func (api *APIService) getScheduleTarget(c *gin.Context) (string, bool) {
+	var uri ScheduleRequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		common.AbortWithError(c, err)
+		return "", false
+	}
+	if api.scheduler == nil || !api.canAccessTarget(c, uri.Target) {
+		common.AbortWithError(c, perr.NotFoundWithMessage(fmt.Sprintf("%s not found", uri.Target)))
+		return "", false
+	}
+	return uri.Target, true
+}
+
+// canAccessTarget returns whether the user making the request may access the benchmark or dashboard
+func (api *APIService) canAccessTarget(c *gin.Context, target string) bool {
+	return api.dashboardServer == nil || api.dashboardServer.CanAccessTarget(c.Request, target)
+}
+
+func scheduleError(err error, target string) error {
+	var errorModel perr.ErrorModel
+	switch {
+	case errors.As(err, &errorModel):
+		return err
+	case errors.Is(err, schedule.ErrNotFound):
+		return perr.NotFoundWithMessage(fmt.Sprintf("%s is not scheduled", target))
+	case errors.Is(err, schedule.ErrNotRemovable), errors.Is(err, schedule.ErrRunning):
+		return perr.ConflictWithMessage(err.Error())
+	default:
+		return perr.BadRequestWithMessage(err.Error())
+	}
+}
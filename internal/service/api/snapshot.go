@@ -0,0 +1,208 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/pipe-fittings/perr"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/rbac"
+	"github.com/turbot/powerpipe/internal/service/api/common"
+	"github.com/turbot/powerpipe/internal/snapshot"
+)
+
+type SnapshotRequestURI struct {
+	SnapshotName string `uri:"snapshot_name" binding:"required"`
+}
+
+type SnapshotAnnotationRequestURI struct {
+	SnapshotName string `uri:"snapshot_name" binding:"required"`
+	Id           string `uri:"id" binding:"required"`
+}
+
+type SnapshotAnnotationRequest struct {
+	Text string `json:"text" binding:"required"`
+	// the fingerprint of the finding to annotate - the snapshot is annotated if empty
+	Finding string `json:"finding,omitempty"`
+}
+
+type ListSnapshotAnnotationResponse struct {
+	Items []*snapshot.Annotation `json:"items"`
+}
+
+func (api *APIService) registerSnapshotAPI(router *gin.RouterGroup) {
+	authorize := api.authorizeOperation(rbac.OperationReadSnapshots)
+	router.GET("/snapshot/:snapshot_name/annotation", authorize, api.snapshotAnnotationList)
+	router.POST("/snapshot/:snapshot_name/annotation", authorize, api.snapshotAnnotationCreate)
+	router.DELETE("/snapshot/:snapshot_name/annotation/:id", authorize, api.snapshotAnnotationDelete)
+}
+
+// @Summary List snapshot annotations
+// @Description List the annotations of a snapshot of the workspace, and of the findings within it, oldest first
+// @ID   snapshot_annotation_list
+// @Tags Snapshot
+// @Produce json
+// @Param snapshot_name path string true "The name of the snapshot, e.g. snapshot.weekly"
+// @Success 200 {object} ListSnapshotAnnotationResponse
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Router /snapshot/{snapshot_name}/annotation [get]
+func (api *APIService) snapshotAnnotationList(c *gin.Context) {
+	var uri SnapshotRequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+	if _, ok := api.requireAnnotationIdentity(c); !ok {
+		return
+	}
+	snapshotPath, ok := api.getSnapshotPath(c, uri.SnapshotName)
+	if !ok {
+		return
+	}
+	annotations, err := snapshot.ReadAnnotations(snapshotPath)
+	if err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+	res := ListSnapshotAnnotationResponse{Items: []*snapshot.Annotation{}}
+	res.Items = append(res.Items, annotations...)
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Create snapshot annotation
+// @Description Annotate a snapshot of the workspace, or a finding within it, e.g. with triage notes or ticket links. The annotation is stored in the snapshot file.
+// @ID   snapshot_annotation_create
+// @Tags Snapshot
+// @Accept json
+// @Produce json
+// @Param snapshot_name path string true "The name of the snapshot, e.g. snapshot.weekly"
+// @Param request body SnapshotAnnotationRequest true "The annotation"
+// @Success 201 {object} snapshot.Annotation
+// @Failure 400 {object} perr.ErrorModel
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Router /snapshot/{snapshot_name}/annotation [post]
+func (api *APIService) snapshotAnnotationCreate(c *gin.Context) {
+	var uri SnapshotRequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+	var req SnapshotAnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+	identity, ok := api.requireAnnotationIdentity(c)
+	if !ok {
+		return
+	}
+	snapshotPath, ok := api.getSnapshotPath(c, uri.SnapshotName)
+	if !ok {
+		return
+	}
+
+	// record who annotated the snapshot, if known
+	author := c.ClientIP()
+	if identity != nil && identity.Name != "" {
+		author = identity.Name
+	}
+	annotation, err := snapshot.AddAnnotation(snapshotPath, &snapshot.Annotation{
+		Finding: req.Finding,
+		Text:    req.Text,
+		Author:  author,
+	})
+	if err != nil {
+		common.AbortWithError(c, perr.BadRequestWithMessage(err.Error()))
+		return
+	}
+	c.JSON(http.StatusCreated, annotation)
+}
+
+// @Summary Delete snapshot annotation
+// @Description Delete an annotation of a snapshot of the workspace. If auth is enabled, only the author of the annotation or an admin may delete it.
+// @ID   snapshot_annotation_delete
+// @Tags Snapshot
+// @Produce json
+// @Param snapshot_name path string true "The name of the snapshot, e.g. snapshot.weekly"
+// @Param id path string true "The id of the annotation"
+// @Success 200 {object} snapshot.Annotation
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Router /snapshot/{snapshot_name}/annotation/{id} [delete]
+func (api *APIService) snapshotAnnotationDelete(c *gin.Context) {
+	var uri SnapshotAnnotationRequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+	identity, ok := api.requireAnnotationIdentity(c)
+	if !ok {
+		return
+	}
+	snapshotPath, ok := api.getSnapshotPath(c, uri.SnapshotName)
+	if !ok {
+		return
+	}
+
+	if api.authorizer.Enabled() && !api.authorizer.IsAdmin(identity) {
+		annotations, err := snapshot.ReadAnnotations(snapshotPath)
+		if err != nil {
+			common.AbortWithError(c, err)
+			return
+		}
+		for _, a := range annotations {
+			if a.Id == uri.Id && a.Author != identity.Name {
+				common.AbortWithError(c, perr.ForbiddenWithMessage("only the author of an annotation or an admin may delete it"))
+				return
+			}
+		}
+	}
+
+	annotation, err := snapshot.RemoveAnnotation(snapshotPath, uri.Id)
+	if err != nil {
+		if errors.Is(err, snapshot.ErrAnnotationNotFound) {
+			err = perr.NotFoundWithMessage(err.Error())
+		}
+		common.AbortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, annotation)
+}
+
+// requireAnnotationIdentity returns the identity of the request - if auth is enabled, annotations are attributed to
+// their author, so the request must have an identity
+func (api *APIService) requireAnnotationIdentity(c *gin.Context) (*rbac.Identity, bool) {
+	identity := api.authorizer.GetIdentity(c.Request)
+	if api.authorizer.Enabled() && identity == nil {
+		common.AbortWithError(c, perr.UnauthorizedWithMessage("an identity is required to access snapshot annotations"))
+		return nil, false
+	}
+	return identity, true
+}
+
+// getSnapshotPath returns the path of the workspace snapshot with the given name, aborting the request if there is none
+// snapshots are named after their file in the root of the workspace, e.g. snapshot.weekly for weekly.pps
+func (api *APIService) getSnapshotPath(c *gin.Context, name string) (string, bool) {
+	if api.workspace != nil {
+		if snapshotPath, ok := api.workspace.GetResourceMaps().Snapshots[name]; ok {
+			return snapshotPath, true
+		}
+		fileName := strings.TrimPrefix(name, "snapshot.") + localconstants.SnapshotExtension
+		// the snapshot must be in the root of the workspace
+		if fileName == filepath.Base(fileName) {
+			snapshotPath := filepath.Join(api.workspace.Path, fileName)
+			if info, err := os.Stat(snapshotPath); err == nil && info.Mode().IsRegular() {
+				return snapshotPath, true
+			}
+		}
+	}
+	common.AbortWithError(c, perr.NotFoundWithMessage(fmt.Sprintf("snapshot '%s' not found in the workspace", name)))
+	return "", false
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/perr"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/dashboardexecute"
+	"github.com/turbot/powerpipe/internal/service/api/common"
+	"github.com/turbot/powerpipe/internal/types"
+)
+
+// webhook requests must be signed with the server webhook secret - the X-Powerpipe-Signature-256 header contains
+// the HMAC SHA256 signature, in the form 'sha256=<hex digest>', of:
+//
+//	<timestamp>\n<method>\n<path>\n<query>\n<body>
+//
+// where the timestamp is the X-Powerpipe-Timestamp header (the unix time the request was signed), and the path and
+// query are as sent, e.g. '/api/latest/webhook/run/aws_compliance.benchmark.cis_v300' and 'arg=region=us-east-1'
+//
+// so the target and inputs of the run are signed - requests signed more than webhookMaxAge ago are rejected, as
+// are requests whose signature has already been used
+const (
+	webhookSignatureHeader = "X-Powerpipe-Signature-256"
+	webhookTimestampHeader = "X-Powerpipe-Timestamp"
+	webhookMaxAge          = 5 * time.Minute
+)
+
+func (api *APIService) registerWebhookAPI(router *gin.RouterGroup) {
+	router.POST("/webhook/run/:target", api.webhookRun)
+}
+
+// @Summary Trigger run
+// @Description Trigger a benchmark or dashboard run from a webhook. The request (its timestamp, method, path, query and body) must be signed with the server webhook secret.
+// @ID   webhook_run
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Param target path string true "The full name of the benchmark or dashboard to run"
+// @Param arg query []string false "Input values, in the form name=value"
+// @Success 202 {object} dashboardexecute.RunInfo
+// @Failure 401 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Router /webhook/run/{target} [post]
+func (api *APIService) webhookRun(c *gin.Context) {
+	secret := viper.GetString(localconstants.ArgWebhookSecret)
+	// webhooks are disabled unless a secret is configured
+	if secret == "" || api.dashboardServer == nil {
+		common.AbortWithError(c, perr.NotFoundWithMessage("webhooks are not enabled - set a webhook secret to enable"))
+		return
+	}
+
+	var uri types.RunWebhookRequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		common.AbortWithError(c, perr.BadRequestWithMessage("could not read request body"))
+		return
+	}
+
+	if err := api.webhookReplays.verify(c.Request, body, secret, time.Now()); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	inputs, err := getWebhookInputs(c, body)
+	if err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	ctx := dashboardexecute.WithRunInitiator(c.Request.Context(), fmt.Sprintf("webhook (%s)", c.ClientIP()))
+	runInfo, err := api.dashboardServer.TriggerRun(ctx, uri.Target, inputs)
+	if err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, runInfo)
+}
+
+// webhookReplayCache records the signatures of the webhook requests received within webhookMaxAge, so that a request
+// may not be replayed
+type webhookReplayCache struct {
+	mut  sync.Mutex
+	seen map[string]time.Time
+}
+
+func newWebhookReplayCache() *webhookReplayCache {
+	return &webhookReplayCache{seen: make(map[string]time.Time)}
+}
+
+// verify verifies the signature and timestamp of the request, and that it has not been received before
+func (r *webhookReplayCache) verify(req *http.Request, body []byte, secret string, now time.Time) error {
+	signature := req.Header.Get(webhookSignatureHeader)
+	if signature == "" {
+		return perr.UnauthorizedWithMessage(fmt.Sprintf("missing webhook signature - the request must be signed in the %s header", webhookSignatureHeader))
+	}
+	unixTime, err := strconv.ParseInt(req.Header.Get(webhookTimestampHeader), 10, 64)
+	if err != nil {
+		return perr.UnauthorizedWithMessage(fmt.Sprintf("missing or invalid %s header - it must be the unix time the request was signed", webhookTimestampHeader))
+	}
+	timestamp := time.Unix(unixTime, 0)
+	if age := now.Sub(timestamp); age > webhookMaxAge || age < -webhookMaxAge {
+		return perr.UnauthorizedWithMessage("webhook request expired - the request must be signed within 5 minutes of being sent")
+	}
+
+	digest, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || !hmac.Equal(digest, webhookSignature(req, body, secret)) {
+		return perr.UnauthorizedWithMessage("invalid webhook signature")
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	// (signatures older than the maximum age are rejected by the timestamp check, so need not be remembered)
+	for s, t := range r.seen {
+		if now.Sub(t) > webhookMaxAge {
+			delete(r.seen, s)
+		}
+	}
+	if _, ok := r.seen[signature]; ok {
+		return perr.UnauthorizedWithMessage("webhook request has already been received")
+	}
+	r.seen[signature] = timestamp
+	return nil
+}
+
+// webhookSignature returns the HMAC SHA256 signature of the timestamp, method, path, query and body of the request
+func webhookSignature(req *http.Request, body []byte, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n", req.Header.Get(webhookTimestampHeader), req.Method, req.URL.EscapedPath(), req.URL.RawQuery)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// build the run inputs from the 'inputs' property of the body and any 'arg' query parameters
+// (query parameters take precedence - both are signed)
+func getWebhookInputs(c *gin.Context, body []byte) (map[string]any, error) {
+	inputs := make(map[string]any)
+	if len(body) > 0 {
+		var requestBody types.RunWebhookRequestBody
+		// the payload may be from a third party (e.g. a GitHub push event, relayed by a signing proxy) - if it is not
+		// JSON, ignore it
+		if err := json.Unmarshal(body, &requestBody); err == nil {
+			for k, v := range requestBody.Inputs {
+				inputs[k] = v
+			}
+		}
+	}
+
+	for _, arg := range c.QueryArray("arg") {
+		name, value, found := strings.Cut(arg, "=")
+		if !found {
+			return nil, perr.BadRequestWithMessage(fmt.Sprintf("the arg '%s' is not correctly specified - it must be an input name and value separated an equals sign: arg=name=value", arg))
+		}
+		inputs[name] = value
+	}
+	return inputs, nil
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "webhook_secret"
+
+var testWebhookTime = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// newSignedWebhookRequest returns a webhook request for the url, signed at the time
+func newSignedWebhookRequest(url string, body string, signedAt time.Time) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, url, nil)
+	req.Header.Set(webhookTimestampHeader, strconv.FormatInt(signedAt.Unix(), 10))
+	req.Header.Set(webhookSignatureHeader, "sha256="+hex.EncodeToString(webhookSignature(req, []byte(body), testWebhookSecret)))
+	return req
+}
+
+type verifyWebhookTest struct {
+	// the request is signed for the signedUrl, then sent to the url
+	signedUrl string
+	url       string
+	body      string
+	// the body which is sent, if it differs from the signed body
+	sentBody string
+	signedAt time.Time
+	// if set, the signature header is removed
+	unsigned bool
+	err      string
+}
+
+var testCasesVerifyWebhook = map[string]verifyWebhookTest{
+	"valid": {
+		signedUrl: "/api/latest/webhook/run/aws.benchmark.cis?arg=region=us-east-1",
+		body:      `{"inputs": {"bucket": "a"}}`,
+		signedAt:  testWebhookTime.Add(-time.Minute),
+	},
+	"tampered path": {
+		signedUrl: "/api/latest/webhook/run/aws.benchmark.cis",
+		url:       "/api/latest/webhook/run/aws.dashboard.delete_everything",
+		signedAt:  testWebhookTime,
+		err:       "Unauthorized: invalid webhook signature",
+	},
+	"tampered query": {
+		signedUrl: "/api/latest/webhook/run/aws.benchmark.cis?arg=region=us-east-1",
+		url:       "/api/latest/webhook/run/aws.benchmark.cis?arg=region=us-east-1&arg=bucket=b",
+		signedAt:  testWebhookTime,
+		err:       "Unauthorized: invalid webhook signature",
+	},
+	"tampered body": {
+		signedUrl: "/api/latest/webhook/run/aws.benchmark.cis",
+		body:      `{"inputs": {"bucket": "a"}}`,
+		sentBody:  `{"inputs": {"bucket": "b"}}`,
+		signedAt:  testWebhookTime,
+		err:       "Unauthorized: invalid webhook signature",
+	},
+	"expired": {
+		signedUrl: "/api/latest/webhook/run/aws.benchmark.cis",
+		signedAt:  testWebhookTime.Add(-webhookMaxAge - time.Second),
+		err:       "Unauthorized: webhook request expired - the request must be signed within 5 minutes of being sent",
+	},
+	"future": {
+		signedUrl: "/api/latest/webhook/run/aws.benchmark.cis",
+		signedAt:  testWebhookTime.Add(webhookMaxAge + time.Second),
+		err:       "Unauthorized: webhook request expired - the request must be signed within 5 minutes of being sent",
+	},
+	"unsigned": {
+		signedUrl: "/api/latest/webhook/run/aws.benchmark.cis",
+		signedAt:  testWebhookTime,
+		unsigned:  true,
+		err:       "Unauthorized: missing webhook signature - the request must be signed in the X-Powerpipe-Signature-256 header",
+	},
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	for name, test := range testCasesVerifyWebhook {
+		req := newSignedWebhookRequest(test.signedUrl, test.body, test.signedAt)
+		if test.url != "" {
+			req = httptest.NewRequest(http.MethodPost, test.url, nil)
+			req.Header = newSignedWebhookRequest(test.signedUrl, test.body, test.signedAt).Header
+		}
+		if test.unsigned {
+			req.Header.Del(webhookSignatureHeader)
+		}
+		body := test.body
+		if test.sentBody != "" {
+			body = test.sentBody
+		}
+
+		err := newWebhookReplayCache().verify(req, []byte(body), testWebhookSecret, testWebhookTime)
+		var actual string
+		if err != nil {
+			actual = err.Error()
+		}
+		if actual != test.err {
+			t.Errorf("Test: '%s' FAILED : expected error '%s', got '%s'", name, test.err, actual)
+		}
+	}
+}
+
+func TestVerifyWebhookReplay(t *testing.T) {
+	replays := newWebhookReplayCache()
+	req := newSignedWebhookRequest("/api/latest/webhook/run/aws.benchmark.cis", "", testWebhookTime)
+
+	if err := replays.verify(req, nil, testWebhookSecret, testWebhookTime); err != nil {
+		t.Fatalf("Test: 'first' FAILED : unexpected error %v", err)
+	}
+	err := replays.verify(req, nil, testWebhookSecret, testWebhookTime.Add(time.Minute))
+	if err == nil || err.Error() != "Unauthorized: webhook request has already been received" {
+		t.Errorf("Test: 'replay' FAILED : expected the replayed request to be rejected, got %v", err)
+	}
+
+	// a request signed later (with a different timestamp) has a different signature
+	later := newSignedWebhookRequest("/api/latest/webhook/run/aws.benchmark.cis", "", testWebhookTime.Add(time.Second))
+	if err := replays.verify(later, nil, testWebhookSecret, testWebhookTime.Add(time.Minute)); err != nil {
+		t.Errorf("Test: 'resigned' FAILED : unexpected error %v", err)
+	}
+}
@@ -0,0 +1,278 @@
+package snapshot
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// annotations (triage notes, ticket links) are attached to a snapshot, or to a finding within it, after the run,
+// e.g. with 'powerpipe snapshot annotation add prod.pps "Accepted risk - see JIRA-123" --finding <fingerprint>'
+//
+// like the run environment, the annotations are stored in the snapshot file as an additional panel (which is not
+// part of the layout), so they are kept with the snapshot wherever it is copied to - when the snapshot is
+// re-rendered, the annotations of each finding are shown in an annotations column of its control results
+const (
+	AnnotationsPanelName = "powerpipe.annotations"
+	AnnotationsPanelType = "annotations"
+	// ColumnAnnotations is the control data column the annotations of each finding are shown in when re-rendered
+	ColumnAnnotations = "annotations"
+	// the control data column containing the finding fingerprint (controlexecute.ColumnFingerprint - which cannot
+	// be referenced here as controlexecute imports this package)
+	columnFingerprint = "fingerprint"
+
+	maxAnnotationLength = 4096
+)
+
+var ErrAnnotationNotFound = errors.New("annotation not found")
+
+// annotations are written by reading and rewriting the snapshot file - serialise writes within the process
+var annotationMut sync.Mutex
+
+// Annotation is a free-text note attached to a snapshot, or to a finding within it
+type Annotation struct {
+	Id string `json:"id"`
+	// the fingerprint of the annotated finding - empty if the annotation is of the snapshot
+	Finding string `json:"finding,omitempty"`
+	// the control of the annotated finding
+	Control   string    `json:"control,omitempty"`
+	Text      string    `json:"text"`
+	Author    string    `json:"author,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Annotations is the snapshot panel containing the annotations of the snapshot
+type Annotations struct {
+	Name        string        `json:"name"`
+	PanelType   string        `json:"panel_type"`
+	Annotations []*Annotation `json:"annotations"`
+}
+
+// IsSnapshotPanel implements SnapshotPanel
+func (*Annotations) IsSnapshotPanel() {}
+
+// ReadAnnotations returns the annotations of the snapshot file, oldest first
+func ReadAnnotations(path string) ([]*Annotation, error) {
+	snap, err := readSnapshotMap(path)
+	if err != nil {
+		return nil, err
+	}
+	return getAnnotations(snap)
+}
+
+// AddAnnotation adds the annotation to the snapshot file, returning it with its id and creation time set
+// if the annotation is of a finding, the finding must be in the snapshot
+func AddAnnotation(path string, annotation *Annotation) (*Annotation, error) {
+	annotation.Text = strings.TrimSpace(annotation.Text)
+	if annotation.Text == "" {
+		return nil, fmt.Errorf("annotation text must not be empty")
+	}
+	if len(annotation.Text) > maxAnnotationLength {
+		return nil, fmt.Errorf("annotation text must be at most %d characters", maxAnnotationLength)
+	}
+
+	annotationMut.Lock()
+	defer annotationMut.Unlock()
+
+	snap, err := readSnapshotMap(path)
+	if err != nil {
+		return nil, err
+	}
+	annotations, err := getAnnotations(snap)
+	if err != nil {
+		return nil, err
+	}
+	annotation.Control = ""
+	if annotation.Finding != "" {
+		control, ok := findingControl(snap, annotation.Finding)
+		if !ok {
+			return nil, fmt.Errorf("snapshot '%s' has no finding with fingerprint '%s'", path, annotation.Finding)
+		}
+		annotation.Control = control
+	}
+	annotation.Id = newAnnotationId()
+	annotation.CreatedAt = time.Now().UTC()
+
+	if err := writeAnnotations(path, snap, append(annotations, annotation)); err != nil {
+		return nil, err
+	}
+	return annotation, nil
+}
+
+// RemoveAnnotation removes the annotation with the given id from the snapshot file, returning it
+func RemoveAnnotation(path string, id string) (*Annotation, error) {
+	annotationMut.Lock()
+	defer annotationMut.Unlock()
+
+	snap, err := readSnapshotMap(path)
+	if err != nil {
+		return nil, err
+	}
+	annotations, err := getAnnotations(snap)
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(annotations, func(a *Annotation) bool { return a.Id == id })
+	if idx == -1 {
+		return nil, fmt.Errorf("%w: snapshot '%s' has no annotation '%s'", ErrAnnotationNotFound, path, id)
+	}
+	removed := annotations[idx]
+	if err := writeAnnotations(path, snap, slices.Delete(annotations, idx, idx+1)); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// ApplyAnnotations shows the annotations of each finding of the snapshot in the annotations column of its control
+// results, so they are visible when the snapshot is re-rendered
+func ApplyAnnotations(snap map[string]any) error {
+	annotations, err := getAnnotations(snap)
+	if err != nil || len(annotations) == 0 {
+		return err
+	}
+	findingAnnotations := make(map[string][]string)
+	for _, a := range annotations {
+		if a.Finding != "" {
+			findingAnnotations[a.Finding] = append(findingAnnotations[a.Finding], a.Text)
+		}
+	}
+
+	panels, _ := snap["panels"].(map[string]any)
+	for _, p := range panels {
+		data, columns, rows := controlData(p)
+		if data == nil {
+			continue
+		}
+		annotated := false
+		for _, r := range rows {
+			row, _ := r.(map[string]any)
+			fingerprint, _ := row[columnFingerprint].(string)
+			if texts, ok := findingAnnotations[fingerprint]; ok {
+				row[ColumnAnnotations] = strings.Join(texts, "\n")
+				annotated = true
+			}
+		}
+		if annotated {
+			data["columns"] = append(columns, map[string]any{"name": ColumnAnnotations, "data_type": "TEXT"})
+		}
+	}
+	return nil
+}
+
+// findingControl returns the name of the control whose results include the finding with the given fingerprint
+func findingControl(snap map[string]any, fingerprint string) (string, bool) {
+	panels, _ := snap["panels"].(map[string]any)
+	for name, p := range panels {
+		_, _, rows := controlData(p)
+		for _, r := range rows {
+			row, _ := r.(map[string]any)
+			if row[columnFingerprint] == fingerprint {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// controlData returns the data, columns and rows of a control panel - the data is nil if the panel is not a control
+// with results
+func controlData(p any) (map[string]any, []any, []any) {
+	panel, _ := p.(map[string]any)
+	if panel["panel_type"] != "control" {
+		return nil, nil, nil
+	}
+	data, _ := panel["data"].(map[string]any)
+	if data == nil {
+		return nil, nil, nil
+	}
+	columns, _ := data["columns"].([]any)
+	rows, _ := data["rows"].([]any)
+	return data, columns, rows
+}
+
+func getAnnotations(snap map[string]any) ([]*Annotation, error) {
+	panels, ok := snap["panels"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("not a valid snapshot: it has no panels")
+	}
+	p, ok := panels[AnnotationsPanelName]
+	if !ok {
+		return nil, nil
+	}
+	// round trip the panel to decode it
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var panel Annotations
+	if err := json.Unmarshal(data, &panel); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot annotations: %w", err)
+	}
+	return panel.Annotations, nil
+}
+
+func writeAnnotations(path string, snap map[string]any, annotations []*Annotation) error {
+	panels := snap["panels"].(map[string]any)
+	if len(annotations) == 0 {
+		delete(panels, AnnotationsPanelName)
+	} else {
+		panels[AnnotationsPanelName] = &Annotations{
+			Name:        AnnotationsPanelName,
+			PanelType:   AnnotationsPanelType,
+			Annotations: annotations,
+		}
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file and rename it, so the snapshot is not corrupted if the write fails
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		_ = os.Chmod(tmp.Name(), info.Mode())
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// readSnapshotMap reads the snapshot file as a map, so that it is rewritten without losing any properties
+func readSnapshotMap(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// use json.Number so the numbers of the snapshot are rewritten as they were read
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var snap map[string]any
+	if err := decoder.Decode(&snap); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot '%s': %w", path, err)
+	}
+	return snap, nil
+}
+
+func newAnnotationId() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
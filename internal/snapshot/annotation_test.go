@@ -0,0 +1,121 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testAnnotationSnapshot = `{"layout":{"name":"mod.benchmark.b","panel_type":"benchmark"},"panels":{` +
+	`"mod.benchmark.b":{"name":"mod.benchmark.b","panel_type":"benchmark","summary":{"status":{"alarm":1,"ok":1000000}}},` +
+	`"mod.control.c1":{"data":{"columns":[{"data_type":"TEXT","name":"resource"},{"data_type":"TEXT","name":"fingerprint"}],` +
+	`"rows":[{"fingerprint":"f1","resource":"bucket-a"},{"fingerprint":"f2","resource":"bucket-b"}]},"name":"mod.control.c1","panel_type":"control"}}}`
+
+type addAnnotationTest struct {
+	annotation *Annotation
+	// the control of the annotated finding
+	control string
+	err     string
+}
+
+var testCasesAddAnnotation = map[string]addAnnotationTest{
+	"snapshot": {
+		annotation: &Annotation{Text: "Triaged in the weekly review"},
+	},
+	"finding": {
+		annotation: &Annotation{Text: " Accepted risk - see JIRA-123 ", Finding: "f2"},
+		control:    "mod.control.c1",
+	},
+	"unknown finding": {
+		annotation: &Annotation{Text: "Accepted risk", Finding: "f3"},
+		err:        "has no finding with fingerprint 'f3'",
+	},
+	"no text": {
+		annotation: &Annotation{Text: "  "},
+		err:        "annotation text must not be empty",
+	},
+}
+
+func writeTestAnnotationSnapshot(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "test.pps")
+	if err := os.WriteFile(path, []byte(testAnnotationSnapshot), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAddAnnotation(t *testing.T) {
+	for name, test := range testCasesAddAnnotation {
+		path := writeTestAnnotationSnapshot(t)
+		res, err := AddAnnotation(path, test.annotation)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Test: '%s' FAILED : expected error containing '%s', got %v", name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		annotations, err := ReadAnnotations(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(annotations) != 1 || annotations[0].Id != res.Id || annotations[0].Text != strings.TrimSpace(test.annotation.Text) || annotations[0].Control != test.control {
+			t.Errorf("Test: '%s' FAILED : expected the annotation %+v to be saved, got %+v", name, res, annotations)
+		}
+	}
+}
+
+func TestAnnotationPreservesSnapshot(t *testing.T) {
+	path := writeTestAnnotationSnapshot(t)
+	annotation, err := AddAnnotation(path, &Annotation{Text: "Accepted risk", Finding: "f1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RemoveAnnotation(path, annotation.Id); err != nil {
+		t.Fatal(err)
+	}
+	// once the annotation is removed, the snapshot must be as it was (including its numbers)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != testAnnotationSnapshot {
+		t.Errorf("Test: 'preserve snapshot' FAILED : expected %s, got %s", testAnnotationSnapshot, data)
+	}
+	if _, err := RemoveAnnotation(path, annotation.Id); err == nil {
+		t.Errorf("Test: 'remove twice' FAILED : expected an error, got nil")
+	}
+}
+
+func TestApplyAnnotations(t *testing.T) {
+	path := writeTestAnnotationSnapshot(t)
+	for _, a := range []*Annotation{{Text: "Accepted risk", Finding: "f1"}, {Text: "JIRA-123", Finding: "f1"}, {Text: "Triaged"}} {
+		if _, err := AddAnnotation(path, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap map[string]any
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyAnnotations(snap); err != nil {
+		t.Fatal(err)
+	}
+
+	controlResults, _, _ := controlData(snap["panels"].(map[string]any)["mod.control.c1"])
+	res, _ := json.Marshal(controlResults)
+	expected := `{"columns":[{"data_type":"TEXT","name":"resource"},{"data_type":"TEXT","name":"fingerprint"},{"data_type":"TEXT","name":"annotations"}],` +
+		`"rows":[{"annotations":"Accepted risk\nJIRA-123","fingerprint":"f1","resource":"bucket-a"},{"fingerprint":"f2","resource":"bucket-b"}]}`
+	if string(res) != expected {
+		t.Errorf("Test: 'apply annotations' FAILED : expected %s, got %s", expected, res)
+	}
+}
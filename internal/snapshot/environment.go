@@ -0,0 +1,223 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/versionmap"
+	"github.com/turbot/powerpipe/internal/db_client"
+	localqueryresult "github.com/turbot/powerpipe/internal/queryresult"
+)
+
+// the environment of a run is recorded in its snapshot as an additional panel (which is not part of the layout),
+// so that any historical report can be reproduced and attributed
+const (
+	EnvironmentPanelName = "powerpipe.environment"
+	EnvironmentPanelType = "environment"
+
+	databaseVersionTimeout = 5 * time.Second
+	// the same placeholder used by url.URL.Redacted
+	redacted = "xxxxx"
+)
+
+// args whose values are redacted from the recorded CLI args
+var sensitiveArgPattern = regexp.MustCompile(`(?i)(secret|token|password|key)`)
+
+// the database version of each connection string - this is retrieved once per process
+var databaseVersions sync.Map
+
+// Environment is the environment a snapshot was generated in
+type Environment struct {
+	Name             string `json:"name"`
+	PanelType        string `json:"panel_type"`
+	PowerpipeVersion string `json:"powerpipe_version"`
+	// the installed dependency mods, from the workspace lock file
+	Mods     []EnvironmentMod     `json:"mods,omitempty"`
+	Database *EnvironmentDatabase `json:"database,omitempty"`
+	// the commit of the workspace, if it is a git repository
+	GitSha string `json:"git_sha,omitempty"`
+	// the CLI args, with sensitive values redacted
+	Args []string `json:"args"`
+}
+
+type EnvironmentMod struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Branch  string `json:"branch,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Commit  string `json:"commit,omitempty"`
+}
+
+type EnvironmentDatabase struct {
+	Backend string `json:"backend"`
+	Version string `json:"version,omitempty"`
+}
+
+// IsSnapshotPanel implements SnapshotPanel
+func (*Environment) IsSnapshotPanel() {}
+
+// CaptureEnvironment returns the environment of a run of the workspace in the given directory, using the given database client
+// failure to retrieve any part of the environment is logged and does not fail the run
+func CaptureEnvironment(ctx context.Context, workspacePath string, client *db_client.DbClient) *Environment {
+	env := &Environment{
+		Name:             EnvironmentPanelName,
+		PanelType:        EnvironmentPanelType,
+		PowerpipeVersion: app_specific.AppVersion.String(),
+		Args:             RedactArgs(os.Args[1:]),
+	}
+
+	if mods, err := lockedMods(workspacePath); err != nil {
+		slog.Debug("could not load the workspace lock for the snapshot environment", "error", err)
+	} else {
+		env.Mods = mods
+	}
+
+	if repo, err := git.PlainOpenWithOptions(workspacePath, &git.PlainOpenOptions{DetectDotGit: true}); err == nil {
+		if head, err := repo.Head(); err == nil {
+			env.GitSha = head.Hash().String()
+		}
+	}
+
+	if client != nil {
+		env.Database = &EnvironmentDatabase{
+			Backend: client.Backend.Name(),
+			Version: databaseVersion(ctx, client),
+		}
+	}
+	return env
+}
+
+func lockedMods(workspacePath string) ([]EnvironmentMod, error) {
+	lock, err := versionmap.LoadWorkspaceLock(workspacePath)
+	if err != nil {
+		return nil, err
+	}
+	var res []EnvironmentMod
+	for _, dep := range lock.InstallCache.FlatMap() {
+		if dep == nil || dep.ResolvedVersionConstraint == nil {
+			continue
+		}
+		mod := EnvironmentMod{
+			Name:    dep.Name,
+			Version: dep.Tag,
+			Branch:  dep.Branch,
+			Path:    dep.FilePath,
+			Commit:  dep.Commit,
+		}
+		if mod.Version == "" && dep.Version != nil {
+			mod.Version = dep.Version.Original()
+		}
+		res = append(res, mod)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name || res[i].Name == res[j].Name && res[i].Version < res[j].Version
+	})
+	return res, nil
+}
+
+// databaseVersion returns the version of the database of the client
+func databaseVersion(ctx context.Context, client *db_client.DbClient) string {
+	connectionString := client.GetConnectionString()
+	if version, ok := databaseVersions.Load(connectionString); ok {
+		return version.(string)
+	}
+
+	query := "select version()"
+	if client.Backend.Name() == constants.SQLiteBackendName {
+		query = "select sqlite_version()"
+	}
+	ctx, cancel := context.WithTimeout(ctx, databaseVersionTimeout)
+	defer cancel()
+	result, err := client.ExecuteSync(ctx, query)
+	if err != nil {
+		slog.Debug("could not retrieve the database version for the snapshot environment", "error", err)
+		return ""
+	}
+	var version string
+	if len(result.Rows) > 0 {
+		if row, ok := result.Rows[0].(*localqueryresult.RowResult); ok && len(row.Data) > 0 {
+			version = fmt.Sprintf("%v", row.Data[0])
+		}
+	}
+	databaseVersions.Store(connectionString, version)
+	return version
+}
+
+// RedactArgs returns the args with the values of sensitive args (secrets, tokens, passwords and keys)
+// and the passwords of connection strings redacted
+func RedactArgs(args []string) []string {
+	res := make([]string, len(args))
+	redactNext := false
+	for i, arg := range args {
+		switch {
+		case redactNext:
+			res[i] = redacted
+			redactNext = false
+		case strings.HasPrefix(arg, "-") && sensitiveArgPattern.MatchString(arg):
+			// the value is either part of the arg (--arg=value) or the next arg
+			if name, _, ok := strings.Cut(arg, "="); ok {
+				res[i] = name + "=" + redacted
+			} else {
+				res[i] = arg
+				redactNext = true
+			}
+		default:
+			res[i] = redactConnectionString(redactAssignment(arg))
+		}
+	}
+	return res
+}
+
+// RedactValue returns the value of a config setting or variable, redacted if its name is sensitive
+// or it is a connection string containing a password
+func RedactValue(name, value string) string {
+	if sensitiveArgPattern.MatchString(name) {
+		return redacted
+	}
+	return redactConnectionString(value)
+}
+
+// redactAssignment redacts the value of a sensitive variable assignment, e.g. the value of '--var db_password=xxx'
+func redactAssignment(arg string) string {
+	if strings.HasPrefix(arg, "-") {
+		return arg
+	}
+	if name, _, ok := strings.Cut(arg, "="); ok && sensitiveArgPattern.MatchString(name) {
+		return name + "=" + redacted
+	}
+	return arg
+}
+
+// redactConnectionString redacts the password of a connection string (which may be the value of an arg, e.g. --database=<url>)
+func redactConnectionString(arg string) string {
+	prefix, value := "", arg
+	if strings.HasPrefix(arg, "-") {
+		name, v, ok := strings.Cut(arg, "=")
+		if !ok {
+			return arg
+		}
+		prefix, value = name+"=", v
+	}
+	if !strings.Contains(value, "://") {
+		return arg
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return arg
+	}
+	if _, hasPassword := u.User.Password(); !hasPassword {
+		return arg
+	}
+	return prefix + u.Redacted()
+}
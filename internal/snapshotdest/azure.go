@@ -0,0 +1,392 @@
+package snapshotdest
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+	"github.com/turbot/powerpipe/internal/upload"
+)
+
+// azblob://<container>[/<prefix>] writes snapshots to an Azure Blob Storage container - the storage account is set by
+// the account query parameter, or AZURE_STORAGE_ACCOUNT (or the account of AZURE_STORAGE_CONNECTION_STRING)
+// credentials are discovered in the order:
+//   - AZURE_STORAGE_CONNECTION_STRING: the account key or shared access signature of the connection string
+//   - AZURE_STORAGE_KEY: the account key
+//   - AZURE_STORAGE_SAS_TOKEN: a shared access signature
+//   - then, as the default credential chain of the Azure SDKs: a service principal secret (AZURE_TENANT_ID,
+//     AZURE_CLIENT_ID and AZURE_CLIENT_SECRET), workload identity (AZURE_TENANT_ID, AZURE_CLIENT_ID and
+//     AZURE_FEDERATED_TOKEN_FILE), the managed identity of the host, and the Azure CLI
+//
+// the url may also set the query parameter:
+//   - endpoint: the blob endpoint of the account, e.g. of the Azurite emulator (defaults to
+//     https://<account>.blob.core.windows.net)
+const (
+	envAzureStorageAccount     = "AZURE_STORAGE_ACCOUNT"
+	envAzureStorageKey         = "AZURE_STORAGE_KEY"
+	envAzureStorageSASToken    = "AZURE_STORAGE_SAS_TOKEN"
+	envAzureConnectionString   = "AZURE_STORAGE_CONNECTION_STRING"
+	envAzureTenantId           = "AZURE_TENANT_ID"
+	envAzureClientId           = "AZURE_CLIENT_ID"
+	envAzureClientSecret       = "AZURE_CLIENT_SECRET"
+	envAzureFederatedTokenFile = "AZURE_FEDERATED_TOKEN_FILE"
+	envAzureAuthorityHost      = "AZURE_AUTHORITY_HOST"
+	envIdentityEndpoint        = "IDENTITY_ENDPOINT"
+	envIdentityHeader          = "IDENTITY_HEADER"
+
+	azureStorageVersion  = "2021-08-06"
+	azureStorageResource = "https://storage.azure.com/"
+	defaultAuthorityHost = "https://login.microsoftonline.com"
+	// the managed identity endpoint of the instance metadata service
+	imdsTokenUrl = "http://169.254.169.254/metadata/identity/oauth2/token"
+	// the managed identity endpoint is only available on Azure hosts, so is probed with a short timeout
+	imdsProbeTimeout = 2 * time.Second
+)
+
+type azureDestination struct {
+	location  *url.URL
+	account   string
+	container string
+	endpoint  string
+	// the account key (decoded) or shared access signature, if set - otherwise a token is used
+	accountKey []byte
+	sasToken   string
+	// the client of requests to the storage service and token endpoints
+	client *http.Client
+}
+
+func newAzureDestination(location *url.URL) (Destination, error) {
+	d := &azureDestination{location: location, container: location.Host, client: tlspolicy.HTTPClient()}
+	query := location.Query()
+	d.account = query.Get("account")
+	d.endpoint = query.Get("endpoint")
+
+	if connectionString := os.Getenv(envAzureConnectionString); connectionString != "" {
+		if err := d.applyConnectionString(connectionString); err != nil {
+			return nil, err
+		}
+	}
+	if d.account == "" {
+		d.account = os.Getenv(envAzureStorageAccount)
+	}
+	if d.account == "" {
+		return nil, fmt.Errorf("invalid snapshot location '%s' - the storage account must be set with the account query parameter or %s", location, envAzureStorageAccount)
+	}
+	if d.accountKey == nil && d.sasToken == "" {
+		if key := os.Getenv(envAzureStorageKey); key != "" {
+			if err := d.setAccountKey(key); err != nil {
+				return nil, err
+			}
+		} else {
+			d.sasToken = strings.TrimPrefix(os.Getenv(envAzureStorageSASToken), "?")
+		}
+	}
+	if d.endpoint == "" {
+		d.endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", d.account)
+	}
+	if u, err := url.Parse(d.endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid snapshot location '%s' - invalid endpoint '%s'", location, d.endpoint)
+	}
+	d.endpoint = strings.TrimSuffix(d.endpoint, "/")
+	return d, nil
+}
+
+// applyConnectionString sets the account, credentials and endpoint of the connection string, unless set by the url
+func (d *azureDestination) applyConnectionString(connectionString string) error {
+	values := make(map[string]string)
+	for _, part := range strings.Split(connectionString, ";") {
+		if key, value, ok := strings.Cut(part, "="); ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	if d.account == "" {
+		d.account = values["AccountName"]
+	}
+	if d.endpoint == "" {
+		d.endpoint = values["BlobEndpoint"]
+	}
+	if key := values["AccountKey"]; key != "" {
+		return d.setAccountKey(key)
+	}
+	d.sasToken = strings.TrimPrefix(values["SharedAccessSignature"], "?")
+	return nil
+}
+
+func (d *azureDestination) setAccountKey(key string) error {
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("the Azure storage account key is not valid base64")
+	}
+	d.accountKey = decoded
+	return nil
+}
+
+func (d *azureDestination) Write(ctx context.Context, fileName string, data []byte) (string, error) {
+	key := objectKey(d.location, fileName)
+	blobUrl := fmt.Sprintf("%s/%s/%s", d.endpoint, url.PathEscape(d.container), escapeBlobName(key))
+	if err := d.upload(ctx, blobUrl, data); err != nil {
+		return "", fmt.Errorf("failed to upload snapshot to %s: %w", blobUrl, err)
+	}
+	return blobUrl, nil
+}
+
+// upload uploads the data as a block blob
+func (d *azureDestination) upload(ctx context.Context, blobUrl string, data []byte) error {
+	requestUrl := blobUrl
+	if d.sasToken != "" {
+		requestUrl += "?" + d.sasToken
+	}
+	req, err := upload.NewRequest(ctx, http.MethodPut, requestUrl, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	req.Header.Set("x-ms-version", azureStorageVersion)
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+
+	switch {
+	case d.accountKey != nil:
+		req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", d.account, sharedKeySignature(req, d.account, d.accountKey)))
+	case d.sasToken == "":
+		token, err := azureToken(ctx, d.client)
+		if err != nil {
+			return fmt.Errorf("failed to load credentials: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
+
+// sharedKeySignature returns the shared key signature of the request
+// (see https://learn.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key)
+func sharedKeySignature(req *http.Request, account string, key []byte) string {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+	var msHeaders []string
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower)
+		}
+	}
+	sort.Strings(msHeaders)
+	var canonicalHeaders strings.Builder
+	for _, name := range msHeaders {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, strings.TrimSpace(req.Header.Get(name)))
+	}
+
+	canonicalResource := "/" + account + req.URL.EscapedPath()
+	query := req.URL.Query()
+	var params []string
+	for name := range query {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	for _, name := range params {
+		values := query[name]
+		sort.Strings(values)
+		canonicalResource += fmt.Sprintf("\n%s:%s", strings.ToLower(name), strings.Join(values, ","))
+	}
+
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // date (x-ms-date is set)
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		canonicalHeaders.String() + canonicalResource,
+	}, "\n")
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// escapeBlobName escapes each segment of the blob name
+func escapeBlobName(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// azureTokenSources are the sources of an access token for the storage service, in order - each returns an empty
+// token if it is not configured
+var azureTokenSources = []func(ctx context.Context, client *http.Client) (string, error){
+	clientSecretToken,
+	workloadIdentityToken,
+	managedIdentityToken,
+	azureCLIToken,
+}
+
+// azureToken returns an access token for the storage service from the first configured token source
+func azureToken(ctx context.Context, client *http.Client) (string, error) {
+	var errs []string
+	for _, source := range azureTokenSources {
+		token, err := source(ctx, client)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if token != "" {
+			return token, nil
+		}
+	}
+	if len(errs) > 0 {
+		return "", fmt.Errorf("no Azure credentials found: %s", strings.Join(errs, "; "))
+	}
+	return "", fmt.Errorf("no Azure credentials found - set %s, %s or %s, or log in with the Azure CLI", envAzureStorageKey, envAzureStorageSASToken, envAzureClientSecret)
+}
+
+// azureTokenResponse is the response of the Microsoft identity platform and managed identity endpoints
+type azureTokenResponse struct {
+	AccessToken string `json:"access_token"`
+}
+
+func authorityTokenUrl(tenantId string) string {
+	authorityHost := os.Getenv(envAzureAuthorityHost)
+	if authorityHost == "" {
+		authorityHost = defaultAuthorityHost
+	}
+	return fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimSuffix(authorityHost, "/"), url.PathEscape(tenantId))
+}
+
+// clientSecretToken returns a token for the service principal of AZURE_CLIENT_SECRET
+func clientSecretToken(ctx context.Context, client *http.Client) (string, error) {
+	tenantId, clientId, secret := os.Getenv(envAzureTenantId), os.Getenv(envAzureClientId), os.Getenv(envAzureClientSecret)
+	if tenantId == "" || clientId == "" || secret == "" {
+		return "", nil
+	}
+	return requestClientToken(ctx, client, tenantId, url.Values{
+		"client_id":     {clientId},
+		"client_secret": {secret},
+	})
+}
+
+// workloadIdentityToken returns a token for the workload identity of AZURE_FEDERATED_TOKEN_FILE
+func workloadIdentityToken(ctx context.Context, client *http.Client) (string, error) {
+	tenantId, clientId, tokenFile := os.Getenv(envAzureTenantId), os.Getenv(envAzureClientId), os.Getenv(envAzureFederatedTokenFile)
+	if tenantId == "" || clientId == "" || tokenFile == "" {
+		return "", nil
+	}
+	assertion, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the federated token file: %w", err)
+	}
+	return requestClientToken(ctx, client, tenantId, url.Values{
+		"client_id":             {clientId},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {strings.TrimSpace(string(assertion))},
+	})
+}
+
+// requestClientToken requests a token for the storage service with the client credentials grant
+func requestClientToken(ctx context.Context, client *http.Client, tenantId string, values url.Values) (string, error) {
+	values.Set("grant_type", "client_credentials")
+	values.Set("scope", azureStorageResource+".default")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, authorityTokenUrl(tenantId), strings.NewReader(values.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doTokenRequest(client, req)
+}
+
+// managedIdentityToken returns a token for the managed identity of the host (an empty token is returned if the host
+// has no managed identity endpoint)
+func managedIdentityToken(ctx context.Context, client *http.Client) (string, error) {
+	query := url.Values{"resource": {azureStorageResource}}
+	if clientId := os.Getenv(envAzureClientId); clientId != "" {
+		query.Set("client_id", clientId)
+	}
+	// App Service and Functions set the endpoint of their managed identity
+	if endpoint, header := os.Getenv(envIdentityEndpoint), os.Getenv(envIdentityHeader); endpoint != "" && header != "" {
+		query.Set("api-version", "2019-08-01")
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("X-IDENTITY-HEADER", header)
+		return doTokenRequest(client, req)
+	}
+
+	query.Set("api-version", "2018-02-01")
+	probeCtx, cancel := context.WithTimeout(ctx, imdsProbeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(probeCtx, http.MethodGet, imdsTokenUrl+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata", "true")
+	token, err := doTokenRequest(client, req)
+	if err != nil && probeCtx.Err() != nil {
+		// not an Azure host
+		return "", nil
+	}
+	return token, err
+}
+
+// azureCLIToken returns a token of the user logged in with the Azure CLI (an empty token is returned if the CLI is
+// not installed)
+func azureCLIToken(ctx context.Context, _ *http.Client) (string, error) {
+	if _, err := exec.LookPath("az"); err != nil {
+		return "", nil
+	}
+	output, err := exec.CommandContext(ctx, "az", "account", "get-access-token", "--resource", azureStorageResource, "--output", "json").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get a token from the Azure CLI - run 'az login': %w", err)
+	}
+	var res struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if err := json.Unmarshal(output, &res); err != nil {
+		return "", fmt.Errorf("failed to parse the Azure CLI token: %w", err)
+	}
+	return res.AccessToken, nil
+}
+
+func doTokenRequest(client *http.Client, req *http.Request) (string, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return "", err
+	}
+	var res azureTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", err
+	}
+	if res.AccessToken == "" {
+		return "", fmt.Errorf("the token response of %s contains no access token", req.URL.Host)
+	}
+	return res.AccessToken, nil
+}
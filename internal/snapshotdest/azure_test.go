@@ -0,0 +1,121 @@
+package snapshotdest
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// the key of the Azurite emulator account
+const testAzureAccountKey = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+
+func clearAzureEnv(t *testing.T) {
+	for _, env := range []string{envAzureStorageAccount, envAzureStorageKey, envAzureStorageSASToken, envAzureConnectionString,
+		envAzureTenantId, envAzureClientId, envAzureClientSecret, envAzureFederatedTokenFile, envAzureAuthorityHost} {
+		t.Setenv(env, "")
+	}
+}
+
+func TestAzureDestinationSharedKey(t *testing.T) {
+	clearAzureEnv(t)
+	key, _ := base64.StdEncoding.DecodeString(testAzureAccountKey)
+	server := newTestStorageServer(t, func(w http.ResponseWriter, r *http.Request) {
+		// the server computes the signature of the request it received
+		r.Body = http.NoBody
+		expected := fmt.Sprintf("SharedKey devstoreaccount1:%s", sharedKeySignature(r, "devstoreaccount1", key))
+		if r.Header.Get("Authorization") != expected {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	t.Setenv(envAzureConnectionString, fmt.Sprintf("DefaultEndpointsProtocol=http;AccountName=devstoreaccount1;AccountKey=%s;BlobEndpoint=%s/devstoreaccount1;", testAzureAccountKey, server.URL))
+
+	destination, err := New("azblob://snapshots/compliance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := destination.Write(context.Background(), "a b.pps", []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := server.URL + "/devstoreaccount1/snapshots/compliance/a%20b.pps"
+	if res != expected {
+		t.Errorf("Test: 'azure location' FAILED : expected %s, got %s", expected, res)
+	}
+	req := server.requests[0]
+	if req.Method != http.MethodPut || req.Header.Get("x-ms-blob-type") != "BlockBlob" || server.bodies[0] != "{}" {
+		t.Errorf("Test: 'azure upload' FAILED : expected a PUT of a block blob, got %s %s", req.Method, req.Header.Get("x-ms-blob-type"))
+	}
+}
+
+func TestAzureDestinationSASToken(t *testing.T) {
+	clearAzureEnv(t)
+	server := newTestStorageServer(t, nil)
+	t.Setenv(envAzureStorageSASToken, "?sv=2021-08-06&sig=abc")
+
+	destination, err := New("azblob://snapshots?account=acme&endpoint=" + server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := destination.Write(context.Background(), "a.pps", []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	req := server.requests[0]
+	if req.URL.Query().Get("sig") != "abc" || req.Header.Get("Authorization") != "" {
+		t.Errorf("Test: 'azure sas' FAILED : expected the request to be authorized by the sas token, got %s", req.URL)
+	}
+}
+
+func TestAzureDestinationClientSecret(t *testing.T) {
+	clearAzureEnv(t)
+	var authority *testStorageServer
+	authority = newTestStorageServer(t, func(w http.ResponseWriter, r *http.Request) {
+		form, _ := url.ParseQuery(authority.bodies[len(authority.bodies)-1])
+		if r.URL.Path != "/tenant/oauth2/v2.0/token" || form.Get("client_secret") != "secret" || form.Get("scope") != "https://storage.azure.com/.default" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"token"}`))
+	})
+	server := newTestStorageServer(t, nil)
+	t.Setenv(envAzureAuthorityHost, authority.URL)
+	t.Setenv(envAzureTenantId, "tenant")
+	t.Setenv(envAzureClientId, "client")
+	t.Setenv(envAzureClientSecret, "secret")
+
+	destination, err := New("azblob://snapshots?account=acme&endpoint=" + server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := destination.Write(context.Background(), "a.pps", []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	if auth := server.requests[0].Header.Get("Authorization"); auth != "Bearer token" {
+		t.Errorf("Test: 'azure client secret' FAILED : expected Bearer token, got %s", auth)
+	}
+}
+
+func TestSharedKeySignature(t *testing.T) {
+	// the string to sign of a request is fixed, so its signature may be checked against a known value
+	key, _ := base64.StdEncoding.DecodeString(testAzureAccountKey)
+	req, _ := http.NewRequest(http.MethodPut, "http://127.0.0.1:10000/devstoreaccount1/snapshots/a.pps?comp=block&blockid=1", bytes.NewReader([]byte("{}")))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-ms-version", azureStorageVersion)
+	req.Header.Set("x-ms-date", "Fri, 16 Oct 2026 12:00:00 GMT")
+
+	actual := sharedKeySignature(req, "devstoreaccount1", key)
+	// changing any signed part of the request changes the signature
+	req.Header.Set("x-ms-date", "Fri, 16 Oct 2026 12:00:01 GMT")
+	if sharedKeySignature(req, "devstoreaccount1", key) == actual {
+		t.Errorf("Test: 'shared key signature' FAILED : expected the signature to include the x-ms headers")
+	}
+	if strings.TrimSpace(actual) == "" {
+		t.Errorf("Test: 'shared key signature' FAILED : expected a signature")
+	}
+}
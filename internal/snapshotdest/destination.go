@@ -0,0 +1,213 @@
+package snapshotdest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/cloud"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/export"
+	"github.com/turbot/pipe-fittings/steampipeconfig"
+	"github.com/turbot/powerpipe/internal/eventbus"
+	"github.com/turbot/powerpipe/internal/upload"
+)
+
+// in addition to a local directory or a Turbot Pipes workspace, the snapshot location may be the url of a cloud
+// storage bucket, e.g.
+//
+//	powerpipe benchmark run cis_v300 --snapshot --snapshot-location s3://acme-compliance/snapshots
+//	powerpipe dashboard run s3_bucket_report --snapshot --snapshot-location gs://acme-compliance/snapshots
+//	powerpipe benchmark run cis_v300 --snapshot --snapshot-location azblob://snapshots/compliance?account=acme
+//
+// the snapshot is written to the bucket as <prefix>/<file name>, with the same file name as a snapshot saved to a
+// local directory - credentials are discovered using the standard chain of each cloud (see the destination of each
+// scheme), and the destination may be configured with url query parameters
+// further destinations may be added with Register
+const (
+	SchemeS3    = "s3"
+	SchemeGCS   = "gs"
+	SchemeAzure = "azblob"
+)
+
+// Destination is a location snapshots may be written to
+type Destination interface {
+	// Write writes the snapshot data as the file with the given name, returning the url of the written snapshot
+	Write(ctx context.Context, fileName string, data []byte) (string, error)
+}
+
+// Factory returns the destination of a snapshot location url - an error is returned if the url is invalid
+type Factory func(location *url.URL) (Destination, error)
+
+var (
+	factoriesLock sync.RWMutex
+	factories     = map[string]Factory{
+		SchemeS3:    newS3Destination,
+		SchemeGCS:   newGCSDestination,
+		SchemeAzure: newAzureDestination,
+	}
+)
+
+// Register adds (or replaces) the destination of snapshot locations with the given url scheme
+func Register(scheme string, factory Factory) {
+	factoriesLock.Lock()
+	defer factoriesLock.Unlock()
+	factories[strings.ToLower(scheme)] = factory
+}
+
+// Schemes returns the url schemes of the registered destinations, sorted
+func Schemes() []string {
+	factoriesLock.RLock()
+	defer factoriesLock.RUnlock()
+	res := make([]string, 0, len(factories))
+	for scheme := range factories {
+		res = append(res, scheme)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// IsDestination returns whether the snapshot location is the url of a registered destination
+func IsDestination(location string) bool {
+	return getFactory(location) != nil
+}
+
+func getFactory(location string) Factory {
+	scheme, _, ok := strings.Cut(location, "://")
+	if !ok {
+		return nil
+	}
+	factoriesLock.RLock()
+	defer factoriesLock.RUnlock()
+	return factories[strings.ToLower(scheme)]
+}
+
+// New returns the destination of the snapshot location url
+func New(location string) (Destination, error) {
+	factory := getFactory(location)
+	if factory == nil {
+		return nil, fmt.Errorf("snapshot location '%s' is not a supported url - the scheme must be one of: %s", location, strings.Join(Schemes(), ", "))
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot location '%s': %s", location, err.Error())
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid snapshot location '%s' - the bucket is missing", location)
+	}
+	return factory(u)
+}
+
+// PublishSnapshot writes the snapshot to the snapshot location - if the location is not the url of a destination
+// (i.e. it is a local directory or a Turbot Pipes workspace), the snapshot is published by cloud.PublishSnapshot
+func PublishSnapshot(ctx context.Context, snapshot *steampipeconfig.SteampipeSnapshot, share bool) (string, error) {
+	location := viper.GetString(constants.ArgSnapshotLocation)
+	if !IsDestination(location) {
+		return cloud.PublishSnapshot(ctx, snapshot, share)
+	}
+	res, err := WriteSnapshot(ctx, location, snapshot)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("\nSnapshot uploaded to %s\n", res), nil
+}
+
+// SnapshotUpload returns the upload of the snapshot to the snapshot location as PublishSnapshot, for an upload
+// pipeline (so the bandwidth limit of the pipeline applies) - the upload returns the url or path of the written snapshot
+//
+// only uploads to a destination url are retried - each attempt writes the same object, so retries are idempotent
+// - publishing to Turbot Pipes creates a new snapshot on each attempt, so its errors are permanent
+func SnapshotUpload(snapshot *steampipeconfig.SteampipeSnapshot, share bool) func(ctx context.Context) (string, error) {
+	location := viper.GetString(constants.ArgSnapshotLocation)
+	if IsDestination(location) {
+		fileName := snapshotFileName(snapshot)
+		return func(ctx context.Context) (string, error) {
+			return writeSnapshot(ctx, location, fileName, snapshot)
+		}
+	}
+	return func(ctx context.Context) (string, error) {
+		res, err := publishSnapshot(ctx, location, snapshot, share)
+		return res, upload.Permanent(err)
+	}
+}
+
+func publishSnapshot(ctx context.Context, location string, snapshot *steampipeconfig.SteampipeSnapshot, share bool) (string, error) {
+	if steampipeconfig.IsCloudWorkspaceIdentifier(location) {
+		// the request of cloud.PublishSnapshot cannot be limited, so the bandwidth of the snapshot is reserved first
+		data, err := snapshot.AsStrippedJson(false)
+		if err != nil {
+			return "", err
+		}
+		if err := upload.Wait(ctx, len(data)); err != nil {
+			return "", err
+		}
+	}
+	message, err := cloud.PublishSnapshot(ctx, snapshot, share)
+	if err != nil {
+		if err.Error() == "402 Payment Required" {
+			return "", fmt.Errorf("maximum number of snapshots reached")
+		}
+		return "", err
+	}
+	// (the message is of the form 'Snapshot uploaded to <url>' or 'Snapshot saved to <path>')
+	res := strings.TrimSpace(message)
+	for _, prefix := range []string{"Snapshot uploaded to ", "Snapshot saved to "} {
+		res = strings.TrimPrefix(res, prefix)
+	}
+	return res, nil
+}
+
+// WriteSnapshot writes the snapshot to the location - the url of a destination or a local directory - returning the
+// url or path of the written snapshot
+func WriteSnapshot(ctx context.Context, location string, snapshot *steampipeconfig.SteampipeSnapshot) (string, error) {
+	return writeSnapshot(ctx, location, snapshotFileName(snapshot), snapshot)
+}
+
+func snapshotFileName(snapshot *steampipeconfig.SteampipeSnapshot) string {
+	return export.GenerateDefaultExportFileName(snapshot.FileNameRoot, constants.SnapshotExtension)
+}
+
+func writeSnapshot(ctx context.Context, location, fileName string, snapshot *steampipeconfig.SteampipeSnapshot) (string, error) {
+	data, err := snapshot.AsStrippedJson(false)
+	if err != nil {
+		return "", err
+	}
+	data = append(data, '\n')
+
+	var res string
+	if IsDestination(location) {
+		destination, err := New(location)
+		if err != nil {
+			return "", err
+		}
+		if res, err = destination.Write(ctx, fileName, data); err != nil {
+			return "", err
+		}
+	} else {
+		if err := os.MkdirAll(location, 0755); err != nil {
+			return "", fmt.Errorf("failed to create snapshot directory %s: %w", location, err)
+		}
+		res = filepath.Join(location, fileName)
+		if err := os.WriteFile(res, data, 0644); err != nil {
+			return "", fmt.Errorf("failed to write snapshot %s: %w", res, err)
+		}
+	}
+	eventbus.Publish(eventbus.NewEvent(eventbus.EventSnapshotWritten, res, nil))
+	return res, nil
+}
+
+// objectKey returns the key of the file within the prefix of the location url
+func objectKey(location *url.URL, fileName string) string {
+	prefix := strings.Trim(location.Path, "/")
+	if prefix == "" {
+		return fileName
+	}
+	return path.Join(prefix, fileName)
+}
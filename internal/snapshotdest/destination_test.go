@@ -0,0 +1,267 @@
+package snapshotdest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/steampipeconfig"
+)
+
+type newDestinationTest struct {
+	location string
+	env      map[string]string
+	expected string
+}
+
+var testCasesNewDestination = map[string]newDestinationTest{
+	"s3": {
+		location: "s3://acme-compliance/snapshots?region=eu-west-1",
+		expected: "s3",
+	},
+	"s3 endpoint": {
+		location: "s3://acme-compliance?endpoint=http://localhost:9000&path_style=true",
+		expected: "s3",
+	},
+	"s3 invalid endpoint": {
+		location: "s3://acme-compliance?endpoint=localhost:9000",
+		expected: "ERROR",
+	},
+	"s3 invalid path style": {
+		location: "s3://acme-compliance?path_style=maybe",
+		expected: "ERROR",
+	},
+	"gcs": {
+		location: "gs://acme-compliance/snapshots",
+		expected: "gs",
+	},
+	"azure": {
+		location: "azblob://snapshots/compliance?account=acme",
+		expected: "azblob",
+	},
+	"azure account from env": {
+		location: "azblob://snapshots",
+		env:      map[string]string{envAzureStorageAccount: "acme"},
+		expected: "azblob",
+	},
+	"azure no account": {
+		location: "azblob://snapshots",
+		expected: "ERROR",
+	},
+	"azure invalid key": {
+		location: "azblob://snapshots?account=acme",
+		env:      map[string]string{envAzureStorageKey: "not base64!"},
+		expected: "ERROR",
+	},
+	"no bucket": {
+		location: "s3:///snapshots",
+		expected: "ERROR",
+	},
+	"unsupported scheme": {
+		location: "ftp://acme/snapshots",
+		expected: "ERROR",
+	},
+}
+
+func TestNew(t *testing.T) {
+	for name, test := range testCasesNewDestination {
+		t.Run(name, func(t *testing.T) {
+			for _, env := range []string{envAzureStorageAccount, envAzureStorageKey, envAzureConnectionString, envAzureStorageSASToken} {
+				t.Setenv(env, test.env[env])
+			}
+			destination, err := New(test.location)
+			if err != nil {
+				if test.expected != "ERROR" {
+					t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+				}
+				return
+			}
+			if test.expected == "ERROR" {
+				t.Errorf("Test: '%s' FAILED : expected an error", name)
+				return
+			}
+			var actual string
+			switch destination.(type) {
+			case *s3Destination:
+				actual = SchemeS3
+			case *gcsDestination:
+				actual = SchemeGCS
+			case *azureDestination:
+				actual = SchemeAzure
+			}
+			if actual != test.expected {
+				t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsDestination(t *testing.T) {
+	for location, expected := range map[string]bool{
+		"s3://acme/snapshots": true,
+		"GS://acme":           true,
+		"azblob://snapshots":  true,
+		"acme/prod":           false,
+		"/tmp/snapshots":      false,
+		"ftp://acme":          false,
+	} {
+		if actual := IsDestination(location); actual != expected {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", location, expected, actual)
+		}
+	}
+}
+
+type testDestination struct {
+	location string
+}
+
+func (d *testDestination) Write(_ context.Context, fileName string, _ []byte) (string, error) {
+	return d.location + "/" + fileName, nil
+}
+
+func TestRegister(t *testing.T) {
+	Register("mem", func(location *url.URL) (Destination, error) {
+		return &testDestination{location: location.String()}, nil
+	})
+	destination, err := New("mem://acme/snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := destination.Write(context.Background(), "a.pps", nil)
+	if err != nil || res != "mem://acme/snapshots/a.pps" {
+		t.Errorf("Test: 'register' FAILED : expected mem://acme/snapshots/a.pps, got %s (%v)", res, err)
+	}
+}
+
+func TestSnapshotUploadRetry(t *testing.T) {
+	var locations []string
+	Register("retry", func(location *url.URL) (Destination, error) {
+		return &testDestination{location: location.String()}, nil
+	})
+	viper.Set(constants.ArgSnapshotLocation, "retry://acme/snapshots")
+	defer viper.Set(constants.ArgSnapshotLocation, nil)
+
+	uploadFunc := SnapshotUpload(&steampipeconfig.SteampipeSnapshot{FileNameRoot: "aws_compliance.benchmark.cis_v300"}, false)
+	for i := 0; i < 2; i++ {
+		location, err := uploadFunc(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		locations = append(locations, location)
+		// (the file name includes the time, to the second)
+		time.Sleep(1100 * time.Millisecond)
+	}
+	// each attempt writes the same object, so retrying an upload does not create another snapshot
+	if locations[0] != locations[1] {
+		t.Errorf("Test: 'retry' FAILED : expected each attempt to write the same snapshot, got %s and %s", locations[0], locations[1])
+	}
+}
+
+func TestObjectKey(t *testing.T) {
+	for location, expected := range map[string]string{
+		"s3://acme":                 "a.pps",
+		"s3://acme/":                "a.pps",
+		"s3://acme/snapshots":       "snapshots/a.pps",
+		"s3://acme/snapshots/prod/": "snapshots/prod/a.pps",
+	} {
+		u, _ := url.Parse(location)
+		if actual := objectKey(u, "a.pps"); actual != expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", location, expected, actual)
+		}
+	}
+}
+
+// testStorageServer records the requests made to it
+type testStorageServer struct {
+	*httptest.Server
+	mut      sync.Mutex
+	requests []*http.Request
+	bodies   []string
+}
+
+func newTestStorageServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request)) *testStorageServer {
+	s := &testStorageServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mut.Lock()
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, string(body))
+		s.mut.Unlock()
+		if handler != nil {
+			handler(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestS3Destination(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	server := newTestStorageServer(t, nil)
+
+	destination, err := New("s3://acme/snapshots?region=eu-west-1&endpoint=" + server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := destination.Write(context.Background(), "a.pps", []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "s3://acme/snapshots/a.pps" {
+		t.Errorf("Test: 's3 location' FAILED : expected s3://acme/snapshots/a.pps, got %s", res)
+	}
+	req := server.requests[0]
+	if req.Method != http.MethodPut || req.URL.Path != "/acme/snapshots/a.pps" || server.bodies[0] != "{}" {
+		t.Errorf("Test: 's3 upload' FAILED : expected a PUT of /acme/snapshots/a.pps, got %s %s", req.Method, req.URL.Path)
+	}
+	if auth := req.Header.Get("Authorization"); !strings.Contains(auth, "Credential=AKIAEXAMPLE/") || !strings.Contains(auth, "/eu-west-1/s3/") {
+		t.Errorf("Test: 's3 signature' FAILED : expected a signature for the credentials and region, got %s", auth)
+	}
+}
+
+func TestGCSDestination(t *testing.T) {
+	server := newTestStorageServer(t, nil)
+	t.Setenv(envStorageEmulatorHost, strings.TrimPrefix(server.URL, "http://"))
+
+	destination, err := New("gs://acme/snapshots/prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := destination.Write(context.Background(), "a.pps", []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "gs://acme/snapshots/prod/a.pps" {
+		t.Errorf("Test: 'gcs location' FAILED : expected gs://acme/snapshots/prod/a.pps, got %s", res)
+	}
+	req := server.requests[0]
+	if req.URL.Path != "/upload/storage/v1/b/acme/o" || req.URL.Query().Get("name") != "snapshots/prod/a.pps" || req.URL.Query().Get("uploadType") != "media" {
+		t.Errorf("Test: 'gcs upload' FAILED : expected a media upload of snapshots/prod/a.pps, got %s", req.URL)
+	}
+}
+
+func TestGCSDestinationError(t *testing.T) {
+	server := newTestStorageServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("access denied"))
+	})
+	t.Setenv(envStorageEmulatorHost, server.URL)
+
+	destination, err := New("gs://acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = destination.Write(context.Background(), "a.pps", []byte("{}"))
+	if err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("Test: 'gcs error' FAILED : expected the response error, got %v", err)
+	}
+}
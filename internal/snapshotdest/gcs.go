@@ -0,0 +1,105 @@
+package snapshotdest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+	"github.com/turbot/powerpipe/internal/upload"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// gs://<bucket>[/<prefix>] writes snapshots to a Google Cloud Storage bucket, using the application default
+// credentials (GOOGLE_APPLICATION_CREDENTIALS, the gcloud user credentials, or the metadata server of the instance)
+// the url may set the query parameter:
+//   - endpoint: the endpoint of the storage service, e.g. an emulator (STORAGE_EMULATOR_HOST is also supported, in
+//     which case no credentials are used, as for the Google Cloud SDKs)
+const (
+	defaultGCSEndpoint = "https://storage.googleapis.com"
+	gcsScope           = "https://www.googleapis.com/auth/devstorage.read_write"
+	// envStorageEmulatorHost is the standard variable of the Google Cloud SDKs setting the host of a storage emulator
+	envStorageEmulatorHost = "STORAGE_EMULATOR_HOST"
+	maxErrorBodyBytes      = 1024
+)
+
+type gcsDestination struct {
+	location *url.URL
+	endpoint string
+	// whether to authenticate requests (false for an emulator)
+	authenticate bool
+	// the client of requests to the storage service and (when authenticating) the token endpoints
+	client *http.Client
+}
+
+func newGCSDestination(location *url.URL) (Destination, error) {
+	d := &gcsDestination{location: location, endpoint: defaultGCSEndpoint, authenticate: true, client: tlspolicy.HTTPClient()}
+	if emulator := os.Getenv(envStorageEmulatorHost); emulator != "" {
+		if !strings.Contains(emulator, "://") {
+			emulator = "http://" + emulator
+		}
+		d.endpoint = emulator
+		d.authenticate = false
+	}
+	if endpoint := location.Query().Get("endpoint"); endpoint != "" {
+		d.endpoint = endpoint
+	}
+	if u, err := url.Parse(d.endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid snapshot location '%s' - invalid endpoint '%s'", location, d.endpoint)
+	}
+	d.endpoint = strings.TrimSuffix(d.endpoint, "/")
+	return d, nil
+}
+
+func (d *gcsDestination) Write(ctx context.Context, fileName string, data []byte) (string, error) {
+	bucket := d.location.Host
+	key := objectKey(d.location, fileName)
+	res := fmt.Sprintf("gs://%s/%s", bucket, key)
+	if err := d.upload(ctx, bucket, key, data); err != nil {
+		return "", fmt.Errorf("failed to upload snapshot to %s: %w", res, err)
+	}
+	return res, nil
+}
+
+// upload uploads the object with a simple upload request of the JSON API
+func (d *gcsDestination) upload(ctx context.Context, bucket, key string, data []byte) error {
+	client := d.client
+	if d.authenticate {
+		var err error
+		// (the credentials use the client of the context, including to refresh tokens)
+		client, err = google.DefaultClient(context.WithValue(ctx, oauth2.HTTPClient, d.client), gcsScope)
+		if err != nil {
+			return fmt.Errorf("failed to load credentials: %w", err)
+		}
+	}
+	uploadUrl := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s", d.endpoint, url.PathEscape(bucket), url.QueryEscape(key))
+	req, err := upload.NewRequest(ctx, http.MethodPost, uploadUrl, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
+
+// checkResponse returns an error containing the status and (the start of) the body of an unsuccessful response
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	err := fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	if upload.PermanentStatus(resp.StatusCode) {
+		return upload.Permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package snapshotdest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/turbot/powerpipe/internal/publish"
+)
+
+// s3://<bucket>[/<prefix>] writes snapshots to an S3 bucket, using the default AWS credential chain (environment,
+// shared config and credentials files, SSO, web identity and the EC2/ECS instance roles)
+// the url may set the query parameters:
+//   - region: the region of the bucket (defaults to the region of the AWS config)
+//   - profile: the AWS profile to use
+//   - endpoint: the endpoint of an S3 compatible service, e.g. MinIO
+//   - path_style: whether the bucket is addressed in the path of the object urls (defaults to true if endpoint is set)
+const defaultS3Region = "us-east-1"
+
+type s3Destination struct {
+	location    *url.URL
+	integration *publish.Integration
+}
+
+func newS3Destination(location *url.URL) (Destination, error) {
+	query := location.Query()
+	integration := &publish.Integration{
+		Name:     location.Host,
+		Bucket:   location.Host,
+		Region:   query.Get("region"),
+		Profile:  query.Get("profile"),
+		Endpoint: query.Get("endpoint"),
+	}
+	if integration.Endpoint != "" {
+		if u, err := url.Parse(integration.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid snapshot location '%s' - invalid endpoint '%s'", location, integration.Endpoint)
+		}
+	}
+	if pathStyle := query.Get("path_style"); pathStyle != "" {
+		value, err := strconv.ParseBool(pathStyle)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot location '%s' - path_style must be true or false", location)
+		}
+		integration.PathStyle = &value
+	}
+	return &s3Destination{location: location, integration: integration}, nil
+}
+
+func (d *s3Destination) Write(ctx context.Context, fileName string, data []byte) (string, error) {
+	i := d.integration
+	if i.Region == "" {
+		i.Region = resolveS3Region(ctx, i.Profile)
+	}
+	key := objectKey(d.location, fileName)
+	if err := i.Upload(ctx, key, data); err != nil {
+		return "", fmt.Errorf("failed to upload snapshot to s3://%s/%s: %w", i.Bucket, key, err)
+	}
+	return fmt.Sprintf("s3://%s/%s", i.Bucket, key), nil
+}
+
+// resolveS3Region returns the region of the AWS config (e.g. AWS_REGION or the region of the profile)
+func resolveS3Region(ctx context.Context, profile string) string {
+	var opts []func(*config.LoadOptions) error
+	if profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(profile))
+	}
+	if cfg, err := config.LoadDefaultConfig(ctx, opts...); err == nil && cfg.Region != "" {
+		return cfg.Region
+	}
+	return defaultS3Region
+}
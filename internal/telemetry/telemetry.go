@@ -0,0 +1,317 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/filepaths"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/exitcodes"
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+)
+
+// telemetry is fully opt-in - nothing is recorded or sent unless a category has been enabled using
+// 'powerpipe telemetry enable', and nothing is sent unless a telemetry endpoint is configured. Powerpipe has no
+// default endpoint - the maintainers of a build set one at build time (Endpoint), or it is set using
+// POWERPIPE_TELEMETRY_ENDPOINT. Setting POWERPIPE_TELEMETRY=off or DO_NOT_TRACK=1 disables telemetry regardless.
+//
+// the categories are:
+//   - commands: the command which was run, the names (never the values) of the flags which were set, its duration
+//     and its exit code
+//   - errors: the class of error of a command which failed, derived from its exit code
+//
+// once a command completes, an Event containing the enabled categories is sent as a json POST to the endpoint.
+// The last event sent is saved, so exactly what is sent can be inspected using 'powerpipe telemetry status'
+const (
+	CategoryCommands = "commands"
+	CategoryErrors   = "errors"
+
+	settingsFileName = "telemetry.json"
+	sendTimeout      = 2 * time.Second
+)
+
+// Categories are the categories of telemetry which may be enabled
+var Categories = []string{CategoryCommands, CategoryErrors}
+
+// Endpoint is the default telemetry endpoint - it is set at build time, and is empty for powerpipe releases
+var Endpoint string
+
+// Event is the payload sent to the telemetry endpoint
+type Event struct {
+	// a random id, generated when telemetry is first enabled - it is not derived from the machine or the user
+	InstallationID   string    `json:"installation_id"`
+	PowerpipeVersion string    `json:"powerpipe_version"`
+	OS               string    `json:"os"`
+	Arch             string    `json:"arch"`
+	Timestamp        time.Time `json:"timestamp"`
+
+	// set if the commands category is enabled
+	Command    string   `json:"command,omitempty"`
+	Flags      []string `json:"flags,omitempty"`
+	DurationMs int64    `json:"duration_ms,omitempty"`
+	ExitCode   *int     `json:"exit_code,omitempty"`
+
+	// set if the errors category is enabled and the command failed
+	ErrorClass string `json:"error_class,omitempty"`
+}
+
+// Settings are the telemetry settings of the installation
+type Settings struct {
+	InstallationID string          `json:"installation_id,omitempty"`
+	Categories     map[string]bool `json:"categories"`
+	// the last event sent, and when
+	LastEvent *Event `json:"last_event,omitempty"`
+	path      string
+}
+
+// SettingsPath returns the path of the telemetry settings file in the internal directory
+func SettingsPath() string {
+	return filepath.Join(filepaths.EnsureInternalDir(), settingsFileName)
+}
+
+// LoadSettings loads the telemetry settings from the given path - if the file does not exist, all categories
+// are disabled
+func LoadSettings(path string) (*Settings, error) {
+	settings := &Settings{Categories: make(map[string]bool), path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, settings); err != nil {
+		return nil, fmt.Errorf("failed to parse telemetry settings '%s': %w", path, err)
+	}
+	if settings.Categories == nil {
+		settings.Categories = make(map[string]bool)
+	}
+	return settings, nil
+}
+
+// Save saves the settings to the path they were loaded from
+func (s *Settings) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0600)
+}
+
+// Enable enables the given categories, or all categories if none are given
+func (s *Settings) Enable(categories ...string) error {
+	return s.set(true, categories)
+}
+
+// Disable disables the given categories, or all categories if none are given
+// the installation id is discarded once all categories are disabled
+func (s *Settings) Disable(categories ...string) error {
+	if err := s.set(false, categories); err != nil {
+		return err
+	}
+	if !s.AnyEnabled() {
+		s.InstallationID = ""
+		s.LastEvent = nil
+	}
+	return nil
+}
+
+func (s *Settings) set(enabled bool, categories []string) error {
+	if len(categories) == 0 {
+		categories = Categories
+	}
+	for _, c := range categories {
+		if !isCategory(c) {
+			return fmt.Errorf("invalid telemetry category '%s' - must be one of: %s", c, strings.Join(Categories, ", "))
+		}
+	}
+	for _, c := range categories {
+		s.Categories[c] = enabled
+	}
+	if enabled && s.InstallationID == "" {
+		id, err := newInstallationID()
+		if err != nil {
+			return err
+		}
+		s.InstallationID = id
+	}
+	return nil
+}
+
+// Enabled returns whether the category is enabled
+func (s *Settings) Enabled(category string) bool {
+	return s.Categories[category]
+}
+
+// AnyEnabled returns whether any category is enabled
+func (s *Settings) AnyEnabled() bool {
+	for _, c := range Categories {
+		if s.Categories[c] {
+			return true
+		}
+	}
+	return false
+}
+
+func isCategory(category string) bool {
+	for _, c := range Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+func newInstallationID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// DisabledByEnvironment returns whether telemetry is disabled by POWERPIPE_TELEMETRY or DO_NOT_TRACK
+func DisabledByEnvironment() bool {
+	switch strings.ToLower(os.Getenv(localconstants.EnvTelemetry)) {
+	case "off", "false", "0", "disabled":
+		return true
+	}
+	doNotTrack := strings.ToLower(os.Getenv(localconstants.EnvDoNotTrack))
+	return doNotTrack != "" && doNotTrack != "0" && doNotTrack != "false"
+}
+
+// ConfiguredEndpoint returns the telemetry endpoint - POWERPIPE_TELEMETRY_ENDPOINT, or the endpoint set at build time
+func ConfiguredEndpoint() string {
+	if endpoint := os.Getenv(localconstants.EnvTelemetryEndpoint); endpoint != "" {
+		return endpoint
+	}
+	return Endpoint
+}
+
+// RecordCommand sends an event for a completed command, if telemetry is enabled
+// errors are logged, never returned - telemetry must not affect the command
+func RecordCommand(ctx context.Context, cmd *cobra.Command, exitCode int, duration time.Duration) {
+	// the install dir is only set once the command has been initialised
+	if app_specific.InstallDir == "" || cmd == nil || cmd.Hidden || DisabledByEnvironment() {
+		return
+	}
+	endpoint := ConfiguredEndpoint()
+	if endpoint == "" {
+		return
+	}
+	settings, err := LoadSettings(SettingsPath())
+	if err != nil {
+		slog.Debug("could not load telemetry settings", "error", err)
+		return
+	}
+	event := settings.newEvent(cmd, exitCode, duration)
+	if event == nil {
+		return
+	}
+	if err := send(ctx, tlspolicy.HTTPClient(), endpoint, event); err != nil {
+		slog.Debug("could not send telemetry", "error", err)
+		return
+	}
+	settings.LastEvent = event
+	if err := settings.Save(); err != nil {
+		slog.Debug("could not save telemetry settings", "error", err)
+	}
+}
+
+// newEvent returns the event for a completed command, containing the enabled categories
+// returns nil if there is nothing to send
+func (s *Settings) newEvent(cmd *cobra.Command, exitCode int, duration time.Duration) *Event {
+	commands := s.Enabled(CategoryCommands)
+	errorClass := ""
+	if s.Enabled(CategoryErrors) {
+		errorClass = ErrorClass(exitCode)
+	}
+	if !commands && errorClass == "" {
+		return nil
+	}
+
+	event := &Event{
+		InstallationID: s.InstallationID,
+		OS:             runtime.GOOS,
+		Arch:           runtime.GOARCH,
+		Timestamp:      time.Now().UTC(),
+		ErrorClass:     errorClass,
+	}
+	if app_specific.AppVersion != nil {
+		event.PowerpipeVersion = app_specific.AppVersion.String()
+	}
+	if commands {
+		event.Command = commandName(cmd)
+		event.Flags = flagNames(cmd)
+		event.DurationMs = duration.Milliseconds()
+		event.ExitCode = &exitCode
+	}
+	return event
+}
+
+// commandName returns the path of the command without the root command, e.g. 'benchmark run'
+func commandName(cmd *cobra.Command) string {
+	name := cmd.CommandPath()
+	if cmd.Root() != nil {
+		name = strings.TrimPrefix(strings.TrimPrefix(name, cmd.Root().Name()), " ")
+	}
+	return name
+}
+
+// flagNames returns the sorted names of the flags set for the command - flag values are never recorded
+func flagNames(cmd *cobra.Command) []string {
+	var res []string
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		res = append(res, f.Name)
+	})
+	sort.Strings(res)
+	return res
+}
+
+// ErrorClass returns the class of error of an exit code (see exitcodes.Registry) - empty if the command did not fail
+// NOTE: control alarms are a result, not an error, so have no error class
+func ErrorClass(exitCode int) string {
+	if exitCode == constants.ExitCodeSuccessful || exitCode == constants.ExitCodeControlsAlarm {
+		return ""
+	}
+	return exitcodes.Lookup(exitCode).Class
+}
+
+func send(ctx context.Context, client *http.Client, endpoint string, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", endpoint, resp.Status)
+	}
+	return nil
+}
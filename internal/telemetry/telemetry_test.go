@@ -0,0 +1,127 @@
+package telemetry
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type newEventTest struct {
+	categories []string
+	exitCode   int
+	// nil if no event is expected
+	expected *Event
+}
+
+func testCommand(t *testing.T) *cobra.Command {
+	root := &cobra.Command{Use: "powerpipe"}
+	benchmark := &cobra.Command{Use: "benchmark"}
+	run := &cobra.Command{Use: "run", Run: func(*cobra.Command, []string) {}}
+	run.Flags().String("output", "", "")
+	run.Flags().String("search-path", "", "")
+	run.Flags().Bool("timing", false, "")
+	root.AddCommand(benchmark)
+	benchmark.AddCommand(run)
+	if err := run.Flags().Parse([]string{"--timing", "--search-path=secret_schema"}); err != nil {
+		t.Fatal(err)
+	}
+	return run
+}
+
+func exitCode(code int) *int {
+	return &code
+}
+
+var testCasesNewEvent = map[string]newEventTest{
+	"commands": {
+		categories: []string{CategoryCommands},
+		exitCode:   1,
+		expected:   &Event{Command: "benchmark run", Flags: []string{"search-path", "timing"}, DurationMs: 1500, ExitCode: exitCode(1)},
+	},
+	"all categories with error": {
+		exitCode: 62,
+		expected: &Event{Command: "benchmark run", Flags: []string{"search-path", "timing"}, DurationMs: 1500, ExitCode: exitCode(62), ErrorClass: "mod_install"},
+	},
+	"errors with error": {
+		categories: []string{CategoryErrors},
+		exitCode:   254,
+		expected:   &Event{ErrorClass: "invalid_input"},
+	},
+	"errors with alarms": {
+		categories: []string{CategoryErrors},
+		exitCode:   1,
+	},
+}
+
+func TestNewEvent(t *testing.T) {
+	cmd := testCommand(t)
+	for name, test := range testCasesNewEvent {
+		settings, err := LoadSettings(filepath.Join(t.TempDir(), settingsFileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := settings.Enable(test.categories...); err != nil {
+			t.Fatal(err)
+		}
+		event := settings.newEvent(cmd, test.exitCode, 1500*time.Millisecond)
+		if test.expected == nil {
+			if event != nil {
+				t.Errorf("Test: '%s' FAILED : expected no event, got %+v", name, event)
+			}
+			continue
+		}
+		if event == nil {
+			t.Errorf("Test: '%s' FAILED : expected an event, got nil", name)
+			continue
+		}
+		if event.InstallationID != settings.InstallationID || event.InstallationID == "" {
+			t.Errorf("Test: '%s' FAILED : expected installation id '%s', got '%s'", name, settings.InstallationID, event.InstallationID)
+		}
+		// only compare the recorded fields
+		actual := &Event{Command: event.Command, Flags: event.Flags, DurationMs: event.DurationMs, ExitCode: event.ExitCode, ErrorClass: event.ErrorClass}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %+v, got %+v", name, test.expected, actual)
+		}
+	}
+}
+
+func TestSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFileName)
+	settings, err := LoadSettings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.AnyEnabled() {
+		t.Errorf("Test: 'default' FAILED : expected telemetry to be disabled by default")
+	}
+	if err := settings.Enable("usage"); err == nil {
+		t.Errorf("Test: 'invalid category' FAILED : expected error")
+	}
+	if err := settings.Enable(); err != nil {
+		t.Fatal(err)
+	}
+	if err := settings.Disable(CategoryCommands); err != nil {
+		t.Fatal(err)
+	}
+	if err := settings.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadSettings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Enabled(CategoryCommands) || !loaded.Enabled(CategoryErrors) || loaded.InstallationID != settings.InstallationID {
+		t.Errorf("Test: 'save and load' FAILED : expected only errors enabled with id '%s', got %+v", settings.InstallationID, loaded)
+	}
+
+	if err := loaded.Disable(); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.AnyEnabled() || loaded.InstallationID != "" {
+		t.Errorf("Test: 'disable all' FAILED : expected all disabled with no installation id, got %+v", loaded)
+	}
+}
@@ -0,0 +1,130 @@
+package terraform
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	_ "github.com/marcboeker/go-duckdb"
+)
+
+// the version of the tables of the database - this is part of the name of the cached database file, so changing the
+// tables invalidates the cache
+const schemaVersion = 1
+
+// JSON values are stored as JSON text, so the database may be built without the json extension - the json extension
+// is loaded by the DuckDB backend, so the values may be queried with the JSON functions and operators, e.g.
+// attributes->>'bucket'
+var createStatements = []string{
+	`CREATE TABLE metadata (kind VARCHAR, terraform_version VARCHAR, format_version VARCHAR)`,
+	`CREATE TABLE resources (address VARCHAR, module_address VARCHAR, mode VARCHAR, type VARCHAR, name VARCHAR, index VARCHAR, provider_name VARCHAR, attributes VARCHAR, sensitive_values VARCHAR)`,
+	`CREATE TABLE resource_changes (address VARCHAR, module_address VARCHAR, mode VARCHAR, type VARCHAR, name VARCHAR, index VARCHAR, provider_name VARCHAR, actions VARCHAR[], action_reason VARCHAR, before VARCHAR, after VARCHAR, after_unknown VARCHAR)`,
+	`CREATE TABLE outputs (name VARCHAR, value VARCHAR, sensitive BOOLEAN)`,
+}
+
+// databases are built once per input, even when several database clients load the same input concurrently
+var databaseLock sync.Mutex
+
+// Database returns the path of a DuckDB database file of the plan or state file at the given path, building it in
+// the cache directory if it has not already been built for the contents of the file
+func Database(ctx context.Context, inputPath, cacheDir string) (string, error) {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to load terraform input: %s", err.Error())
+	}
+	hash := sha256.Sum256(append(data, byte(schemaVersion)))
+	dbPath := filepath.Join(cacheDir, fmt.Sprintf("%s.duckdb", hex.EncodeToString(hash[:16])))
+
+	databaseLock.Lock()
+	defer databaseLock.Unlock()
+	if _, err := os.Stat(dbPath); err == nil {
+		return dbPath, nil
+	}
+
+	input, err := Parse(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to load terraform input %s: %s", inputPath, err.Error())
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", err
+	}
+	// build the database alongside, so an interrupted build is never used
+	tmpPath := dbPath + ".tmp"
+	_ = os.Remove(tmpPath)
+	if err := Write(ctx, tmpPath, input); err != nil {
+		_ = os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to load terraform input %s: %s", inputPath, err.Error())
+	}
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		return "", err
+	}
+	return dbPath, nil
+}
+
+// Write writes the input to a new DuckDB database file
+func Write(ctx context.Context, dbPath string, input *Input) error {
+	db, err := sql.Open("duckdb", dbPath)
+	if err != nil {
+		return err
+	}
+	// closing the database checkpoints it, so the file is complete
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after commit
+
+	for _, statement := range createStatements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO metadata VALUES (?, ?, ?)`, input.Kind, input.TerraformVersion, input.FormatVersion); err != nil {
+		return err
+	}
+	for _, r := range input.Resources {
+		_, err := tx.ExecContext(ctx, `INSERT INTO resources VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			r.Address, r.ModuleAddress, r.Mode, r.Type, r.Name, jsonValue(r.Index), r.ProviderName, jsonValue(r.Attributes), jsonValue(r.SensitiveValues))
+		if err != nil {
+			return err
+		}
+	}
+	for _, c := range input.ResourceChanges {
+		// (actions are single words, e.g. 'create')
+		var actions any
+		if len(c.Actions) > 0 {
+			actions = strings.Join(c.Actions, ",")
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO resource_changes VALUES (?, ?, ?, ?, ?, ?, ?, string_split(?, ','), ?, ?, ?, ?)`,
+			c.Address, c.ModuleAddress, c.Mode, c.Type, c.Name, jsonValue(c.Index), c.ProviderName, actions, c.ActionReason, jsonValue(c.Before), jsonValue(c.After), jsonValue(c.AfterUnknown))
+		if err != nil {
+			return err
+		}
+	}
+	for _, o := range input.Outputs {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO outputs VALUES (?, ?, ?)`, o.Name, jsonValue(o.Value), o.Sensitive); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return db.Close()
+}
+
+// jsonValue returns the JSON text of a value, or nil (inserted as NULL) if there is no value
+func jsonValue(raw json.RawMessage) any {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	return string(raw)
+}
@@ -0,0 +1,289 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// a Terraform plan or state file may be loaded as an input of a run using --terraform-input, so that controls can
+// evaluate proposed infrastructure before it is deployed, e.g.
+//
+//	terraform plan -out tfplan && terraform show -json tfplan > plan.json
+//	powerpipe benchmark run pre_deploy --database duckdb:///tmp/empty.duckdb --terraform-input plan.json
+//
+// the file is loaded into a DuckDB database file, which is attached to each DuckDB database of the run (as
+// 'terraform', unless an alias is given) - it has the tables:
+//
+//	resources        - the resources of the state, or the planned resources of a plan (the proposed state after apply)
+//	resource_changes - the changes of a plan: the actions (e.g. ['create'], ['delete', 'create']) and the values
+//	                   before and after each change
+//	outputs          - the outputs of the state or plan
+//	metadata         - the kind of the file ('plan' or 'state') and the versions of Terraform and of the file format
+//
+// the plan must be the JSON output of 'terraform show -json' - the state may be the JSON output of 'terraform show
+// -json', or the state file itself (terraform.tfstate)
+const (
+	KindPlan  = "plan"
+	KindState = "state"
+)
+
+// Input is the resources, changes and outputs of a Terraform plan or state file
+type Input struct {
+	Kind             string
+	TerraformVersion string
+	FormatVersion    string
+	Resources        []Resource
+	ResourceChanges  []ResourceChange
+	Outputs          []Output
+}
+
+// Resource is a resource (or data source) of a state, or a planned resource of a plan
+type Resource struct {
+	Address         string
+	ModuleAddress   string
+	Mode            string
+	Type            string
+	Name            string
+	Index           json.RawMessage
+	ProviderName    string
+	Attributes      json.RawMessage
+	SensitiveValues json.RawMessage
+}
+
+// ResourceChange is the planned change of a resource
+type ResourceChange struct {
+	Address       string
+	ModuleAddress string
+	Mode          string
+	Type          string
+	Name          string
+	Index         json.RawMessage
+	ProviderName  string
+	Actions       []string
+	ActionReason  string
+	Before        json.RawMessage
+	After         json.RawMessage
+	AfterUnknown  json.RawMessage
+}
+
+// Output is an output value - the value of a planned output is null if it is not known until apply
+type Output struct {
+	Name      string
+	Value     json.RawMessage
+	Sensitive bool
+}
+
+// the JSON output format of 'terraform show -json', for plans and states
+type jsonFile struct {
+	FormatVersion    string               `json:"format_version"`
+	TerraformVersion string               `json:"terraform_version"`
+	Values           *jsonValues          `json:"values"`
+	PlannedValues    *jsonValues          `json:"planned_values"`
+	ResourceChanges  []jsonResourceChange `json:"resource_changes"`
+
+	// the fields of a state file
+	Version   *int                  `json:"version"`
+	Resources []stateFileResource   `json:"resources"`
+	Outputs   map[string]jsonOutput `json:"outputs"`
+}
+
+type jsonValues struct {
+	Outputs    map[string]jsonOutput `json:"outputs"`
+	RootModule *jsonModule           `json:"root_module"`
+}
+
+type jsonModule struct {
+	Address      string         `json:"address"`
+	Resources    []jsonResource `json:"resources"`
+	ChildModules []jsonModule   `json:"child_modules"`
+}
+
+type jsonResource struct {
+	Address         string          `json:"address"`
+	Mode            string          `json:"mode"`
+	Type            string          `json:"type"`
+	Name            string          `json:"name"`
+	Index           json.RawMessage `json:"index"`
+	ProviderName    string          `json:"provider_name"`
+	Values          json.RawMessage `json:"values"`
+	SensitiveValues json.RawMessage `json:"sensitive_values"`
+}
+
+type jsonResourceChange struct {
+	Address       string          `json:"address"`
+	ModuleAddress string          `json:"module_address"`
+	Mode          string          `json:"mode"`
+	Type          string          `json:"type"`
+	Name          string          `json:"name"`
+	Index         json.RawMessage `json:"index"`
+	ProviderName  string          `json:"provider_name"`
+	ActionReason  string          `json:"action_reason"`
+	Change        struct {
+		Actions      []string        `json:"actions"`
+		Before       json.RawMessage `json:"before"`
+		After        json.RawMessage `json:"after"`
+		AfterUnknown json.RawMessage `json:"after_unknown"`
+	} `json:"change"`
+}
+
+type jsonOutput struct {
+	Value     json.RawMessage `json:"value"`
+	Sensitive bool            `json:"sensitive"`
+}
+
+// the resources of a state file (terraform.tfstate)
+type stateFileResource struct {
+	Module    string `json:"module"`
+	Mode      string `json:"mode"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Provider  string `json:"provider"`
+	Instances []struct {
+		IndexKey   json.RawMessage `json:"index_key"`
+		Attributes json.RawMessage `json:"attributes"`
+	} `json:"instances"`
+}
+
+// Parse parses a plan or state in the JSON output format of 'terraform show -json', or a state file
+func Parse(data []byte) (*Input, error) {
+	var f jsonFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+			return nil, fmt.Errorf("the file is not JSON - a plan must first be converted using 'terraform show -json'")
+		}
+		return nil, err
+	}
+
+	res := &Input{TerraformVersion: f.TerraformVersion, FormatVersion: f.FormatVersion}
+	switch {
+	case f.PlannedValues != nil || f.ResourceChanges != nil:
+		res.Kind = KindPlan
+		res.Resources, res.Outputs = valuesResources(f.PlannedValues), valuesOutputs(f.PlannedValues)
+		res.ResourceChanges = resourceChanges(f.ResourceChanges)
+	case f.Values != nil || (f.FormatVersion != "" && f.Version == nil):
+		// (the values of an empty state are omitted)
+		res.Kind = KindState
+		res.Resources, res.Outputs = valuesResources(f.Values), valuesOutputs(f.Values)
+	case f.Version != nil:
+		if *f.Version != 4 {
+			return nil, fmt.Errorf("unsupported state file version %d - the output of 'terraform show -json' may be loaded instead", *f.Version)
+		}
+		res.Kind = KindState
+		res.FormatVersion = fmt.Sprintf("%d", *f.Version)
+		res.Resources, res.Outputs = stateFileResources(f.Resources), outputs(f.Outputs)
+	default:
+		return nil, fmt.Errorf("the file is not a Terraform plan or state")
+	}
+	return res, nil
+}
+
+func valuesResources(values *jsonValues) []Resource {
+	if values == nil || values.RootModule == nil {
+		return nil
+	}
+	var res []Resource
+	var addModule func(m *jsonModule)
+	addModule = func(m *jsonModule) {
+		for _, r := range m.Resources {
+			res = append(res, Resource{
+				Address:         r.Address,
+				ModuleAddress:   m.Address,
+				Mode:            r.Mode,
+				Type:            r.Type,
+				Name:            r.Name,
+				Index:           r.Index,
+				ProviderName:    r.ProviderName,
+				Attributes:      r.Values,
+				SensitiveValues: r.SensitiveValues,
+			})
+		}
+		for i := range m.ChildModules {
+			addModule(&m.ChildModules[i])
+		}
+	}
+	addModule(values.RootModule)
+	return res
+}
+
+func valuesOutputs(values *jsonValues) []Output {
+	if values == nil {
+		return nil
+	}
+	return outputs(values.Outputs)
+}
+
+func outputs(jsonOutputs map[string]jsonOutput) []Output {
+	var res []Output
+	for name, o := range jsonOutputs {
+		res = append(res, Output{Name: name, Value: o.Value, Sensitive: o.Sensitive})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+func resourceChanges(changes []jsonResourceChange) []ResourceChange {
+	res := make([]ResourceChange, len(changes))
+	for i, c := range changes {
+		res[i] = ResourceChange{
+			Address:       c.Address,
+			ModuleAddress: c.ModuleAddress,
+			Mode:          c.Mode,
+			Type:          c.Type,
+			Name:          c.Name,
+			Index:         c.Index,
+			ProviderName:  c.ProviderName,
+			Actions:       c.Change.Actions,
+			ActionReason:  c.ActionReason,
+			Before:        c.Change.Before,
+			After:         c.Change.After,
+			AfterUnknown:  c.Change.AfterUnknown,
+		}
+	}
+	return res
+}
+
+// stateFileResources returns a resource for each instance of the resources of a state file, addressed as by
+// 'terraform show -json'
+func stateFileResources(resources []stateFileResource) []Resource {
+	var res []Resource
+	for _, r := range resources {
+		address := r.Type + "." + r.Name
+		if r.Mode == "data" {
+			address = "data." + address
+		}
+		if r.Module != "" {
+			address = r.Module + "." + address
+		}
+		for _, instance := range r.Instances {
+			instanceAddress := address
+			if len(instance.IndexKey) > 0 {
+				instanceAddress = fmt.Sprintf("%s[%s]", address, instance.IndexKey)
+			}
+			res = append(res, Resource{
+				Address:       instanceAddress,
+				ModuleAddress: r.Module,
+				Mode:          r.Mode,
+				Type:          r.Type,
+				Name:          r.Name,
+				Index:         instance.IndexKey,
+				ProviderName:  providerName(r.Provider),
+				Attributes:    instance.Attributes,
+			})
+		}
+	}
+	return res
+}
+
+// providerName returns the provider name of a state file provider address,
+// e.g. 'module.a.provider["registry.terraform.io/hashicorp/aws"].west' is 'registry.terraform.io/hashicorp/aws'
+func providerName(address string) string {
+	_, rest, ok := strings.Cut(address, `provider["`)
+	if !ok {
+		return address
+	}
+	name, _, _ := strings.Cut(rest, `"]`)
+	return name
+}
@@ -0,0 +1,198 @@
+package terraform
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type parseTest struct {
+	file string
+	data string
+	// each expected resource, in the form '<address> <module address> <provider name>: <attributes>'
+	expected        []string
+	expectedKind    string
+	expectedChanges []string
+	expectedOutputs []string
+	expectError     bool
+}
+
+var testCasesParse = map[string]parseTest{
+	"plan": {
+		file:         "plan.json",
+		expectedKind: KindPlan,
+		expected: []string{
+			`aws_s3_bucket.logs  registry.terraform.io/hashicorp/aws: {"bucket": "acme-logs", "force_destroy": false}`,
+			`module.network.aws_security_group.web[0] module.network registry.terraform.io/hashicorp/aws: {"ingress": [{"cidr_blocks": ["0.0.0.0/0"], "from_port": 22, "to_port": 22}]}`,
+		},
+		expectedChanges: []string{"aws_s3_bucket.logs [create]", "module.network.aws_security_group.web[0] [delete create]"},
+		expectedOutputs: []string{"bucket_arn <nil>", `region "us-east-1"`},
+	},
+	"state file": {
+		file:         "terraform.tfstate",
+		expectedKind: KindState,
+		expected: []string{
+			`data.aws_caller_identity.current  registry.terraform.io/hashicorp/aws: {"account_id": "123456789012"}`,
+			`module.storage.aws_s3_bucket.data["raw"] module.storage registry.terraform.io/hashicorp/aws: {"bucket": "acme-raw"}`,
+			`module.storage.aws_s3_bucket.data["curated"] module.storage registry.terraform.io/hashicorp/aws: {"bucket": "acme-curated"}`,
+		},
+		expectedOutputs: []string{`password "secret" (sensitive)`},
+	},
+	"state": {
+		data:         `{"format_version": "1.0", "terraform_version": "1.7.5", "values": {"root_module": {"resources": [{"address": "aws_vpc.main", "mode": "managed", "type": "aws_vpc", "name": "main", "provider_name": "registry.terraform.io/hashicorp/aws", "values": {"cidr_block": "10.0.0.0/16"}}]}}}`,
+		expectedKind: KindState,
+		expected:     []string{`aws_vpc.main  registry.terraform.io/hashicorp/aws: {"cidr_block": "10.0.0.0/16"}`},
+	},
+	"empty state": {
+		data:         `{"format_version": "1.0"}`,
+		expectedKind: KindState,
+	},
+	"binary plan": {
+		data:        "PK\x03\x04",
+		expectError: true,
+	},
+	"old state file": {
+		data:        `{"version": 3, "modules": []}`,
+		expectError: true,
+	},
+	"not terraform": {
+		data:        `{"name": "acme"}`,
+		expectError: true,
+	},
+}
+
+func TestParse(t *testing.T) {
+	for name, test := range testCasesParse {
+		data := []byte(test.data)
+		if test.file != "" {
+			var err error
+			if data, err = os.ReadFile(filepath.Join("testdata", test.file)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		input, err := Parse(data)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error, got none", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %v", name, err)
+			continue
+		}
+		if input.Kind != test.expectedKind {
+			t.Errorf("Test: '%s' FAILED : expected kind %s, got %s", name, test.expectedKind, input.Kind)
+		}
+
+		var resources, changes, outputs []string
+		for _, r := range input.Resources {
+			resources = append(resources, fmt.Sprintf("%s %s %s: %s", r.Address, r.ModuleAddress, r.ProviderName, compact(string(r.Attributes))))
+		}
+		for _, c := range input.ResourceChanges {
+			changes = append(changes, fmt.Sprintf("%s %v", c.Address, c.Actions))
+		}
+		for _, o := range input.Outputs {
+			output := fmt.Sprintf("%s %v", o.Name, jsonValue(o.Value))
+			if o.Sensitive {
+				output += " (sensitive)"
+			}
+			outputs = append(outputs, output)
+		}
+		if !reflect.DeepEqual(resources, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected resources\n%s\ngot\n%s", name, strings.Join(test.expected, "\n"), strings.Join(resources, "\n"))
+		}
+		if !reflect.DeepEqual(changes, test.expectedChanges) {
+			t.Errorf("Test: '%s' FAILED : expected changes %v, got %v", name, test.expectedChanges, changes)
+		}
+		if !reflect.DeepEqual(outputs, test.expectedOutputs) {
+			t.Errorf("Test: '%s' FAILED : expected outputs %v, got %v", name, test.expectedOutputs, outputs)
+		}
+	}
+}
+
+type databaseTest struct {
+	query    string
+	expected string
+}
+
+var testCasesDatabase = map[string]databaseTest{
+	"metadata": {
+		query:    "select kind || ' ' || terraform_version from metadata",
+		expected: "plan 1.7.5",
+	},
+	"planned resources": {
+		query:    "select string_agg(address || ' ' || coalesce(index, ''), ', ' order by address) from resources where mode = 'managed'",
+		expected: "aws_s3_bucket.logs , module.network.aws_security_group.web[0] 0",
+	},
+	"attributes": {
+		query:    "select attributes from resources where type = 'aws_s3_bucket'",
+		expected: `{"bucket": "acme-logs", "force_destroy": false}`,
+	},
+	"replaced resources": {
+		query:    "select address || ' ' || action_reason from resource_changes where list_contains(actions, 'delete')",
+		expected: "module.network.aws_security_group.web[0] replace_because_cannot_update",
+	},
+	"created resources": {
+		query:    "select address || ' ' || (before is null) || ' ' || after_unknown from resource_changes where actions = ['create']",
+		expected: `aws_s3_bucket.logs true {"arn": true}`,
+	},
+	"unknown outputs": {
+		query:    "select name from outputs where value is null",
+		expected: "bucket_arn",
+	},
+}
+
+func TestDatabase(t *testing.T) {
+	cacheDir := t.TempDir()
+	inputPath := filepath.Join("testdata", "plan.json")
+	dbPath, err := Database(context.Background(), inputPath, cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the database is cached by the contents of the input
+	if cached, err := Database(context.Background(), inputPath, cacheDir); err != nil || cached != dbPath {
+		t.Errorf("Test: 'cached' FAILED : expected %s, got %s %v", dbPath, cached, err)
+	}
+
+	db, err := sql.Open("duckdb", dbPath+"?access_mode=read_only")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for name, test := range testCasesDatabase {
+		var actual string
+		if err := db.QueryRow(test.query).Scan(&actual); err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %v", name, err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, actual)
+		}
+	}
+}
+
+func TestDatabaseError(t *testing.T) {
+	cacheDir := t.TempDir()
+	inputPath := filepath.Join(t.TempDir(), "tfplan")
+	if err := os.WriteFile(inputPath, []byte("PK\x03\x04"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Database(context.Background(), inputPath, cacheDir); err == nil {
+		t.Errorf("Test: 'binary plan' FAILED : expected error, got none")
+	}
+	if entries, _ := os.ReadDir(cacheDir); len(entries) != 0 {
+		t.Errorf("Test: 'binary plan' FAILED : expected no database to be cached, got %d files", len(entries))
+	}
+}
+
+// compact formats JSON with a space after each separator, so the expected attributes are readable
+func compact(s string) string {
+	s = strings.ReplaceAll(s, " ", "")
+	return strings.NewReplacer(",", ", ", ":", ": ").Replace(s)
+}
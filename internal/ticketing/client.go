@@ -0,0 +1,71 @@
+package ticketing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/tlspolicy"
+)
+
+const requestTimeout = 30 * time.Second
+
+// client makes authenticated JSON requests to the API of a ticketing system
+type client struct {
+	baseUrl    string
+	username   string
+	token      string
+	httpClient *http.Client
+}
+
+func newClient(integration *Integration) *client {
+	return &client{
+		baseUrl:    strings.TrimSuffix(integration.Url, "/"),
+		username:   integration.Username,
+		token:      integration.Token,
+		httpClient: tlspolicy.HTTPClient(),
+	}
+}
+
+// do sends the request body as JSON, and decodes the JSON response into result (if not nil)
+func (c *client) do(ctx context.Context, method, path string, body, result any) error {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		bodyReader = bytes.NewReader(bodyBytes)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, c.baseUrl+path, bodyReader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.username, c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s returned status %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,175 @@
+package ticketing
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/schema"
+)
+
+// ticketing integrations are defined in the workspace config (.ppc) files, e.g.
+//
+//	integration "jira" "security" {
+//	  url                = "https://acme.atlassian.net"
+//	  username           = "powerpipe@acme.com"
+//	  token              = "..."
+//	  project            = "SEC"
+//	  issue_type         = "Bug"
+//	  resolve_transition = "Done"
+//	}
+//
+//	integration "servicenow" "ops" {
+//	  url      = "https://acme.service-now.com"
+//	  username = "powerpipe"
+//	  token    = "..."
+//	}
+//
+// and are used by a run with 'powerpipe benchmark run --ticket-integration security'
+// a ticket is opened for each new alarm finding, updated while the finding remains in alarm, and closed once it resolves
+const (
+	IntegrationTypeJira       = "jira"
+	IntegrationTypeServiceNow = "servicenow"
+
+	defaultJiraIssueType         = "Task"
+	defaultJiraResolveTransition = "Done"
+	defaultServiceNowTable       = "incident"
+	defaultServiceNowCloseState  = "6"
+	defaultServiceNowCloseCode   = "Resolved by caller"
+)
+
+// Integration is a ticketing system which findings are raised in
+type Integration struct {
+	Type     string
+	Name     string
+	Url      string `hcl:"url"`
+	Username string `hcl:"username"`
+	Token    string `hcl:"token"`
+	// jira
+	Project           string `hcl:"project,optional"`
+	IssueType         string `hcl:"issue_type,optional"`
+	ResolveTransition string `hcl:"resolve_transition,optional"`
+	// servicenow
+	Table      string `hcl:"table,optional"`
+	CloseState string `hcl:"close_state,optional"`
+	CloseCode  string `hcl:"close_code,optional"`
+}
+
+var integrationFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       schema.BlockTypeIntegration,
+			LabelNames: []string{schema.LabelType, schema.LabelName},
+		},
+	},
+}
+
+// LoadIntegrations loads the ticketing integrations from the config files in the given paths
+// paths are in order of decreasing precedence - if an integration is defined in more than one path, the first is used
+func LoadIntegrations(configPaths []string) (map[string]*Integration, error) {
+	res := make(map[string]*Integration)
+	for _, configPath := range configPaths {
+		filePaths, err := filepath.Glob(filepath.Join(configPath, "*"+app_specific.ConfigExtension))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(filePaths)
+
+		pathIntegrations := make(map[string]*Integration)
+		for _, filePath := range filePaths {
+			integrations, err := loadIntegrationFile(filePath)
+			if err != nil {
+				return nil, err
+			}
+			for _, integration := range integrations {
+				if _, ok := pathIntegrations[integration.Name]; ok {
+					return nil, fmt.Errorf("duplicate integration '%s' in %s", integration.Name, configPath)
+				}
+				pathIntegrations[integration.Name] = integration
+			}
+		}
+		for name, integration := range pathIntegrations {
+			if _, ok := res[name]; !ok {
+				res[name] = integration
+			}
+		}
+	}
+	return res, nil
+}
+
+func loadIntegrationFile(filePath string) ([]*Integration, error) {
+	fileData, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	file, diags := hclparse.NewParser().ParseHCL(fileData, filePath)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %s: %s", filePath, diags.Error())
+	}
+	// the file may contain other blocks, which are loaded elsewhere
+	content, _, diags := file.Body.PartialContent(integrationFileSchema)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %s: %s", filePath, diags.Error())
+	}
+
+	var res []*Integration
+	for _, block := range content.Blocks {
+		integrationType := block.Labels[0]
+		// other integration types (e.g. slack) are not ticketing integrations
+		if integrationType != IntegrationTypeJira && integrationType != IntegrationTypeServiceNow {
+			continue
+		}
+		integration := &Integration{Type: integrationType, Name: block.Labels[1]}
+		if diags := gohcl.DecodeBody(block.Body, nil, integration); diags.HasErrors() {
+			return nil, fmt.Errorf("failed to decode integration '%s': %s", integration.Name, diags.Error())
+		}
+		if err := integration.validate(); err != nil {
+			return nil, err
+		}
+		res = append(res, integration)
+	}
+	return res, nil
+}
+
+func (i *Integration) validate() error {
+	if u, err := url.Parse(i.Url); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("integration '%s' has invalid url '%s'", i.Name, i.Url)
+	}
+	switch i.Type {
+	case IntegrationTypeJira:
+		if i.Project == "" {
+			return fmt.Errorf("jira integration '%s' has no project", i.Name)
+		}
+		if i.IssueType == "" {
+			i.IssueType = defaultJiraIssueType
+		}
+		if i.ResolveTransition == "" {
+			i.ResolveTransition = defaultJiraResolveTransition
+		}
+	case IntegrationTypeServiceNow:
+		if i.Table == "" {
+			i.Table = defaultServiceNowTable
+		}
+		if i.CloseState == "" {
+			i.CloseState = defaultServiceNowCloseState
+		}
+		if i.CloseCode == "" {
+			i.CloseCode = defaultServiceNowCloseCode
+		}
+	}
+	return nil
+}
+
+// Tracker returns the client for the integration
+func (i *Integration) Tracker() Tracker {
+	if i.Type == IntegrationTypeJira {
+		return newJiraTracker(i)
+	}
+	return newServiceNowTracker(i)
+}
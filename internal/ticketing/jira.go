@@ -0,0 +1,131 @@
+package ticketing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// jira tickets are identified by labels - every ticket has the powerpipe label, along with labels
+// for the finding fingerprint and control
+const (
+	jiraLabel             = "powerpipe"
+	jiraFingerprintPrefix = "powerpipe-fp-"
+	jiraControlPrefix     = "powerpipe-control-"
+	jiraSearchPageSize    = 100
+	jiraOpenFilter        = "statusCategory != Done"
+)
+
+type jiraTracker struct {
+	client     *client
+	project    string
+	issueType  string
+	transition string
+}
+
+func newJiraTracker(integration *Integration) *jiraTracker {
+	return &jiraTracker{
+		client:     newClient(integration),
+		project:    integration.Project,
+		issueType:  integration.IssueType,
+		transition: integration.ResolveTransition,
+	}
+}
+
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Labels []string `json:"labels"`
+	} `json:"fields"`
+}
+
+// OpenTickets implements Tracker
+func (t *jiraTracker) OpenTickets(ctx context.Context) ([]*Ticket, error) {
+	jql := fmt.Sprintf("project = %q AND labels = %q AND %s", t.project, jiraLabel, jiraOpenFilter)
+	var res []*Ticket
+	for startAt := 0; ; startAt += jiraSearchPageSize {
+		query := url.Values{
+			"jql":        {jql},
+			"fields":     {"labels"},
+			"startAt":    {fmt.Sprintf("%d", startAt)},
+			"maxResults": {fmt.Sprintf("%d", jiraSearchPageSize)},
+		}
+		var page struct {
+			Issues []*jiraIssue `json:"issues"`
+			Total  int          `json:"total"`
+		}
+		if err := t.client.do(ctx, http.MethodGet, "/rest/api/2/search?"+query.Encode(), nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to search jira issues: %w", err)
+		}
+		for _, issue := range page.Issues {
+			ticket := &Ticket{Id: issue.Key}
+			for _, label := range issue.Fields.Labels {
+				if fingerprint, ok := strings.CutPrefix(label, jiraFingerprintPrefix); ok {
+					ticket.Fingerprint = fingerprint
+				} else if control, ok := strings.CutPrefix(label, jiraControlPrefix); ok {
+					ticket.Control = control
+				}
+			}
+			if ticket.Fingerprint != "" {
+				res = append(res, ticket)
+			}
+		}
+		if len(page.Issues) == 0 || startAt+len(page.Issues) >= page.Total {
+			return res, nil
+		}
+	}
+}
+
+// Create implements Tracker
+func (t *jiraTracker) Create(ctx context.Context, finding *Finding) error {
+	body := map[string]any{
+		"fields": map[string]any{
+			"project":     map[string]string{"key": t.project},
+			"issuetype":   map[string]string{"name": t.issueType},
+			"summary":     finding.summary(),
+			"description": finding.description(),
+			"labels":      []string{jiraLabel, jiraFingerprintPrefix + finding.Fingerprint, jiraControlPrefix + finding.Control},
+		},
+	}
+	if err := t.client.do(ctx, http.MethodPost, "/rest/api/2/issue", body, nil); err != nil {
+		return fmt.Errorf("failed to create jira issue for %s: %w", finding.summary(), err)
+	}
+	return nil
+}
+
+// Update implements Tracker
+func (t *jiraTracker) Update(ctx context.Context, ticket *Ticket, finding *Finding) error {
+	body := map[string]any{
+		"fields": map[string]any{"description": finding.description()},
+	}
+	if err := t.client.do(ctx, http.MethodPut, "/rest/api/2/issue/"+url.PathEscape(ticket.Id), body, nil); err != nil {
+		return fmt.Errorf("failed to update jira issue %s: %w", ticket.Id, err)
+	}
+	return nil
+}
+
+// Close implements Tracker - the issue is moved using the configured resolve transition
+func (t *jiraTracker) Close(ctx context.Context, ticket *Ticket) error {
+	path := "/rest/api/2/issue/" + url.PathEscape(ticket.Id) + "/transitions"
+	var transitions struct {
+		Transitions []struct {
+			Id   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"transitions"`
+	}
+	if err := t.client.do(ctx, http.MethodGet, path, nil, &transitions); err != nil {
+		return fmt.Errorf("failed to get transitions for jira issue %s: %w", ticket.Id, err)
+	}
+	for _, transition := range transitions.Transitions {
+		if strings.EqualFold(transition.Name, t.transition) {
+			body := map[string]any{"transition": map[string]string{"id": transition.Id}}
+			if err := t.client.do(ctx, http.MethodPost, path, body, nil); err != nil {
+				return fmt.Errorf("failed to close jira issue %s: %w", ticket.Id, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to close jira issue %s: no '%s' transition", ticket.Id, t.transition)
+}
@@ -0,0 +1,108 @@
+package ticketing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// servicenow tickets are identified by their correlation fields - the correlation id is the finding fingerprint,
+// and the correlation display is the control name, prefixed with powerpipe
+const (
+	serviceNowCorrelationPrefix = "powerpipe:"
+	serviceNowPageSize          = 100
+)
+
+type serviceNowTracker struct {
+	client     *client
+	table      string
+	closeState string
+	closeCode  string
+}
+
+func newServiceNowTracker(integration *Integration) *serviceNowTracker {
+	return &serviceNowTracker{
+		client:     newClient(integration),
+		table:      integration.Table,
+		closeState: integration.CloseState,
+		closeCode:  integration.CloseCode,
+	}
+}
+
+func (t *serviceNowTracker) tablePath() string {
+	return "/api/now/table/" + url.PathEscape(t.table)
+}
+
+// OpenTickets implements Tracker
+func (t *serviceNowTracker) OpenTickets(ctx context.Context) ([]*Ticket, error) {
+	var res []*Ticket
+	for offset := 0; ; offset += serviceNowPageSize {
+		query := url.Values{
+			"sysparm_query":  {"active=true^correlation_displaySTARTSWITH" + serviceNowCorrelationPrefix},
+			"sysparm_fields": {"sys_id,correlation_id,correlation_display"},
+			"sysparm_limit":  {fmt.Sprintf("%d", serviceNowPageSize)},
+			"sysparm_offset": {fmt.Sprintf("%d", offset)},
+		}
+		var page struct {
+			Result []struct {
+				SysId              string `json:"sys_id"`
+				CorrelationId      string `json:"correlation_id"`
+				CorrelationDisplay string `json:"correlation_display"`
+			} `json:"result"`
+		}
+		if err := t.client.do(ctx, http.MethodGet, t.tablePath()+"?"+query.Encode(), nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to query servicenow %s records: %w", t.table, err)
+		}
+		for _, record := range page.Result {
+			if record.CorrelationId == "" {
+				continue
+			}
+			res = append(res, &Ticket{
+				Id:          record.SysId,
+				Fingerprint: record.CorrelationId,
+				Control:     strings.TrimPrefix(record.CorrelationDisplay, serviceNowCorrelationPrefix),
+			})
+		}
+		if len(page.Result) < serviceNowPageSize {
+			return res, nil
+		}
+	}
+}
+
+// Create implements Tracker
+func (t *serviceNowTracker) Create(ctx context.Context, finding *Finding) error {
+	body := map[string]string{
+		"short_description":   finding.summary(),
+		"description":         finding.description(),
+		"correlation_id":      finding.Fingerprint,
+		"correlation_display": serviceNowCorrelationPrefix + finding.Control,
+	}
+	if err := t.client.do(ctx, http.MethodPost, t.tablePath(), body, nil); err != nil {
+		return fmt.Errorf("failed to create servicenow %s record for %s: %w", t.table, finding.summary(), err)
+	}
+	return nil
+}
+
+// Update implements Tracker
+func (t *serviceNowTracker) Update(ctx context.Context, ticket *Ticket, finding *Finding) error {
+	body := map[string]string{"description": finding.description()}
+	if err := t.client.do(ctx, http.MethodPatch, t.tablePath()+"/"+url.PathEscape(ticket.Id), body, nil); err != nil {
+		return fmt.Errorf("failed to update servicenow %s record %s: %w", t.table, ticket.Id, err)
+	}
+	return nil
+}
+
+// Close implements Tracker - the record is moved to the configured close state
+func (t *serviceNowTracker) Close(ctx context.Context, ticket *Ticket) error {
+	body := map[string]string{
+		"state":       t.closeState,
+		"close_code":  t.closeCode,
+		"close_notes": "Resolved - the finding is no longer in alarm.",
+	}
+	if err := t.client.do(ctx, http.MethodPatch, t.tablePath()+"/"+url.PathEscape(ticket.Id), body, nil); err != nil {
+		return fmt.Errorf("failed to close servicenow %s record %s: %w", t.table, ticket.Id, err)
+	}
+	return nil
+}
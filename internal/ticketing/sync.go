@@ -0,0 +1,160 @@
+package ticketing
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+// Finding is an alarm result of a control
+type Finding struct {
+	Fingerprint string
+	Control     string
+	Title       string
+	Severity    string
+	Resource    string
+	Reason      string
+	Href        string
+	Dimensions  []controlexecute.Dimension
+}
+
+// Ticket is an open ticket raised for a finding
+type Ticket struct {
+	Id          string
+	Fingerprint string
+	Control     string
+}
+
+// Tracker opens, updates and closes the tickets of a ticketing system
+type Tracker interface {
+	// OpenTickets returns the open tickets raised by powerpipe
+	OpenTickets(ctx context.Context) ([]*Ticket, error)
+	Create(ctx context.Context, finding *Finding) error
+	Update(ctx context.Context, ticket *Ticket, finding *Finding) error
+	Close(ctx context.Context, ticket *Ticket) error
+}
+
+// SyncResult is the number of tickets changed by a sync
+type SyncResult struct {
+	Created int
+	Updated int
+	Closed  int
+}
+
+func (r SyncResult) String() string {
+	return fmt.Sprintf("%d created, %d updated, %d closed", r.Created, r.Updated, r.Closed)
+}
+
+// Sync brings the tickets of the tracker in line with the results of an executed tree:
+//   - a ticket is opened for each alarm finding which does not have an open ticket
+//   - open tickets for findings which are still in alarm are updated
+//   - open tickets for the controls of the tree which no longer have an alarm finding are closed
+//
+// tickets for controls which were not run are left unchanged
+func Sync(ctx context.Context, tracker Tracker, tree *controlexecute.ExecutionTree) (SyncResult, error) {
+	var res SyncResult
+
+	findings := make(map[string]*Finding)
+	for _, run := range tree.ControlRuns {
+		for _, row := range run.Rows {
+			if row.Status != constants.ControlAlarm {
+				continue
+			}
+			finding := &Finding{
+				Fingerprint: row.Fingerprint,
+				Control:     run.FullName,
+				Title:       run.Title,
+				Severity:    run.Severity,
+				Resource:    row.Resource,
+				Reason:      row.Reason,
+				Href:        row.Href,
+				Dimensions:  row.Dimensions,
+			}
+			findings[finding.Fingerprint] = finding
+		}
+	}
+
+	tickets, err := tracker.OpenTickets(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	var errors []error
+	ticketed := make(map[string]struct{}, len(tickets))
+	for _, ticket := range tickets {
+		ticketed[ticket.Fingerprint] = struct{}{}
+		if finding, ok := findings[ticket.Fingerprint]; ok {
+			if err := tracker.Update(ctx, ticket, finding); err != nil {
+				errors = append(errors, err)
+				continue
+			}
+			res.Updated++
+			continue
+		}
+		if _, ran := tree.ControlRuns[ticket.Control]; !ran {
+			continue
+		}
+		if err := tracker.Close(ctx, ticket); err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		res.Closed++
+	}
+
+	// create tickets in a consistent order
+	fingerprints := make([]string, 0, len(findings))
+	for fingerprint := range findings {
+		if _, ok := ticketed[fingerprint]; !ok {
+			fingerprints = append(fingerprints, fingerprint)
+		}
+	}
+	sort.Slice(fingerprints, func(i, j int) bool {
+		a, b := findings[fingerprints[i]], findings[fingerprints[j]]
+		if a.Control != b.Control {
+			return a.Control < b.Control
+		}
+		return a.Resource < b.Resource
+	})
+	for _, fingerprint := range fingerprints {
+		if err := tracker.Create(ctx, findings[fingerprint]); err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		res.Created++
+	}
+
+	return res, error_helpers.CombineErrors(errors...)
+}
+
+// summary returns the ticket title for a finding
+func (f *Finding) summary() string {
+	title := f.Title
+	if title == "" {
+		title = f.Control
+	}
+	return fmt.Sprintf("%s: %s", title, f.Resource)
+}
+
+// description returns the ticket description for a finding
+func (f *Finding) description() string {
+	var b strings.Builder
+	b.WriteString(f.Reason + "\n\n")
+	b.WriteString(fmt.Sprintf("Control: %s\n", f.Control))
+	if f.Severity != "" {
+		b.WriteString(fmt.Sprintf("Severity: %s\n", f.Severity))
+	}
+	b.WriteString(fmt.Sprintf("Resource: %s\n", f.Resource))
+	if f.Href != "" {
+		b.WriteString(fmt.Sprintf("Link: %s\n", f.Href))
+	}
+	for _, d := range f.Dimensions {
+		b.WriteString(fmt.Sprintf("%s: %s\n", d.Key, d.Value))
+	}
+	b.WriteString(fmt.Sprintf("Fingerprint: %s\n", f.Fingerprint))
+	return b.String()
+}
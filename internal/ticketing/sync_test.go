@@ -0,0 +1,150 @@
+package ticketing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+// testTracker records the changes made by a sync
+type testTracker struct {
+	tickets []*Ticket
+	changes []string
+}
+
+func (t *testTracker) OpenTickets(context.Context) ([]*Ticket, error) {
+	return t.tickets, nil
+}
+
+func (t *testTracker) Create(_ context.Context, finding *Finding) error {
+	t.changes = append(t.changes, "create "+finding.Control+":"+finding.Resource)
+	return nil
+}
+
+func (t *testTracker) Update(_ context.Context, ticket *Ticket, _ *Finding) error {
+	t.changes = append(t.changes, "update "+ticket.Id)
+	return nil
+}
+
+func (t *testTracker) Close(_ context.Context, ticket *Ticket) error {
+	t.changes = append(t.changes, "close "+ticket.Id)
+	return nil
+}
+
+func newTestTree() *controlexecute.ExecutionTree {
+	tree := &controlexecute.ExecutionTree{ControlRuns: map[string]*controlexecute.ControlRun{
+		"c1": {FullName: "c1", Rows: []*controlexecute.ResultRow{
+			{Resource: "r1", Status: "alarm"},
+			{Resource: "r2", Status: "ok"},
+			{Resource: "r3", Status: "alarm"},
+		}},
+		"c2": {FullName: "c2", Rows: []*controlexecute.ResultRow{
+			{Resource: "r4", Status: "error"},
+		}},
+	}}
+	for _, run := range tree.ControlRuns {
+		for _, row := range run.Rows {
+			row.Fingerprint = testFingerprint(run.FullName, row.Resource)
+		}
+	}
+	return tree
+}
+
+func testFingerprint(control, resource string) string {
+	return controlexecute.FindingFingerprint(control, resource, "", nil)
+}
+
+type syncTest struct {
+	tickets  []*Ticket
+	expected string
+}
+
+var testCasesSync = map[string]syncTest{
+	"no tickets": {
+		expected: "create c1:r1, create c1:r3",
+	},
+	"existing tickets": {
+		tickets: []*Ticket{
+			{Id: "T-1", Fingerprint: testFingerprint("c1", "r1"), Control: "c1"},
+			{Id: "T-2", Fingerprint: testFingerprint("c1", "r2"), Control: "c1"},
+			{Id: "T-3", Fingerprint: testFingerprint("c2", "r4"), Control: "c2"},
+		},
+		expected: "close T-2, close T-3, create c1:r3, update T-1",
+	},
+	"control not run": {
+		tickets: []*Ticket{
+			{Id: "T-1", Fingerprint: testFingerprint("c3", "r1"), Control: "c3"},
+		},
+		expected: "create c1:r1, create c1:r3",
+	},
+}
+
+func TestSync(t *testing.T) {
+	for name, test := range testCasesSync {
+		tracker := &testTracker{tickets: test.tickets}
+		if _, err := Sync(context.Background(), tracker, newTestTree()); err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		sort.Strings(tracker.changes)
+		if actual := strings.Join(tracker.changes, ", "); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, actual)
+		}
+	}
+}
+
+func TestLoadIntegrations(t *testing.T) {
+	app_specific.ConfigExtension = ".ppc"
+	high, low := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		filepath.Join(high, "integrations.ppc"): `
+integration "jira" "security" {
+  url      = "https://acme.atlassian.net"
+  username = "powerpipe@acme.com"
+  token    = "abc"
+  project  = "SEC"
+}
+integration "slack" "alerts" {
+  webhook_url = "https://hooks.slack.com/services/x"
+}
+workspace "default" {
+  database = "postgres://localhost"
+}`,
+		filepath.Join(low, "integrations.ppc"): `
+integration "servicenow" "security" {
+  url      = "https://acme.service-now.com"
+  username = "powerpipe"
+  token    = "abc"
+}
+integration "servicenow" "ops" {
+  url      = "https://acme.service-now.com"
+  username = "powerpipe"
+  token    = "abc"
+}`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	integrations, err := LoadIntegrations([]string{high, low})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(integrations) != 2 {
+		t.Errorf("Test: 'load' FAILED : expected 2 integrations, got %d", len(integrations))
+	}
+	if i := integrations["security"]; i == nil || i.Type != IntegrationTypeJira || i.IssueType != defaultJiraIssueType {
+		t.Errorf("Test: 'precedence' FAILED : expected jira integration 'security', got %v", i)
+	}
+	if i := integrations["ops"]; i == nil || i.Type != IntegrationTypeServiceNow || i.Table != defaultServiceNowTable {
+		t.Errorf("Test: 'defaults' FAILED : expected servicenow integration 'ops', got %v", i)
+	}
+}
@@ -0,0 +1,40 @@
+package tlspolicy
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+// LoadCABundles returns a pool of the system roots and the CA certificates of the (comma separated) PEM files - nil is
+// returned if no files are given, in which case the system roots are used
+func LoadCABundles(caBundles string) (*x509.CertPool, error) {
+	var paths []string
+	for _, path := range strings.Split(caBundles, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		// (the system roots are not available on all platforms)
+		pool = x509.NewCertPool()
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of '%s': failed to read CA bundle: %s", localconstants.ArgCABundle, err.Error())
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("invalid value of '%s': %s does not contain any PEM encoded certificates", localconstants.ArgCABundle, path)
+		}
+	}
+	return pool, nil
+}
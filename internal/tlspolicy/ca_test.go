@@ -0,0 +1,105 @@
+package tlspolicy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type loadCABundlesTest struct {
+	// the content of each bundle file ('server' for the certificate of the test server)
+	bundles  []string
+	expected string
+}
+
+var testCasesLoadCABundles = map[string]loadCABundlesTest{
+	"none": {
+		expected: "system",
+	},
+	"server": {
+		bundles:  []string{"server"},
+		expected: "trusted",
+	},
+	"multiple": {
+		bundles:  []string{"server", "server"},
+		expected: "trusted",
+	},
+	"no certificates": {
+		bundles:  []string{"server", "not a certificate"},
+		expected: "ERROR",
+	},
+}
+
+func TestLoadCABundles(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	for name, test := range testCasesLoadCABundles {
+		dir := t.TempDir()
+		var paths []string
+		for i, bundle := range test.bundles {
+			if bundle == "server" {
+				bundle = serverPEM
+			}
+			path := filepath.Join(dir, string(rune('a'+i))+".pem")
+			if err := os.WriteFile(path, []byte(bundle), 0600); err != nil {
+				t.Fatal(err)
+			}
+			paths = append(paths, path)
+		}
+
+		pool, err := LoadCABundles(strings.Join(paths, ", "))
+		if err != nil {
+			if test.expected != "ERROR" {
+				t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			}
+			continue
+		}
+		if test.expected == "ERROR" {
+			t.Errorf("Test: '%s' FAILED : expected an error", name)
+			continue
+		}
+		if test.expected == "system" {
+			if pool != nil {
+				t.Errorf("Test: '%s' FAILED : expected the system roots", name)
+			}
+			continue
+		}
+
+		// the server is trusted by a transport applying a policy with the pool
+		p := &Policy{MinVersion: tls.VersionTLS12, RootCAs: pool}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = p.Apply(nil)
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : expected the server to be trusted: %v", name, err)
+			continue
+		}
+		resp.Body.Close()
+	}
+}
+
+func TestLoadCABundlesMissing(t *testing.T) {
+	if _, err := LoadCABundles(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Errorf("Test: 'missing' FAILED : expected an error")
+	}
+}
+
+func TestApplyRootCAs(t *testing.T) {
+	policyPool, configPool := x509.NewCertPool(), x509.NewCertPool()
+	p := &Policy{MinVersion: tls.VersionTLS12, RootCAs: policyPool}
+	if res := p.Apply(nil); res.RootCAs != policyPool {
+		t.Errorf("Test: 'policy roots' FAILED : expected the root CAs of the policy")
+	}
+	// a config with its own roots (e.g. the CA of a Kubernetes cluster) keeps them
+	if res := p.Apply(&tls.Config{RootCAs: configPool}); res.RootCAs != configPool {
+		t.Errorf("Test: 'config roots' FAILED : expected the root CAs of the config")
+	}
+}
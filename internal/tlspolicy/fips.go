@@ -0,0 +1,17 @@
+package tlspolicy
+
+import "crypto/tls"
+
+// FIPS mode requires the binary to use the FIPS 140-3 Go Cryptographic Module, which is enabled either at build time
+// (GOFIPS140=v1.0.0, see 'make build-fips') or at run time (GODEBUG=fips140=on) - when the module is enabled, Go
+// restricts TLS to the FIPS approved versions, cipher suites, curves and signature algorithms
+// powerpipe additionally restricts TLS 1.2 connections to the approved ECDHE AES-GCM cipher suites, so the policy is
+// the same whichever TLS implementation negotiates the connection
+
+// fipsCipherSuites are the FIPS approved TLS 1.2 cipher suites
+var fipsCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
@@ -0,0 +1,10 @@
+//go:build go1.24
+
+package tlspolicy
+
+import "crypto/fips140"
+
+// FIPSModuleEnabled returns whether the FIPS 140-3 Go Cryptographic Module is enabled
+func FIPSModuleEnabled() bool {
+	return fips140.Enabled()
+}
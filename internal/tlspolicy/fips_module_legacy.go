@@ -0,0 +1,9 @@
+//go:build !go1.24
+
+package tlspolicy
+
+// FIPSModuleEnabled returns whether the FIPS 140-3 Go Cryptographic Module is enabled - the module is only available
+// in binaries built with go 1.24 or later
+func FIPSModuleEnabled() bool {
+	return false
+}
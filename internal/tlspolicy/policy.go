@@ -0,0 +1,199 @@
+package tlspolicy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+// the TLS policy is applied to the HTTPS listener of 'powerpipe server' and to outbound connections (the HTTP clients
+// of integrations, snapshot destinations and mod installs, and the SMTP, NATS and Kubernetes clients), and is set by:
+//   - --tls-min-version: the minimum TLS version; one of 1.2 (the default) or 1.3
+//   - --tls-cipher-suites: the cipher suites allowed for TLS 1.2 connections (comma-separated, using the IANA names,
+//     e.g. TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384) - TLS 1.3 cipher suites are not configurable
+//   - --fips: require the FIPS 140-3 Go Cryptographic Module, and restrict TLS to the FIPS approved versions and
+//     cipher suites (see fips.go)
+//   - --ca-bundle: PEM files of CA certificates trusted by outbound connections in addition to the system roots (also
+//     set by ca_bundles in an options "network" block of the workspace config)
+//
+// outbound HTTP connections use the proxy set by HTTPS_PROXY, HTTP_PROXY and NO_PROXY (see NewTransport)
+// the HTTP clients of powerpipe use HTTPClient, or a transport returned by NewTransport - the global default transport
+// (and so http.DefaultClient) is not changed
+const (
+	TLSVersion12 = "1.2"
+	TLSVersion13 = "1.3"
+)
+
+// TLSVersions are the values of --tls-min-version
+var TLSVersions = []string{TLSVersion12, TLSVersion13}
+
+var tlsVersions = map[string]uint16{
+	TLSVersion12: tls.VersionTLS12,
+	TLSVersion13: tls.VersionTLS13,
+}
+
+// Policy is the minimum TLS version and cipher suites of connections
+type Policy struct {
+	MinVersion uint16
+	// the allowed TLS 1.2 cipher suites (nil for the Go defaults)
+	CipherSuites []uint16
+	// whether TLS is restricted to FIPS approved algorithms
+	FIPS bool
+	// the root CAs of outbound connections (nil for the system roots)
+	RootCAs *x509.CertPool
+}
+
+var (
+	policyLock sync.RWMutex
+	policy     = &Policy{MinVersion: tls.VersionTLS12}
+	// the client of outbound HTTP connections, applying the policy
+	httpClient = &http.Client{Transport: policy.NewTransport(nil)}
+)
+
+// Init validates the TLS policy flags, and sets the policy of outbound connections
+func Init() error {
+	p, err := NewPolicy(viper.GetString(localconstants.ArgTLSMinVersion), viper.GetString(localconstants.ArgTLSCipherSuites), viper.GetBool(localconstants.ArgFIPS))
+	if err != nil {
+		return err
+	}
+	if p.RootCAs, err = LoadCABundles(viper.GetString(localconstants.ArgCABundle)); err != nil {
+		return err
+	}
+	setPolicy(p)
+	return nil
+}
+
+// NewPolicy returns the policy with the given minimum version and (comma separated) cipher suites - if fips is set,
+// or the FIPS 140-3 module is enabled, the policy is restricted to the FIPS approved cipher suites
+func NewPolicy(minVersion, cipherSuites string, fips bool) (*Policy, error) {
+	if fips && !FIPSModuleEnabled() {
+		return nil, fmt.Errorf("--%s requires the FIPS 140-3 Go Cryptographic Module - run with GODEBUG=fips140=on, or use a binary built with GOFIPS140 (see 'make build-fips')", localconstants.ArgFIPS)
+	}
+	p := &Policy{FIPS: fips || FIPSModuleEnabled()}
+
+	if minVersion == "" {
+		minVersion = TLSVersion12
+	}
+	version, ok := tlsVersions[minVersion]
+	if !ok {
+		return nil, fmt.Errorf("invalid value of '%s' (%s), must be one of: %s", localconstants.ArgTLSMinVersion, minVersion, strings.Join(TLSVersions, ", "))
+	}
+	p.MinVersion = version
+
+	for _, name := range strings.Split(cipherSuites, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		id, err := cipherSuiteId(name)
+		if err != nil {
+			return nil, err
+		}
+		if p.FIPS && !slices.Contains(fipsCipherSuites, id) {
+			return nil, fmt.Errorf("invalid value of '%s': cipher suite %s is not FIPS approved", localconstants.ArgTLSCipherSuites, name)
+		}
+		p.CipherSuites = append(p.CipherSuites, id)
+	}
+	if p.FIPS && len(p.CipherSuites) == 0 {
+		p.CipherSuites = fipsCipherSuites
+	}
+	return p, nil
+}
+
+// cipherSuiteId returns the id of the secure cipher suite with the given name
+func cipherSuiteId(name string) (uint16, error) {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			if !slices.Contains(suite.SupportedVersions, tls.VersionTLS12) {
+				return 0, fmt.Errorf("invalid value of '%s': %s is a TLS 1.3 cipher suite, which is not configurable", localconstants.ArgTLSCipherSuites, name)
+			}
+			return suite.ID, nil
+		}
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.Name == name {
+			return 0, fmt.Errorf("invalid value of '%s': cipher suite %s is insecure", localconstants.ArgTLSCipherSuites, name)
+		}
+	}
+	return 0, fmt.Errorf("invalid value of '%s': unknown cipher suite %s", localconstants.ArgTLSCipherSuites, name)
+}
+
+// Current returns the TLS policy
+func Current() *Policy {
+	policyLock.RLock()
+	defer policyLock.RUnlock()
+	return policy
+}
+
+// HTTPClient returns the client of outbound HTTP connections, which applies the current policy and uses the proxy of
+// the environment - it has no timeout, so requests should be made with a context with a deadline
+// (the client is shared, so its connections are reused)
+func HTTPClient() *http.Client {
+	policyLock.RLock()
+	defer policyLock.RUnlock()
+	return httpClient
+}
+
+func setPolicy(p *Policy) {
+	policyLock.Lock()
+	defer policyLock.Unlock()
+	policy = p
+	httpClient = &http.Client{Transport: p.NewTransport(nil)}
+}
+
+// Apply returns a copy of the tls config (which may be nil) with the policy applied - the minimum version is raised to
+// that of the policy, the cipher suites are restricted to those of the policy, and the root CAs of the policy are used
+// unless the config sets its own (e.g. the CA of a Kubernetes cluster)
+func (p *Policy) Apply(cfg *tls.Config) *tls.Config {
+	res := cfg.Clone()
+	if res == nil {
+		res = &tls.Config{}
+	}
+	if res.RootCAs == nil {
+		res.RootCAs = p.RootCAs
+	}
+	if res.MinVersion < p.MinVersion {
+		res.MinVersion = p.MinVersion
+	}
+	if len(p.CipherSuites) > 0 {
+		if len(res.CipherSuites) == 0 {
+			res.CipherSuites = p.CipherSuites
+		} else {
+			res.CipherSuites = slices.DeleteFunc(slices.Clone(res.CipherSuites), func(id uint16) bool {
+				return !slices.Contains(p.CipherSuites, id)
+			})
+			// (an empty list would allow the Go defaults)
+			if len(res.CipherSuites) == 0 {
+				res.CipherSuites = p.CipherSuites
+			}
+		}
+	}
+	return res
+}
+
+// Apply returns a copy of the tls config (which may be nil) with the current policy applied
+func Apply(cfg *tls.Config) *tls.Config {
+	return Current().Apply(cfg)
+}
+
+// NewTransport returns a copy of the default transport, with the tls config applying the policy and the proxy of the
+// environment
+func (p *Policy) NewTransport(cfg *tls.Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = p.Apply(cfg)
+	transport.Proxy = http.ProxyFromEnvironment
+	return transport
+}
+
+// NewTransport returns a copy of the default transport, with the tls config applying the current policy and the proxy
+// of the environment
+func NewTransport(cfg *tls.Config) *http.Transport {
+	return Current().NewTransport(cfg)
+}
@@ -0,0 +1,162 @@
+package tlspolicy
+
+import (
+	"crypto/tls"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+type newPolicyTest struct {
+	minVersion   string
+	cipherSuites string
+	fips         bool
+	expected     interface{}
+}
+
+var testCasesNewPolicy = map[string]newPolicyTest{
+	"default": {
+		expected: Policy{MinVersion: tls.VersionTLS12},
+	},
+	"tls 1.3": {
+		minVersion: "1.3",
+		expected:   Policy{MinVersion: tls.VersionTLS13},
+	},
+	"tls 1.1": {
+		minVersion: "1.1",
+		expected:   "ERROR",
+	},
+	"cipher suites": {
+		cipherSuites: "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384, TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256",
+		expected: Policy{MinVersion: tls.VersionTLS12, CipherSuites: []uint16{
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		}},
+	},
+	"insecure cipher suite": {
+		cipherSuites: "TLS_RSA_WITH_RC4_128_SHA",
+		expected:     "ERROR",
+	},
+	"tls 1.3 cipher suite": {
+		cipherSuites: "TLS_AES_128_GCM_SHA256",
+		expected:     "ERROR",
+	},
+	"unknown cipher suite": {
+		cipherSuites: "TLS_ECDHE_RSA_WITH_AES_512_GCM_SHA512",
+		expected:     "ERROR",
+	},
+}
+
+func TestNewPolicy(t *testing.T) {
+	if FIPSModuleEnabled() {
+		t.Skip("the FIPS 140-3 module is enabled")
+	}
+	for name, test := range testCasesNewPolicy {
+		p, err := NewPolicy(test.minVersion, test.cipherSuites, test.fips)
+		if err != nil {
+			if test.expected != "ERROR" {
+				t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			}
+			continue
+		}
+		if test.expected == "ERROR" {
+			t.Errorf("Test: '%s' FAILED : expected an error", name)
+			continue
+		}
+		expected := test.expected.(Policy)
+		if p.MinVersion != expected.MinVersion || !slices.Equal(p.CipherSuites, expected.CipherSuites) || p.FIPS != expected.FIPS {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, expected, *p)
+		}
+	}
+}
+
+func TestNewPolicyFIPS(t *testing.T) {
+	p, err := NewPolicy("", "", true)
+	if !FIPSModuleEnabled() {
+		if err == nil {
+			t.Errorf("Test: 'fips' FAILED : expected an error when the FIPS 140-3 module is not enabled")
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.FIPS || !slices.Equal(p.CipherSuites, fipsCipherSuites) {
+		t.Errorf("Test: 'fips' FAILED : expected the FIPS approved cipher suites, got %v", p.CipherSuites)
+	}
+	if _, err := NewPolicy("", "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256", true); err == nil {
+		t.Errorf("Test: 'fips cipher suite' FAILED : expected an error for a cipher suite which is not FIPS approved")
+	}
+}
+
+type applyTest struct {
+	policy   Policy
+	config   *tls.Config
+	expected *tls.Config
+}
+
+var testCasesApply = map[string]applyTest{
+	"nil config": {
+		policy:   Policy{MinVersion: tls.VersionTLS13},
+		expected: &tls.Config{MinVersion: tls.VersionTLS13},
+	},
+	"raises min version": {
+		policy:   Policy{MinVersion: tls.VersionTLS13},
+		config:   &tls.Config{ServerName: "smtp.acme.com", MinVersion: tls.VersionTLS12},
+		expected: &tls.Config{ServerName: "smtp.acme.com", MinVersion: tls.VersionTLS13},
+	},
+	"keeps higher min version": {
+		policy:   Policy{MinVersion: tls.VersionTLS12},
+		config:   &tls.Config{MinVersion: tls.VersionTLS13},
+		expected: &tls.Config{MinVersion: tls.VersionTLS13},
+	},
+	"sets cipher suites": {
+		policy:   Policy{MinVersion: tls.VersionTLS12, CipherSuites: fipsCipherSuites},
+		config:   &tls.Config{},
+		expected: &tls.Config{MinVersion: tls.VersionTLS12, CipherSuites: fipsCipherSuites},
+	},
+	"restricts cipher suites": {
+		policy: Policy{MinVersion: tls.VersionTLS12, CipherSuites: fipsCipherSuites},
+		config: &tls.Config{CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}},
+		expected: &tls.Config{MinVersion: tls.VersionTLS12, CipherSuites: []uint16{
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		}},
+	},
+	"no allowed cipher suites": {
+		policy:   Policy{MinVersion: tls.VersionTLS12, CipherSuites: fipsCipherSuites},
+		config:   &tls.Config{CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256}},
+		expected: &tls.Config{MinVersion: tls.VersionTLS12, CipherSuites: fipsCipherSuites},
+	},
+}
+
+func TestApply(t *testing.T) {
+	for name, test := range testCasesApply {
+		var original []uint16
+		if test.config != nil {
+			original = slices.Clone(test.config.CipherSuites)
+		}
+		res := test.policy.Apply(test.config)
+		if res.ServerName != test.expected.ServerName || res.MinVersion != test.expected.MinVersion || !slices.Equal(res.CipherSuites, test.expected.CipherSuites) {
+			t.Errorf("Test: '%s' FAILED : expected min version %x and cipher suites %v, got %x and %v", name, test.expected.MinVersion, test.expected.CipherSuites, res.MinVersion, res.CipherSuites)
+		}
+		if test.config != nil && !slices.Equal(test.config.CipherSuites, original) {
+			t.Errorf("Test: '%s' FAILED : expected the config not to be modified", name)
+		}
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	previous := Current()
+	defer setPolicy(previous)
+	defaultConfig := http.DefaultTransport.(*http.Transport).TLSClientConfig
+
+	setPolicy(&Policy{MinVersion: tls.VersionTLS13})
+	transport, ok := HTTPClient().Transport.(*http.Transport)
+	if !ok || transport.TLSClientConfig.MinVersion != tls.VersionTLS13 {
+		t.Errorf("Test: 'client' FAILED : expected the client to apply the policy")
+	}
+	// the policy is not applied to the global default transport
+	if http.DefaultTransport.(*http.Transport).TLSClientConfig != defaultConfig {
+		t.Errorf("Test: 'default transport' FAILED : expected the default transport not to be modified")
+	}
+}
@@ -40,3 +40,26 @@ type WebhookRequestQuery struct {
 type PipelineRequestQuery struct {
 	ExecutionMode *string `json:"execution_mode" form:"execution_mode" binding:"omitempty,oneof=synchronous asynchronous"`
 }
+
+type RunRequestURI struct {
+	RunId string `uri:"run_id" binding:"required"`
+}
+
+type PanelDataRequestURI struct {
+	RunId     string `uri:"run_id" binding:"required"`
+	PanelName string `uri:"panel_name" binding:"required"`
+}
+
+type RunWebhookRequestURI struct {
+	Target string `uri:"target" binding:"required"`
+}
+
+type BenchmarkBadgeRequestURI struct {
+	BenchmarkName string `uri:"benchmark_name" binding:"required"`
+}
+
+// RunWebhookRequestBody contains the fields of an inbound webhook payload used by the run webhook
+// - any other fields in the payload (e.g. a GitHub push event) are ignored
+type RunWebhookRequestBody struct {
+	Inputs map[string]any `json:"inputs"`
+}
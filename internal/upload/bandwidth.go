@@ -0,0 +1,88 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// the maximum number of bytes read from a request body at a time, when the bandwidth is limited
+const maxBurst = 256 * 1024
+
+type limiterKey struct{}
+
+// NewRequest returns a request with the data as its body - if the context is that of an upload of a pipeline with a
+// bandwidth limit, the body is read at the limit (shared by all the uploads of the pipeline)
+func NewRequest(ctx context.Context, method, url string, data []byte) (*http.Request, error) {
+	limiter := contextLimiter(ctx)
+	if limiter == nil {
+		return http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, newLimitedReader(ctx, limiter, data))
+	if err != nil {
+		return nil, err
+	}
+	// (the length is only set by NewRequest for known readers)
+	req.ContentLength = int64(len(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(newLimitedReader(ctx, limiter, data)), nil
+	}
+	return req, nil
+}
+
+// Wait waits until the bandwidth limit of the pipeline of the context allows n bytes to be uploaded - it is used by
+// uploads which cannot read their body with NewRequest, e.g. the upload of a snapshot to Turbot Pipes
+func Wait(ctx context.Context, n int) error {
+	limiter := contextLimiter(ctx)
+	if limiter == nil {
+		return nil
+	}
+	for n > 0 {
+		chunk := min(n, limiter.Burst())
+		if err := limiter.WaitN(ctx, chunk); err != nil {
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+func contextLimiter(ctx context.Context) *rate.Limiter {
+	limiter, _ := ctx.Value(limiterKey{}).(*rate.Limiter)
+	return limiter
+}
+
+// burst returns the burst of the limiter of the bandwidth limit - a second of bandwidth, up to maxBurst
+func burst(bandwidthLimit int64) int {
+	return int(min(bandwidthLimit, maxBurst))
+}
+
+// limitedReader reads the data at the rate of the limiter
+type limitedReader struct {
+	ctx     context.Context
+	limiter *rate.Limiter
+	reader  *bytes.Reader
+}
+
+func newLimitedReader(ctx context.Context, limiter *rate.Limiter, data []byte) *limitedReader {
+	return &limitedReader{ctx: ctx, limiter: limiter, reader: bytes.NewReader(data)}
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.reader.Len() == 0 {
+		return 0, io.EOF
+	}
+	if len(p) > r.limiter.Burst() {
+		p = p[:r.limiter.Burst()]
+	}
+	if len(p) > r.reader.Len() {
+		p = p[:r.reader.Len()]
+	}
+	if err := r.limiter.WaitN(r.ctx, len(p)); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
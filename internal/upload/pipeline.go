@@ -0,0 +1,184 @@
+// Package upload uploads the artifacts of a run (snapshots and exports) concurrently, retrying failed uploads and
+// limiting the total bandwidth used, and reports the status of each artifact
+//
+// uploads are submitted to a Pipeline as they are produced, e.g. as each benchmark of a run completes, so that they
+// overlap with the rest of the run - Wait returns the status of each upload once all have completed
+package upload
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	DefaultConcurrency = 4
+	DefaultRetries     = 3
+	DefaultRetryDelay  = time.Second
+	// the maximum delay between attempts of an upload
+	maxRetryDelay = 30 * time.Second
+)
+
+// Options configures the concurrency, retries and bandwidth of a pipeline - zero values are the defaults
+type Options struct {
+	// the maximum number of concurrent uploads
+	Concurrency int
+	// the number of times a failed upload is retried - negative values disable retries
+	Retries int
+	// the delay before the first retry of an upload, which doubles with each retry
+	RetryDelay time.Duration
+	// the maximum total bandwidth of the uploads, in bytes per second - 0 is unlimited
+	BandwidthLimit int64
+}
+
+// Task is the upload of an artifact
+type Task struct {
+	// the name of the artifact, e.g. its file name
+	Name string
+	// the size of the artifact in bytes
+	Size int
+	// Upload uploads the artifact, returning its location (e.g. a url) - the request body should be created with
+	// NewRequest (or the upload should call Wait), so that the bandwidth limit of the pipeline applies
+	Upload func(ctx context.Context) (string, error)
+}
+
+// Status is the result of the upload of an artifact
+type Status struct {
+	Name     string
+	Size     int
+	Location string
+	Attempts int
+	Duration time.Duration
+	Error    error
+}
+
+// Pipeline uploads the submitted tasks concurrently
+type Pipeline struct {
+	ctx     context.Context
+	options Options
+	limiter *rate.Limiter
+	sem     chan struct{}
+	wg      sync.WaitGroup
+
+	mut      sync.Mutex
+	statuses []*Status
+}
+
+// NewPipeline returns a pipeline uploading tasks with the context - cancelling the context cancels the uploads
+func NewPipeline(ctx context.Context, options Options) *Pipeline {
+	if options.Concurrency <= 0 {
+		options.Concurrency = DefaultConcurrency
+	}
+	if options.Retries == 0 {
+		options.Retries = DefaultRetries
+	}
+	if options.RetryDelay <= 0 {
+		options.RetryDelay = DefaultRetryDelay
+	}
+	p := &Pipeline{
+		options: options,
+		sem:     make(chan struct{}, options.Concurrency),
+	}
+	if options.BandwidthLimit > 0 {
+		p.limiter = rate.NewLimiter(rate.Limit(options.BandwidthLimit), burst(options.BandwidthLimit))
+	}
+	p.ctx = context.WithValue(ctx, limiterKey{}, p.limiter)
+	return p
+}
+
+// Submit starts the upload of the task, once fewer than the maximum number of concurrent uploads are in progress
+func (p *Pipeline) Submit(task Task) {
+	status := &Status{Name: task.Name, Size: task.Size}
+	// (statuses are reported in the order the tasks are submitted)
+	p.mut.Lock()
+	p.statuses = append(p.statuses, status)
+	p.mut.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		select {
+		case p.sem <- struct{}{}:
+		case <-p.ctx.Done():
+			status.Error = p.ctx.Err()
+			return
+		}
+		defer func() { <-p.sem }()
+		p.run(task, status)
+	}()
+}
+
+// Wait waits for the submitted uploads to complete, returning the status of each upload in the order they were
+// submitted - the pipeline may not be used once Wait has been called
+func (p *Pipeline) Wait() []*Status {
+	p.wg.Wait()
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return p.statuses
+}
+
+// run uploads the task, retrying failed uploads (unless the error is permanent) with an exponential backoff
+func (p *Pipeline) run(task Task, status *Status) {
+	start := time.Now()
+	defer func() { status.Duration = time.Since(start) }()
+
+	delay := p.options.RetryDelay
+	for {
+		status.Attempts++
+		status.Location, status.Error = task.Upload(p.ctx)
+		if status.Error == nil || status.Attempts > p.options.Retries || !retryable(p.ctx, status.Error) {
+			return
+		}
+		select {
+		case <-time.After(delay):
+		case <-p.ctx.Done():
+			return
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+// Failed returns the statuses of the failed uploads
+func Failed(statuses []*Status) []*Status {
+	var res []*Status
+	for _, status := range statuses {
+		if status.Error != nil {
+			res = append(res, status)
+		}
+	}
+	return res
+}
+
+type permanentError struct {
+	error
+}
+
+func (e permanentError) Unwrap() error {
+	return e.error
+}
+
+// Permanent marks an error of an upload as permanent, e.g. an authorization failure, so the upload is not retried
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// PermanentStatus returns whether an upload failing with the http status code should not be retried - i.e. client
+// errors, other than timeouts and rate limiting
+func PermanentStatus(statusCode int) bool {
+	return statusCode/100 == 4 && statusCode != http.StatusRequestTimeout && statusCode != http.StatusTooManyRequests
+}
+
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var permanent permanentError
+	return !errors.As(err, &permanent)
+}
@@ -0,0 +1,194 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type pipelineTest struct {
+	retries int
+	// the errors of the attempts of the upload, after which it succeeds
+	errors []error
+	// the expected attempts and error of the upload
+	attempts int
+	err      string
+}
+
+var errUnavailable = errors.New("503 Service Unavailable")
+
+var testCasesPipeline = map[string]pipelineTest{
+	"success": {
+		attempts: 1,
+	},
+	"retried": {
+		errors:   []error{errUnavailable, errUnavailable},
+		attempts: 3,
+	},
+	"retries exhausted": {
+		errors:   []error{errUnavailable, errUnavailable, errUnavailable, errUnavailable},
+		attempts: 4,
+		err:      "503 Service Unavailable",
+	},
+	"no retries": {
+		retries:  -1,
+		errors:   []error{errUnavailable},
+		attempts: 1,
+		err:      "503 Service Unavailable",
+	},
+	"permanent": {
+		errors:   []error{Permanent(errors.New("403 Forbidden"))},
+		attempts: 1,
+		err:      "403 Forbidden",
+	},
+}
+
+func TestPipeline(t *testing.T) {
+	for name, test := range testCasesPipeline {
+		pipeline := NewPipeline(context.Background(), Options{Retries: test.retries, RetryDelay: time.Millisecond})
+		attempt := 0
+		pipeline.Submit(Task{
+			Name: "run.csv",
+			Size: 10,
+			Upload: func(ctx context.Context) (string, error) {
+				attempt++
+				if attempt <= len(test.errors) {
+					return "", test.errors[attempt-1]
+				}
+				return "s3://acme/run.csv", nil
+			},
+		})
+		statuses := pipeline.Wait()
+		if len(statuses) != 1 {
+			t.Fatalf("Test: '%s' FAILED : expected 1 status, got %d", name, len(statuses))
+		}
+		status := statuses[0]
+		var err string
+		if status.Error != nil {
+			err = status.Error.Error()
+		}
+		if status.Attempts != test.attempts || err != test.err {
+			t.Errorf("Test: '%s' FAILED : expected %d attempts (%q), got %d (%q)", name, test.attempts, test.err, status.Attempts, err)
+		}
+		if test.err == "" && status.Location != "s3://acme/run.csv" {
+			t.Errorf("Test: '%s' FAILED : expected location s3://acme/run.csv, got %s", name, status.Location)
+		}
+	}
+}
+
+func TestPipelineConcurrency(t *testing.T) {
+	pipeline := NewPipeline(context.Background(), Options{Concurrency: 2})
+	var running, maxRunning atomic.Int32
+	names := []string{"a", "b", "c", "d", "e"}
+	for _, name := range names {
+		pipeline.Submit(Task{
+			Name: name,
+			Upload: func(ctx context.Context) (string, error) {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					m := maxRunning.Load()
+					if n <= m || maxRunning.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				return name, nil
+			},
+		})
+	}
+	statuses := pipeline.Wait()
+
+	if maxRunning.Load() != 2 {
+		t.Errorf("Test: 'concurrency' FAILED : expected 2 concurrent uploads, got %d", maxRunning.Load())
+	}
+	// statuses are in the order the tasks were submitted
+	for idx, status := range statuses {
+		if status.Name != names[idx] || status.Location != names[idx] {
+			t.Errorf("Test: 'order' FAILED : expected status %d to be %s, got %s (%s)", idx, names[idx], status.Name, status.Location)
+		}
+	}
+	if failed := Failed(statuses); len(failed) != 0 {
+		t.Errorf("Test: 'failed' FAILED : expected no failed uploads, got %d", len(failed))
+	}
+}
+
+func TestPipelineCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pipeline := NewPipeline(ctx, Options{Concurrency: 1, RetryDelay: time.Hour})
+	started := make(chan struct{})
+	var once sync.Once
+	for _, name := range []string{"a", "b"} {
+		pipeline.Submit(Task{
+			Name: name,
+			Upload: func(ctx context.Context) (string, error) {
+				once.Do(func() { close(started) })
+				return "", errUnavailable
+			},
+		})
+	}
+	<-started
+	cancel()
+
+	for _, status := range pipeline.Wait() {
+		if status.Error == nil {
+			t.Errorf("Test: 'cancel' FAILED : expected the upload of %s to fail", status.Name)
+		}
+	}
+}
+
+func TestBandwidthLimit(t *testing.T) {
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		received = len(data)
+	}))
+	defer server.Close()
+
+	// 30KB at 20KB/s - the first 20KB are the burst of the limiter, so the upload takes at least half a second
+	data := make([]byte, 30*1024)
+	pipeline := NewPipeline(context.Background(), Options{BandwidthLimit: 20 * 1024})
+	pipeline.Submit(Task{
+		Name: "run.pps",
+		Size: len(data),
+		Upload: func(ctx context.Context) (string, error) {
+			req, err := NewRequest(ctx, http.MethodPut, server.URL, data)
+			if err != nil {
+				return "", Permanent(err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return "", Permanent(err)
+			}
+			resp.Body.Close()
+			return server.URL, nil
+		},
+	})
+	status := pipeline.Wait()[0]
+
+	if status.Error != nil || received != len(data) {
+		t.Fatalf("Test: 'upload' FAILED : expected %d bytes to be uploaded, got %d (%v)", len(data), received, status.Error)
+	}
+	if status.Duration < 400*time.Millisecond {
+		t.Errorf("Test: 'limit' FAILED : expected the upload to take at least 500ms, got %s", status.Duration)
+	}
+}
+
+func TestNewRequestUnlimited(t *testing.T) {
+	req, err := NewRequest(context.Background(), http.MethodPut, "https://example.com", []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.ContentLength != 4 {
+		t.Errorf("Test: 'unlimited' FAILED : expected content length 4, got %d", req.ContentLength)
+	}
+	if err := Wait(context.Background(), 1<<30); err != nil {
+		t.Errorf("Test: 'wait' FAILED : expected no error, got %v", err)
+	}
+}
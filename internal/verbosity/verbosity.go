@@ -0,0 +1,72 @@
+package verbosity
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/constants"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+// the verbosity of all commands is set using --quiet or --verbose:
+//   - quiet: only the artifacts of the command (its results, exported files or listings) are output - progress,
+//     timing, summaries and informational messages are suppressed. Errors and warnings are still displayed,
+//     and the exit code is unchanged
+//   - normal: the default
+//   - verbose: as well as the normal output, the timing of queries and controls is displayed, and details of what the
+//     command is doing (e.g. the workspace loaded and the database connected to) are written to stderr
+//
+// subsystems with their own verbosity settings (--progress and --timing) are set from the tier by Init
+// NOTE: these are set in addition to any explicit settings - so --quiet overrides --progress, and --verbose
+// overrides --timing=false
+
+// Init validates the verbosity flags and applies the verbosity tier to the progress and timing settings
+func Init() error {
+	if Quiet() && Verbose() {
+		return fmt.Errorf("only one of --%s and --%s may be set", localconstants.ArgQuiet, constants.ArgVerbose)
+	}
+	if Quiet() {
+		viper.Set(constants.ArgProgress, false)
+		viper.Set(constants.ArgTiming, false)
+	}
+	if Verbose() {
+		viper.Set(constants.ArgTiming, true)
+	}
+	return nil
+}
+
+// Quiet returns whether only the artifacts of the command should be output
+func Quiet() bool {
+	return viper.GetBool(localconstants.ArgQuiet)
+}
+
+// Verbose returns whether details of what the command is doing should be displayed
+func Verbose() bool {
+	return viper.GetBool(constants.ArgVerbose)
+}
+
+// Printf prints an informational message, such as a summary, to stdout - unless --quiet is set
+func Printf(format string, a ...any) {
+	if Quiet() {
+		return
+	}
+	fmt.Printf(format, a...) //nolint:forbidigo // intended output
+}
+
+// Println prints an informational message, such as a summary, to stdout - unless --quiet is set
+func Println(a ...any) {
+	if Quiet() {
+		return
+	}
+	fmt.Println(a...) //nolint:forbidigo // intended output
+}
+
+// Detailf writes a detail of what the command is doing to stderr, if --verbose is set
+// details are written to stderr so they do not affect the output of the command
+func Detailf(format string, a ...any) {
+	if !Verbose() {
+		return
+	}
+	fmt.Fprintf(os.Stderr, format+"\n", a...)
+}
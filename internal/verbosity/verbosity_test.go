@@ -0,0 +1,72 @@
+package verbosity
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/constants"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+type initTest struct {
+	quiet   bool
+	verbose bool
+	// the settings before Init
+	progress bool
+	timing   bool
+
+	expectedProgress bool
+	expectedTiming   bool
+	err              bool
+}
+
+var testCasesInit = map[string]initTest{
+	"normal": {
+		progress:         true,
+		expectedProgress: true,
+	},
+	"quiet": {
+		quiet:            true,
+		progress:         true,
+		timing:           true,
+		expectedProgress: false,
+		expectedTiming:   false,
+	},
+	"verbose": {
+		verbose:          true,
+		progress:         true,
+		expectedProgress: true,
+		expectedTiming:   true,
+	},
+	"quiet and verbose": {
+		quiet:   true,
+		verbose: true,
+		err:     true,
+	},
+}
+
+func TestInit(t *testing.T) {
+	defer viper.Reset()
+	for name, test := range testCasesInit {
+		viper.Reset()
+		viper.Set(localconstants.ArgQuiet, test.quiet)
+		viper.Set(constants.ArgVerbose, test.verbose)
+		viper.Set(constants.ArgProgress, test.progress)
+		viper.Set(constants.ArgTiming, test.timing)
+
+		err := Init()
+		if (err != nil) != test.err {
+			t.Errorf("Test: '%s' FAILED : expected error %v, got %v", name, test.err, err)
+			continue
+		}
+		if test.err {
+			continue
+		}
+		if actual := viper.GetBool(constants.ArgProgress); actual != test.expectedProgress {
+			t.Errorf("Test: '%s' FAILED : expected progress %v, got %v", name, test.expectedProgress, actual)
+		}
+		if actual := viper.GetBool(constants.ArgTiming); actual != test.expectedTiming {
+			t.Errorf("Test: '%s' FAILED : expected timing %v, got %v", name, test.expectedTiming, actual)
+		}
+	}
+}
@@ -5,12 +5,11 @@ import (
 	"os"
 
 	"github.com/spf13/viper"
-	"github.com/turbot/go-kit/helpers"
-	"github.com/turbot/pipe-fittings/error_helpers"
 	"github.com/turbot/pipe-fittings/utils"
 	"github.com/turbot/powerpipe/internal/cmd"
 	"github.com/turbot/powerpipe/internal/cmdconfig"
 	"github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/crash"
 )
 
 var exitCode int
@@ -34,7 +33,7 @@ func main() {
 
 	defer func() {
 		if r := recover(); r != nil {
-			error_helpers.ShowError(ctx, helpers.ToError(r))
+			_ = crash.Recover(ctx, r)
 			if exitCode == 0 {
 				exitCode = 255
 			}
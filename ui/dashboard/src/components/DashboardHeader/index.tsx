@@ -5,6 +5,7 @@ import OpenSnapshotButton from "@powerpipe/components/OpenSnapshotButton";
 import PowerpipeLogo from "@powerpipe/components/DashboardHeader/PowerpipeLogo";
 import SaveSnapshotButton from "@powerpipe/components/SaveSnapshotButton";
 import ThemeToggle from "@powerpipe/components/ThemeToggle";
+import UploadDatasetButton from "@powerpipe/components/UploadDatasetButton";
 import { classNames } from "@powerpipe/utils/styles";
 import { getComponent } from "@powerpipe/components/dashboards";
 
@@ -24,6 +25,7 @@ const DashboardHeader = () => {
           <DashboardTagGroupSelect />
           <SaveSnapshotButton />
           <OpenSnapshotButton />
+          <UploadDatasetButton />
         </div>
         <div className="space-x-2 sm:space-x-4 md:space-x-8 flex items-center justify-end">
           <ExternalLink
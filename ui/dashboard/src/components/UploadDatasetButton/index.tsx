@@ -0,0 +1,65 @@
+import { DashboardDataModeLive } from "@powerpipe/types";
+import { useDashboard } from "@powerpipe/hooks/useDashboard";
+import { useRef } from "react";
+
+// The dataset is named after the file, e.g. "Exceptions List.csv" can be
+// queried as the table "upload_exceptions_list"
+const datasetName = (fileName: string) =>
+  fileName
+    .replace(/\.[^.]*$/, "")
+    .toLowerCase()
+    .replace(/[^a-z0-9_]+/g, "_")
+    .replace(/^([0-9])/, "_$1");
+
+const UploadDatasetButton = () => {
+  const { dataMode, uploadDataset } = useDashboard();
+  const fileInputRef = useRef<HTMLInputElement | null>(null);
+
+  if (dataMode !== DashboardDataModeLive) {
+    return null;
+  }
+
+  return (
+    <>
+      <span
+        className="hidden md:inline text-base text-foreground-lighter hover:text-foreground cursor-pointer"
+        title="Upload a CSV or JSON file to query as a table in this session"
+        onClick={() => {
+          fileInputRef.current?.click();
+        }}
+      >
+        Upload data…
+      </span>
+      <input
+        ref={fileInputRef}
+        accept=".csv,.json"
+        className="hidden"
+        id="upload-dataset"
+        name="upload-dataset"
+        type="file"
+        onChange={(e) => {
+          const files = e.target.files;
+          if (!files || files.length === 0) {
+            return;
+          }
+          const fileName = files[0].name;
+          const fr = new FileReader();
+          fr.onload = () => {
+            e.target.value = "";
+            if (!fr.result) {
+              return;
+            }
+            uploadDataset(
+              datasetName(fileName),
+              fileName.toLowerCase().endsWith(".json") ? "json" : "csv",
+              fr.result.toString(),
+            );
+          };
+          fr.readAsText(files[0]);
+        }}
+      />
+    </>
+  );
+};
+
+export default UploadDatasetButton;
@@ -18,6 +18,8 @@ import {
   LeafNodeDataRow,
 } from "../common";
 import { classNames } from "@powerpipe/utils/styles";
+import { formatNumber, formatTimestamp } from "@powerpipe/utils/locale";
+import { useDashboard } from "@powerpipe/hooks/useDashboard";
 import {
   ErrorIcon,
   SortAscendingIcon,
@@ -119,6 +121,7 @@ const CellValue = ({
   showTitle = false,
 }: CellValueProps) => {
   const ExternalLink = getComponent("external_link");
+  const { metadata } = useDashboard();
   const [href, setHref] = useState<string | null>(null);
   const [error, setError] = useState<string | null>(null);
 
@@ -281,37 +284,39 @@ const CellValue = ({
       );
     }
   } else if (dataType === "timestamp" || dataType === "timestamptz") {
+    const formattedValue = formatTimestamp(value, dataType, metadata?.locale);
     cellContent = href ? (
       <ExternalLink
         to={href}
         className="link-highlight tabular-nums"
         title={showTitle ? `${column.title}=${value}` : undefined}
       >
-        {value}
+        {formattedValue}
       </ExternalLink>
     ) : (
       <span
         className="tabular-nums"
         title={showTitle ? `${column.title}=${value}` : undefined}
       >
-        {value}
+        {formattedValue}
       </span>
     );
   } else if (isNumericCol(dataType)) {
+    const formattedValue = formatNumber(value, column.name, metadata?.locale);
     cellContent = href ? (
       <ExternalLink
         to={href}
         className="link-highlight tabular-nums"
         title={showTitle ? `${column.title}=${value}` : undefined}
       >
-        {value.toLocaleString()}
+        {formattedValue}
       </ExternalLink>
     ) : (
       <span
         className="tabular-nums"
         title={showTitle ? `${column.title}=${value}` : undefined}
       >
-        {value.toLocaleString()}
+        {formattedValue}
       </span>
     );
   }
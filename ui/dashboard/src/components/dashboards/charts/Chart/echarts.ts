@@ -2,9 +2,11 @@ import * as echarts from "echarts/core";
 import {
   BarChart,
   GraphChart,
+  HeatmapChart,
   LineChart,
   PieChart,
   SankeyChart,
+  ScatterChart,
   TreeChart,
 } from "echarts/charts";
 import { CanvasRenderer } from "echarts/renderers";
@@ -15,6 +17,7 @@ import {
   TitleComponent,
   TooltipComponent,
   MarkLineComponent,
+  VisualMapComponent,
 } from "echarts/components";
 import { LabelLayout } from "echarts/features";
 
@@ -24,15 +27,18 @@ echarts.use([
   DatasetComponent,
   GraphChart,
   GridComponent,
+  HeatmapChart,
   LabelLayout,
   LegendComponent,
   LineChart,
   MarkLineComponent,
   PieChart,
   SankeyChart,
+  ScatterChart,
   TitleComponent,
   TooltipComponent,
   TreeChart,
+  VisualMapComponent,
 ]);
 
 export { echarts };
@@ -743,8 +743,130 @@ const buildChartOptions = (props: ChartProps, themeColors: any) => {
   return adjustGridConfig(config, props.properties);
 };
 
+// Heatmaps plot the value (3rd column) in a grid of the x (1st column) and y (2nd column) dimensions.
+// Colour thresholds are configured using the points of the value series, where each point name is the
+// lower bound of the threshold e.g.
+//
+// series "value" {
+//   point "0" { color = "ok" }
+//   point "50" { color = "info" }
+//   point "80" { color = "alert" }
+// }
+const getHeatmapThresholds = (
+  valueColumnName: string,
+  properties: ChartProperties | undefined,
+  themeColors,
+) => {
+  const points = properties?.series?.[valueColumnName]?.points || {};
+  return Object.entries(points)
+    .filter(
+      ([threshold, point]) => !isNaN(Number(threshold)) && !!point.color,
+    )
+    .map(([threshold, point]) => ({
+      gte: Number(threshold),
+      color: getColorOverride(point.color, themeColors),
+    }))
+    .sort((a, b) => a.gte - b.gte)
+    .map((piece, index, pieces) =>
+      // Each threshold applies up to the next threshold
+      index + 1 < pieces.length
+        ? { ...piece, lt: pieces[index + 1].gte }
+        : piece,
+    );
+};
+
+const buildHeatmapOptions = (props: ChartProps, themeColors: any) => {
+  const data = props.data;
+  if (!data || data.columns.length < 3) {
+    return null;
+  }
+  const [xColumn, yColumn, valueColumn] = data.columns;
+  const xValues: string[] = [];
+  const yValues: string[] = [];
+  const values: any[] = [];
+  let min: number | null = null;
+  let max: number | null = null;
+  for (const row of data.rows) {
+    const x = String(row[xColumn.name]);
+    const y = String(row[yColumn.name]);
+    const value = row[valueColumn.name];
+    let xIndex = xValues.indexOf(x);
+    if (xIndex === -1) {
+      xIndex = xValues.push(x) - 1;
+    }
+    let yIndex = yValues.indexOf(y);
+    if (yIndex === -1) {
+      yIndex = yValues.push(y) - 1;
+    }
+    values.push([xIndex, yIndex, value === null ? "-" : value]);
+    if (value !== null && !isNaN(Number(value))) {
+      min = min === null ? Number(value) : Math.min(min, Number(value));
+      max = max === null ? Number(value) : Math.max(max, Number(value));
+    }
+  }
+
+  const thresholds = getHeatmapThresholds(
+    valueColumn.name,
+    props.properties,
+    themeColors,
+  );
+  const visualMap =
+    thresholds.length > 0
+      ? { type: "piecewise", pieces: thresholds }
+      : {
+          type: "continuous",
+          min: min === null ? 0 : min,
+          max: max === null ? 0 : max,
+          inRange: {
+            color: [themeColors.foregroundLightest, themeColors.charts[0]],
+          },
+        };
+
+  const axis = {
+    type: "category",
+    axisLabel: { color: themeColors.foreground, fontSize: 10 },
+    axisLine: { lineStyle: { color: themeColors.foregroundLightest } },
+    axisTick: { show: false },
+    splitArea: { show: true },
+    nameLocation: "center",
+    nameTextStyle: { color: themeColors.foreground },
+  };
+
+  const config = merge(
+    getCommonBaseOptions(),
+    {
+      legend: { show: false },
+      grid: { bottom: "20%" },
+      xAxis: { ...axis, data: xValues, nameGap: 30 },
+      yAxis: { ...axis, data: yValues, nameGap: 50 },
+      visualMap: {
+        ...visualMap,
+        calculable: true,
+        orient: "horizontal",
+        left: "center",
+        bottom: 0,
+        textStyle: { color: themeColors.foreground, fontSize: 10 },
+      },
+      series: [
+        {
+          name: valueColumn.name,
+          type: "heatmap",
+          data: values,
+          label: { show: values.length <= 100, fontSize: 10 },
+          itemStyle: {
+            borderColor: themeColors.dashboardPanel,
+            borderWidth: 1,
+          },
+        },
+      ],
+    },
+    getOptionOverridesForChartType("heatmap", props.properties, false),
+  );
+  return adjustGridConfig(config, props.properties);
+};
+
 type ChartComponentProps = {
-  options: EChartsOption;
+  options: EChartsOption | null;
   type: ChartType | FlowType | GraphType | HierarchyType;
 };
 
@@ -850,7 +972,11 @@ const ChartWrapper = (props: ChartProps) => {
 
   return (
     <Chart
-      options={buildChartOptions(props, themeColors)}
+      options={
+        props.display_type === "heatmap"
+          ? buildHeatmapOptions(props, themeColors)
+          : buildChartOptions(props, themeColors)
+      }
       type={props.display_type || "column"}
     />
   );
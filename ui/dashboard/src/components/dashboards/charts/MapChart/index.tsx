@@ -0,0 +1,136 @@
+import useChartThemeColors from "@powerpipe/hooks/useChartThemeColors";
+import { Chart } from "@powerpipe/components/dashboards/charts/Chart";
+import {
+  ChartProps,
+  IChart,
+} from "@powerpipe/components/dashboards/charts/types";
+import { EChartsOption } from "echarts-for-react/src/types";
+import { getColorOverride } from "@powerpipe/components/dashboards/common";
+import { getRegionCoordinates } from "./regions";
+import { registerChartComponent } from "@powerpipe/components/dashboards/charts";
+import { useDashboard } from "@powerpipe/hooks/useDashboard";
+
+const latitudeColumns = ["latitude", "lat"];
+const longitudeColumns = ["longitude", "lon", "lng"];
+
+const getNumber = (row, columns: string[]): number | null => {
+  for (const column of columns) {
+    const value = row[column];
+    if (value !== null && value !== undefined && !isNaN(Number(value))) {
+      return Number(value);
+    }
+  }
+  return null;
+};
+
+// Rows are plotted using their latitude & longitude columns, or else the
+// coordinates of their region column. An optional category column colours the
+// points (overridden using the chart series colours) and an optional value
+// column sizes them.
+const buildMapOptions = (props: ChartProps, themeColors: any) => {
+  const data = props.data;
+  if (!data) {
+    return null;
+  }
+
+  const categories: { [category: string]: any[] } = {};
+  let maxValue = 0;
+  for (const row of data.rows) {
+    let longitude = getNumber(row, longitudeColumns);
+    let latitude = getNumber(row, latitudeColumns);
+    if (longitude === null || latitude === null) {
+      const regionCoordinates = getRegionCoordinates(row.region);
+      if (!regionCoordinates) {
+        continue;
+      }
+      [longitude, latitude] = regionCoordinates;
+    }
+    const value = getNumber(row, ["value"]);
+    if (value !== null) {
+      maxValue = Math.max(maxValue, value);
+    }
+    const category =
+      row.category !== null && row.category !== undefined
+        ? String(row.category)
+        : "";
+    categories[category] = categories[category] || [];
+    categories[category].push({
+      name: row.title || row.name || row.region || "",
+      value: [longitude, latitude, value],
+    });
+  }
+
+  const series = Object.entries(categories).map(
+    ([category, points], index) => {
+      const seriesOverrides = props.properties?.series?.[category];
+      return {
+        name: seriesOverrides?.title || category,
+        type: "scatter",
+        data: points,
+        symbolSize: (value) =>
+          value[2] !== null && maxValue > 0
+            ? 6 + 24 * (value[2] / maxValue)
+            : 10,
+        itemStyle: {
+          color: seriesOverrides?.color
+            ? getColorOverride(seriesOverrides.color, themeColors)
+            : themeColors.charts[index % themeColors.charts.length],
+          opacity: 0.8,
+        },
+      };
+    },
+  );
+
+  // Plot longitude/latitude on an equirectangular grid, with the grid lines
+  // acting as a graticule
+  const axis = {
+    type: "value",
+    axisLabel: { color: themeColors.foreground, fontSize: 10 },
+    axisLine: { show: false },
+    axisTick: { show: false },
+    splitLine: { lineStyle: { color: themeColors.foregroundLightest } },
+  };
+  return {
+    animation: false,
+    grid: { left: 40, right: 20, top: 40, bottom: 30 },
+    legend: {
+      show: series.length > 1,
+      top: 10,
+      textStyle: { color: themeColors.foreground, fontSize: 11 },
+    },
+    tooltip: {
+      appendToBody: true,
+      trigger: "item",
+      textStyle: { fontSize: 11 },
+      formatter: (params) =>
+        `${params.data.name}${
+          params.data.value[2] !== null ? `: ${params.data.value[2]}` : ""
+        }`,
+    },
+    xAxis: { ...axis, min: -180, max: 180, interval: 30 },
+    yAxis: { ...axis, min: -90, max: 90, interval: 30 },
+    series,
+  } as EChartsOption;
+};
+
+const MapChart = (props: ChartProps) => {
+  const {
+    themeContext: { wrapperRef },
+  } = useDashboard();
+  const themeColors = useChartThemeColors();
+
+  if (!wrapperRef || !props.data) {
+    return null;
+  }
+
+  return <Chart options={buildMapOptions(props, themeColors)} type="map" />;
+};
+
+const definition: IChart = {
+  type: "map",
+  component: MapChart,
+};
+
+registerChartComponent(definition.type, definition);
+
+export default definition;
@@ -0,0 +1,99 @@
+// Approximate coordinates of common cloud provider regions, used to plot rows
+// which have a region code rather than a latitude and longitude.
+type RegionCoordinates = {
+  [region: string]: [number, number];
+};
+
+// [longitude, latitude]
+const regionCoordinates: RegionCoordinates = {
+  // AWS
+  "af-south-1": [18.42, -33.92],
+  "ap-east-1": [114.17, 22.32],
+  "ap-northeast-1": [139.69, 35.69],
+  "ap-northeast-2": [126.98, 37.57],
+  "ap-northeast-3": [135.5, 34.69],
+  "ap-south-1": [72.88, 19.08],
+  "ap-south-2": [78.49, 17.39],
+  "ap-southeast-1": [103.82, 1.35],
+  "ap-southeast-2": [151.21, -33.87],
+  "ap-southeast-3": [106.85, -6.21],
+  "ap-southeast-4": [144.96, -37.81],
+  "ca-central-1": [-73.57, 45.5],
+  "ca-west-1": [-114.07, 51.05],
+  "eu-central-1": [8.68, 50.11],
+  "eu-central-2": [8.54, 47.37],
+  "eu-north-1": [18.07, 59.33],
+  "eu-south-1": [9.19, 45.46],
+  "eu-south-2": [-0.88, 41.65],
+  "eu-west-1": [-6.26, 53.35],
+  "eu-west-2": [-0.13, 51.51],
+  "eu-west-3": [2.35, 48.86],
+  "il-central-1": [34.78, 32.09],
+  "me-central-1": [55.27, 25.2],
+  "me-south-1": [50.59, 26.07],
+  "sa-east-1": [-46.63, -23.55],
+  "us-east-1": [-77.47, 39.04],
+  "us-east-2": [-82.99, 39.96],
+  "us-gov-east-1": [-82.99, 39.96],
+  "us-gov-west-1": [-122.68, 45.52],
+  "us-west-1": [-122.42, 37.77],
+  "us-west-2": [-122.68, 45.52],
+  // Azure
+  australiaeast: [151.21, -33.87],
+  brazilsouth: [-46.63, -23.55],
+  canadacentral: [-79.38, 43.65],
+  centralindia: [73.86, 18.52],
+  centralus: [-93.62, 41.59],
+  eastasia: [114.17, 22.32],
+  eastus: [-79.82, 37.37],
+  eastus2: [-78.39, 36.68],
+  francecentral: [2.35, 48.86],
+  germanywestcentral: [8.68, 50.11],
+  japaneast: [139.69, 35.69],
+  koreacentral: [126.98, 37.57],
+  northcentralus: [-87.63, 41.88],
+  northeurope: [-6.26, 53.35],
+  norwayeast: [10.75, 59.91],
+  southafricanorth: [28.05, -26.2],
+  southcentralus: [-98.49, 29.42],
+  southeastasia: [103.82, 1.35],
+  swedencentral: [17.14, 60.67],
+  switzerlandnorth: [8.54, 47.37],
+  uaenorth: [55.27, 25.2],
+  uksouth: [-0.13, 51.51],
+  westeurope: [4.9, 52.37],
+  westus: [-122.42, 37.77],
+  westus2: [-119.85, 47.23],
+  westus3: [-112.07, 33.45],
+  // GCP
+  "asia-east1": [120.52, 24.05],
+  "asia-east2": [114.17, 22.32],
+  "asia-northeast1": [139.69, 35.69],
+  "asia-northeast3": [126.98, 37.57],
+  "asia-south1": [72.88, 19.08],
+  "asia-southeast1": [103.82, 1.35],
+  "australia-southeast1": [151.21, -33.87],
+  "europe-north1": [26.95, 60.57],
+  "europe-west1": [3.81, 50.45],
+  "europe-west2": [-0.13, 51.51],
+  "europe-west3": [8.68, 50.11],
+  "europe-west4": [6.83, 53.44],
+  "northamerica-northeast1": [-73.57, 45.5],
+  "southamerica-east1": [-46.63, -23.55],
+  "us-central1": [-95.86, 41.26],
+  "us-east1": [-80.04, 33.2],
+  "us-east4": [-77.47, 39.04],
+  "us-west1": [-121.18, 45.6],
+  "us-west2": [-118.24, 34.05],
+};
+
+const getRegionCoordinates = (
+  region: string | null | undefined,
+): [number, number] | null => {
+  if (!region) {
+    return null;
+  }
+  return regionCoordinates[region.toLowerCase()] || null;
+};
+
+export { getRegionCoordinates };
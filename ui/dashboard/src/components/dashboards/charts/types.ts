@@ -88,7 +88,9 @@ export type ChartType =
   | "bar"
   | "column"
   | "donut"
+  | "heatmap"
   | "line"
+  | "map"
   | "pie"
   | "table";
 
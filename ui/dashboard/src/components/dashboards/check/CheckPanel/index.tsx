@@ -27,6 +27,7 @@ import {
   CheckSeveritySummary,
 } from "../common";
 import { classNames } from "@powerpipe/utils/styles";
+import { getComponent } from "@powerpipe/components/dashboards";
 import { useMemo } from "react";
 
 type CheckChildrenProps = {
@@ -67,6 +68,10 @@ type CheckErrorRowProps = {
   error: string;
 };
 
+type CheckRemediationRowProps = {
+  result: CheckResult;
+};
+
 type CheckResultRowStatusIconProps = {
   status: CheckResultStatus;
 };
@@ -143,6 +148,7 @@ const getCheckResultRowIconTitle = (status: CheckResultStatus) => {
 };
 
 const CheckResultRow = ({ result }: CheckResultRowProps) => {
+  const ExternalLink = getComponent("external_link");
   return (
     <div className="flex bg-dashboard-panel print:bg-white p-4 last:rounded-b-md space-x-4">
       <div
@@ -152,7 +158,15 @@ const CheckResultRow = ({ result }: CheckResultRowProps) => {
         <CheckResultRowStatusIcon status={result.status} />
       </div>
       <div className="flex flex-col md:flex-row flex-grow">
-        <div className="md:flex-grow leading-4 mt-px">{result.reason}</div>
+        <div className="md:flex-grow leading-4 mt-px">
+          {result.href ? (
+            <ExternalLink to={result.href} title={result.resource}>
+              {result.reason}
+            </ExternalLink>
+          ) : (
+            result.reason
+          )}
+        </div>
         <div className="flex space-x-2 mt-2 md:mt-px md:text-right">
           {(result.dimensions || []).map((dimension) => (
             <ControlDimension
@@ -195,6 +209,55 @@ const CheckErrorRow = ({ error }: CheckErrorRowProps) => {
   );
 };
 
+const CheckRemediationRow = ({ result }: CheckRemediationRowProps) => {
+  const ExternalLink = getComponent("external_link");
+  const description = result.tags.remediation;
+  const docUrl = result.tags.remediation_url;
+  const snippets = [
+    result.tags.remediation_cli,
+    result.tags.remediation_terraform,
+  ].filter((snippet) => !!snippet);
+  return (
+    <div className="bg-dashboard-panel print:bg-white p-4 space-y-2">
+      <div className="leading-4">
+        <span className="font-semibold">Remediation: </span>
+        {description}
+        {docUrl && (
+          <>
+            {" "}
+            <ExternalLink to={docUrl}>Documentation</ExternalLink>
+          </>
+        )}
+      </div>
+      {snippets.map((snippet, idx) => (
+        <pre
+          key={idx}
+          className="p-2 rounded-md bg-dashboard text-sm whitespace-pre-wrap break-all"
+        >
+          {snippet}
+        </pre>
+      ))}
+    </div>
+  );
+};
+
+// show the remediation for each control with alarm or error results
+const getRemediationResults = (results: ControlResultNode[]) => {
+  const remediations: { [control: string]: CheckResult } = {};
+  for (const resultNode of results) {
+    const result = resultNode.result;
+    if (
+      (result.status === CheckResultStatus.alarm ||
+        result.status === CheckResultStatus.error) &&
+      (result.tags.remediation || result.tags.remediation_url) &&
+      !remediations[result.control.name]
+    ) {
+      remediations[result.control.name] = result;
+    }
+  }
+  return Object.values(remediations);
+};
+
 const CheckResults = ({ empties, errors, results }: CheckResultsProps) => {
   if (empties.length === 0 && errors.length === 0 && results.length === 0) {
     return null;
@@ -212,6 +275,12 @@ const CheckResults = ({ empties, errors, results }: CheckResultsProps) => {
       {errors.map((errorNode) => (
         <CheckErrorRow key={`${errorNode.name}`} error={errorNode.error} />
       ))}
+      {getRemediationResults(results).map((result) => (
+        <CheckRemediationRow
+          key={`${result.control.name}-remediation`}
+          result={result}
+        />
+      ))}
       {results.map((resultNode) => (
         <CheckResultRow
           key={`${resultNode.result.control.name}-${
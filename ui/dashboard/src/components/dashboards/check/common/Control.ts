@@ -264,7 +264,9 @@ class Control implements CheckNode {
       if (
         col.name === "reason" ||
         col.name === "resource" ||
-        col.name === "status"
+        col.name === "status" ||
+        col.name === "href" ||
+        col.name === "fingerprint"
       ) {
         continue;
       }
@@ -275,6 +277,8 @@ class Control implements CheckNode {
         reason: row.reason,
         resource: row.resource,
         status: row.status,
+        href: row.href || undefined,
+        fingerprint: row.fingerprint || undefined,
         dimensions: dimensionColumns.map((col) => ({
           key: col.name,
           value: row[col.name],
@@ -91,6 +91,8 @@ export type CheckResult = {
   status: CheckResultStatus;
   reason: string;
   resource: string;
+  href?: string;
+  fingerprint?: string;
   severity?: CheckSeverity;
   error?: string;
   type: CheckResultType;
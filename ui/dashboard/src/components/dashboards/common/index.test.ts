@@ -299,6 +299,7 @@ describe("common.buildNodesAndEdges", () => {
       title: null,
       category: null,
       row_data: null,
+      weight: null,
       isFolded: false,
     };
     expect(nodesAndEdges).toEqual({
@@ -351,6 +352,7 @@ describe("common.buildNodesAndEdges", () => {
       title: null,
       category: null,
       row_data: null,
+      weight: null,
       isFolded: false,
     };
     const nodesAndEdges = buildNodesAndEdges({}, rawData);
@@ -386,6 +388,7 @@ describe("common.buildNodesAndEdges", () => {
       title: null,
       category: null,
       row_data: null,
+      weight: null,
       isFolded: false,
     };
     const node = {
@@ -461,6 +464,7 @@ describe("common.buildNodesAndEdges", () => {
       title: null,
       category: null,
       row_data: { from_id: "from_node", to_id: "to_node" },
+      weight: null,
       isFolded: false,
     };
     const sourceNode = {
@@ -500,6 +504,22 @@ describe("common.buildNodesAndEdges", () => {
     });
   });
 
+  test("weighted edge", () => {
+    const rawData = {
+      columns: [
+        { name: "from_id", data_type: "TEXT" },
+        { name: "to_id", data_type: "TEXT" },
+        { name: "weight", data_type: "INT4" },
+      ],
+      rows: [
+        { from_id: "from_node", to_id: "to_node", weight: 10 },
+        { from_id: "from_node", to_id: "other_node", weight: "not a number" },
+      ],
+    };
+    const nodesAndEdges = buildNodesAndEdges({}, rawData);
+    expect(nodesAndEdges.edges.map((edge) => edge.weight)).toEqual([10, null]);
+  });
+
   test("two nodes with separate edge declaration and title set on explicit node rows", () => {
     const rawData = {
       columns: [
@@ -521,6 +541,7 @@ describe("common.buildNodesAndEdges", () => {
       title: null,
       category: null,
       row_data: { from_id: "from_node", to_id: "to_node" },
+      weight: null,
       isFolded: false,
     };
     const sourceNode = {
@@ -592,6 +613,7 @@ describe("common.buildNodesAndEdges", () => {
         title: "The Edge",
         properties: { foobar: "barfoo" },
       },
+      weight: null,
       isFolded: false,
     };
     const sourceNode = {
@@ -715,6 +737,7 @@ const createEdge = ({ id, from_id, to_id }): Edge => {
     category: null,
     row_data: null,
     title: null,
+    weight: null,
     isFolded: false,
   };
 };
@@ -832,6 +855,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
       },
       edges: [
@@ -843,6 +867,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
       ],
       root_nodes: { [node_c1_1.id]: node_c1_1 },
@@ -1008,6 +1033,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
         "c3-1_fold-c2-1": {
           id: "c3-1_fold-c2-1",
@@ -1017,6 +1043,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
       },
       edges: [
@@ -1028,6 +1055,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
         {
           id: "c3-1_fold-c2-1",
@@ -1037,6 +1065,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
       ],
       root_nodes: { [node_c1_1.id]: node_c1_1, [node_c3_1.id]: node_c3_1 },
@@ -1249,6 +1278,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
         "c3-1_fold-c2-1": {
           id: "c3-1_fold-c2-1",
@@ -1258,6 +1288,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
         "c4-1_fold-c2-1": {
           id: "c4-1_fold-c2-1",
@@ -1267,6 +1298,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
       },
       edges: [
@@ -1278,6 +1310,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
         {
           id: "c3-1_fold-c2-1",
@@ -1287,6 +1320,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
         {
           id: "c4-1_fold-c2-1",
@@ -1296,6 +1330,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
       ],
       root_nodes: {
@@ -1448,6 +1483,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
       },
       edges: [
@@ -1461,6 +1497,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
       ],
       root_nodes: { [node_c1_1.id]: node_c1_1, [node_c3_1.id]: node_c3_1 },
@@ -1748,6 +1785,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
         "c3-1_fold-c2-1": {
           id: "c3-1_fold-c2-1",
@@ -1757,6 +1795,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
       },
       edges: [
@@ -1771,6 +1810,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
         {
           id: "c3-1_fold-c2-1",
@@ -1780,6 +1820,7 @@ describe("common.foldNodesAndEdges", () => {
           title: null,
           isFolded: true,
           row_data: null,
+          weight: null,
         },
       ],
       root_nodes: {
@@ -259,6 +259,7 @@ const recordEdge = (
   title: string | null = null,
   category: string | null = null,
   row_data: LeafNodeDataRow | null = null,
+  weight: number | null = null,
 ) => {
   let duplicate_edge = false;
   // Find any existing edge
@@ -272,6 +273,7 @@ const recordEdge = (
     title,
     category,
     row_data,
+    weight,
     isFolded: false,
   };
 
@@ -539,6 +541,7 @@ const foldNodesAndEdges = (
             title: sourceEdgeTitle,
             isFolded: true,
             row_data: null,
+            weight: null,
           };
           newNodesAndEdges.edgeMap[edge.id] = edge;
         }
@@ -659,6 +662,13 @@ const buildNodesAndEdges = (
     const category: string | null = row.category || null;
     const depth: number | null =
       typeof row.depth === "number" ? row.depth : null;
+    // Edges may be weighted (e.g. by traffic or capacity) using a numeric weight column
+    const weight: number | null =
+      row.weight !== null &&
+      row.weight !== undefined &&
+      !isNaN(Number(row.weight))
+        ? Number(row.weight)
+        : null;
 
     if (category && !categories[category]) {
       const overrides = categoryProperties[category];
@@ -779,6 +789,10 @@ const buildNodesAndEdges = (
           edge_lookup,
           from_id,
           node_id,
+          null,
+          null,
+          null,
+          weight,
         );
         if (duplicate_edge) {
           contains_duplicate_edges = true;
@@ -811,6 +825,10 @@ const buildNodesAndEdges = (
           edge_lookup,
           node_id,
           to_id,
+          null,
+          null,
+          null,
+          weight,
         );
         if (duplicate_edge) {
           contains_duplicate_edges = true;
@@ -866,6 +884,7 @@ const buildNodesAndEdges = (
         title,
         category,
         nodeAndEdgeMask === 6 ? row : null,
+        weight,
       );
       if (duplicate_edge) {
         contains_duplicate_edges = true;
@@ -917,7 +936,8 @@ const buildSankeyDataInputs = (
     links.push({
       source: edge.from_id,
       target: edge.to_id,
-      value: 0.01,
+      // Weighted edges are sized by their weight
+      value: edge.weight !== null ? edge.weight : 0.01,
       lineStyle: {
         color:
           categoryOverrides && categoryOverrides.color
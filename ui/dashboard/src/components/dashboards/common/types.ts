@@ -90,6 +90,7 @@ export type Edge = {
   title: string | null;
   category: string | null;
   row_data: LeafNodeDataRow | null;
+  weight: number | null;
   isFolded: boolean;
 };
 
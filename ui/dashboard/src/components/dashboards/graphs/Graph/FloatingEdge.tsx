@@ -22,6 +22,7 @@ const FloatingEdge = ({
     properties,
     labelOpacity,
     lineOpacity,
+    strokeWidth = 1,
     row_data,
     label,
     themeColors,
@@ -100,7 +101,7 @@ const FloatingEdge = ({
           ...(style || {}),
           opacity: lineOpacity,
           stroke: color,
-          strokeWidth: 1,
+          strokeWidth,
         }}
       />
       <EdgeLabelRenderer>
@@ -188,6 +188,11 @@ const buildGraphNodesAndEdges = (
       },
     });
   }
+  // Weighted edges are drawn with a stroke width relative to the maximum weight
+  const maxEdgeWeight = Math.max(
+    0,
+    ...nodesAndEdges.edges.map((e) => (e.weight !== null ? e.weight : 0)),
+  );
   for (const edge of nodesAndEdges.edges) {
     // The color rules are:
     // 1) If the target node of the edge specifies a category and that
@@ -245,6 +250,10 @@ const buildGraphNodesAndEdges = (
         properties: matchingCategory ? matchingCategory.properties : null,
         labelOpacity,
         lineOpacity,
+        strokeWidth:
+          edge.weight !== null && maxEdgeWeight > 0
+            ? 1 + 5 * (edge.weight / maxEdgeWeight)
+            : 1,
         row_data: edge.row_data,
         label: getNodeOrEdgeLabel(edge, matchingCategory),
         themeColors,
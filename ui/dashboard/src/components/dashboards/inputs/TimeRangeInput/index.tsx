@@ -0,0 +1,220 @@
+import Select from "react-select";
+import useSelectInputStyles from "@powerpipe/components/dashboards/inputs/common/useSelectInputStyles";
+import { DashboardActions, DashboardDataModeLive } from "@powerpipe/types";
+import {
+  IInput,
+  InputProps,
+  SelectOption,
+} from "@powerpipe/components/dashboards/inputs/types";
+import { SubmitIcon } from "@powerpipe/constants/icons";
+import { registerInputComponent } from "@powerpipe/components/dashboards/inputs";
+import { useDashboard } from "@powerpipe/hooks/useDashboard";
+import { useEffect, useMemo, useState } from "react";
+
+// The value of a time range input is either a relative range ending now, e.g. "24h",
+// or an absolute range of two ISO 8601 times separated by a "/"
+const defaultTimeRange = "24h";
+const customRangeValue = "custom";
+
+const defaultPresets: SelectOption[] = [
+  { label: "Last 15 minutes", value: "15m" },
+  { label: "Last hour", value: "1h" },
+  { label: "Last 6 hours", value: "6h" },
+  { label: "Last 24 hours", value: "24h" },
+  { label: "Last 7 days", value: "7d" },
+  { label: "Last 30 days", value: "30d" },
+  { label: "Last 90 days", value: "90d" },
+];
+
+const customRangeOption: SelectOption = {
+  label: "Custom range",
+  value: customRangeValue,
+};
+
+const isAbsoluteRange = (value: string | undefined) =>
+  !!value && value.indexOf("/") >= 0;
+
+// Convert between the ISO 8601 time of a range and the local time of a datetime-local input
+const toLocalInputValue = (isoTime: string) => {
+  const date = new Date(isoTime);
+  if (isNaN(date.getTime())) {
+    return "";
+  }
+  const offsetMs = date.getTimezoneOffset() * 60 * 1000;
+  return new Date(date.getTime() - offsetMs).toISOString().slice(0, 16);
+};
+
+const fromLocalInputValue = (localTime: string) =>
+  new Date(localTime).toISOString().replace(/\.\d{3}Z$/, "Z");
+
+const TimeRangeInput = ({ name, properties, tags }: InputProps) => {
+  const { dataMode, dispatch, selectedDashboardInputs } = useDashboard();
+  const stateValue = selectedDashboardInputs[name];
+  const defaultValue = tags?.time_range_default || defaultTimeRange;
+  const [showCustom, setShowCustom] = useState(isAbsoluteRange(stateValue));
+  const [customFrom, setCustomFrom] = useState("");
+  const [customTo, setCustomTo] = useState("");
+
+  // The input options, if there are any, replace the default presets
+  const options = useMemo<SelectOption[]>(() => {
+    const presets =
+      properties.options && properties.options.length > 0
+        ? properties.options.map((option) => ({
+            label: option.label || option.name,
+            value: option.name,
+          }))
+        : defaultPresets;
+    return [...presets, customRangeOption];
+  }, [properties.options]);
+
+  // Panels using the time range wait for it to be set, so if there is no value, set the default
+  useEffect(() => {
+    if (stateValue) {
+      return;
+    }
+    dispatch({
+      type: DashboardActions.SET_DASHBOARD_INPUT,
+      name,
+      value: defaultValue,
+      recordInputsHistory: false,
+    });
+  }, [defaultValue, dispatch, name, stateValue]);
+
+  // Keep the custom range fields in sync with the state
+  useEffect(() => {
+    if (!isAbsoluteRange(stateValue)) {
+      return;
+    }
+    const [from, to] = stateValue.split("/");
+    setCustomFrom(toLocalInputValue(from));
+    setCustomTo(toLocalInputValue(to));
+    setShowCustom(true);
+  }, [stateValue]);
+
+  const selectedOption = useMemo(() => {
+    if (showCustom) {
+      return customRangeOption;
+    }
+    return (
+      options.find((option) => option.value === stateValue) || {
+        label: stateValue,
+        value: stateValue,
+      }
+    );
+  }, [options, showCustom, stateValue]);
+
+  const setValue = (value: string) => {
+    dispatch({
+      type: DashboardActions.SET_DASHBOARD_INPUT,
+      name,
+      value,
+      recordInputsHistory: true,
+    });
+  };
+
+  const updateOption = (option: SelectOption) => {
+    if (option.value === customRangeValue) {
+      setShowCustom(true);
+      return;
+    }
+    setShowCustom(false);
+    if (option.value) {
+      setValue(option.value);
+    }
+  };
+
+  const canSubmitCustom =
+    !!customFrom && !!customTo && new Date(customFrom) < new Date(customTo);
+
+  const submitCustom = () => {
+    if (!canSubmitCustom) {
+      return;
+    }
+    setValue(
+      `${fromLocalInputValue(customFrom)}/${fromLocalInputValue(customTo)}`,
+    );
+  };
+
+  const styles = useSelectInputStyles();
+
+  if (!styles) {
+    return null;
+  }
+
+  const readOnly = dataMode !== DashboardDataModeLive;
+
+  return (
+    <form
+      onSubmit={(e) => {
+        e.preventDefault();
+        submitCustom();
+      }}
+    >
+      {properties.label && (
+        <label
+          className="block mb-1 text-sm"
+          id={`${name}.label`}
+          htmlFor={`${name}.input`}
+        >
+          {properties.label}
+        </label>
+      )}
+      <Select
+        aria-labelledby={`${name}.input`}
+        className="basic-single"
+        classNamePrefix="select"
+        // @ts-ignore as this element definitely exists
+        menuPortalTarget={document.getElementById("portals")}
+        inputId={`${name}.input`}
+        isDisabled={readOnly}
+        isSearchable={false}
+        name={name}
+        // @ts-ignore
+        onChange={updateOption}
+        options={options}
+        placeholder={properties.placeholder}
+        styles={styles}
+        value={selectedOption}
+      />
+      {showCustom && (
+        <div className="flex items-center space-x-2 mt-2">
+          <input
+            type="datetime-local"
+            aria-label="From"
+            className="flex-1 block w-full bg-dashboard-panel rounded-md border border-black-scale-3 text-sm focus:ring-0"
+            onChange={(e) => setCustomFrom(e.target.value)}
+            readOnly={readOnly}
+            value={customFrom}
+          />
+          <input
+            type="datetime-local"
+            aria-label="To"
+            className="flex-1 block w-full bg-dashboard-panel rounded-md border border-black-scale-3 text-sm focus:ring-0"
+            onChange={(e) => setCustomTo(e.target.value)}
+            readOnly={readOnly}
+            value={customTo}
+          />
+          {!readOnly && (
+            <button
+              type="submit"
+              className="text-foreground-light disabled:opacity-50"
+              disabled={!canSubmitCustom}
+              title="Apply"
+            >
+              <SubmitIcon className="h-4 w-4" />
+            </button>
+          )}
+        </div>
+      )}
+    </form>
+  );
+};
+
+const definition: IInput = {
+  type: "time_range",
+  component: TimeRangeInput,
+};
+
+registerInputComponent(definition.type, definition);
+
+export default definition;
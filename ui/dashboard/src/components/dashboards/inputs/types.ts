@@ -39,7 +39,8 @@ export type InputType =
   | "multiselect"
   | "select"
   | "table"
-  | "text";
+  | "text"
+  | "time_range";
 
 export type IInput = {
   type: InputType;
@@ -6,6 +6,7 @@ import Grid from "../Grid";
 import PanelDetail from "../PanelDetail";
 import SnapshotRenderComplete from "@powerpipe/components/snapshot/SnapshotRenderComplete";
 import { DashboardControlsProvider } from "./DashboardControlsProvider";
+import { ErrorIcon } from "@powerpipe/constants/icons";
 import {
   DashboardDataModeCLISnapshot,
   DashboardDataModeLive,
@@ -21,6 +22,30 @@ type DashboardProps = {
   withPadding?: boolean;
 };
 
+type DeprecationWarningsProps = {
+  warnings: string[];
+};
+
+// Warns of the deprecated resources the dashboard is or references, so mod users can migrate
+const DeprecationWarnings = ({ warnings }: DeprecationWarningsProps) => {
+  if (!warnings || warnings.length === 0) {
+    return null;
+  }
+  return (
+    <div
+      className="col-span-12 flex space-x-2 p-3 rounded-md border border-alert bg-alert-light text-sm text-foreground print:hidden"
+      role="alert"
+    >
+      <ErrorIcon className="w-4 h-4 text-alert shrink-0" />
+      <ul className="space-y-1">
+        {warnings.map((warning) => (
+          <li key={warning}>{warning}</li>
+        ))}
+      </ul>
+    </div>
+  );
+};
+
 type DashboardWrapperProps = {
   showPanelControls?: boolean;
 };
@@ -34,12 +59,25 @@ const Dashboard = ({
     components: { SnapshotHeader },
     dataMode,
     showCustomizeBenchmarkPanel,
+    warnings,
   } = useDashboard();
+  // the server re-executes panels on the dashboard refresh interval and sends us the updated results
+  const refreshInterval = definition.tags?.refresh_interval;
   const grid = (
     <Grid name={definition.name} width={isRoot ? 12 : definition.width}>
       {isRoot && !definition.artificial && (
-        <DashboardTitle title={definition.title} />
+        <DashboardTitle
+          title={definition.title}
+          controls={
+            dataMode === DashboardDataModeLive && refreshInterval ? (
+              <span className="text-sm text-foreground-lighter print:hidden">
+                Auto-refresh every {refreshInterval}
+              </span>
+            ) : undefined
+          }
+        />
       )}
+      {isRoot && <DeprecationWarnings warnings={warnings} />}
       <Children
         children={definition.children}
         parentType="dashboard"
@@ -5,8 +5,12 @@ import usePanelDependenciesStatus from "@powerpipe/hooks/usePanelDependenciesSta
 import { classNames } from "@powerpipe/utils/styles";
 import { HashLink } from "react-router-hash-link";
 import { InputProperties } from "../../inputs/types";
-import { PanelDefinition } from "@powerpipe/types";
+import {
+  DashboardDataModeLive,
+  PanelDefinition,
+} from "@powerpipe/types";
 import { ReactNode } from "react";
+import { useDashboard } from "@powerpipe/hooks/useDashboard";
 import { useLocation } from "react-router-dom";
 
 type PanelStatusProps = PanelStatusBaseProps & {
@@ -150,6 +154,7 @@ const PanelCancelled = ({ definition }) => {
 };
 
 const PanelError = ({ definition }) => {
+  const { dataMode, retryPanel } = useDashboard();
   return (
     <BasePanelStatus
       className="bg-alert-light border-alert text-foreground"
@@ -161,6 +166,15 @@ const PanelError = ({ definition }) => {
           icon="materialsymbols-solid:error"
         />
         <span className="block truncate">Error</span>
+        {dataMode === DashboardDataModeLive && (
+          <button
+            className="ml-auto link-highlight hover:underline shrink-0"
+            onClick={() => retryPanel(definition.name)}
+            type="button"
+          >
+            Retry
+          </button>
+        )}
       </div>
       <span className="block">
         <ErrorMessage error={definition.error} />
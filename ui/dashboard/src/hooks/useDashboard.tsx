@@ -626,6 +626,39 @@ const DashboardProvider = ({
     });
   }, [dispatch]);
 
+  // Re-execute a failed panel - the rest of the dashboard is left as-is
+  const retryPanel = useCallback(
+    (panelName: string) => {
+      if (!socketReady || state.dataMode !== DashboardDataModeLive) {
+        return;
+      }
+      sendSocketMessage({
+        action: SocketActions.RETRY_PANEL,
+        payload: {
+          panel: panelName,
+        },
+      });
+    },
+    [sendSocketMessage, socketReady, state.dataMode],
+  );
+
+  // Upload a dataset for this session - if a dashboard is selected, the
+  // server re-executes it so its queries may use the dataset
+  const uploadDataset = useCallback(
+    (name: string, format: "csv" | "json", content: string) => {
+      if (!socketReady || state.dataMode !== DashboardDataModeLive) {
+        return;
+      }
+      sendSocketMessage({
+        action: SocketActions.UPLOAD_DATASET,
+        payload: {
+          dataset: { name, format, content },
+        },
+      });
+    },
+    [sendSocketMessage, socketReady, state.dataMode],
+  );
+
   useEffect(() => {
     setHotKeysHandlers({
       CLOSE_PANEL_DETAIL: closePanelDetail,
@@ -655,6 +688,8 @@ const DashboardProvider = ({
         components,
         dispatch,
         closePanelDetail,
+        retryPanel,
+        uploadDataset,
         themeContext,
         render: {
           headless: renderOptions?.headless,
@@ -137,6 +137,7 @@ const reducer = (state: IDashboardContext, action) => {
         diff: null,
         dashboard,
         execution_id: migratedEvent.execution_id,
+        warnings: migratedEvent.warnings || [],
         refetchDashboard: false,
         progress: 0,
         snapshot: null,
@@ -276,6 +277,7 @@ const reducer = (state: IDashboardContext, action) => {
         dataMode: DashboardDataModeLive,
         dashboard: null,
         execution_id: null,
+        warnings: [],
         panelsMap: {},
         snapshot: null,
         snapshotFileName: null,
@@ -436,6 +438,7 @@ const getInitialState = (searchParams, defaults: any = {}) => {
     },
 
     execution_id: null,
+    warnings: [],
 
     progress: 0,
 
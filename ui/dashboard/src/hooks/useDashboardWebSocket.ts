@@ -18,6 +18,8 @@ export const SocketActions: IActions = {
   SELECT_DASHBOARD: "select_dashboard",
   SELECT_SNAPSHOT: "select_snapshot",
   INPUT_CHANGED: "input_changed",
+  RETRY_PANEL: "retry_panel",
+  UPLOAD_DATASET: "upload_dataset",
 };
 
 const useDashboardWebSocket = (
@@ -15,6 +15,8 @@ export type IDashboardContext = {
 
   closePanelDetail(): void;
   dispatch(action: DashboardAction): void;
+  retryPanel(panelName: string): void;
+  uploadDataset(name: string, format: "csv" | "json", content: string): void;
 
   dataMode: DashboardDataMode;
   snapshotId: string | null;
@@ -27,6 +29,8 @@ export type IDashboardContext = {
   panelsMap: PanelsMap;
 
   execution_id: string | null;
+  // warnings for the deprecated resources the dashboard is or references
+  warnings: string[];
 
   dashboards: AvailableDashboard[];
   dashboardsMap: AvailableDashboardsDictionary;
@@ -202,6 +206,7 @@ export type DashboardExecutionStartedEvent = {
   layout: DashboardLayoutNode;
   panels: PanelsMap;
   variables: DashboardVariables;
+  warnings?: string[];
   schema_version: DashboardExecutionStartedEventSchemaVersion;
   start_time: string;
 };
@@ -309,6 +314,15 @@ export type ServerMetadata = {
   cloud?: CloudServerMetadata;
   telemetry: "info" | "none";
   search_path: SearchPathMetadata;
+  locale?: LocaleMetadata;
+};
+
+export type LocaleMetadata = {
+  timezone?: string;
+  date_format: string;
+  thousands_separator?: string;
+  decimal_separator?: string;
+  currency?: string;
 };
 
 export type DashboardLayoutNode = {
@@ -434,6 +448,7 @@ export type PanelDefinition = {
   dashboard: string;
   children?: DashboardLayoutNode[];
   dependencies?: string[];
+  tags?: { [key: string]: string };
 };
 
 export type PanelDependenciesByStatus = {
@@ -448,6 +463,7 @@ export type DashboardDefinition = {
   width?: number;
   children?: (ContainerDefinition | PanelDefinition)[];
   dashboard: string;
+  tags?: { [key: string]: string };
 };
 
 export type DashboardsCollection = {
@@ -0,0 +1,71 @@
+import dayjs from "dayjs";
+import timezone from "dayjs/plugin/timezone";
+import utc from "dayjs/plugin/utc";
+import { LocaleMetadata } from "@powerpipe/types";
+
+dayjs.extend(utc);
+dayjs.extend(timezone);
+
+const defaultDateFormat = "YYYY-MM-DD HH:mm:ss";
+
+// Numeric columns with these names (or name suffixes) are formatted as currency
+const currencyColumnRegex = /(^|_)(cost|price|amount|spend)$/i;
+
+// Format a timestamp using the server timezone and date format settings. Timestamps
+// without a timezone are not converted. If no settings are configured, the value is
+// returned unchanged.
+const formatTimestamp = (
+  value: any,
+  dataType: string,
+  locale?: LocaleMetadata | null,
+) => {
+  if (
+    !locale ||
+    value === null ||
+    value === undefined ||
+    (!locale.timezone && locale.date_format === defaultDateFormat)
+  ) {
+    return value;
+  }
+  let parsed = dataType === "timestamptz" ? dayjs(value) : dayjs.utc(value);
+  if (!parsed.isValid()) {
+    return value;
+  }
+  if (dataType === "timestamptz" && locale.timezone) {
+    parsed = parsed.tz(locale.timezone);
+  }
+  return parsed.format(locale.date_format || defaultDateFormat);
+};
+
+const separate = (value: number, locale: LocaleMetadata) => {
+  const [integer, fraction] = Math.abs(value).toString().split(".");
+  const grouped = integer.replace(
+    /\B(?=(\d{3})+(?!\d))/g,
+    locale.thousands_separator || "",
+  );
+  const sign = value < 0 ? "-" : "";
+  return fraction === undefined
+    ? `${sign}${grouped}`
+    : `${sign}${grouped}${locale.decimal_separator || "."}${fraction}`;
+};
+
+// Format a number using the server number format settings (or the browser locale if
+// none is set), adding the currency symbol for monetary columns.
+const formatNumber = (
+  value: number,
+  columnName: string,
+  locale?: LocaleMetadata | null,
+) => {
+  const formatted =
+    locale?.decimal_separator !== undefined
+      ? separate(value, locale)
+      : value.toLocaleString();
+  if (!locale?.currency || !currencyColumnRegex.test(columnName)) {
+    return formatted;
+  }
+  return formatted.startsWith("-")
+    ? `-${locale.currency}${formatted.substring(1)}`
+    : `${locale.currency}${formatted}`;
+};
+
+export { formatNumber, formatTimestamp };
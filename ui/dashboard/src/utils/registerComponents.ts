@@ -14,7 +14,9 @@ import "@powerpipe/components/dashboards/charts/AreaChart";
 import "@powerpipe/components/dashboards/charts/BarChart";
 import "@powerpipe/components/dashboards/charts/ColumnChart";
 import "@powerpipe/components/dashboards/charts/DonutChart";
+import "@powerpipe/components/dashboards/charts/HeatmapChart";
 import "@powerpipe/components/dashboards/charts/LineChart";
+import "@powerpipe/components/dashboards/charts/MapChart";
 import "@powerpipe/components/dashboards/charts/PieChart";
 import "@powerpipe/components/dashboards/charts/Chart";
 
@@ -36,6 +38,7 @@ import "@powerpipe/components/dashboards/inputs/MultiSelectInput";
 import "@powerpipe/components/dashboards/inputs/SingleComboInput";
 import "@powerpipe/components/dashboards/inputs/SingleSelectInput";
 import "@powerpipe/components/dashboards/inputs/TextInput";
+import "@powerpipe/components/dashboards/inputs/TimeRangeInput";
 import "@powerpipe/components/dashboards/inputs/Input";
 
 // Check
@@ -65,6 +65,8 @@ export const PanelStoryDecorator = ({
         },
         availableDashboardsLoaded: true,
         closePanelDetail: noop,
+        retryPanel: noop,
+        uploadDataset: noop,
         dataMode: DashboardDataModeLive,
         snapshotId: null,
         dispatch: noop,
@@ -84,6 +86,7 @@ export const PanelStoryDecorator = ({
         selectedDashboardInputs: {},
         lastChangedInput: null,
         execution_id: null,
+        warnings: [],
         panelsLog: {},
         panelsMap: {
           [newPanel.name]: newPanel,